@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
@@ -15,6 +16,7 @@ import (
 	"github.com/spf13/viper"
 	"github.com/yourusername/panka/internal/logger"
 	"github.com/yourusername/panka/pkg/diff"
+	"github.com/yourusername/panka/pkg/events"
 	"github.com/yourusername/panka/pkg/graph"
 	"github.com/yourusername/panka/pkg/parser"
 	"github.com/yourusername/panka/pkg/parser/schema"
@@ -27,32 +29,97 @@ import (
 )
 
 var (
-	applyDryRun        bool
-	applyAutoApprove   bool
-	applyTarget        string
-	applyNoRollback    bool
+	applyDryRun      bool
+	applyAutoApprove bool
+	applyNoRollback  bool
+	applyParallelism int
 )
 
 // applyCmd represents the apply command
 var applyCmd = &cobra.Command{
-	Use:   "apply <path>",
+	Use:   "apply <path | plan-file>",
 	Short: "Apply infrastructure changes",
-	Long: `Apply infrastructure changes defined in a stack folder.
+	Long: `Apply infrastructure changes defined in a stack folder, or the changes
+saved in a plan file by 'panka plan --out'.
 
 This command:
   1. Parses the stack configuration
   2. Loads tenant networking (VPC, subnets, security groups)
   3. Builds the dependency graph
-  4. Creates/updates resources in dependency order
-  5. Saves state to S3
+  4. Computes the changes between the configuration and the state
+  5. Creates, updates or recreates changed resources in dependency
+     order, running the resources of each stage in parallel (see
+     --parallelism), and leaves unchanged resources alone. References
+     to component outputs (valueFrom and ${component.output}) are
+     resolved from the resources applied before them
+  6. Deletes resources that were removed from the configuration,
+     dependents first
+  7. Evaluates the outputs declared by the stack and its services
+  8. Saves state to S3
+
+The state is checkpointed to S3 before and after every resource operation.
+If an apply is interrupted, the next apply checks the resources it left in
+progress with AWS: resources that were created are adopted, resources that
+do not exist are removed from the state, and the remaining changes are
+planned again.
 
 The stack will use the tenant's networking configuration automatically.
 
+Use --env to deploy an environment of the stack. Each environment has its
+own state, and the YAML documents in environments/<env>/ are merged over
+the base configuration.
+
+Use --target to apply only some components. Each target pulls in its
+transitive dependencies; --target-dependents also pulls in the components
+that consume the targets. Removed resources are not deleted while
+targeting.
+
+Applying a plan file executes exactly the saved plan without asking for
+confirmation. It is refused if the stack files or the stack state have
+changed since the plan was generated; plan again in that case.
+
+Resource operations that fail with throttling or other transient AWS
+errors are retried with backoff, and the operations on an account are
+rate limited. Use --resource-timeout to bound how long a single resource
+operation, retries included, may take.
+
+Resources can declare a lifecycle block in their metadata:
+  preventDestroy       refuse to delete or replace the resource
+  ignoreChanges        attribute paths (e.g. spec.readCapacity) not to plan
+  createBeforeDestroy  create a replacement before deleting the original
+
+Stacks and services can declare hooks: local shell commands run from the
+stack folder at these points, stack hooks first, then service hooks:
+  prePlan    before the changes are computed
+  preApply   before the changes are applied
+  postApply  after a successful apply
+  onFailure  after a failed apply
+Hooks get PANKA_STACK, PANKA_ENVIRONMENT, PANKA_SERVICE, the variables as
+PANKA_VAR_<NAME> and the stack outputs as PANKA_OUTPUT_<NAME>; onFailure
+hooks also get PANKA_ERROR. preApply, postApply and onFailure hooks read
+the change set as JSON on stdin. A failing prePlan or preApply hook aborts
+the apply; a failing postApply or onFailure hook is reported as a warning.
+
+Use --output json to stream progress as newline delimited JSON events on
+stdout, for dashboards and CI. Every event has a schema_version and a type:
+plan_computed, started, stage_started, resource_started,
+resource_succeeded, resource_failed, resource_skipped, rollback_started,
+rollback_completed, outputs_evaluated, state_saved, hook_started,
+hook_succeeded, hook_failed, warning and summary.
+Everything else is printed to stderr.
+
 Examples:
   panka apply ./my-stack
   panka apply ./my-stack --dry-run
+  panka apply ./my-stack --env production
   panka apply ./my-stack --auto-approve
-  panka apply ./my-stack --target api-server`,
+  panka apply ./my-stack --target api-server
+  panka apply ./my-stack --target backend/api-server --target orders-queue
+  panka apply ./my-stack --target orders-db --target-dependents
+  panka apply ./my-stack --parallelism 4
+  panka apply ./my-stack --auto-approve --output json
+  panka apply plan.panka
+  panka apply ./my-stack --lock-timeout 5m`,
 	Args: cobra.ExactArgs(1),
 	RunE: runApply,
 }
@@ -62,8 +129,13 @@ func init() {
 
 	applyCmd.Flags().BoolVar(&applyDryRun, "dry-run", false, "Preview changes without applying")
 	applyCmd.Flags().BoolVarP(&applyAutoApprove, "auto-approve", "y", false, "Skip confirmation prompt")
-	applyCmd.Flags().StringVar(&applyTarget, "target", "", "Target a specific resource")
 	applyCmd.Flags().BoolVar(&applyNoRollback, "no-rollback", false, "Disable automatic rollback on failure")
+	applyCmd.Flags().IntVar(&applyParallelism, "parallelism", graph.DefaultParallelism, "Maximum number of resources applied concurrently within a stage")
+	addEnvFlag(applyCmd)
+	addTargetFlags(applyCmd, true)
+	addLockFlags(applyCmd)
+	addOutputFlag(applyCmd)
+	addResourceTimeoutFlag(applyCmd)
 }
 
 func runApply(cmd *cobra.Command, args []string) error {
@@ -74,6 +146,11 @@ func runApply(cmd *cobra.Command, args []string) error {
 
 	path := args[0]
 
+	environment, err := selectedEnvironment()
+	if err != nil {
+		return err
+	}
+
 	// Get absolute path
 	absPath, err := filepath.Abs(path)
 	if err != nil {
@@ -86,30 +163,32 @@ func runApply(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("path not found: %s", absPath)
 	}
 	if !info.IsDir() {
-		return fmt.Errorf("apply requires a stack folder, not a file: %s", absPath)
+		return runApplyPlanFile(cmd, absPath)
+	}
+
+	sink, err := newEventSink()
+	if err != nil {
+		return err
 	}
 
 	cyan.Println("\n🚀 Panka Apply")
 	cyan.Println(strings.Repeat("─", 60))
 	fmt.Printf("Stack Path: %s\n", absPath)
+	fmt.Printf("Environment: %s\n", environment)
 
-	log := logger.Global()
-	ctx := context.Background()
+	ctx, stop := withSignalCancel(context.Background())
+	defer stop()
 
 	// Step 1: Check authentication
-	fmt.Print("\n⏳ Checking authentication... ")
-	sessionMgr := tenant.NewSessionManager()
-	session, err := sessionMgr.LoadSession()
-	if err != nil || session.Mode != tenant.ModeTenant || session.Tenant == nil {
-		red.Println("✗")
-		return fmt.Errorf("not logged in as tenant. Run 'panka login' first")
+	session, err := checkTenantSession()
+	if err != nil {
+		return err
 	}
-	green.Println("✓")
-	fmt.Printf("   Tenant: %s\n", session.Tenant.ID)
 
 	// Step 2: Parse stack folder
 	fmt.Print("⏳ Parsing stack configuration... ")
 	fp := parser.NewFolderParser()
+	fp.SetEnvironment(environment)
 	parseResult, err := fp.ParseStackFolder(absPath)
 	if err != nil {
 		red.Println("✗")
@@ -119,56 +198,21 @@ func runApply(cmd *cobra.Command, args []string) error {
 	fmt.Printf("   Stack: %s\n", parseResult.Stack.Metadata.Name)
 	fmt.Printf("   Services: %d\n", len(parseResult.Services))
 	fmt.Printf("   Components: %d\n", len(parseResult.AllComponents))
-
-	// Step 3: Load tenant configuration (for networking)
-	fmt.Print("⏳ Loading tenant configuration... ")
-	bucket := viper.GetString("backend.bucket")
-	region := viper.GetString("backend.region")
-
-	if bucket == "" || region == "" {
-		red.Println("✗")
-		return fmt.Errorf("backend.bucket and backend.region must be configured in .panka.yaml")
+	for _, w := range parseResult.Warnings {
+		yellow.Printf("   ⚠️  %s\n", w)
 	}
 
-	tenantBackend, err := tenant.NewS3RegistryBackend(bucket, region)
-	if err != nil {
-		red.Println("✗")
-		return fmt.Errorf("failed to create tenant backend: %w", err)
-	}
-
-	tenantConfig, err := tenantBackend.LoadTenantConfig(ctx, session.Tenant.ID)
+	// Step 3: Load tenant configuration (for networking)
+	tenantConfig, bucket, region, err := loadTenantConfig(ctx, session)
 	if err != nil {
-		red.Println("✗")
-		return fmt.Errorf("failed to load tenant config: %w", err)
-	}
-	green.Println("✓")
-
-	// Display networking info
-	if tenantConfig.Networking.ResourceIDs != nil && tenantConfig.Networking.ResourceIDs.VPCID != "" {
-		fmt.Printf("   VPC: %s\n", tenantConfig.Networking.ResourceIDs.VPCID)
-		fmt.Printf("   Security Group: %s\n", tenantConfig.Networking.ResourceIDs.SecurityGroupID)
-	} else {
-		yellow.Println("   ⚠️  No networking provisioned for this tenant")
-		yellow.Println("      Resources will be created without VPC configuration")
+		return err
 	}
 
 	// Step 4: Validate configuration
-	fmt.Print("⏳ Validating configuration... ")
-	v := parser.NewValidator()
-	validationResult := &parser.ParseResult{
-		Stack:      parseResult.Stack,
-		Components: parseResult.AllComponents,
-	}
-	for _, svc := range parseResult.Services {
-		if svc.Service != nil {
-			validationResult.Services = append(validationResult.Services, svc.Service)
-		}
-	}
-	if err := v.Validate(validationResult); err != nil {
-		red.Println("✗")
-		return fmt.Errorf("validation failed: %w", err)
+	validationResult, err := validateStack(parseResult)
+	if err != nil {
+		return err
 	}
-	green.Println("✓")
 
 	// Step 5: Build dependency graph
 	fmt.Print("⏳ Building dependency graph... ")
@@ -186,29 +230,34 @@ func runApply(cmd *cobra.Command, args []string) error {
 	green.Println("✓")
 	fmt.Printf("   Nodes: %d, Edges: %d\n", depGraph.NodeCount(), depGraph.EdgeCount())
 
-	// Step 6: Load current state for comparison
-	fmt.Print("⏳ Loading current state... ")
+	selected, err := selectTargets(depGraph)
+	if err != nil {
+		return fmt.Errorf("failed to resolve targets: %w", err)
+	}
+	if selected != nil {
+		depGraph = depGraph.Subgraph(selected)
+		printTargetSelection(selected)
+	}
 
-	awsCfg, err := awsconfig.LoadDefaultConfig(ctx, awsconfig.WithRegion(region))
+	stackName := parseResult.Stack.Metadata.Name
+	emitter := events.NewEmitter(sink, events.OperationApply, stackName, environment)
+	stackHooks := newStackHooks(absPath, validationResult, environment, emitter)
+
+	// Step 6: Acquire state lock and load current state for comparison
+	ctx, releaseLock, err := acquireStackLock(ctx, session, stackName, environment)
 	if err != nil {
-		red.Println("✗")
-		return fmt.Errorf("failed to load AWS config: %w", err)
+		return err
 	}
-	s3Client := s3.NewFromConfig(awsCfg)
+	defer releaseLock()
 
-	stateBackend, err := state.NewS3Backend(&state.S3BackendConfig{
-		Client: s3Client,
-		Bucket: bucket,
-		Prefix: fmt.Sprintf("tenants/%s/v1/stacks", session.Tenant.ID),
-	})
+	fmt.Print("⏳ Loading current state... ")
+
+	stateBackend, err := openStateBackend(ctx, session.Tenant.ID, bucket, region)
 	if err != nil {
 		red.Println("✗")
-		return fmt.Errorf("failed to create state backend: %w", err)
+		return err
 	}
 
-	stackName := parseResult.Stack.Metadata.Name
-	environment := "default" // TODO: Get from stack or flag
-
 	stateKey := fmt.Sprintf("%s/%s/state.json", stackName, environment)
 	currentState, err := stateBackend.Load(ctx, stateKey)
 	if err != nil {
@@ -218,6 +267,28 @@ func runApply(cmd *cobra.Command, args []string) error {
 	}
 	green.Println("✓")
 
+	// Reconcile the operations of an interrupted apply before planning
+	var awsProvider *aws.Provider
+	if len(currentState.InProgressResources()) > 0 {
+		if applyDryRun {
+			yellow.Println("\n⚠️  The state has resources left in progress by an interrupted apply; they will be reconciled by the next apply")
+		} else {
+			awsProvider, err = initAWSProvider(ctx, tenantConfig, region, session.Tenant.ID, stackName)
+			if err != nil {
+				return err
+			}
+			defer awsProvider.Close()
+
+			if err := reconcileInterrupted(ctx, awsProvider, currentState, stateBackend, stateKey, session.Tenant.ID, stackName); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := stackHooks.run(ctx, schema.HookPrePlan, currentState, nil, nil); err != nil {
+		return err
+	}
+
 	// Step 7: Compute changes (state vs desired)
 	fmt.Print("⏳ Computing changes... ")
 	differ := diff.NewDiffer(nil)
@@ -227,8 +298,18 @@ func runApply(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to compute changes: %w", err)
 	}
 	changeSet.TenantID = session.Tenant.ID
+	if selected != nil {
+		selectedSet := toSet(selected)
+		changeSet = changeSet.Filter(func(change *diff.Change) bool {
+			return selectedSet[change.ResourceName]
+		})
+	}
 	green.Println("✓")
 
+	if err := checkSecrets(ctx, workloadRegion(tenantConfig, region), changeSet); err != nil {
+		return err
+	}
+
 	// Step 8: Generate deployment plan
 	fmt.Print("⏳ Generating deployment plan... ")
 	planner := graph.NewPlanner()
@@ -240,11 +321,20 @@ func runApply(cmd *cobra.Command, args []string) error {
 	green.Println("✓")
 
 	// Display changes
-	diff.PrintDiff(changeSet)
+	emitter.Emit(events.Event{
+		Type:      events.PlanComputed,
+		Plan:      events.NewPlan(changeSet),
+		ChangeSet: changeSet,
+	})
+
+	if err := checkPreventDestroy(changeSet); err != nil {
+		return err
+	}
 
 	// Check if there are any changes
 	if !changeSet.HasChanges() {
 		green.Println("\n✨ No changes to apply. Infrastructure is up-to-date!")
+		emitter.Emit(noChangesSummary(changeSet))
 		return nil
 	}
 
@@ -265,253 +355,381 @@ func runApply(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	// Step 9: Initialize AWS provider
+	return executeApplyWithHooks(ctx, &applyRun{
+		session:      session,
+		tenantConfig: tenantConfig,
+		region:       region,
+		stackName:    stackName,
+		environment:  environment,
+		state:        currentState,
+		stateBackend: stateBackend,
+		stateKey:     stateKey,
+		plan:         plan,
+		changeSet:    changeSet,
+		outputs:      parser.StackOutputs(validationResult.Stack, validationResult.Services),
+		events:       emitter,
+		hooks:        stackHooks,
+		provider:     awsProvider,
+	})
+}
+
+// applyRun holds everything needed to execute a reviewed plan
+type applyRun struct {
+	session      *tenant.Session
+	tenantConfig *tenant.Tenant
+	region       string
+	stackName    string
+	environment  string
+	state        *state.State
+	stateBackend state.Backend
+	stateKey     string
+	plan         *graph.DeploymentPlan
+	changeSet    *diff.ChangeSet
+	outputs      map[string]string
+	events       *events.Emitter
+	hooks        *stackHooks
+
+	// provider is an already initialized AWS provider, owned by the caller
+	provider *aws.Provider
+}
+
+// initAWSProvider initializes the AWS provider for a stack of a tenant
+func initAWSProvider(ctx context.Context, tenantConfig *tenant.Tenant, region, tenantID, stackName string) (*aws.Provider, error) {
+	green := color.New(color.FgGreen, color.Bold)
+	red := color.New(color.FgRed, color.Bold)
+
 	fmt.Print("\n⏳ Initializing AWS provider... ")
 	awsProvider := aws.NewProvider()
-	providerRegion := tenantConfig.AWS.Region
-	if providerRegion == "" {
-		providerRegion = region
-	}
-
-	err = awsProvider.Initialize(ctx, &provider.Config{
+	err := awsProvider.Initialize(ctx, &provider.Config{
 		Name:   "aws",
-		Region: providerRegion,
+		Region: workloadRegion(tenantConfig, region),
 		DefaultTags: map[string]string{
-			"tenant":     session.Tenant.ID,
+			"tenant":     tenantID,
 			"stack":      stackName,
 			"managed-by": "panka",
 		},
+		Networking: tenantNetworking(tenantConfig),
 	})
 	if err != nil {
 		red.Println("✗")
-		return fmt.Errorf("failed to initialize AWS provider: %w", err)
+		return nil, fmt.Errorf("failed to initialize AWS provider: %w", err)
 	}
-	defer awsProvider.Close()
 	green.Println("✓")
 
-	// Step 10: Initialize rollback manager
-	rollbackMgr := rollback.NewManager(awsProvider)
-	rollbackMgr.StartTransaction(stackName, session.Tenant.ID, currentState)
+	return awsProvider, nil
+}
 
-	// Step 11: Apply changes
-	cyan.Println("\n🔧 Applying Changes")
-	cyan.Println(strings.Repeat("─", 60))
+// workloadRegion returns the region the resources and secrets of a tenant
+// live in: the region of its AWS configuration, or the backend region if it
+// has none
+func workloadRegion(tenantConfig *tenant.Tenant, backendRegion string) string {
+	if tenantConfig != nil && tenantConfig.AWS.Region != "" {
+		return tenantConfig.AWS.Region
+	}
+	return backendRegion
+}
 
-	startTime := time.Now()
-	successCount := 0
-	failCount := 0
-	applyFailed := false
+// loadWorkloadRegion loads the configuration of the session's tenant and
+// returns its workload region
+func loadWorkloadRegion(ctx context.Context, session *tenant.Session, bucket, region string) (string, error) {
+	tenantBackend, err := tenant.NewS3RegistryBackend(bucket, region)
+	if err != nil {
+		return "", fmt.Errorf("failed to create tenant backend: %w", err)
+	}
+	tenantConfig, err := tenantBackend.LoadTenantConfig(ctx, session.Tenant.ID)
+	if err != nil {
+		return "", fmt.Errorf("failed to load tenant config: %w", err)
+	}
+	return workloadRegion(tenantConfig, region), nil
+}
 
-	for _, stage := range plan.Stages {
-		fmt.Printf("\n📦 Stage %d: %d resource(s)\n", stage.Number, len(stage.Resources))
+// tenantNetworking returns the networking resources created for a tenant,
+// or nil if its networking has not been set up
+func tenantNetworking(tenantConfig *tenant.Tenant) *provider.Networking {
+	if tenantConfig == nil || tenantConfig.Networking.ResourceIDs == nil {
+		return nil
+	}
+	ids := tenantConfig.Networking.ResourceIDs
+	return &provider.Networking{
+		VPCID:            ids.VPCID,
+		PrivateSubnetIDs: ids.PrivateSubnetIDs,
+		PublicSubnetIDs:  ids.PublicSubnetIDs,
+		SecurityGroupID:  ids.SecurityGroupID,
+	}
+}
 
-		for _, res := range stage.Resources {
-			resourceName := res.ID
-			resourceKind := res.Kind
+// executeApply applies a deployment plan and its change set, deletes removed
+// resources and saves the resulting state. The caller holds the stack lock.
+// Progress is reported to run.events.
+func executeApply(ctx context.Context, run *applyRun) error {
+	log := logger.Global()
+	session := run.session
+	stackName := run.stackName
+	currentState := run.state
+	changeSet := run.changeSet
+	stateBackend := run.stateBackend
+	stateKey := run.stateKey
+	emitter := run.events
 
-			// Check if resource already exists in state
-			existingResource, existsInState := currentState.GetResource(resourceName)
+	// Step 9: Initialize AWS provider
+	awsProvider := run.provider
+	if awsProvider == nil {
+		var err error
+		awsProvider, err = initAWSProvider(ctx, run.tenantConfig, run.region, session.Tenant.ID, stackName)
+		if err != nil {
+			return err
+		}
+		defer awsProvider.Close()
+	}
 
-			// Get resource provider
-			resourceProvider, err := awsProvider.GetResourceProvider(schema.Kind(resourceKind))
-			if err != nil {
-				yellow.Printf("   ⚠️  [%s] %s - Skipped (no provider)\n", resourceKind, resourceName)
-				log.Warn("No provider for resource kind",
-					zap.String("kind", string(resourceKind)),
-					zap.String("name", resourceName),
-				)
-				continue
-			}
+	// Step 10: Initialize rollback manager
+	rollbackMgr := rollback.NewManager(awsProvider)
+	rollbackMgr.StartTransaction(stackName, session.Tenant.ID, currentState)
 
-			// Build options
-			opts := &provider.ResourceOptions{
-				TenantID:    session.Tenant.ID,
-				StackName:   stackName,
-				ServiceName: res.Resource.GetMetadata().Service,
-				Tags: map[string]string{
-					"stack":   stackName,
-					"service": res.Resource.GetMetadata().Service,
-				},
-				DryRun: false,
-			}
+	// Step 11: Apply changes, running the resources of each stage in parallel
+	emitter.Emit(events.Event{Type: events.Started})
 
-			// If resource exists in state, check if it exists in AWS and skip if unchanged
-			if existsInState && existingResource.ID != "" {
-				// Check if resource still exists in AWS
-				exists, _ := resourceProvider.Exists(ctx, existingResource.ID, opts)
-				if exists {
-					green.Printf("   ✓ [%s] %s - Already exists\n", resourceKind, resourceName)
-					log.Info("Resource already exists, skipping",
-						zap.String("name", resourceName),
-						zap.String("id", existingResource.ID),
-					)
-					successCount++
-					continue
+	startTime := time.Now()
+	exec := newApplyExecution(awsProvider, rollbackMgr, emitter, currentState, changeSet, stateBackend, stateKey, session.Tenant.ID, stackName)
+	executor := graph.NewExecutor(applyParallelism)
+
+	// fail saves the partial state and reports the failed apply
+	fail := func(err error) error {
+		saveErr := exec.saveState(ctx, stateBackend, stateKey)
+		emitter.Emit(stateSavedEvent(saveErr))
+		emitter.Emit(events.Event{Type: events.Summary, Error: err.Error(), Summary: exec.totals(time.Since(startTime))})
+		return err
+	}
+
+	for _, stage := range run.plan.Stages {
+		emitter.Emit(events.Event{
+			Type:      events.StageStarted,
+			Phase:     events.PhaseApply,
+			Stage:     stage.Number,
+			Resources: len(stage.Resources),
+		})
+
+		if err := executor.ExecuteStage(ctx, stage, exec.applyResource); err != nil {
+			// Trigger rollback if enabled. The stage context has been
+			// cancelled, but the command context is still usable.
+			if !applyNoRollback && rollbackMgr.CanRollback() {
+				emitter.Emit(events.Event{Type: events.RollbackStarted, Error: err.Error()})
+				rollbackResult, rollbackErr := rollbackMgr.Rollback(ctx)
+				if rollbackErr != nil {
+					emitter.Emit(events.Event{Type: events.RollbackCompleted, Error: rollbackErr.Error()})
+				} else {
+					emitter.Emit(events.Event{Type: events.RollbackCompleted, Rollback: rollbackResult})
 				}
-				// Resource in state but not in AWS - recreate it
-				yellow.Printf("   ~ [%s] %s - Recreating (missing in AWS)... ", resourceKind, resourceName)
-			} else {
-				fmt.Printf("   + [%s] %s - Creating... ", resourceKind, resourceName)
 			}
 
-			// Create resource
-			result, err := resourceProvider.Create(ctx, res.Resource, opts)
-			if err != nil {
-				red.Println("✗")
-				log.Error("Failed to create resource",
-					zap.String("name", resourceName),
-					zap.Error(err),
-				)
-				failCount++
-				applyFailed = true
-
-				// Record failed action for rollback tracking
-				rollbackMgr.RecordCreate(resourceName, "", schema.Kind(resourceKind), nil, false, err)
-
-				// Trigger rollback if enabled
-				if !applyNoRollback && rollbackMgr.CanRollback() {
-					yellow.Println("\n⚠️  Apply failed. Initiating rollback...")
-					rollbackResult, rollbackErr := rollbackMgr.Rollback(ctx)
-					if rollbackErr != nil {
-						red.Printf("❌ Rollback error: %v\n", rollbackErr)
-					} else {
-						displayRollbackResult(rollbackResult)
-					}
-				}
-
-				// Save partial state before returning
-				currentState.Metadata.UpdatedAt = time.Now()
-				_ = stateBackend.Save(ctx, stateKey, currentState)
-
-				return fmt.Errorf("failed to create %s: %w", resourceName, err)
-			}
+			return fail(err)
+		}
+	}
 
-			green.Println("✓")
-			successCount++
-
-			// Create state resource
-			stateResource := &state.Resource{
-				ID:         result.ResourceID,
-				Type:       string(result.Kind),
-				Name:       resourceName,
-				Provider:   "aws",
-				Status:     state.ResourceStatusReady,
-				Attributes: convertOutputsToMap(result.Outputs),
-				CreatedAt:  time.Now(),
-				UpdatedAt:  time.Now(),
+	// Step 12: Delete resources that are in state but not in config,
+	// removing dependents before the resources they depend on
+	deletes := changeSet.GetDeletes()
+	if len(deletes) > 0 {
+		removed := make([]*state.Resource, 0, len(deletes))
+		for _, change := range deletes {
+			if change.Before != nil {
+				removed = append(removed, change.Before)
 			}
+		}
 
-			// Record successful action for rollback
-			rollbackMgr.RecordCreate(resourceName, result.ResourceID, schema.Kind(resourceKind), stateResource, true, nil)
-
-			// Update state
-			currentState.AddResource(resourceName, stateResource)
+		deleteGraph, err := graph.NewBuilder().BuildFromState(stackName, removed)
+		if err != nil {
+			return fail(fmt.Errorf("failed to order deletions: %w", err))
+		}
+		deletePlan, err := graph.NewPlanner().CreateDeletionPlan(deleteGraph)
+		if err != nil {
+			return fail(fmt.Errorf("failed to create deletion plan: %w", err))
+		}
 
-			// Log outputs
-			if len(result.Outputs) > 0 {
-				for k, v := range result.Outputs {
-					fmt.Printf("      %s: %s\n", k, v)
-				}
+		for _, stage := range deletePlan.Stages {
+			emitter.Emit(events.Event{
+				Type:      events.StageStarted,
+				Phase:     events.PhaseDelete,
+				Stage:     stage.Number,
+				Resources: len(stage.Resources),
+			})
+			if err := executor.ExecuteStage(ctx, stage, exec.deleteResource); err != nil {
+				return fail(err)
 			}
 		}
 	}
 
-	// Step 12: Delete resources that are in state but not in config
-	// Build set of desired resource names
-	desiredResources := make(map[string]bool)
-	for _, comp := range parseResult.AllComponents {
-		desiredResources[comp.GetMetadata().Name] = true
+	// Clear rollback transaction on success
+	rollbackMgr.ClearTransaction()
+
+	// Step 13: Evaluate the declared stack and service outputs
+	if len(run.outputs) > 0 {
+		exec.evaluateOutputs(run.outputs)
 	}
 
-	// Find resources to delete (in state but not in desired)
-	resourcesToDelete := make([]*state.Resource, 0)
-	for _, res := range currentState.ListResources() {
-		if !desiredResources[res.Name] {
-			resourcesToDelete = append(resourcesToDelete, res)
-		}
+	// Step 14: Save state
+	currentState.Metadata.DeployedBy = "panka-cli"
+	emitter.Emit(stateSavedEvent(exec.saveState(ctx, stateBackend, stateKey)))
+
+	// Summary
+	totals := exec.totals(time.Since(startTime))
+	if !totals.Success {
+		err := fmt.Errorf("%d operation(s) failed", totals.Failed)
+		emitter.Emit(events.Event{Type: events.Summary, Error: err.Error(), Summary: totals})
+		return err
+	}
+
+	log.Info("Apply complete",
+		zap.String("stack", stackName),
+		zap.String("environment", run.environment),
+		zap.Int("created", totals.Created),
+		zap.Int("updated", totals.Updated),
+		zap.Int("deleted", totals.Deleted),
+	)
+
+	emitter.Emit(events.Event{Type: events.Summary, Summary: totals})
+	return nil
+}
+
+// checkPreventDestroy refuses a change set that deletes or replaces
+// resources protected by lifecycle.preventDestroy
+func checkPreventDestroy(changeSet *diff.ChangeSet) error {
+	prevented := changeSet.PreventedDestroys()
+	if len(prevented) == 0 {
+		return nil
 	}
 
-	// Delete removed resources
-	deleteCount := 0
-	deleteFailCount := 0
-	if len(resourcesToDelete) > 0 {
-		red.Printf("\n🗑️  Deleting %d removed resource(s)\n", len(resourcesToDelete))
+	names := make([]string, 0, len(prevented))
+	for _, change := range prevented {
+		names = append(names, fmt.Sprintf("%s (%s)", change.ResourceName, change.Type))
+	}
+	sort.Strings(names)
 
-		for _, res := range resourcesToDelete {
-			fmt.Printf("   - [%s] %s... ", res.Type, res.Name)
+	return fmt.Errorf("refusing to delete resources protected by lifecycle.preventDestroy: %s. Set preventDestroy to false and apply that first", strings.Join(names, ", "))
+}
 
-			// Get resource provider
-			resourceProvider, err := awsProvider.GetResourceProvider(schema.Kind(res.Type))
-			if err != nil {
-				yellow.Printf("⚠️  Skipped (no provider)\n")
-				continue
-			}
+// stateSavedEvent reports the result of saving the state
+func stateSavedEvent(err error) events.Event {
+	event := events.Event{Type: events.StateSaved}
+	if err != nil {
+		event.Error = err.Error()
+	}
+	return event
+}
 
-			// Delete resource
-			_, err = resourceProvider.Delete(ctx, res.ID, &provider.ResourceOptions{
-				TenantID:  session.Tenant.ID,
-				StackName: stackName,
-			})
-			if err != nil {
-				red.Println("✗")
-				log.Error("Failed to delete resource",
-					zap.String("name", res.Name),
-					zap.Error(err),
-				)
-				deleteFailCount++
-			} else {
-				green.Println("✓")
-				deleteCount++
-				// Remove from state
-				currentState.RemoveResource(res.Name)
-			}
+// noChangesSummary reports an operation that found nothing to change
+func noChangesSummary(changeSet *diff.ChangeSet) events.Event {
+	return events.Event{
+		Type: events.Summary,
+		Summary: &events.Totals{
+			Success:   true,
+			Unchanged: changeSet.Summary.NoChange,
+		},
+	}
+}
+
+// validateStack validates a parsed stack folder and returns it as a
+// ParseResult for graph building
+func validateStack(parseResult *parser.StackParseResult) (*parser.ParseResult, error) {
+	green := color.New(color.FgGreen, color.Bold)
+	red := color.New(color.FgRed, color.Bold)
+
+	fmt.Print("⏳ Validating configuration... ")
+	v := parser.NewValidator()
+	validationResult := &parser.ParseResult{
+		Stack:      parseResult.Stack,
+		Components: parseResult.AllComponents,
+	}
+	for _, svc := range parseResult.Services {
+		if svc.Service != nil {
+			validationResult.Services = append(validationResult.Services, svc.Service)
 		}
 	}
+	if err := v.Validate(validationResult); err != nil {
+		red.Println("✗")
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+	green.Println("✓")
 
-	// Clear rollback transaction on success
-	if !applyFailed {
-		rollbackMgr.ClearTransaction()
+	return validationResult, nil
+}
+
+// checkTenantSession loads the current session and requires a tenant login
+func checkTenantSession() (*tenant.Session, error) {
+	green := color.New(color.FgGreen, color.Bold)
+	red := color.New(color.FgRed, color.Bold)
+
+	fmt.Print("\n⏳ Checking authentication... ")
+	sessionMgr := tenant.NewSessionManager()
+	session, err := sessionMgr.LoadSession()
+	if err != nil || session.Mode != tenant.ModeTenant || session.Tenant == nil {
+		red.Println("✗")
+		return nil, fmt.Errorf("not logged in as tenant. Run 'panka login' first")
 	}
+	green.Println("✓")
+	fmt.Printf("   Tenant: %s\n", session.Tenant.ID)
 
-	// Step 13: Save state
-	fmt.Print("\n⏳ Saving state... ")
-	currentState.Metadata.UpdatedAt = time.Now()
-	currentState.Metadata.DeployedBy = "panka-cli"
+	return session, nil
+}
 
-	if err := stateBackend.Save(ctx, stateKey, currentState); err != nil {
+// loadTenantConfig loads the configuration of the session's tenant and
+// shows its networking. It also returns the configured state backend.
+func loadTenantConfig(ctx context.Context, session *tenant.Session) (*tenant.Tenant, string, string, error) {
+	green := color.New(color.FgGreen, color.Bold)
+	red := color.New(color.FgRed, color.Bold)
+	yellow := color.New(color.FgYellow)
+
+	fmt.Print("⏳ Loading tenant configuration... ")
+	bucket := viper.GetString("backend.bucket")
+	region := viper.GetString("backend.region")
+
+	if bucket == "" || region == "" {
 		red.Println("✗")
-		yellow.Printf("⚠️  Warning: Failed to save state: %v\n", err)
-	} else {
-		green.Println("✓")
+		return nil, "", "", fmt.Errorf("backend.bucket and backend.region must be configured in .panka.yaml")
 	}
 
-	// Summary
-	duration := time.Since(startTime)
-	cyan.Println("\n" + strings.Repeat("─", 60))
-	cyan.Println("📊 Apply Summary")
-	cyan.Println(strings.Repeat("─", 60))
+	tenantBackend, err := tenant.NewS3RegistryBackend(bucket, region)
+	if err != nil {
+		red.Println("✗")
+		return nil, "", "", fmt.Errorf("failed to create tenant backend: %w", err)
+	}
 
-	fmt.Printf("Stack:      %s\n", stackName)
-	fmt.Printf("Duration:   %s\n", duration.Round(time.Second))
-	green.Printf("Created/Updated: %d\n", successCount)
-	if deleteCount > 0 {
-		red.Printf("Deleted:    %d\n", deleteCount)
+	tenantConfig, err := tenantBackend.LoadTenantConfig(ctx, session.Tenant.ID)
+	if err != nil {
+		red.Println("✗")
+		return nil, "", "", fmt.Errorf("failed to load tenant config: %w", err)
 	}
-	if failCount > 0 {
-		red.Printf("Failed:     %d\n", failCount)
+	green.Println("✓")
+
+	// Display networking info
+	if tenantConfig.Networking.ResourceIDs != nil && tenantConfig.Networking.ResourceIDs.VPCID != "" {
+		fmt.Printf("   VPC: %s\n", tenantConfig.Networking.ResourceIDs.VPCID)
+		fmt.Printf("   Security Group: %s\n", tenantConfig.Networking.ResourceIDs.SecurityGroupID)
+	} else {
+		yellow.Println("   ⚠️  No networking provisioned for this tenant")
+		yellow.Println("      Resources will be created without VPC configuration")
 	}
-	if deleteFailCount > 0 {
-		red.Printf("Delete Failed: %d\n", deleteFailCount)
+
+	return tenantConfig, bucket, region, nil
+}
+
+// openStateBackend creates the S3 state backend of a tenant
+func openStateBackend(ctx context.Context, tenantID, bucket, region string) (*state.S3Backend, error) {
+	awsCfg, err := awsconfig.LoadDefaultConfig(ctx, awsconfig.WithRegion(region))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS config: %w", err)
 	}
 
-	totalFailed := failCount + deleteFailCount
-	if totalFailed > 0 {
-		yellow.Println("\n⚠️  Some operations failed")
-		return fmt.Errorf("%d operation(s) failed", totalFailed)
+	stateBackend, err := state.NewS3Backend(&state.S3BackendConfig{
+		Client: s3.NewFromConfig(awsCfg),
+		Bucket: bucket,
+		Prefix: fmt.Sprintf("tenants/%s/v1/stacks", tenantID),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create state backend: %w", err)
 	}
 
-	green.Println("\n✨ Apply complete!")
-	return nil
+	return stateBackend, nil
 }
 
 // displayRollbackResult displays the result of a rollback operation
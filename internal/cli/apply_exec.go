@@ -0,0 +1,744 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/yourusername/panka/internal/logger"
+	"github.com/yourusername/panka/pkg/diff"
+	"github.com/yourusername/panka/pkg/events"
+	"github.com/yourusername/panka/pkg/graph"
+	"github.com/yourusername/panka/pkg/parser"
+	"github.com/yourusername/panka/pkg/parser/schema"
+	"github.com/yourusername/panka/pkg/provider"
+	"github.com/yourusername/panka/pkg/rollback"
+	"github.com/yourusername/panka/pkg/state"
+	"go.uber.org/zap"
+)
+
+// resourceTimeout bounds each create, update and delete, retries included
+var resourceTimeout time.Duration
+
+// addResourceTimeoutFlag registers the resource operation timeout flag on a
+// command
+func addResourceTimeoutFlag(cmd *cobra.Command) {
+	cmd.Flags().DurationVar(&resourceTimeout, "resource-timeout", time.Hour, "Maximum duration of a single resource operation, retries included (0 for no limit)")
+}
+
+// applyExecution holds the shared state of a running apply.
+// Resources within a stage are applied by concurrent workers, so every
+// access to the state and the counters goes through a mutex. Progress is
+// reported as events.
+//
+// The state is checkpointed to the backend before and after every resource
+// operation. Before the provider is called the resource is saved with an
+// in-progress status, so that an interrupted apply leaves a record of every
+// operation that may have reached AWS (see reconcileInterrupted).
+type applyExecution struct {
+	provider    resourceProviders
+	rollbackMgr *rollback.Manager
+	log         *logger.Logger
+	events      *events.Emitter
+	changes     map[string]*diff.Change
+
+	tenantID  string
+	stackName string
+
+	stateBackend state.Backend
+	stateKey     string
+
+	stateMu        sync.Mutex
+	state          *state.State
+	revision       int
+	createdCount   int
+	updatedCount   int
+	unchangedCount int
+	deletedCount   int
+	skippedCount   int
+	failCount      int
+
+	// saveMu serializes uploads of the state. savedRevision is the revision
+	// of the last snapshot uploaded, or -1 before the first one.
+	saveMu        sync.Mutex
+	savedRevision int
+}
+
+// resourceProviders looks up the provider of a resource kind
+type resourceProviders interface {
+	GetResourceProvider(kind schema.Kind) (provider.ResourceProvider, error)
+}
+
+// deposedSuffix is appended to the state name of a resource replaced with
+// createBeforeDestroy until the old resource has been deleted
+const deposedSuffix = "#deposed"
+
+// resourceOperation is an operation on a single resource that has been
+// reported as started
+type resourceOperation struct {
+	name    string
+	kind    string
+	action  diff.ChangeType
+	reason  string
+	started time.Time
+}
+
+// newApplyExecution creates the shared execution state for an apply
+func newApplyExecution(p resourceProviders, rollbackMgr *rollback.Manager, emitter *events.Emitter, currentState *state.State, changeSet *diff.ChangeSet, stateBackend state.Backend, stateKey, tenantID, stackName string) *applyExecution {
+	changes := make(map[string]*diff.Change, len(changeSet.Changes))
+	for _, change := range changeSet.Changes {
+		changes[change.ResourceName] = change
+	}
+
+	return &applyExecution{
+		provider:     p,
+		rollbackMgr:  rollbackMgr,
+		log:          logger.Global(),
+		events:       emitter,
+		changes:      changes,
+		tenantID:     tenantID,
+		stackName:    stackName,
+		stateBackend: stateBackend,
+		stateKey:     stateKey,
+		state:        currentState,
+
+		savedRevision: -1,
+	}
+}
+
+// applyResource applies a single resource from a deployment stage according
+// to its change in the change set.
+func (e *applyExecution) applyResource(ctx context.Context, res *graph.DeploymentResource) error {
+	resourceName := res.ID
+	resourceKind := res.Kind
+
+	// Check if resource already exists in state
+	existingResource, existsInState := e.getResource(resourceName)
+
+	// Get resource provider
+	resourceProvider, err := e.provider.GetResourceProvider(schema.Kind(resourceKind))
+	if err != nil {
+		e.skipResource(resourceName, string(resourceKind), "no provider")
+		return nil
+	}
+
+	// Build options
+	opts := &provider.ResourceOptions{
+		TenantID:    e.tenantID,
+		StackName:   e.stackName,
+		ServiceName: res.Resource.GetMetadata().Service,
+		Tags: map[string]string{
+			"stack":   e.stackName,
+			"service": res.Resource.GetMetadata().Service,
+		},
+		Timeout: resourceTimeout,
+		DryRun:  false,
+	}
+
+	changeType := diff.ChangeCreate
+	if change, ok := e.changes[resourceName]; ok {
+		changeType = change.Type
+	}
+
+	switch changeType {
+	case diff.ChangeUpdate:
+		if existsInState {
+			return e.updateResource(ctx, res, resourceProvider, existingResource, opts)
+		}
+
+	case diff.ChangeRecreate:
+		if existsInState && existingResource.ID != "" && res.Resource.GetMetadata().Lifecycle.CreatesBeforeDestroy() {
+			return e.replaceCreateBeforeDestroy(ctx, res, resourceProvider, existingResource, opts)
+		}
+		if existsInState && existingResource.ID != "" {
+			op := e.startResource(resourceName, string(resourceKind), diff.ChangeRecreate, "")
+			e.markInProgress(ctx, existingResource, state.ResourceStatusDeleting)
+			if _, err := resourceProvider.Delete(ctx, existingResource.ID, opts); err != nil {
+				return e.failResource(op, res, "delete", err)
+			}
+			return e.createResource(ctx, op, res, resourceProvider, existingResource, opts)
+		}
+
+	case diff.ChangeNoChange:
+		if existsInState && existingResource.ID != "" {
+			// Check if resource still exists in AWS. A failed check is not
+			// taken as a missing resource, which would recreate it.
+			exists, err := resourceProvider.Exists(ctx, existingResource.ID, opts)
+			if err != nil {
+				op := e.startResource(resourceName, string(resourceKind), diff.ChangeNoChange, "")
+				return e.failResource(op, res, "check existence of", err)
+			}
+			if exists {
+				e.events.Emit(events.Event{
+					Type:     events.ResourceSucceeded,
+					Resource: resourceName,
+					Kind:     string(resourceKind),
+					Action:   string(diff.ChangeNoChange),
+					ID:       existingResource.ID,
+				})
+				e.log.Info("Resource unchanged, skipping",
+					zap.String("name", resourceName),
+					zap.String("id", existingResource.ID),
+				)
+				e.record(&e.unchangedCount)
+				return nil
+			}
+			// Resource in state but not in AWS - recreate it
+			op := e.startResource(resourceName, string(resourceKind), diff.ChangeRecreate, "missing in AWS")
+			return e.createResource(ctx, op, res, resourceProvider, existingResource, opts)
+		}
+	}
+
+	op := e.startResource(resourceName, string(resourceKind), diff.ChangeCreate, "")
+	return e.createResource(ctx, op, res, resourceProvider, nil, opts)
+}
+
+// createResource creates a resource and records it in state. previous is the
+// state entry being replaced, if any.
+func (e *applyExecution) createResource(ctx context.Context, op *resourceOperation, res *graph.DeploymentResource, resourceProvider provider.ResourceProvider, previous *state.Resource, opts *provider.ResourceOptions) error {
+	// Attributes are recorded from the resource as declared, which is what
+	// the differ compares them with
+	declared := res.Resource
+	ignored, err := parser.IgnoredValues(declared)
+	if err != nil {
+		return e.failResource(op, res, "record ignored attributes of", err)
+	}
+	res, err = e.resolveOutputs(res)
+	if err != nil {
+		return e.failResource(op, res, "resolve outputs for", err)
+	}
+
+	resourceName := res.ID
+	resourceKind := res.Kind
+
+	createdAt := time.Now()
+	if previous != nil {
+		createdAt = previous.CreatedAt
+	}
+
+	// Record the create before calling AWS, with the ID the resource will
+	// get, so that an interrupted create can be found again
+	e.markInProgress(ctx, &state.Resource{
+		ID:        e.plannedID(res, resourceProvider, opts),
+		Type:      string(resourceKind),
+		Name:      resourceName,
+		Provider:  "aws",
+		DependsOn: res.Dependencies,
+		CreatedAt: createdAt,
+	}, state.ResourceStatusCreating)
+
+	result, err := resourceProvider.Create(ctx, res.Resource, opts)
+	if err != nil {
+		return e.failResource(op, res, "create", err)
+	}
+
+	// Create state resource
+	stateResource := &state.Resource{
+		ID:         result.ResourceID,
+		Type:       string(result.Kind),
+		Name:       resourceName,
+		Provider:   "aws",
+		Status:     state.ResourceStatusReady,
+		Attributes: mergeAttributes(nil, result.Outputs, declared, ignored),
+		DependsOn:  res.Dependencies,
+		CreatedAt:  createdAt,
+		UpdatedAt:  time.Now(),
+
+		PreventDestroy: res.Resource.GetMetadata().Lifecycle.PreventsDestroy(),
+	}
+
+	// Record successful action for rollback
+	e.rollbackMgr.RecordCreate(resourceName, result.ResourceID, schema.Kind(resourceKind), stateResource, true, nil)
+
+	// Update state
+	e.addResource(resourceName, stateResource)
+	e.checkpoint(ctx)
+	e.record(&e.createdCount)
+
+	e.succeedResource(op, result.ResourceID, result.Outputs, nil)
+	return nil
+}
+
+// updateResource updates a resource in place and refreshes its state entry.
+// The resource keeps the ID it was created with.
+func (e *applyExecution) updateResource(ctx context.Context, res *graph.DeploymentResource, resourceProvider provider.ResourceProvider, existing *state.Resource, opts *provider.ResourceOptions) error {
+	resourceName := res.ID
+	resourceKind := res.Kind
+
+	op := e.startResource(resourceName, string(resourceKind), diff.ChangeUpdate, "")
+
+	// Ignored attributes are updated with the values they were last applied
+	// with, so that an update made for other changes does not reset them
+	res, ignored, err := restoreIgnored(res, existing)
+	if err != nil {
+		return e.failResource(op, res, "restore ignored attributes of", err)
+	}
+	declared := res.Resource
+	res, err = e.resolveOutputs(res)
+	if err != nil {
+		return e.failResource(op, res, "resolve outputs for", err)
+	}
+
+	before := *existing
+	e.markInProgress(ctx, existing, state.ResourceStatusUpdating)
+
+	result, err := resourceProvider.Update(ctx, res.Resource, opts)
+	if err != nil {
+		return e.failResource(op, res, "update", err)
+	}
+
+	stateResource := &state.Resource{
+		ID:         existing.ID,
+		Type:       existing.Type,
+		Name:       resourceName,
+		Provider:   existing.Provider,
+		Status:     state.ResourceStatusReady,
+		Attributes: mergeAttributes(existing.Attributes, result.Outputs, declared, ignored),
+		DependsOn:  res.Dependencies,
+		CreatedAt:  existing.CreatedAt,
+		UpdatedAt:  time.Now(),
+
+		PreventDestroy: res.Resource.GetMetadata().Lifecycle.PreventsDestroy(),
+	}
+
+	e.rollbackMgr.RecordUpdate(resourceName, existing.ID, schema.Kind(resourceKind), &before, stateResource, true, nil)
+
+	e.addResource(resourceName, stateResource)
+	e.checkpoint(ctx)
+	e.record(&e.updatedCount)
+
+	var changes []diff.AttributeChange
+	if change, ok := e.changes[resourceName]; ok {
+		changes = change.AttributeChanges
+	}
+	e.succeedResource(op, existing.ID, result.Outputs, changes)
+
+	return nil
+}
+
+// replaceCreateBeforeDestroy replaces a resource with lifecycle
+// createBeforeDestroy. The old resource is kept in state under its deposed
+// name until it has been deleted; if deleting it fails, the next apply
+// deletes it since it is no longer configured.
+func (e *applyExecution) replaceCreateBeforeDestroy(ctx context.Context, res *graph.DeploymentResource, resourceProvider provider.ResourceProvider, existing *state.Resource, opts *provider.ResourceOptions) error {
+	op := e.startResource(res.ID, string(res.Kind), diff.ChangeRecreate, "create before destroy")
+
+	if plannedID := e.plannedID(res, resourceProvider, opts); plannedID == existing.ID {
+		return e.failResource(op, res, "replace", fmt.Errorf("createBeforeDestroy needs a new identifier for the replacement, but it would reuse %s", existing.ID))
+	}
+
+	deposed := *existing
+	deposed.Name = existing.Name + deposedSuffix
+	e.markInProgress(ctx, &deposed, state.ResourceStatusDeleting)
+
+	if err := e.createResource(ctx, op, res, resourceProvider, nil, opts); err != nil {
+		return err
+	}
+
+	if _, err := resourceProvider.Delete(ctx, existing.ID, opts); err != nil {
+		e.events.Emit(events.Event{
+			Type:    events.Warning,
+			Message: fmt.Sprintf("failed to delete replaced %s (%s), it is deleted by the next apply: %v", existing.Name, existing.ID, err),
+		})
+		e.log.Warn("Failed to delete replaced resource",
+			zap.String("name", existing.Name),
+			zap.String("id", existing.ID),
+			zap.Error(err),
+		)
+		deposed.Status = state.ResourceStatusReady
+		e.addResource(deposed.Name, &deposed)
+		e.checkpoint(ctx)
+		return nil
+	}
+
+	e.removeResource(deposed.Name)
+	e.checkpoint(ctx)
+
+	return nil
+}
+
+// startResource reports the start of a resource operation
+func (e *applyExecution) startResource(name, kind string, action diff.ChangeType, reason string) *resourceOperation {
+	op := &resourceOperation{
+		name:    name,
+		kind:    kind,
+		action:  action,
+		reason:  reason,
+		started: time.Now(),
+	}
+
+	e.events.Emit(events.Event{
+		Type:     events.ResourceStarted,
+		Resource: name,
+		Kind:     kind,
+		Action:   string(action),
+		Reason:   reason,
+	})
+
+	return op
+}
+
+// succeedResource reports a successful resource operation
+func (e *applyExecution) succeedResource(op *resourceOperation, id string, outputs map[string]string, changes []diff.AttributeChange) {
+	e.events.Emit(events.Event{
+		Type:       events.ResourceSucceeded,
+		Resource:   op.name,
+		Kind:       op.kind,
+		Action:     string(op.action),
+		Reason:     op.reason,
+		ID:         id,
+		Outputs:    outputs,
+		Changes:    events.MaskSensitive(changes),
+		DurationMS: time.Since(op.started).Milliseconds(),
+	})
+}
+
+// skipResource reports a resource that cannot be applied
+func (e *applyExecution) skipResource(name, kind, reason string) {
+	e.events.Emit(events.Event{
+		Type:     events.ResourceSkipped,
+		Resource: name,
+		Kind:     kind,
+		Reason:   reason,
+	})
+	e.log.Warn("No provider for resource kind",
+		zap.String("kind", kind),
+		zap.String("name", name),
+	)
+	e.record(&e.skippedCount)
+}
+
+// failResource reports a failed resource operation
+func (e *applyExecution) failResource(op *resourceOperation, res *graph.DeploymentResource, operation string, err error) error {
+	e.events.Emit(events.Event{
+		Type:       events.ResourceFailed,
+		Resource:   op.name,
+		Kind:       op.kind,
+		Action:     string(op.action),
+		Error:      err.Error(),
+		DurationMS: time.Since(op.started).Milliseconds(),
+	})
+	e.log.Error("Failed to "+operation+" resource",
+		zap.String("name", res.ID),
+		zap.Error(err),
+	)
+	e.record(&e.failCount)
+
+	// Record failed action for rollback tracking
+	if operation == "update" {
+		e.rollbackMgr.RecordUpdate(res.ID, "", schema.Kind(res.Kind), nil, nil, false, err)
+	} else {
+		e.rollbackMgr.RecordCreate(res.ID, "", schema.Kind(res.Kind), nil, false, err)
+	}
+
+	return fmt.Errorf("failed to %s %s: %w", operation, res.ID, err)
+}
+
+// deleteResource deletes a resource that was removed from the configuration.
+// Failures are counted rather than returned so that the remaining deletions
+// still run.
+func (e *applyExecution) deleteResource(ctx context.Context, res *graph.DeploymentResource) error {
+	existing, ok := e.getResource(res.ID)
+	if !ok {
+		return nil
+	}
+
+	resourceProvider, err := e.provider.GetResourceProvider(schema.Kind(existing.Type))
+	if err != nil {
+		e.skipResource(existing.Name, existing.Type, "no provider")
+		return nil
+	}
+
+	op := e.startResource(existing.Name, existing.Type, diff.ChangeDelete, "")
+
+	e.markInProgress(ctx, existing, state.ResourceStatusDeleting)
+
+	_, err = resourceProvider.Delete(ctx, existing.ID, &provider.ResourceOptions{
+		TenantID:  e.tenantID,
+		StackName: e.stackName,
+		Timeout:   resourceTimeout,
+	})
+	if err != nil {
+		e.events.Emit(events.Event{
+			Type:       events.ResourceFailed,
+			Resource:   op.name,
+			Kind:       op.kind,
+			Action:     string(op.action),
+			Error:      err.Error(),
+			DurationMS: time.Since(op.started).Milliseconds(),
+		})
+		e.log.Error("Failed to delete resource",
+			zap.String("name", existing.Name),
+			zap.Error(err),
+		)
+		e.record(&e.failCount)
+		return nil
+	}
+
+	e.rollbackMgr.RecordDelete(existing.Name, existing.ID, schema.Kind(existing.Type), existing, true, nil)
+	e.removeResource(existing.Name)
+	e.checkpoint(ctx)
+	e.record(&e.deletedCount)
+
+	e.succeedResource(op, existing.ID, nil, nil)
+	return nil
+}
+
+// ignoredValuesAttribute is the state attribute that records the values
+// that the attributes ignored by the lifecycle of a resource were applied
+// with, by path
+const ignoredValuesAttribute = "ignored_values"
+
+// mergeAttributes builds the state attributes of a resource from its previous
+// attributes, the provider outputs and the configuration compared by the
+// differ. An updated resource must have its ignored attributes restored by
+// restoreIgnored, so that they keep their previous state values.
+func mergeAttributes(previous map[string]interface{}, outputs map[string]string, resource schema.Resource, ignored map[string]interface{}) map[string]interface{} {
+	attrs := make(map[string]interface{}, len(previous)+len(outputs))
+	for k, v := range previous {
+		attrs[k] = v
+	}
+	for k, v := range outputs {
+		attrs[k] = v
+	}
+	for k, v := range diff.StateAttributes(resource) {
+		attrs[k] = v
+	}
+	delete(attrs, ignoredValuesAttribute)
+	if len(ignored) > 0 {
+		attrs[ignoredValuesAttribute] = ignored
+	}
+	return attrs
+}
+
+// restoreIgnored returns the deployment resource to update with the
+// attributes ignored by its lifecycle set to the values recorded in its
+// previous state entry, together with the ignored values to record. An
+// attribute that was not ignored when the resource was last applied keeps
+// its configured value.
+func restoreIgnored(res *graph.DeploymentResource, previous *state.Resource) (*graph.DeploymentResource, map[string]interface{}, error) {
+	recorded, _ := previous.Attributes[ignoredValuesAttribute].(map[string]interface{})
+	restored, err := parser.RestoreIgnoredValues(res.Resource, recorded)
+	if err != nil {
+		return res, nil, err
+	}
+	ignored, err := parser.IgnoredValues(restored)
+	if err != nil {
+		return res, nil, err
+	}
+
+	out := *res
+	out.Resource = restored
+	return &out, ignored, nil
+}
+
+// resolveOutputs returns the deployment resource with its references to
+// component outputs replaced by their values, and its trigger sources and
+// access grants resolved to ARNs. Outputs come from the state,
+// which holds the outputs of unchanged resources and of resources applied in
+// earlier stages.
+func (e *applyExecution) resolveOutputs(res *graph.DeploymentResource) (*graph.DeploymentResource, error) {
+	outputs := e.componentOutputs()
+	resolved, err := parser.ResolveOutputReferences(res.Resource, outputs)
+	if err != nil {
+		return res, err
+	}
+	if err := parser.ResolveTriggerSources(resolved, outputs); err != nil {
+		return res, err
+	}
+	if err := parser.ResolveAccessGrants(resolved, outputs); err != nil {
+		return res, err
+	}
+
+	out := *res
+	out.Resource = resolved
+	return &out, nil
+}
+
+// evaluateOutputs evaluates the declared outputs against the applied
+// resources and stores them in the state, replacing the previous outputs.
+// Outputs that cannot be evaluated are reported and left out.
+func (e *applyExecution) evaluateOutputs(declared map[string]string) {
+	names := make([]string, 0, len(declared))
+	for name := range declared {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	componentOutputs := e.componentOutputs()
+	values := make(map[string]string, len(declared))
+	for _, name := range names {
+		value, err := parser.ResolveOutputValue(declared[name], componentOutputs)
+		if err != nil {
+			e.events.Emit(events.Event{
+				Type:    events.Warning,
+				Message: fmt.Sprintf("output %s: %v", name, err),
+			})
+			e.log.Warn("Failed to evaluate output",
+				zap.String("output", name),
+				zap.Error(err),
+			)
+			continue
+		}
+		values[name] = value
+	}
+
+	e.events.Emit(events.Event{
+		Type:    events.OutputsEvaluated,
+		Outputs: values,
+	})
+
+	e.stateMu.Lock()
+	defer e.stateMu.Unlock()
+	e.state.Outputs = make(map[string]interface{}, len(values))
+	for name, value := range values {
+		e.state.SetOutput(name, value)
+	}
+	e.revision++
+}
+
+// totals returns the results of the execution for the summary event
+func (e *applyExecution) totals(duration time.Duration) *events.Totals {
+	e.stateMu.Lock()
+	defer e.stateMu.Unlock()
+	return &events.Totals{
+		Success:    e.failCount == 0,
+		DurationMS: duration.Milliseconds(),
+		Created:    e.createdCount,
+		Updated:    e.updatedCount,
+		Unchanged:  e.unchangedCount,
+		Deleted:    e.deletedCount,
+		Skipped:    e.skippedCount,
+		Failed:     e.failCount,
+	}
+}
+
+// componentOutputs returns the known outputs of every component of the
+// stack. The string attributes of a state resource are its outputs, and its
+// ID is available as the id output.
+func (e *applyExecution) componentOutputs() map[string]map[string]string {
+	e.stateMu.Lock()
+	defer e.stateMu.Unlock()
+
+	outputs := make(map[string]map[string]string, len(e.changes))
+	for name := range e.changes {
+		outputs[name] = make(map[string]string)
+	}
+
+	for name, res := range e.state.Resources {
+		values, ok := outputs[name]
+		if !ok {
+			values = make(map[string]string)
+			outputs[name] = values
+		}
+		for k, v := range res.Attributes {
+			if value, ok := v.(string); ok {
+				values[k] = value
+			}
+		}
+		if _, ok := values["id"]; !ok && res.ID != "" {
+			values["id"] = res.ID
+		}
+	}
+
+	return outputs
+}
+
+// getResource reads a resource from the shared state
+func (e *applyExecution) getResource(name string) (*state.Resource, bool) {
+	e.stateMu.Lock()
+	defer e.stateMu.Unlock()
+	return e.state.GetResource(name)
+}
+
+// addResource writes a resource into the shared state
+func (e *applyExecution) addResource(name string, res *state.Resource) {
+	e.stateMu.Lock()
+	defer e.stateMu.Unlock()
+	e.state.AddResource(name, res)
+	e.revision++
+}
+
+// removeResource deletes a resource from the shared state
+func (e *applyExecution) removeResource(name string) {
+	e.stateMu.Lock()
+	defer e.stateMu.Unlock()
+	e.state.RemoveResource(name)
+	e.revision++
+}
+
+// record increments one of the execution counters
+func (e *applyExecution) record(counter *int) {
+	e.stateMu.Lock()
+	defer e.stateMu.Unlock()
+	*counter++
+}
+
+// markInProgress records that an operation on a resource is about to start
+// and checkpoints the state. The marker is a copy of res, since res may be
+// the entry held in the shared state, which other workers snapshot.
+func (e *applyExecution) markInProgress(ctx context.Context, res *state.Resource, status state.ResourceStatus) {
+	marker := *res
+	marker.Status = status
+	marker.UpdatedAt = time.Now()
+	e.addResource(marker.Name, &marker)
+	e.checkpoint(ctx)
+}
+
+// plannedID returns the ID a resource will be created with, or an empty
+// string if its provider cannot tell it in advance
+func (e *applyExecution) plannedID(res *graph.DeploymentResource, resourceProvider provider.ResourceProvider, opts *provider.ResourceOptions) string {
+	planner, ok := resourceProvider.(provider.IDPlanner)
+	if !ok {
+		return ""
+	}
+	id, err := planner.PlannedID(res.Resource, opts)
+	if err != nil {
+		return ""
+	}
+	return id
+}
+
+// checkpoint saves the state after a resource operation. The save is not
+// cancelled with the apply, so that an interrupted operation is recorded.
+// A failed checkpoint is logged; the state is saved again at the end.
+func (e *applyExecution) checkpoint(ctx context.Context) {
+	if e.stateBackend == nil {
+		return
+	}
+	if err := e.saveState(context.WithoutCancel(ctx), e.stateBackend, e.stateKey); err != nil {
+		e.log.Warn("Failed to checkpoint state", zap.Error(err))
+	}
+}
+
+// saveState persists the shared state. A snapshot is taken under the state
+// mutex and uploaded outside it, so that workers are not held up by the
+// backend. Uploads run one at a time; a save returns once a snapshot holding
+// every change made before it was called has been uploaded, which may be the
+// snapshot of a concurrent save.
+func (e *applyExecution) saveState(ctx context.Context, backend state.Backend, key string) error {
+	e.stateMu.Lock()
+	wanted := e.revision
+	e.stateMu.Unlock()
+
+	e.saveMu.Lock()
+	defer e.saveMu.Unlock()
+	if e.savedRevision >= wanted {
+		return nil
+	}
+
+	e.stateMu.Lock()
+	e.state.Metadata.UpdatedAt = time.Now()
+	snapshot := e.state.Clone()
+	revision := e.revision
+	e.stateMu.Unlock()
+
+	if err := backend.Save(ctx, key, snapshot); err != nil {
+		return err
+	}
+	e.savedRevision = revision
+	return nil
+}
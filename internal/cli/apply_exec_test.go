@@ -0,0 +1,371 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourusername/panka/internal/logger"
+	"github.com/yourusername/panka/pkg/diff"
+	"github.com/yourusername/panka/pkg/events"
+	"github.com/yourusername/panka/pkg/graph"
+	"github.com/yourusername/panka/pkg/parser"
+	"github.com/yourusername/panka/pkg/parser/schema"
+	"github.com/yourusername/panka/pkg/provider"
+	"github.com/yourusername/panka/pkg/rollback"
+	"github.com/yourusername/panka/pkg/state"
+	"go.uber.org/zap"
+)
+
+// fakeProviders serves the same resource provider for every kind
+type fakeProviders struct {
+	provider provider.ResourceProvider
+}
+
+func (f *fakeProviders) GetResourceProvider(kind schema.Kind) (provider.ResourceProvider, error) {
+	return f.provider, nil
+}
+
+// fakeResourceProvider creates resources with the planned ID <name>-v2 and
+// rejects dry runs. It fails to delete the resources whose ID starts with
+// "stuck-". Deletes are slow so that other workers save the state meanwhile.
+// existsErr is returned by Exists.
+type fakeResourceProvider struct {
+	existsErr error
+
+	// updated holds the resources passed to Update, by name
+	mu      sync.Mutex
+	updated map[string]schema.Resource
+}
+
+func (f *fakeResourceProvider) Create(ctx context.Context, resource schema.Resource, opts *provider.ResourceOptions) (*provider.ResourceResult, error) {
+	if opts.DryRun {
+		return nil, fmt.Errorf("unexpected dry run of %s", resource.GetMetadata().Name)
+	}
+	return &provider.ResourceResult{
+		ResourceID: resource.GetMetadata().Name + "-v2",
+		Kind:       resource.GetKind(),
+		Outputs:    map[string]string{"arn": "arn:" + resource.GetMetadata().Name},
+	}, nil
+}
+
+func (f *fakeResourceProvider) PlannedID(resource schema.Resource, opts *provider.ResourceOptions) (string, error) {
+	return resource.GetMetadata().Name + "-v2", nil
+}
+
+func (f *fakeResourceProvider) Read(ctx context.Context, resourceID string, opts *provider.ResourceOptions) (*provider.ResourceResult, error) {
+	return &provider.ResourceResult{ResourceID: resourceID}, nil
+}
+
+func (f *fakeResourceProvider) Update(ctx context.Context, resource schema.Resource, opts *provider.ResourceOptions) (*provider.ResourceResult, error) {
+	f.mu.Lock()
+	if f.updated == nil {
+		f.updated = make(map[string]schema.Resource)
+	}
+	f.updated[resource.GetMetadata().Name] = resource
+	f.mu.Unlock()
+	return &provider.ResourceResult{ResourceID: resource.GetMetadata().Name + "-v1", Kind: resource.GetKind()}, nil
+}
+
+func (f *fakeResourceProvider) Delete(ctx context.Context, resourceID string, opts *provider.ResourceOptions) (*provider.ResourceResult, error) {
+	time.Sleep(2 * time.Millisecond)
+	if strings.HasPrefix(resourceID, "stuck-") {
+		return nil, fmt.Errorf("resource in use")
+	}
+	return &provider.ResourceResult{ResourceID: resourceID}, nil
+}
+
+func (f *fakeResourceProvider) Exists(ctx context.Context, resourceID string, opts *provider.ResourceOptions) (bool, error) {
+	if f.existsErr != nil {
+		return false, f.existsErr
+	}
+	return true, nil
+}
+
+func (f *fakeResourceProvider) GetOutputs(ctx context.Context, resourceID string, opts *provider.ResourceOptions) (map[string]string, error) {
+	return nil, nil
+}
+
+// memoryBackend keeps the last saved state as JSON. Saves are slow so that
+// workers keep running while a snapshot is uploaded, and fail with a
+// cancelled context like uploads do.
+type memoryBackend struct {
+	mu    sync.Mutex
+	data  []byte
+	saves int
+}
+
+func (b *memoryBackend) Save(ctx context.Context, key string, st *state.State) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	data, err := json.Marshal(st)
+	if err != nil {
+		return err
+	}
+	time.Sleep(time.Millisecond)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.data = data
+	b.saves++
+	return nil
+}
+
+func (b *memoryBackend) Load(ctx context.Context, key string) (*state.State, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var st state.State
+	if err := json.Unmarshal(b.data, &st); err != nil {
+		return nil, err
+	}
+	return &st, nil
+}
+
+func (b *memoryBackend) Exists(ctx context.Context, key string) (bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.data != nil, nil
+}
+
+func (b *memoryBackend) Delete(ctx context.Context, key string) error { return nil }
+
+func (b *memoryBackend) List(ctx context.Context, prefix string) ([]string, error) { return nil, nil }
+
+func (b *memoryBackend) ListVersions(ctx context.Context, key string) ([]*state.StateVersion, error) {
+	return nil, nil
+}
+
+func (b *memoryBackend) GetVersion(ctx context.Context, key string, versionID string) (*state.State, error) {
+	return nil, nil
+}
+
+func (b *memoryBackend) Close() error { return nil }
+
+// testStage is a deployment stage of SQS queues with their changes and the
+// state they are applied to
+type testStage struct {
+	current   *state.State
+	changeSet *diff.ChangeSet
+	stage     *graph.DeploymentStage
+}
+
+func newTestStage() *testStage {
+	return &testStage{
+		current:   state.NewState("test-stack", "default"),
+		changeSet: &diff.ChangeSet{StackName: "test-stack", Environment: "default"},
+		stage:     &graph.DeploymentStage{Number: 1},
+	}
+}
+
+// add adds a queue with a change to the stage. A queue with an id is in the
+// state already.
+func (s *testStage) add(name string, changeType diff.ChangeType, id string, lifecycle *schema.Lifecycle) {
+	queue := schema.NewSQS(name, "backend", "test-stack")
+	queue.Metadata.Lifecycle = lifecycle
+	if id != "" {
+		s.current.AddResource(name, &state.Resource{
+			ID:         id,
+			Type:       string(schema.KindSQS),
+			Name:       name,
+			Provider:   "aws",
+			Status:     state.ResourceStatusReady,
+			Attributes: diff.StateAttributes(queue),
+		})
+	}
+	s.changeSet.Changes = append(s.changeSet.Changes, &diff.Change{
+		ResourceName: name,
+		ResourceKind: schema.KindSQS,
+		Type:         changeType,
+		After:        queue,
+	})
+	s.stage.Resources = append(s.stage.Resources, &graph.DeploymentResource{
+		ID:       name,
+		Kind:     schema.KindSQS,
+		Resource: queue,
+	})
+}
+
+// apply applies the stage with 8 concurrent workers, checkpointing the
+// state to backend after every operation
+func (s *testStage) apply(t *testing.T, backend *memoryBackend) *applyExecution {
+	t.Helper()
+	emitter := events.NewEmitter(events.NewJSONSink(io.Discard), events.OperationApply, "test-stack", "default")
+	exec := newApplyExecution(&fakeProviders{provider: &fakeResourceProvider{}}, rollback.NewManager(nil), emitter, s.current, s.changeSet, backend, "test-stack/default", "tenant", "test-stack")
+	exec.log = &logger.Logger{Logger: zap.NewNop()}
+
+	ctx := context.Background()
+	require.NoError(t, graph.NewExecutor(8).ExecuteStage(ctx, s.stage, exec.applyResource))
+	require.NoError(t, exec.saveState(ctx, backend, "test-stack/default"))
+	return exec
+}
+
+// TestApplyExecution_ParallelReplace applies a stage of creates, updates and
+// createBeforeDestroy replacements concurrently while every operation
+// checkpoints the state. Run with -race.
+func TestApplyExecution_ParallelReplace(t *testing.T) {
+	s := newTestStage()
+	createBeforeDestroy := &schema.Lifecycle{CreateBeforeDestroy: true}
+	for i := 0; i < 4; i++ {
+		s.add(fmt.Sprintf("new-%d", i), diff.ChangeCreate, "", nil)
+		s.add(fmt.Sprintf("changed-%d", i), diff.ChangeUpdate, fmt.Sprintf("changed-%d-v1", i), nil)
+		s.add(fmt.Sprintf("replaced-%d", i), diff.ChangeRecreate, fmt.Sprintf("replaced-%d-v1", i), createBeforeDestroy)
+		s.add(fmt.Sprintf("stuck-%d", i), diff.ChangeRecreate, fmt.Sprintf("stuck-%d-v1", i), createBeforeDestroy)
+	}
+
+	backend := &memoryBackend{}
+	exec := s.apply(t, backend)
+
+	saved, err := backend.Load(context.Background(), "test-stack/default")
+	require.NoError(t, err)
+
+	for i := 0; i < 4; i++ {
+		for _, name := range []string{"new", "changed", "replaced", "stuck"} {
+			res, ok := saved.GetResource(fmt.Sprintf("%s-%d", name, i))
+			require.True(t, ok, "%s-%d", name, i)
+			assert.Equal(t, state.ResourceStatusReady, res.Status)
+		}
+
+		// Replacements whose old resource could not be deleted keep it deposed
+		_, ok := saved.GetResource(fmt.Sprintf("replaced-%d%s", i, deposedSuffix))
+		assert.False(t, ok)
+		deposed, ok := saved.GetResource(fmt.Sprintf("stuck-%d%s", i, deposedSuffix))
+		require.True(t, ok)
+		assert.Equal(t, fmt.Sprintf("stuck-%d-v1", i), deposed.ID)
+		assert.Equal(t, state.ResourceStatusReady, deposed.Status)
+	}
+
+	totals := exec.totals(0)
+	assert.Equal(t, 12, totals.Created)
+	assert.Equal(t, 4, totals.Updated)
+	assert.Zero(t, totals.Failed)
+	assert.Positive(t, backend.saves)
+}
+
+// TestApplyExecution_ParallelRecreate applies replacements that delete the
+// old resource first alongside creates. Run with -race.
+func TestApplyExecution_ParallelRecreate(t *testing.T) {
+	s := newTestStage()
+	for i := 0; i < 8; i++ {
+		s.add(fmt.Sprintf("new-%d", i), diff.ChangeCreate, "", nil)
+		s.add(fmt.Sprintf("recreated-%d", i), diff.ChangeRecreate, fmt.Sprintf("recreated-%d-v1", i), nil)
+	}
+	before, ok := s.current.GetResource("recreated-0")
+	require.True(t, ok)
+
+	backend := &memoryBackend{}
+	exec := s.apply(t, backend)
+
+	saved, err := backend.Load(context.Background(), "test-stack/default")
+	require.NoError(t, err)
+	for i := 0; i < 8; i++ {
+		res, ok := saved.GetResource(fmt.Sprintf("recreated-%d", i))
+		require.True(t, ok)
+		assert.Equal(t, fmt.Sprintf("recreated-%d-v2", i), res.ID)
+		assert.Equal(t, state.ResourceStatusReady, res.Status)
+	}
+
+	// The entry of the replaced resource was not modified in place
+	assert.Equal(t, state.ResourceStatusReady, before.Status)
+	assert.Equal(t, 16, exec.totals(0).Created)
+}
+
+func TestApplyExecution_SaveStateSkipsSavedRevisions(t *testing.T) {
+	backend := &memoryBackend{}
+	emitter := events.NewEmitter(events.NewJSONSink(io.Discard), events.OperationApply, "test-stack", "default")
+	exec := newApplyExecution(&fakeProviders{provider: &fakeResourceProvider{}}, rollback.NewManager(nil), emitter, state.NewState("test-stack", "default"), &diff.ChangeSet{}, backend, "key", "tenant", "test-stack")
+
+	ctx := context.Background()
+
+	// The first save always uploads the state
+	require.NoError(t, exec.saveState(ctx, backend, "key"))
+	assert.Equal(t, 1, backend.saves)
+
+	// An unchanged state is not uploaded again
+	require.NoError(t, exec.saveState(ctx, backend, "key"))
+	assert.Equal(t, 1, backend.saves)
+
+	exec.addResource("queue", &state.Resource{ID: "queue-v1", Name: "queue"})
+	require.NoError(t, exec.saveState(ctx, backend, "key"))
+	assert.Equal(t, 2, backend.saves)
+}
+
+func TestApplyExecution_UnchangedExistsError(t *testing.T) {
+	queue := schema.NewSQS("queue", "backend", "test-stack")
+	current := state.NewState("test-stack", "default")
+	current.AddResource("queue", &state.Resource{ID: "queue-v1", Type: string(schema.KindSQS), Name: "queue", Status: state.ResourceStatusReady})
+	changeSet := &diff.ChangeSet{Changes: []*diff.Change{{ResourceName: "queue", ResourceKind: schema.KindSQS, Type: diff.ChangeNoChange, After: queue}}}
+
+	emitter := events.NewEmitter(events.NewJSONSink(io.Discard), events.OperationApply, "test-stack", "default")
+	resourceProvider := &fakeResourceProvider{existsErr: fmt.Errorf("ThrottlingException: rate exceeded")}
+	exec := newApplyExecution(&fakeProviders{provider: resourceProvider}, rollback.NewManager(nil), emitter, current, changeSet, nil, "", "tenant", "test-stack")
+	exec.log = &logger.Logger{Logger: zap.NewNop()}
+
+	// A failed existence check fails the resource instead of recreating it
+	err := exec.applyResource(context.Background(), &graph.DeploymentResource{ID: "queue", Kind: schema.KindSQS, Resource: queue})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "rate exceeded")
+
+	res, ok := exec.getResource("queue")
+	require.True(t, ok)
+	assert.Equal(t, "queue-v1", res.ID)
+	assert.Equal(t, state.ResourceStatusReady, res.Status)
+
+	totals := exec.totals(0)
+	assert.Zero(t, totals.Created)
+	assert.Equal(t, 1, totals.Failed)
+}
+
+func TestApplyExecution_UpdateKeepsIgnoredAttributes(t *testing.T) {
+	ignoreVisibility := &schema.Lifecycle{IgnoreChanges: []string{"spec.visibilityTimeout"}}
+
+	// The queue was created with a visibility timeout of 30 seconds
+	queue := schema.NewSQS("queue", "backend", "test-stack")
+	queue.Metadata.Lifecycle = ignoreVisibility
+	current := state.NewState("test-stack", "default")
+	current.AddResource("queue", &state.Resource{
+		ID:         "queue-v1",
+		Type:       string(schema.KindSQS),
+		Name:       "queue",
+		Status:     state.ResourceStatusReady,
+		Attributes: mergeAttributes(nil, nil, queue, map[string]interface{}{"spec.visibilityTimeout": float64(30)}),
+	})
+
+	// and is now updated for its retention, with a new ignored timeout
+	updated := schema.NewSQS("queue", "backend", "test-stack")
+	updated.Metadata.Lifecycle = ignoreVisibility
+	updated.Spec.VisibilityTimeout = 120
+	updated.Spec.MessageRetentionPeriod = 86400
+	changeSet := &diff.ChangeSet{Changes: []*diff.Change{{ResourceName: "queue", ResourceKind: schema.KindSQS, Type: diff.ChangeUpdate, After: updated}}}
+	stage := &graph.DeploymentStage{Number: 1, Resources: []*graph.DeploymentResource{{ID: "queue", Kind: schema.KindSQS, Resource: updated}}}
+
+	emitter := events.NewEmitter(events.NewJSONSink(io.Discard), events.OperationApply, "test-stack", "default")
+	resourceProvider := &fakeResourceProvider{}
+	exec := newApplyExecution(&fakeProviders{provider: resourceProvider}, rollback.NewManager(nil), emitter, current, changeSet, nil, "", "tenant", "test-stack")
+	exec.log = &logger.Logger{Logger: zap.NewNop()}
+	require.NoError(t, graph.NewExecutor(1).ExecuteStage(context.Background(), stage, exec.applyResource))
+
+	// Update does not reset the ignored timeout to its configured value
+	applied, ok := resourceProvider.updated["queue"].(*schema.SQS)
+	require.True(t, ok)
+	assert.Equal(t, 30, applied.Spec.VisibilityTimeout)
+	assert.Equal(t, 86400, applied.Spec.MessageRetentionPeriod)
+
+	// and the state keeps its previous value, so the change stays ignored
+	res, ok := exec.state.GetResource("queue")
+	require.True(t, ok)
+	assert.Equal(t, float64(30), res.Attributes["visibility_timeout"])
+	assert.Equal(t, float64(86400), res.Attributes["message_retention"])
+	assert.Equal(t, map[string]interface{}{"spec.visibilityTimeout": 30}, res.Attributes[ignoredValuesAttribute])
+
+	cs, err := diff.NewDiffer(nil).ComputeChanges(&parser.ParseResult{Stack: schema.NewStack("test-stack"), Components: []schema.Resource{updated}}, exec.state, "test-stack", "default")
+	require.NoError(t, err)
+	assert.Equal(t, diff.ChangeNoChange, cs.GetChange("queue").Type)
+}
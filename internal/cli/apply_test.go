@@ -0,0 +1,28 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yourusername/panka/pkg/tenant"
+)
+
+func TestWorkloadRegion(t *testing.T) {
+	withRegion := &tenant.Tenant{}
+	withRegion.AWS.Region = "eu-west-1"
+
+	tests := []struct {
+		name   string
+		tenant *tenant.Tenant
+		want   string
+	}{
+		{"tenant region", withRegion, "eu-west-1"},
+		{"no tenant region", &tenant.Tenant{}, "us-east-1"},
+		{"no tenant config", nil, "us-east-1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, workloadRegion(tt.tenant, "us-east-1"))
+		})
+	}
+}
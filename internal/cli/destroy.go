@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
@@ -15,6 +16,10 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"github.com/yourusername/panka/internal/logger"
+	"github.com/yourusername/panka/pkg/diff"
+	"github.com/yourusername/panka/pkg/events"
+	"github.com/yourusername/panka/pkg/graph"
+	"github.com/yourusername/panka/pkg/parser"
 	"github.com/yourusername/panka/pkg/parser/schema"
 	"github.com/yourusername/panka/pkg/provider"
 	"github.com/yourusername/panka/pkg/provider/aws"
@@ -52,11 +57,30 @@ Examples:
   panka destroy ./my-stack --dry-run
   panka destroy ./my-stack --auto-approve
   panka destroy ./my-stack --force
+  panka destroy ./my-stack --env staging
+  panka destroy ./my-stack --target backend/orders-queue
+
+The preDestroy hooks of the stack and its services run after confirmation,
+before anything is deleted; a failing hook aborts the destroy. See
+'panka apply --help' for the environment of hooks.
+
+Resources with lifecycle.preventDestroy are never destroyed, even with
+--force; set preventDestroy to false and apply that first.
+
+Use --target to destroy only some components. Components that depend on a
+target are destroyed with it, since they cannot outlive their dependencies.
 
 Flags:
   --dry-run       Show what would be destroyed without doing it
   --force         Force destruction even if some resources fail
-  --auto-approve  Skip confirmation prompt`,
+  --auto-approve  Skip confirmation prompt
+  --env           Stack environment to destroy (default "default")
+  --target        Destroy only this component and its dependents (repeatable)
+  --lock-timeout  How long to wait for the state lock
+  --no-lock       Do not acquire the state lock
+  --resource-timeout  Maximum duration of deleting one resource (default 1h)
+  --output        Output format: text, or json for newline delimited JSON
+                  events on stdout`,
 	Args: cobra.ExactArgs(1),
 	RunE: runDestroy,
 }
@@ -67,6 +91,11 @@ func init() {
 	destroyCmd.Flags().BoolVar(&destroyForce, "force", false, "Force destruction even if some resources fail")
 	destroyCmd.Flags().BoolVar(&destroyDryRun, "dry-run", false, "Show what would be destroyed")
 	destroyCmd.Flags().BoolVar(&destroyAuto, "auto-approve", false, "Skip confirmation prompt")
+	addEnvFlag(destroyCmd)
+	addOutputFlag(destroyCmd)
+	addTargetFlags(destroyCmd, false)
+	addLockFlags(destroyCmd)
+	addResourceTimeoutFlag(destroyCmd)
 }
 
 func runDestroy(cmd *cobra.Command, args []string) error {
@@ -77,6 +106,16 @@ func runDestroy(cmd *cobra.Command, args []string) error {
 
 	path := args[0]
 
+	environment, err := selectedEnvironment()
+	if err != nil {
+		return err
+	}
+
+	sink, err := newEventSink()
+	if err != nil {
+		return err
+	}
+
 	// Get absolute path
 	absPath, err := filepath.Abs(path)
 	if err != nil {
@@ -95,13 +134,15 @@ func runDestroy(cmd *cobra.Command, args []string) error {
 	red.Println("\n🗑️  Panka Destroy")
 	cyan.Println(strings.Repeat("─", 60))
 	fmt.Printf("Stack Path: %s\n", absPath)
+	fmt.Printf("Environment: %s\n", environment)
 
 	if destroyDryRun {
 		yellow.Println("\n⚠️  DRY-RUN MODE - No resources will be destroyed")
 	}
 
 	log := logger.Global()
-	ctx := context.Background()
+	ctx, stop := withSignalCancel(context.Background())
+	defer stop()
 
 	// Step 1: Check authentication
 	fmt.Print("\n⏳ Checking authentication... ")
@@ -127,6 +168,15 @@ func runDestroy(cmd *cobra.Command, args []string) error {
 	green.Println("✓")
 	fmt.Printf("   Stack: %s\n", stackNameFromFolder)
 
+	// The hooks are read from the configuration, which does not need to be
+	// valid for the resources in state to be destroyed
+	fp := parser.NewFolderParser()
+	fp.SetEnvironment(environment)
+	parseResult, parseErr := fp.ParseStackFolder(absPath)
+	if parseErr != nil {
+		yellow.Printf("   ⚠️  Failed to parse the stack, preDestroy hooks will not run: %s\n", parseErr)
+	}
+
 	// Step 3: Load backend config
 	bucket := viper.GetString("backend.bucket")
 	region := viper.GetString("backend.region")
@@ -135,7 +185,15 @@ func runDestroy(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("backend.bucket and backend.region must be configured in .panka.yaml")
 	}
 
-	// Step 4: Load current state from S3
+	stackName := stackNameFromFolder
+
+	// Step 4: Acquire state lock and load current state from S3
+	ctx, releaseLock, err := acquireStackLock(ctx, session, stackName, environment)
+	if err != nil {
+		return err
+	}
+	defer releaseLock()
+
 	fmt.Print("⏳ Loading current state... ")
 
 	awsCfg, err := awsconfig.LoadDefaultConfig(ctx, awsconfig.WithRegion(region))
@@ -155,14 +213,11 @@ func runDestroy(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to create state backend: %w", err)
 	}
 
-	stackName := stackNameFromFolder
-	environment := "default"
-
 	stateKey := fmt.Sprintf("%s/%s/state.json", stackName, environment)
 	currentState, err := stateBackend.Load(ctx, stateKey)
 	if err != nil {
 		red.Println("✗")
-		return fmt.Errorf("no state found for stack '%s'. Nothing to destroy", stackName)
+		return fmt.Errorf("no state found for stack '%s' (%s). Nothing to destroy", stackName, environment)
 	}
 	green.Println("✓")
 
@@ -182,11 +237,51 @@ func runDestroy(cmd *cobra.Command, args []string) error {
 	// Get resources from state and build a destruction order
 	// We reverse the order based on dependencies stored in state
 	resources := currentState.ListResources()
-	
+
+	// Limit to the targets and everything that depends on them
+	var selected []string
+	if len(targets) > 0 {
+		stateGraph, err := graph.NewBuilder().BuildFromState(stackName, resources)
+		if err != nil {
+			red.Println("✗")
+			return fmt.Errorf("failed to build graph from state: %w", err)
+		}
+		selected, err = selectDestroyTargets(stateGraph)
+		if err != nil {
+			red.Println("✗")
+			return fmt.Errorf("failed to resolve targets: %w", err)
+		}
+
+		selectedSet := toSet(selected)
+		targeted := make([]*state.Resource, 0, len(selected))
+		for _, res := range resources {
+			if selectedSet[res.Name] {
+				targeted = append(targeted, res)
+			}
+		}
+		resources = targeted
+		resourceCount = len(resources)
+	}
+
 	// Build destruction plan (reverse dependency order)
 	destructionPlan := buildDestructionPlan(resources)
 	green.Println("✓")
 
+	// Protected resources are never destroyed, not even with --force
+	var protected []string
+	for _, res := range resources {
+		if res.PreventDestroy {
+			protected = append(protected, res.Name)
+		}
+	}
+	if len(protected) > 0 {
+		sort.Strings(protected)
+		return fmt.Errorf("refusing to destroy resources protected by lifecycle.preventDestroy: %s. Set preventDestroy to false and apply that first, or target other resources", strings.Join(protected, ", "))
+	}
+	if selected != nil {
+		printTargetSelection(selected)
+	}
+
 	// Step 6: Display destruction plan
 	displayDestructionPlan(destructionPlan, resourceCount)
 
@@ -211,6 +306,21 @@ func runDestroy(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
+	emitter := events.NewEmitter(sink, events.OperationDestroy, stackName, environment)
+
+	if parseResult != nil {
+		hookResult := &parser.ParseResult{Stack: parseResult.Stack}
+		for _, svc := range parseResult.Services {
+			if svc.Service != nil {
+				hookResult.Services = append(hookResult.Services, svc.Service)
+			}
+		}
+		stackHooks := newStackHooks(absPath, hookResult, environment, emitter)
+		if err := stackHooks.run(ctx, schema.HookPreDestroy, currentState, nil, nil); err != nil {
+			return err
+		}
+	}
+
 	// Step 7: Initialize AWS provider
 	fmt.Print("\n⏳ Initializing AWS provider... ")
 	awsProvider := aws.NewProvider()
@@ -222,15 +332,12 @@ func runDestroy(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to create tenant backend: %w", err)
 	}
 
-	tenantConfig, err := tenantBackend.LoadTenantConfig(ctx, session.Tenant.ID)
-	providerRegion := region
-	if err == nil && tenantConfig.AWS.Region != "" {
-		providerRegion = tenantConfig.AWS.Region
-	}
-
+	// Without a tenant config the resources are looked up in the backend
+	// region
+	tenantConfig, _ := tenantBackend.LoadTenantConfig(ctx, session.Tenant.ID)
 	err = awsProvider.Initialize(ctx, &provider.Config{
 		Name:   "aws",
-		Region: providerRegion,
+		Region: workloadRegion(tenantConfig, region),
 		DefaultTags: map[string]string{
 			"tenant":     session.Tenant.ID,
 			"stack":      stackName,
@@ -245,24 +352,42 @@ func runDestroy(cmd *cobra.Command, args []string) error {
 	green.Println("✓")
 
 	// Step 8: Execute destruction
-	red.Println("\n🔥 Destroying Resources")
-	cyan.Println(strings.Repeat("─", 60))
+	emitter.Emit(events.Event{Type: events.PlanComputed, Plan: destructionPlanEvent(destructionPlan)})
+	emitter.Emit(events.Event{Type: events.Started})
 
 	startTime := time.Now()
 	successCount := 0
 	failCount := 0
 	skippedCount := 0
 
+	totals := func() *events.Totals {
+		return &events.Totals{
+			Success:    failCount == 0,
+			DurationMS: time.Since(startTime).Milliseconds(),
+			Deleted:    successCount,
+			Skipped:    skippedCount,
+			Failed:     failCount,
+		}
+	}
+
 	for _, stage := range destructionPlan {
-		fmt.Printf("\n📦 Stage %d: %d resource(s)\n", stage.Number, len(stage.Resources))
+		emitter.Emit(events.Event{
+			Type:      events.StageStarted,
+			Phase:     events.PhaseDelete,
+			Stage:     stage.Number,
+			Resources: len(stage.Resources),
+		})
 
 		for _, res := range stage.Resources {
-			fmt.Printf("   Deleting [%s] %s... ", res.Type, res.Name)
-
 			// Get resource provider
 			resourceProvider, err := awsProvider.GetResourceProvider(schema.Kind(res.Type))
 			if err != nil {
-				yellow.Printf("⚠️  Skipped (no provider)\n")
+				emitter.Emit(events.Event{
+					Type:     events.ResourceSkipped,
+					Resource: res.Name,
+					Kind:     res.Type,
+					Reason:   "no provider",
+				})
 				log.Warn("No provider for resource kind",
 					zap.String("kind", res.Type),
 					zap.String("name", res.Name),
@@ -271,46 +396,81 @@ func runDestroy(cmd *cobra.Command, args []string) error {
 				continue
 			}
 
+			emitter.Emit(events.Event{
+				Type:     events.ResourceStarted,
+				Resource: res.Name,
+				Kind:     res.Type,
+				Action:   string(diff.ChangeDelete),
+				ID:       res.ID,
+			})
+			started := time.Now()
+
 			// Delete resource
 			opts := &provider.ResourceOptions{
 				TenantID:  session.Tenant.ID,
 				StackName: stackName,
+				Timeout:   resourceTimeout,
 				DryRun:    false,
 			}
 
+			// Record the delete before calling AWS, so that an interrupted
+			// destroy leaves a record of it
+			res.Status = state.ResourceStatusDeleting
+			res.UpdatedAt = time.Now()
+			checkpointDestroy(ctx, stateBackend, stateKey, currentState)
+
 			result, err := resourceProvider.Delete(ctx, res.ID, opts)
 			if err != nil {
+				failCount++
+				failed := events.Event{
+					Type:       events.ResourceFailed,
+					Resource:   res.Name,
+					Kind:       res.Type,
+					Action:     string(diff.ChangeDelete),
+					ID:         res.ID,
+					Error:      err.Error(),
+					DurationMS: time.Since(started).Milliseconds(),
+				}
+
 				if destroyForce {
-					yellow.Printf("⚠️  Failed (continuing due to --force)\n")
+					failed.Reason = "continuing due to --force"
+					emitter.Emit(failed)
 					log.Warn("Failed to delete resource, continuing",
 						zap.String("name", res.Name),
 						zap.Error(err),
 					)
-					failCount++
-				} else {
-					red.Println("✗")
-					log.Error("Failed to delete resource",
-						zap.String("name", res.Name),
-						zap.Error(err),
-					)
-					failCount++
+					continue
+				}
 
-					// Save partial state before returning error
-					currentState.Metadata.UpdatedAt = time.Now()
-					if saveErr := stateBackend.Save(ctx, stateKey, currentState); saveErr != nil {
-						yellow.Printf("⚠️  Warning: Failed to save state: %v\n", saveErr)
-					}
+				emitter.Emit(failed)
+				log.Error("Failed to delete resource",
+					zap.String("name", res.Name),
+					zap.Error(err),
+				)
 
-					return fmt.Errorf("failed to delete %s: %w. Use --force to continue on errors", res.Name, err)
-				}
-				continue
+				// Save partial state before returning error
+				currentState.Metadata.UpdatedAt = time.Now()
+				emitter.Emit(stateSavedEvent(stateBackend.Save(context.WithoutCancel(ctx), stateKey, currentState)))
+
+				err = fmt.Errorf("failed to delete %s: %w. Use --force to continue on errors", res.Name, err)
+				emitter.Emit(events.Event{Type: events.Summary, Error: err.Error(), Summary: totals()})
+				return err
 			}
 
-			green.Println("✓")
 			successCount++
 
 			// Remove from state
 			currentState.RemoveResource(res.Name)
+			checkpointDestroy(ctx, stateBackend, stateKey, currentState)
+
+			emitter.Emit(events.Event{
+				Type:       events.ResourceSucceeded,
+				Resource:   res.Name,
+				Kind:       res.Type,
+				Action:     string(diff.ChangeDelete),
+				ID:         res.ID,
+				DurationMS: time.Since(started).Milliseconds(),
+			})
 
 			log.Info("Resource deleted",
 				zap.String("name", res.Name),
@@ -320,54 +480,55 @@ func runDestroy(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	// Step 9: Save final state
-	fmt.Print("\n⏳ Saving state... ")
+	// Step 9: Save final state. The save is not cancelled with the destroy,
+	// so that the deletions done before an interrupt are recorded.
 	currentState.Metadata.UpdatedAt = time.Now()
 	currentState.Metadata.DeployedBy = "panka-cli"
 
+	saveCtx := context.WithoutCancel(ctx)
 	if currentState.ResourceCount() == 0 {
 		// All resources deleted, remove state file
-		if err := stateBackend.Delete(ctx, stateKey); err != nil {
-			yellow.Printf("⚠️  Warning: Failed to delete state file: %v\n", err)
-		} else {
-			green.Println("✓ (state file deleted)")
+		saved := stateSavedEvent(stateBackend.Delete(saveCtx, stateKey))
+		if saved.Error == "" {
+			saved.Message = "state file deleted"
 		}
+		emitter.Emit(saved)
 	} else {
-		if err := stateBackend.Save(ctx, stateKey, currentState); err != nil {
-			yellow.Printf("⚠️  Warning: Failed to save state: %v\n", err)
-		} else {
-			green.Println("✓")
-		}
+		emitter.Emit(stateSavedEvent(stateBackend.Save(saveCtx, stateKey, currentState)))
 	}
 
 	// Summary
-	duration := time.Since(startTime)
-	cyan.Println("\n" + strings.Repeat("─", 60))
-	red.Println("🗑️  Destroy Summary")
-	cyan.Println(strings.Repeat("─", 60))
+	emitter.Emit(events.Event{Type: events.Summary, Summary: totals()})
 
-	fmt.Printf("Stack:      %s\n", stackName)
-	fmt.Printf("Duration:   %s\n", duration.Round(time.Second))
-	green.Printf("Destroyed:  %d\n", successCount)
-	if failCount > 0 {
-		red.Printf("Failed:     %d\n", failCount)
-	}
-	if skippedCount > 0 {
-		yellow.Printf("Skipped:    %d\n", skippedCount)
-	}
+	return nil
+}
 
-	if failCount > 0 && !destroyForce {
-		yellow.Println("\n⚠️  Some resources failed to delete")
-		return fmt.Errorf("%d resource(s) failed to delete", failCount)
+// checkpointDestroy saves the state before and after each deletion of a
+// destroy. The save is not cancelled with the destroy, so that an
+// interrupted deletion is recorded. A failed checkpoint is logged; the state
+// is saved again at the end.
+func checkpointDestroy(ctx context.Context, backend state.Backend, key string, st *state.State) {
+	st.Metadata.UpdatedAt = time.Now()
+	if err := backend.Save(context.WithoutCancel(ctx), key, st); err != nil {
+		logger.Global().Warn("Failed to checkpoint state", zap.Error(err))
 	}
+}
 
-	if successCount == resourceCount {
-		green.Println("\n✨ All resources destroyed successfully!")
-	} else if successCount > 0 {
-		yellow.Println("\n⚠️  Partial destruction complete")
+// destructionPlanEvent describes a destruction plan for the PlanComputed event
+func destructionPlanEvent(stages []*DestructionStage) *events.Plan {
+	plan := &events.Plan{}
+	for _, stage := range stages {
+		for _, res := range stage.Resources {
+			plan.Changes = append(plan.Changes, events.PlannedChange{
+				Resource: res.Name,
+				Kind:     res.Type,
+				Action:   string(diff.ChangeDelete),
+			})
+		}
 	}
-
-	return nil
+	plan.Summary.Delete = len(plan.Changes)
+	plan.Summary.Total = len(plan.Changes)
+	return plan
 }
 
 // DestructionStage represents a stage in the destruction plan
@@ -0,0 +1,28 @@
+package cli
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourusername/panka/pkg/state"
+)
+
+func TestCheckpointDestroy_CancelledContext(t *testing.T) {
+	current := state.NewState("test-stack", "default")
+	current.AddResource("queue", &state.Resource{ID: "queue-v1", Name: "queue", Status: state.ResourceStatusReady})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// Deletions done before an interrupt are still saved
+	backend := &memoryBackend{}
+	current.RemoveResource("queue")
+	checkpointDestroy(ctx, backend, "test-stack/default", current)
+
+	saved, err := backend.Load(context.Background(), "test-stack/default")
+	require.NoError(t, err)
+	assert.Zero(t, saved.ResourceCount())
+	assert.Equal(t, 1, backend.saves)
+}
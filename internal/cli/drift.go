@@ -42,6 +42,7 @@ Drift can occur when:
 
 Examples:
   panka drift ./my-stack
+  panka drift ./my-stack --env production
   panka drift ./my-stack --output json
   panka drift ./my-stack --output table`,
 	Args: cobra.ExactArgs(1),
@@ -52,6 +53,7 @@ func init() {
 	rootCmd.AddCommand(driftCmd)
 
 	driftCmd.Flags().StringVarP(&driftOutput, "output", "o", "table", "Output format: table, json")
+	addEnvFlag(driftCmd)
 }
 
 func runDrift(cmd *cobra.Command, args []string) error {
@@ -63,6 +65,11 @@ func runDrift(cmd *cobra.Command, args []string) error {
 
 	path := args[0]
 
+	environment, err := selectedEnvironment()
+	if err != nil {
+		return err
+	}
+
 	// Get absolute path
 	absPath, err := filepath.Abs(path)
 	if err != nil {
@@ -81,6 +88,7 @@ func runDrift(cmd *cobra.Command, args []string) error {
 	cyan.Println("\n🔍 Panka Drift Detection")
 	cyan.Println(strings.Repeat("─", 60))
 	fmt.Printf("Stack Path: %s\n", absPath)
+	fmt.Printf("Environment: %s\n", environment)
 
 	log := logger.Global()
 	ctx := context.Background()
@@ -136,12 +144,11 @@ func runDrift(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to create state backend: %w", err)
 	}
 
-	environment := "default"
 	stateKey := fmt.Sprintf("%s/%s/state.json", stackName, environment)
 	currentState, err := stateBackend.Load(ctx, stateKey)
 	if err != nil {
 		red.Println("✗")
-		return fmt.Errorf("no state found for stack '%s'. Nothing to check for drift", stackName)
+		return fmt.Errorf("no state found for stack '%s' (%s). Nothing to check for drift", stackName, environment)
 	}
 	green.Println("✓")
 
@@ -0,0 +1,30 @@
+package cli
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/spf13/cobra"
+)
+
+// defaultEnvironment is used when no --env is given
+const defaultEnvironment = "default"
+
+var environmentName string
+
+var environmentNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]*$`)
+
+// addEnvFlag registers the stack environment flag on a command
+func addEnvFlag(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&environmentName, "env", defaultEnvironment, "Stack environment; selects the environments/<env>/ overlays and the state to use")
+}
+
+// selectedEnvironment returns the environment chosen with --env.
+// The name is used in state keys and folder paths, so it is restricted to
+// letters, digits, dashes and underscores.
+func selectedEnvironment() (string, error) {
+	if !environmentNamePattern.MatchString(environmentName) {
+		return "", fmt.Errorf("invalid environment name %q: use letters, digits, '-' and '_'", environmentName)
+	}
+	return environmentName, nil
+}
@@ -0,0 +1,54 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/yourusername/panka/pkg/events"
+)
+
+// Output formats selected with --output
+const (
+	outputFormatText = "text"
+	outputFormatJSON = "json"
+)
+
+var outputFormat string
+
+// eventOutput is where the JSON event stream is written. In JSON mode the
+// process stdout is reserved for the stream and everything else printed by
+// a command goes to stderr.
+var eventOutput = os.Stdout
+
+// addOutputFlag registers the output format flag on a command
+func addOutputFlag(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&outputFormat, "output", outputFormatText, "Output format: text, or json for newline delimited JSON events on stdout")
+}
+
+// reserveStdoutForEvents redirects the terminal output of a command to
+// stderr when it streams JSON events to stdout. It runs before the logger is
+// created so that log lines do not end up in the stream either.
+func reserveStdoutForEvents(cmd *cobra.Command) {
+	flag := cmd.Flags().Lookup("output")
+	if flag == nil || flag.Value.String() != outputFormatJSON {
+		return
+	}
+
+	eventOutput = os.Stdout
+	os.Stdout = os.Stderr
+	color.Output = os.Stderr
+}
+
+// newEventSink returns the sink for the output format chosen with --output
+func newEventSink() (events.Sink, error) {
+	switch outputFormat {
+	case outputFormatText:
+		return &humanSink{}, nil
+	case outputFormatJSON:
+		return events.NewJSONSink(eventOutput), nil
+	default:
+		return nil, fmt.Errorf("invalid output format %q: use text or json", outputFormat)
+	}
+}
@@ -0,0 +1,128 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/yourusername/panka/pkg/diff"
+	"github.com/yourusername/panka/pkg/events"
+	"github.com/yourusername/panka/pkg/hooks"
+	"github.com/yourusername/panka/pkg/parser"
+	"github.com/yourusername/panka/pkg/parser/schema"
+	"github.com/yourusername/panka/pkg/state"
+)
+
+// stackHooks runs the hooks declared by a stack and its services. A nil
+// stackHooks runs nothing.
+type stackHooks struct {
+	stackPath   string
+	stack       *schema.Stack
+	services    []*schema.Service
+	environment string
+	events      *events.Emitter
+}
+
+// newStackHooks returns the hooks of a parsed stack folder
+func newStackHooks(stackPath string, result *parser.ParseResult, environment string, emitter *events.Emitter) *stackHooks {
+	return &stackHooks{
+		stackPath:   stackPath,
+		stack:       result.Stack,
+		services:    result.Services,
+		environment: environment,
+		events:      emitter,
+	}
+}
+
+// run runs the hooks at a point with the outputs recorded in the state.
+// A failing prePlan, preApply or preDestroy hook aborts the operation and
+// its error is returned. A failing postApply or onFailure hook is reported
+// and the remaining hooks still run.
+func (h *stackHooks) run(ctx context.Context, point schema.HookPoint, st *state.State, changeSet *diff.ChangeSet, opErr error) error {
+	if h == nil {
+		return nil
+	}
+
+	scopes := hooks.Collect(h.stack, h.services, point)
+	if len(scopes) == 0 {
+		return nil
+	}
+
+	hc := &hooks.Context{
+		Point:       point,
+		Stack:       h.stack.Metadata.Name,
+		Environment: h.environment,
+		Outputs:     stateOutputs(st),
+		ChangeSet:   changeSet,
+	}
+	if opErr != nil {
+		hc.Error = opErr.Error()
+	}
+
+	post := point == schema.HookPostApply || point == schema.HookOnFailure
+	if post {
+		// Post hooks also run after an interrupted apply
+		ctx = context.WithoutCancel(ctx)
+	}
+
+	runner := hooks.NewRunner(h.stackPath, os.Stdout, os.Stderr)
+	for _, scope := range scopes {
+		for _, hook := range scope.Hooks {
+			event := events.Event{
+				Hook:      hooks.Name(hook),
+				HookPoint: string(point),
+				Service:   scope.Service,
+			}
+
+			event.Type = events.HookStarted
+			h.events.Emit(event)
+
+			start := time.Now()
+			err := runner.Run(ctx, scope, hook, hc)
+			event.DurationMS = time.Since(start).Milliseconds()
+
+			if err != nil {
+				event.Type = events.HookFailed
+				event.Error = err.Error()
+				h.events.Emit(event)
+				if post {
+					continue
+				}
+				return fmt.Errorf("aborted by %s hook: %w", point, err)
+			}
+
+			event.Type = events.HookSucceeded
+			h.events.Emit(event)
+		}
+	}
+
+	return nil
+}
+
+// executeApplyWithHooks runs the preApply hooks and applies. Afterwards it
+// runs the postApply hooks, or the onFailure hooks if the apply failed.
+func executeApplyWithHooks(ctx context.Context, run *applyRun) error {
+	if err := run.hooks.run(ctx, schema.HookPreApply, run.state, run.changeSet, nil); err != nil {
+		return err
+	}
+
+	if err := executeApply(ctx, run); err != nil {
+		run.hooks.run(ctx, schema.HookOnFailure, run.state, run.changeSet, err)
+		return err
+	}
+
+	return run.hooks.run(ctx, schema.HookPostApply, run.state, run.changeSet, nil)
+}
+
+// stateOutputs returns the stack and service outputs recorded in a state
+func stateOutputs(st *state.State) map[string]string {
+	if st == nil || len(st.Outputs) == 0 {
+		return nil
+	}
+	outputs := make(map[string]string, len(st.Outputs))
+	for name, value := range st.Outputs {
+		outputs[name] = fmt.Sprint(value)
+	}
+	return outputs
+}
@@ -0,0 +1,182 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/yourusername/panka/pkg/parser/schema"
+	"github.com/yourusername/panka/pkg/provider/aws"
+	"github.com/yourusername/panka/pkg/state"
+)
+
+var (
+	jobStack             string
+	jobConcurrencyPolicy string
+	jobTimeout           time.Duration
+)
+
+// jobCmd represents the job command
+var jobCmd = &cobra.Command{
+	Use:   "job",
+	Short: "Manage cron jobs",
+	Long: `Manage the cron jobs of a deployed stack.
+
+Cron jobs run on their schedule once the stack is applied. These commands
+act on the cron jobs recorded in the stack's state.`,
+}
+
+// jobRunCmd represents the job run command
+var jobRunCmd = &cobra.Command{
+	Use:   "run <cronjob>",
+	Short: "Run a cron job once",
+	Long: `Run a cron job once, outside of its schedule, and wait until it finishes.
+
+The job runs the task definition of its last apply in the tenant's cluster
+and private subnets. The concurrency policy decides what happens when a run
+is still in progress: Allow starts another run, Forbid refuses to start and
+Replace stops the running one first. A run that exceeds the timeout is
+stopped.
+
+The command exits with an error when the job's container exits with a
+non-zero code.
+
+Examples:
+  panka job run nightly-report --stack my-stack
+  panka job run nightly-report --stack my-stack --env production
+  panka job run nightly-report --stack my-stack --concurrency-policy Forbid --timeout 30m`,
+	Args: cobra.ExactArgs(1),
+	RunE: runJobRun,
+}
+
+func init() {
+	rootCmd.AddCommand(jobCmd)
+	jobCmd.AddCommand(jobRunCmd)
+
+	jobRunCmd.Flags().StringVar(&jobStack, "stack", "", "Stack name (required)")
+	jobRunCmd.MarkFlagRequired("stack")
+	jobRunCmd.Flags().StringVar(&jobConcurrencyPolicy, "concurrency-policy", schema.ConcurrencyAllow, "What to do when the job is already running: Allow, Forbid or Replace")
+	jobRunCmd.Flags().DurationVar(&jobTimeout, "timeout", 0, "Stop the run after this long (default: wait until it finishes)")
+	addEnvFlag(jobRunCmd)
+}
+
+func runJobRun(cmd *cobra.Command, args []string) error {
+	green := color.New(color.FgGreen, color.Bold)
+	red := color.New(color.FgRed, color.Bold)
+	cyan := color.New(color.FgCyan, color.Bold)
+
+	name := args[0]
+
+	switch jobConcurrencyPolicy {
+	case schema.ConcurrencyAllow, schema.ConcurrencyForbid, schema.ConcurrencyReplace:
+	default:
+		return fmt.Errorf("--concurrency-policy must be Allow, Forbid or Replace")
+	}
+
+	environment, err := selectedEnvironment()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := withSignalCancel(context.Background())
+	defer cancel()
+
+	cyan.Println("Cron Job Run")
+	fmt.Println(strings.Repeat("─", 60))
+	fmt.Printf("Stack: %s (%s)\n", jobStack, environment)
+	fmt.Printf("Job:   %s\n", name)
+
+	session, err := checkTenantSession()
+	if err != nil {
+		return err
+	}
+
+	tenantConfig, bucket, region, err := loadTenantConfig(ctx, session)
+	if err != nil {
+		return err
+	}
+
+	fmt.Print("⏳ Loading state... ")
+	stateBackend, err := openStateBackend(ctx, session.Tenant.ID, bucket, region)
+	if err != nil {
+		red.Println("✗")
+		return err
+	}
+	stateKey := fmt.Sprintf("%s/%s/state.json", jobStack, environment)
+	currentState, err := stateBackend.Load(ctx, stateKey)
+	if err != nil {
+		red.Println("✗")
+		return fmt.Errorf("failed to load state for stack '%s' (%s): %w", jobStack, environment, err)
+	}
+	job, err := findCronJob(currentState, name)
+	if err != nil {
+		red.Println("✗")
+		return err
+	}
+	green.Println("✓")
+
+	awsProvider, err := initAWSProvider(ctx, tenantConfig, region, session.Tenant.ID, jobStack)
+	if err != nil {
+		return err
+	}
+	defer awsProvider.Close()
+
+	runOpts := aws.JobRunOptions{
+		TaskDefinitionARN: cronJobTaskDefinition(job),
+		ConcurrencyPolicy: jobConcurrencyPolicy,
+		Timeout:           jobTimeout,
+	}
+	if runOpts.Timeout > 0 {
+		fmt.Printf("   Timeout: %s\n", runOpts.Timeout)
+	}
+
+	fmt.Printf("\n⏳ Running %s... ", name)
+	run, err := aws.NewCronJobProvider(awsProvider).Run(ctx, job.ID, runOpts)
+	if err != nil {
+		red.Println("✗")
+		return fmt.Errorf("failed to run cron job: %w", err)
+	}
+
+	if run.ExitCode == 0 && !run.TimedOut {
+		green.Println("✓")
+	} else {
+		red.Println("✗")
+	}
+
+	fmt.Printf("   Task:      %s\n", run.TaskARN)
+	fmt.Printf("   Duration:  %s\n", run.Duration.Round(time.Second))
+	fmt.Printf("   Exit code: %d\n", run.ExitCode)
+	if run.Reason != "" {
+		fmt.Printf("   Reason:    %s\n", run.Reason)
+	}
+
+	if run.TimedOut {
+		return fmt.Errorf("cron job %s timed out after %s", name, runOpts.Timeout)
+	}
+	if run.ExitCode != 0 {
+		return fmt.Errorf("cron job %s exited with code %d", name, run.ExitCode)
+	}
+	return nil
+}
+
+// findCronJob returns the cron job of the given name from a stack's state
+func findCronJob(st *state.State, name string) (*state.Resource, error) {
+	res, ok := st.GetResource(name)
+	if !ok {
+		return nil, fmt.Errorf("cron job '%s' not found in state, apply the stack first", name)
+	}
+	if res.Type != string(schema.KindCronJob) {
+		return nil, fmt.Errorf("'%s' is a %s, not a cron job", name, res.Type)
+	}
+	return res, nil
+}
+
+// cronJobTaskDefinition returns the task definition recorded in a cron
+// job's state
+func cronJobTaskDefinition(res *state.Resource) string {
+	arn, _ := res.Attributes["task_definition_arn"].(string)
+	return arn
+}
@@ -0,0 +1,160 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/yourusername/panka/internal/logger"
+	"github.com/yourusername/panka/pkg/lock"
+	"github.com/yourusername/panka/pkg/tenant"
+	"go.uber.org/zap"
+)
+
+var (
+	lockTimeout time.Duration
+	noLock      bool
+)
+
+// addLockFlags registers the state locking flags on a command
+func addLockFlags(cmd *cobra.Command) {
+	cmd.Flags().DurationVar(&lockTimeout, "lock-timeout", 0, "How long to wait for the state lock (e.g. 30s, 5m)")
+	cmd.Flags().BoolVar(&noLock, "no-lock", false, "Do not acquire the state lock (dangerous)")
+}
+
+// withSignalCancel returns a context that is cancelled on SIGINT/SIGTERM
+// so that deferred lock releases still run when the user interrupts
+func withSignalCancel(ctx context.Context) (context.Context, context.CancelFunc) {
+	return signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+}
+
+// stackLockKey returns the lock key guarding a stack environment's state
+func stackLockKey(stackName, environment string) string {
+	return fmt.Sprintf("stacks/%s/%s", stackName, environment)
+}
+
+// acquireStackLock acquires the distributed lock for a stack environment.
+// The returned context is cancelled if the lock is lost while held, and
+// the returned release function must be called when the operation ends.
+func acquireStackLock(ctx context.Context, session *tenant.Session, stackName, environment string) (context.Context, func(), error) {
+	green := color.New(color.FgGreen, color.Bold)
+	red := color.New(color.FgRed, color.Bold)
+	yellow := color.New(color.FgYellow)
+
+	if noLock {
+		yellow.Println("⚠️  State locking disabled (--no-lock)")
+		return ctx, func() {}, nil
+	}
+
+	fmt.Print("⏳ Acquiring state lock... ")
+
+	table, region := lockTableConfig(session)
+	if table == "" {
+		red.Println("✗")
+		return nil, nil, fmt.Errorf("no lock table configured. Log in again or set backend.dynamodb_table, or use --no-lock")
+	}
+
+	awsCfg, err := awsconfig.LoadDefaultConfig(ctx, awsconfig.WithRegion(region))
+	if err != nil {
+		red.Println("✗")
+		return nil, nil, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+
+	log := logger.Global()
+	config := lock.DefaultConfig()
+	dynamoMgr, err := lock.NewDynamoDBManager(&lock.DynamoDBConfig{
+		Client:    dynamodb.NewFromConfig(awsCfg),
+		TableName: table,
+		Logger:    log.Desugar(),
+		Config:    config,
+	})
+	if err != nil {
+		red.Println("✗")
+		return nil, nil, fmt.Errorf("failed to create lock manager: %w", err)
+	}
+
+	lockCtx := tenant.WithTenant(ctx, &tenant.TenantContext{
+		TenantID:   session.Tenant.ID,
+		LockPrefix: lockPrefix(session),
+		Enabled:    true,
+	})
+
+	holder := lock.NewHolder(lock.NewTenantAwareManager(dynamoMgr), config)
+	key := stackLockKey(stackName, environment)
+	if err := holder.Acquire(lockCtx, key, lockOwner(), lockTimeout); err != nil {
+		red.Println("✗")
+		return nil, nil, fmt.Errorf("failed to acquire state lock: %w", err)
+	}
+	green.Println("✓")
+	fmt.Printf("   Lock: %s\n", holder.Lock().Key)
+
+	opCtx, cancel := context.WithCancel(ctx)
+	go func() {
+		select {
+		case <-holder.Lost():
+			log.Error("State lock lost, aborting operation",
+				zap.String("key", key),
+				zap.Error(holder.Err()),
+			)
+			cancel()
+		case <-opCtx.Done():
+		}
+	}()
+
+	release := func() {
+		cancel()
+		// Use a fresh context: the operation context may already be cancelled
+		releaseCtx, releaseCancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer releaseCancel()
+		if err := holder.Release(releaseCtx); err != nil {
+			yellow.Printf("⚠️  Warning: Failed to release state lock: %v\n", err)
+			log.Warn("Failed to release state lock", zap.String("key", key), zap.Error(err))
+		}
+	}
+
+	return opCtx, release, nil
+}
+
+// lockTableConfig returns the DynamoDB lock table and region for a session
+func lockTableConfig(session *tenant.Session) (string, string) {
+	table := viper.GetString("backend.dynamodb_table")
+	region := viper.GetString("backend.region")
+	if session.Locks != nil {
+		if session.Locks.Table != "" {
+			table = session.Locks.Table
+		}
+		if session.Locks.Region != "" {
+			region = session.Locks.Region
+		}
+	}
+	return table, region
+}
+
+// lockPrefix returns the tenant lock prefix, defaulting to tenant:<id>
+func lockPrefix(session *tenant.Session) string {
+	if session.Locks != nil && session.Locks.Prefix != "" {
+		return session.Locks.Prefix
+	}
+	return fmt.Sprintf("tenant:%s", session.Tenant.ID)
+}
+
+// lockOwner identifies this process as the lock owner
+func lockOwner() string {
+	user := os.Getenv("USER")
+	if user == "" {
+		user = "unknown"
+	}
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	return fmt.Sprintf("%s@%s:%d", user, host, os.Getpid())
+}
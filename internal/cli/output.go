@@ -0,0 +1,135 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/yourusername/panka/pkg/tenant"
+)
+
+var (
+	outputStack string
+	outputJSON  bool
+)
+
+// outputCmd represents the output command
+var outputCmd = &cobra.Command{
+	Use:   "output [name]",
+	Short: "Show stack outputs",
+	Long: `Show the outputs of a deployed stack.
+
+Outputs are declared in the outputs section of stack.yaml and service.yaml
+and reference component outputs, for example:
+
+  spec:
+    outputs:
+      queueUrl: ${jobs.queue_url}
+
+They are evaluated and saved to the state by 'panka apply'. Outputs
+declared by a service are named <service>.<output>.
+
+With a name, only the value of that output is printed, which makes it easy
+to consume from scripts and pipelines. --json prints JSON instead.
+
+Examples:
+  panka output --stack my-stack
+  panka output queueUrl --stack my-stack --env production
+  panka output --stack my-stack --json`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runOutput,
+}
+
+func init() {
+	rootCmd.AddCommand(outputCmd)
+
+	outputCmd.Flags().StringVar(&outputStack, "stack", "", "Stack name (required)")
+	outputCmd.Flags().BoolVar(&outputJSON, "json", false, "Print outputs as JSON")
+	outputCmd.MarkFlagRequired("stack")
+	addEnvFlag(outputCmd)
+}
+
+func runOutput(cmd *cobra.Command, args []string) error {
+	environment, err := selectedEnvironment()
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+
+	// Nothing but the outputs is written to stdout, so that the result can
+	// be piped into other tools
+	session, err := tenant.NewSessionManager().LoadSession()
+	if err != nil || session.Mode != tenant.ModeTenant || session.Tenant == nil {
+		return fmt.Errorf("not logged in as tenant. Run 'panka login' first")
+	}
+
+	bucket := viper.GetString("backend.bucket")
+	region := viper.GetString("backend.region")
+	if bucket == "" || region == "" {
+		return fmt.Errorf("backend.bucket and backend.region must be configured in .panka.yaml")
+	}
+
+	stateBackend, err := openStateBackend(ctx, session.Tenant.ID, bucket, region)
+	if err != nil {
+		return err
+	}
+
+	stateKey := fmt.Sprintf("%s/%s/state.json", outputStack, environment)
+	currentState, err := stateBackend.Load(ctx, stateKey)
+	if err != nil {
+		return fmt.Errorf("failed to load state for stack '%s' (%s): %w", outputStack, environment, err)
+	}
+
+	if len(args) == 1 {
+		name := args[0]
+		value, ok := currentState.GetOutput(name)
+		if !ok {
+			return fmt.Errorf("output '%s' not found for stack '%s' (%s)", name, outputStack, environment)
+		}
+		if outputJSON {
+			return printJSON(value)
+		}
+		fmt.Println(value)
+		return nil
+	}
+
+	if outputJSON {
+		outputs := currentState.Outputs
+		if outputs == nil {
+			outputs = map[string]interface{}{}
+		}
+		return printJSON(outputs)
+	}
+
+	if len(currentState.Outputs) == 0 {
+		fmt.Fprintf(os.Stderr, "No outputs for stack '%s' (%s)\n", outputStack, environment)
+		return nil
+	}
+
+	names := make([]string, 0, len(currentState.Outputs))
+	for name := range currentState.Outputs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		fmt.Printf("%s = %v\n", name, currentState.Outputs[name])
+	}
+
+	return nil
+}
+
+// printJSON writes a value to stdout as indented JSON
+func printJSON(value interface{}) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(value); err != nil {
+		return fmt.Errorf("failed to encode JSON: %w", err)
+	}
+	return nil
+}
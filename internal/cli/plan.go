@@ -11,6 +11,7 @@ import (
 	"github.com/yourusername/panka/internal/logger"
 	"github.com/yourusername/panka/pkg/graph"
 	"github.com/yourusername/panka/pkg/parser"
+	"github.com/yourusername/panka/pkg/planfile"
 	"go.uber.org/zap"
 )
 
@@ -38,9 +39,22 @@ The plan command:
 
 No actual changes are made - this is a dry-run to preview actions.
 
+Use --out to save the plan of a stack folder to a file. The saved plan
+includes the changes against the current state, and 'panka apply <file>'
+executes exactly those changes. Saving a plan requires a tenant login.
+
+Use --env to plan an environment; the YAML documents in environments/<env>/
+are merged over the base configuration of a stack folder.
+
+Use --target to plan only some components together with their
+dependencies (and, with --target-dependents, their consumers).
+
 Examples:
   panka plan ./my-stack
   panka plan ./my-stack --detailed
+  panka plan ./my-stack --env staging
+  panka plan ./my-stack --target backend/api-server
+  panka plan ./my-stack --env production --out plan.panka
   panka plan infrastructure.yaml`,
 	Args: cobra.ExactArgs(1),
 	RunE: runPlan,
@@ -50,7 +64,9 @@ func init() {
 	rootCmd.AddCommand(planCmd)
 
 	planCmd.Flags().BoolVarP(&planDetailed, "detailed", "d", false, "show detailed resource information")
-	planCmd.Flags().StringVarP(&planFile, "out", "o", "", "write plan to file")
+	planCmd.Flags().StringVarP(&planFile, "out", "o", "", "save the plan to a file for 'panka apply'")
+	addEnvFlag(planCmd)
+	addTargetFlags(planCmd, true)
 }
 
 func runPlan(cmd *cobra.Command, args []string) error {
@@ -60,6 +76,11 @@ func runPlan(cmd *cobra.Command, args []string) error {
 
 	path := args[0]
 
+	environment, err := selectedEnvironment()
+	if err != nil {
+		return err
+	}
+
 	// Get absolute path
 	absPath, err := filepath.Abs(path)
 	if err != nil {
@@ -76,14 +97,29 @@ func runPlan(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("path not found: %s", absPath)
 	}
 
+	// A saved plan records the hash of the files it was generated from,
+	// taken before parsing so that any later edit invalidates the plan
+	var sourceHash string
+	if planFile != "" {
+		if !info.IsDir() {
+			return fmt.Errorf("--out requires a stack folder, not a file: %s", absPath)
+		}
+		sourceHash, err = planfile.SourceHash(absPath)
+		if err != nil {
+			return fmt.Errorf("failed to hash stack files: %w", err)
+		}
+	}
+
 	var result *parser.ParseResult
+	var folderResult *parser.StackParseResult
 
 	// Step 1: Parse configuration
 	fmt.Print("🔍 Parsing configuration... ")
 	if info.IsDir() {
 		// Parse as stack folder
 		fp := parser.NewFolderParser()
-		folderResult, err := fp.ParseStackFolder(absPath)
+		fp.SetEnvironment(environment)
+		folderResult, err = fp.ParseStackFolder(absPath)
 		if err != nil {
 			return fmt.Errorf("failed to parse stack folder: %w", err)
 		}
@@ -107,6 +143,11 @@ func runPlan(cmd *cobra.Command, args []string) error {
 		}
 	}
 	green.Println("✓")
+	if folderResult != nil {
+		for _, w := range folderResult.Warnings {
+			yellow.Printf("   ⚠️  %s\n", w)
+		}
+	}
 
 	if result.Stack == nil {
 		return fmt.Errorf("no Stack definition found in configuration")
@@ -144,6 +185,15 @@ func runPlan(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("circular dependency detected - cannot generate plan")
 	}
 
+	selected, err := selectTargets(g)
+	if err != nil {
+		return fmt.Errorf("failed to resolve targets: %w", err)
+	}
+	if selected != nil {
+		g = g.Subgraph(selected)
+		printTargetSelection(selected)
+	}
+
 	// Step 4: Generate deployment plan
 	fmt.Print("📊 Generating deployment plan... ")
 	planner := graph.NewPlanner()
@@ -151,7 +201,7 @@ func runPlan(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return fmt.Errorf("failed to generate plan: %w", err)
 	}
-	green.Println("✓\n")
+	green.Print("✓\n\n")
 
 	// Display plan
 	displayPlan(plan, result, planDetailed)
@@ -162,7 +212,8 @@ func runPlan(cmd *cobra.Command, args []string) error {
 	cyan.Println("==================================================")
 
 	fmt.Printf("\nStack:      %s\n", result.Stack.Metadata.Name)
-	fmt.Printf("Resources:  %d\n", len(result.Components))
+	fmt.Printf("Env:        %s\n", environment)
+	fmt.Printf("Resources:  %d\n", plan.TotalResources)
 	fmt.Printf("Stages:     %d\n", plan.TotalStages)
 
 	// Estimate duration
@@ -170,6 +221,18 @@ func runPlan(cmd *cobra.Command, args []string) error {
 	fmt.Printf("Estimated Duration: ~%d minutes\n", estimatedMinutes)
 
 	cyan.Println("\n==================================================")
+
+	if planFile != "" {
+		fmt.Println()
+		if err := savePlanFile(planFile, absPath, sourceHash, folderResult, selected, plan, environment); err != nil {
+			return fmt.Errorf("failed to save plan: %w", err)
+		}
+		yellow.Printf("\n⚠️  Plan saved to %s. No resources were changed.\n", planFile)
+		fmt.Printf("   Run 'panka apply %s' to execute exactly this plan.\n", planFile)
+		green.Println("\n✨ Plan generation complete!")
+		return nil
+	}
+
 	yellow.Println("\n⚠️  This is a plan preview. No resources will be created.")
 	fmt.Println("   Run 'panka apply' to execute this plan.")
 	green.Println("\n✨ Plan generation complete!")
@@ -185,6 +248,11 @@ func displayPlan(plan *graph.DeploymentPlan, result *parser.ParseResult, detaile
 	cyan.Println("🚀 Deployment Plan:")
 	cyan.Println(strings.Repeat("─", 50))
 
+	components := make(map[string]bool, len(result.Components))
+	for _, component := range result.Components {
+		components[component.GetMetadata().Name] = true
+	}
+
 	for _, stage := range plan.Stages {
 		fmt.Printf("\n")
 		magenta.Printf("Stage %d", stage.Number)
@@ -214,7 +282,31 @@ func displayPlan(plan *graph.DeploymentPlan, result *parser.ParseResult, detaile
 					}
 				}
 			}
+
+			displayKnownAfterApply(res, components)
+		}
+	}
+}
+
+// displayKnownAfterApply lists the values of a resource that reference
+// outputs of other components, which are only known once those are created
+func displayKnownAfterApply(res *graph.DeploymentResource, components map[string]bool) {
+	if res.Resource == nil {
+		return
+	}
+
+	refs, err := parser.OutputReferences(res.Resource)
+	if err != nil {
+		return
+	}
+
+	dim := color.New(color.Faint)
+	for _, ref := range refs {
+		if !components[ref.Component] {
+			continue
 		}
+		fmt.Printf("      %s: ", ref.Path)
+		dim.Printf("%s from %s\n", parser.KnownAfterApply, ref)
 	}
 }
 
@@ -0,0 +1,289 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/yourusername/panka/pkg/diff"
+	"github.com/yourusername/panka/pkg/events"
+	"github.com/yourusername/panka/pkg/graph"
+	"github.com/yourusername/panka/pkg/parser"
+	"github.com/yourusername/panka/pkg/planfile"
+	"github.com/yourusername/panka/pkg/state"
+)
+
+// savePlanFile computes the changes of a plan against the current state of
+// the stack and writes both to a plan file for 'panka apply'
+func savePlanFile(outPath, stackPath, sourceHash string, parseResult *parser.StackParseResult, selected []string, plan *graph.DeploymentPlan, environment string) error {
+	green := color.New(color.FgGreen, color.Bold)
+	red := color.New(color.FgRed, color.Bold)
+
+	ctx, stop := withSignalCancel(context.Background())
+	defer stop()
+
+	session, err := checkTenantSession()
+	if err != nil {
+		return err
+	}
+
+	bucket := viper.GetString("backend.bucket")
+	region := viper.GetString("backend.region")
+	if bucket == "" || region == "" {
+		return fmt.Errorf("backend.bucket and backend.region must be configured in .panka.yaml")
+	}
+
+	stackName := parseResult.Stack.Metadata.Name
+
+	fmt.Print("⏳ Loading current state... ")
+	stateBackend, err := openStateBackend(ctx, session.Tenant.ID, bucket, region)
+	if err != nil {
+		red.Println("✗")
+		return err
+	}
+
+	// Read the version before the state so that a concurrent save is
+	// detected by apply rather than silently included in the plan
+	stateKey := fmt.Sprintf("%s/%s/state.json", stackName, environment)
+	stateVersion, err := stateBackend.CurrentVersion(ctx, stateKey)
+	if err != nil {
+		red.Println("✗")
+		return err
+	}
+
+	currentState := state.NewState(stackName, environment)
+	currentState.Metadata.Tenant = session.Tenant.ID
+	if stateVersion != "" {
+		currentState, err = stateBackend.Load(ctx, stateKey)
+		if err != nil {
+			red.Println("✗")
+			return fmt.Errorf("failed to load state: %w", err)
+		}
+	}
+	green.Println("✓")
+
+	if len(currentState.InProgressResources()) > 0 {
+		return fmt.Errorf("the state has resources left in progress by an interrupted apply; run 'panka apply' to reconcile them first")
+	}
+
+	fmt.Print("⏳ Computing changes... ")
+	changeSet, err := diff.NewDiffer(nil).ComputeChangesFromFolderParse(parseResult, currentState)
+	if err != nil {
+		red.Println("✗")
+		return fmt.Errorf("failed to compute changes: %w", err)
+	}
+	changeSet.TenantID = session.Tenant.ID
+	if selected != nil {
+		selectedSet := toSet(selected)
+		changeSet = changeSet.Filter(func(change *diff.Change) bool {
+			return selectedSet[change.ResourceName]
+		})
+	}
+	green.Println("✓")
+
+	secretsRegion, err := loadWorkloadRegion(ctx, session, bucket, region)
+	if err != nil {
+		return err
+	}
+	if err := checkSecrets(ctx, secretsRegion, changeSet); err != nil {
+		return err
+	}
+
+	diff.PrintDiff(changeSet)
+
+	if err := checkPreventDestroy(changeSet); err != nil {
+		return err
+	}
+
+	fmt.Print("\n⏳ Writing plan file... ")
+	err = planfile.Write(outPath, &planfile.PlanFile{
+		CreatedAt:        time.Now().UTC(),
+		TenantID:         session.Tenant.ID,
+		StackName:        stackName,
+		Environment:      environment,
+		StackPath:        stackPath,
+		SourceHash:       sourceHash,
+		StateVersionID:   stateVersion,
+		Targets:          targets,
+		TargetDependents: targetDependents,
+		Plan:             plan,
+		ChangeSet:        changeSet,
+	})
+	if err != nil {
+		red.Println("✗")
+		return err
+	}
+	green.Println("✓")
+
+	return nil
+}
+
+// runApplyPlanFile executes a plan file written by 'panka plan --out'.
+// The saved plan is refused if the tenant, the stack files or the state
+// have changed since it was generated.
+func runApplyPlanFile(cmd *cobra.Command, planPath string) error {
+	green := color.New(color.FgGreen, color.Bold)
+	cyan := color.New(color.FgCyan, color.Bold)
+	red := color.New(color.FgRed, color.Bold)
+	yellow := color.New(color.FgYellow)
+
+	for _, name := range []string{"env", "target", "target-dependents"} {
+		if cmd.Flags().Changed(name) {
+			return fmt.Errorf("--%s cannot be used with a plan file; the plan already records it", name)
+		}
+	}
+
+	sink, err := newEventSink()
+	if err != nil {
+		return err
+	}
+
+	pf, err := planfile.Read(planPath)
+	if err != nil {
+		return err
+	}
+
+	cyan.Println("\n🚀 Panka Apply")
+	cyan.Println(strings.Repeat("─", 60))
+	fmt.Printf("Plan File: %s\n", planPath)
+	fmt.Printf("Planned At: %s\n", pf.CreatedAt.Local().Format(time.RFC1123))
+	fmt.Printf("Stack Path: %s\n", pf.StackPath)
+	fmt.Printf("Environment: %s\n", pf.Environment)
+	if len(pf.Targets) > 0 {
+		fmt.Printf("Targets: %s\n", strings.Join(pf.Targets, ", "))
+	}
+
+	ctx, stop := withSignalCancel(context.Background())
+	defer stop()
+
+	// Step 1: Check authentication
+	session, err := checkTenantSession()
+	if err != nil {
+		return err
+	}
+	if session.Tenant.ID != pf.TenantID {
+		return fmt.Errorf("plan was generated for tenant %s, but you are logged in as %s", pf.TenantID, session.Tenant.ID)
+	}
+
+	// Step 2: Verify the stack files are unchanged
+	fmt.Print("⏳ Verifying stack files... ")
+	sourceHash, err := planfile.SourceHash(pf.StackPath)
+	if err != nil {
+		red.Println("✗")
+		return err
+	}
+	if sourceHash != pf.SourceHash {
+		red.Println("✗")
+		return fmt.Errorf("stack files have changed since the plan was generated; run 'panka plan' again")
+	}
+	green.Println("✓")
+
+	// Step 3: Parse and validate the planned configuration
+	fmt.Print("⏳ Parsing stack configuration... ")
+	fp := parser.NewFolderParser()
+	fp.SetEnvironment(pf.Environment)
+	parseResult, err := fp.ParseStackFolder(pf.StackPath)
+	if err != nil {
+		red.Println("✗")
+		return fmt.Errorf("failed to parse stack: %w", err)
+	}
+	green.Println("✓")
+
+	stackName := parseResult.Stack.Metadata.Name
+	if stackName != pf.StackName {
+		return fmt.Errorf("plan is for stack %s, but the stack folder defines %s", pf.StackName, stackName)
+	}
+
+	validationResult, err := validateStack(parseResult)
+	if err != nil {
+		return err
+	}
+	if err := pf.AttachResources(parseResult.AllComponents); err != nil {
+		return fmt.Errorf("failed to load plan: %w", err)
+	}
+
+	// Step 4: Load tenant configuration (for networking)
+	tenantConfig, bucket, region, err := loadTenantConfig(ctx, session)
+	if err != nil {
+		return err
+	}
+
+	// Step 5: Acquire state lock and verify the state is unchanged
+	ctx, releaseLock, err := acquireStackLock(ctx, session, stackName, pf.Environment)
+	if err != nil {
+		return err
+	}
+	defer releaseLock()
+
+	fmt.Print("⏳ Loading current state... ")
+	stateBackend, err := openStateBackend(ctx, session.Tenant.ID, bucket, region)
+	if err != nil {
+		red.Println("✗")
+		return err
+	}
+
+	stateKey := fmt.Sprintf("%s/%s/state.json", stackName, pf.Environment)
+	stateVersion, err := stateBackend.CurrentVersion(ctx, stateKey)
+	if err != nil {
+		red.Println("✗")
+		return err
+	}
+	if stateVersion != pf.StateVersionID {
+		red.Println("✗")
+		return fmt.Errorf("stack state has changed since the plan was generated; run 'panka plan' again")
+	}
+
+	currentState := state.NewState(stackName, pf.Environment)
+	currentState.Metadata.Tenant = session.Tenant.ID
+	if stateVersion != "" {
+		currentState, err = stateBackend.Load(ctx, stateKey)
+		if err != nil {
+			red.Println("✗")
+			return fmt.Errorf("failed to load state: %w", err)
+		}
+	}
+	green.Println("✓")
+
+	// Display the saved changes
+	emitter := events.NewEmitter(sink, events.OperationApply, stackName, pf.Environment)
+	emitter.Emit(events.Event{
+		Type:      events.PlanComputed,
+		Plan:      events.NewPlan(pf.ChangeSet),
+		ChangeSet: pf.ChangeSet,
+	})
+
+	if err := checkPreventDestroy(pf.ChangeSet); err != nil {
+		return err
+	}
+
+	if !pf.ChangeSet.HasChanges() {
+		green.Println("\n✨ No changes to apply. Infrastructure is up-to-date!")
+		emitter.Emit(noChangesSummary(pf.ChangeSet))
+		return nil
+	}
+
+	if applyDryRun {
+		yellow.Println("\n⚠️  Dry-run mode - no changes will be made")
+		return nil
+	}
+
+	return executeApplyWithHooks(ctx, &applyRun{
+		session:      session,
+		tenantConfig: tenantConfig,
+		region:       region,
+		stackName:    stackName,
+		environment:  pf.Environment,
+		state:        currentState,
+		stateBackend: stateBackend,
+		stateKey:     stateKey,
+		plan:         pf.Plan,
+		changeSet:    pf.ChangeSet,
+		outputs:      parser.StackOutputs(validationResult.Stack, validationResult.Services),
+		events:       emitter,
+		hooks:        newStackHooks(pf.StackPath, validationResult, pf.Environment, emitter),
+	})
+}
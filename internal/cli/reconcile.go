@@ -0,0 +1,90 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/yourusername/panka/pkg/parser/schema"
+	"github.com/yourusername/panka/pkg/provider"
+	"github.com/yourusername/panka/pkg/provider/aws"
+	"github.com/yourusername/panka/pkg/state"
+)
+
+// reconcileInterrupted resolves the resources left in progress by an
+// interrupted apply, using the provider to find out what happened:
+//   - a resource that does not exist is removed from the state, so that it
+//     is created again if it is still configured
+//   - an interrupted create that reached AWS is adopted
+//   - an interrupted update or delete is marked ready; the changes are then
+//     computed again against the configuration
+//
+// The state is saved afterwards. The caller holds the stack lock.
+func reconcileInterrupted(ctx context.Context, awsProvider *aws.Provider, currentState *state.State, stateBackend state.Backend, stateKey, tenantID, stackName string) error {
+	green := color.New(color.FgGreen)
+	yellow := color.New(color.FgYellow)
+
+	interrupted := currentState.InProgressResources()
+	if len(interrupted) == 0 {
+		return nil
+	}
+
+	yellow.Printf("\n⚠️  Found %d resource(s) left in progress by an interrupted apply\n", len(interrupted))
+
+	opts := &provider.ResourceOptions{
+		TenantID:  tenantID,
+		StackName: stackName,
+	}
+
+	for _, res := range interrupted {
+		fmt.Printf("   ↻ [%s] %s (%s)... ", res.Type, res.Name, res.Status)
+
+		resourceProvider, err := awsProvider.GetResourceProvider(schema.Kind(res.Type))
+		if err != nil {
+			yellow.Println("⚠️  Skipped (no provider)")
+			continue
+		}
+
+		exists := false
+		if res.ID != "" {
+			exists, err = resourceProvider.Exists(ctx, res.ID, opts)
+			if err != nil {
+				return fmt.Errorf("failed to check resource %s: %w", res.Name, err)
+			}
+		}
+
+		if !exists {
+			currentState.RemoveResource(res.Name)
+			green.Println("not found, removed from state")
+			continue
+		}
+
+		if res.Status == state.ResourceStatusCreating {
+			result, err := resourceProvider.Read(ctx, res.ID, opts)
+			if err != nil {
+				return fmt.Errorf("failed to read resource %s: %w", res.Name, err)
+			}
+			if result.ResourceID != "" {
+				res.ID = result.ResourceID
+			}
+			if res.Attributes == nil {
+				res.Attributes = make(map[string]interface{})
+			}
+			for k, v := range result.Outputs {
+				res.Attributes[k] = v
+			}
+		}
+
+		res.Status = state.ResourceStatusReady
+		res.UpdatedAt = time.Now()
+		currentState.AddResource(res.Name, res)
+		green.Println("exists, adopted")
+	}
+
+	if err := stateBackend.Save(ctx, stateKey, currentState); err != nil {
+		return fmt.Errorf("failed to save reconciled state: %w", err)
+	}
+
+	return nil
+}
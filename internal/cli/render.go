@@ -0,0 +1,247 @@
+package cli
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/yourusername/panka/pkg/diff"
+	"github.com/yourusername/panka/pkg/events"
+	"github.com/yourusername/panka/pkg/parser/schema"
+)
+
+// humanSink renders apply and destroy events for the terminal.
+// A resource is printed as one line once its operation has finished, so
+// that the lines of resources applied in parallel do not interleave.
+type humanSink struct{}
+
+// Emit prints an event
+func (s *humanSink) Emit(event events.Event) {
+	cyan := color.New(color.FgCyan, color.Bold)
+	green := color.New(color.FgGreen, color.Bold)
+	red := color.New(color.FgRed, color.Bold)
+	yellow := color.New(color.FgYellow)
+
+	switch event.Type {
+	case events.PlanComputed:
+		if event.ChangeSet != nil {
+			diff.PrintDiff(event.ChangeSet)
+		}
+
+	case events.Started:
+		if event.Operation == events.OperationDestroy {
+			red.Println("\n🔥 Destroying Resources")
+		} else {
+			cyan.Println("\n🔧 Applying Changes")
+		}
+		cyan.Println(strings.Repeat("─", 60))
+
+	case events.StageStarted:
+		if event.Operation == events.OperationApply && event.Phase == events.PhaseDelete {
+			red.Printf("\n🗑️  Stage %d: deleting %d removed resource(s)\n", event.Stage, event.Resources)
+			return
+		}
+		fmt.Printf("\n📦 Stage %d: %d resource(s)\n", event.Stage, event.Resources)
+
+	case events.ResourceSucceeded, events.ResourceFailed, events.ResourceSkipped:
+		s.renderResource(event)
+
+	case events.RollbackStarted:
+		yellow.Println("\n⚠️  Apply failed. Initiating rollback...")
+
+	case events.RollbackCompleted:
+		if event.Rollback == nil {
+			red.Printf("❌ Rollback error: %s\n", event.Error)
+			return
+		}
+		displayRollbackResult(event.Rollback)
+
+	case events.OutputsEvaluated:
+		cyan.Println("\n📤 Outputs")
+		for _, name := range sortedKeys(event.Outputs) {
+			fmt.Printf("   %s = %s\n", name, event.Outputs[name])
+		}
+
+	case events.StateSaved:
+		fmt.Print("\n⏳ Saving state... ")
+		if event.Error != "" {
+			red.Println("✗")
+			yellow.Printf("⚠️  Warning: Failed to save state: %s\n", event.Error)
+			return
+		}
+		if event.Message != "" {
+			green.Printf("✓ (%s)\n", event.Message)
+			return
+		}
+		green.Println("✓")
+
+	case events.HookStarted:
+		if event.Service != "" {
+			cyan.Printf("\n🪝 Running %s hook %s (service %s)\n", event.HookPoint, event.Hook, event.Service)
+		} else {
+			cyan.Printf("\n🪝 Running %s hook %s\n", event.HookPoint, event.Hook)
+		}
+
+	case events.HookSucceeded:
+		duration := time.Duration(event.DurationMS) * time.Millisecond
+		green.Printf("   ✓ %s (%s)\n", event.Hook, duration.Round(time.Millisecond))
+
+	case events.HookFailed:
+		if event.HookPoint == string(schema.HookPostApply) || event.HookPoint == string(schema.HookOnFailure) {
+			yellow.Printf("   ⚠️  Warning: %s\n", event.Error)
+			return
+		}
+		red.Printf("   ✗ %s\n", event.Error)
+
+	case events.Warning:
+		yellow.Printf("   ⚠️  %s\n", event.Message)
+
+	case events.Summary:
+		if event.Summary == nil {
+			return
+		}
+		if event.Operation == events.OperationDestroy {
+			renderDestroySummary(event)
+		} else {
+			renderApplySummary(event)
+		}
+	}
+}
+
+// renderResource prints the line of a finished resource operation
+func (s *humanSink) renderResource(event events.Event) {
+	green := color.New(color.FgGreen, color.Bold)
+	red := color.New(color.FgRed, color.Bold)
+	yellow := color.New(color.FgYellow)
+
+	if event.Type == events.ResourceSkipped {
+		yellow.Printf("   ⚠️  [%s] %s - Skipped (%s)\n", event.Kind, event.Resource, event.Reason)
+		return
+	}
+
+	switch diff.ChangeType(event.Action) {
+	case diff.ChangeNoChange:
+		green.Printf("   ✓ [%s] %s - No changes\n", event.Kind, event.Resource)
+		return
+	case diff.ChangeUpdate:
+		yellow.Printf("   ~ [%s] %s - Updating... ", event.Kind, event.Resource)
+	case diff.ChangeRecreate:
+		if event.Reason != "" {
+			yellow.Printf("   ~ [%s] %s - Recreating (%s)... ", event.Kind, event.Resource, event.Reason)
+		} else {
+			fmt.Printf("   ± [%s] %s - Recreating... ", event.Kind, event.Resource)
+		}
+	case diff.ChangeDelete:
+		if event.Operation == events.OperationDestroy {
+			fmt.Printf("   Deleting [%s] %s... ", event.Kind, event.Resource)
+		} else {
+			fmt.Printf("   - [%s] %s... ", event.Kind, event.Resource)
+		}
+	default:
+		fmt.Printf("   + [%s] %s - Creating... ", event.Kind, event.Resource)
+	}
+
+	if event.Type == events.ResourceFailed {
+		if event.Reason != "" {
+			yellow.Printf("⚠️  Failed (%s)\n", event.Reason)
+		} else {
+			red.Println("✗")
+		}
+		fmt.Printf("      %s\n", event.Error)
+		return
+	}
+
+	green.Println("✓")
+	for _, ac := range event.Changes {
+		if ac.Sensitive {
+			fmt.Printf("      %s: (sensitive value)\n", ac.Path)
+			continue
+		}
+		fmt.Printf("      %s: %v → %v\n", ac.Path, ac.OldValue, ac.NewValue)
+	}
+	if len(event.Changes) == 0 {
+		for _, k := range sortedKeys(event.Outputs) {
+			fmt.Printf("      %s: %s\n", k, event.Outputs[k])
+		}
+	}
+}
+
+// renderApplySummary prints the summary of an apply
+func renderApplySummary(event events.Event) {
+	cyan := color.New(color.FgCyan, color.Bold)
+	green := color.New(color.FgGreen, color.Bold)
+	red := color.New(color.FgRed, color.Bold)
+	yellow := color.New(color.FgYellow)
+
+	totals := event.Summary
+	duration := time.Duration(totals.DurationMS) * time.Millisecond
+
+	cyan.Println("\n" + strings.Repeat("─", 60))
+	cyan.Println("📊 Apply Summary")
+	cyan.Println(strings.Repeat("─", 60))
+
+	fmt.Printf("Stack:      %s\n", event.Stack)
+	fmt.Printf("Env:        %s\n", event.Environment)
+	fmt.Printf("Duration:   %s\n", duration.Round(time.Second))
+	green.Printf("Created:    %d\n", totals.Created)
+	yellow.Printf("Updated:    %d\n", totals.Updated)
+	fmt.Printf("Unchanged:  %d\n", totals.Unchanged)
+	if totals.Deleted > 0 {
+		red.Printf("Deleted:    %d\n", totals.Deleted)
+	}
+	if totals.Failed > 0 {
+		red.Printf("Failed:     %d\n", totals.Failed)
+	}
+
+	if !totals.Success {
+		yellow.Println("\n⚠️  Some operations failed")
+		return
+	}
+	green.Println("\n✨ Apply complete!")
+}
+
+// renderDestroySummary prints the summary of a destroy
+func renderDestroySummary(event events.Event) {
+	cyan := color.New(color.FgCyan, color.Bold)
+	green := color.New(color.FgGreen, color.Bold)
+	red := color.New(color.FgRed, color.Bold)
+	yellow := color.New(color.FgYellow)
+
+	totals := event.Summary
+	duration := time.Duration(totals.DurationMS) * time.Millisecond
+
+	cyan.Println("\n" + strings.Repeat("─", 60))
+	red.Println("🗑️  Destroy Summary")
+	cyan.Println(strings.Repeat("─", 60))
+
+	fmt.Printf("Stack:      %s\n", event.Stack)
+	fmt.Printf("Duration:   %s\n", duration.Round(time.Second))
+	green.Printf("Destroyed:  %d\n", totals.Deleted)
+	if totals.Failed > 0 {
+		red.Printf("Failed:     %d\n", totals.Failed)
+	}
+	if totals.Skipped > 0 {
+		yellow.Printf("Skipped:    %d\n", totals.Skipped)
+	}
+
+	switch {
+	case totals.Failed == 0 && totals.Skipped == 0:
+		green.Println("\n✨ All resources destroyed successfully!")
+	case totals.Deleted > 0:
+		yellow.Println("\n⚠️  Partial destruction complete")
+	default:
+		yellow.Println("\n⚠️  Some resources failed to delete")
+	}
+}
+
+// sortedKeys returns the keys of a string map in order
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
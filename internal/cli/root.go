@@ -40,6 +40,9 @@ Features:
 			return
 		}
 		
+		// Keep stdout for the event stream of --output json
+		reserveStdoutForEvents(cmd)
+
 		// Initialize logger
 		initLogger()
 	},
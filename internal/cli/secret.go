@@ -0,0 +1,308 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/yourusername/panka/pkg/diff"
+	"github.com/yourusername/panka/pkg/secrets"
+	"github.com/yourusername/panka/pkg/tenant"
+	"golang.org/x/term"
+)
+
+var (
+	secretStack   string
+	secretBackend string
+)
+
+// secretCmd represents the secret command
+var secretCmd = &cobra.Command{
+	Use:   "secret",
+	Short: "Manage stack secrets",
+	Long: `Manage the secrets of a stack.
+
+Secrets are stored in AWS Secrets Manager (the default) or, with
+--backend ssm, as SecureString parameters in SSM Parameter Store. They are
+scoped to the tenant and the stack under panka/<tenant>/<stack>/.
+
+Components reference secrets by name or ARN:
+
+  secrets:
+    - name: db-password
+      secretRef: panka/<tenant>/<stack>/db-password
+      envVar: DB_PASSWORD
+
+Plan and apply check that every referenced secret exists. ECS tasks get the
+secret injected by ECS, and Lambda functions get the ARN of the secret in
+their environment. Secret values are never written to the state.`,
+}
+
+// secretSetCmd represents the secret set command
+var secretSetCmd = &cobra.Command{
+	Use:   "set <name> [value]",
+	Short: "Create or update a secret",
+	Long: `Create a secret of a stack, or store a new value of an existing one.
+
+When no value is given it is read from stdin, with a hidden prompt when
+stdin is a terminal. Prefer stdin to keep values out of the shell history.
+
+Examples:
+  panka secret set db-password --stack my-stack
+  echo -n "$TOKEN" | panka secret set api-token --stack my-stack
+  panka secret set api-token --stack my-stack --backend ssm`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: runSecretSet,
+}
+
+// secretGetCmd represents the secret get command
+var secretGetCmd = &cobra.Command{
+	Use:   "get <name>",
+	Short: "Show a secret and its value",
+	Long: `Show a secret of a stack together with its value.
+
+Examples:
+  panka secret get db-password --stack my-stack`,
+	Args: cobra.ExactArgs(1),
+	RunE: runSecretGet,
+}
+
+// secretListCmd represents the secret list command
+var secretListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the secrets of a stack",
+	Long: `List the secrets of a stack, without their values.
+
+Examples:
+  panka secret list --stack my-stack
+  panka secret list --stack my-stack --backend ssm`,
+	Args: cobra.NoArgs,
+	RunE: runSecretList,
+}
+
+func init() {
+	rootCmd.AddCommand(secretCmd)
+	secretCmd.AddCommand(secretSetCmd)
+	secretCmd.AddCommand(secretGetCmd)
+	secretCmd.AddCommand(secretListCmd)
+
+	secretCmd.PersistentFlags().StringVar(&secretStack, "stack", "", "Stack name (required)")
+	secretCmd.PersistentFlags().StringVar(&secretBackend, "backend", secrets.BackendSecretsManager, "Secret backend (secretsmanager or ssm)")
+	secretCmd.MarkPersistentFlagRequired("stack")
+}
+
+func runSecretSet(cmd *cobra.Command, args []string) error {
+	green := color.New(color.FgGreen, color.Bold)
+	red := color.New(color.FgRed, color.Bold)
+
+	ctx, cancel := withSignalCancel(context.Background())
+	defer cancel()
+
+	session, store, err := openSecretStore(ctx)
+	if err != nil {
+		return err
+	}
+
+	var value string
+	if len(args) == 2 {
+		value = args[1]
+	} else {
+		value, err = readSecretValue()
+		if err != nil {
+			return err
+		}
+	}
+	if value == "" {
+		return fmt.Errorf("secret value cannot be empty")
+	}
+
+	name := secrets.Path(secretBackend, session.Tenant.ID, secretStack, args[0])
+	fmt.Printf("⏳ Storing %s... ", name)
+	secret, err := store.Put(ctx, name, value, map[string]string{
+		"panka:tenant":     session.Tenant.ID,
+		"panka:stack":      secretStack,
+		"panka:managed-by": "panka",
+	})
+	if err != nil {
+		red.Println("✗")
+		return err
+	}
+	green.Println("✓")
+
+	fmt.Printf("   ARN: %s\n", secret.ARN)
+	fmt.Printf("\nReference it from a component with:\n")
+	fmt.Printf("   secretRef: %s\n", secret.Name)
+
+	return nil
+}
+
+func runSecretGet(cmd *cobra.Command, args []string) error {
+	green := color.New(color.FgGreen, color.Bold)
+	red := color.New(color.FgRed, color.Bold)
+
+	ctx, cancel := withSignalCancel(context.Background())
+	defer cancel()
+
+	session, store, err := openSecretStore(ctx)
+	if err != nil {
+		return err
+	}
+
+	name := secrets.Path(secretBackend, session.Tenant.ID, secretStack, args[0])
+	fmt.Printf("⏳ Reading %s... ", name)
+	secret, err := store.Describe(ctx, name)
+	if err != nil {
+		red.Println("✗")
+		return fmt.Errorf("failed to describe secret %s: %w", name, err)
+	}
+	value, err := store.Value(ctx, secret.ARN)
+	if err != nil {
+		red.Println("✗")
+		return fmt.Errorf("failed to read secret %s: %w", name, err)
+	}
+	green.Println("✓")
+
+	fmt.Printf("   ARN:     %s\n", secret.ARN)
+	if !secret.UpdatedAt.IsZero() {
+		fmt.Printf("   Updated: %s\n", secret.UpdatedAt.Format("2006-01-02 15:04"))
+	}
+	fmt.Printf("\n%s\n", value)
+
+	return nil
+}
+
+func runSecretList(cmd *cobra.Command, args []string) error {
+	green := color.New(color.FgGreen, color.Bold)
+	red := color.New(color.FgRed, color.Bold)
+	yellow := color.New(color.FgYellow)
+
+	ctx, cancel := withSignalCancel(context.Background())
+	defer cancel()
+
+	session, store, err := openSecretStore(ctx)
+	if err != nil {
+		return err
+	}
+
+	prefix := secrets.Path(secretBackend, session.Tenant.ID, secretStack, "")
+	fmt.Printf("⏳ Listing %s... ", prefix)
+	list, err := store.List(ctx, prefix)
+	if err != nil {
+		red.Println("✗")
+		return err
+	}
+	green.Println("✓")
+	fmt.Println()
+
+	if len(list) == 0 {
+		yellow.Println("No secrets found")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "NAME\tREF\tLAST UPDATED")
+	fmt.Fprintln(w, strings.Repeat("─", 80))
+	for _, secret := range list {
+		fmt.Fprintf(w, "%s\t%s\t%s\n",
+			strings.TrimPrefix(secret.Name, prefix),
+			secret.Name,
+			secret.UpdatedAt.Format("2006-01-02 15:04"),
+		)
+	}
+	w.Flush()
+
+	fmt.Printf("\n")
+	green.Printf("Total: %d secrets\n", len(list))
+
+	return nil
+}
+
+// openSecretStore checks the tenant session and opens the secret store
+// selected by --backend in the workload region of the tenant, where its
+// resources read the secrets
+func openSecretStore(ctx context.Context) (*tenant.Session, secrets.Store, error) {
+	session, err := checkTenantSession()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	bucket := viper.GetString("backend.bucket")
+	backendRegion := viper.GetString("backend.region")
+	if bucket == "" || backendRegion == "" {
+		return nil, nil, fmt.Errorf("backend.bucket and backend.region must be configured in .panka.yaml")
+	}
+	region, err := loadWorkloadRegion(ctx, session, bucket, backendRegion)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	awsCfg, err := awsconfig.LoadDefaultConfig(ctx, awsconfig.WithRegion(region))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+
+	store, err := secrets.NewManager(awsCfg).Store(secretBackend)
+	if err != nil {
+		return nil, nil, err
+	}
+	return session, store, nil
+}
+
+// readSecretValue reads a secret value from stdin, prompting without echo
+// when stdin is a terminal
+func readSecretValue() (string, error) {
+	if term.IsTerminal(int(os.Stdin.Fd())) {
+		fmt.Print("? Secret value: ")
+		value, err := term.ReadPassword(int(os.Stdin.Fd()))
+		fmt.Println()
+		if err != nil {
+			return "", fmt.Errorf("failed to read secret value: %w", err)
+		}
+		return string(value), nil
+	}
+
+	value, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return "", fmt.Errorf("failed to read secret value: %w", err)
+	}
+	return strings.TrimRight(string(value), "\r\n"), nil
+}
+
+// checkSecrets verifies that the secrets referenced by the created and
+// updated resources of a change set exist in the workload region
+func checkSecrets(ctx context.Context, region string, changeSet *diff.ChangeSet) error {
+	green := color.New(color.FgGreen, color.Bold)
+	red := color.New(color.FgRed, color.Bold)
+
+	var refs []string
+	for _, change := range changeSet.Changes {
+		if change.After == nil || change.Type == diff.ChangeNoChange {
+			continue
+		}
+		refs = append(refs, secrets.References(change.After)...)
+	}
+	if len(refs) == 0 {
+		return nil
+	}
+
+	fmt.Print("⏳ Checking secrets... ")
+	awsCfg, err := awsconfig.LoadDefaultConfig(ctx, awsconfig.WithRegion(region))
+	if err != nil {
+		red.Println("✗")
+		return fmt.Errorf("failed to load AWS config: %w", err)
+	}
+	if err := secrets.NewManager(awsCfg).Check(ctx, refs); err != nil {
+		red.Println("✗")
+		return err
+	}
+	green.Println("✓")
+
+	return nil
+}
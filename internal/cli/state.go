@@ -1,6 +1,7 @@
 package cli
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -8,8 +9,20 @@ import (
 	"text/tabwriter"
 	"time"
 
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/yourusername/panka/internal/logger"
+	"github.com/yourusername/panka/pkg/state"
+	"github.com/yourusername/panka/pkg/tenant"
+	"go.uber.org/zap"
+)
+
+var (
+	stateStack       string
+	stateAutoApprove bool
 )
 
 // stateCmd represents the state command
@@ -51,10 +64,13 @@ The resource ID should be in the format: stack.service.resource`,
 
 // stateRemoveCmd removes a resource from state
 var stateRemoveCmd = &cobra.Command{
-	Use:   "rm [resource-id]",
+	Use:   "rm <resource-name>",
 	Short: "Remove resource from state",
 	Long: `Remove a resource from the state without destroying it.
 
+The state lock for the stack environment is held while the state
+is modified, so this cannot race with a concurrent apply or destroy.
+
 ⚠️  WARNING: This does not destroy the actual resource!
 This only removes it from Panka's tracking. The resource will
 continue to exist in your cloud provider.
@@ -62,7 +78,11 @@ continue to exist in your cloud provider.
 Use this when:
   • Resource was manually deleted outside Panka
   • You want to stop managing a resource with Panka
-  • State is corrupted and needs manual cleanup`,
+  • State is corrupted and needs manual cleanup
+
+Examples:
+  panka state rm api-server --stack my-stack
+  panka state rm orders-queue --stack my-stack --env production --lock-timeout 1m`,
 	Args: cobra.ExactArgs(1),
 	RunE: runStateRemove,
 }
@@ -72,6 +92,12 @@ func init() {
 	stateCmd.AddCommand(stateListCmd)
 	stateCmd.AddCommand(stateShowCmd)
 	stateCmd.AddCommand(stateRemoveCmd)
+
+	stateRemoveCmd.Flags().StringVar(&stateStack, "stack", "", "Stack name (required)")
+	stateRemoveCmd.Flags().BoolVarP(&stateAutoApprove, "auto-approve", "y", false, "Skip confirmation prompt")
+	stateRemoveCmd.MarkFlagRequired("stack")
+	addEnvFlag(stateRemoveCmd)
+	addLockFlags(stateRemoveCmd)
 }
 
 func runStateList(cmd *cobra.Command, args []string) error {
@@ -79,7 +105,7 @@ func runStateList(cmd *cobra.Command, args []string) error {
 	cyan := color.New(color.FgCyan)
 	yellow := color.New(color.FgYellow)
 
-	cyan.Println("\n📋 Listing resources in state...\n")
+	cyan.Print("\n📋 Listing resources in state...\n\n")
 
 	// In a real implementation, this would:
 	// 1. Load backend configuration
@@ -192,27 +218,113 @@ func runStateShow(cmd *cobra.Command, args []string) error {
 }
 
 func runStateRemove(cmd *cobra.Command, args []string) error {
+	green := color.New(color.FgGreen, color.Bold)
 	red := color.New(color.FgRed, color.Bold)
 	yellow := color.New(color.FgYellow, color.Bold)
-	cyan := color.New(color.FgCyan)
 
-	resourceID := args[0]
+	resourceName := args[0]
 
-	red.Printf("\n⚠️  Removing resource from state: %s\n\n", resourceID)
+	stateEnvironment, err := selectedEnvironment()
+	if err != nil {
+		return err
+	}
+
+	red.Printf("\n⚠️  Removing resource from state: %s\n\n", resourceName)
 
 	yellow.Println("WARNING: This will remove the resource from state tracking.")
 	yellow.Println("The actual cloud resource will NOT be destroyed!")
 	fmt.Println()
 
-	cyan.Println("In a full implementation, this would:")
-	fmt.Println("  1. Load current state from S3")
-	fmt.Println("  2. Acquire DynamoDB lock")
-	fmt.Println("  3. Remove resource from state")
-	fmt.Println("  4. Save updated state to S3")
-	fmt.Println("  5. Release lock")
+	log := logger.Global()
+	ctx, stop := withSignalCancel(context.Background())
+	defer stop()
+
+	// Step 1: Check authentication
+	fmt.Print("⏳ Checking authentication... ")
+	sessionMgr := tenant.NewSessionManager()
+	session, err := sessionMgr.LoadSession()
+	if err != nil || session.Mode != tenant.ModeTenant || session.Tenant == nil {
+		red.Println("✗")
+		return fmt.Errorf("not logged in as tenant. Run 'panka login' first")
+	}
+	green.Println("✓")
+
+	bucket := viper.GetString("backend.bucket")
+	region := viper.GetString("backend.region")
+	if bucket == "" || region == "" {
+		return fmt.Errorf("backend.bucket and backend.region must be configured in .panka.yaml")
+	}
+
+	// Step 2: Acquire state lock
+	ctx, releaseLock, err := acquireStackLock(ctx, session, stateStack, stateEnvironment)
+	if err != nil {
+		return err
+	}
+	defer releaseLock()
+
+	// Step 3: Load current state
+	fmt.Print("⏳ Loading current state... ")
+	awsCfg, err := awsconfig.LoadDefaultConfig(ctx, awsconfig.WithRegion(region))
+	if err != nil {
+		red.Println("✗")
+		return fmt.Errorf("failed to load AWS config: %w", err)
+	}
+
+	stateBackend, err := state.NewS3Backend(&state.S3BackendConfig{
+		Client: s3.NewFromConfig(awsCfg),
+		Bucket: bucket,
+		Prefix: fmt.Sprintf("tenants/%s/v1/stacks", session.Tenant.ID),
+	})
+	if err != nil {
+		red.Println("✗")
+		return fmt.Errorf("failed to create state backend: %w", err)
+	}
+
+	stateKey := fmt.Sprintf("%s/%s/state.json", stateStack, stateEnvironment)
+	currentState, err := stateBackend.Load(ctx, stateKey)
+	if err != nil {
+		red.Println("✗")
+		return fmt.Errorf("failed to load state for stack '%s': %w", stateStack, err)
+	}
+	green.Println("✓")
+
+	res, ok := currentState.GetResource(resourceName)
+	if !ok {
+		return fmt.Errorf("resource '%s' not found in state for stack '%s' (%s)", resourceName, stateStack, stateEnvironment)
+	}
+	fmt.Printf("   [%s] %s\n", res.Type, res.Name)
+	if res.ID != "" {
+		fmt.Printf("      ID: %s\n", res.ID)
+	}
+
+	// Confirmation
+	if !stateAutoApprove {
+		fmt.Print("\nDo you want to remove this resource from state? (yes/no): ")
+		var response string
+		fmt.Scanln(&response)
+		if strings.ToLower(response) != "yes" {
+			yellow.Println("Remove cancelled")
+			return nil
+		}
+	}
+
+	// Step 4: Remove resource and save state
+	fmt.Print("⏳ Saving state... ")
+	currentState.RemoveResource(resourceName)
+	currentState.Metadata.UpdatedAt = time.Now()
+	if err := stateBackend.Save(ctx, stateKey, currentState); err != nil {
+		red.Println("✗")
+		return fmt.Errorf("failed to save state: %w", err)
+	}
+	green.Println("✓")
 
-	yellow.Println("\n⚠️  This operation requires confirmation and state backend")
-	cyan.Println("   Use 'panka state rm' with caution in production")
+	log.Info("Resource removed from state",
+		zap.String("stack", stateStack),
+		zap.String("environment", stateEnvironment),
+		zap.String("resource", resourceName),
+		zap.String("id", res.ID),
+	)
 
+	green.Printf("\n✨ Removed '%s' from state\n", resourceName)
 	return nil
 }
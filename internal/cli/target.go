@@ -0,0 +1,79 @@
+package cli
+
+import (
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/yourusername/panka/pkg/graph"
+)
+
+var (
+	targets          []string
+	targetDependents bool
+)
+
+// addTargetFlags registers the resource targeting flags on a command.
+// withDependents also registers --target-dependents.
+func addTargetFlags(cmd *cobra.Command, withDependents bool) {
+	cmd.Flags().StringArrayVar(&targets, "target", nil, "Limit the operation to a component (<component> or <service>/<component>); can be repeated")
+	if withDependents {
+		cmd.Flags().BoolVar(&targetDependents, "target-dependents", false, "Also include the components that depend on the targets")
+	}
+}
+
+// selectTargets returns the IDs selected by the --target flags for a deploy,
+// or nil when no targets were given. The selection contains the targets and
+// their transitive dependencies. With --target-dependents the downstream
+// consumers of the targets, and their dependencies, are included as well.
+func selectTargets(g *graph.Graph) ([]string, error) {
+	if len(targets) == 0 {
+		return nil, nil
+	}
+
+	ids, err := g.ResolveTargets(targets)
+	if err != nil {
+		return nil, err
+	}
+
+	if targetDependents {
+		ids = g.DependentClosure(ids)
+	}
+
+	return g.DependencyClosure(ids), nil
+}
+
+// selectDestroyTargets returns the IDs selected by the --target flags for a
+// destroy, or nil when no targets were given. A resource cannot outlive the
+// resources it depends on, so the selection always contains the targets and
+// everything that transitively depends on them.
+func selectDestroyTargets(g *graph.Graph) ([]string, error) {
+	if len(targets) == 0 {
+		return nil, nil
+	}
+
+	ids, err := g.ResolveTargets(targets)
+	if err != nil {
+		return nil, err
+	}
+
+	return g.DependentClosure(ids), nil
+}
+
+// printTargetSelection shows which resources a targeted operation covers
+func printTargetSelection(selected []string) {
+	yellow := color.New(color.FgYellow)
+
+	yellow.Printf("   Targeting %d resource(s): %s\n", len(selected), strings.Join(selected, ", "))
+	yellow.Println("   ⚠️  Changes to other resources are ignored")
+}
+
+// toSet converts a list of IDs into a lookup set
+func toSet(ids []string) map[string]bool {
+	set := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		set[id] = true
+	}
+	return set
+}
+
@@ -0,0 +1,435 @@
+package diff
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/yourusername/panka/pkg/parser/schema"
+)
+
+// StateAttributes returns the configuration attributes of a resource that the
+// Differ compares against state. Apply stores them in state.Resource.Attributes
+// so that later configuration changes are detected as updates.
+//
+// Numbers are stored as float64, which is how they are decoded when state is
+// loaded back from JSON.
+func StateAttributes(resource schema.Resource) map[string]interface{} {
+	attrs := make(map[string]interface{})
+
+	switch res := resource.(type) {
+	case *schema.S3:
+		if res.Spec.Versioning != nil {
+			attrs["versioning"] = res.Spec.Versioning.Enabled
+		}
+		if res.Spec.Bucket.ACL != "" {
+			attrs["acl"] = res.Spec.Bucket.ACL
+		}
+		if res.Spec.Encryption != nil {
+			attrs["encryption_enabled"] = res.Spec.Encryption.Enabled
+		}
+	case *schema.DynamoDB:
+		attrs["hash_key"] = res.Spec.HashKey.Name
+		attrs["hash_key_type"] = res.Spec.HashKey.Type
+		if res.Spec.RangeKey != nil {
+			attrs["range_key"] = res.Spec.RangeKey.Name
+			attrs["range_key_type"] = res.Spec.RangeKey.Type
+		}
+		attrs["billing_mode"] = res.Spec.BillingMode
+		if res.Spec.BillingMode == "PROVISIONED" {
+			attrs["read_capacity"] = float64(res.Spec.ReadCapacity)
+			attrs["write_capacity"] = float64(res.Spec.WriteCapacity)
+		}
+	case *schema.SQS:
+		attrs["type"] = res.Spec.Type
+		if res.Spec.VisibilityTimeout > 0 {
+			attrs["visibility_timeout"] = float64(res.Spec.VisibilityTimeout)
+		}
+		if res.Spec.MessageRetentionPeriod > 0 {
+			attrs["message_retention"] = float64(res.Spec.MessageRetentionPeriod)
+		}
+	case *schema.SNS:
+		attrs["fifo"] = res.Spec.FifoTopic
+		if res.Spec.DisplayName != "" {
+			attrs["display_name"] = res.Spec.DisplayName
+		}
+	case *schema.RDS:
+		attrs["engine"] = res.Spec.Engine.Type
+		attrs["instance_class"] = res.Spec.Instance.Class
+		attrs["storage_size"] = float64(res.Spec.Instance.Storage.AllocatedGB)
+		attrs["multi_az"] = res.Spec.Instance.MultiAZ
+	case *schema.ElastiCacheRedis:
+		if res.Spec.EngineVersion != "" {
+			attrs["engine_version"] = res.Spec.EngineVersion
+		}
+		attrs["node_type"] = res.Spec.NodeType
+		attrs["num_shards"] = float64(res.ShardCount())
+		attrs["replicas_per_shard"] = float64(res.Spec.ReplicasPerShard)
+		attrs["at_rest_encryption"] = res.Spec.Encryption.AtRest
+		attrs["transit_encryption"] = res.Spec.Encryption.InTransit
+		attrs["auth_enabled"] = res.AuthEnabled()
+	case *schema.ElastiCacheMemcached:
+		if res.Spec.EngineVersion != "" {
+			attrs["engine_version"] = res.Spec.EngineVersion
+		}
+		attrs["node_type"] = res.Spec.NodeType
+		attrs["num_nodes"] = float64(res.NodeCount())
+		attrs["transit_encryption"] = res.Spec.Encryption.InTransit
+	case *schema.MicroService:
+		cpu, memory := res.TaskResources()
+		attrs["image"] = res.Spec.Image.Repository + ":" + res.Spec.Image.Tag
+		attrs["cpu"] = float64(cpu)
+		attrs["memory"] = float64(memory)
+		attrs["configs"] = configsSummary(res.Spec.Configs)
+		containerAttributes(attrs, containerSpecOf(res))
+		autoScalingAttributes(attrs, res.DesiredCount(), res.Infra.AutoScalingConfig())
+		loadBalancerAttributes(attrs, res)
+	case *schema.Worker:
+		cpu, memory := res.TaskResources()
+		attrs["image"] = res.Spec.Image.Repository + ":" + res.Spec.Image.Tag
+		attrs["cpu"] = float64(cpu)
+		attrs["memory"] = float64(memory)
+		attrs["stop_timeout"] = float64(res.Spec.StopTimeout)
+		containerAttributes(attrs, containerSpecOf(res))
+		// Autoscaling owns the desired count of a scaled worker
+		if scaling := res.Spec.Scaling; scaling != nil {
+			attrs["autoscaled"] = true
+			attrs["min_replicas"] = float64(scaling.MinReplicas)
+			attrs["max_replicas"] = float64(scaling.MaxReplicas)
+			attrs["messages_per_task"] = float64(scaling.TargetMessagesPerTask())
+			attrs["scale_in_cooldown"] = float64(scaling.ScaleInCooldown)
+			attrs["scale_out_cooldown"] = float64(scaling.ScaleOutCooldown)
+			attrs["scaling_schedules"] = scheduledScalingSummary(scaling.Schedules)
+		} else {
+			autoScalingAttributes(attrs, res.DesiredCount(), res.Infra.AutoScalingConfig())
+		}
+	case *schema.CronJob:
+		cpu, memory := res.TaskResources()
+		attrs["image"] = res.Spec.Image.Repository + ":" + res.Spec.Image.Tag
+		attrs["cpu"] = float64(cpu)
+		attrs["memory"] = float64(memory)
+		attrs["schedule"] = res.Spec.Schedule
+		attrs["timezone"] = res.Spec.Timezone
+		attrs["retry_count"] = float64(res.Spec.RetryCount)
+		containerAttributes(attrs, containerSpecOf(res))
+	case *schema.Lambda:
+		attrs["code"] = lambdaCodeSummary(res.Spec.Code)
+		attrs["handler"] = res.Spec.Handler
+		attrs["runtime"] = res.Spec.Runtime
+		attrs["memory"] = float64(res.MemoryMB())
+		attrs["timeout"] = float64(res.TimeoutSeconds())
+		attrs["environment"] = environmentSummary(lambdaEnvironment(res))
+		attrs["triggers"] = lambdaTriggerSummary(res)
+		attrs["provisioned_concurrency"] = provisionedConcurrencySummary(res.Spec.ProvisionedConcurrency)
+	}
+
+	// Workloads record their access grants and secrets even without any, so
+	// that removing the last one is detected
+	switch resource.(type) {
+	case *schema.MicroService, *schema.Worker, *schema.CronJob, *schema.Lambda:
+		attrs["access"] = accessSummary(resource)
+		attrs["secrets"] = secretsSummary(resource)
+	}
+
+	return attrs
+}
+
+// accessSummary describes the access grants of a workload in a string such
+// as "orders:readwrite,uploads:read"
+func accessSummary(resource schema.Resource) string {
+	grants := schema.AccessGrants(resource)
+	summaries := make([]string, 0, len(grants))
+	for _, grant := range grants {
+		summaries = append(summaries, grant.Component+":"+grant.Mode)
+	}
+	sort.Strings(summaries)
+	return strings.Join(summaries, ",")
+}
+
+// secretsSummary describes the secret references of a workload in a string
+// such as "DB_PASSWORD=panka/acme/shop/db". Only references are recorded,
+// never secret values.
+func secretsSummary(resource schema.Resource) string {
+	secrets := schema.WorkloadSecrets(resource)
+	summaries := make([]string, 0, len(secrets))
+	for _, secret := range secrets {
+		summaries = append(summaries, secret.EnvName()+"="+secret.SecretRef)
+	}
+	sort.Strings(summaries)
+	return strings.Join(summaries, ",")
+}
+
+// containerSpec is the part of the container definition of an ECS workload
+// that is compared besides its image, task size and secrets
+type containerSpec struct {
+	environment []schema.EnvironmentVariable
+	command     []string
+	args        []string
+	ports       []schema.Port
+	healthCheck *schema.HealthCheck
+}
+
+// containerSpecOf returns the container definition inputs of a
+// microservice, worker or cron job
+func containerSpecOf(resource schema.Resource) containerSpec {
+	switch r := resource.(type) {
+	case *schema.MicroService:
+		return containerSpec{
+			environment: r.Spec.Environment,
+			command:     r.Spec.Command,
+			args:        r.Spec.Args,
+			ports:       r.Spec.Ports,
+			healthCheck: r.Spec.HealthCheck,
+		}
+	case *schema.Worker:
+		return containerSpec{
+			environment: r.Spec.Environment,
+			command:     r.Spec.Command,
+			args:        r.Spec.Args,
+			healthCheck: r.Spec.HealthCheck,
+		}
+	case *schema.CronJob:
+		return containerSpec{
+			environment: r.Spec.Environment,
+			command:     r.Spec.Command,
+			args:        r.Spec.Args,
+		}
+	default:
+		return containerSpec{}
+	}
+}
+
+// containerAttributes adds the container definition inputs of an ECS
+// workload to its attributes. They are recorded even when empty, so that
+// removing the last variable or port is detected.
+func containerAttributes(attrs map[string]interface{}, container containerSpec) {
+	attrs["environment"] = environmentSummary(container.environment)
+	attrs["command"] = argumentsSummary(container.command)
+	attrs["args"] = argumentsSummary(container.args)
+	attrs["ports"] = portsSummary(container.ports)
+	attrs["health_check"] = healthCheckSummary(container.healthCheck)
+}
+
+// environmentSummary describes environment variables in a string such as
+// "LOG_LEVEL=3f2a9c1b7e4d,QUEUE_URL=from:orders.url". Values are recorded as
+// hashes, so that state holds no configuration values.
+func environmentSummary(environment []schema.EnvironmentVariable) string {
+	summaries := make([]string, 0, len(environment))
+	for _, env := range environment {
+		if env.ValueFrom != nil {
+			summaries = append(summaries, env.Name+"=from:"+env.ValueFrom.Component+"."+env.ValueFrom.Output)
+			continue
+		}
+		sum := sha256.Sum256([]byte(env.Value))
+		summaries = append(summaries, env.Name+"="+hex.EncodeToString(sum[:])[:12])
+	}
+	sort.Strings(summaries)
+	return strings.Join(summaries, ",")
+}
+
+// argumentsSummary describes a command or its arguments as a JSON array,
+// such as ["/bin/api","--port","8080"]. It is empty without any.
+func argumentsSummary(arguments []string) string {
+	if len(arguments) == 0 {
+		return ""
+	}
+	data, _ := json.Marshal(arguments)
+	return string(data)
+}
+
+// portsSummary describes container ports in a string such as
+// "http=8080/tcp,metrics=9090/udp"
+func portsSummary(ports []schema.Port) string {
+	summaries := make([]string, 0, len(ports))
+	for _, port := range ports {
+		protocol := strings.ToLower(port.Protocol)
+		if protocol == "" {
+			protocol = "tcp"
+		}
+		summaries = append(summaries, fmt.Sprintf("%s=%d/%s", port.Name, port.Port, protocol))
+	}
+	sort.Strings(summaries)
+	return strings.Join(summaries, ",")
+}
+
+// healthCheckSummary describes a container health check in a hash of its
+// probes. It is empty without a health check.
+func healthCheckSummary(healthCheck *schema.HealthCheck) string {
+	if healthCheck == nil {
+		return ""
+	}
+	data, _ := json.Marshal(healthCheck)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])[:12]
+}
+
+// configsSummary describes the mounted config files of a microservice in a
+// string that changes with their content, such as
+// "/etc/api:app.conf=3f2a9c1b7e4d". It is empty without config files.
+func configsSummary(configs *schema.ConfigsMount) string {
+	if configs == nil {
+		return ""
+	}
+	files := make([]string, 0, len(configs.Files))
+	for _, file := range configs.Files {
+		files = append(files, file+"="+configs.FileHash(file)[:12])
+	}
+	sort.Strings(files)
+	return configs.MountPath + ":" + strings.Join(files, ",")
+}
+
+// lambdaCodeSummary describes the code of a function in a string such as
+// "image:123456789012.dkr.ecr.us-east-1.amazonaws.com/fn:1.0" or
+// "s3://artifacts/fn.zip". It is empty without code.
+func lambdaCodeSummary(code schema.LambdaCode) string {
+	switch {
+	case code.ImageUri != "":
+		return "image:" + code.ImageUri
+	case code.S3Bucket != "":
+		return "s3://" + code.S3Bucket + "/" + code.S3Key
+	default:
+		return ""
+	}
+}
+
+// lambdaEnvironment returns the environment variables of a function as
+// container environment variables, with their values formatted the way
+// the function is configured with them
+func lambdaEnvironment(fn *schema.Lambda) []schema.EnvironmentVariable {
+	environment := make([]schema.EnvironmentVariable, 0, len(fn.Spec.Environment))
+	for name, value := range fn.Spec.Environment {
+		environment = append(environment, schema.EnvironmentVariable{Name: name, Value: fmt.Sprintf("%v", value)})
+	}
+	return environment
+}
+
+// lambdaTriggerSummary describes the triggers of a function in a string
+// that changes with any of them, such as "sqs:orders batch=10"
+func lambdaTriggerSummary(fn *schema.Lambda) string {
+	summaries := make([]string, 0, len(fn.Spec.Triggers))
+	for _, trigger := range fn.Spec.Triggers {
+		parts := []string{trigger.Type}
+		if source := trigger.Source; source != nil {
+			if source.Component != "" {
+				parts[0] += ":" + source.Component
+			} else {
+				parts[0] += ":" + source.Arn
+			}
+		}
+		if trigger.BatchSize != "" {
+			parts = append(parts, "batch="+trigger.BatchSize)
+		}
+		if trigger.Schedule != "" {
+			parts = append(parts, "schedule="+trigger.Schedule)
+		}
+		if s3 := trigger.S3; s3 != nil {
+			parts = append(parts, "bucket="+s3.Bucket)
+			if len(s3.Events) > 0 {
+				parts = append(parts, "events="+strings.Join(s3.Events, "|"))
+			}
+			if s3.Prefix != "" {
+				parts = append(parts, "prefix="+s3.Prefix)
+			}
+			if s3.Suffix != "" {
+				parts = append(parts, "suffix="+s3.Suffix)
+			}
+		}
+		summaries = append(summaries, strings.Join(parts, " "))
+	}
+	sort.Strings(summaries)
+	return strings.Join(summaries, ", ")
+}
+
+// autoScalingAttributes adds the autoscaling of an ECS service to its
+// attributes. Autoscaling owns the desired count of the service, which is
+// only recorded when it is fixed.
+func autoScalingAttributes(attrs map[string]interface{}, desiredCount int, autoScaling *schema.AutoScaling) {
+	attrs["autoscaled"] = autoScaling != nil
+	if autoScaling == nil {
+		attrs["desired_count"] = float64(desiredCount)
+		return
+	}
+	attrs["min_replicas"] = float64(autoScaling.MinReplicas)
+	attrs["max_replicas"] = float64(autoScaling.MaxReplicas)
+	attrs["target_cpu"] = float64(autoScaling.CPUTarget())
+	attrs["target_memory"] = float64(autoScaling.TargetMemoryPercent)
+	attrs["scale_in_cooldown"] = float64(autoScaling.ScaleInCooldown)
+	attrs["scale_out_cooldown"] = float64(autoScaling.ScaleOutCooldown)
+	attrs["scaling_schedules"] = scheduledScalingSummary(autoScaling.Schedules)
+}
+
+// scheduledScalingSummary describes scheduled scaling in a string such as
+// "night=0 22 * * *@Europe/Berlin:0-2", with a bound left out when the
+// schedule keeps it
+func scheduledScalingSummary(schedules []schema.ScheduledScaling) string {
+	capacity := func(value *int) string {
+		if value == nil {
+			return ""
+		}
+		return strconv.Itoa(*value)
+	}
+
+	summaries := make([]string, 0, len(schedules))
+	for _, schedule := range schedules {
+		summary := schedule.Name + "=" + schedule.Schedule
+		if schedule.Timezone != "" {
+			summary += "@" + schedule.Timezone
+		}
+		summary += ":" + capacity(schedule.MinCapacity) + "-" + capacity(schedule.MaxCapacity)
+		summaries = append(summaries, summary)
+	}
+	sort.Strings(summaries)
+	return strings.Join(summaries, ",")
+}
+
+// provisionedConcurrencySummary describes the provisioned concurrency of a
+// function in a string such as "1-10 target=70 cooldown=0/0", followed by
+// its schedules. It is empty without provisioned concurrency.
+func provisionedConcurrencySummary(provisioned *schema.ProvisionedConcurrency) string {
+	if provisioned == nil {
+		return ""
+	}
+	summary := fmt.Sprintf("%d-%d target=%d cooldown=%d/%d",
+		provisioned.MinCapacity, provisioned.MaxCapacity, provisioned.TargetPercent(),
+		provisioned.ScaleInCooldown, provisioned.ScaleOutCooldown)
+	if len(provisioned.Schedules) > 0 {
+		summary += " schedules=" + scheduledScalingSummary(provisioned.Schedules)
+	}
+	return summary
+}
+
+// loadBalancerAttributes adds the load balancer and ingress of a
+// microservice to its attributes
+func loadBalancerAttributes(attrs map[string]interface{}, ms *schema.MicroService) {
+	attrs["load_balancer"] = loadBalancerMode(ms.LoadBalancer())
+	if lb := ms.LoadBalancer(); lb != nil {
+		attrs["load_balancer_internal"] = lb.Internal
+		attrs["health_check_path"] = lb.HealthCheckPath
+		attrs["certificate_arn"] = lb.CertificateARN
+		attrs["allowed_cidrs"] = strings.Join(lb.AllowedCIDRs, ",")
+	}
+
+	ingress := ms.Ingress()
+	if ingress == nil {
+		ingress = &schema.IngressConfig{}
+	}
+	attrs["ingress_hostname"] = ingress.Hostname
+	attrs["ingress_path"] = ingress.Path
+}
+
+// loadBalancerMode returns whether a microservice has no load balancer, one
+// of its own or the shared one
+func loadBalancerMode(lb *schema.LoadBalancerConfig) string {
+	switch {
+	case lb == nil:
+		return "none"
+	case lb.Shared:
+		return "shared"
+	default:
+		return "dedicated"
+	}
+}
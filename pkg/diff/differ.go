@@ -1,7 +1,9 @@
 package diff
 
 import (
+	"fmt"
 	"reflect"
+	"strconv"
 	"strings"
 
 	"github.com/yourusername/panka/pkg/parser"
@@ -111,9 +113,66 @@ func (d *Differ) ComputeChanges(
 		}
 	}
 
+	if err := d.markKnownAfterApply(changeSet); err != nil {
+		return nil, err
+	}
+
 	return changeSet, nil
 }
 
+// markKnownAfterApply records which attributes of each change reference
+// outputs of components that are created or recreated by the change set.
+// Their values are only known once those components have been applied.
+func (d *Differ) markKnownAfterApply(cs *ChangeSet) error {
+	pending := make(map[string]bool)
+	replaced := make(map[string]bool)
+	for _, change := range cs.Changes {
+		if change.Type == ChangeCreate || change.Type == ChangeRecreate {
+			pending[change.ResourceName] = true
+		}
+		if change.Type == ChangeRecreate {
+			replaced[change.ResourceName] = true
+		}
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+
+	for _, change := range cs.Changes {
+		if change.After == nil {
+			continue
+		}
+
+		refs, err := parser.OutputReferences(change.After)
+		if err != nil {
+			return fmt.Errorf("failed to find output references of %s: %w", change.ResourceName, err)
+		}
+
+		for _, ref := range refs {
+			if !pending[ref.Component] || ref.Component == change.ResourceName {
+				continue
+			}
+			if change.KnownAfterApply == nil {
+				change.KnownAfterApply = make(map[string]string)
+			}
+			if existing, ok := change.KnownAfterApply[ref.Path]; ok {
+				change.KnownAfterApply[ref.Path] = existing + ", " + ref.String()
+			} else {
+				change.KnownAfterApply[ref.Path] = ref.String()
+			}
+
+			// A resource using outputs of a replaced resource is updated
+			// after the replacement, to pick up the new values
+			if replaced[ref.Component] && change.Type == ChangeNoChange {
+				cs.setType(change, ChangeUpdate)
+				change.Reason = "References outputs of replaced component " + ref.Component
+			}
+		}
+	}
+
+	return nil
+}
+
 // compareResource compares a desired resource with its current state
 func (d *Differ) compareResource(desired schema.Resource, current *state.Resource) *Change {
 	metadata := desired.GetMetadata()
@@ -130,6 +189,7 @@ func (d *Differ) compareResource(desired schema.Resource, current *state.Resourc
 	if string(desired.GetKind()) != current.Type {
 		change.Type = ChangeRecreate
 		change.RequiresRecreate = true
+		change.CreateBeforeDestroy = metadata.Lifecycle.CreatesBeforeDestroy()
 		change.Reason = "Resource type changed"
 		change.AttributeChanges = append(change.AttributeChanges, AttributeChange{
 			Path:          "kind",
@@ -140,8 +200,27 @@ func (d *Differ) compareResource(desired schema.Resource, current *state.Resourc
 		return change
 	}
 
-	// Compare attributes based on resource type
-	attrChanges := d.compareAttributes(desired, current)
+	// Compare attributes based on resource type, leaving out the changes
+	// ignored by the lifecycle
+	var attrChanges []AttributeChange
+	for _, ac := range d.compareAttributes(desired, current) {
+		if metadata.Lifecycle.IgnoresChange(ac.Path) {
+			continue
+		}
+		if forcesReplacement(desired.GetKind(), ac.Path) {
+			ac.ForceRecreate = true
+		}
+		attrChanges = append(attrChanges, ac)
+	}
+
+	// The protection is kept in state, so changing it is an update
+	if preventDestroy := metadata.Lifecycle.PreventsDestroy(); preventDestroy != current.PreventDestroy {
+		attrChanges = append(attrChanges, AttributeChange{
+			Path:     "metadata.lifecycle.preventDestroy",
+			OldValue: current.PreventDestroy,
+			NewValue: preventDestroy,
+		})
+	}
 
 	if len(attrChanges) == 0 {
 		change.Type = ChangeNoChange
@@ -159,6 +238,7 @@ func (d *Differ) compareResource(desired schema.Resource, current *state.Resourc
 		if requiresRecreate {
 			change.Type = ChangeRecreate
 			change.RequiresRecreate = true
+			change.CreateBeforeDestroy = metadata.Lifecycle.CreatesBeforeDestroy()
 			change.Reason = "One or more changes require recreation"
 		} else {
 			change.Type = ChangeUpdate
@@ -197,15 +277,80 @@ func (d *Differ) compareSpec(desired schema.Resource, currentAttrs map[string]in
 		changes = append(changes, d.compareSNS(res, currentAttrs)...)
 	case *schema.RDS:
 		changes = append(changes, d.compareRDS(res, currentAttrs)...)
+	case *schema.ElastiCacheRedis:
+		changes = append(changes, d.compareElastiCacheRedis(res, currentAttrs)...)
+	case *schema.ElastiCacheMemcached:
+		changes = append(changes, d.compareElastiCacheMemcached(res, currentAttrs)...)
+	case *schema.MicroService:
+		changes = append(changes, d.compareMicroService(res, currentAttrs)...)
+	case *schema.Worker:
+		changes = append(changes, d.compareWorker(res, currentAttrs)...)
+	case *schema.CronJob:
+		changes = append(changes, d.compareCronJob(res, currentAttrs)...)
+	case *schema.Lambda:
+		changes = append(changes, d.compareLambda(res, currentAttrs)...)
 	}
 
-	return changes
+	changes = append(changes, compareAccess(desired, currentAttrs)...)
+	return append(changes, compareSecrets(desired, currentAttrs)...)
+}
+
+// compareAccess compares the access grants of a workload, which change its
+// IAM roles in place.
+func compareAccess(desired schema.Resource, current map[string]interface{}) []AttributeChange {
+	switch desired.(type) {
+	case *schema.MicroService, *schema.Worker, *schema.CronJob, *schema.Lambda:
+	default:
+		return nil
+	}
+
+	currentValue, _ := current["access"].(string)
+	if value := accessSummary(desired); currentValue != value {
+		return []AttributeChange{{
+			Path:     "spec.access",
+			OldValue: currentValue,
+			NewValue: value,
+		}}
+	}
+	return nil
+}
+
+// compareSecrets compares the secret references of a workload, which change
+// its task definition or function configuration in place
+func compareSecrets(desired schema.Resource, current map[string]interface{}) []AttributeChange {
+	switch desired.(type) {
+	case *schema.MicroService, *schema.Worker, *schema.CronJob, *schema.Lambda:
+	default:
+		return nil
+	}
+
+	currentValue, _ := current["secrets"].(string)
+	if value := secretsSummary(desired); currentValue != value {
+		return []AttributeChange{{
+			Path:     "spec.secrets",
+			OldValue: currentValue,
+			NewValue: value,
+		}}
+	}
+	return nil
 }
 
 // compareS3 compares S3 bucket configuration
 func (d *Differ) compareS3(desired *schema.S3, current map[string]interface{}) []AttributeChange {
 	var changes []AttributeChange
 
+	// Bucket name (generated names are derived from the resource name)
+	if desired.Spec.Bucket.Name != "" && current["bucket_name"] != nil {
+		currentName, _ := current["bucket_name"].(string)
+		if currentName != desired.Spec.Bucket.Name {
+			changes = append(changes, AttributeChange{
+				Path:     "spec.bucket.name",
+				OldValue: currentName,
+				NewValue: desired.Spec.Bucket.Name,
+			})
+		}
+	}
+
 	// Versioning
 	if desired.Spec.Versioning != nil {
 		if current["versioning"] != nil {
@@ -258,23 +403,41 @@ func (d *Differ) compareDynamoDB(desired *schema.DynamoDB, current map[string]in
 		currentPK, _ := current["hash_key"].(string)
 		if currentPK != desired.Spec.HashKey.Name {
 			changes = append(changes, AttributeChange{
-				Path:          "spec.hashKey.name",
-				OldValue:      currentPK,
-				NewValue:      desired.Spec.HashKey.Name,
-				ForceRecreate: true,
+				Path:     "spec.hashKey.name",
+				OldValue: currentPK,
+				NewValue: desired.Spec.HashKey.Name,
 			})
 		}
 	}
 
+	currentHashType, _ := current["hash_key_type"].(string)
+	if currentHashType != desired.Spec.HashKey.Type {
+		changes = append(changes, AttributeChange{
+			Path:     "spec.hashKey.type",
+			OldValue: currentHashType,
+			NewValue: desired.Spec.HashKey.Type,
+		})
+	}
+
 	// Range key change requires recreation
 	if desired.Spec.RangeKey != nil && current["range_key"] != nil {
 		currentSK, _ := current["range_key"].(string)
 		if currentSK != desired.Spec.RangeKey.Name {
 			changes = append(changes, AttributeChange{
-				Path:          "spec.rangeKey.name",
-				OldValue:      currentSK,
-				NewValue:      desired.Spec.RangeKey.Name,
-				ForceRecreate: true,
+				Path:     "spec.rangeKey.name",
+				OldValue: currentSK,
+				NewValue: desired.Spec.RangeKey.Name,
+			})
+		}
+	}
+
+	if desired.Spec.RangeKey != nil && current["range_key"] != nil {
+		currentType, _ := current["range_key_type"].(string)
+		if currentType != desired.Spec.RangeKey.Type {
+			changes = append(changes, AttributeChange{
+				Path:     "spec.rangeKey.type",
+				OldValue: currentType,
+				NewValue: desired.Spec.RangeKey.Type,
 			})
 		}
 	}
@@ -329,10 +492,9 @@ func (d *Differ) compareSQS(desired *schema.SQS, current map[string]interface{})
 		currentIsFifo := currentType == "fifo"
 		if currentIsFifo != isFifo {
 			changes = append(changes, AttributeChange{
-				Path:          "spec.type",
-				OldValue:      currentType,
-				NewValue:      desired.Spec.Type,
-				ForceRecreate: true,
+				Path:     "spec.type",
+				OldValue: currentType,
+				NewValue: desired.Spec.Type,
 			})
 		}
 	}
@@ -373,10 +535,9 @@ func (d *Differ) compareSNS(desired *schema.SNS, current map[string]interface{})
 		currentFIFO, _ := current["fifo"].(bool)
 		if currentFIFO != desired.Spec.FifoTopic {
 			changes = append(changes, AttributeChange{
-				Path:          "spec.fifoTopic",
-				OldValue:      currentFIFO,
-				NewValue:      desired.Spec.FifoTopic,
-				ForceRecreate: true,
+				Path:     "spec.fifoTopic",
+				OldValue: currentFIFO,
+				NewValue: desired.Spec.FifoTopic,
 			})
 		}
 	}
@@ -405,10 +566,9 @@ func (d *Differ) compareRDS(desired *schema.RDS, current map[string]interface{})
 		currentEngine, _ := current["engine"].(string)
 		if currentEngine != desired.Spec.Engine.Type {
 			changes = append(changes, AttributeChange{
-				Path:          "spec.engine.type",
-				OldValue:      currentEngine,
-				NewValue:      desired.Spec.Engine.Type,
-				ForceRecreate: true,
+				Path:     "spec.engine.type",
+				OldValue: currentEngine,
+				NewValue: desired.Spec.Engine.Type,
 			})
 		}
 	}
@@ -457,6 +617,471 @@ func (d *Differ) compareRDS(desired *schema.RDS, current map[string]interface{})
 	return changes
 }
 
+// compareMicroService compares microservice configuration. All changes are
+// rolled out by registering a new task definition revision.
+func (d *Differ) compareMicroService(desired *schema.MicroService, current map[string]interface{}) []AttributeChange {
+	var changes []AttributeChange
+
+	if current["image"] != nil {
+		currentImage, _ := current["image"].(string)
+		image := desired.Spec.Image.Repository + ":" + desired.Spec.Image.Tag
+		if currentImage != image {
+			changes = append(changes, AttributeChange{
+				Path:     "spec.image",
+				OldValue: currentImage,
+				NewValue: image,
+			})
+		}
+	}
+
+	cpu, memory := desired.TaskResources()
+	if current["cpu"] != nil {
+		currentCPU, _ := current["cpu"].(float64)
+		if int(currentCPU) != cpu {
+			changes = append(changes, AttributeChange{
+				Path:     "infra.spec.resources.cpu",
+				OldValue: int(currentCPU),
+				NewValue: cpu,
+			})
+		}
+	}
+	if current["memory"] != nil {
+		currentMemory, _ := current["memory"].(float64)
+		if int(currentMemory) != memory {
+			changes = append(changes, AttributeChange{
+				Path:     "infra.spec.resources.memory",
+				OldValue: int(currentMemory),
+				NewValue: memory,
+			})
+		}
+	}
+
+	changes = append(changes, compareAutoScaling(desired.DesiredCount(), desired.Infra.AutoScalingConfig(), current)...)
+
+	// Config files are compared by content
+	currentConfigs, _ := current["configs"].(string)
+	if configs := configsSummary(desired.Spec.Configs); currentConfigs != configs {
+		changes = append(changes, AttributeChange{
+			Path:     "spec.configs",
+			OldValue: currentConfigs,
+			NewValue: configs,
+		})
+	}
+
+	changes = append(changes, compareContainer(containerSpecOf(desired), current)...)
+	return append(changes, compareLoadBalancer(desired, current)...)
+}
+
+// compareContainer compares the container definition inputs of an ECS
+// workload, which are rolled out by registering a new task definition
+// revision
+func compareContainer(container containerSpec, current map[string]interface{}) []AttributeChange {
+	var changes []AttributeChange
+
+	compareString := func(key, path, value string) {
+		currentValue, _ := current[key].(string)
+		if currentValue != value {
+			changes = append(changes, AttributeChange{
+				Path:     path,
+				OldValue: currentValue,
+				NewValue: value,
+			})
+		}
+	}
+
+	compareString("environment", "spec.environment", environmentSummary(container.environment))
+	compareString("command", "spec.command", argumentsSummary(container.command))
+	compareString("args", "spec.args", argumentsSummary(container.args))
+	compareString("ports", "spec.ports", portsSummary(container.ports))
+	compareString("health_check", "spec.healthCheck", healthCheckSummary(container.healthCheck))
+
+	return changes
+}
+
+// compareLoadBalancer compares the load balancer and ingress of a
+// microservice. They are changed in place, including moves between the
+// service's own and the shared load balancer.
+func compareLoadBalancer(desired *schema.MicroService, current map[string]interface{}) []AttributeChange {
+	var changes []AttributeChange
+
+	compareString := func(key, path, value string) {
+		if current[key] == nil {
+			return
+		}
+		currentValue, _ := current[key].(string)
+		if currentValue != value {
+			changes = append(changes, AttributeChange{
+				Path:     path,
+				OldValue: currentValue,
+				NewValue: value,
+			})
+		}
+	}
+
+	compareString("load_balancer", "infra.spec.networking.loadBalancer", loadBalancerMode(desired.LoadBalancer()))
+
+	if lb := desired.LoadBalancer(); lb != nil {
+		if current["load_balancer_internal"] != nil {
+			currentValue, _ := current["load_balancer_internal"].(bool)
+			if currentValue != lb.Internal {
+				changes = append(changes, AttributeChange{
+					Path:     "infra.spec.networking.loadBalancer.internal",
+					OldValue: currentValue,
+					NewValue: lb.Internal,
+				})
+			}
+		}
+		compareString("health_check_path", "infra.spec.networking.loadBalancer.healthCheckPath", lb.HealthCheckPath)
+		compareString("certificate_arn", "infra.spec.networking.loadBalancer.certificateArn", lb.CertificateARN)
+		compareString("allowed_cidrs", "infra.spec.networking.loadBalancer.allowedCIDRs", strings.Join(lb.AllowedCIDRs, ","))
+	}
+
+	ingress := desired.Ingress()
+	if ingress == nil {
+		ingress = &schema.IngressConfig{}
+	}
+	compareString("ingress_hostname", "infra.spec.networking.ingress.hostname", ingress.Hostname)
+	compareString("ingress_path", "infra.spec.networking.ingress.path", ingress.Path)
+
+	return changes
+}
+
+// compareWorker compares worker configuration. The desired count of a
+// worker that scales on its queue is left to autoscaling.
+func (d *Differ) compareWorker(desired *schema.Worker, current map[string]interface{}) []AttributeChange {
+	var changes []AttributeChange
+
+	if current["image"] != nil {
+		currentImage, _ := current["image"].(string)
+		image := desired.Spec.Image.Repository + ":" + desired.Spec.Image.Tag
+		if currentImage != image {
+			changes = append(changes, AttributeChange{
+				Path:     "spec.image",
+				OldValue: currentImage,
+				NewValue: image,
+			})
+		}
+	}
+
+	compareNumber := func(key, path string, value int) {
+		if current[key] == nil {
+			return
+		}
+		currentValue, _ := current[key].(float64)
+		if int(currentValue) != value {
+			changes = append(changes, AttributeChange{
+				Path:     path,
+				OldValue: int(currentValue),
+				NewValue: value,
+			})
+		}
+	}
+
+	cpu, memory := desired.TaskResources()
+	compareNumber("cpu", "infra.spec.resources.cpu", cpu)
+	compareNumber("memory", "infra.spec.resources.memory", memory)
+	compareNumber("stop_timeout", "spec.stopTimeout", desired.Spec.StopTimeout)
+	changes = append(changes, compareContainer(containerSpecOf(desired), current)...)
+
+	scaling := desired.Spec.Scaling
+	if scaling == nil {
+		return append(changes, compareAutoScaling(desired.DesiredCount(), desired.Infra.AutoScalingConfig(), current)...)
+	}
+
+	changes = append(changes, compareAutoscaled("spec.scaling", true, current)...)
+	compareNumber("min_replicas", "spec.scaling.minReplicas", scaling.MinReplicas)
+	compareNumber("max_replicas", "spec.scaling.maxReplicas", scaling.MaxReplicas)
+	compareNumber("messages_per_task", "spec.scaling.messagesPerTask", scaling.TargetMessagesPerTask())
+	compareNumber("scale_in_cooldown", "spec.scaling.scaleInCooldown", scaling.ScaleInCooldown)
+	compareNumber("scale_out_cooldown", "spec.scaling.scaleOutCooldown", scaling.ScaleOutCooldown)
+	if current["scaling_schedules"] != nil {
+		currentValue, _ := current["scaling_schedules"].(string)
+		if value := scheduledScalingSummary(scaling.Schedules); currentValue != value {
+			changes = append(changes, AttributeChange{
+				Path:     "spec.scaling.schedules",
+				OldValue: currentValue,
+				NewValue: value,
+			})
+		}
+	}
+
+	return changes
+}
+
+// compareAutoScaling compares the autoscaling of an ECS service, or its
+// desired count when it has none. Scaling is changed in place.
+func compareAutoScaling(desiredCount int, autoScaling *schema.AutoScaling, current map[string]interface{}) []AttributeChange {
+	changes := compareAutoscaled("infra.spec.scaling.autoscaling", autoScaling != nil, current)
+
+	compareNumber := func(key, path string, value int) {
+		if current[key] == nil {
+			return
+		}
+		currentValue, _ := current[key].(float64)
+		if int(currentValue) != value {
+			changes = append(changes, AttributeChange{
+				Path:     path,
+				OldValue: int(currentValue),
+				NewValue: value,
+			})
+		}
+	}
+
+	if autoScaling == nil {
+		compareNumber("desired_count", "infra.spec.scaling.replicas", desiredCount)
+		return changes
+	}
+
+	compareNumber("min_replicas", "infra.spec.scaling.autoscaling.minReplicas", autoScaling.MinReplicas)
+	compareNumber("max_replicas", "infra.spec.scaling.autoscaling.maxReplicas", autoScaling.MaxReplicas)
+	compareNumber("target_cpu", "infra.spec.scaling.autoscaling.targetCPUPercent", autoScaling.CPUTarget())
+	compareNumber("target_memory", "infra.spec.scaling.autoscaling.targetMemoryPercent", autoScaling.TargetMemoryPercent)
+	compareNumber("scale_in_cooldown", "infra.spec.scaling.autoscaling.scaleInCooldown", autoScaling.ScaleInCooldown)
+	compareNumber("scale_out_cooldown", "infra.spec.scaling.autoscaling.scaleOutCooldown", autoScaling.ScaleOutCooldown)
+	if current["scaling_schedules"] != nil {
+		currentValue, _ := current["scaling_schedules"].(string)
+		if value := scheduledScalingSummary(autoScaling.Schedules); currentValue != value {
+			changes = append(changes, AttributeChange{
+				Path:     "infra.spec.scaling.autoscaling.schedules",
+				OldValue: currentValue,
+				NewValue: value,
+			})
+		}
+	}
+
+	return changes
+}
+
+// compareAutoscaled detects an ECS service that starts or stops
+// autoscaling.
+func compareAutoscaled(path string, autoscaled bool, current map[string]interface{}) []AttributeChange {
+	currentValue, _ := current["autoscaled"].(bool)
+	if currentValue == autoscaled {
+		return nil
+	}
+	return []AttributeChange{{
+		Path:     path,
+		OldValue: currentValue,
+		NewValue: autoscaled,
+	}}
+}
+
+// compareCronJob compares cron job configuration
+func (d *Differ) compareCronJob(desired *schema.CronJob, current map[string]interface{}) []AttributeChange {
+	var changes []AttributeChange
+
+	compareString := func(key, path, value string) {
+		if current[key] == nil {
+			return
+		}
+		currentValue, _ := current[key].(string)
+		if currentValue != value {
+			changes = append(changes, AttributeChange{
+				Path:     path,
+				OldValue: currentValue,
+				NewValue: value,
+			})
+		}
+	}
+
+	compareNumber := func(key, path string, value int) {
+		if current[key] == nil {
+			return
+		}
+		currentValue, _ := current[key].(float64)
+		if int(currentValue) != value {
+			changes = append(changes, AttributeChange{
+				Path:     path,
+				OldValue: int(currentValue),
+				NewValue: value,
+			})
+		}
+	}
+
+	compareString("image", "spec.image", desired.Spec.Image.Repository+":"+desired.Spec.Image.Tag)
+	compareString("schedule", "spec.schedule", desired.Spec.Schedule)
+	compareString("timezone", "spec.timezone", desired.Spec.Timezone)
+
+	cpu, memory := desired.TaskResources()
+	compareNumber("cpu", "infra.spec.resources.cpu", cpu)
+	compareNumber("memory", "infra.spec.resources.memory", memory)
+	compareNumber("retry_count", "spec.retryCount", desired.Spec.RetryCount)
+
+	return append(changes, compareContainer(containerSpecOf(desired), current)...)
+}
+
+// compareLambda compares the code, configuration and triggers of a Lambda
+// function.
+func (d *Differ) compareLambda(desired *schema.Lambda, current map[string]interface{}) []AttributeChange {
+	var changes []AttributeChange
+
+	compareString := func(key, path, value string) {
+		currentValue, _ := current[key].(string)
+		if currentValue != value {
+			changes = append(changes, AttributeChange{
+				Path:     path,
+				OldValue: currentValue,
+				NewValue: value,
+			})
+		}
+	}
+
+	compareNumber := func(key, path string, value int) {
+		currentValue, _ := current[key].(float64)
+		if int(currentValue) != value {
+			changes = append(changes, AttributeChange{
+				Path:     path,
+				OldValue: int(currentValue),
+				NewValue: value,
+			})
+		}
+	}
+
+	// A function cannot change between a container image and a deployment
+	// package in place
+	currentCode, _ := current["code"].(string)
+	if code := lambdaCodeSummary(desired.Spec.Code); currentCode != code {
+		changes = append(changes, AttributeChange{
+			Path:          "spec.code",
+			OldValue:      currentCode,
+			NewValue:      code,
+			ForceRecreate: strings.HasPrefix(currentCode, "image:") != strings.HasPrefix(code, "image:"),
+		})
+	}
+	compareString("handler", "spec.handler", desired.Spec.Handler)
+	compareString("runtime", "spec.runtime", desired.Spec.Runtime)
+	compareNumber("memory", "spec.memory", desired.MemoryMB())
+	compareNumber("timeout", "spec.timeout", desired.TimeoutSeconds())
+	compareString("environment", "spec.environment", environmentSummary(lambdaEnvironment(desired)))
+
+	currentValue, _ := current["triggers"].(string)
+	if value := lambdaTriggerSummary(desired); currentValue != value {
+		changes = append(changes, AttributeChange{
+			Path:     "spec.triggers",
+			OldValue: currentValue,
+			NewValue: value,
+		})
+	}
+
+	currentValue, _ = current["provisioned_concurrency"].(string)
+	if value := provisionedConcurrencySummary(desired.Spec.ProvisionedConcurrency); currentValue != value {
+		changes = append(changes, AttributeChange{
+			Path:     "spec.provisionedConcurrency",
+			OldValue: currentValue,
+			NewValue: value,
+		})
+	}
+
+	return changes
+}
+
+// compareElastiCacheRedis compares Redis cache configuration
+func (d *Differ) compareElastiCacheRedis(desired *schema.ElastiCacheRedis, current map[string]interface{}) []AttributeChange {
+	var changes []AttributeChange
+
+	compareString := func(key, path, value string) {
+		if current[key] == nil || value == "" {
+			return
+		}
+		currentValue, _ := current[key].(string)
+		if currentValue != value {
+			changes = append(changes, AttributeChange{
+				Path:     path,
+				OldValue: currentValue,
+				NewValue: value,
+			})
+		}
+	}
+
+	compareNumber := func(key, path string, value int) {
+		if current[key] == nil {
+			return
+		}
+		currentValue, _ := current[key].(float64)
+		if int(currentValue) != value {
+			changes = append(changes, AttributeChange{
+				Path:     path,
+				OldValue: int(currentValue),
+				NewValue: value,
+			})
+		}
+	}
+
+	compareBool := func(key, path string, value bool) {
+		if current[key] == nil {
+			return
+		}
+		currentValue, _ := current[key].(bool)
+		if currentValue != value {
+			changes = append(changes, AttributeChange{
+				Path:     path,
+				OldValue: currentValue,
+				NewValue: value,
+			})
+		}
+	}
+
+	// An empty engine version leaves the version to ElastiCache
+	compareString("engine_version", "spec.engineVersion", desired.Spec.EngineVersion)
+	compareString("node_type", "spec.nodeType", desired.Spec.NodeType)
+	// The port is recorded from the cache's outputs, as a string
+	compareString("port", "spec.port", strconv.Itoa(desired.CachePort()))
+	compareNumber("num_shards", "spec.shards", desired.ShardCount())
+	compareNumber("replicas_per_shard", "spec.replicasPerShard", desired.Spec.ReplicasPerShard)
+	compareBool("at_rest_encryption", "spec.encryption.atRest", desired.Spec.Encryption.AtRest)
+	compareBool("transit_encryption", "spec.encryption.inTransit", desired.Spec.Encryption.InTransit)
+	compareBool("auth_enabled", "spec.authToken", desired.AuthEnabled())
+
+	return changes
+}
+
+// compareElastiCacheMemcached compares Memcached cache configuration
+func (d *Differ) compareElastiCacheMemcached(desired *schema.ElastiCacheMemcached, current map[string]interface{}) []AttributeChange {
+	var changes []AttributeChange
+
+	compareString := func(key, path, value string) {
+		if current[key] == nil || value == "" {
+			return
+		}
+		currentValue, _ := current[key].(string)
+		if currentValue != value {
+			changes = append(changes, AttributeChange{
+				Path:     path,
+				OldValue: currentValue,
+				NewValue: value,
+			})
+		}
+	}
+
+	compareString("engine_version", "spec.engineVersion", desired.Spec.EngineVersion)
+	compareString("node_type", "spec.nodeType", desired.Spec.NodeType)
+	compareString("port", "spec.port", strconv.Itoa(desired.CachePort()))
+
+	if current["num_nodes"] != nil {
+		currentNodes, _ := current["num_nodes"].(float64)
+		if int(currentNodes) != desired.NodeCount() {
+			changes = append(changes, AttributeChange{
+				Path:     "spec.nodes",
+				OldValue: int(currentNodes),
+				NewValue: desired.NodeCount(),
+			})
+		}
+	}
+
+	if current["transit_encryption"] != nil {
+		currentTransit, _ := current["transit_encryption"].(bool)
+		if currentTransit != desired.Spec.Encryption.InTransit {
+			changes = append(changes, AttributeChange{
+				Path:     "spec.encryption.inTransit",
+				OldValue: currentTransit,
+				NewValue: desired.Spec.Encryption.InTransit,
+			})
+		}
+	}
+
+	return changes
+}
+
 // compareValues compares two values and returns true if they differ
 func (d *Differ) compareValues(a, b interface{}) bool {
 	if d.options.DeepCompare {
@@ -493,6 +1118,9 @@ func (d *Differ) ComputeChangesFromFolderParse(
 
 	stackName := ""
 	environment := "default"
+	if desired.Environment != "" {
+		environment = desired.Environment
+	}
 	if desired.Stack != nil {
 		stackName = desired.Stack.Metadata.Name
 	}
@@ -0,0 +1,768 @@
+package diff
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourusername/panka/pkg/parser"
+	"github.com/yourusername/panka/pkg/parser/schema"
+	"github.com/yourusername/panka/pkg/state"
+)
+
+func createTestState(resources ...schema.Resource) *state.State {
+	st := state.NewState("test-stack", "default")
+	for _, res := range resources {
+		st.AddResource(res.GetMetadata().Name, &state.Resource{
+			ID:         res.GetMetadata().Name + "-id",
+			Type:       string(res.GetKind()),
+			Name:       res.GetMetadata().Name,
+			Attributes: StateAttributes(res),
+		})
+	}
+	return st
+}
+
+func computeTestChanges(t *testing.T, st *state.State, components ...schema.Resource) *ChangeSet {
+	t.Helper()
+	result := &parser.ParseResult{
+		Stack:      schema.NewStack("test-stack"),
+		Components: components,
+	}
+	cs, err := NewDiffer(nil).ComputeChanges(result, st, "test-stack", "default")
+	require.NoError(t, err)
+	return cs
+}
+
+func TestDiffer_ComputeChanges_Create(t *testing.T) {
+	queue := schema.NewSQS("queue", "backend", "test-stack")
+
+	cs := computeTestChanges(t, state.NewState("test-stack", "default"), queue)
+
+	require.Len(t, cs.Changes, 1)
+	assert.Equal(t, ChangeCreate, cs.Changes[0].Type)
+	assert.True(t, cs.HasChanges())
+}
+
+func TestDiffer_ComputeChanges_NoChange(t *testing.T) {
+	queue := schema.NewSQS("queue", "backend", "test-stack")
+	table := schema.NewDynamoDB("table", "backend", "test-stack")
+
+	cs := computeTestChanges(t, createTestState(queue, table), queue, table)
+
+	assert.False(t, cs.HasChanges())
+	assert.Equal(t, 2, cs.Summary.NoChange)
+}
+
+func TestDiffer_ComputeChanges_Update(t *testing.T) {
+	queue := schema.NewSQS("queue", "backend", "test-stack")
+	st := createTestState(queue)
+
+	updated := schema.NewSQS("queue", "backend", "test-stack")
+	updated.Spec.VisibilityTimeout = 120
+
+	cs := computeTestChanges(t, st, updated)
+
+	change := cs.GetChange("queue")
+	require.NotNil(t, change)
+	assert.Equal(t, ChangeUpdate, change.Type)
+	assert.Equal(t, "queue-id", change.ResourceID)
+	require.Len(t, change.AttributeChanges, 1)
+	assert.Equal(t, "spec.visibilityTimeout", change.AttributeChanges[0].Path)
+	assert.Equal(t, 30, change.AttributeChanges[0].OldValue)
+	assert.Equal(t, 120, change.AttributeChanges[0].NewValue)
+}
+
+func TestDiffer_ComputeChanges_Recreate(t *testing.T) {
+	queue := schema.NewSQS("queue", "backend", "test-stack")
+	st := createTestState(queue)
+
+	fifo := schema.NewSQS("queue", "backend", "test-stack")
+	fifo.Spec.Type = "fifo"
+
+	cs := computeTestChanges(t, st, fifo)
+
+	change := cs.GetChange("queue")
+	require.NotNil(t, change)
+	assert.Equal(t, ChangeRecreate, change.Type)
+	assert.True(t, change.RequiresRecreate)
+}
+
+func TestDiffer_ComputeChanges_Delete(t *testing.T) {
+	queue := schema.NewSQS("queue", "backend", "test-stack")
+
+	cs := computeTestChanges(t, createTestState(queue))
+
+	deletes := cs.GetDeletes()
+	require.Len(t, deletes, 1)
+	assert.Equal(t, "queue", deletes[0].ResourceName)
+	assert.Equal(t, "queue-id", deletes[0].ResourceID)
+}
+
+func TestDiffer_ComputeChanges_KnownAfterApply(t *testing.T) {
+	queue := schema.NewSQS("queue", "backend", "test-stack")
+	table := schema.NewDynamoDB("table", "backend", "test-stack")
+
+	api := schema.NewMicroService("api", "backend", "test-stack")
+	api.Spec.Environment = []schema.EnvironmentVariable{
+		{Name: "QUEUE_URL", ValueFrom: &schema.ValueFrom{Component: "queue", Output: "queue_url"}},
+		{Name: "TABLE", Value: "${table.table_name}"},
+	}
+
+	// The table already exists, so only the queue output is unknown
+	cs := computeTestChanges(t, createTestState(table), queue, table, api)
+
+	change := cs.GetChange("api")
+	require.NotNil(t, change)
+	assert.Equal(t, map[string]string{
+		"spec.environment[QUEUE_URL]": "queue.queue_url",
+	}, change.KnownAfterApply)
+}
+
+func TestDiffer_ComputeChanges_IgnoreChanges(t *testing.T) {
+	queue := schema.NewSQS("queue", "backend", "test-stack")
+	st := createTestState(queue)
+
+	updated := schema.NewSQS("queue", "backend", "test-stack")
+	updated.Spec.VisibilityTimeout = 120
+	updated.Spec.Type = "fifo"
+	updated.Metadata.Lifecycle = &schema.Lifecycle{IgnoreChanges: []string{"spec.visibilityTimeout", "spec.type"}}
+
+	cs := computeTestChanges(t, st, updated)
+
+	change := cs.GetChange("queue")
+	require.NotNil(t, change)
+	assert.Equal(t, ChangeNoChange, change.Type)
+	assert.Empty(t, change.AttributeChanges)
+}
+
+func TestDiffer_ComputeChanges_PreventDestroy(t *testing.T) {
+	table := schema.NewDynamoDB("table", "backend", "test-stack")
+	st := createTestState(table)
+
+	// Turning the protection on is recorded as an update
+	protected := schema.NewDynamoDB("table", "backend", "test-stack")
+	protected.Metadata.Lifecycle = &schema.Lifecycle{PreventDestroy: true}
+
+	cs := computeTestChanges(t, st, protected)
+	change := cs.GetChange("table")
+	require.NotNil(t, change)
+	assert.Equal(t, ChangeUpdate, change.Type)
+	require.Len(t, change.AttributeChanges, 1)
+	assert.Equal(t, "metadata.lifecycle.preventDestroy", change.AttributeChanges[0].Path)
+	assert.Empty(t, cs.PreventedDestroys())
+
+	// A protected resource in state cannot be removed from the configuration
+	res, _ := st.GetResource("table")
+	res.PreventDestroy = true
+	cs = computeTestChanges(t, st)
+	prevented := cs.PreventedDestroys()
+	require.Len(t, prevented, 1)
+	assert.Equal(t, "table", prevented[0].ResourceName)
+
+	// nor replaced while the configuration protects it
+	replaced := schema.NewDynamoDB("table", "backend", "test-stack")
+	replaced.Spec.HashKey.Name = "pk"
+	replaced.Metadata.Lifecycle = &schema.Lifecycle{PreventDestroy: true}
+	cs = computeTestChanges(t, st, replaced)
+	require.Equal(t, ChangeRecreate, cs.GetChange("table").Type)
+	assert.Len(t, cs.PreventedDestroys(), 1)
+
+	// Removing the protection in the same change allows the replacement
+	replaced.Metadata.Lifecycle.PreventDestroy = false
+	cs = computeTestChanges(t, st, replaced)
+	assert.Empty(t, cs.PreventedDestroys())
+}
+
+func TestDiffer_ComputeChanges_ForceNewAttributes(t *testing.T) {
+	bucket := schema.NewS3("assets", "backend", "test-stack")
+	bucket.Spec.Bucket.Name = "assets-v1"
+	st := createTestState(bucket)
+	res, _ := st.GetResource("assets")
+	res.Attributes["bucket_name"] = "assets-v1"
+
+	renamed := schema.NewS3("assets", "backend", "test-stack")
+	renamed.Spec.Bucket.Name = "assets-v2"
+	renamed.Metadata.Lifecycle = &schema.Lifecycle{CreateBeforeDestroy: true}
+
+	cs := computeTestChanges(t, st, renamed)
+
+	change := cs.GetChange("assets")
+	require.NotNil(t, change)
+	assert.Equal(t, ChangeRecreate, change.Type)
+	assert.True(t, change.CreateBeforeDestroy)
+	require.Len(t, change.AttributeChanges, 1)
+	assert.Equal(t, "spec.bucket.name", change.AttributeChanges[0].Path)
+	assert.True(t, change.AttributeChanges[0].ForceRecreate)
+
+	assert.Contains(t, ForceNewAttributes(schema.KindS3), "spec.bucket.name")
+	assert.Empty(t, ForceNewAttributes(schema.KindMicroService))
+}
+
+func TestDiffer_ComputeChanges_ReplacedDependency(t *testing.T) {
+	queue := schema.NewSQS("queue", "backend", "test-stack")
+	api := schema.NewMicroService("api", "backend", "test-stack")
+	api.Spec.Environment = []schema.EnvironmentVariable{
+		{Name: "QUEUE_URL", ValueFrom: &schema.ValueFrom{Component: "queue", Output: "queue_url"}},
+	}
+	st := createTestState(queue, api)
+
+	fifo := schema.NewSQS("queue", "backend", "test-stack")
+	fifo.Spec.Type = "fifo"
+
+	cs := computeTestChanges(t, st, fifo, api)
+
+	// The service is updated to pick up the URL of the new queue
+	change := cs.GetChange("api")
+	require.NotNil(t, change)
+	assert.Equal(t, ChangeUpdate, change.Type)
+	assert.Equal(t, "queue.queue_url", change.KnownAfterApply["spec.environment[QUEUE_URL]"])
+	assert.Equal(t, 1, cs.Summary.Recreate)
+	assert.Equal(t, 1, cs.Summary.Update)
+	assert.Equal(t, 0, cs.Summary.NoChange)
+}
+
+func TestStateAttributes(t *testing.T) {
+	table := schema.NewDynamoDB("table", "backend", "test-stack")
+	table.Spec.BillingMode = "PROVISIONED"
+	table.Spec.ReadCapacity = 5
+	table.Spec.WriteCapacity = 10
+
+	attrs := StateAttributes(table)
+	assert.Equal(t, "PROVISIONED", attrs["billing_mode"])
+	assert.Equal(t, float64(5), attrs["read_capacity"])
+	assert.Equal(t, float64(10), attrs["write_capacity"])
+
+	// Functions record their triggers, even when they have none
+	assert.Equal(t, map[string]interface{}{
+		"code":                    "",
+		"handler":                 "index.handler",
+		"runtime":                 "nodejs18.x",
+		"memory":                  float64(128),
+		"timeout":                 float64(30),
+		"environment":             "",
+		"triggers":                "",
+		"provisioned_concurrency": "",
+		"access":                  "",
+		"secrets":                 "",
+	}, StateAttributes(schema.NewLambda("fn", "backend", "test-stack")))
+}
+
+func TestDiffer_ComputeChanges_MicroService(t *testing.T) {
+	api := schema.NewMicroService("api", "backend", "test-stack")
+	api.Spec.Image = schema.ImageConfig{Repository: "example/api", Tag: "1.0.0"}
+	st := createTestState(api)
+
+	// Unchanged
+	cs := computeTestChanges(t, st, api)
+	assert.Equal(t, ChangeNoChange, cs.GetChange("api").Type)
+
+	// A new image and task size are rolled out in place
+	updated := schema.NewMicroService("api", "backend", "test-stack")
+	updated.Spec.Image = schema.ImageConfig{Repository: "example/api", Tag: "1.1.0"}
+	updated.Infra = schema.NewComponentInfra("api", "backend", "test-stack")
+	updated.Infra.Spec.Resources.CPU = 512
+	updated.Infra.Spec.Resources.Memory = 1024
+
+	cs = computeTestChanges(t, st, updated)
+	change := cs.GetChange("api")
+	require.NotNil(t, change)
+	assert.Equal(t, ChangeUpdate, change.Type)
+
+	paths := make([]string, 0, len(change.AttributeChanges))
+	for _, ac := range change.AttributeChanges {
+		paths = append(paths, ac.Path)
+	}
+	assert.ElementsMatch(t, []string{"spec.image", "infra.spec.resources.cpu", "infra.spec.resources.memory"}, paths)
+}
+
+func TestDiffer_ComputeChanges_Container(t *testing.T) {
+	api := schema.NewMicroService("api", "backend", "test-stack")
+	api.Spec.Image = schema.ImageConfig{Repository: "example/api", Tag: "1.0.0"}
+	api.Spec.Environment = []schema.EnvironmentVariable{
+		{Name: "LOG_LEVEL", Value: "info"},
+		{Name: "ORDERS_URL", ValueFrom: &schema.ValueFrom{Component: "orders", Output: "url"}},
+	}
+	api.Spec.Ports = []schema.Port{{Name: "http", Port: 8080}}
+	st := createTestState(api)
+
+	// Values are recorded as hashes
+	environment := st.Resources["api"].Attributes["environment"].(string)
+	assert.NotContains(t, environment, "info")
+	assert.Contains(t, environment, "ORDERS_URL=from:orders.url")
+	assert.Equal(t, "http=8080/tcp", st.Resources["api"].Attributes["ports"])
+
+	cs := computeTestChanges(t, st, api)
+	assert.Equal(t, ChangeNoChange, cs.GetChange("api").Type)
+
+	// Changing the value of a variable rolls out a new task definition
+	api.Spec.Environment[0].Value = "debug"
+	cs = computeTestChanges(t, st, api)
+	change := cs.GetChange("api")
+	require.NotNil(t, change)
+	assert.Equal(t, ChangeUpdate, change.Type)
+	require.Len(t, change.AttributeChanges, 1)
+	assert.Equal(t, "spec.environment", change.AttributeChanges[0].Path)
+	assert.False(t, change.AttributeChanges[0].ForceRecreate)
+
+	// So do a new command, arguments, port and health check
+	api.Spec.Environment[0].Value = "info"
+	api.Spec.Command = []string{"/bin/api"}
+	api.Spec.Args = []string{"--port", "8080"}
+	api.Spec.Ports = append(api.Spec.Ports, schema.Port{Name: "metrics", Port: 9090, Protocol: "UDP"})
+	api.Spec.HealthCheck = &schema.HealthCheck{
+		Liveness: &schema.HealthCheckProbe{HTTP: &schema.HTTPHealthCheck{Path: "/health", Port: 8080}},
+	}
+	cs = computeTestChanges(t, st, api)
+	change = cs.GetChange("api")
+	require.NotNil(t, change)
+
+	paths := make([]string, 0, len(change.AttributeChanges))
+	for _, ac := range change.AttributeChanges {
+		paths = append(paths, ac.Path)
+	}
+	assert.ElementsMatch(t, []string{"spec.command", "spec.args", "spec.ports", "spec.healthCheck"}, paths)
+	for _, ac := range change.AttributeChanges {
+		if ac.Path == "spec.args" {
+			assert.Equal(t, `["--port","8080"]`, ac.NewValue)
+		}
+	}
+}
+
+func TestDiffer_ComputeChanges_Lambda(t *testing.T) {
+	fn := schema.NewLambda("processor", "backend", "test-stack")
+	fn.Spec.Code = schema.LambdaCode{S3Bucket: "artifacts", S3Key: "processor-1.0.zip"}
+	fn.Spec.Environment = map[string]interface{}{"BATCH_SIZE": 10}
+	st := createTestState(fn)
+
+	cs := computeTestChanges(t, st, fn)
+	assert.Equal(t, ChangeNoChange, cs.GetChange("processor").Type)
+
+	// New code and configuration are deployed in place
+	fn.Spec.Code.S3Key = "processor-1.1.zip"
+	fn.Spec.Handler = "main.handler"
+	fn.Spec.Runtime = "nodejs20.x"
+	fn.Spec.Memory = "512"
+	fn.Spec.Timeout = "60"
+	fn.Spec.Environment["BATCH_SIZE"] = 20
+
+	cs = computeTestChanges(t, st, fn)
+	change := cs.GetChange("processor")
+	require.NotNil(t, change)
+	assert.Equal(t, ChangeUpdate, change.Type)
+
+	paths := make([]string, 0, len(change.AttributeChanges))
+	for _, ac := range change.AttributeChanges {
+		paths = append(paths, ac.Path)
+		assert.False(t, ac.ForceRecreate)
+	}
+	assert.ElementsMatch(t, []string{"spec.code", "spec.handler", "spec.runtime", "spec.memory", "spec.timeout", "spec.environment"}, paths)
+
+	// Moving to a container image replaces the function
+	image := schema.NewLambda("processor", "backend", "test-stack")
+	image.Spec.Code = schema.LambdaCode{ImageUri: "123456789012.dkr.ecr.us-east-1.amazonaws.com/processor:1.0"}
+	image.Spec.Environment = map[string]interface{}{"BATCH_SIZE": 10}
+
+	cs = computeTestChanges(t, st, image)
+	change = cs.GetChange("processor")
+	require.NotNil(t, change)
+	assert.Equal(t, ChangeRecreate, change.Type)
+	require.Len(t, change.AttributeChanges, 1)
+	assert.Equal(t, "spec.code", change.AttributeChanges[0].Path)
+	assert.True(t, change.AttributeChanges[0].ForceRecreate)
+}
+
+func TestDiffer_ComputeChanges_LambdaTriggers(t *testing.T) {
+	fn := schema.NewLambda("processor", "backend", "test-stack")
+	st := createTestState(fn)
+
+	cs := computeTestChanges(t, st, fn)
+	assert.Equal(t, ChangeNoChange, cs.GetChange("processor").Type)
+
+	// Adding a trigger updates the function
+	fn.Spec.Triggers = []schema.LambdaTrigger{
+		{Type: schema.TriggerSQS, Source: &schema.TriggerSource{Component: "orders"}, BatchSize: "10"},
+	}
+	cs = computeTestChanges(t, st, fn)
+	change := cs.GetChange("processor")
+	require.NotNil(t, change)
+	assert.Equal(t, ChangeUpdate, change.Type)
+	require.Len(t, change.AttributeChanges, 1)
+	assert.Equal(t, "spec.triggers", change.AttributeChanges[0].Path)
+	assert.Equal(t, "sqs:orders batch=10", change.AttributeChanges[0].NewValue)
+
+	// Triggers compare regardless of their order
+	st = createTestState(fn)
+	fn.Spec.Triggers = append([]schema.LambdaTrigger{
+		{Type: schema.TriggerS3, S3: &schema.S3Trigger{Bucket: "uploads", Prefix: "images/"}},
+	}, fn.Spec.Triggers...)
+	cs = computeTestChanges(t, st, fn)
+	assert.Equal(t, ChangeUpdate, cs.GetChange("processor").Type)
+
+	st = createTestState(fn)
+	fn.Spec.Triggers[0], fn.Spec.Triggers[1] = fn.Spec.Triggers[1], fn.Spec.Triggers[0]
+	cs = computeTestChanges(t, st, fn)
+	assert.Equal(t, ChangeNoChange, cs.GetChange("processor").Type)
+
+	// Removing every trigger is also an update
+	fn.Spec.Triggers = nil
+	cs = computeTestChanges(t, st, fn)
+	assert.Equal(t, ChangeUpdate, cs.GetChange("processor").Type)
+}
+
+func TestDiffer_ComputeChanges_Access(t *testing.T) {
+	worker := schema.NewWorker("consumer", "backend", "test-stack")
+	worker.Spec.Image = schema.ImageConfig{Repository: "example/consumer", Tag: "1.0.0"}
+	st := createTestState(worker)
+
+	// Granting access updates the worker's roles
+	worker.Spec.DependsOn = []string{"orders", "users"}
+	worker.Spec.Access = []schema.ComponentAccess{
+		{Component: "users", Mode: schema.AccessRead},
+		{Component: "orders", Mode: schema.AccessReadWrite},
+	}
+	cs := computeTestChanges(t, st, worker)
+	change := cs.GetChange("consumer")
+	require.NotNil(t, change)
+	assert.Equal(t, ChangeUpdate, change.Type)
+	require.Len(t, change.AttributeChanges, 1)
+	assert.Equal(t, "spec.access", change.AttributeChanges[0].Path)
+	assert.Equal(t, "orders:readwrite,users:read", change.AttributeChanges[0].NewValue)
+
+	st = createTestState(worker)
+	cs = computeTestChanges(t, st, worker)
+	assert.Equal(t, ChangeNoChange, cs.GetChange("consumer").Type)
+
+	// Removing every grant is also an update
+	worker.Spec.Access = nil
+	cs = computeTestChanges(t, st, worker)
+	assert.Equal(t, ChangeUpdate, cs.GetChange("consumer").Type)
+}
+
+func TestDiffer_ComputeChanges_Secrets(t *testing.T) {
+	fn := schema.NewLambda("processor", "backend", "test-stack")
+	st := createTestState(fn)
+
+	// Adding a secret updates the function, recording only its reference
+	fn.Spec.Secrets = []schema.Secret{
+		{Name: "token", SecretRef: "panka/acme/test-stack/token", EnvVar: "API_TOKEN"},
+		{Name: "DB_PASSWORD", SecretRef: "/panka/acme/test-stack/db"},
+	}
+	cs := computeTestChanges(t, st, fn)
+	change := cs.GetChange("processor")
+	require.NotNil(t, change)
+	assert.Equal(t, ChangeUpdate, change.Type)
+	require.Len(t, change.AttributeChanges, 1)
+	assert.Equal(t, "spec.secrets", change.AttributeChanges[0].Path)
+	assert.Equal(t, "API_TOKEN=panka/acme/test-stack/token,DB_PASSWORD=/panka/acme/test-stack/db", change.AttributeChanges[0].NewValue)
+
+	st = createTestState(fn)
+	cs = computeTestChanges(t, st, fn)
+	assert.Equal(t, ChangeNoChange, cs.GetChange("processor").Type)
+
+	// Pointing a variable at another secret is an update
+	fn.Spec.Secrets[1].SecretRef = "/panka/acme/test-stack/db-v2"
+	cs = computeTestChanges(t, st, fn)
+	assert.Equal(t, ChangeUpdate, cs.GetChange("processor").Type)
+}
+
+func TestDiffer_ComputeChanges_Configs(t *testing.T) {
+	api := schema.NewMicroService("api", "backend", "test-stack")
+	api.Spec.Image = schema.ImageConfig{Repository: "example/api", Tag: "1.0.0"}
+	api.Spec.Configs = &schema.ConfigsMount{
+		MountPath: "/etc/api",
+		Files:     []string{"app.conf"},
+		Contents:  map[string]string{"app.conf": "workers = 4\n"},
+	}
+	st := createTestState(api)
+
+	cs := computeTestChanges(t, st, api)
+	assert.Equal(t, ChangeNoChange, cs.GetChange("api").Type)
+
+	// Changing the content of a file rolls out a new task definition
+	api.Spec.Configs.Contents["app.conf"] = "workers = 8\n"
+	cs = computeTestChanges(t, st, api)
+	change := cs.GetChange("api")
+	require.NotNil(t, change)
+	assert.Equal(t, ChangeUpdate, change.Type)
+	require.Len(t, change.AttributeChanges, 1)
+	assert.Equal(t, "spec.configs", change.AttributeChanges[0].Path)
+	assert.Equal(t, "/etc/api:app.conf="+api.Spec.Configs.FileHash("app.conf")[:12], change.AttributeChanges[0].NewValue)
+
+	// Removing the configs is also an update
+	api.Spec.Configs = nil
+	cs = computeTestChanges(t, st, api)
+	assert.Equal(t, ChangeUpdate, cs.GetChange("api").Type)
+}
+
+func TestDiffer_ComputeChanges_LoadBalancer(t *testing.T) {
+	api := schema.NewMicroService("api", "backend", "test-stack")
+	api.Spec.Image = schema.ImageConfig{Repository: "example/api", Tag: "1.0.0"}
+	api.Infra = schema.NewComponentInfra("api", "backend", "test-stack")
+	api.Infra.Spec.Networking.LoadBalancer = &schema.LoadBalancerConfig{Enabled: true, AllowedCIDRs: []string{"10.0.0.0/8"}}
+	st := createTestState(api)
+
+	cs := computeTestChanges(t, st, api)
+	assert.Equal(t, ChangeNoChange, cs.GetChange("api").Type)
+
+	// Moving to the shared load balancer and restricting access are
+	// changed in place
+	updated := schema.NewMicroService("api", "backend", "test-stack")
+	updated.Spec.Image = schema.ImageConfig{Repository: "example/api", Tag: "1.0.0"}
+	updated.Infra = schema.NewComponentInfra("api", "backend", "test-stack")
+	updated.Infra.Spec.Networking.LoadBalancer = &schema.LoadBalancerConfig{
+		Enabled:      true,
+		Shared:       true,
+		AllowedCIDRs: []string{"10.0.0.0/8", "192.168.0.0/16"},
+	}
+	updated.Infra.Spec.Networking.Ingress = &schema.IngressConfig{Enabled: true, Hostname: "api.example.com"}
+
+	cs = computeTestChanges(t, st, updated)
+	change := cs.GetChange("api")
+	require.NotNil(t, change)
+	assert.Equal(t, ChangeUpdate, change.Type)
+
+	paths := make([]string, 0, len(change.AttributeChanges))
+	for _, ac := range change.AttributeChanges {
+		paths = append(paths, ac.Path)
+		assert.False(t, ac.ForceRecreate)
+	}
+	assert.ElementsMatch(t, []string{
+		"infra.spec.networking.loadBalancer",
+		"infra.spec.networking.loadBalancer.allowedCIDRs",
+		"infra.spec.networking.ingress.hostname",
+	}, paths)
+
+	// Removing the load balancer is an update too
+	removed := schema.NewMicroService("api", "backend", "test-stack")
+	removed.Spec.Image = schema.ImageConfig{Repository: "example/api", Tag: "1.0.0"}
+
+	cs = computeTestChanges(t, st, removed)
+	change = cs.GetChange("api")
+	require.NotNil(t, change)
+	assert.Equal(t, ChangeUpdate, change.Type)
+	require.Len(t, change.AttributeChanges, 1)
+	assert.Equal(t, "dedicated", change.AttributeChanges[0].OldValue)
+	assert.Equal(t, "none", change.AttributeChanges[0].NewValue)
+}
+
+func TestDiffer_ComputeChanges_Worker(t *testing.T) {
+	worker := schema.NewWorker("consumer", "backend", "test-stack")
+	worker.Spec.Image = schema.ImageConfig{Repository: "example/consumer", Tag: "1.0.0"}
+	worker.Spec.Scaling = &schema.QueueScaling{Queue: "orders", MinReplicas: 1, MaxReplicas: 5}
+	st := createTestState(worker)
+
+	cs := computeTestChanges(t, st, worker)
+	assert.Equal(t, ChangeNoChange, cs.GetChange("consumer").Type)
+
+	// Autoscaling owns the desired count, so replicas are not compared
+	updated := schema.NewWorker("consumer", "backend", "test-stack")
+	updated.Spec.Image = schema.ImageConfig{Repository: "example/consumer", Tag: "1.0.0"}
+	updated.Spec.StopTimeout = 90
+	updated.Spec.Scaling = &schema.QueueScaling{Queue: "orders", MinReplicas: 1, MaxReplicas: 20}
+	updated.Infra = schema.NewComponentInfra("consumer", "backend", "test-stack")
+	updated.Infra.Spec.Scaling.Replicas = 3
+
+	cs = computeTestChanges(t, st, updated)
+	change := cs.GetChange("consumer")
+	require.NotNil(t, change)
+	assert.Equal(t, ChangeUpdate, change.Type)
+
+	paths := make([]string, 0, len(change.AttributeChanges))
+	for _, ac := range change.AttributeChanges {
+		paths = append(paths, ac.Path)
+	}
+	assert.ElementsMatch(t, []string{"spec.stopTimeout", "spec.scaling.maxReplicas"}, paths)
+
+	// A new variable, command and health check roll out a new task
+	// definition
+	st = createTestState(updated)
+	updated.Spec.Environment = []schema.EnvironmentVariable{{Name: "BATCH_SIZE", Value: "10"}}
+	updated.Spec.Command = []string{"/bin/consumer"}
+	updated.Spec.HealthCheck = &schema.HealthCheck{
+		Liveness: &schema.HealthCheckProbe{Exec: &schema.ExecHealthCheck{Command: []string{"/bin/check"}}},
+	}
+
+	cs = computeTestChanges(t, st, updated)
+	change = cs.GetChange("consumer")
+	require.NotNil(t, change)
+	assert.Equal(t, ChangeUpdate, change.Type)
+
+	paths = paths[:0]
+	for _, ac := range change.AttributeChanges {
+		paths = append(paths, ac.Path)
+	}
+	assert.ElementsMatch(t, []string{"spec.environment", "spec.command", "spec.healthCheck"}, paths)
+}
+
+func TestDiffer_ComputeChanges_AutoScaling(t *testing.T) {
+	api := schema.NewMicroService("api", "backend", "test-stack")
+	api.Spec.Image = schema.ImageConfig{Repository: "example/api", Tag: "1.0.0"}
+	api.Infra = schema.NewComponentInfra("api", "backend", "test-stack")
+	api.Infra.Spec.Scaling.Replicas = 2
+	st := createTestState(api)
+
+	paths := func(change *Change) []string {
+		require.NotNil(t, change)
+		result := make([]string, 0, len(change.AttributeChanges))
+		for _, ac := range change.AttributeChanges {
+			result = append(result, ac.Path)
+		}
+		return result
+	}
+
+	// Enabling autoscaling hands the desired count over to it
+	night := 1
+	api.Infra.Spec.Scaling.AutoScaling = &schema.AutoScaling{
+		Enabled:     true,
+		MinReplicas: 2,
+		MaxReplicas: 10,
+		Schedules: []schema.ScheduledScaling{
+			{Name: "night", Schedule: "0 22 * * *", Timezone: "Europe/Berlin", MinCapacity: &night},
+		},
+	}
+	cs := computeTestChanges(t, st, api)
+	assert.Equal(t, []string{"infra.spec.scaling.autoscaling"}, paths(cs.GetChange("api")))
+
+	// Replicas are left to autoscaling, while its settings are compared
+	st = createTestState(api)
+	api.Infra.Spec.Scaling.Replicas = 4
+	cs = computeTestChanges(t, st, api)
+	assert.Equal(t, ChangeNoChange, cs.GetChange("api").Type)
+
+	api.Infra.Spec.Scaling.AutoScaling.MaxReplicas = 20
+	api.Infra.Spec.Scaling.AutoScaling.TargetMemoryPercent = 80
+	api.Infra.Spec.Scaling.AutoScaling.Schedules[0].Schedule = "0 23 * * *"
+	cs = computeTestChanges(t, st, api)
+	assert.ElementsMatch(t, []string{
+		"infra.spec.scaling.autoscaling.maxReplicas",
+		"infra.spec.scaling.autoscaling.targetCPUPercent",
+		"infra.spec.scaling.autoscaling.targetMemoryPercent",
+		"infra.spec.scaling.autoscaling.schedules",
+	}, paths(cs.GetChange("api")))
+
+	// Disabling it fixes the desired count again
+	st = createTestState(api)
+	api.Infra.Spec.Scaling.AutoScaling.Enabled = false
+	cs = computeTestChanges(t, st, api)
+	assert.Equal(t, []string{"infra.spec.scaling.autoscaling"}, paths(cs.GetChange("api")))
+
+	// Schedules of a worker scaling on its queue are compared too
+	worker := schema.NewWorker("consumer", "backend", "test-stack")
+	worker.Spec.Image = schema.ImageConfig{Repository: "example/consumer", Tag: "1.0.0"}
+	worker.Spec.Scaling = &schema.QueueScaling{Queue: "orders", MinReplicas: 1, MaxReplicas: 5}
+	st = createTestState(worker)
+	worker.Spec.Scaling.Schedules = []schema.ScheduledScaling{{Name: "night", Schedule: "0 22 * * *", MinCapacity: &night}}
+	cs = computeTestChanges(t, st, worker)
+	assert.Equal(t, []string{"spec.scaling.schedules"}, paths(cs.GetChange("consumer")))
+
+	// Provisioned concurrency of functions
+	fn := schema.NewLambda("processor", "backend", "test-stack")
+	st = createTestState(fn)
+	fn.Spec.ProvisionedConcurrency = &schema.ProvisionedConcurrency{MinCapacity: 1, MaxCapacity: 10}
+	cs = computeTestChanges(t, st, fn)
+	change := cs.GetChange("processor")
+	assert.Equal(t, []string{"spec.provisionedConcurrency"}, paths(change))
+	assert.Equal(t, "1-10 target=70 cooldown=0/0", change.AttributeChanges[0].NewValue)
+}
+
+func TestDiffer_ComputeChanges_CronJob(t *testing.T) {
+	job := schema.NewCronJob("report", "backend", "test-stack")
+	job.Spec.Image = schema.ImageConfig{Repository: "example/report", Tag: "1.0.0"}
+	job.Spec.Schedule = "0 3 * * *"
+	st := createTestState(job)
+
+	cs := computeTestChanges(t, st, job)
+	assert.Equal(t, ChangeNoChange, cs.GetChange("report").Type)
+
+	updated := schema.NewCronJob("report", "backend", "test-stack")
+	updated.Spec.Image = schema.ImageConfig{Repository: "example/report", Tag: "1.1.0"}
+	updated.Spec.Schedule = "30 4 * * 1-5"
+	updated.Spec.Timezone = "Europe/Berlin"
+	updated.Spec.RetryCount = 3
+
+	cs = computeTestChanges(t, st, updated)
+	change := cs.GetChange("report")
+	require.NotNil(t, change)
+	assert.Equal(t, ChangeUpdate, change.Type)
+
+	paths := make([]string, 0, len(change.AttributeChanges))
+	for _, ac := range change.AttributeChanges {
+		paths = append(paths, ac.Path)
+	}
+	assert.ElementsMatch(t, []string{"spec.image", "spec.schedule", "spec.timezone", "spec.retryCount"}, paths)
+
+	// Changing a variable or the arguments registers a new task definition
+	updated.Spec.Environment = []schema.EnvironmentVariable{{Name: "REPORT_DAYS", Value: "7"}}
+	st = createTestState(updated)
+	updated.Spec.Environment[0].Value = "30"
+	updated.Spec.Args = []string{"--format", "csv"}
+
+	cs = computeTestChanges(t, st, updated)
+	change = cs.GetChange("report")
+	require.NotNil(t, change)
+
+	paths = paths[:0]
+	for _, ac := range change.AttributeChanges {
+		paths = append(paths, ac.Path)
+	}
+	assert.ElementsMatch(t, []string{"spec.environment", "spec.args"}, paths)
+}
+
+func TestDiffer_ComputeChanges_ElastiCache(t *testing.T) {
+	redis := schema.NewElastiCacheRedis("sessions", "backend", "test-stack")
+	memcached := schema.NewElastiCacheMemcached("pages", "backend", "test-stack")
+	st := createTestState(redis, memcached)
+
+	cs := computeTestChanges(t, st, redis, memcached)
+	assert.Equal(t, ChangeNoChange, cs.GetChange("sessions").Type)
+	assert.Equal(t, ChangeNoChange, cs.GetChange("pages").Type)
+
+	updatedRedis := schema.NewElastiCacheRedis("sessions", "backend", "test-stack")
+	updatedRedis.Spec.NodeType = "cache.r7g.large"
+	updatedRedis.Spec.ReplicasPerShard = 2
+	updatedMemcached := schema.NewElastiCacheMemcached("pages", "backend", "test-stack")
+	updatedMemcached.Spec.Nodes = 3
+
+	cs = computeTestChanges(t, st, updatedRedis, updatedMemcached)
+	change := cs.GetChange("sessions")
+	require.NotNil(t, change)
+	assert.Equal(t, ChangeUpdate, change.Type)
+	paths := make([]string, 0, len(change.AttributeChanges))
+	for _, ac := range change.AttributeChanges {
+		paths = append(paths, ac.Path)
+	}
+	assert.ElementsMatch(t, []string{"spec.nodeType", "spec.replicasPerShard"}, paths)
+
+	change = cs.GetChange("pages")
+	require.NotNil(t, change)
+	assert.Equal(t, ChangeUpdate, change.Type)
+	require.Len(t, change.AttributeChanges, 1)
+	assert.Equal(t, "spec.nodes", change.AttributeChanges[0].Path)
+
+	// Encryption at rest cannot be changed in place
+	updatedRedis = schema.NewElastiCacheRedis("sessions", "backend", "test-stack")
+	updatedRedis.Spec.Encryption.AtRest = false
+	cs = computeTestChanges(t, st, updatedRedis, memcached)
+	change = cs.GetChange("sessions")
+	require.NotNil(t, change)
+	assert.True(t, change.RequiresRecreate)
+}
+
+func TestChangeSet_Filter(t *testing.T) {
+	queue := schema.NewSQS("queue", "backend", "test-stack")
+	topic := schema.NewSNS("topic", "backend", "test-stack")
+
+	cs := computeTestChanges(t, createTestState(queue), topic)
+	cs.TenantID = "tenant-1"
+	require.Equal(t, 1, cs.Summary.Create)
+	require.Equal(t, 1, cs.Summary.Delete)
+
+	filtered := cs.Filter(func(change *Change) bool {
+		return change.ResourceName == "topic"
+	})
+	assert.Len(t, filtered.Changes, 1)
+	assert.Equal(t, 1, filtered.Summary.Create)
+	assert.Equal(t, 0, filtered.Summary.Delete)
+	assert.Equal(t, "tenant-1", filtered.TenantID)
+}
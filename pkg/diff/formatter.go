@@ -2,9 +2,11 @@ package diff
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/fatih/color"
+	"github.com/yourusername/panka/pkg/parser"
 )
 
 // Formatter formats change sets for display
@@ -152,6 +154,15 @@ func (f *Formatter) formatChange(change *Change) string {
 		sb.WriteString(fmt.Sprintf("      ID: %s\n", change.ResourceID))
 	}
 
+	// Order of the replacement steps
+	if change.Type == ChangeRecreate {
+		if change.CreateBeforeDestroy {
+			sb.WriteString(changeColor.Sprint("      Replacement: create new, then delete old (createBeforeDestroy)\n"))
+		} else {
+			sb.WriteString(changeColor.Sprint("      Replacement: delete old, then create new\n"))
+		}
+	}
+
 	// Show attribute changes for updates
 	if f.ShowDetails && len(change.AttributeChanges) > 0 {
 		sb.WriteString("      Changes:\n")
@@ -160,6 +171,22 @@ func (f *Formatter) formatChange(change *Change) string {
 		}
 	}
 
+	// Show values that depend on components applied first
+	if f.ShowDetails && len(change.KnownAfterApply) > 0 {
+		paths := make([]string, 0, len(change.KnownAfterApply))
+		for path := range change.KnownAfterApply {
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+
+		dimColor := color.New(color.Faint)
+		sb.WriteString("      Known after apply:\n")
+		for _, path := range paths {
+			sb.WriteString(fmt.Sprintf("        %s: ", path))
+			sb.WriteString(dimColor.Sprintf("%s from %s\n", parser.KnownAfterApply, change.KnownAfterApply[path]))
+		}
+	}
+
 	// Show reason
 	if change.Reason != "" && change.Type != ChangeNoChange {
 		dimColor := color.New(color.Faint)
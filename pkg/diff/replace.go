@@ -0,0 +1,36 @@
+package diff
+
+import (
+	"github.com/yourusername/panka/pkg/parser/schema"
+)
+
+// forceNewAttributes lists, per kind, the attributes that AWS cannot change
+// in place. Changing one of them replaces the resource: it is deleted and
+// created again, or created first with lifecycle.createBeforeDestroy.
+var forceNewAttributes = map[schema.Kind][]string{
+	schema.KindS3:       {"spec.bucket.name"},
+	schema.KindDynamoDB: {"spec.hashKey.name", "spec.hashKey.type", "spec.rangeKey.name", "spec.rangeKey.type"},
+	schema.KindSQS:      {"spec.type"},
+	schema.KindSNS:      {"spec.fifoTopic"},
+	schema.KindRDS:      {"spec.engine.type"},
+
+	schema.KindElastiCacheRedis:     {"spec.encryption.atRest", "spec.encryption.inTransit", "spec.port"},
+	schema.KindElastiCacheMemcached: {"spec.nodeType", "spec.encryption.inTransit", "spec.port"},
+}
+
+// ForceNewAttributes returns the attribute paths of a kind whose changes
+// replace the resource
+func ForceNewAttributes(kind schema.Kind) []string {
+	return append([]string(nil), forceNewAttributes[kind]...)
+}
+
+// forcesReplacement reports whether a change to an attribute of a kind
+// replaces the resource
+func forcesReplacement(kind schema.Kind, path string) bool {
+	for _, attr := range forceNewAttributes[kind] {
+		if attr == path {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,45 @@
+package diff
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourusername/panka/pkg/parser/schema"
+)
+
+func TestForceNewAttributes_DynamoDBKeyTypes(t *testing.T) {
+	attrs := ForceNewAttributes(schema.KindDynamoDB)
+	assert.Contains(t, attrs, "spec.hashKey.type")
+	assert.Contains(t, attrs, "spec.rangeKey.type")
+
+	newTable := func(hashType, rangeType string) *schema.DynamoDB {
+		table := schema.NewDynamoDB("table", "backend", "test-stack")
+		table.Spec.HashKey = schema.AttributeDefinition{Name: "pk", Type: hashType}
+		table.Spec.RangeKey = &schema.AttributeDefinition{Name: "sk", Type: rangeType}
+		return table
+	}
+	st := createTestState(newTable("S", "S"))
+
+	tests := []struct {
+		name    string
+		desired *schema.DynamoDB
+		path    string
+	}{
+		{"hash key type", newTable("N", "S"), "spec.hashKey.type"},
+		{"range key type", newTable("S", "B"), "spec.rangeKey.type"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cs := computeTestChanges(t, st, tt.desired)
+
+			change := cs.GetChange("table")
+			require.NotNil(t, change)
+			assert.Equal(t, ChangeRecreate, change.Type)
+			require.Len(t, change.AttributeChanges, 1)
+			assert.Equal(t, tt.path, change.AttributeChanges[0].Path)
+			assert.True(t, change.AttributeChanges[0].ForceRecreate)
+		})
+	}
+}
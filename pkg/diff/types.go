@@ -60,8 +60,16 @@ type Change struct {
 	// RequiresRecreate indicates if the resource must be recreated
 	RequiresRecreate bool `json:"requires_recreate,omitempty"`
 
+	// CreateBeforeDestroy indicates that a replacement is created before
+	// the resource it replaces is deleted
+	CreateBeforeDestroy bool `json:"create_before_destroy,omitempty"`
+
 	// DependsOn lists resources this change depends on
 	DependsOn []string `json:"depends_on,omitempty"`
+
+	// KnownAfterApply maps the attributes that reference outputs of
+	// components created by the same change set to those outputs
+	KnownAfterApply map[string]string `json:"known_after_apply,omitempty"`
 }
 
 // AttributeChange represents a change to a specific attribute
@@ -146,6 +154,16 @@ func (cs *ChangeSet) updateSummary(changeType ChangeType) {
 	cs.Summary.Total++
 }
 
+// setType changes the type of a change in the set, keeping the summary
+// counts in line
+func (cs *ChangeSet) setType(change *Change, changeType ChangeType) {
+	cs.Summary = ChangeSummary{}
+	change.Type = changeType
+	for _, c := range cs.Changes {
+		cs.updateSummary(c.Type)
+	}
+}
+
 // HasChanges returns true if there are any changes to apply
 func (cs *ChangeSet) HasChanges() bool {
 	return cs.Summary.Create > 0 || cs.Summary.Update > 0 ||
@@ -178,6 +196,30 @@ func (cs *ChangeSet) GetDeletes() []*Change {
 	return cs.GetChangesByType(ChangeDelete)
 }
 
+// GetChange returns the change for a resource by name, or nil if there is none
+func (cs *ChangeSet) GetChange(resourceName string) *Change {
+	for _, change := range cs.Changes {
+		if change.ResourceName == resourceName {
+			return change
+		}
+	}
+	return nil
+}
+
+// Filter returns a new change set containing only the changes for which keep
+// returns true
+func (cs *ChangeSet) Filter(keep func(change *Change) bool) *ChangeSet {
+	filtered := NewChangeSet(cs.StackName, cs.Environment)
+	filtered.TenantID = cs.TenantID
+	filtered.CreatedAt = cs.CreatedAt
+	for _, change := range cs.Changes {
+		if keep(change) {
+			filtered.AddChange(change)
+		}
+	}
+	return filtered
+}
+
 // String returns a human-readable summary
 func (cs *ChangeSet) String() string {
 	return fmt.Sprintf("ChangeSet for %s/%s: %d create, %d update, %d delete, %d no-change",
@@ -250,3 +292,22 @@ func (c *Change) DetailedString() string {
 	return sb.String()
 }
 
+// PreventedDestroys returns the changes that would delete a resource
+// protected by lifecycle.preventDestroy. A replaced resource is protected by
+// its configuration, a deleted one by the protection recorded in state.
+func (cs *ChangeSet) PreventedDestroys() []*Change {
+	var prevented []*Change
+	for _, change := range cs.Changes {
+		switch change.Type {
+		case ChangeDelete:
+			if change.Before != nil && change.Before.PreventDestroy {
+				prevented = append(prevented, change)
+			}
+		case ChangeRecreate:
+			if change.After != nil && change.After.GetMetadata().Lifecycle.PreventsDestroy() {
+				prevented = append(prevented, change)
+			}
+		}
+	}
+	return prevented
+}
@@ -0,0 +1,236 @@
+// Package events defines the progress events emitted while applying or
+// destroying a stack. The same events drive the human readable terminal
+// output and the machine readable JSON stream.
+package events
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/yourusername/panka/pkg/diff"
+	"github.com/yourusername/panka/pkg/rollback"
+)
+
+// SchemaVersion is the version of the JSON event schema. It is increased
+// whenever a field is removed or changes meaning; new fields may be added
+// without a version change.
+const SchemaVersion = 1
+
+// Type identifies the kind of an event
+type Type string
+
+const (
+	// PlanComputed is emitted once the changes to make are known
+	PlanComputed Type = "plan_computed"
+	// Started is emitted when resources start to be changed
+	Started Type = "started"
+	// StageStarted is emitted at the start of each stage of the plan
+	StageStarted Type = "stage_started"
+	// ResourceStarted is emitted before a resource operation
+	ResourceStarted Type = "resource_started"
+	// ResourceSucceeded is emitted after a successful resource operation,
+	// or for a resource that needed no change
+	ResourceSucceeded Type = "resource_succeeded"
+	// ResourceFailed is emitted after a failed resource operation
+	ResourceFailed Type = "resource_failed"
+	// ResourceSkipped is emitted for a resource that cannot be handled
+	ResourceSkipped Type = "resource_skipped"
+	// RollbackStarted is emitted when a failed apply is rolled back
+	RollbackStarted Type = "rollback_started"
+	// RollbackCompleted is emitted when the rollback has finished
+	RollbackCompleted Type = "rollback_completed"
+	// OutputsEvaluated is emitted with the evaluated stack outputs
+	OutputsEvaluated Type = "outputs_evaluated"
+	// StateSaved is emitted when the state has been saved, with Error set
+	// if saving failed
+	StateSaved Type = "state_saved"
+	// Warning is emitted for problems that do not stop the operation
+	Warning Type = "warning"
+	// HookStarted is emitted before a stack or service hook runs
+	HookStarted Type = "hook_started"
+	// HookSucceeded is emitted after a hook exited successfully
+	HookSucceeded Type = "hook_succeeded"
+	// HookFailed is emitted after a hook failed. Failed pre hooks abort
+	// the operation; failed post hooks do not.
+	HookFailed Type = "hook_failed"
+	// Summary is the last event of an operation
+	Summary Type = "summary"
+)
+
+// Operations reported in Event.Operation
+const (
+	OperationApply   = "apply"
+	OperationDestroy = "destroy"
+)
+
+// Phases reported in Event.Phase
+const (
+	// PhaseApply creates and updates the configured resources
+	PhaseApply = "apply"
+	// PhaseDelete deletes removed or destroyed resources
+	PhaseDelete = "delete"
+)
+
+// Event is a single progress event. Only the fields relevant to the event
+// type are set.
+type Event struct {
+	SchemaVersion int       `json:"schema_version"`
+	Type          Type      `json:"type"`
+	Time          time.Time `json:"time"`
+	Operation     string    `json:"operation"`
+	Stack         string    `json:"stack"`
+	Environment   string    `json:"environment"`
+
+	// Stage events
+	Phase     string `json:"phase,omitempty"`
+	Stage     int    `json:"stage,omitempty"`
+	Resources int    `json:"resources,omitempty"`
+
+	// Resource events. Action is the diff change type of the operation.
+	Resource   string                 `json:"resource,omitempty"`
+	Kind       string                 `json:"kind,omitempty"`
+	Action     string                 `json:"action,omitempty"`
+	ID         string                 `json:"id,omitempty"`
+	Reason     string                 `json:"reason,omitempty"`
+	Changes    []diff.AttributeChange `json:"changes,omitempty"`
+	DurationMS int64                  `json:"duration_ms,omitempty"`
+
+	// Hook events. HookPoint is prePlan, preApply, postApply, onFailure
+	// or preDestroy; Service is empty for the hooks of the stack.
+	Hook      string `json:"hook,omitempty"`
+	HookPoint string `json:"hook_point,omitempty"`
+	Service   string `json:"service,omitempty"`
+
+	// Outputs are the outputs of a resource or of the stack
+	Outputs map[string]string `json:"outputs,omitempty"`
+
+	Message string `json:"message,omitempty"`
+	Error   string `json:"error,omitempty"`
+
+	Plan     *Plan                    `json:"plan,omitempty"`
+	Rollback *rollback.RollbackResult `json:"rollback,omitempty"`
+	Summary  *Totals                  `json:"summary,omitempty"`
+
+	// ChangeSet is the computed change set of a PlanComputed event, for
+	// renderers that display it in full
+	ChangeSet *diff.ChangeSet `json:"-"`
+}
+
+// Plan describes the changes of a PlanComputed event
+type Plan struct {
+	Summary diff.ChangeSummary `json:"summary"`
+	Changes []PlannedChange    `json:"changes"`
+}
+
+// PlannedChange is a single resource change of a plan
+type PlannedChange struct {
+	Resource string                 `json:"resource"`
+	Kind     string                 `json:"kind"`
+	Action   string                 `json:"action"`
+	Changes  []diff.AttributeChange `json:"changes,omitempty"`
+}
+
+// Totals are the results of an operation, reported by the Summary event
+type Totals struct {
+	Success    bool  `json:"success"`
+	DurationMS int64 `json:"duration_ms"`
+	Created    int   `json:"created"`
+	Updated    int   `json:"updated"`
+	Unchanged  int   `json:"unchanged"`
+	Deleted    int   `json:"deleted"`
+	Skipped    int   `json:"skipped"`
+	Failed     int   `json:"failed"`
+}
+
+// NewPlan describes a change set for a PlanComputed event. Sensitive
+// values are masked.
+func NewPlan(cs *diff.ChangeSet) *Plan {
+	plan := &Plan{
+		Summary: cs.Summary,
+		Changes: make([]PlannedChange, 0, len(cs.Changes)),
+	}
+	for _, change := range cs.Changes {
+		plan.Changes = append(plan.Changes, PlannedChange{
+			Resource: change.ResourceName,
+			Kind:     string(change.ResourceKind),
+			Action:   string(change.Type),
+			Changes:  MaskSensitive(change.AttributeChanges),
+		})
+	}
+	return plan
+}
+
+// MaskSensitive returns attribute changes with sensitive values masked
+func MaskSensitive(changes []diff.AttributeChange) []diff.AttributeChange {
+	if len(changes) == 0 {
+		return nil
+	}
+	masked := make([]diff.AttributeChange, len(changes))
+	for i, ac := range changes {
+		if ac.Sensitive {
+			ac.OldValue = nil
+			ac.NewValue = nil
+		}
+		masked[i] = ac
+	}
+	return masked
+}
+
+// Sink receives events. Sinks are called by one Emitter at a time and need
+// not be safe for concurrent use.
+type Sink interface {
+	Emit(event Event)
+}
+
+// Emitter stamps events with the operation they belong to and delivers them
+// to a sink. It is safe for concurrent use.
+type Emitter struct {
+	mu          sync.Mutex
+	sink        Sink
+	operation   string
+	stack       string
+	environment string
+}
+
+// NewEmitter creates an emitter for an operation on a stack environment
+func NewEmitter(sink Sink, operation, stack, environment string) *Emitter {
+	return &Emitter{
+		sink:        sink,
+		operation:   operation,
+		stack:       stack,
+		environment: environment,
+	}
+}
+
+// Emit completes the common fields of an event and delivers it
+func (e *Emitter) Emit(event Event) {
+	event.SchemaVersion = SchemaVersion
+	event.Operation = e.operation
+	event.Stack = e.stack
+	event.Environment = e.environment
+	if event.Time.IsZero() {
+		event.Time = time.Now().UTC()
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.sink.Emit(event)
+}
+
+// JSONSink writes events as newline delimited JSON
+type JSONSink struct {
+	encoder *json.Encoder
+}
+
+// NewJSONSink creates a sink writing one JSON object per line to w
+func NewJSONSink(w io.Writer) *JSONSink {
+	return &JSONSink{encoder: json.NewEncoder(w)}
+}
+
+// Emit writes an event as a single line of JSON
+func (s *JSONSink) Emit(event Event) {
+	// Encode only fails for unsupported values, which events do not contain
+	_ = s.encoder.Encode(event)
+}
@@ -0,0 +1,103 @@
+package events
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourusername/panka/pkg/diff"
+	"github.com/yourusername/panka/pkg/parser/schema"
+)
+
+type recordingSink struct {
+	events []Event
+}
+
+func (s *recordingSink) Emit(event Event) {
+	s.events = append(s.events, event)
+}
+
+func TestEmitter_StampsEvents(t *testing.T) {
+	sink := &recordingSink{}
+	emitter := NewEmitter(sink, OperationApply, "my-stack", "staging")
+
+	emitter.Emit(Event{Type: StageStarted, Stage: 1, Resources: 2})
+
+	require.Len(t, sink.events, 1)
+	event := sink.events[0]
+	assert.Equal(t, SchemaVersion, event.SchemaVersion)
+	assert.Equal(t, OperationApply, event.Operation)
+	assert.Equal(t, "my-stack", event.Stack)
+	assert.Equal(t, "staging", event.Environment)
+	assert.False(t, event.Time.IsZero())
+}
+
+func TestJSONSink_WritesOneEventPerLine(t *testing.T) {
+	var buf bytes.Buffer
+	emitter := NewEmitter(NewJSONSink(&buf), OperationDestroy, "my-stack", "default")
+
+	emitter.Emit(Event{Type: Started})
+	emitter.Emit(Event{
+		Type:       ResourceSucceeded,
+		Resource:   "orders",
+		Kind:       "SQS",
+		Action:     string(diff.ChangeDelete),
+		DurationMS: 1200,
+	})
+	emitter.Emit(Event{Type: Summary, Summary: &Totals{Success: true, Deleted: 1}})
+
+	var lines []map[string]interface{}
+	scanner := bufio.NewScanner(&buf)
+	for scanner.Scan() {
+		var line map[string]interface{}
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &line))
+		lines = append(lines, line)
+	}
+
+	require.Len(t, lines, 3)
+	assert.Equal(t, float64(SchemaVersion), lines[0]["schema_version"])
+	assert.Equal(t, "started", lines[0]["type"])
+	assert.Equal(t, "destroy", lines[0]["operation"])
+	assert.NotContains(t, lines[0], "resource")
+
+	assert.Equal(t, "resource_succeeded", lines[1]["type"])
+	assert.Equal(t, "orders", lines[1]["resource"])
+	assert.Equal(t, "delete", lines[1]["action"])
+	assert.Equal(t, float64(1200), lines[1]["duration_ms"])
+
+	summary := lines[2]["summary"].(map[string]interface{})
+	assert.Equal(t, true, summary["success"])
+	assert.Equal(t, float64(1), summary["deleted"])
+}
+
+func TestNewPlan_MasksSensitiveValues(t *testing.T) {
+	cs := diff.NewChangeSet("my-stack", "default")
+	cs.AddChange(&diff.Change{
+		ResourceName: "db",
+		ResourceKind: schema.KindRDS,
+		Type:         diff.ChangeUpdate,
+		AttributeChanges: []diff.AttributeChange{
+			{Path: "spec.password", OldValue: "old", NewValue: "new", Sensitive: true},
+			{Path: "spec.instanceClass", OldValue: "db.t3.micro", NewValue: "db.t3.small"},
+		},
+	})
+
+	plan := NewPlan(cs)
+
+	assert.Equal(t, 1, plan.Summary.Update)
+	require.Len(t, plan.Changes, 1)
+	change := plan.Changes[0]
+	assert.Equal(t, "db", change.Resource)
+	assert.Equal(t, "update", change.Action)
+	require.Len(t, change.Changes, 2)
+	assert.Nil(t, change.Changes[0].OldValue)
+	assert.Nil(t, change.Changes[0].NewValue)
+	assert.True(t, change.Changes[0].Sensitive)
+	assert.Equal(t, "db.t3.small", change.Changes[1].NewValue)
+
+	// The change set itself is left untouched
+	assert.Equal(t, "new", cs.Changes[0].AttributeChanges[0].NewValue)
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/yourusername/panka/internal/logger"
 	"github.com/yourusername/panka/pkg/parser"
 	"github.com/yourusername/panka/pkg/parser/schema"
+	"github.com/yourusername/panka/pkg/state"
 	"go.uber.org/zap"
 )
 
@@ -109,6 +110,48 @@ func (b *Builder) extractDependencies(resource schema.Resource) []string {
 		
 		return deps
 		
+	case *schema.Worker:
+		deps := make([]string, len(r.Spec.DependsOn))
+		copy(deps, r.Spec.DependsOn)
+
+		for _, env := range r.Spec.Environment {
+			if env.ValueFrom != nil {
+				deps = append(deps, env.ValueFrom.Component)
+			}
+		}
+
+		// The queue driving the worker's scaling
+		if r.Spec.Scaling != nil && r.Spec.Scaling.Queue != "" {
+			deps = append(deps, r.Spec.Scaling.Queue)
+		}
+
+		return deps
+
+	case *schema.CronJob:
+		deps := make([]string, len(r.Spec.DependsOn))
+		copy(deps, r.Spec.DependsOn)
+
+		for _, env := range r.Spec.Environment {
+			if env.ValueFrom != nil {
+				deps = append(deps, env.ValueFrom.Component)
+			}
+		}
+
+		return deps
+
+	case *schema.Lambda:
+		deps := make([]string, len(r.Spec.DependsOn))
+		copy(deps, r.Spec.DependsOn)
+
+		// The components whose events trigger the function
+		for _, trigger := range r.Spec.Triggers {
+			if trigger.Source != nil && trigger.Source.Component != "" {
+				deps = append(deps, trigger.Source.Component)
+			}
+		}
+
+		return deps
+		
 	case *schema.RDS:
 		if r.Spec.DependsOn != nil {
 			deps := make([]string, len(r.Spec.DependsOn))
@@ -116,6 +159,20 @@ func (b *Builder) extractDependencies(resource schema.Resource) []string {
 			return deps
 		}
 		
+	case *schema.ElastiCacheRedis:
+		if r.Spec.DependsOn != nil {
+			deps := make([]string, len(r.Spec.DependsOn))
+			copy(deps, r.Spec.DependsOn)
+			return deps
+		}
+		
+	case *schema.ElastiCacheMemcached:
+		if r.Spec.DependsOn != nil {
+			deps := make([]string, len(r.Spec.DependsOn))
+			copy(deps, r.Spec.DependsOn)
+			return deps
+		}
+		
 	case *schema.DynamoDB:
 		if r.Spec.DependsOn != nil {
 			deps := make([]string, len(r.Spec.DependsOn))
@@ -181,6 +238,33 @@ func (b *Builder) addEdges(graph *Graph, resource schema.Resource) error {
 				}
 			}
 		}
+		if w, ok := resource.(*schema.Worker); ok {
+			if w.Spec.Scaling != nil && w.Spec.Scaling.Queue == depID {
+				edgeType = EdgeTypeImplicit
+			}
+			for _, env := range w.Spec.Environment {
+				if env.ValueFrom != nil && env.ValueFrom.Component == depID {
+					edgeType = EdgeTypeImplicit
+					break
+				}
+			}
+		}
+		if c, ok := resource.(*schema.CronJob); ok {
+			for _, env := range c.Spec.Environment {
+				if env.ValueFrom != nil && env.ValueFrom.Component == depID {
+					edgeType = EdgeTypeImplicit
+					break
+				}
+			}
+		}
+		if fn, ok := resource.(*schema.Lambda); ok {
+			for _, trigger := range fn.Spec.Triggers {
+				if trigger.Source != nil && trigger.Source.Component == depID {
+					edgeType = EdgeTypeImplicit
+					break
+				}
+			}
+		}
 		
 		// Add edge
 		if err := graph.AddEdge(fromID, depID, edgeType); err != nil {
@@ -194,63 +278,63 @@ func (b *Builder) addEdges(graph *Graph, resource schema.Resource) error {
 		)
 	}
 	
-	return nil
+	return b.addOutputReferenceEdges(graph, resource, deps)
 }
 
-// calculateLevels calculates deployment level for each node
-// Level 0 = no dependencies, Level N = max(dependency levels) + 1
-func (b *Builder) calculateLevels(graph *Graph) error {
-	// Reset all levels
-	for _, node := range graph.Nodes {
-		node.Level = -1
+// addOutputReferenceEdges adds implicit edges for ${component.output}
+// references and S3 trigger buckets to other components of the graph
+func (b *Builder) addOutputReferenceEdges(graph *Graph, resource schema.Resource, deps []string) error {
+	fromID := resource.GetMetadata().Name
+	
+	refs, err := parser.OutputReferences(resource)
+	if err != nil {
+		return fmt.Errorf("failed to find output references: %w", err)
 	}
 	
-	var calculateLevel func(id string) (int, error)
-	calculateLevel = func(id string) (int, error) {
-		node := graph.Nodes[id]
-		
-		// Already calculated
-		if node.Level >= 0 {
-			return node.Level, nil
+	linked := make(map[string]bool, len(deps))
+	for _, dep := range deps {
+		linked[dep] = true
+	}
+	
+	// S3 triggers name their bucket, which may be a component of the graph
+	if fn, ok := resource.(*schema.Lambda); ok {
+		for _, trigger := range fn.Spec.Triggers {
+			if trigger.S3 != nil && trigger.S3.Bucket != "" {
+				refs = append(refs, parser.OutputReference{Path: "spec.triggers.s3.bucket", Component: trigger.S3.Bucket, Output: "bucket_name"})
+			}
 		}
-		
-		// No dependencies, level 0
-		if node.InDegree == 0 {
-			node.Level = 0
-			return 0, nil
+	}
+	
+	for _, ref := range refs {
+		if ref.Component == fromID || linked[ref.Component] {
+			continue
 		}
-		
-		// Calculate based on dependencies
-		maxDepLevel := 0
-		deps, err := graph.GetDependencies(id)
-		if err != nil {
-			return 0, err
+		if _, exists := graph.GetNode(ref.Component); !exists {
+			continue
 		}
 		
-		for _, dep := range deps {
-			depLevel, err := calculateLevel(dep.ID)
-			if err != nil {
-				return 0, err
-			}
-			if depLevel > maxDepLevel {
-				maxDepLevel = depLevel
-			}
+		if err := graph.AddEdge(fromID, ref.Component, EdgeTypeImplicit); err != nil {
+			return fmt.Errorf("failed to add edge %s -> %s: %w", fromID, ref.Component, err)
 		}
+		graph.Nodes[fromID].DependsOn = append(graph.Nodes[fromID].DependsOn, ref.Component)
+		linked[ref.Component] = true
 		
-		node.Level = maxDepLevel + 1
-		return node.Level, nil
-	}
-	
-	// Calculate level for each node
-	for id := range graph.Nodes {
-		if _, err := calculateLevel(id); err != nil {
-			return err
-		}
+		b.logger.Debug("Added edge",
+			zap.String("from", fromID),
+			zap.String("to", ref.Component),
+			zap.String("type", string(EdgeTypeImplicit)),
+		)
 	}
 	
 	return nil
 }
 
+// calculateLevels calculates deployment level for each node
+// Level 0 = no dependencies, Level N = max(dependency levels) + 1
+func (b *Builder) calculateLevels(graph *Graph) error {
+	return graph.calculateLevels()
+}
+
 // BuildFromComponents builds a graph from a list of components
 // This is useful for testing or when you have components without a full parse result
 func (b *Builder) BuildFromComponents(stackName string, components []schema.Resource) (*Graph, error) {
@@ -270,3 +354,44 @@ func (b *Builder) BuildFromComponents(stackName string, components []schema.Reso
 	return b.Build(result)
 }
 
+
+// BuildFromState builds a graph from resources recorded in state, using the
+// dependencies stored with each resource. Dependencies on resources that are
+// not in the list are ignored. The resulting nodes carry no schema resource.
+func (b *Builder) BuildFromState(stackName string, resources []*state.Resource) (*Graph, error) {
+	graph := NewGraph(stackName)
+
+	for _, res := range resources {
+		node := &Node{
+			ID:        res.Name,
+			Kind:      schema.Kind(res.Type),
+			DependsOn: append([]string{}, res.DependsOn...),
+			Level:     0,
+		}
+		if err := graph.AddNode(node); err != nil {
+			return nil, fmt.Errorf("failed to add node %s: %w", node.ID, err)
+		}
+	}
+
+	for _, res := range resources {
+		for _, depID := range res.DependsOn {
+			if _, exists := graph.GetNode(depID); !exists {
+				continue
+			}
+			if err := graph.AddEdge(res.Name, depID, EdgeTypeExplicit); err != nil {
+				return nil, fmt.Errorf("failed to add edge %s -> %s: %w", res.Name, depID, err)
+			}
+		}
+	}
+
+	if graph.HasCycle() {
+		cycle := graph.GetCycle()
+		return nil, fmt.Errorf("circular dependency detected: %v", cycle)
+	}
+
+	if err := b.calculateLevels(graph); err != nil {
+		return nil, fmt.Errorf("failed to calculate deployment levels: %w", err)
+	}
+
+	return graph, nil
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/stretchr/testify/require"
 	"github.com/yourusername/panka/pkg/parser"
 	"github.com/yourusername/panka/pkg/parser/schema"
+	"github.com/yourusername/panka/pkg/state"
 )
 
 func TestBuilder_Build_EmptyStack(t *testing.T) {
@@ -142,6 +143,108 @@ func TestBuilder_Build_WithImplicitDependencies(t *testing.T) {
 	assert.Equal(t, EdgeTypeImplicit, edges[0].Type)
 }
 
+func TestBuilder_Build_WithOutputReferences(t *testing.T) {
+	builder := NewBuilder()
+	
+	queue := schema.NewSQS("queue", "backend", "test-stack")
+	
+	api := schema.NewMicroService("api", "backend", "test-stack")
+	api.Spec.Image.Repository = "myrepo/api"
+	api.Spec.Image.Tag = "v1.0.0"
+	api.Spec.Environment = []schema.EnvironmentVariable{
+		{Name: "QUEUE_URL", Value: "${queue.queue_url}"},
+		{Name: "REGION", Value: "${aws.region}"},
+	}
+	
+	result := &parser.ParseResult{
+		Stack:      schema.NewStack("test-stack"),
+		Services:   []*schema.Service{schema.NewService("backend", "test-stack")},
+		Components: []schema.Resource{queue, api},
+	}
+	
+	g, err := builder.Build(result)
+	require.NoError(t, err)
+	
+	// Only references to components of the stack become edges
+	edges := g.Edges["api"]
+	require.Len(t, edges, 1)
+	assert.Equal(t, "queue", edges[0].To)
+	assert.Equal(t, EdgeTypeImplicit, edges[0].Type)
+	
+	apiNode, _ := g.GetNode("api")
+	assert.Equal(t, []string{"queue"}, apiNode.DependsOn)
+	assert.Equal(t, 1, apiNode.Level)
+}
+
+func TestBuilder_Build_WorkerScalingQueue(t *testing.T) {
+	builder := NewBuilder()
+
+	queue := schema.NewSQS("orders", "messaging", "test-stack")
+
+	worker := schema.NewWorker("consumer", "backend", "test-stack")
+	worker.Spec.Image = schema.ImageConfig{Repository: "myrepo/consumer", Tag: "v1.0.0"}
+	worker.Spec.Scaling = &schema.QueueScaling{
+		Queue:       "orders",
+		QueueName:   "${orders.queue_name}",
+		MaxReplicas: 5,
+	}
+
+	result := &parser.ParseResult{
+		Stack: schema.NewStack("test-stack"),
+		Services: []*schema.Service{
+			schema.NewService("backend", "test-stack"),
+			schema.NewService("messaging", "test-stack"),
+		},
+		Components: []schema.Resource{worker, queue},
+	}
+
+	g, err := builder.Build(result)
+	require.NoError(t, err)
+
+	// The queue is deployed before the worker that scales on it
+	edges := g.Edges["consumer"]
+	require.Len(t, edges, 1)
+	assert.Equal(t, "orders", edges[0].To)
+	assert.Equal(t, EdgeTypeImplicit, edges[0].Type)
+	assert.Equal(t, 1, g.Nodes["consumer"].Level)
+}
+
+func TestBuilder_Build_LambdaTriggers(t *testing.T) {
+	builder := NewBuilder()
+
+	queue := schema.NewSQS("orders", "messaging", "test-stack")
+	uploads := schema.NewS3("uploads", "messaging", "test-stack")
+
+	fn := schema.NewLambda("processor", "backend", "test-stack")
+	fn.Spec.Triggers = []schema.LambdaTrigger{
+		{Type: schema.TriggerSQS, Source: &schema.TriggerSource{Component: "orders"}},
+		{Type: schema.TriggerS3, S3: &schema.S3Trigger{Bucket: "uploads"}},
+		{Type: schema.TriggerS3, S3: &schema.S3Trigger{Bucket: "external-bucket"}},
+	}
+
+	result := &parser.ParseResult{
+		Stack: schema.NewStack("test-stack"),
+		Services: []*schema.Service{
+			schema.NewService("backend", "test-stack"),
+			schema.NewService("messaging", "test-stack"),
+		},
+		Components: []schema.Resource{fn, queue, uploads},
+	}
+
+	g, err := builder.Build(result)
+	require.NoError(t, err)
+
+	// Trigger sources are deployed before the function, buckets that are
+	// not components are ignored
+	edges := g.Edges["processor"]
+	require.Len(t, edges, 2)
+	for _, edge := range edges {
+		assert.Equal(t, EdgeTypeImplicit, edge.Type)
+	}
+	assert.ElementsMatch(t, []string{"orders", "uploads"}, g.Nodes["processor"].DependsOn)
+	assert.Equal(t, 1, g.Nodes["processor"].Level)
+}
+
 func TestBuilder_Build_CircularDependency(t *testing.T) {
 	builder := NewBuilder()
 	
@@ -216,3 +319,42 @@ func TestBuilder_BuildFromComponents(t *testing.T) {
 	assert.Equal(t, "test-stack", g.StackName)
 }
 
+
+func TestBuilder_BuildFromState(t *testing.T) {
+	builder := NewBuilder()
+
+	resources := []*state.Resource{
+		{Name: "db", Type: string(schema.KindRDS)},
+		{Name: "queue", Type: string(schema.KindSQS)},
+		{Name: "api", Type: string(schema.KindMicroService), DependsOn: []string{"db", "queue", "missing"}},
+	}
+
+	g, err := builder.BuildFromState("test-stack", resources)
+	require.NoError(t, err)
+	assert.Equal(t, 3, g.NodeCount())
+	assert.Equal(t, 2, g.EdgeCount())
+
+	apiNode, exists := g.GetNode("api")
+	require.True(t, exists)
+	assert.Equal(t, schema.KindMicroService, apiNode.Kind)
+	assert.Equal(t, 1, apiNode.Level)
+
+	// Deletion removes dependents before their dependencies
+	plan, err := NewPlanner().CreateDeletionPlan(g)
+	require.NoError(t, err)
+	require.Len(t, plan.Stages, 2)
+	assert.Equal(t, "api", plan.Stages[0].Resources[0].ID)
+	assert.Len(t, plan.Stages[1].Resources, 2)
+}
+
+func TestBuilder_BuildFromState_Cycle(t *testing.T) {
+	builder := NewBuilder()
+
+	resources := []*state.Resource{
+		{Name: "a", Type: string(schema.KindSQS), DependsOn: []string{"b"}},
+		{Name: "b", Type: string(schema.KindSQS), DependsOn: []string{"a"}},
+	}
+
+	_, err := builder.BuildFromState("test-stack", resources)
+	assert.Error(t, err)
+}
@@ -0,0 +1,87 @@
+package graph
+
+import (
+	"context"
+	"sync"
+)
+
+// DefaultParallelism is the default number of resources processed concurrently
+const DefaultParallelism = 10
+
+// ResourceFunc performs the work for a single resource in a deployment stage
+type ResourceFunc func(ctx context.Context, resource *DeploymentResource) error
+
+// Executor runs the resources of a deployment stage concurrently
+type Executor struct {
+	// Parallelism is the maximum number of resources processed at once
+	Parallelism int
+}
+
+// NewExecutor creates a new executor with the given parallelism.
+// Values below 1 fall back to DefaultParallelism.
+func NewExecutor(parallelism int) *Executor {
+	if parallelism < 1 {
+		parallelism = DefaultParallelism
+	}
+	return &Executor{
+		Parallelism: parallelism,
+	}
+}
+
+// ExecuteStage runs fn for every resource in the stage using at most
+// Parallelism workers. Resources in a stage have no dependencies on each
+// other, so they may run in any order.
+//
+// Execution is fail-fast: the first error cancels the context passed to the
+// workers still running, no further resources are started, and that first
+// error is returned once all in-flight workers have finished.
+func (e *Executor) ExecuteStage(ctx context.Context, stage *DeploymentStage, fn ResourceFunc) error {
+	if stage == nil || len(stage.Resources) == 0 {
+		return ctx.Err()
+	}
+
+	parallelism := e.Parallelism
+	if parallelism < 1 {
+		parallelism = DefaultParallelism
+	}
+
+	stageCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+		sem      = make(chan struct{}, parallelism)
+	)
+
+	for _, resource := range stage.Resources {
+		select {
+		case sem <- struct{}{}:
+		case <-stageCtx.Done():
+		}
+		if stageCtx.Err() != nil {
+			break
+		}
+
+		wg.Add(1)
+		go func(res *DeploymentResource) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			if err := fn(stageCtx, res); err != nil {
+				once.Do(func() {
+					firstErr = err
+					cancel()
+				})
+			}
+		}(resource)
+	}
+
+	wg.Wait()
+
+	if firstErr != nil {
+		return firstErr
+	}
+	return ctx.Err()
+}
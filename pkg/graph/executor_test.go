@@ -0,0 +1,125 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createTestStage(n int) *DeploymentStage {
+	stage := &DeploymentStage{Number: 1}
+	for i := 0; i < n; i++ {
+		stage.Resources = append(stage.Resources, &DeploymentResource{
+			ID:     fmt.Sprintf("res-%d", i),
+			Action: ActionCreate,
+		})
+	}
+	return stage
+}
+
+func TestNewExecutor(t *testing.T) {
+	assert.Equal(t, 4, NewExecutor(4).Parallelism)
+	assert.Equal(t, DefaultParallelism, NewExecutor(0).Parallelism)
+	assert.Equal(t, DefaultParallelism, NewExecutor(-1).Parallelism)
+}
+
+func TestExecutor_ExecuteStage_RunsAllResources(t *testing.T) {
+	stage := createTestStage(20)
+	executor := NewExecutor(5)
+
+	var mu sync.Mutex
+	seen := make(map[string]bool)
+
+	err := executor.ExecuteStage(context.Background(), stage, func(ctx context.Context, res *DeploymentResource) error {
+		mu.Lock()
+		defer mu.Unlock()
+		seen[res.ID] = true
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Len(t, seen, 20)
+}
+
+func TestExecutor_ExecuteStage_RespectsParallelism(t *testing.T) {
+	stage := createTestStage(12)
+	executor := NewExecutor(3)
+
+	var running, maxRunning int32
+	err := executor.ExecuteStage(context.Background(), stage, func(ctx context.Context, res *DeploymentResource) error {
+		current := atomic.AddInt32(&running, 1)
+		for {
+			prev := atomic.LoadInt32(&maxRunning)
+			if current <= prev || atomic.CompareAndSwapInt32(&maxRunning, prev, current) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		atomic.AddInt32(&running, -1)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.LessOrEqual(t, maxRunning, int32(3))
+	assert.Greater(t, maxRunning, int32(1))
+}
+
+func TestExecutor_ExecuteStage_FailFast(t *testing.T) {
+	stage := createTestStage(10)
+	executor := NewExecutor(2)
+	boom := errors.New("boom")
+
+	var started int32
+	var cancelled int32
+	err := executor.ExecuteStage(context.Background(), stage, func(ctx context.Context, res *DeploymentResource) error {
+		atomic.AddInt32(&started, 1)
+		if res.ID == "res-0" {
+			return boom
+		}
+		select {
+		case <-ctx.Done():
+			atomic.AddInt32(&cancelled, 1)
+			return ctx.Err()
+		case <-time.After(time.Second):
+			return nil
+		}
+	})
+
+	assert.ErrorIs(t, err, boom)
+	assert.Less(t, atomic.LoadInt32(&started), int32(10), "remaining resources should not start after a failure")
+	assert.Equal(t, atomic.LoadInt32(&started)-1, atomic.LoadInt32(&cancelled), "in-flight resources should observe cancellation")
+}
+
+func TestExecutor_ExecuteStage_ParentCancelled(t *testing.T) {
+	stage := createTestStage(5)
+	executor := NewExecutor(2)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var started int32
+	err := executor.ExecuteStage(ctx, stage, func(ctx context.Context, res *DeploymentResource) error {
+		atomic.AddInt32(&started, 1)
+		return nil
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, int32(0), started)
+}
+
+func TestExecutor_ExecuteStage_EmptyStage(t *testing.T) {
+	executor := NewExecutor(2)
+
+	err := executor.ExecuteStage(context.Background(), &DeploymentStage{}, func(ctx context.Context, res *DeploymentResource) error {
+		t.Fatal("should not be called")
+		return nil
+	})
+	assert.NoError(t, err)
+
+	err = executor.ExecuteStage(context.Background(), nil, nil)
+	assert.NoError(t, err)
+}
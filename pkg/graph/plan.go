@@ -12,45 +12,47 @@ import (
 // DeploymentPlan represents a plan for deploying resources
 type DeploymentPlan struct {
 	// Metadata
-	StackName string
-	CreatedAt time.Time
+	StackName string    `json:"stack_name"`
+	CreatedAt time.Time `json:"created_at"`
 	
 	// Deployment stages
-	Stages []*DeploymentStage
+	Stages []*DeploymentStage `json:"stages"`
 	
 	// Summary
-	TotalResources int
-	TotalStages    int
-	EstimatedTime  time.Duration
+	TotalResources int           `json:"total_resources"`
+	TotalStages    int           `json:"total_stages"`
+	EstimatedTime  time.Duration `json:"estimated_time"`
 	
 	// Graph reference
-	Graph *Graph
+	Graph *Graph `json:"-"`
 }
 
 // DeploymentStage represents a stage in the deployment plan
 // All resources in a stage can be deployed in parallel
 type DeploymentStage struct {
 	// Stage information
-	Number      int
-	Level       int
-	Description string
+	Number      int    `json:"number"`
+	Level       int    `json:"level"`
+	Description string `json:"description"`
 	
 	// Resources to deploy
-	Resources []*DeploymentResource
+	Resources []*DeploymentResource `json:"resources"`
 	
 	// Timing
-	EstimatedDuration time.Duration
+	EstimatedDuration time.Duration `json:"estimated_duration"`
 }
 
-// DeploymentResource represents a resource to be deployed
+// DeploymentResource represents a resource to be deployed.
+// Resource is not serialized; it is re-attached from the parsed
+// configuration when a saved plan is loaded.
 type DeploymentResource struct {
-	ID          string
-	Kind        schema.Kind
-	Resource    schema.Resource
-	Dependencies []string
+	ID           string          `json:"id"`
+	Kind         schema.Kind     `json:"kind"`
+	Resource     schema.Resource `json:"-"`
+	Dependencies []string        `json:"dependencies,omitempty"`
 	
 	// Action to perform
-	Action ResourceAction
+	Action ResourceAction `json:"action"`
 }
 
 // ResourceAction defines the action to perform on a resource
@@ -219,7 +221,11 @@ func (p *Planner) estimateStageDuration(nodes []*Node, action ResourceAction) ti
 		schema.KindSQS:           20 * time.Second,
 		schema.KindSNS:           20 * time.Second,
 		schema.KindRDS:           10 * time.Minute, // RDS takes much longer
+		schema.KindElastiCacheRedis:     10 * time.Minute,
+		schema.KindElastiCacheMemcached: 5 * time.Minute,
 		schema.KindMicroService:  3 * time.Minute,  // ECS deployment
+		schema.KindWorker:        3 * time.Minute,  // ECS deployment
+		schema.KindCronJob:       time.Minute,      // Task definition and schedule
 		schema.KindComponentInfra: 2 * time.Minute,
 	}
 	
@@ -0,0 +1,122 @@
+package graph
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ResolveTargets resolves target expressions to node IDs. A target is either
+// a component name or service/component. The service part is checked against
+// the resource metadata when the node carries a resource.
+func (g *Graph) ResolveTargets(targets []string) ([]string, error) {
+	ids := make([]string, 0, len(targets))
+	seen := make(map[string]bool)
+
+	for _, target := range targets {
+		service, name := "", target
+		if idx := strings.Index(target, "/"); idx >= 0 {
+			service, name = target[:idx], target[idx+1:]
+		}
+		if name == "" {
+			return nil, fmt.Errorf("invalid target %q: expected <component> or <service>/<component>", target)
+		}
+
+		node, exists := g.GetNode(name)
+		if !exists {
+			return nil, fmt.Errorf("target %q not found in stack", target)
+		}
+		if service != "" && node.Resource != nil && node.Resource.GetMetadata().Service != service {
+			return nil, fmt.Errorf("target %q not found in stack: %s belongs to service %s",
+				target, name, node.Resource.GetMetadata().Service)
+		}
+
+		if !seen[name] {
+			seen[name] = true
+			ids = append(ids, name)
+		}
+	}
+
+	return ids, nil
+}
+
+// DependencyClosure returns the given nodes together with all of their
+// transitive dependencies, sorted by ID
+func (g *Graph) DependencyClosure(ids []string) []string {
+	return g.closure(ids, g.GetDependencies)
+}
+
+// DependentClosure returns the given nodes together with every node that
+// transitively depends on them, sorted by ID
+func (g *Graph) DependentClosure(ids []string) []string {
+	return g.closure(ids, g.GetDependents)
+}
+
+// closure walks the graph from the given nodes using next
+func (g *Graph) closure(ids []string, next func(id string) ([]*Node, error)) []string {
+	visited := make(map[string]bool)
+	queue := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if _, exists := g.Nodes[id]; exists && !visited[id] {
+			visited[id] = true
+			queue = append(queue, id)
+		}
+	}
+
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+
+		nodes, _ := next(id)
+		for _, node := range nodes {
+			if !visited[node.ID] {
+				visited[node.ID] = true
+				queue = append(queue, node.ID)
+			}
+		}
+	}
+
+	result := make([]string, 0, len(visited))
+	for id := range visited {
+		result = append(result, id)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// Subgraph returns a new graph containing only the given nodes and the edges
+// between them, with deployment levels recalculated
+func (g *Graph) Subgraph(ids []string) *Graph {
+	sub := NewGraph(g.StackName)
+	sub.ServiceName = g.ServiceName
+
+	include := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		node, exists := g.Nodes[id]
+		if !exists || include[id] {
+			continue
+		}
+		include[id] = true
+
+		nodeCopy := *node
+		nodeCopy.DependsOn = make([]string, len(node.DependsOn))
+		copy(nodeCopy.DependsOn, node.DependsOn)
+		nodeCopy.InDegree = 0
+		nodeCopy.Level = 0
+		sub.Nodes[id] = &nodeCopy
+	}
+
+	for from, edges := range g.Edges {
+		if !include[from] {
+			continue
+		}
+		for _, edge := range edges {
+			if include[edge.To] {
+				sub.AddEdge(edge.From, edge.To, edge.Type)
+			}
+		}
+	}
+
+	sub.calculateLevels()
+	return sub
+}
@@ -0,0 +1,74 @@
+package graph
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourusername/panka/pkg/parser"
+	"github.com/yourusername/panka/pkg/parser/schema"
+)
+
+func TestGraph_ResolveTargets(t *testing.T) {
+	builder := NewBuilder()
+
+	db := schema.NewRDS("db", "backend", "test-stack")
+	api := schema.NewMicroService("api", "backend", "test-stack")
+	api.Spec.DependsOn = []string{"db"}
+
+	g, err := builder.Build(&parser.ParseResult{
+		Stack:      schema.NewStack("test-stack"),
+		Components: []schema.Resource{db, api},
+	})
+	require.NoError(t, err)
+
+	ids, err := g.ResolveTargets([]string{"api", "backend/db", "api"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"api", "db"}, ids)
+
+	_, err = g.ResolveTargets([]string{"missing"})
+	assert.Error(t, err)
+
+	_, err = g.ResolveTargets([]string{"frontend/api"})
+	assert.Error(t, err)
+
+	_, err = g.ResolveTargets([]string{"backend/"})
+	assert.Error(t, err)
+}
+
+func TestGraph_DependencyClosure(t *testing.T) {
+	g := createTestGraph()
+
+	assert.Equal(t, []string{"api", "cache", "db"}, g.DependencyClosure([]string{"api"}))
+	assert.Equal(t, []string{"db"}, g.DependencyClosure([]string{"db"}))
+	assert.Equal(t, []string{"api", "cache", "db", "frontend"}, g.DependencyClosure([]string{"frontend"}))
+	assert.Empty(t, g.DependencyClosure([]string{"missing"}))
+}
+
+func TestGraph_DependentClosure(t *testing.T) {
+	g := createTestGraph()
+
+	assert.Equal(t, []string{"api", "db", "frontend"}, g.DependentClosure([]string{"db"}))
+	assert.Equal(t, []string{"frontend"}, g.DependentClosure([]string{"frontend"}))
+}
+
+func TestGraph_Subgraph(t *testing.T) {
+	g := createTestGraph()
+
+	sub := g.Subgraph([]string{"api", "frontend"})
+	assert.Equal(t, 2, sub.NodeCount())
+	assert.Equal(t, 1, sub.EdgeCount())
+
+	api, exists := sub.GetNode("api")
+	require.True(t, exists)
+	assert.Equal(t, 0, api.Level)
+	assert.Equal(t, 0, api.InDegree)
+
+	frontend, _ := sub.GetNode("frontend")
+	assert.Equal(t, 1, frontend.Level)
+
+	// The original graph is unchanged
+	original, _ := g.GetNode("api")
+	assert.Equal(t, 1, original.Level)
+	assert.Equal(t, 4, g.NodeCount())
+}
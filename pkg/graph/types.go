@@ -384,3 +384,57 @@ func (g *Graph) GetStats() GraphStats {
 	return stats
 }
 
+// calculateLevels calculates deployment level for each node
+// Level 0 = no dependencies, Level N = max(dependency levels) + 1
+func (g *Graph) calculateLevels() error {
+	// Reset all levels
+	for _, node := range g.Nodes {
+		node.Level = -1
+	}
+	
+	var calculateLevel func(id string) (int, error)
+	calculateLevel = func(id string) (int, error) {
+		node := g.Nodes[id]
+		
+		// Already calculated
+		if node.Level >= 0 {
+			return node.Level, nil
+		}
+		
+		// No dependencies, level 0
+		if node.InDegree == 0 {
+			node.Level = 0
+			return 0, nil
+		}
+		
+		// Calculate based on dependencies
+		maxDepLevel := 0
+		deps, err := g.GetDependencies(id)
+		if err != nil {
+			return 0, err
+		}
+		
+		for _, dep := range deps {
+			depLevel, err := calculateLevel(dep.ID)
+			if err != nil {
+				return 0, err
+			}
+			if depLevel > maxDepLevel {
+				maxDepLevel = depLevel
+			}
+		}
+		
+		node.Level = maxDepLevel + 1
+		return node.Level, nil
+	}
+	
+	// Calculate level for each node
+	for id := range g.Nodes {
+		if _, err := calculateLevel(id); err != nil {
+			return err
+		}
+	}
+	
+	return nil
+}
+
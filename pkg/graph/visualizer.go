@@ -104,8 +104,12 @@ func (v *Visualizer) ToDOT(g *Graph) string {
 func (v *Visualizer) getColorForKind(kind schema.Kind) string {
 	colors := map[schema.Kind]string{
 		schema.KindMicroService: "lightblue",
+		schema.KindWorker:       "lightblue",
+		schema.KindCronJob:      "lightblue",
 		schema.KindRDS:         "lightgreen",
 		schema.KindDynamoDB:    "lightgreen",
+		schema.KindElastiCacheRedis:     "lightgreen",
+		schema.KindElastiCacheMemcached: "lightgreen",
 		schema.KindS3:          "lightyellow",
 		schema.KindSQS:         "lightpink",
 		schema.KindSNS:         "lightpink",
@@ -0,0 +1,238 @@
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/yourusername/panka/pkg/diff"
+	"github.com/yourusername/panka/pkg/events"
+	"github.com/yourusername/panka/pkg/parser/schema"
+)
+
+// waitDelay bounds how long a hook's output is read after it exits or is
+// killed, in case it left background processes holding the pipes
+const waitDelay = 5 * time.Second
+
+// Scope is the stack or a service with the hooks it runs at a point
+type Scope struct {
+	// Service is empty for the hooks of the stack
+	Service   string
+	Variables map[string]string
+	Hooks     []schema.Hook
+}
+
+// Collect returns the scopes with hooks at a point: the stack first, then
+// its services by name. The variables of a service are merged over the
+// variables of the stack.
+func Collect(stack *schema.Stack, services []*schema.Service, point schema.HookPoint) []Scope {
+	var scopes []Scope
+	if stack == nil {
+		return scopes
+	}
+
+	if hooks := stack.Spec.Hooks.For(point); len(hooks) > 0 {
+		scopes = append(scopes, Scope{Variables: stack.Spec.Variables, Hooks: hooks})
+	}
+
+	sorted := make([]*schema.Service, 0, len(services))
+	for _, svc := range services {
+		if svc != nil && len(svc.Spec.Hooks.For(point)) > 0 {
+			sorted = append(sorted, svc)
+		}
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Metadata.Name < sorted[j].Metadata.Name
+	})
+
+	for _, svc := range sorted {
+		variables := make(map[string]string, len(stack.Spec.Variables)+len(svc.Spec.Variables))
+		for k, v := range stack.Spec.Variables {
+			variables[k] = v
+		}
+		for k, v := range svc.Spec.Variables {
+			variables[k] = v
+		}
+		scopes = append(scopes, Scope{
+			Service:   svc.Metadata.Name,
+			Variables: variables,
+			Hooks:     svc.Spec.Hooks.For(point),
+		})
+	}
+
+	return scopes
+}
+
+// Context describes the operation a hook runs for
+type Context struct {
+	Point       schema.HookPoint
+	Stack       string
+	Environment string
+
+	// Outputs are the stack and service outputs recorded in the state
+	Outputs map[string]string
+
+	// ChangeSet is written to the hook's stdin as JSON when set
+	ChangeSet *diff.ChangeSet
+
+	// Error is the error of a failed apply, for onFailure hooks
+	Error string
+}
+
+// Runner runs hooks as shell commands
+type Runner struct {
+	dir    string
+	stdout io.Writer
+	stderr io.Writer
+}
+
+// NewRunner creates a runner for the hooks of the stack folder dir. The
+// output of the hooks is copied to stdout and stderr.
+func NewRunner(dir string, stdout, stderr io.Writer) *Runner {
+	return &Runner{
+		dir:    dir,
+		stdout: stdout,
+		stderr: stderr,
+	}
+}
+
+// Run runs a hook of a scope and returns an error if it cannot be started,
+// times out or exits with a non-zero code
+func (r *Runner) Run(ctx context.Context, scope Scope, hook schema.Hook, hc *Context) error {
+	name := Name(hook)
+
+	if hook.Timeout != "" {
+		timeout, err := time.ParseDuration(hook.Timeout)
+		if err != nil {
+			return fmt.Errorf("hook %s has an invalid timeout: %w", name, err)
+		}
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	stdin, err := changeSetJSON(hc.ChangeSet)
+	if err != nil {
+		return fmt.Errorf("failed to encode change set for hook %s: %w", name, err)
+	}
+
+	dir := r.dir
+	if hook.WorkingDir != "" {
+		dir = filepath.Join(r.dir, hook.WorkingDir)
+	}
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", hook.Command)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), Env(scope, hook, hc)...)
+	cmd.Stdin = bytes.NewReader(stdin)
+	cmd.Stdout = r.stdout
+	cmd.Stderr = r.stderr
+	cmd.WaitDelay = waitDelay
+	killProcessGroup(cmd)
+
+	if err := cmd.Run(); err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return fmt.Errorf("hook %s timed out after %s", name, hook.Timeout)
+		}
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() > 0 {
+			return fmt.Errorf("hook %s exited with code %d", name, exitErr.ExitCode())
+		}
+		return fmt.Errorf("hook %s failed: %w", name, err)
+	}
+
+	return nil
+}
+
+// Name returns the name of a hook, or its command when it has none
+func Name(hook schema.Hook) string {
+	if hook.Name != "" {
+		return hook.Name
+	}
+	return hook.Command
+}
+
+// Env returns the environment variables set for a hook:
+//
+//	PANKA_HOOK, PANKA_HOOK_NAME, PANKA_STACK, PANKA_ENVIRONMENT
+//	PANKA_SERVICE      for the hooks of a service
+//	PANKA_VAR_<NAME>   for each variable
+//	PANKA_OUTPUT_<NAME> for each output, e.g. PANKA_OUTPUT_BACKEND_API_URL
+//	PANKA_ERROR        for onFailure hooks
+//
+// followed by the env of the hook itself
+func Env(scope Scope, hook schema.Hook, hc *Context) []string {
+	env := []string{
+		"PANKA_HOOK=" + string(hc.Point),
+		"PANKA_HOOK_NAME=" + Name(hook),
+		"PANKA_STACK=" + hc.Stack,
+		"PANKA_ENVIRONMENT=" + hc.Environment,
+	}
+	if scope.Service != "" {
+		env = append(env, "PANKA_SERVICE="+scope.Service)
+	}
+	for _, k := range sortedKeys(scope.Variables) {
+		env = append(env, "PANKA_VAR_"+envName(k)+"="+scope.Variables[k])
+	}
+	for _, k := range sortedKeys(hc.Outputs) {
+		env = append(env, "PANKA_OUTPUT_"+envName(k)+"="+hc.Outputs[k])
+	}
+	if hc.Error != "" {
+		env = append(env, "PANKA_ERROR="+hc.Error)
+	}
+	for _, k := range sortedKeys(hook.Env) {
+		env = append(env, k+"="+hook.Env[k])
+	}
+	return env
+}
+
+// envName turns a variable or output name into an environment variable
+// name suffix
+func envName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		default:
+			return '_'
+		}
+	}, name)
+}
+
+// changeSetJSON encodes a change set with its sensitive values masked
+func changeSetJSON(cs *diff.ChangeSet) ([]byte, error) {
+	if cs == nil {
+		return nil, nil
+	}
+
+	masked := *cs
+	masked.Changes = make([]*diff.Change, len(cs.Changes))
+	for i, change := range cs.Changes {
+		c := *change
+		c.AttributeChanges = events.MaskSensitive(change.AttributeChanges)
+		masked.Changes[i] = &c
+	}
+
+	return json.Marshal(&masked)
+}
+
+// sortedKeys returns the keys of a string map in order
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,115 @@
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourusername/panka/pkg/diff"
+	"github.com/yourusername/panka/pkg/parser/schema"
+)
+
+func TestCollect(t *testing.T) {
+	stack := schema.NewStack("shop")
+	stack.Spec.Variables = map[string]string{"region": "us-east-1", "tier": "gold"}
+	stack.Spec.Hooks = &schema.Hooks{PreApply: []schema.Hook{{Name: "stack", Command: "true"}}}
+
+	orders := schema.NewService("orders", "shop")
+	orders.Spec.Variables = map[string]string{"tier": "silver"}
+	orders.Spec.Hooks = &schema.Hooks{PreApply: []schema.Hook{{Name: "migrate", Command: "true"}}}
+
+	api := schema.NewService("api", "shop")
+	api.Spec.Hooks = &schema.Hooks{PreApply: []schema.Hook{{Name: "build", Command: "true"}}}
+
+	quiet := schema.NewService("quiet", "shop")
+
+	scopes := Collect(stack, []*schema.Service{orders, quiet, api}, schema.HookPreApply)
+
+	require.Len(t, scopes, 3)
+	assert.Equal(t, "", scopes[0].Service)
+	assert.Equal(t, "api", scopes[1].Service)
+	assert.Equal(t, "orders", scopes[2].Service)
+	assert.Equal(t, map[string]string{"region": "us-east-1", "tier": "silver"}, scopes[2].Variables)
+
+	assert.Empty(t, Collect(stack, []*schema.Service{orders, api}, schema.HookPostApply))
+}
+
+func TestRunner_Run(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "scripts"), 0o755))
+
+	cs := diff.NewChangeSet("shop", "staging")
+	cs.AddChange(&diff.Change{
+		ResourceName: "db",
+		ResourceKind: schema.KindRDS,
+		Type:         diff.ChangeUpdate,
+		AttributeChanges: []diff.AttributeChange{
+			{Path: "spec.password", OldValue: "old", NewValue: "new", Sensitive: true},
+		},
+	})
+
+	var stdout, stderr bytes.Buffer
+	runner := NewRunner(dir, &stdout, &stderr)
+
+	scope := Scope{Service: "orders", Variables: map[string]string{"db-name": "orders"}}
+	hook := schema.Hook{
+		Name:       "inspect",
+		Command:    `pwd; echo "$PANKA_HOOK $PANKA_SERVICE $PANKA_VAR_DB_NAME $PANKA_OUTPUT_ORDERS_URL $EXTRA"; cat`,
+		WorkingDir: "scripts",
+		Env:        map[string]string{"EXTRA": "yes"},
+	}
+	hc := &Context{
+		Point:       schema.HookPreApply,
+		Stack:       "shop",
+		Environment: "staging",
+		Outputs:     map[string]string{"orders.url": "https://orders"},
+		ChangeSet:   cs,
+	}
+
+	require.NoError(t, runner.Run(context.Background(), scope, hook, hc))
+
+	lines := bytes.SplitN(stdout.Bytes(), []byte("\n"), 3)
+	require.Len(t, lines, 3)
+	assert.Equal(t, filepath.Join(dir, "scripts"), string(lines[0]))
+	assert.Equal(t, "preApply orders orders https://orders yes", string(lines[1]))
+
+	var decoded diff.ChangeSet
+	require.NoError(t, json.Unmarshal(lines[2], &decoded))
+	require.Len(t, decoded.Changes, 1)
+	assert.Equal(t, "db", decoded.Changes[0].ResourceName)
+	assert.Nil(t, decoded.Changes[0].AttributeChanges[0].NewValue)
+
+	// The change set itself is left untouched
+	assert.Equal(t, "new", cs.Changes[0].AttributeChanges[0].NewValue)
+}
+
+func TestRunner_RunFailures(t *testing.T) {
+	runner := NewRunner(t.TempDir(), &bytes.Buffer{}, &bytes.Buffer{})
+	hc := &Context{Point: schema.HookPostApply, Stack: "shop"}
+
+	err := runner.Run(context.Background(), Scope{}, schema.Hook{Name: "smoke", Command: "exit 3"}, hc)
+	require.Error(t, err)
+	assert.Equal(t, "hook smoke exited with code 3", err.Error())
+
+	err = runner.Run(context.Background(), Scope{}, schema.Hook{Command: "sleep 5", Timeout: "50ms"}, hc)
+	require.Error(t, err)
+	assert.Equal(t, "hook sleep 5 timed out after 50ms", err.Error())
+}
+
+func TestEnv_Error(t *testing.T) {
+	env := Env(Scope{}, schema.Hook{Command: "notify"}, &Context{
+		Point: schema.HookOnFailure,
+		Stack: "shop",
+		Error: "stage 2 failed",
+	})
+
+	assert.Contains(t, env, "PANKA_HOOK=onFailure")
+	assert.Contains(t, env, "PANKA_HOOK_NAME=notify")
+	assert.Contains(t, env, "PANKA_ERROR=stage 2 failed")
+	assert.NotContains(t, env, "PANKA_SERVICE=")
+}
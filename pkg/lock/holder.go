@@ -0,0 +1,181 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Holder acquires a lock, keeps it alive with periodic heartbeats and
+// releases it when the guarded operation is finished
+type Holder struct {
+	manager Manager
+	config  *Config
+
+	mu       sync.Mutex
+	lock     *Lock
+	lastErr  error
+	stopCh   chan struct{}
+	doneCh   chan struct{}
+	lostCh   chan struct{}
+	lostOnce sync.Once
+}
+
+// NewHolder creates a new lock holder using the given manager
+func NewHolder(manager Manager, config *Config) *Holder {
+	if config == nil {
+		config = DefaultConfig()
+	}
+	return &Holder{
+		manager: manager,
+		config:  config,
+		lostCh:  make(chan struct{}),
+	}
+}
+
+// Acquire acquires the lock, retrying while it is held by someone else
+// until timeout elapses. A zero timeout makes a single attempt.
+// Once acquired, a background heartbeat refreshes the lock every
+// Config.HeartbeatInterval until Release is called.
+func (h *Holder) Acquire(ctx context.Context, key, owner string, timeout time.Duration) error {
+	h.mu.Lock()
+	if h.lock != nil {
+		h.mu.Unlock()
+		return fmt.Errorf("lock %s is already held by this holder", key)
+	}
+	h.mu.Unlock()
+
+	deadline := time.Now().Add(timeout)
+	for {
+		l, err := h.manager.Acquire(ctx, key, h.config.DefaultTTL, owner)
+		if err == nil {
+			h.start(l)
+			return nil
+		}
+		if !errors.Is(err, ErrLockAlreadyHeld) {
+			return err
+		}
+
+		wait := h.config.RetryDelay
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return h.heldError(ctx, key, timeout)
+		}
+		if wait > remaining {
+			wait = remaining
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+// Lock returns the currently held lock, or nil if none is held
+func (h *Holder) Lock() *Lock {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.lock
+}
+
+// Lost returns a channel that is closed when the heartbeat can no longer
+// keep the lock alive (it expired or was taken over)
+func (h *Holder) Lost() <-chan struct{} {
+	return h.lostCh
+}
+
+// Err returns the last heartbeat error, if any
+func (h *Holder) Err() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.lastErr
+}
+
+// Release stops the heartbeat and releases the lock.
+// It is safe to call Release multiple times.
+func (h *Holder) Release(ctx context.Context) error {
+	h.mu.Lock()
+	l := h.lock
+	stopCh := h.stopCh
+	doneCh := h.doneCh
+	h.lock = nil
+	h.stopCh = nil
+	h.doneCh = nil
+	h.mu.Unlock()
+
+	if l == nil {
+		return nil
+	}
+
+	close(stopCh)
+	<-doneCh
+
+	if err := h.manager.Release(ctx, l); err != nil {
+		return fmt.Errorf("failed to release lock %s: %w", l.Key, err)
+	}
+	return nil
+}
+
+// start records the acquired lock and launches the heartbeat goroutine
+func (h *Holder) start(l *Lock) {
+	h.mu.Lock()
+	h.lock = l
+	h.lastErr = nil
+	h.stopCh = make(chan struct{})
+	h.doneCh = make(chan struct{})
+	stopCh, doneCh := h.stopCh, h.doneCh
+	h.mu.Unlock()
+
+	go h.heartbeat(l, stopCh, doneCh)
+}
+
+// heartbeat refreshes the lock until stopped or the lock is lost
+func (h *Holder) heartbeat(l *Lock, stopCh, doneCh chan struct{}) {
+	defer close(doneCh)
+
+	interval := h.config.HeartbeatInterval
+	if interval <= 0 {
+		interval = DefaultConfig().HeartbeatInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stopCh:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), interval)
+			err := h.manager.Refresh(ctx, l)
+			cancel()
+			if err == nil {
+				continue
+			}
+
+			h.mu.Lock()
+			h.lastErr = err
+			h.mu.Unlock()
+
+			// Transient errors are retried on the next tick; losing
+			// ownership of the lock is fatal for the guarded operation
+			if errors.Is(err, ErrLockExpired) || errors.Is(err, ErrInvalidLockID) || errors.Is(err, ErrLockNotFound) {
+				h.lostOnce.Do(func() { close(h.lostCh) })
+				return
+			}
+		}
+	}
+}
+
+// heldError builds a descriptive error for a lock that could not be acquired
+func (h *Holder) heldError(ctx context.Context, key string, timeout time.Duration) error {
+	info, err := h.manager.Get(ctx, key)
+	if err != nil || info == nil {
+		return fmt.Errorf("%w: %s (waited %s)", ErrLockAlreadyHeld, key, timeout)
+	}
+	return fmt.Errorf("%w: %s by %s since %s (waited %s)",
+		ErrLockAlreadyHeld, key, info.Owner, info.AcquiredAt.Format(time.RFC3339), timeout)
+}
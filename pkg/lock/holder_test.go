@@ -0,0 +1,182 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeManager is an in-memory Manager used to exercise Holder
+type fakeManager struct {
+	mu         sync.Mutex
+	held       map[string]*Lock
+	refreshes  int
+	refreshErr error
+	releases   int
+}
+
+func newFakeManager() *fakeManager {
+	return &fakeManager{held: make(map[string]*Lock)}
+}
+
+func (f *fakeManager) Acquire(ctx context.Context, key string, ttl time.Duration, owner string) (*Lock, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.held[key]; ok {
+		return nil, ErrLockAlreadyHeld
+	}
+	l := NewLock(key, "id-"+owner, owner, int64(ttl.Seconds()))
+	f.held[key] = l
+	return l, nil
+}
+
+func (f *fakeManager) Refresh(ctx context.Context, lock *Lock) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.refreshes++
+	return f.refreshErr
+}
+
+func (f *fakeManager) Release(ctx context.Context, lock *Lock) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.releases++
+	delete(f.held, lock.Key)
+	return nil
+}
+
+func (f *fakeManager) ForceRelease(ctx context.Context, key string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.held, key)
+	return nil
+}
+
+func (f *fakeManager) Get(ctx context.Context, key string) (*LockInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	l, ok := f.held[key]
+	if !ok {
+		return nil, ErrLockNotFound
+	}
+	return l.ToLockInfo(), nil
+}
+
+func (f *fakeManager) List(ctx context.Context, prefix string) ([]*LockInfo, error) {
+	return nil, nil
+}
+
+func (f *fakeManager) Close() error {
+	return nil
+}
+
+func (f *fakeManager) refreshCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.refreshes
+}
+
+func testConfig() *Config {
+	return &Config{
+		DefaultTTL:        time.Minute,
+		HeartbeatInterval: 10 * time.Millisecond,
+		RetryAttempts:     3,
+		RetryDelay:        5 * time.Millisecond,
+	}
+}
+
+func TestHolder_AcquireAndRelease(t *testing.T) {
+	mgr := newFakeManager()
+	h := NewHolder(mgr, testConfig())
+
+	err := h.Acquire(context.Background(), "stacks/app/prod", "alice", 0)
+	require.NoError(t, err)
+	require.NotNil(t, h.Lock())
+	assert.Equal(t, "stacks/app/prod", h.Lock().Key)
+
+	require.NoError(t, h.Release(context.Background()))
+	assert.Nil(t, h.Lock())
+	assert.Equal(t, 1, mgr.releases)
+
+	// Releasing twice is a no-op
+	require.NoError(t, h.Release(context.Background()))
+	assert.Equal(t, 1, mgr.releases)
+}
+
+func TestHolder_AcquireHeldNoTimeout(t *testing.T) {
+	mgr := newFakeManager()
+	_, err := mgr.Acquire(context.Background(), "stacks/app/prod", time.Minute, "bob")
+	require.NoError(t, err)
+
+	h := NewHolder(mgr, testConfig())
+	err = h.Acquire(context.Background(), "stacks/app/prod", "alice", 0)
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrLockAlreadyHeld))
+	assert.Contains(t, err.Error(), "bob")
+	assert.Nil(t, h.Lock())
+}
+
+func TestHolder_AcquireWaitsForRelease(t *testing.T) {
+	mgr := newFakeManager()
+	other, err := mgr.Acquire(context.Background(), "stacks/app/prod", time.Minute, "bob")
+	require.NoError(t, err)
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		_ = mgr.Release(context.Background(), other)
+	}()
+
+	h := NewHolder(mgr, testConfig())
+	err = h.Acquire(context.Background(), "stacks/app/prod", "alice", time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, "alice", h.Lock().Owner)
+	require.NoError(t, h.Release(context.Background()))
+}
+
+func TestHolder_AcquireContextCancelled(t *testing.T) {
+	mgr := newFakeManager()
+	_, err := mgr.Acquire(context.Background(), "stacks/app/prod", time.Minute, "bob")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	h := NewHolder(mgr, testConfig())
+	err = h.Acquire(ctx, "stacks/app/prod", "alice", time.Minute)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestHolder_Heartbeat(t *testing.T) {
+	mgr := newFakeManager()
+	h := NewHolder(mgr, testConfig())
+
+	require.NoError(t, h.Acquire(context.Background(), "stacks/app/prod", "alice", 0))
+	assert.Eventually(t, func() bool { return mgr.refreshCount() >= 2 }, time.Second, 5*time.Millisecond)
+	require.NoError(t, h.Release(context.Background()))
+
+	// No more refreshes after release
+	count := mgr.refreshCount()
+	time.Sleep(30 * time.Millisecond)
+	assert.Equal(t, count, mgr.refreshCount())
+}
+
+func TestHolder_LostOnExpiry(t *testing.T) {
+	mgr := newFakeManager()
+	mgr.refreshErr = ErrLockExpired
+	h := NewHolder(mgr, testConfig())
+
+	require.NoError(t, h.Acquire(context.Background(), "stacks/app/prod", "alice", 0))
+
+	select {
+	case <-h.Lost():
+	case <-time.After(time.Second):
+		t.Fatal("expected lock to be reported as lost")
+	}
+	assert.ErrorIs(t, h.Err(), ErrLockExpired)
+	require.NoError(t, h.Release(context.Background()))
+}
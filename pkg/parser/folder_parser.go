@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/yourusername/panka/internal/logger"
@@ -17,17 +18,27 @@ import (
 //
 //	my-stack/
 //	├── stack.yaml
-//	└── services/
-//	    ├── api/
-//	    │   ├── service.yaml
-//	    │   └── *.yaml
-//	    └── worker/
-//	        ├── service.yaml
+//	├── services/
+//	│   ├── api/
+//	│   │   ├── service.yaml
+//	│   │   └── *.yaml
+//	│   └── worker/
+//	│       ├── service.yaml
+//	│       └── *.yaml
+//	└── environments/
+//	    └── production/
 //	        └── *.yaml
+//
+// Documents in environments/<env>/ are overlays: partial resources that are
+// strategically merged over the stack or the component with the same kind
+// and name when that environment is parsed.
 type FolderParser struct {
 	parser *Parser
 	logger *logger.Logger
 
+	// Environment whose overlays are applied (empty for none)
+	environment string
+
 	// Tenant configuration (for networking inheritance)
 	tenantConfig *tenant.Tenant
 }
@@ -47,6 +58,11 @@ func NewFolderParserWithTenant(tenantCfg *tenant.Tenant) *FolderParser {
 	return fp
 }
 
+// SetEnvironment sets the environment whose overlays are applied
+func (fp *FolderParser) SetEnvironment(environment string) {
+	fp.environment = environment
+}
+
 // StackParseResult contains the complete parsed stack
 type StackParseResult struct {
 	// Stack definition from stack.yaml
@@ -64,6 +80,9 @@ type StackParseResult struct {
 	// Stack folder path
 	StackPath string
 
+	// Environment the stack was parsed for
+	Environment string
+
 	// Validation errors (non-fatal)
 	Warnings []string
 }
@@ -102,15 +121,29 @@ func (fp *FolderParser) ParseStackFolder(stackPath string) (*StackParseResult, e
 		Services:      make(map[string]*ServiceParseResult),
 		AllComponents: make([]schema.Resource, 0),
 		StackPath:     stackPath,
+		Environment:   fp.environment,
 		Warnings:      make([]string, 0),
 	}
 
+	// Load environment overlays
+	var overlays []*environmentOverlay
+	if fp.environment != "" {
+		overlays, err = fp.loadOverlays(filepath.Join(stackPath, "environments", fp.environment))
+		if err != nil {
+			return nil, fmt.Errorf("failed to load overlays for environment %s: %w", fp.environment, err)
+		}
+	}
+
 	// 1. Parse stack.yaml
 	stackFile := filepath.Join(stackPath, "stack.yaml")
 	stack, err := fp.parseStackFile(stackFile)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse stack.yaml: %w", err)
 	}
+	stack, err = fp.applyStackOverlays(stack, overlays)
+	if err != nil {
+		return nil, err
+	}
 	result.Stack = stack
 
 	// Store stack variables for interpolation
@@ -128,13 +161,32 @@ func (fp *FolderParser) ParseStackFolder(stackPath string) (*StackParseResult, e
 			return nil, fmt.Errorf("failed to parse services: %w", err)
 		}
 		result.Services = services
+	}
 
-		// Flatten all components
-		for _, svc := range services {
-			result.AllComponents = append(result.AllComponents, svc.Components...)
+	// Apply environment overlays
+	if err := fp.applyComponentOverlays(result.Services, stack, overlays); err != nil {
+		return nil, err
+	}
+
+	// Attach infrastructure requirements to their microservices and workers
+	for _, svc := range result.Services {
+		svc.Components = attachComponentInfra(svc.Components)
+	}
+
+	// Attach config files to the microservices that mount them
+	for name, svc := range result.Services {
+		if err := attachConfigFiles(svc); err != nil {
+			return nil, fmt.Errorf("service %s: %w", name, err)
 		}
 	}
 
+	// Flatten all components
+	for _, svc := range result.Services {
+		result.AllComponents = append(result.AllComponents, svc.Components...)
+	}
+
+	linkWorkerQueues(result.AllComponents)
+
 	// 3. Add tenant networking if available
 	if fp.tenantConfig != nil {
 		result.TenantNetworking = &fp.tenantConfig.Networking
@@ -147,6 +199,7 @@ func (fp *FolderParser) ParseStackFolder(stackPath string) (*StackParseResult, e
 
 	fp.logger.Info("Stack parsing complete",
 		zap.String("stack", stack.Metadata.Name),
+		zap.String("environment", fp.environment),
 		zap.Int("services", len(result.Services)),
 		zap.Int("components", len(result.AllComponents)),
 	)
@@ -154,6 +207,74 @@ func (fp *FolderParser) ParseStackFolder(stackPath string) (*StackParseResult, e
 	return result, nil
 }
 
+// attachComponentInfra attaches each ComponentInfra to the MicroService,
+// Worker or CronJob of the same name and removes it from the components. A
+// ComponentInfra without a matching component is kept as a component.
+func attachComponentInfra(components []schema.Resource) []schema.Resource {
+	targets := make(map[string]**schema.ComponentInfra)
+	for _, comp := range components {
+		switch c := comp.(type) {
+		case *schema.MicroService:
+			targets[c.Metadata.Name] = &c.Infra
+		case *schema.Worker:
+			targets[c.Metadata.Name] = &c.Infra
+		case *schema.CronJob:
+			targets[c.Metadata.Name] = &c.Infra
+		}
+	}
+
+	attached := make([]schema.Resource, 0, len(components))
+	for _, comp := range components {
+		if infra, ok := comp.(*schema.ComponentInfra); ok {
+			if target, ok := targets[infra.Metadata.Name]; ok {
+				*target = infra
+				continue
+			}
+		}
+		attached = append(attached, comp)
+	}
+
+	return attached
+}
+
+// attachConfigFiles sets the contents of the config files mounted by the
+// microservices of a service from its config/ folder. Every mounted file
+// must exist there.
+func attachConfigFiles(svc *ServiceParseResult) error {
+	for _, comp := range svc.Components {
+		ms, ok := comp.(*schema.MicroService)
+		if !ok || ms.Spec.Configs == nil {
+			continue
+		}
+
+		configs := ms.Spec.Configs
+		configs.Contents = make(map[string]string, len(configs.Files))
+		for _, file := range configs.Files {
+			content, ok := svc.ConfigFiles[file]
+			if !ok {
+				return fmt.Errorf("component %s: config file %s not found in config/", ms.Metadata.Name, file)
+			}
+			configs.Contents[file] = string(content)
+		}
+	}
+	return nil
+}
+
+// linkWorkerQueues points the queue-depth scaling of each worker at the
+// queue_name output of its SQS component, so that it is resolved at apply
+// wherever the queue is declared in the stack
+func linkWorkerQueues(components []schema.Resource) {
+	for _, comp := range components {
+		worker, ok := comp.(*schema.Worker)
+		if !ok || worker.Spec.Scaling == nil || worker.Spec.Scaling.QueueName != "" {
+			continue
+		}
+		if worker.Spec.Scaling.Queue != "" {
+			worker.Spec.Scaling.QueueName = "${" + worker.Spec.Scaling.Queue + ".queue_name}"
+		}
+	}
+}
+
 // validateFolderStructure checks that the folder has the expected structure
 func (fp *FolderParser) validateFolderStructure(stackPath string) error {
 	// Check if directory exists
@@ -262,6 +383,14 @@ func (fp *FolderParser) parseServiceFolder(servicePath string, stack *schema.Sta
 	}
 	yamlFiles = append(yamlFiles, ymlFiles...)
 
+	// The service definition is parsed first, so that its variables are
+	// known to the components, which resolve them by their bare name
+	sort.SliceStable(yamlFiles, func(i, j int) bool {
+		return isServiceFile(yamlFiles[i]) && !isServiceFile(yamlFiles[j])
+	})
+	fp.parser.scope = serviceName
+	defer func() { fp.parser.scope = "" }()
+
 	fp.logger.Debug("Found YAML files in service folder",
 		zap.String("service", serviceName),
 		zap.Int("count", len(yamlFiles)),
@@ -325,6 +454,13 @@ func (fp *FolderParser) parseServiceFolder(servicePath string, stack *schema.Sta
 	return result, nil
 }
 
+// isServiceFile reports whether a file of a service folder holds the service
+// definition
+func isServiceFile(path string) bool {
+	name := filepath.Base(path)
+	return name == "service.yaml" || name == "service.yml"
+}
+
 // parseServiceYAMLFile parses a YAML file within a service folder
 func (fp *FolderParser) parseServiceYAMLFile(path string, stack *schema.Stack, serviceName string) ([]schema.Resource, error) {
 	content, err := os.ReadFile(path)
@@ -419,6 +555,22 @@ func (fp *FolderParser) parseDocument(content []byte, stack *schema.Stack, servi
 			resource = &ms
 		}
 
+	case schema.KindWorker:
+		var worker schema.Worker
+		err = yaml.Unmarshal(interpolated, &worker)
+		if err == nil {
+			fp.setComponentMetadata(&worker.ResourceBase, stack, serviceName)
+			resource = &worker
+		}
+
+	case schema.KindCronJob:
+		var cronJob schema.CronJob
+		err = yaml.Unmarshal(interpolated, &cronJob)
+		if err == nil {
+			fp.setComponentMetadata(&cronJob.ResourceBase, stack, serviceName)
+			resource = &cronJob
+		}
+
 	case schema.KindRDS:
 		var rds schema.RDS
 		err = yaml.Unmarshal(interpolated, &rds)
@@ -427,6 +579,22 @@ func (fp *FolderParser) parseDocument(content []byte, stack *schema.Stack, servi
 			resource = &rds
 		}
 
+	case schema.KindElastiCacheRedis:
+		var redis schema.ElastiCacheRedis
+		err = yaml.Unmarshal(interpolated, &redis)
+		if err == nil {
+			fp.setComponentMetadata(&redis.ResourceBase, stack, serviceName)
+			resource = &redis
+		}
+
+	case schema.KindElastiCacheMemcached:
+		var memcached schema.ElastiCacheMemcached
+		err = yaml.Unmarshal(interpolated, &memcached)
+		if err == nil {
+			fp.setComponentMetadata(&memcached.ResourceBase, stack, serviceName)
+			resource = &memcached
+		}
+
 	case schema.KindDynamoDB:
 		var dynamo schema.DynamoDB
 		err = yaml.Unmarshal(interpolated, &dynamo)
@@ -563,8 +731,16 @@ func (fp *FolderParser) extractDependencies(resource schema.Resource) []string {
 	switch r := resource.(type) {
 	case *schema.MicroService:
 		return r.Spec.DependsOn
+	case *schema.Worker:
+		return r.Spec.DependsOn
+	case *schema.CronJob:
+		return r.Spec.DependsOn
 	case *schema.RDS:
 		return r.Spec.DependsOn
+	case *schema.ElastiCacheRedis:
+		return r.Spec.DependsOn
+	case *schema.ElastiCacheMemcached:
+		return r.Spec.DependsOn
 	case *schema.DynamoDB:
 		return r.Spec.DependsOn
 	case *schema.S3:
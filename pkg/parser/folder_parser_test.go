@@ -203,6 +203,348 @@ spec:
 	assert.Equal(t, "processor", lambda.GetMetadata().Name)
 }
 
+func TestFolderParser_ComponentInfra(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	stackYAML := `apiVersion: core.panka.io/v1
+kind: Stack
+metadata:
+  name: infra-stack
+spec:
+  provider:
+    name: aws
+    region: us-east-1
+`
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "stack.yaml"), []byte(stackYAML), 0644))
+
+	apiDir := filepath.Join(tmpDir, "services", "api")
+	require.NoError(t, os.MkdirAll(apiDir, 0755))
+
+	apiYAML := `apiVersion: components.panka.io/v1
+kind: MicroService
+metadata:
+  name: api-server
+spec:
+  image:
+    repository: example/api
+    tag: "1.0.0"
+  runtime:
+    platform: fargate
+  ports:
+    - name: http
+      port: 8080
+---
+apiVersion: infra.panka.io/v1
+kind: ComponentInfra
+metadata:
+  name: api-server
+spec:
+  resources:
+    cpu: 1024
+    memory: 2048
+  scaling:
+    replicas: 3
+  networking:
+    loadBalancer:
+      enabled: true
+      shared: true
+      healthCheckPath: /healthz
+      certificateArn: arn:aws:acm:us-east-1:123456789012:certificate/abc
+      allowedCIDRs:
+        - 10.0.0.0/8
+    ingress:
+      enabled: true
+      hostname: api.example.com
+---
+apiVersion: infra.panka.io/v1
+kind: ComponentInfra
+metadata:
+  name: orphan
+spec:
+  resources:
+    cpu: 256
+    memory: 512
+`
+	require.NoError(t, os.WriteFile(filepath.Join(apiDir, "api.yaml"), []byte(apiYAML), 0644))
+
+	fp := NewFolderParser()
+	result, err := fp.ParseStackFolder(tmpDir)
+	require.NoError(t, err)
+
+	// The matching ComponentInfra is attached, the other one is kept
+	assert.Len(t, result.AllComponents, 2)
+
+	api, ok := result.GetComponentByName("api-server").(*schema.MicroService)
+	require.True(t, ok)
+	require.NotNil(t, api.Infra)
+
+	cpu, memory := api.TaskResources()
+	assert.Equal(t, 1024, cpu)
+	assert.Equal(t, 2048, memory)
+	assert.Equal(t, 3, api.DesiredCount())
+
+	lb := api.LoadBalancer()
+	require.NotNil(t, lb)
+	assert.True(t, lb.Shared)
+	assert.Equal(t, "/healthz", lb.HealthCheckPath)
+	assert.Equal(t, []string{"10.0.0.0/8"}, lb.AllowedCIDRs)
+	require.NotNil(t, api.Ingress())
+	assert.Equal(t, "api.example.com", api.Ingress().Hostname)
+
+	orphan := result.GetComponentByName("orphan")
+	require.NotNil(t, orphan)
+	assert.Equal(t, schema.KindComponentInfra, orphan.GetKind())
+}
+
+func TestFolderParser_ConfigFiles(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	stackYAML := `apiVersion: core.panka.io/v1
+kind: Stack
+metadata:
+  name: config-stack
+spec:
+  provider:
+    name: aws
+    region: us-east-1
+`
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "stack.yaml"), []byte(stackYAML), 0644))
+
+	apiDir := filepath.Join(tmpDir, "services", "api")
+	require.NoError(t, os.MkdirAll(filepath.Join(apiDir, "config"), 0755))
+
+	apiYAML := `apiVersion: components.panka.io/v1
+kind: MicroService
+metadata:
+  name: api-server
+spec:
+  image:
+    repository: example/api
+    tag: "1.0.0"
+  runtime:
+    platform: fargate
+  configs:
+    mountPath: /etc/api
+    files:
+      - app.conf
+`
+	require.NoError(t, os.WriteFile(filepath.Join(apiDir, "api.yaml"), []byte(apiYAML), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(apiDir, "config", "app.conf"), []byte("workers = 4\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(apiDir, "config", "unused.conf"), []byte("debug = true\n"), 0644))
+
+	result, err := NewFolderParser().ParseStackFolder(tmpDir)
+	require.NoError(t, err)
+
+	// Only the mounted files are attached
+	api, ok := result.GetComponentByName("api-server").(*schema.MicroService)
+	require.True(t, ok)
+	require.NotNil(t, api.Spec.Configs)
+	assert.Equal(t, map[string]string{"app.conf": "workers = 4\n"}, api.Spec.Configs.Contents)
+
+	// Mounting a file that is not in config/ fails
+	apiYAML += "      - missing.conf\n"
+	require.NoError(t, os.WriteFile(filepath.Join(apiDir, "api.yaml"), []byte(apiYAML), 0644))
+	_, err = NewFolderParser().ParseStackFolder(tmpDir)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "config file missing.conf not found")
+}
+
+func TestFolderParser_Worker(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	stackYAML := `apiVersion: core.panka.io/v1
+kind: Stack
+metadata:
+  name: worker-stack
+spec:
+  provider:
+    name: aws
+    region: us-east-1
+`
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "stack.yaml"), []byte(stackYAML), 0644))
+
+	queueDir := filepath.Join(tmpDir, "services", "messaging")
+	require.NoError(t, os.MkdirAll(queueDir, 0755))
+	queueYAML := `apiVersion: components.panka.io/v1
+kind: SQS
+metadata:
+  name: orders
+spec:
+  type: standard
+`
+	require.NoError(t, os.WriteFile(filepath.Join(queueDir, "orders.yaml"), []byte(queueYAML), 0644))
+
+	workerDir := filepath.Join(tmpDir, "services", "fulfillment")
+	require.NoError(t, os.MkdirAll(workerDir, 0755))
+	workerYAML := `apiVersion: components.panka.io/v1
+kind: Worker
+metadata:
+  name: order-consumer
+spec:
+  image:
+    repository: example/consumer
+    tag: "2.0.0"
+  stopTimeout: 90
+  scaling:
+    queue: orders
+    minReplicas: 1
+    maxReplicas: 10
+    messagesPerTask: 50
+---
+apiVersion: infra.panka.io/v1
+kind: ComponentInfra
+metadata:
+  name: order-consumer
+spec:
+  resources:
+    cpu: 512
+    memory: 1024
+  scaling:
+    replicas: 0
+`
+	require.NoError(t, os.WriteFile(filepath.Join(workerDir, "consumer.yaml"), []byte(workerYAML), 0644))
+
+	fp := NewFolderParser()
+	result, err := fp.ParseStackFolder(tmpDir)
+	require.NoError(t, err)
+
+	worker, ok := result.GetComponentByName("order-consumer").(*schema.Worker)
+	require.True(t, ok)
+	assert.Equal(t, "fulfillment", worker.Metadata.Service)
+	assert.Equal(t, 90, worker.Spec.StopTimeout)
+	require.NotNil(t, worker.Infra)
+
+	cpu, memory := worker.TaskResources()
+	assert.Equal(t, 512, cpu)
+	assert.Equal(t, 1024, memory)
+	// The replicas are kept within the scaling bounds
+	assert.Equal(t, 1, worker.DesiredCount())
+
+	// The queue is found through its outputs, wherever it is declared
+	require.NotNil(t, worker.Spec.Scaling)
+	assert.Equal(t, "${orders.queue_name}", worker.Spec.Scaling.QueueName)
+	assert.Equal(t, 50, worker.Spec.Scaling.TargetMessagesPerTask())
+}
+
+func TestFolderParser_CronJob(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	stackYAML := `apiVersion: core.panka.io/v1
+kind: Stack
+metadata:
+  name: jobs-stack
+spec:
+  provider:
+    name: aws
+    region: us-east-1
+`
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "stack.yaml"), []byte(stackYAML), 0644))
+
+	jobDir := filepath.Join(tmpDir, "services", "reporting")
+	require.NoError(t, os.MkdirAll(jobDir, 0755))
+	jobYAML := `apiVersion: components.panka.io/v1
+kind: CronJob
+metadata:
+  name: nightly-report
+spec:
+  image:
+    repository: example/report
+    tag: "1.0.0"
+  command: ["report", "--daily"]
+  schedule: "0 3 * * *"
+  timezone: Europe/Berlin
+  retryCount: 2
+---
+apiVersion: infra.panka.io/v1
+kind: ComponentInfra
+metadata:
+  name: nightly-report
+spec:
+  resources:
+    cpu: 1024
+    memory: 2048
+`
+	require.NoError(t, os.WriteFile(filepath.Join(jobDir, "report.yaml"), []byte(jobYAML), 0644))
+
+	fp := NewFolderParser()
+	result, err := fp.ParseStackFolder(tmpDir)
+	require.NoError(t, err)
+
+	job, ok := result.GetComponentByName("nightly-report").(*schema.CronJob)
+	require.True(t, ok)
+	assert.Equal(t, "reporting", job.Metadata.Service)
+	assert.Equal(t, []string{"report", "--daily"}, job.Spec.Command)
+	assert.Equal(t, "Europe/Berlin", job.Spec.Timezone)
+	assert.Equal(t, schema.ConcurrencyAllow, job.Concurrency())
+	assert.Equal(t, 2, job.Spec.RetryCount)
+
+	require.NotNil(t, job.Infra)
+	cpu, memory := job.TaskResources()
+	assert.Equal(t, 1024, cpu)
+	assert.Equal(t, 2048, memory)
+}
+
+func TestFolderParser_ElastiCache(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	stackYAML := `apiVersion: core.panka.io/v1
+kind: Stack
+metadata:
+  name: cache-stack
+spec:
+  provider:
+    name: aws
+    region: us-east-1
+`
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "stack.yaml"), []byte(stackYAML), 0644))
+
+	serviceDir := filepath.Join(tmpDir, "services", "backend")
+	require.NoError(t, os.MkdirAll(serviceDir, 0755))
+	cacheYAML := `apiVersion: components.panka.io/v1
+kind: ElastiCacheRedis
+metadata:
+  name: sessions
+spec:
+  engineVersion: "7.1"
+  nodeType: cache.r7g.large
+  shards: 2
+  replicasPerShard: 1
+  authToken:
+    ref: sessions-auth-token
+  encryption:
+    atRest: true
+    inTransit: true
+---
+apiVersion: components.panka.io/v1
+kind: ElastiCacheMemcached
+metadata:
+  name: pages
+spec:
+  nodeType: cache.t4g.small
+  nodes: 3
+`
+	require.NoError(t, os.WriteFile(filepath.Join(serviceDir, "cache.yaml"), []byte(cacheYAML), 0644))
+
+	fp := NewFolderParser()
+	result, err := fp.ParseStackFolder(tmpDir)
+	require.NoError(t, err)
+
+	redis, ok := result.GetComponentByName("sessions").(*schema.ElastiCacheRedis)
+	require.True(t, ok)
+	assert.Equal(t, "backend", redis.Metadata.Service)
+	assert.Equal(t, "cache.r7g.large", redis.Spec.NodeType)
+	assert.True(t, redis.ClusterMode())
+	assert.Equal(t, 1, redis.Spec.ReplicasPerShard)
+	assert.True(t, redis.AuthEnabled())
+	assert.Equal(t, "7", redis.MajorVersion())
+	assert.Equal(t, 6379, redis.CachePort())
+
+	memcached, ok := result.GetComponentByName("pages").(*schema.ElastiCacheMemcached)
+	require.True(t, ok)
+	assert.Equal(t, 3, memcached.NodeCount())
+	assert.Equal(t, 11211, memcached.CachePort())
+}
+
 func TestStackParseResult_GetComponentByName(t *testing.T) {
 	result := &StackParseResult{
 		AllComponents: []schema.Resource{
@@ -231,3 +573,261 @@ func TestStackParseResult_GetComponentByName(t *testing.T) {
 	assert.Nil(t, comp)
 }
 
+
+func writeOverlayTestStack(t *testing.T) string {
+	t.Helper()
+	tmpDir := t.TempDir()
+
+	stackYAML := `apiVersion: core.panka.io/v1
+kind: Stack
+metadata:
+  name: overlay-stack
+spec:
+  provider:
+    name: aws
+    region: us-east-1
+  variables:
+    LOG_LEVEL: debug
+`
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "stack.yaml"), []byte(stackYAML), 0644))
+
+	apiDir := filepath.Join(tmpDir, "services", "api")
+	require.NoError(t, os.MkdirAll(apiDir, 0755))
+
+	apiYAML := `apiVersion: components.panka.io/v1
+kind: MicroService
+metadata:
+  name: api-server
+spec:
+  image:
+    repository: example/api
+    tag: "1.0.0"
+  runtime:
+    platform: fargate
+  environment:
+    - name: LOG_LEVEL
+      value: "${LOG_LEVEL}"
+    - name: PORT
+      value: "8080"
+---
+apiVersion: components.panka.io/v1
+kind: SQS
+metadata:
+  name: api-queue
+spec:
+  type: standard
+  visibilityTimeout: 30
+`
+	require.NoError(t, os.WriteFile(filepath.Join(apiDir, "resources.yaml"), []byte(apiYAML), 0644))
+
+	prodDir := filepath.Join(tmpDir, "environments", "production")
+	require.NoError(t, os.MkdirAll(prodDir, 0755))
+
+	overlayYAML := `apiVersion: core.panka.io/v1
+kind: Stack
+metadata:
+  name: overlay-stack
+spec:
+  variables:
+    LOG_LEVEL: warn
+---
+apiVersion: components.panka.io/v1
+kind: MicroService
+metadata:
+  name: api-server
+spec:
+  image:
+    tag: "2.0.0"
+  environment:
+    - name: REGION
+      value: us-west-2
+---
+apiVersion: components.panka.io/v1
+kind: SQS
+metadata:
+  name: api-queue
+  service: api
+spec:
+  visibilityTimeout: 120
+`
+	require.NoError(t, os.WriteFile(filepath.Join(prodDir, "overrides.yaml"), []byte(overlayYAML), 0644))
+
+	return tmpDir
+}
+
+func TestFolderParser_EnvironmentOverlay(t *testing.T) {
+	tmpDir := writeOverlayTestStack(t)
+
+	fp := NewFolderParser()
+	fp.SetEnvironment("production")
+	result, err := fp.ParseStackFolder(tmpDir)
+	require.NoError(t, err)
+
+	assert.Equal(t, "production", result.Environment)
+	assert.Equal(t, "warn", result.Stack.Spec.Variables["LOG_LEVEL"])
+	assert.Equal(t, "us-east-1", result.Stack.Spec.Provider.Region)
+
+	api, ok := result.GetComponentByName("api-server").(*schema.MicroService)
+	require.True(t, ok)
+	assert.Equal(t, "2.0.0", api.Spec.Image.Tag)
+	assert.Equal(t, "example/api", api.Spec.Image.Repository)
+	assert.Equal(t, "api", api.Metadata.Service)
+
+	// Environment variables are merged by name, with stack variables from the overlay
+	require.Len(t, api.Spec.Environment, 3)
+	assert.Equal(t, "LOG_LEVEL", api.Spec.Environment[0].Name)
+	assert.Equal(t, "warn", api.Spec.Environment[0].Value)
+	assert.Equal(t, "PORT", api.Spec.Environment[1].Name)
+	assert.Equal(t, "REGION", api.Spec.Environment[2].Name)
+
+	queue, ok := result.GetComponentByName("api-queue").(*schema.SQS)
+	require.True(t, ok)
+	assert.Equal(t, 120, queue.Spec.VisibilityTimeout)
+	assert.Equal(t, "standard", queue.Spec.Type)
+
+	// The services map holds the same merged components
+	assert.Same(t, result.Services["api"].Components[1], result.GetComponentByName("api-queue"))
+}
+
+func TestFolderParser_EnvironmentWithoutOverlays(t *testing.T) {
+	tmpDir := writeOverlayTestStack(t)
+
+	fp := NewFolderParser()
+	fp.SetEnvironment("staging")
+	result, err := fp.ParseStackFolder(tmpDir)
+	require.NoError(t, err)
+
+	api := result.GetComponentByName("api-server").(*schema.MicroService)
+	assert.Equal(t, "1.0.0", api.Spec.Image.Tag)
+	assert.Equal(t, "debug", api.Spec.Environment[0].Value)
+}
+
+func TestFolderParser_EnvironmentOverlayUnknownComponent(t *testing.T) {
+	tmpDir := writeOverlayTestStack(t)
+
+	overlayYAML := `apiVersion: components.panka.io/v1
+kind: SQS
+metadata:
+  name: missing-queue
+spec:
+  visibilityTimeout: 60
+`
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "environments", "production", "extra.yaml"), []byte(overlayYAML), 0644))
+
+	fp := NewFolderParser()
+	fp.SetEnvironment("production")
+	_, err := fp.ParseStackFolder(tmpDir)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "missing-queue")
+}
+
+func TestFolderParser_EnvironmentOverlayServiceVariables(t *testing.T) {
+	tmpDir := writeOverlayTestStack(t)
+
+	serviceYAML := `apiVersion: core.panka.io/v1
+kind: Service
+metadata:
+  name: api
+spec:
+  variables:
+    LOG_LEVEL: info
+    QUEUE_TIMEOUT: "90"
+`
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "services", "api", "service.yaml"), []byte(serviceYAML), 0644))
+
+	overlayYAML := `apiVersion: components.panka.io/v1
+kind: SQS
+metadata:
+  name: api-queue
+spec:
+  visibilityTimeout: ${QUEUE_TIMEOUT}
+`
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "environments", "production", "overrides.yaml"), []byte(overlayYAML), 0644))
+
+	fp := NewFolderParser()
+	fp.SetEnvironment("production")
+	result, err := fp.ParseStackFolder(tmpDir)
+	require.NoError(t, err)
+
+	// Base files and overlays resolve bare names to the service variables,
+	// which shadow the stack variables of the same name
+	api := result.GetComponentByName("api-server").(*schema.MicroService)
+	assert.Equal(t, "info", api.Spec.Environment[0].Value)
+
+	queue := result.GetComponentByName("api-queue").(*schema.SQS)
+	assert.Equal(t, 90, queue.Spec.VisibilityTimeout)
+}
+
+func TestStrategicMerge(t *testing.T) {
+	base := map[string]interface{}{
+		"image":   map[string]interface{}{"repository": "repo", "tag": "1"},
+		"command": []interface{}{"run", "--fast"},
+		"ports": []interface{}{
+			map[string]interface{}{"name": "http", "port": 80},
+		},
+		"debug": true,
+	}
+	overlay := map[string]interface{}{
+		"image":   map[string]interface{}{"tag": "2"},
+		"command": []interface{}{"serve"},
+		"ports": []interface{}{
+			map[string]interface{}{"name": "http", "port": 8080},
+			map[string]interface{}{"name": "admin", "port": 9090},
+		},
+		"debug": nil,
+	}
+
+	merged := strategicMerge(base, overlay)
+
+	assert.Equal(t, map[string]interface{}{"repository": "repo", "tag": "2"}, merged["image"])
+	assert.Equal(t, []interface{}{"serve"}, merged["command"])
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"name": "http", "port": 8080},
+		map[string]interface{}{"name": "admin", "port": 9090},
+	}, merged["ports"])
+	assert.NotContains(t, merged, "debug")
+
+	// The base is not modified
+	assert.Equal(t, "1", base["image"].(map[string]interface{})["tag"])
+}
+
+func TestFolderParser_BundledExamples(t *testing.T) {
+	stacks, err := filepath.Glob(filepath.Join("..", "..", "examples", "*", "stack.yaml"))
+	require.NoError(t, err)
+	require.NotEmpty(t, stacks)
+
+	for _, stackFile := range stacks {
+		stackPath := filepath.Dir(stackFile)
+		t.Run(filepath.Base(stackPath), func(t *testing.T) {
+			result, err := NewFolderParser().ParseStackFolder(stackPath)
+			require.NoError(t, err)
+
+			// Validated the way panka validate and plan do
+			parseResult := &ParseResult{
+				Stack:      result.Stack,
+				Components: result.AllComponents,
+			}
+			for _, svc := range result.Services {
+				if svc.Service != nil {
+					parseResult.Services = append(parseResult.Services, svc.Service)
+				}
+			}
+			assert.NoError(t, NewValidator().Validate(parseResult))
+		})
+	}
+}
+
+func TestFolderParser_ServiceVariables(t *testing.T) {
+	result, err := NewFolderParser().ParseStackFolder(filepath.Join("..", "..", "examples", "notification-platform"))
+	require.NoError(t, err)
+
+	// Components resolve the variables of their service by their bare name,
+	// even when their file sorts before service.yaml
+	processor, ok := result.GetComponentByName("notification-processor").(*schema.Lambda)
+	require.True(t, ok)
+	require.Len(t, processor.Spec.Triggers, 1)
+	assert.Equal(t, "10", processor.Spec.Triggers[0].BatchSize)
+	size, err := processor.Spec.Triggers[0].BatchSizeCount()
+	require.NoError(t, err)
+	assert.Equal(t, 10, size)
+}
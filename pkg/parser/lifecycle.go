@@ -0,0 +1,123 @@
+package parser
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/yourusername/panka/pkg/parser/schema"
+	"gopkg.in/yaml.v3"
+)
+
+// IgnoredValues returns the values of the attribute paths that the
+// lifecycle of a resource ignores, keyed by path. A path the resource does
+// not set has a nil value.
+func IgnoredValues(resource schema.Resource) (map[string]interface{}, error) {
+	paths := ignoredPaths(resource)
+	if len(paths) == 0 {
+		return nil, nil
+	}
+
+	doc, err := resourceDocument(resource)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]interface{}, len(paths))
+	for _, path := range paths {
+		values[path] = documentValue(doc, strings.Split(path, "."))
+	}
+	return values, nil
+}
+
+// RestoreIgnoredValues returns a copy of a resource with the attribute paths
+// that its lifecycle ignores set to values, as returned by IgnoredValues
+// when the resource was last applied. A nil value unsets its path. Ignored
+// paths without a value keep their configured value.
+func RestoreIgnoredValues(resource schema.Resource, values map[string]interface{}) (schema.Resource, error) {
+	var restore []string
+	for _, path := range ignoredPaths(resource) {
+		if _, ok := values[path]; ok {
+			restore = append(restore, path)
+		}
+	}
+	if len(restore) == 0 {
+		return resource, nil
+	}
+
+	doc, err := resourceDocument(resource)
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range restore {
+		if err := setDocumentValue(doc, strings.Split(path, "."), values[path]); err != nil {
+			return nil, fmt.Errorf("failed to restore ignored %s: %w", path, err)
+		}
+	}
+
+	content, err := yaml.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode restored resource: %w", err)
+	}
+
+	restored := reflect.New(reflect.TypeOf(resource).Elem()).Interface().(schema.Resource)
+	if err := yaml.Unmarshal(content, restored); err != nil {
+		return nil, fmt.Errorf("failed to decode restored resource: %w", err)
+	}
+
+	return restored, nil
+}
+
+// ignoredPaths returns the attribute paths ignored by the lifecycle of a
+// resource
+func ignoredPaths(resource schema.Resource) []string {
+	lifecycle := resource.GetMetadata().Lifecycle
+	if lifecycle == nil {
+		return nil
+	}
+	return lifecycle.IgnoreChanges
+}
+
+// documentValue returns the value at a path of a YAML document, or nil when
+// the path is not set
+func documentValue(node interface{}, keys []string) interface{} {
+	for _, key := range keys {
+		m, ok := node.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		node = m[key]
+	}
+	return node
+}
+
+// setDocumentValue sets the value at a path of a YAML document, creating
+// the maps above it. A nil value removes the path.
+func setDocumentValue(node interface{}, keys []string, value interface{}) error {
+	m, ok := node.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("%s is not a map", keys[0])
+	}
+	for _, key := range keys[:len(keys)-1] {
+		child, ok := m[key].(map[string]interface{})
+		if !ok {
+			if m[key] != nil {
+				return fmt.Errorf("%s is not a map", key)
+			}
+			if value == nil {
+				return nil
+			}
+			child = make(map[string]interface{})
+			m[key] = child
+		}
+		m = child
+	}
+
+	last := keys[len(keys)-1]
+	if value == nil {
+		delete(m, last)
+	} else {
+		m[last] = value
+	}
+	return nil
+}
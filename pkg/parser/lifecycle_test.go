@@ -0,0 +1,75 @@
+package parser
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourusername/panka/pkg/parser/schema"
+)
+
+func TestIgnoredValues(t *testing.T) {
+	table := schema.NewDynamoDB("table", "backend", "test-stack")
+	table.Spec.BillingMode = "PROVISIONED"
+	table.Spec.ReadCapacity = 5
+
+	values, err := IgnoredValues(table)
+	require.NoError(t, err)
+	assert.Nil(t, values)
+
+	table.Metadata.Lifecycle = &schema.Lifecycle{IgnoreChanges: []string{"spec.readCapacity", "spec.writeCapacity"}}
+	values, err = IgnoredValues(table)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"spec.readCapacity": 5, "spec.writeCapacity": nil}, values)
+}
+
+func TestRestoreIgnoredValues(t *testing.T) {
+	table := schema.NewDynamoDB("table", "backend", "test-stack")
+	table.Spec.BillingMode = "PROVISIONED"
+	table.Spec.ReadCapacity = 5
+	table.Spec.WriteCapacity = 5
+	table.Metadata.Lifecycle = &schema.Lifecycle{IgnoreChanges: []string{"spec.readCapacity", "spec.writeCapacity"}}
+
+	values, err := IgnoredValues(table)
+	require.NoError(t, err)
+
+	// Values survive being stored in state
+	content, err := json.Marshal(values)
+	require.NoError(t, err)
+	values = nil
+	require.NoError(t, json.Unmarshal(content, &values))
+
+	// Later configuration changes to the ignored paths are undone, other
+	// changes are kept
+	updated := schema.NewDynamoDB("table", "backend", "test-stack")
+	updated.Spec.BillingMode = "PROVISIONED"
+	updated.Spec.ReadCapacity = 50
+	updated.Spec.WriteCapacity = 50
+	updated.Spec.PointInTimeRecovery = true
+	updated.Metadata.Lifecycle = table.Metadata.Lifecycle
+
+	restored, err := RestoreIgnoredValues(updated, values)
+	require.NoError(t, err)
+	restoredTable, ok := restored.(*schema.DynamoDB)
+	require.True(t, ok)
+	assert.Equal(t, 5, restoredTable.Spec.ReadCapacity)
+	assert.Equal(t, 5, restoredTable.Spec.WriteCapacity)
+	assert.True(t, restoredTable.Spec.PointInTimeRecovery)
+	assert.Equal(t, 50, updated.Spec.ReadCapacity)
+
+	// A path that was unset is unset again, and a path without a recorded
+	// value keeps its configured value
+	updated.Metadata.Lifecycle = &schema.Lifecycle{IgnoreChanges: []string{"spec.readCapacity", "spec.writeCapacity", "spec.billingMode"}}
+	restored, err = RestoreIgnoredValues(updated, map[string]interface{}{"spec.readCapacity": nil})
+	require.NoError(t, err)
+	restoredTable = restored.(*schema.DynamoDB)
+	assert.Equal(t, 0, restoredTable.Spec.ReadCapacity)
+	assert.Equal(t, 50, restoredTable.Spec.WriteCapacity)
+	assert.Equal(t, "PROVISIONED", restoredTable.Spec.BillingMode)
+
+	// Without recorded values the resource is returned as is
+	restored, err = RestoreIgnoredValues(updated, nil)
+	require.NoError(t, err)
+	assert.Same(t, updated, restored)
+}
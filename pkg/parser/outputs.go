@@ -0,0 +1,284 @@
+package parser
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+
+	"github.com/yourusername/panka/pkg/parser/schema"
+	"gopkg.in/yaml.v3"
+)
+
+// KnownAfterApply is shown in place of values that reference outputs of
+// components which do not exist yet
+const KnownAfterApply = "(known after apply)"
+
+// outputRefPattern matches ${component.output} references
+var outputRefPattern = regexp.MustCompile(`\$\{([^}.]+)\.([^}]+)\}`)
+
+// OutputReference is a reference to an output of another component, written
+// as ${component.output} or as an environment variable valueFrom
+type OutputReference struct {
+	// Path is the location of the reference in the resource, such as
+	// spec.environment[DB_HOST]
+	Path      string
+	Component string
+	Output    string
+}
+
+// String returns the referenced output as component.output
+func (r OutputReference) String() string {
+	return r.Component + "." + r.Output
+}
+
+// OutputReferences returns the output references of a resource, sorted by
+// path. ${a.b} placeholders are reported whether or not a is a component, so
+// callers must ignore references to unknown components.
+func OutputReferences(resource schema.Resource) ([]OutputReference, error) {
+	doc, err := resourceDocument(resource)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[OutputReference]bool)
+	walkOutputReferences(doc, "", func(ref OutputReference) (string, bool) {
+		seen[ref] = true
+		return "", false
+	})
+
+	refs := make([]OutputReference, 0, len(seen))
+	for ref := range seen {
+		refs = append(refs, ref)
+	}
+	sort.Slice(refs, func(i, j int) bool {
+		if refs[i].Path != refs[j].Path {
+			return refs[i].Path < refs[j].Path
+		}
+		return refs[i].String() < refs[j].String()
+	})
+
+	return refs, nil
+}
+
+// ResolveOutputReferences returns a copy of a resource with its references
+// to the components in outputs replaced by the output values. References to
+// other names are left untouched. It fails if a referenced component does
+// not have the referenced output.
+func ResolveOutputReferences(resource schema.Resource, outputs map[string]map[string]string) (schema.Resource, error) {
+	doc, err := resourceDocument(resource)
+	if err != nil {
+		return nil, err
+	}
+
+	var missing []string
+	doc = walkOutputReferences(doc, "", func(ref OutputReference) (string, bool) {
+		values, ok := outputs[ref.Component]
+		if !ok {
+			return "", false
+		}
+		value, ok := values[ref.Output]
+		if !ok {
+			missing = append(missing, ref.String())
+			return "", false
+		}
+		return value, true
+	})
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("unknown output(s) referenced: %v", missing)
+	}
+
+	content, err := yaml.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode resolved resource: %w", err)
+	}
+
+	resolved := reflect.New(reflect.TypeOf(resource).Elem()).Interface().(schema.Resource)
+	if err := yaml.Unmarshal(content, resolved); err != nil {
+		return nil, fmt.Errorf("failed to decode resolved resource: %w", err)
+	}
+
+	return resolved, nil
+}
+
+// ResolveTriggerSources sets the ARN of the Lambda trigger sources that
+// reference a component from its arn output, and replaces S3 trigger buckets
+// that name a component with its bucket name. Components without outputs
+// are left unresolved. The resource is changed in place.
+func ResolveTriggerSources(resource schema.Resource, outputs map[string]map[string]string) error {
+	fn, ok := resource.(*schema.Lambda)
+	if !ok {
+		return nil
+	}
+
+	for i := range fn.Spec.Triggers {
+		trigger := &fn.Spec.Triggers[i]
+
+		if source := trigger.Source; source != nil && source.Component != "" && source.Arn == "" {
+			if values, ok := outputs[source.Component]; ok {
+				arn, ok := values["arn"]
+				if !ok {
+					return fmt.Errorf("trigger source %s has no arn output", source.Component)
+				}
+				source.Arn = arn
+			}
+		}
+
+		if s3 := trigger.S3; s3 != nil && s3.Bucket != "" {
+			if name, ok := outputs[s3.Bucket]["bucket_name"]; ok {
+				s3.Bucket = name
+			}
+		}
+	}
+
+	return nil
+}
+
+// ResolveAccessGrants sets the ARN of the access grants of a workload from
+// the arn output of their component. Components without outputs are left
+// unresolved. The resource is changed in place.
+func ResolveAccessGrants(resource schema.Resource, outputs map[string]map[string]string) error {
+	grants := schema.AccessGrants(resource)
+	for i := range grants {
+		grant := &grants[i]
+		if grant.Arn != "" {
+			continue
+		}
+		values, ok := outputs[grant.Component]
+		if !ok {
+			continue
+		}
+		arn, ok := values["arn"]
+		if !ok {
+			return fmt.Errorf("access grant component %s has no arn output", grant.Component)
+		}
+		grant.Arn = arn
+	}
+	return nil
+}
+
+// StackOutputs returns the outputs declared by a stack and its services.
+// Service outputs are named <service>.<output>.
+func StackOutputs(stack *schema.Stack, services []*schema.Service) map[string]string {
+	outputs := make(map[string]string)
+	if stack != nil {
+		for name, value := range stack.Spec.Outputs {
+			outputs[name] = value
+		}
+	}
+	for _, service := range services {
+		for name, value := range service.Spec.Outputs {
+			outputs[service.Metadata.Name+"."+name] = value
+		}
+	}
+	return outputs
+}
+
+// ValueOutputReferences returns the ${component.output} references in a value
+func ValueOutputReferences(value string) []OutputReference {
+	var refs []OutputReference
+	walkOutputReferences(value, "", func(ref OutputReference) (string, bool) {
+		refs = append(refs, ref)
+		return "", false
+	})
+	return refs
+}
+
+// ResolveOutputValue replaces the ${component.output} references in a value
+// with the output values. Every reference must resolve.
+func ResolveOutputValue(value string, outputs map[string]map[string]string) (string, error) {
+	var missing []string
+	resolved := walkOutputReferences(value, "", func(ref OutputReference) (string, bool) {
+		v, ok := outputs[ref.Component][ref.Output]
+		if !ok {
+			missing = append(missing, ref.String())
+		}
+		return v, ok
+	})
+	if len(missing) > 0 {
+		return "", fmt.Errorf("unknown output(s) referenced: %v", missing)
+	}
+
+	return resolved.(string), nil
+}
+
+// resourceDocument converts a resource to its generic YAML form
+func resourceDocument(resource schema.Resource) (interface{}, error) {
+	content, err := yaml.Marshal(resource)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode resource: %w", err)
+	}
+
+	var doc interface{}
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return nil, fmt.Errorf("failed to decode resource: %w", err)
+	}
+
+	return doc, nil
+}
+
+// walkOutputReferences visits every output reference in a YAML document and
+// replaces it with the value returned by resolve when ok is true. A resolved
+// valueFrom becomes a plain value.
+func walkOutputReferences(node interface{}, path string, resolve func(OutputReference) (string, bool)) interface{} {
+	switch n := node.(type) {
+	case map[string]interface{}:
+		if ref, ok := valueFromReference(n); ok {
+			ref.Path = path
+			if value, ok := resolve(ref); ok {
+				delete(n, "valueFrom")
+				n["value"] = value
+			}
+			return n
+		}
+		for k, v := range n {
+			childPath := k
+			if path != "" {
+				childPath = path + "." + k
+			}
+			n[k] = walkOutputReferences(v, childPath, resolve)
+		}
+		return n
+
+	case []interface{}:
+		for i, v := range n {
+			// Items with a name are addressed by name, others by index
+			itemPath := fmt.Sprintf("%s[%d]", path, i)
+			if m, ok := v.(map[string]interface{}); ok {
+				if name, ok := m["name"].(string); ok && name != "" {
+					itemPath = fmt.Sprintf("%s[%s]", path, name)
+				}
+			}
+			n[i] = walkOutputReferences(v, itemPath, resolve)
+		}
+		return n
+
+	case string:
+		return outputRefPattern.ReplaceAllStringFunc(n, func(match string) string {
+			parts := outputRefPattern.FindStringSubmatch(match)
+			ref := OutputReference{Path: path, Component: parts[1], Output: parts[2]}
+			if value, ok := resolve(ref); ok {
+				return value
+			}
+			return match
+		})
+	}
+
+	return node
+}
+
+// valueFromReference returns the reference of a valueFrom block
+func valueFromReference(m map[string]interface{}) (OutputReference, bool) {
+	vf, ok := m["valueFrom"].(map[string]interface{})
+	if !ok {
+		return OutputReference{}, false
+	}
+
+	component, _ := vf["component"].(string)
+	output, _ := vf["output"].(string)
+	if component == "" || output == "" {
+		return OutputReference{}, false
+	}
+
+	return OutputReference{Component: component, Output: output}, true
+}
@@ -0,0 +1,145 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourusername/panka/pkg/parser/schema"
+)
+
+func createReferencingService() *schema.MicroService {
+	api := schema.NewMicroService("api", "backend", "test-stack")
+	api.Spec.Environment = []schema.EnvironmentVariable{
+		{Name: "QUEUE_URL", ValueFrom: &schema.ValueFrom{Component: "orders-queue", Output: "queue_url"}},
+		{Name: "TABLE", Value: "${orders-table.table_name}"},
+		{Name: "LOG_LEVEL", Value: "info"},
+	}
+	api.Spec.Command = []string{"serve", "--topic=${events.arn}"}
+	return api
+}
+
+func TestOutputReferences(t *testing.T) {
+	refs, err := OutputReferences(createReferencingService())
+	require.NoError(t, err)
+
+	assert.Equal(t, []OutputReference{
+		{Path: "spec.command[1]", Component: "events", Output: "arn"},
+		{Path: "spec.environment[QUEUE_URL]", Component: "orders-queue", Output: "queue_url"},
+		{Path: "spec.environment[TABLE].value", Component: "orders-table", Output: "table_name"},
+	}, refs)
+}
+
+func TestResolveOutputReferences(t *testing.T) {
+	api := createReferencingService()
+
+	resolved, err := ResolveOutputReferences(api, map[string]map[string]string{
+		"orders-queue": {"queue_url": "https://sqs/orders"},
+		"orders-table": {"table_name": "orders"},
+	})
+	require.NoError(t, err)
+
+	ms, ok := resolved.(*schema.MicroService)
+	require.True(t, ok)
+	assert.Equal(t, "https://sqs/orders", ms.Spec.Environment[0].Value)
+	assert.Nil(t, ms.Spec.Environment[0].ValueFrom)
+	assert.Equal(t, "orders", ms.Spec.Environment[1].Value)
+	assert.Equal(t, "info", ms.Spec.Environment[2].Value)
+
+	// References to unknown components are left untouched
+	assert.Equal(t, "--topic=${events.arn}", ms.Spec.Command[1])
+
+	// The original resource is not modified
+	assert.NotNil(t, api.Spec.Environment[0].ValueFrom)
+	assert.Equal(t, "${orders-table.table_name}", api.Spec.Environment[1].Value)
+}
+
+func TestResolveOutputReferences_MissingOutput(t *testing.T) {
+	_, err := ResolveOutputReferences(createReferencingService(), map[string]map[string]string{
+		"orders-queue": {},
+	})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "orders-queue.queue_url")
+}
+
+func TestResolveTriggerSources(t *testing.T) {
+	fn := schema.NewLambda("processor", "backend", "test-stack")
+	fn.Spec.Triggers = []schema.LambdaTrigger{
+		{Type: schema.TriggerSQS, Source: &schema.TriggerSource{Component: "orders-queue"}},
+		{Type: schema.TriggerKinesis, Source: &schema.TriggerSource{Arn: "arn:aws:kinesis:us-east-1:123456789012:stream/clicks"}},
+		{Type: schema.TriggerSNS, Source: &schema.TriggerSource{Component: "events"}},
+		{Type: schema.TriggerS3, S3: &schema.S3Trigger{Bucket: "uploads"}},
+		{Type: schema.TriggerS3, S3: &schema.S3Trigger{Bucket: "existing-bucket"}},
+	}
+
+	outputs := map[string]map[string]string{
+		"orders-queue": {"arn": "arn:aws:sqs:us-east-1:123456789012:orders"},
+		"uploads":      {"arn": "arn:aws:s3:::test-stack-uploads", "bucket_name": "test-stack-uploads"},
+	}
+	require.NoError(t, ResolveTriggerSources(fn, outputs))
+
+	triggers := fn.Spec.Triggers
+	assert.Equal(t, "arn:aws:sqs:us-east-1:123456789012:orders", triggers[0].Source.Arn)
+	assert.Equal(t, "arn:aws:kinesis:us-east-1:123456789012:stream/clicks", triggers[1].Source.Arn)
+	// Components without outputs are left unresolved
+	assert.Empty(t, triggers[2].Source.Arn)
+	assert.Equal(t, "test-stack-uploads", triggers[3].S3.Bucket)
+	assert.Equal(t, "existing-bucket", triggers[4].S3.Bucket)
+
+	// A source must have an arn output
+	fn.Spec.Triggers[0].Source.Arn = ""
+	outputs["orders-queue"] = map[string]string{"queue_url": "https://sqs/orders"}
+	err := ResolveTriggerSources(fn, outputs)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "orders-queue")
+
+	// Other kinds are ignored
+	assert.NoError(t, ResolveTriggerSources(createReferencingService(), outputs))
+}
+
+func TestResolveAccessGrants(t *testing.T) {
+	ms := schema.NewMicroService("api", "backend", "test-stack")
+	ms.Spec.Access = []schema.ComponentAccess{
+		{Component: "users", Mode: schema.AccessReadWrite},
+		{Component: "uploads", Mode: schema.AccessRead},
+	}
+
+	outputs := map[string]map[string]string{
+		"users": {"arn": "arn:aws:dynamodb:us-east-1:123456789012:table/users"},
+	}
+	require.NoError(t, ResolveAccessGrants(ms, outputs))
+	assert.Equal(t, "arn:aws:dynamodb:us-east-1:123456789012:table/users", ms.Spec.Access[0].Arn)
+	// Components without outputs are left unresolved
+	assert.Empty(t, ms.Spec.Access[1].Arn)
+
+	// A granted component must have an arn output
+	outputs["uploads"] = map[string]string{"bucket_name": "test-stack-uploads"}
+	err := ResolveAccessGrants(ms, outputs)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "uploads")
+}
+
+func TestStackOutputs(t *testing.T) {
+	stack := schema.NewStack("test-stack")
+	stack.Spec.Outputs = map[string]string{"queueUrl": "${jobs.queue_url}"}
+	service := schema.NewService("backend", "test-stack")
+	service.Spec.Outputs = map[string]string{"endpoint": "https://${api.dns_name}/v1"}
+
+	assert.Equal(t, map[string]string{
+		"queueUrl":         "${jobs.queue_url}",
+		"backend.endpoint": "https://${api.dns_name}/v1",
+	}, StackOutputs(stack, []*schema.Service{service}))
+}
+
+func TestResolveOutputValue(t *testing.T) {
+	outputs := map[string]map[string]string{
+		"api": {"dns_name": "api.example.com"},
+	}
+
+	value, err := ResolveOutputValue("https://${api.dns_name}/v1", outputs)
+	require.NoError(t, err)
+	assert.Equal(t, "https://api.example.com/v1", value)
+
+	_, err = ResolveOutputValue("${jobs.queue_url}", outputs)
+	assert.Error(t, err)
+}
@@ -0,0 +1,258 @@
+package parser
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/yourusername/panka/pkg/parser/schema"
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+)
+
+// environmentOverlay is a partial resource document from environments/<env>/
+// that is merged over the base resource with the same kind and name
+type environmentOverlay struct {
+	Kind    schema.Kind
+	Name    string
+	Service string
+	File    string
+	Doc     map[string]interface{}
+	applied bool
+}
+
+// loadOverlays reads all overlay documents from an environment folder.
+// A missing folder means the environment has no overlays.
+func (fp *FolderParser) loadOverlays(envPath string) ([]*environmentOverlay, error) {
+	info, err := os.Stat(envPath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to access environment folder: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("environment path is not a directory: %s", envPath)
+	}
+
+	yamlFiles, err := filepath.Glob(filepath.Join(envPath, "*.yaml"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list YAML files: %w", err)
+	}
+	ymlFiles, err := filepath.Glob(filepath.Join(envPath, "*.yml"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list YML files: %w", err)
+	}
+	yamlFiles = append(yamlFiles, ymlFiles...)
+	sort.Strings(yamlFiles)
+
+	var overlays []*environmentOverlay
+	for _, yamlFile := range yamlFiles {
+		content, err := os.ReadFile(yamlFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", filepath.Base(yamlFile), err)
+		}
+
+		for i, doc := range fp.splitDocuments(content) {
+			var raw map[string]interface{}
+			if err := yaml.Unmarshal(doc, &raw); err != nil {
+				return nil, fmt.Errorf("failed to parse %s document %d: %w", filepath.Base(yamlFile), i+1, err)
+			}
+
+			var base schema.ResourceBase
+			if err := yaml.Unmarshal(doc, &base); err != nil {
+				return nil, fmt.Errorf("failed to parse %s document %d: %w", filepath.Base(yamlFile), i+1, err)
+			}
+			if base.Kind == "" || (base.Kind != schema.KindStack && base.Metadata.Name == "") {
+				return nil, fmt.Errorf("%s document %d: overlay must set kind and metadata.name", filepath.Base(yamlFile), i+1)
+			}
+
+			overlays = append(overlays, &environmentOverlay{
+				Kind:    base.Kind,
+				Name:    base.Metadata.Name,
+				Service: base.Metadata.Service,
+				File:    filepath.Base(yamlFile),
+				Doc:     raw,
+			})
+		}
+	}
+
+	return overlays, nil
+}
+
+// applyStackOverlays merges Stack overlays over the stack definition
+func (fp *FolderParser) applyStackOverlays(stack *schema.Stack, overlays []*environmentOverlay) (*schema.Stack, error) {
+	for _, overlay := range overlays {
+		if overlay.Kind != schema.KindStack {
+			continue
+		}
+
+		merged, err := mergeOverlay(stack, overlay.Doc)
+		if err != nil {
+			return nil, fmt.Errorf("failed to apply overlay from %s: %w", overlay.File, err)
+		}
+
+		var result schema.Stack
+		if err := yaml.Unmarshal(merged, &result); err != nil {
+			return nil, fmt.Errorf("failed to apply overlay from %s: %w", overlay.File, err)
+		}
+		stack = &result
+		overlay.applied = true
+	}
+
+	return stack, nil
+}
+
+// applyComponentOverlays merges component overlays over the components of
+// each service. Every component overlay must match a base component.
+func (fp *FolderParser) applyComponentOverlays(services map[string]*ServiceParseResult, stack *schema.Stack, overlays []*environmentOverlay) error {
+	defer func() { fp.parser.scope = "" }()
+
+	for serviceName, svc := range services {
+		// Overlays resolve bare variable names like the service files do
+		fp.parser.scope = serviceName
+
+		for i, component := range svc.Components {
+			metadata := component.GetMetadata()
+
+			for _, overlay := range overlays {
+				if overlay.Kind == schema.KindStack || overlay.Name != metadata.Name {
+					continue
+				}
+				if overlay.Kind != component.GetKind() {
+					return fmt.Errorf("overlay %s in %s has kind %s, but the component is %s",
+						overlay.Name, overlay.File, overlay.Kind, component.GetKind())
+				}
+				if overlay.Service != "" && overlay.Service != metadata.Service {
+					continue
+				}
+
+				merged, err := mergeOverlay(component, overlay.Doc)
+				if err != nil {
+					return fmt.Errorf("failed to apply overlay %s from %s: %w", overlay.Name, overlay.File, err)
+				}
+
+				resource, err := fp.parseDocument(merged, stack, serviceName)
+				if err != nil {
+					return fmt.Errorf("failed to apply overlay %s from %s: %w", overlay.Name, overlay.File, err)
+				}
+
+				fp.logger.Debug("Applied environment overlay",
+					zap.String("component", overlay.Name),
+					zap.String("file", overlay.File),
+				)
+
+				component = resource
+				svc.Components[i] = resource
+				overlay.applied = true
+			}
+		}
+	}
+
+	for _, overlay := range overlays {
+		if !overlay.applied {
+			return fmt.Errorf("overlay %s (%s) in %s does not match any component", overlay.Name, overlay.Kind, overlay.File)
+		}
+	}
+
+	return nil
+}
+
+// mergeOverlay strategically merges an overlay document over a resource and
+// returns the merged YAML
+func mergeOverlay(base interface{}, overlay map[string]interface{}) ([]byte, error) {
+	content, err := yaml.Marshal(base)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode base resource: %w", err)
+	}
+
+	var baseDoc map[string]interface{}
+	if err := yaml.Unmarshal(content, &baseDoc); err != nil {
+		return nil, fmt.Errorf("failed to decode base resource: %w", err)
+	}
+
+	merged, err := yaml.Marshal(strategicMerge(baseDoc, overlay))
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode merged resource: %w", err)
+	}
+
+	return merged, nil
+}
+
+// strategicMerge merges overlay into base and returns the result:
+//   - maps are merged key by key
+//   - lists whose items are all maps with a "name" key are merged by name,
+//     with new items appended
+//   - any other value in the overlay replaces the base value
+//   - a null value in the overlay removes the key
+func strategicMerge(base, overlay map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(base))
+	for k, v := range base {
+		result[k] = v
+	}
+
+	for k, v := range overlay {
+		if v == nil {
+			delete(result, k)
+			continue
+		}
+		result[k] = mergeValue(result[k], v)
+	}
+
+	return result
+}
+
+// mergeValue merges a single overlay value over a base value
+func mergeValue(base, overlay interface{}) interface{} {
+	switch o := overlay.(type) {
+	case map[string]interface{}:
+		if b, ok := base.(map[string]interface{}); ok {
+			return strategicMerge(b, o)
+		}
+	case []interface{}:
+		if b, ok := base.([]interface{}); ok && isNamedList(b) && isNamedList(o) {
+			return mergeNamedLists(b, o)
+		}
+	}
+	return overlay
+}
+
+// isNamedList reports whether every item of a list is a map with a name key
+func isNamedList(list []interface{}) bool {
+	if len(list) == 0 {
+		return false
+	}
+	for _, item := range list {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		if _, ok := m["name"]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// mergeNamedLists merges two lists of named maps by their name key
+func mergeNamedLists(base, overlay []interface{}) []interface{} {
+	result := make([]interface{}, len(base))
+	copy(result, base)
+
+	index := make(map[interface{}]int, len(base))
+	for i, item := range base {
+		index[item.(map[string]interface{})["name"]] = i
+	}
+
+	for _, item := range overlay {
+		m := item.(map[string]interface{})
+		if i, ok := index[m["name"]]; ok {
+			result[i] = strategicMerge(result[i].(map[string]interface{}), m)
+		} else {
+			result = append(result, m)
+		}
+	}
+
+	return result
+}
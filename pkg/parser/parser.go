@@ -18,6 +18,10 @@ type Parser struct {
 	
 	// Variables for interpolation
 	variables map[string]string
+
+	// Service whose variables are also resolved by their bare name, which
+	// take precedence over stack variables
+	scope string
 	
 	// Component outputs for cross-reference
 	componentOutputs map[string]map[string]string
@@ -158,6 +162,20 @@ func (p *Parser) parseDocument(content []byte) (schema.Resource, error) {
 		}
 		resource = &ms
 		
+	case schema.KindWorker:
+		var worker schema.Worker
+		if err := yaml.Unmarshal(interpolated, &worker); err != nil {
+			return nil, fmt.Errorf("failed to parse Worker: %w", err)
+		}
+		resource = &worker
+		
+	case schema.KindCronJob:
+		var cronJob schema.CronJob
+		if err := yaml.Unmarshal(interpolated, &cronJob); err != nil {
+			return nil, fmt.Errorf("failed to parse CronJob: %w", err)
+		}
+		resource = &cronJob
+		
 	case schema.KindComponentInfra:
 		var infra schema.ComponentInfra
 		if err := yaml.Unmarshal(interpolated, &infra); err != nil {
@@ -172,6 +190,20 @@ func (p *Parser) parseDocument(content []byte) (schema.Resource, error) {
 		}
 		resource = &rds
 		
+	case schema.KindElastiCacheRedis:
+		var redis schema.ElastiCacheRedis
+		if err := yaml.Unmarshal(interpolated, &redis); err != nil {
+			return nil, fmt.Errorf("failed to parse ElastiCacheRedis: %w", err)
+		}
+		resource = &redis
+		
+	case schema.KindElastiCacheMemcached:
+		var memcached schema.ElastiCacheMemcached
+		if err := yaml.Unmarshal(interpolated, &memcached); err != nil {
+			return nil, fmt.Errorf("failed to parse ElastiCacheMemcached: %w", err)
+		}
+		resource = &memcached
+		
 	case schema.KindDynamoDB:
 		var dynamo schema.DynamoDB
 		if err := yaml.Unmarshal(interpolated, &dynamo); err != nil {
@@ -224,6 +256,13 @@ func (p *Parser) interpolateVariables(content []byte) ([]byte, error) {
 		// Extract variable name (remove ${ and })
 		varName := match[2 : len(match)-1]
 		
+		// Variables of the service being parsed shadow stack variables
+		if p.scope != "" && !strings.Contains(varName, ".") {
+			if value, ok := p.variables[p.scope+"."+varName]; ok {
+				return value
+			}
+		}
+
 		// First check regular variables (including dotted names like service.var)
 		if value, ok := p.variables[varName]; ok {
 			return value
@@ -293,8 +332,16 @@ func (p *Parser) extractDependencies(resource schema.Resource) []string {
 	switch r := resource.(type) {
 	case *schema.MicroService:
 		return r.Spec.DependsOn
+	case *schema.Worker:
+		return r.Spec.DependsOn
+	case *schema.CronJob:
+		return r.Spec.DependsOn
 	case *schema.RDS:
 		return r.Spec.DependsOn
+	case *schema.ElastiCacheRedis:
+		return r.Spec.DependsOn
+	case *schema.ElastiCacheMemcached:
+		return r.Spec.DependsOn
 	case *schema.DynamoDB:
 		return r.Spec.DependsOn
 	case *schema.S3:
@@ -0,0 +1,174 @@
+package schema
+
+import "strings"
+
+// ElastiCacheRedis represents an ElastiCache for Redis replication group
+type ElastiCacheRedis struct {
+	ResourceBase `yaml:",inline"`
+	Spec         ElastiCacheRedisSpec `yaml:"spec" validate:"required"`
+}
+
+// ElastiCacheRedisSpec defines the Redis cache specification
+type ElastiCacheRedisSpec struct {
+	// EngineVersion such as 7.1 (default: the latest version)
+	EngineVersion string `yaml:"engineVersion,omitempty"`
+
+	// NodeType such as cache.t4g.small
+	NodeType string `yaml:"nodeType" validate:"required"`
+
+	// Shards is the number of node groups. More than one shard enables
+	// cluster mode.
+	Shards int `yaml:"shards,omitempty" validate:"omitempty,min=1,max=500"`
+
+	// ReplicasPerShard is the number of read replicas of each shard. With
+	// replicas, the primary fails over automatically.
+	ReplicasPerShard int `yaml:"replicasPerShard,omitempty" validate:"omitempty,min=0,max=5"`
+
+	Port int `yaml:"port,omitempty" validate:"omitempty,min=1,max=65535"`
+
+	// AuthToken references the secret holding the Redis AUTH token. It
+	// requires encryption in transit.
+	AuthToken *SecretRef `yaml:"authToken,omitempty"`
+
+	Encryption CacheEncryption `yaml:"encryption,omitempty"`
+
+	// Dependencies
+	DependsOn []string `yaml:"dependsOn,omitempty"`
+}
+
+// ElastiCacheMemcached represents an ElastiCache for Memcached cluster
+type ElastiCacheMemcached struct {
+	ResourceBase `yaml:",inline"`
+	Spec         ElastiCacheMemcachedSpec `yaml:"spec" validate:"required"`
+}
+
+// ElastiCacheMemcachedSpec defines the Memcached cache specification
+type ElastiCacheMemcachedSpec struct {
+	// EngineVersion such as 1.6.22 (default: the latest version)
+	EngineVersion string `yaml:"engineVersion,omitempty"`
+
+	// NodeType such as cache.t4g.small
+	NodeType string `yaml:"nodeType" validate:"required"`
+
+	// Nodes is the number of cache nodes (default 1)
+	Nodes int `yaml:"nodes,omitempty" validate:"omitempty,min=1,max=60"`
+
+	Port int `yaml:"port,omitempty" validate:"omitempty,min=1,max=65535"`
+
+	// Encryption in transit; Memcached does not support encryption at rest
+	Encryption CacheEncryption `yaml:"encryption,omitempty"`
+
+	// Dependencies
+	DependsOn []string `yaml:"dependsOn,omitempty"`
+}
+
+// CacheEncryption defines the encryption of a cache
+type CacheEncryption struct {
+	AtRest    bool `yaml:"atRest,omitempty"`
+	InTransit bool `yaml:"inTransit,omitempty"`
+}
+
+// Validate validates the Redis cache
+func (r *ElastiCacheRedis) Validate() error {
+	// TODO: Implement comprehensive validation
+	return nil
+}
+
+// ShardCount returns the number of shards, at least 1
+func (r *ElastiCacheRedis) ShardCount() int {
+	if r.Spec.Shards > 0 {
+		return r.Spec.Shards
+	}
+	return 1
+}
+
+// ClusterMode reports whether the replication group is sharded
+func (r *ElastiCacheRedis) ClusterMode() bool {
+	return r.ShardCount() > 1
+}
+
+// CachePort returns the port of the cache
+func (r *ElastiCacheRedis) CachePort() int {
+	if r.Spec.Port > 0 {
+		return r.Spec.Port
+	}
+	return 6379
+}
+
+// AuthEnabled reports whether clients authenticate with a token
+func (r *ElastiCacheRedis) AuthEnabled() bool {
+	return r.Spec.AuthToken != nil && r.Spec.AuthToken.Ref != ""
+}
+
+// MajorVersion returns the major engine version, such as 7, or an empty
+// string when the version is left to ElastiCache
+func (r *ElastiCacheRedis) MajorVersion() string {
+	major, _, _ := strings.Cut(r.Spec.EngineVersion, ".")
+	return major
+}
+
+// Validate validates the Memcached cache
+func (m *ElastiCacheMemcached) Validate() error {
+	// TODO: Implement comprehensive validation
+	return nil
+}
+
+// NodeCount returns the number of cache nodes, at least 1
+func (m *ElastiCacheMemcached) NodeCount() int {
+	if m.Spec.Nodes > 0 {
+		return m.Spec.Nodes
+	}
+	return 1
+}
+
+// CachePort returns the port of the cache
+func (m *ElastiCacheMemcached) CachePort() int {
+	if m.Spec.Port > 0 {
+		return m.Spec.Port
+	}
+	return 11211
+}
+
+// NewElastiCacheRedis creates a new Redis cache with defaults
+func NewElastiCacheRedis(name, service, stack string) *ElastiCacheRedis {
+	return &ElastiCacheRedis{
+		ResourceBase: ResourceBase{
+			APIVersion: ComponentsAPIVersion,
+			Kind:       KindElastiCacheRedis,
+			Metadata: Metadata{
+				Name:    name,
+				Service: service,
+				Stack:   stack,
+				Labels:  make(map[string]string),
+			},
+		},
+		Spec: ElastiCacheRedisSpec{
+			NodeType: "cache.t4g.micro",
+			Shards:   1,
+			Encryption: CacheEncryption{
+				AtRest:    true,
+				InTransit: true,
+			},
+		},
+	}
+}
+
+// NewElastiCacheMemcached creates a new Memcached cache with defaults
+func NewElastiCacheMemcached(name, service, stack string) *ElastiCacheMemcached {
+	return &ElastiCacheMemcached{
+		ResourceBase: ResourceBase{
+			APIVersion: ComponentsAPIVersion,
+			Kind:       KindElastiCacheMemcached,
+			Metadata: Metadata{
+				Name:    name,
+				Service: service,
+				Stack:   stack,
+				Labels:  make(map[string]string),
+			},
+		},
+		Spec: ElastiCacheMemcachedSpec{
+			NodeType: "cache.t4g.micro",
+			Nodes:    1,
+		},
+	}
+}
@@ -1,5 +1,7 @@
 package schema
 
+import "strings"
+
 // APIVersion represents the API version of a resource
 type APIVersion string
 
@@ -75,6 +77,52 @@ type Metadata struct {
 	Tenant  string `yaml:"tenant,omitempty"`
 	Stack   string `yaml:"stack,omitempty"`
 	Service string `yaml:"service,omitempty"`
+
+	// Lifecycle controls how changes to the resource are applied
+	Lifecycle *Lifecycle `yaml:"lifecycle,omitempty"`
+}
+
+// Lifecycle controls how a resource is changed and deleted
+type Lifecycle struct {
+	// PreventDestroy makes apply and destroy refuse to delete the resource,
+	// including replacing it
+	PreventDestroy bool `yaml:"preventDestroy,omitempty"`
+
+	// IgnoreChanges lists attribute paths, such as spec.readCapacity, whose
+	// changes are not planned. A path also covers the attributes below it.
+	// An update made for other changes applies ignored paths with the values
+	// they were last applied with, so their configured values are never
+	// applied; a replacement creates the resource as configured.
+	IgnoreChanges []string `yaml:"ignoreChanges,omitempty"`
+
+	// CreateBeforeDestroy creates the replacement of a resource before the
+	// resource is deleted. The replacement must get a new identifier.
+	CreateBeforeDestroy bool `yaml:"createBeforeDestroy,omitempty"`
+}
+
+// PreventsDestroy reports whether the lifecycle protects the resource from
+// deletion. It is false for a nil lifecycle.
+func (l *Lifecycle) PreventsDestroy() bool {
+	return l != nil && l.PreventDestroy
+}
+
+// CreatesBeforeDestroy reports whether replacements are created before the
+// resource is deleted. It is false for a nil lifecycle.
+func (l *Lifecycle) CreatesBeforeDestroy() bool {
+	return l != nil && l.CreateBeforeDestroy
+}
+
+// IgnoresChange reports whether changes to an attribute path are ignored
+func (l *Lifecycle) IgnoresChange(path string) bool {
+	if l == nil {
+		return false
+	}
+	for _, ignored := range l.IgnoreChanges {
+		if path == ignored || strings.HasPrefix(path, ignored+".") {
+			return true
+		}
+	}
+	return false
 }
 
 // Resource is the base interface that all resources implement
@@ -133,6 +181,74 @@ type Secret struct {
 	EnvVar    string    `yaml:"envVar,omitempty"`
 }
 
+// EnvName returns the environment variable a secret is exposed as
+func (s Secret) EnvName() string {
+	if s.EnvVar != "" {
+		return s.EnvVar
+	}
+	return s.Name
+}
+
+// Access modes of a component access grant
+const (
+	AccessRead      = "read"
+	AccessWrite     = "write"
+	AccessReadWrite = "readwrite"
+)
+
+// ComponentAccess grants a workload access to an S3 bucket, DynamoDB table,
+// SQS queue or SNS topic of the stack. Arn is set from the outputs of the
+// component before the workload is applied.
+type ComponentAccess struct {
+	Component string `yaml:"component" validate:"required"`
+	Mode      string `yaml:"mode" validate:"required"` // read, write or readwrite
+	Arn       string `yaml:"arn,omitempty"`
+}
+
+// Reads reports whether the grant allows reading the component
+func (a *ComponentAccess) Reads() bool {
+	return a.Mode == AccessRead || a.Mode == AccessReadWrite
+}
+
+// Writes reports whether the grant allows writing to the component
+func (a *ComponentAccess) Writes() bool {
+	return a.Mode == AccessWrite || a.Mode == AccessReadWrite
+}
+
+// AccessGrants returns the access grants of a workload, or nil for other
+// resources. The grants are shared with the resource.
+func AccessGrants(resource Resource) []ComponentAccess {
+	switch r := resource.(type) {
+	case *MicroService:
+		return r.Spec.Access
+	case *Worker:
+		return r.Spec.Access
+	case *CronJob:
+		return r.Spec.Access
+	case *Lambda:
+		return r.Spec.Access
+	default:
+		return nil
+	}
+}
+
+// WorkloadSecrets returns the secrets of a workload, or nil for other
+// resources. The secrets are shared with the resource.
+func WorkloadSecrets(resource Resource) []Secret {
+	switch r := resource.(type) {
+	case *MicroService:
+		return r.Spec.Secrets
+	case *Worker:
+		return r.Spec.Secrets
+	case *CronJob:
+		return r.Spec.Secrets
+	case *Lambda:
+		return r.Spec.Secrets
+	default:
+		return nil
+	}
+}
+
 // ResourceRequirements defines compute resources
 type ResourceRequirements struct {
 	CPU    int `yaml:"cpu" validate:"required,min=128"`       // CPU units (256 = 0.25 vCPU)
@@ -148,6 +264,40 @@ type AutoScaling struct {
 	// Scaling policies
 	TargetCPUPercent    int `yaml:"targetCPUPercent,omitempty" validate:"omitempty,min=1,max=100"`
 	TargetMemoryPercent int `yaml:"targetMemoryPercent,omitempty" validate:"omitempty,min=1,max=100"`
+
+	// Cooldowns in seconds after scaling in and out
+	ScaleInCooldown  int `yaml:"scaleInCooldown,omitempty" validate:"omitempty,min=0"`
+	ScaleOutCooldown int `yaml:"scaleOutCooldown,omitempty" validate:"omitempty,min=0"`
+
+	// Schedules change the replica bounds at set times
+	Schedules []ScheduledScaling `yaml:"schedules,omitempty" validate:"dive"`
+}
+
+// DefaultTargetCPUPercent is the CPU utilization autoscaling keeps when no
+// target is set
+const DefaultTargetCPUPercent = 70
+
+// CPUTarget returns the CPU utilization to keep, defaulting when neither a
+// CPU nor a memory target is set
+func (a *AutoScaling) CPUTarget() int {
+	if a.TargetCPUPercent == 0 && a.TargetMemoryPercent == 0 {
+		return DefaultTargetCPUPercent
+	}
+	return a.TargetCPUPercent
+}
+
+// ScheduledScaling sets new scaling bounds at scheduled times, e.g. to
+// scale in at night. A bound that is left out is not changed.
+type ScheduledScaling struct {
+	Name string `yaml:"name" validate:"required"`
+
+	// Schedule is a five-field cron expression, or a cron(), rate() or at()
+	// expression
+	Schedule string `yaml:"schedule" validate:"required"`
+	Timezone string `yaml:"timezone,omitempty"`
+
+	MinCapacity *int `yaml:"minCapacity,omitempty" validate:"omitempty,min=0"`
+	MaxCapacity *int `yaml:"maxCapacity,omitempty" validate:"omitempty,min=0"`
 }
 
 // HealthCheck defines health check configuration
@@ -0,0 +1,189 @@
+package schema
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Concurrency policies of a CronJob
+const (
+	ConcurrencyAllow   = "Allow"
+	ConcurrencyForbid  = "Forbid"
+	ConcurrencyReplace = "Replace"
+)
+
+// CronJob represents a containerized task that runs on a schedule
+type CronJob struct {
+	ResourceBase `yaml:",inline"`
+	Spec         CronJobSpec `yaml:"spec" validate:"required"`
+
+	// Infra holds the ComponentInfra of the same name, attached by the
+	// folder parser
+	Infra *ComponentInfra `yaml:"infra,omitempty"`
+}
+
+// CronJobSpec defines the cron job specification
+type CronJobSpec struct {
+	// Container image configuration
+	Image ImageConfig `yaml:"image" validate:"required"`
+
+	// Runtime configuration
+	Runtime RuntimeConfig `yaml:"runtime,omitempty"`
+
+	// Environment and secrets
+	Environment []EnvironmentVariable `yaml:"environment,omitempty" validate:"dive"`
+	Secrets     []Secret              `yaml:"secrets,omitempty" validate:"dive"`
+
+	// Dependencies
+	DependsOn []string `yaml:"dependsOn,omitempty"`
+
+	// Access grants the IAM role of the workload access to components it
+	// depends on
+	Access []ComponentAccess `yaml:"access,omitempty" validate:"dive"`
+
+	// Command override
+	Command []string `yaml:"command,omitempty"`
+	Args    []string `yaml:"args,omitempty"`
+
+	// Schedule is a five-field cron expression such as "0 3 * * *", or an
+	// EventBridge cron(...), rate(...) or at(...) expression
+	Schedule string `yaml:"schedule" validate:"required"`
+
+	// Timezone is the IANA time zone of the schedule (default UTC)
+	Timezone string `yaml:"timezone,omitempty"`
+
+	// ConcurrencyPolicy says what to do when a run is due while the
+	// previous one is still running. EventBridge Scheduler starts scheduled
+	// runs regardless of the previous one, so only Allow is accepted.
+	ConcurrencyPolicy string `yaml:"concurrencyPolicy,omitempty" validate:"omitempty,oneof=Allow Forbid Replace"`
+
+	// RetryCount is how often a run that could not be started is retried
+	RetryCount int `yaml:"retryCount,omitempty" validate:"omitempty,min=0,max=185"`
+
+	// Timeout is the number of seconds after which a run is stopped.
+	// Scheduled runs are not stopped, so it is rejected.
+	Timeout int `yaml:"timeout,omitempty" validate:"omitempty,min=1"`
+}
+
+// Validate validates the cron job
+func (c *CronJob) Validate() error {
+	// TODO: Implement comprehensive validation
+	return nil
+}
+
+// TaskResources returns the CPU units and memory in MB of each task, taken
+// from the attached ComponentInfra or the smallest Fargate task size
+func (c *CronJob) TaskResources() (cpu, memory int) {
+	cpu, memory = 256, 512
+	if c.Infra != nil {
+		if c.Infra.Spec.Resources.CPU > 0 {
+			cpu = c.Infra.Spec.Resources.CPU
+		}
+		if c.Infra.Spec.Resources.Memory > 0 {
+			memory = c.Infra.Spec.Resources.Memory
+		}
+	}
+	return cpu, memory
+}
+
+// Concurrency returns the concurrency policy, defaulting to Allow
+func (c *CronJob) Concurrency() string {
+	if c.Spec.ConcurrencyPolicy == "" {
+		return ConcurrencyAllow
+	}
+	return c.Spec.ConcurrencyPolicy
+}
+
+// ScheduleExpression converts a schedule into an EventBridge schedule
+// expression. cron(...), rate(...) and at(...) expressions are kept as they
+// are. A five-field cron expression gets the year field, a "?" for the day
+// of month or week that is not restricted, and days of week numbered from
+// 1 (Sunday) to 7.
+func ScheduleExpression(schedule string) (string, error) {
+	schedule = strings.TrimSpace(schedule)
+	for _, prefix := range []string{"cron(", "rate(", "at("} {
+		if strings.HasPrefix(schedule, prefix) {
+			if !strings.HasSuffix(schedule, ")") {
+				return "", fmt.Errorf("invalid schedule %q: missing closing parenthesis", schedule)
+			}
+			return schedule, nil
+		}
+	}
+
+	fields := strings.Fields(schedule)
+	if len(fields) != 5 {
+		return "", fmt.Errorf("invalid schedule %q: expected 5 cron fields, or a cron(), rate() or at() expression", schedule)
+	}
+	minute, hour, dayOfMonth, month, dayOfWeek := fields[0], fields[1], fields[2], fields[3], fields[4]
+
+	if dayOfWeek != "*" && dayOfWeek != "?" {
+		converted, err := cronDaysOfWeek(dayOfWeek)
+		if err != nil {
+			return "", fmt.Errorf("invalid schedule %q: %w", schedule, err)
+		}
+		dayOfWeek = converted
+		if dayOfMonth != "*" && dayOfMonth != "?" {
+			return "", fmt.Errorf("invalid schedule %q: day of month and day of week cannot both be set", schedule)
+		}
+		dayOfMonth = "?"
+	} else {
+		dayOfWeek = "?"
+	}
+
+	return fmt.Sprintf("cron(%s %s %s %s %s *)", minute, hour, dayOfMonth, month, dayOfWeek), nil
+}
+
+// cronDaysOfWeek renumbers the days of a cron day-of-week field from 0-7
+// (Sunday is 0 and 7) to EventBridge's 1-7 (Sunday is 1). Names such as MON
+// are kept.
+func cronDaysOfWeek(field string) (string, error) {
+	var b strings.Builder
+	start := -1
+	for i := 0; i <= len(field); i++ {
+		if i < len(field) && field[i] >= '0' && field[i] <= '9' {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start >= 0 {
+			// The step of a range such as */2 is a count, not a day
+			if start > 0 && field[start-1] == '/' {
+				b.WriteString(field[start:i])
+			} else {
+				day, _ := strconv.Atoi(field[start:i])
+				if day > 7 {
+					return "", fmt.Errorf("day of week %d out of range", day)
+				}
+				b.WriteString(strconv.Itoa(day%7 + 1))
+			}
+			start = -1
+		}
+		if i < len(field) {
+			b.WriteByte(field[i])
+		}
+	}
+	return b.String(), nil
+}
+
+// NewCronJob creates a new cron job with defaults
+func NewCronJob(name, service, stack string) *CronJob {
+	return &CronJob{
+		ResourceBase: ResourceBase{
+			APIVersion: ComponentsAPIVersion,
+			Kind:       KindCronJob,
+			Metadata: Metadata{
+				Name:    name,
+				Service: service,
+				Stack:   stack,
+				Labels:  make(map[string]string),
+			},
+		},
+		Spec: CronJobSpec{
+			Runtime: RuntimeConfig{
+				Platform: "fargate",
+			},
+		},
+	}
+}
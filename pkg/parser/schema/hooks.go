@@ -0,0 +1,61 @@
+package schema
+
+// HookPoint names the moment of a deployment at which hooks run
+type HookPoint string
+
+const (
+	HookPrePlan    HookPoint = "prePlan"
+	HookPreApply   HookPoint = "preApply"
+	HookPostApply  HookPoint = "postApply"
+	HookOnFailure  HookPoint = "onFailure"
+	HookPreDestroy HookPoint = "preDestroy"
+)
+
+// Hooks lists the local commands run around the deployments of a stack or
+// a service
+type Hooks struct {
+	PrePlan    []Hook `yaml:"prePlan,omitempty"`
+	PreApply   []Hook `yaml:"preApply,omitempty"`
+	PostApply  []Hook `yaml:"postApply,omitempty"`
+	OnFailure  []Hook `yaml:"onFailure,omitempty"`
+	PreDestroy []Hook `yaml:"preDestroy,omitempty"`
+}
+
+// Hook is a command run with the shell of the machine running panka
+type Hook struct {
+	Name    string `yaml:"name,omitempty"`
+	Command string `yaml:"command" validate:"required"`
+
+	// WorkingDir is relative to the stack folder
+	WorkingDir string `yaml:"workingDir,omitempty"`
+
+	// Timeout is a duration such as 5m. There is no timeout by default.
+	Timeout string `yaml:"timeout,omitempty"`
+
+	Env map[string]string `yaml:"env,omitempty"`
+}
+
+// For returns the hooks run at a point. A nil Hooks has none.
+func (h *Hooks) For(point HookPoint) []Hook {
+	if h == nil {
+		return nil
+	}
+	switch point {
+	case HookPrePlan:
+		return h.PrePlan
+	case HookPreApply:
+		return h.PreApply
+	case HookPostApply:
+		return h.PostApply
+	case HookOnFailure:
+		return h.OnFailure
+	case HookPreDestroy:
+		return h.PreDestroy
+	}
+	return nil
+}
+
+// HookPoints returns all hook points in the order of a deployment
+func HookPoints() []HookPoint {
+	return []HookPoint{HookPrePlan, HookPreApply, HookPostApply, HookOnFailure, HookPreDestroy}
+}
@@ -36,6 +36,12 @@ type LoadBalancerConfig struct {
 	CertificateARN   string   `yaml:"certificateArn,omitempty"`
 	SSLPolicy        string   `yaml:"sslPolicy,omitempty"`
 	AllowedCIDRs     []string `yaml:"allowedCIDRs,omitempty"`
+
+	// Shared puts the component behind the load balancer shared by the
+	// tenant's services instead of its own. It routes by the ingress
+	// hostname and path. A shared load balancer is open to every client,
+	// so it cannot be combined with AllowedCIDRs.
+	Shared bool `yaml:"shared,omitempty"`
 }
 
 // IngressConfig defines ingress configuration
@@ -73,6 +79,14 @@ func (c *ComponentInfra) Validate() error {
 	return nil
 }
 
+// AutoScalingConfig returns the enabled autoscaling of a component, or nil
+func (c *ComponentInfra) AutoScalingConfig() *AutoScaling {
+	if c == nil || c.Spec.Scaling.AutoScaling == nil || !c.Spec.Scaling.AutoScaling.Enabled {
+		return nil
+	}
+	return c.Spec.Scaling.AutoScaling
+}
+
 // NewComponentInfra creates new component infrastructure with defaults
 func NewComponentInfra(name, service, stack string) *ComponentInfra {
 	return &ComponentInfra{
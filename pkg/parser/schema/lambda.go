@@ -1,5 +1,10 @@
 package schema
 
+import (
+	"fmt"
+	"strconv"
+)
+
 // Lambda represents an AWS Lambda function
 type Lambda struct {
 	ResourceBase `yaml:",inline"`
@@ -22,6 +27,10 @@ type LambdaSpec struct {
 	// Environment variables
 	Environment map[string]interface{} `yaml:"environment,omitempty"`
 
+	// Secrets are passed by reference: their environment variable holds the
+	// ARN of the secret, which the function reads at runtime
+	Secrets []Secret `yaml:"secrets,omitempty" validate:"dive"`
+
 	// Triggers (SQS, EventBridge, API Gateway, etc.)
 	Triggers []LambdaTrigger `yaml:"triggers,omitempty"`
 
@@ -31,12 +40,19 @@ type LambdaSpec struct {
 	// Concurrency
 	ReservedConcurrentExecutions int `yaml:"reservedConcurrentExecutions,omitempty"`
 
+	// ProvisionedConcurrency keeps instances of the function initialized
+	ProvisionedConcurrency *ProvisionedConcurrency `yaml:"provisionedConcurrency,omitempty"`
+
 	// IAM
 	RoleArn string `yaml:"roleArn,omitempty"` // Custom IAM role (auto-generated if empty)
 
 	// Dependencies
 	DependsOn []string `yaml:"dependsOn,omitempty"`
 
+	// Access grants the IAM role of the workload access to components it
+	// depends on
+	Access []ComponentAccess `yaml:"access,omitempty" validate:"dive"`
+
 	// Layers
 	Layers []string `yaml:"layers,omitempty"`
 
@@ -44,6 +60,50 @@ type LambdaSpec struct {
 	Tags map[string]string `yaml:"tags,omitempty"`
 }
 
+// MemoryMB returns the memory of the function in MB (default 128)
+func (l *Lambda) MemoryMB() int {
+	if memory, err := strconv.Atoi(l.Spec.Memory); err == nil {
+		return memory
+	}
+	return 128
+}
+
+// TimeoutSeconds returns the timeout of the function in seconds (default 30)
+func (l *Lambda) TimeoutSeconds() int {
+	if timeout, err := strconv.Atoi(l.Spec.Timeout); err == nil {
+		return timeout
+	}
+	return 30
+}
+
+// ProvisionedConcurrency keeps initialized instances of the live alias of a
+// function, scaled between MinCapacity and MaxCapacity to keep their
+// utilization at TargetUtilization percent. The alias points to the version
+// published by each apply; callers invoke it to use the instances.
+type ProvisionedConcurrency struct {
+	MinCapacity int `yaml:"minCapacity" validate:"required,min=1"`
+	MaxCapacity int `yaml:"maxCapacity" validate:"required,min=1"`
+
+	// TargetUtilization is the percentage of instances in use to keep,
+	// between 10 and 90 (default 70)
+	TargetUtilization int `yaml:"targetUtilization,omitempty" validate:"omitempty,min=10,max=90"`
+
+	// Cooldowns in seconds after scaling in and out
+	ScaleInCooldown  int `yaml:"scaleInCooldown,omitempty" validate:"omitempty,min=0"`
+	ScaleOutCooldown int `yaml:"scaleOutCooldown,omitempty" validate:"omitempty,min=0"`
+
+	// Schedules change the bounds at set times
+	Schedules []ScheduledScaling `yaml:"schedules,omitempty" validate:"dive"`
+}
+
+// TargetPercent returns the utilization to keep in percent
+func (p *ProvisionedConcurrency) TargetPercent() int {
+	if p.TargetUtilization > 0 {
+		return p.TargetUtilization
+	}
+	return 70
+}
+
 // LambdaCode defines the Lambda code location
 type LambdaCode struct {
 	// S3 deployment
@@ -76,6 +136,36 @@ type LambdaTrigger struct {
 	S3 *S3Trigger `yaml:"s3,omitempty"`
 }
 
+// Lambda trigger types
+const (
+	TriggerSQS         = "sqs"
+	TriggerDynamoDB    = "dynamodb"
+	TriggerKinesis     = "kinesis"
+	TriggerSNS         = "sns"
+	TriggerS3          = "s3"
+	TriggerEventBridge = "eventbridge"
+	TriggerAPIGateway  = "apigateway"
+)
+
+// PollsSource reports whether Lambda polls the source of a trigger through
+// an event source mapping, rather than the source pushing events
+func (t *LambdaTrigger) PollsSource() bool {
+	return t.Type == TriggerSQS || t.Type == TriggerDynamoDB || t.Type == TriggerKinesis
+}
+
+// BatchSizeCount returns the batch size of a trigger, or 0 when the default
+// of the source applies
+func (t *LambdaTrigger) BatchSizeCount() (int, error) {
+	if t.BatchSize == "" {
+		return 0, nil
+	}
+	size, err := strconv.Atoi(t.BatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("invalid batchSize %q", t.BatchSize)
+	}
+	return size, nil
+}
+
 // TriggerSource defines the source component for a trigger
 type TriggerSource struct {
 	Component string `yaml:"component,omitempty"` // Reference to another component
@@ -1,9 +1,19 @@
 package schema
 
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
 // MicroService represents a containerized microservice component
 type MicroService struct {
 	ResourceBase `yaml:",inline"`
 	Spec         MicroServiceSpec `yaml:"spec" validate:"required"`
+
+	// Infra holds the ComponentInfra of the same name, attached by the
+	// folder parser
+	Infra *ComponentInfra `yaml:"infra,omitempty"`
 }
 
 // MicroServiceSpec defines the microservice specification
@@ -29,6 +39,10 @@ type MicroServiceSpec struct {
 	
 	// Dependencies
 	DependsOn []string `yaml:"dependsOn,omitempty"`
+
+	// Access grants the IAM role of the workload access to components it
+	// depends on
+	Access []ComponentAccess `yaml:"access,omitempty" validate:"dive"`
 	
 	// Command override
 	Command []string `yaml:"command,omitempty"`
@@ -57,10 +71,24 @@ type RuntimeConfig struct {
 	Timeout int    `yaml:"timeout,omitempty" validate:"omitempty,min=1,max=900"`
 }
 
+// ConfigFileMaxSize is the size in bytes of the largest config file that
+// can be mounted, the limit of an advanced SSM parameter
+const ConfigFileMaxSize = 8192
+
 // ConfigsMount defines configuration file mounting
 type ConfigsMount struct {
 	MountPath string   `yaml:"mountPath" validate:"required"`
 	Files     []string `yaml:"files" validate:"required,min=1"`
+
+	// Contents holds the content of each file, attached by the folder
+	// parser from the config/ folder of the service
+	Contents map[string]string `yaml:"contents,omitempty"`
+}
+
+// FileHash returns the SHA-256 of the content of a file, in hex
+func (c *ConfigsMount) FileHash(name string) string {
+	sum := sha256.Sum256([]byte(c.Contents[name]))
+	return hex.EncodeToString(sum[:])
 }
 
 // Validate validates the microservice
@@ -69,6 +97,81 @@ func (m *MicroService) Validate() error {
 	return nil
 }
 
+// TaskResources returns the CPU units and memory in MB of each task, taken
+// from the attached ComponentInfra or the smallest Fargate task size
+func (m *MicroService) TaskResources() (cpu, memory int) {
+	cpu, memory = 256, 512
+	if m.Infra != nil {
+		if m.Infra.Spec.Resources.CPU > 0 {
+			cpu = m.Infra.Spec.Resources.CPU
+		}
+		if m.Infra.Spec.Resources.Memory > 0 {
+			memory = m.Infra.Spec.Resources.Memory
+		}
+	}
+	return cpu, memory
+}
+
+// DesiredCount returns the number of tasks to run, taken from the attached
+// ComponentInfra or 1 and kept within the autoscaling bounds
+func (m *MicroService) DesiredCount() int {
+	if m.Infra == nil {
+		return 1
+	}
+	count := m.Infra.Spec.Scaling.Replicas
+	if autoScaling := m.Infra.AutoScalingConfig(); autoScaling != nil {
+		count = max(autoScaling.MinReplicas, min(count, autoScaling.MaxReplicas))
+	}
+	return count
+}
+
+// LoadBalancer returns the load balancer of the attached ComponentInfra, or
+// nil when the microservice has none
+func (m *MicroService) LoadBalancer() *LoadBalancerConfig {
+	if m.Infra == nil {
+		return nil
+	}
+	lb := m.Infra.Spec.Networking.LoadBalancer
+	if lb == nil || !lb.Enabled {
+		return nil
+	}
+	return lb
+}
+
+// Ingress returns the ingress of the attached ComponentInfra, or nil when
+// the microservice has none
+func (m *MicroService) Ingress() *IngressConfig {
+	if m.Infra == nil {
+		return nil
+	}
+	ingress := m.Infra.Spec.Networking.Ingress
+	if ingress == nil || !ingress.Enabled {
+		return nil
+	}
+	return ingress
+}
+
+// TrafficPort returns the port a load balancer forwards to: the port named
+// http, or else the first TCP port
+func (m *MicroService) TrafficPort() (Port, bool) {
+	var first *Port
+	for i, port := range m.Spec.Ports {
+		if strings.EqualFold(port.Protocol, "udp") {
+			continue
+		}
+		if port.Name == "http" {
+			return port, true
+		}
+		if first == nil {
+			first = &m.Spec.Ports[i]
+		}
+	}
+	if first == nil {
+		return Port{}, false
+	}
+	return *first, true
+}
+
 // NewMicroService creates a new microservice with defaults
 func NewMicroService(name, service, stack string) *MicroService {
 	return &MicroService{
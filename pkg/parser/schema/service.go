@@ -11,6 +11,12 @@ type ServiceSpec struct {
 	Infrastructure InfrastructureConfig `yaml:"infrastructure,omitempty"`
 	Variables      map[string]string    `yaml:"variables,omitempty"`
 	DependsOn      []string             `yaml:"dependsOn,omitempty"`
+	
+	// Outputs are stored in the state as <service>.<name>, see StackSpec
+	Outputs map[string]string `yaml:"outputs,omitempty"`
+
+	// Hooks run after the stack hooks, see StackSpec
+	Hooks *Hooks `yaml:"hooks,omitempty"`
 }
 
 // Validate validates the service
@@ -11,6 +11,14 @@ type StackSpec struct {
 	Provider       ProviderConfig       `yaml:"provider" validate:"required"`
 	Infrastructure InfrastructureConfig `yaml:"infrastructure,omitempty"`
 	Variables      map[string]string    `yaml:"variables,omitempty"`
+	
+	// Outputs maps output names to values that reference component
+	// outputs, such as ${jobs.queue_url}. They are stored in the state
+	// after apply.
+	Outputs map[string]string `yaml:"outputs,omitempty"`
+
+	// Hooks are local commands run around plans, applies and destroys
+	Hooks *Hooks `yaml:"hooks,omitempty"`
 }
 
 // ProviderConfig defines the cloud provider configuration
@@ -0,0 +1,143 @@
+package schema
+
+// Worker represents a containerized background worker, such as a queue
+// consumer. Unlike a MicroService it exposes no ports and has no load
+// balancer.
+type Worker struct {
+	ResourceBase `yaml:",inline"`
+	Spec         WorkerSpec `yaml:"spec" validate:"required"`
+
+	// Infra holds the ComponentInfra of the same name, attached by the
+	// folder parser
+	Infra *ComponentInfra `yaml:"infra,omitempty"`
+}
+
+// WorkerSpec defines the worker specification
+type WorkerSpec struct {
+	// Container image configuration
+	Image ImageConfig `yaml:"image" validate:"required"`
+
+	// Runtime configuration
+	Runtime RuntimeConfig `yaml:"runtime,omitempty"`
+
+	// Environment and secrets
+	Environment []EnvironmentVariable `yaml:"environment,omitempty" validate:"dive"`
+	Secrets     []Secret              `yaml:"secrets,omitempty" validate:"dive"`
+
+	// Health checks
+	HealthCheck *HealthCheck `yaml:"healthCheck,omitempty"`
+
+	// Dependencies
+	DependsOn []string `yaml:"dependsOn,omitempty"`
+
+	// Access grants the IAM role of the workload access to components it
+	// depends on
+	Access []ComponentAccess `yaml:"access,omitempty" validate:"dive"`
+
+	// Command override
+	Command []string `yaml:"command,omitempty"`
+	Args    []string `yaml:"args,omitempty"`
+
+	// StopTimeout is the number of seconds a task has to finish its work
+	// after SIGTERM before it is killed (default 30, at most 120 on Fargate)
+	StopTimeout int `yaml:"stopTimeout,omitempty" validate:"omitempty,min=1,max=120"`
+
+	// Scaling scales the worker on the backlog of an SQS queue, in place of
+	// the CPU and memory autoscaling of its ComponentInfra
+	Scaling *QueueScaling `yaml:"scaling,omitempty"`
+}
+
+// QueueScaling scales a worker so that each task has about MessagesPerTask
+// messages of the queue's backlog to process
+type QueueScaling struct {
+	// Queue is the name of the SQS component the worker consumes
+	Queue string `yaml:"queue" validate:"required"`
+
+	// QueueName is the name of the queue in AWS. The folder parser sets it
+	// to the queue's ${<queue>.queue_name} output, resolved at apply.
+	QueueName string `yaml:"queueName,omitempty"`
+
+	MinReplicas int `yaml:"minReplicas" validate:"min=0"`
+	MaxReplicas int `yaml:"maxReplicas" validate:"required,min=1"`
+
+	// MessagesPerTask is the target backlog per task (default 100)
+	MessagesPerTask int `yaml:"messagesPerTask,omitempty" validate:"omitempty,min=1"`
+
+	// Cooldowns in seconds after scaling in and out
+	ScaleInCooldown  int `yaml:"scaleInCooldown,omitempty" validate:"omitempty,min=0"`
+	ScaleOutCooldown int `yaml:"scaleOutCooldown,omitempty" validate:"omitempty,min=0"`
+
+	// Schedules change the replica bounds at set times
+	Schedules []ScheduledScaling `yaml:"schedules,omitempty" validate:"dive"`
+}
+
+// Validate validates the worker
+func (w *Worker) Validate() error {
+	// TODO: Implement comprehensive validation
+	return nil
+}
+
+// TaskResources returns the CPU units and memory in MB of each task, taken
+// from the attached ComponentInfra or the smallest Fargate task size
+func (w *Worker) TaskResources() (cpu, memory int) {
+	cpu, memory = 256, 512
+	if w.Infra != nil {
+		if w.Infra.Spec.Resources.CPU > 0 {
+			cpu = w.Infra.Spec.Resources.CPU
+		}
+		if w.Infra.Spec.Resources.Memory > 0 {
+			memory = w.Infra.Spec.Resources.Memory
+		}
+	}
+	return cpu, memory
+}
+
+// DesiredCount returns the number of tasks to start with, taken from the
+// attached ComponentInfra or 1 and kept within the scaling bounds
+func (w *Worker) DesiredCount() int {
+	count := 1
+	if w.Infra != nil {
+		count = w.Infra.Spec.Scaling.Replicas
+	}
+	if autoScaling := w.Infra.AutoScalingConfig(); autoScaling != nil {
+		count = max(autoScaling.MinReplicas, min(count, autoScaling.MaxReplicas))
+	}
+	if w.Spec.Scaling != nil {
+		if count < w.Spec.Scaling.MinReplicas {
+			count = w.Spec.Scaling.MinReplicas
+		}
+		if count > w.Spec.Scaling.MaxReplicas {
+			count = w.Spec.Scaling.MaxReplicas
+		}
+	}
+	return count
+}
+
+// TargetMessagesPerTask returns the target backlog per task
+func (s *QueueScaling) TargetMessagesPerTask() int {
+	if s.MessagesPerTask > 0 {
+		return s.MessagesPerTask
+	}
+	return 100
+}
+
+// NewWorker creates a new worker with defaults
+func NewWorker(name, service, stack string) *Worker {
+	return &Worker{
+		ResourceBase: ResourceBase{
+			APIVersion: ComponentsAPIVersion,
+			Kind:       KindWorker,
+			Metadata: Metadata{
+				Name:    name,
+				Service: service,
+				Stack:   stack,
+				Labels:  make(map[string]string),
+			},
+		},
+		Spec: WorkerSpec{
+			Runtime: RuntimeConfig{
+				Platform: "fargate",
+			},
+		},
+	}
+}
@@ -2,12 +2,24 @@ package parser
 
 import (
 	"fmt"
+	"net"
 	"regexp"
 	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/yourusername/panka/pkg/parser/schema"
+	"github.com/yourusername/panka/pkg/secrets"
 )
 
+// outputNamePattern matches declared output names; service outputs are
+// prefixed with the service name and a dot
+var outputNamePattern = regexp.MustCompile(`^([a-z][a-z0-9-]*\.)?[A-Za-z][A-Za-z0-9_-]*$`)
+
+// configFilePattern matches the names of mounted config files, which become
+// part of SSM parameter names
+var configFilePattern = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
 // Validator provides comprehensive validation for parsed resources
 type Validator struct {
 	errors []error
@@ -64,6 +76,11 @@ func (v *Validator) Validate(result *ParseResult) error {
 		v.addError(err)
 	}
 	
+	// Validate declared outputs
+	for _, err := range v.validateOutputs(result) {
+		v.addError(err)
+	}
+	
 	if len(v.errors) > 0 {
 		return v.formatErrors()
 	}
@@ -92,7 +109,7 @@ func (v *Validator) validateStack(stack *schema.Stack) error {
 		return fmt.Errorf("stack provider region is required")
 	}
 	
-	return nil
+	return v.validateHooks("stack "+stack.Metadata.Name, stack.Spec.Hooks)
 }
 
 // validateService validates service configuration
@@ -112,9 +129,49 @@ func (v *Validator) validateService(service *schema.Service, stack *schema.Stack
 			service.Metadata.Name, service.Metadata.Stack)
 	}
 	
+	return v.validateHooks("service "+service.Metadata.Name, service.Spec.Hooks)
+}
+
+// validateHooks validates the hooks of a stack or service
+func (v *Validator) validateHooks(owner string, hooks *schema.Hooks) error {
+	for _, point := range schema.HookPoints() {
+		for i, hook := range hooks.For(point) {
+			if strings.TrimSpace(hook.Command) == "" {
+				return fmt.Errorf("%s: hooks.%s[%d] has no command", owner, point, i)
+			}
+			if hook.Timeout != "" {
+				if d, err := time.ParseDuration(hook.Timeout); err != nil || d <= 0 {
+					return fmt.Errorf("%s: hooks.%s[%d] has an invalid timeout %q", owner, point, i, hook.Timeout)
+				}
+			}
+		}
+	}
 	return nil
 }
 
+// validateOutputs validates the outputs declared by the stack and services
+func (v *Validator) validateOutputs(result *ParseResult) []error {
+	var errs []error
+	
+	components := make(map[string]bool, len(result.Components))
+	for _, comp := range result.Components {
+		components[comp.GetMetadata().Name] = true
+	}
+	
+	for name, value := range StackOutputs(result.Stack, result.Services) {
+		if !outputNamePattern.MatchString(name) {
+			errs = append(errs, fmt.Errorf("output name %s is invalid", name))
+		}
+		for _, ref := range ValueOutputReferences(value) {
+			if !components[ref.Component] {
+				errs = append(errs, fmt.Errorf("output %s references unknown component: %s", name, ref.Component))
+			}
+		}
+	}
+	
+	return errs
+}
+
 // validateComponent validates component configuration
 func (v *Validator) validateComponent(comp schema.Resource, result *ParseResult) error {
 	metadata := comp.GetMetadata()
@@ -138,21 +195,121 @@ func (v *Validator) validateComponent(comp schema.Resource, result *ParseResult)
 			metadata.Name, metadata.Service)
 	}
 	
+	if err := v.validateLifecycle(metadata); err != nil {
+		return err
+	}
+
+	if err := v.validateAccess(comp, result); err != nil {
+		return err
+	}
+
+	if err := v.validateSecrets(comp); err != nil {
+		return err
+	}
+	
 	// Type-specific validation
 	switch c := comp.(type) {
 	case *schema.MicroService:
 		return v.validateMicroService(c)
+	case *schema.Worker:
+		return v.validateWorker(c, result)
+	case *schema.CronJob:
+		return v.validateCronJob(c)
 	case *schema.RDS:
 		return v.validateRDS(c)
+	case *schema.ElastiCacheRedis:
+		return v.validateElastiCacheRedis(c)
+	case *schema.ElastiCacheMemcached:
+		return v.validateElastiCacheMemcached(c)
 	case *schema.DynamoDB:
 		return v.validateDynamoDB(c)
 	case *schema.S3:
 		return v.validateS3(c)
+	case *schema.Lambda:
+		return v.validateLambda(c, result)
 	}
 	
 	return nil
 }
 
+// validateLifecycle validates the lifecycle block of a component
+func (v *Validator) validateLifecycle(metadata *schema.Metadata) error {
+	if metadata.Lifecycle == nil {
+		return nil
+	}
+	for _, path := range metadata.Lifecycle.IgnoreChanges {
+		if !strings.HasPrefix(path, "spec.") || strings.HasSuffix(path, ".") {
+			return fmt.Errorf("component %s: lifecycle.ignoreChanges path %q must name an attribute under spec, such as spec.readCapacity", metadata.Name, path)
+		}
+	}
+	return nil
+}
+
+// accessKinds are the component kinds workloads can be granted access to
+var accessKinds = map[schema.Kind]bool{
+	schema.KindS3:       true,
+	schema.KindDynamoDB: true,
+	schema.KindSQS:      true,
+	schema.KindSNS:      true,
+}
+
+// validateAccess validates the access grants of a workload. Granted
+// components must be dependencies, so that they exist before the workload.
+func (v *Validator) validateAccess(comp schema.Resource, result *ParseResult) error {
+	name := comp.GetMetadata().Name
+
+	dependsOn := make(map[string]bool)
+	for _, dep := range extractDependenciesFromResource(comp) {
+		dependsOn[dep] = true
+	}
+
+	for _, grant := range schema.AccessGrants(comp) {
+		switch grant.Mode {
+		case schema.AccessRead, schema.AccessWrite, schema.AccessReadWrite:
+		default:
+			return fmt.Errorf("component %s: access mode for %s must be read, write or readwrite", name, grant.Component)
+		}
+		if !dependsOn[grant.Component] {
+			return fmt.Errorf("component %s: access to %s requires it in dependsOn", name, grant.Component)
+		}
+
+		var kind schema.Kind
+		for _, other := range result.Components {
+			if other.GetMetadata().Name == grant.Component {
+				kind = other.GetKind()
+			}
+		}
+		if kind == "" {
+			return fmt.Errorf("component %s: access references unknown component: %s", name, grant.Component)
+		}
+		if !accessKinds[kind] {
+			return fmt.Errorf("component %s: access to %s is not supported for %s components", name, grant.Component, kind)
+		}
+		if kind == schema.KindSNS && grant.Reads() {
+			return fmt.Errorf("component %s: SNS topic %s only supports write access", name, grant.Component)
+		}
+	}
+	return nil
+}
+
+// validateSecrets validates the secret references of a workload. Each
+// secret must have a valid reference and its own environment variable.
+func (v *Validator) validateSecrets(comp schema.Resource) error {
+	name := comp.GetMetadata().Name
+
+	envNames := make(map[string]bool)
+	for _, secret := range schema.WorkloadSecrets(comp) {
+		if _, err := secrets.ParseRef(secret.SecretRef); err != nil {
+			return fmt.Errorf("component %s: secret %s: %w", name, secret.Name, err)
+		}
+		if envNames[secret.EnvName()] {
+			return fmt.Errorf("component %s: secret environment variable %s is set more than once", name, secret.EnvName())
+		}
+		envNames[secret.EnvName()] = true
+	}
+	return nil
+}
+
 // validateMicroService validates microservice-specific configuration
 func (v *Validator) validateMicroService(ms *schema.MicroService) error {
 	// Validate image
@@ -182,7 +339,383 @@ func (v *Validator) validateMicroService(ms *schema.MicroService) error {
 		}
 		portNames[port.Name] = true
 	}
+
+	if err := v.validateConfigs(ms); err != nil {
+		return err
+	}
+
+	if err := validateAutoScaling("microservice "+ms.Metadata.Name, ms.Infra.AutoScalingConfig()); err != nil {
+		return err
+	}
 	
+	return v.validateLoadBalancer(ms)
+}
+
+// validateConfigs validates the config files mounted by a microservice.
+// Files are stored as SSM parameters, so they must be non-empty UTF-8 text
+// within the parameter size limit.
+func (v *Validator) validateConfigs(ms *schema.MicroService) error {
+	configs := ms.Spec.Configs
+	if configs == nil {
+		return nil
+	}
+
+	if !strings.HasPrefix(configs.MountPath, "/") {
+		return fmt.Errorf("microservice %s: configs mountPath must be an absolute path", ms.Metadata.Name)
+	}
+
+	seen := make(map[string]bool)
+	for _, file := range configs.Files {
+		if !configFilePattern.MatchString(file) {
+			return fmt.Errorf("microservice %s: config file name %q may only contain letters, digits, '.', '_' and '-'", ms.Metadata.Name, file)
+		}
+		if seen[file] {
+			return fmt.Errorf("microservice %s: config file %s is mounted more than once", ms.Metadata.Name, file)
+		}
+		seen[file] = true
+
+		content, ok := configs.Contents[file]
+		if !ok {
+			continue
+		}
+		switch {
+		case content == "":
+			return fmt.Errorf("microservice %s: config file %s is empty", ms.Metadata.Name, file)
+		case len(content) > schema.ConfigFileMaxSize:
+			return fmt.Errorf("microservice %s: config file %s is larger than %d bytes", ms.Metadata.Name, file, schema.ConfigFileMaxSize)
+		case !utf8.ValidString(content):
+			return fmt.Errorf("microservice %s: config file %s is not UTF-8 text", ms.Metadata.Name, file)
+		}
+	}
+	return nil
+}
+
+// validateLoadBalancer validates the load balancer and ingress of a
+// microservice
+func (v *Validator) validateLoadBalancer(ms *schema.MicroService) error {
+	lb := ms.LoadBalancer()
+	if lb == nil {
+		return nil
+	}
+
+	if lb.Type != "" && lb.Type != "application" {
+		return fmt.Errorf("microservice %s: only application load balancers are supported", ms.Metadata.Name)
+	}
+	if _, ok := ms.TrafficPort(); !ok {
+		return fmt.Errorf("microservice %s: a load balancer requires a TCP port", ms.Metadata.Name)
+	}
+	if lb.HealthCheckPath != "" && !strings.HasPrefix(lb.HealthCheckPath, "/") {
+		return fmt.Errorf("microservice %s: loadBalancer.healthCheckPath must start with /", ms.Metadata.Name)
+	}
+	if lb.CertificateARN != "" && !strings.HasPrefix(lb.CertificateARN, "arn:") {
+		return fmt.Errorf("microservice %s: loadBalancer.certificateArn must be an ACM certificate ARN", ms.Metadata.Name)
+	}
+	if lb.SSLPolicy != "" && lb.CertificateARN == "" {
+		return fmt.Errorf("microservice %s: loadBalancer.sslPolicy requires a certificateArn", ms.Metadata.Name)
+	}
+	for _, cidr := range lb.AllowedCIDRs {
+		if _, _, err := net.ParseCIDR(cidr); err != nil {
+			return fmt.Errorf("microservice %s: invalid loadBalancer.allowedCIDRs entry %q", ms.Metadata.Name, cidr)
+		}
+	}
+
+	ingress := ms.Ingress()
+	if ingress != nil && ingress.Path != "" && !strings.HasPrefix(ingress.Path, "/") {
+		return fmt.Errorf("microservice %s: ingress.path must start with /", ms.Metadata.Name)
+	}
+	// A shared load balancer routes to its services by host and path
+	if lb.Shared && (ingress == nil || (ingress.Hostname == "" && ingress.Path == "")) {
+		return fmt.Errorf("microservice %s: a shared load balancer requires an ingress hostname or path", ms.Metadata.Name)
+	}
+	// The security group of a shared load balancer is shared too, so it
+	// cannot restrict the clients of a single service
+	if lb.Shared && len(lb.AllowedCIDRs) > 0 {
+		return fmt.Errorf("microservice %s: loadBalancer.allowedCIDRs cannot be used with a shared load balancer", ms.Metadata.Name)
+	}
+
+	return nil
+}
+
+// validateWorker validates worker-specific configuration
+func (v *Validator) validateWorker(w *schema.Worker, result *ParseResult) error {
+	if w.Spec.Image.Repository == "" {
+		return fmt.Errorf("worker %s: image repository is required", w.Metadata.Name)
+	}
+	if w.Spec.Image.Tag == "" {
+		return fmt.Errorf("worker %s: image tag is required", w.Metadata.Name)
+	}
+
+	if w.Spec.StopTimeout < 0 || w.Spec.StopTimeout > 120 {
+		return fmt.Errorf("worker %s: stopTimeout must be at most 120 seconds", w.Metadata.Name)
+	}
+
+	owner := "worker " + w.Metadata.Name
+	if err := validateAutoScaling(owner, w.Infra.AutoScalingConfig()); err != nil {
+		return err
+	}
+
+	scaling := w.Spec.Scaling
+	if scaling == nil {
+		return nil
+	}
+	if w.Infra.AutoScalingConfig() != nil {
+		return fmt.Errorf("worker %s: scaling on a queue cannot be combined with infra autoscaling", w.Metadata.Name)
+	}
+	if scaling.MinReplicas < 0 || scaling.MaxReplicas < 1 || scaling.MinReplicas > scaling.MaxReplicas {
+		return fmt.Errorf("worker %s: scaling needs 0 <= minReplicas <= maxReplicas and maxReplicas >= 1", w.Metadata.Name)
+	}
+	if scaling.MessagesPerTask < 0 {
+		return fmt.Errorf("worker %s: scaling.messagesPerTask must be positive", w.Metadata.Name)
+	}
+	if err := validateScheduledScaling(owner, "scaling", scaling.Schedules); err != nil {
+		return err
+	}
+
+	for _, comp := range result.Components {
+		if comp.GetMetadata().Name != scaling.Queue {
+			continue
+		}
+		if comp.GetKind() != schema.KindSQS {
+			return fmt.Errorf("worker %s: scaling queue %s is a %s, not an SQS queue", w.Metadata.Name, scaling.Queue, comp.GetKind())
+		}
+		return nil
+	}
+	return fmt.Errorf("worker %s: scaling references unknown SQS component: %s", w.Metadata.Name, scaling.Queue)
+}
+
+// validateAutoScaling validates the enabled autoscaling of a component
+func validateAutoScaling(owner string, autoScaling *schema.AutoScaling) error {
+	if autoScaling == nil {
+		return nil
+	}
+	if autoScaling.MinReplicas < 0 || autoScaling.MaxReplicas < 1 || autoScaling.MinReplicas > autoScaling.MaxReplicas {
+		return fmt.Errorf("%s: autoscaling needs 0 <= minReplicas <= maxReplicas and maxReplicas >= 1", owner)
+	}
+	for _, target := range []int{autoScaling.TargetCPUPercent, autoScaling.TargetMemoryPercent} {
+		if target < 0 || target > 100 {
+			return fmt.Errorf("%s: autoscaling targets must be between 1 and 100 percent", owner)
+		}
+	}
+	if autoScaling.ScaleInCooldown < 0 || autoScaling.ScaleOutCooldown < 0 {
+		return fmt.Errorf("%s: autoscaling cooldowns must be positive", owner)
+	}
+	return validateScheduledScaling(owner, "autoscaling", autoScaling.Schedules)
+}
+
+// validateScheduledScaling validates the scheduled actions of a scaling
+// block. Their names must be unique, as they name the actions in AWS.
+func validateScheduledScaling(owner, field string, schedules []schema.ScheduledScaling) error {
+	names := make(map[string]bool)
+	for _, schedule := range schedules {
+		if !isValidName(schedule.Name) {
+			return fmt.Errorf("%s: %s schedule name %q is invalid", owner, field, schedule.Name)
+		}
+		if names[schedule.Name] {
+			return fmt.Errorf("%s: %s schedule %s is defined more than once", owner, field, schedule.Name)
+		}
+		names[schedule.Name] = true
+
+		if _, err := schema.ScheduleExpression(schedule.Schedule); err != nil {
+			return fmt.Errorf("%s: %s schedule %s: %w", owner, field, schedule.Name, err)
+		}
+		if schedule.Timezone != "" {
+			if _, err := time.LoadLocation(schedule.Timezone); err != nil {
+				return fmt.Errorf("%s: %s schedule %s: unknown timezone %s", owner, field, schedule.Name, schedule.Timezone)
+			}
+		}
+
+		if schedule.MinCapacity == nil && schedule.MaxCapacity == nil {
+			return fmt.Errorf("%s: %s schedule %s must set minCapacity or maxCapacity", owner, field, schedule.Name)
+		}
+		if (schedule.MinCapacity != nil && *schedule.MinCapacity < 0) || (schedule.MaxCapacity != nil && *schedule.MaxCapacity < 0) {
+			return fmt.Errorf("%s: %s schedule %s capacities must be positive", owner, field, schedule.Name)
+		}
+		if schedule.MinCapacity != nil && schedule.MaxCapacity != nil && *schedule.MinCapacity > *schedule.MaxCapacity {
+			return fmt.Errorf("%s: %s schedule %s has minCapacity above maxCapacity", owner, field, schedule.Name)
+		}
+	}
+	return nil
+}
+
+// validateCronJob validates cron job-specific configuration
+func (v *Validator) validateCronJob(c *schema.CronJob) error {
+	if c.Spec.Image.Repository == "" {
+		return fmt.Errorf("cronjob %s: image repository is required", c.Metadata.Name)
+	}
+	if c.Spec.Image.Tag == "" {
+		return fmt.Errorf("cronjob %s: image tag is required", c.Metadata.Name)
+	}
+
+	if c.Spec.Schedule == "" {
+		return fmt.Errorf("cronjob %s: schedule is required", c.Metadata.Name)
+	}
+	if _, err := schema.ScheduleExpression(c.Spec.Schedule); err != nil {
+		return fmt.Errorf("cronjob %s: %w", c.Metadata.Name, err)
+	}
+	if c.Spec.Timezone != "" {
+		if _, err := time.LoadLocation(c.Spec.Timezone); err != nil {
+			return fmt.Errorf("cronjob %s: unknown timezone %s", c.Metadata.Name, c.Spec.Timezone)
+		}
+	}
+
+	// EventBridge Scheduler starts scheduled runs regardless of the previous
+	// one and never stops them
+	switch c.Concurrency() {
+	case schema.ConcurrencyAllow:
+	case schema.ConcurrencyForbid, schema.ConcurrencyReplace:
+		return fmt.Errorf("cronjob %s: concurrencyPolicy %s cannot be enforced for scheduled runs, which may overlap; use panka job run --concurrency-policy for one-off runs", c.Metadata.Name, c.Concurrency())
+	default:
+		return fmt.Errorf("cronjob %s: concurrencyPolicy must be Allow, Forbid or Replace", c.Metadata.Name)
+	}
+
+	if c.Spec.RetryCount < 0 || c.Spec.RetryCount > 185 {
+		return fmt.Errorf("cronjob %s: retryCount must be between 0 and 185", c.Metadata.Name)
+	}
+	if c.Spec.Timeout != 0 {
+		return fmt.Errorf("cronjob %s: timeout cannot be enforced for scheduled runs, which are not stopped; use panka job run --timeout for one-off runs", c.Metadata.Name)
+	}
+	return nil
+}
+
+// lambdaTriggerSourceKinds are the component kinds each trigger type takes
+// its events from
+var lambdaTriggerSourceKinds = map[string]schema.Kind{
+	schema.TriggerSQS:      schema.KindSQS,
+	schema.TriggerDynamoDB: schema.KindDynamoDB,
+	schema.TriggerSNS:      schema.KindSNS,
+}
+
+// validateLambda validates the triggers and provisioned concurrency of a
+// Lambda function
+func (v *Validator) validateLambda(l *schema.Lambda, result *ParseResult) error {
+	if provisioned := l.Spec.ProvisionedConcurrency; provisioned != nil {
+		if provisioned.MinCapacity < 1 || provisioned.MinCapacity > provisioned.MaxCapacity {
+			return fmt.Errorf("lambda %s: provisionedConcurrency needs 1 <= minCapacity <= maxCapacity", l.Metadata.Name)
+		}
+		if provisioned.TargetUtilization != 0 && (provisioned.TargetUtilization < 10 || provisioned.TargetUtilization > 90) {
+			return fmt.Errorf("lambda %s: provisionedConcurrency.targetUtilization must be between 10 and 90 percent", l.Metadata.Name)
+		}
+		if l.Spec.ReservedConcurrentExecutions > 0 && provisioned.MaxCapacity > l.Spec.ReservedConcurrentExecutions {
+			return fmt.Errorf("lambda %s: provisionedConcurrency.maxCapacity exceeds reservedConcurrentExecutions", l.Metadata.Name)
+		}
+		if err := validateScheduledScaling("lambda "+l.Metadata.Name, "provisionedConcurrency", provisioned.Schedules); err != nil {
+			return err
+		}
+	}
+
+	for i := range l.Spec.Triggers {
+		trigger := &l.Spec.Triggers[i]
+
+		switch trigger.Type {
+		case schema.TriggerSQS, schema.TriggerDynamoDB, schema.TriggerSNS, schema.TriggerKinesis:
+			if trigger.Source == nil || (trigger.Source.Component == "" && trigger.Source.Arn == "") {
+				return fmt.Errorf("lambda %s: %s trigger requires a source component or arn", l.Metadata.Name, trigger.Type)
+			}
+			if trigger.Type == schema.TriggerKinesis && trigger.Source.Arn == "" {
+				return fmt.Errorf("lambda %s: kinesis trigger requires a source arn", l.Metadata.Name)
+			}
+			if trigger.Source.Component != "" {
+				if err := validateTriggerComponent(l, trigger.Source.Component, lambdaTriggerSourceKinds[trigger.Type], result); err != nil {
+					return err
+				}
+			}
+
+		case schema.TriggerS3:
+			if trigger.S3 == nil || trigger.S3.Bucket == "" {
+				return fmt.Errorf("lambda %s: s3 trigger requires s3.bucket", l.Metadata.Name)
+			}
+			for _, event := range trigger.S3.Events {
+				if !strings.HasPrefix(event, "s3:") {
+					return fmt.Errorf("lambda %s: invalid s3 trigger event %q", l.Metadata.Name, event)
+				}
+			}
+			// The bucket is either a component of the stack or an existing bucket
+			for _, comp := range result.Components {
+				if comp.GetMetadata().Name == trigger.S3.Bucket && comp.GetKind() != schema.KindS3 {
+					return fmt.Errorf("lambda %s: s3 trigger bucket %s is a %s, not an S3 bucket", l.Metadata.Name, trigger.S3.Bucket, comp.GetKind())
+				}
+			}
+
+		case schema.TriggerEventBridge:
+			if trigger.Schedule == "" {
+				return fmt.Errorf("lambda %s: eventbridge trigger requires a schedule", l.Metadata.Name)
+			}
+			expression, err := schema.ScheduleExpression(trigger.Schedule)
+			if err != nil {
+				return fmt.Errorf("lambda %s: %w", l.Metadata.Name, err)
+			}
+			if strings.HasPrefix(expression, "at(") {
+				return fmt.Errorf("lambda %s: eventbridge trigger schedule must be a cron or rate expression", l.Metadata.Name)
+			}
+
+		case schema.TriggerAPIGateway:
+
+		default:
+			return fmt.Errorf("lambda %s: unknown trigger type %q", l.Metadata.Name, trigger.Type)
+		}
+
+		size, err := trigger.BatchSizeCount()
+		if err != nil {
+			return fmt.Errorf("lambda %s: %w", l.Metadata.Name, err)
+		}
+		if trigger.BatchSize != "" && !trigger.PollsSource() {
+			return fmt.Errorf("lambda %s: batchSize only applies to sqs, dynamodb and kinesis triggers", l.Metadata.Name)
+		}
+		if trigger.BatchSize != "" && (size < 1 || size > 10000) {
+			return fmt.Errorf("lambda %s: batchSize must be between 1 and 10000", l.Metadata.Name)
+		}
+	}
+	return nil
+}
+
+// validateTriggerComponent checks that the source component of a trigger
+// exists and is of the kind the trigger takes events from
+func validateTriggerComponent(l *schema.Lambda, name string, kind schema.Kind, result *ParseResult) error {
+	for _, comp := range result.Components {
+		if comp.GetMetadata().Name != name {
+			continue
+		}
+		if comp.GetKind() != kind {
+			return fmt.Errorf("lambda %s: trigger source %s is a %s, not a %s", l.Metadata.Name, name, comp.GetKind(), kind)
+		}
+		return nil
+	}
+	return fmt.Errorf("lambda %s: trigger references unknown component: %s", l.Metadata.Name, name)
+}
+
+// validateElastiCacheRedis validates Redis cache configuration
+func (v *Validator) validateElastiCacheRedis(r *schema.ElastiCacheRedis) error {
+	if !strings.HasPrefix(r.Spec.NodeType, "cache.") {
+		return fmt.Errorf("redis %s: nodeType must be a cache node type such as cache.t4g.small", r.Metadata.Name)
+	}
+	if r.Spec.Shards < 0 || r.Spec.Shards > 500 {
+		return fmt.Errorf("redis %s: shards must be between 1 and 500", r.Metadata.Name)
+	}
+	if r.Spec.ReplicasPerShard < 0 || r.Spec.ReplicasPerShard > 5 {
+		return fmt.Errorf("redis %s: replicasPerShard must be between 0 and 5", r.Metadata.Name)
+	}
+	if r.Spec.AuthToken != nil {
+		if r.Spec.AuthToken.Ref == "" {
+			return fmt.Errorf("redis %s: authToken.ref is required", r.Metadata.Name)
+		}
+		if !r.Spec.Encryption.InTransit {
+			return fmt.Errorf("redis %s: authToken requires encryption.inTransit", r.Metadata.Name)
+		}
+	}
+	return nil
+}
+
+// validateElastiCacheMemcached validates Memcached cache configuration
+func (v *Validator) validateElastiCacheMemcached(m *schema.ElastiCacheMemcached) error {
+	if !strings.HasPrefix(m.Spec.NodeType, "cache.") {
+		return fmt.Errorf("memcached %s: nodeType must be a cache node type such as cache.t4g.small", m.Metadata.Name)
+	}
+	if m.Spec.Nodes < 0 || m.Spec.Nodes > 60 {
+		return fmt.Errorf("memcached %s: nodes must be between 1 and 60", m.Metadata.Name)
+	}
+	if m.Spec.Encryption.AtRest {
+		return fmt.Errorf("memcached %s: Memcached does not support encryption at rest", m.Metadata.Name)
+	}
 	return nil
 }
 
@@ -336,8 +869,16 @@ func extractDependenciesFromResource(resource schema.Resource) []string {
 	switch r := resource.(type) {
 	case *schema.MicroService:
 		return r.Spec.DependsOn
+	case *schema.Worker:
+		return r.Spec.DependsOn
+	case *schema.CronJob:
+		return r.Spec.DependsOn
 	case *schema.RDS:
 		return r.Spec.DependsOn
+	case *schema.ElastiCacheRedis:
+		return r.Spec.DependsOn
+	case *schema.ElastiCacheMemcached:
+		return r.Spec.DependsOn
 	case *schema.DynamoDB:
 		return r.Spec.DependsOn
 	case *schema.S3:
@@ -346,6 +887,8 @@ func extractDependenciesFromResource(resource schema.Resource) []string {
 		return r.Spec.DependsOn
 	case *schema.SNS:
 		return r.Spec.DependsOn
+	case *schema.Lambda:
+		return r.Spec.DependsOn
 	default:
 		return nil
 	}
@@ -1,9 +1,11 @@
 package parser
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/yourusername/panka/pkg/parser/schema"
 )
 
@@ -153,6 +155,113 @@ func TestValidator_MicroServiceValidation(t *testing.T) {
 	assert.Contains(t, err.Error(), "image")
 }
 
+func TestValidator_WorkerValidation(t *testing.T) {
+	worker := schema.NewWorker("consumer", "backend", "test-stack")
+	worker.Spec.Image = schema.ImageConfig{Repository: "example/consumer", Tag: "1.0.0"}
+	worker.Spec.Scaling = &schema.QueueScaling{Queue: "orders", MinReplicas: 1, MaxReplicas: 5}
+
+	newResult := func(components ...schema.Resource) *ParseResult {
+		result := &ParseResult{
+			Stack: schema.NewStack("test-stack"),
+			Services: []*schema.Service{
+				schema.NewService("backend", "test-stack"),
+			},
+			Components: append([]schema.Resource{worker}, components...),
+		}
+		result.Stack.Spec.Provider.Name = "aws"
+		result.Stack.Spec.Provider.Region = "us-east-1"
+		return result
+	}
+
+	err := NewValidator().Validate(newResult(schema.NewSQS("orders", "backend", "test-stack")))
+	assert.NoError(t, err)
+
+	// The scaling queue must be an SQS component
+	err = NewValidator().Validate(newResult())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown SQS component: orders")
+
+	err = NewValidator().Validate(newResult(schema.NewSNS("orders", "backend", "test-stack")))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not an SQS queue")
+
+	worker.Spec.Scaling.MinReplicas = 6
+	err = NewValidator().Validate(newResult(schema.NewSQS("orders", "backend", "test-stack")))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "minReplicas <= maxReplicas")
+
+	worker.Spec.Scaling.MinReplicas = 1
+	worker.Spec.StopTimeout = 300
+	err = NewValidator().Validate(newResult(schema.NewSQS("orders", "backend", "test-stack")))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "stopTimeout")
+
+	// Queue scaling replaces the autoscaling of the infra
+	worker.Spec.StopTimeout = 0
+	worker.Infra = schema.NewComponentInfra("consumer", "backend", "test-stack")
+	worker.Infra.Spec.Scaling.AutoScaling = &schema.AutoScaling{Enabled: true, MinReplicas: 1, MaxReplicas: 4}
+	err = NewValidator().Validate(newResult(schema.NewSQS("orders", "backend", "test-stack")))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "cannot be combined with infra autoscaling")
+}
+
+func TestValidator_CronJobValidation(t *testing.T) {
+	job := schema.NewCronJob("report", "backend", "test-stack")
+	job.Spec.Image = schema.ImageConfig{Repository: "example/report", Tag: "1.0.0"}
+	job.Spec.Schedule = "0 3 * * *"
+
+	result := &ParseResult{
+		Stack: schema.NewStack("test-stack"),
+		Services: []*schema.Service{
+			schema.NewService("backend", "test-stack"),
+		},
+		Components: []schema.Resource{job},
+	}
+	result.Stack.Spec.Provider.Name = "aws"
+	result.Stack.Spec.Provider.Region = "us-east-1"
+
+	assert.NoError(t, NewValidator().Validate(result))
+
+	job.Spec.Schedule = "rate(15 minutes)"
+	job.Spec.ConcurrencyPolicy = schema.ConcurrencyAllow
+	assert.NoError(t, NewValidator().Validate(result))
+
+	// Scheduled runs can neither wait for nor stop each other
+	job.Spec.ConcurrencyPolicy = schema.ConcurrencyReplace
+	err := NewValidator().Validate(result)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "concurrencyPolicy Replace cannot be enforced for scheduled runs")
+
+	job.Spec.ConcurrencyPolicy = ""
+	job.Spec.Timeout = 600
+	err = NewValidator().Validate(result)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "timeout cannot be enforced for scheduled runs")
+	job.Spec.Timeout = 0
+
+	job.Spec.Schedule = "0 3 * *"
+	err = NewValidator().Validate(result)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "expected 5 cron fields")
+
+	job.Spec.Schedule = "0 3 1 * MON"
+	err = NewValidator().Validate(result)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "cannot both be set")
+
+	job.Spec.Schedule = "0 3 * * *"
+	job.Spec.ConcurrencyPolicy = "Queue"
+	err = NewValidator().Validate(result)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "concurrencyPolicy")
+
+	job.Spec.ConcurrencyPolicy = ""
+	job.Spec.RetryCount = 200
+	err = NewValidator().Validate(result)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "retryCount")
+}
+
 func TestValidator_RDSValidation(t *testing.T) {
 	result := &ParseResult{
 		Stack: schema.NewStack("test-stack"),
@@ -282,6 +391,57 @@ func TestValidator_ValidDependencies(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestValidator_LifecycleIgnoreChanges(t *testing.T) {
+	result := &ParseResult{
+		Stack: schema.NewStack("test-stack"),
+		Services: []*schema.Service{
+			schema.NewService("backend", "test-stack"),
+		},
+	}
+	result.Stack.Spec.Provider.Name = "aws"
+	result.Stack.Spec.Provider.Region = "us-east-1"
+
+	queue := schema.NewSQS("queue", "backend", "test-stack")
+	queue.Metadata.Lifecycle = &schema.Lifecycle{IgnoreChanges: []string{"spec.visibilityTimeout"}}
+	result.Components = []schema.Resource{queue}
+
+	assert.NoError(t, NewValidator().Validate(result))
+
+	queue.Metadata.Lifecycle.IgnoreChanges = []string{"visibilityTimeout"}
+	err := NewValidator().Validate(result)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "lifecycle.ignoreChanges")
+}
+
+func TestValidator_Hooks(t *testing.T) {
+	result := &ParseResult{
+		Stack: schema.NewStack("test-stack"),
+		Services: []*schema.Service{
+			schema.NewService("backend", "test-stack"),
+		},
+	}
+	result.Stack.Spec.Provider.Name = "aws"
+	result.Stack.Spec.Provider.Region = "us-east-1"
+	result.Stack.Spec.Hooks = &schema.Hooks{
+		PreApply: []schema.Hook{{Name: "migrate", Command: "./migrate.sh", Timeout: "10m"}},
+	}
+	result.Components = []schema.Resource{schema.NewSQS("queue", "backend", "test-stack")}
+
+	assert.NoError(t, NewValidator().Validate(result))
+
+	result.Services[0].Spec.Hooks = &schema.Hooks{
+		PostApply: []schema.Hook{{Name: "smoke", Command: "./smoke.sh", Timeout: "soon"}},
+	}
+	err := NewValidator().Validate(result)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "hooks.postApply[0]")
+
+	result.Services[0].Spec.Hooks = &schema.Hooks{OnFailure: []schema.Hook{{Name: "page"}}}
+	err = NewValidator().Validate(result)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "has no command")
+}
+
 func TestValidator_DuplicatePortNames(t *testing.T) {
 	result := &ParseResult{
 		Stack: schema.NewStack("test-stack"),
@@ -432,3 +592,379 @@ func TestValidator_S3InvalidACL(t *testing.T) {
 	assert.Contains(t, err.Error(), "invalid ACL")
 }
 
+
+func TestValidator_Outputs(t *testing.T) {
+	result := &ParseResult{
+		Stack: schema.NewStack("my-stack"),
+		Services: []*schema.Service{
+			schema.NewService("backend", "my-stack"),
+		},
+		Components: []schema.Resource{
+			schema.NewSQS("jobs", "backend", "my-stack"),
+		},
+	}
+	result.Stack.Spec.Provider.Name = "aws"
+	result.Stack.Spec.Provider.Region = "us-east-1"
+
+	result.Stack.Spec.Outputs = map[string]string{"queueUrl": "${jobs.queue_url}"}
+	result.Services[0].Spec.Outputs = map[string]string{"queueArn": "${jobs.queue_arn}"}
+	assert.NoError(t, NewValidator().Validate(result))
+
+	result.Stack.Spec.Outputs["missing"] = "${unknown.endpoint}"
+	err := NewValidator().Validate(result)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown component: unknown")
+}
+
+func TestValidator_ElastiCacheValidation(t *testing.T) {
+	redis := schema.NewElastiCacheRedis("sessions", "backend", "test-stack")
+	memcached := schema.NewElastiCacheMemcached("pages", "backend", "test-stack")
+
+	result := &ParseResult{
+		Stack: schema.NewStack("test-stack"),
+		Services: []*schema.Service{
+			schema.NewService("backend", "test-stack"),
+		},
+		Components: []schema.Resource{redis, memcached},
+	}
+	result.Stack.Spec.Provider.Name = "aws"
+	result.Stack.Spec.Provider.Region = "us-east-1"
+
+	assert.NoError(t, NewValidator().Validate(result))
+
+	redis.Spec.NodeType = "r7g.large"
+	err := NewValidator().Validate(result)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "nodeType")
+
+	redis.Spec.NodeType = "cache.r7g.large"
+	redis.Spec.ReplicasPerShard = 6
+	err = NewValidator().Validate(result)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "replicasPerShard")
+
+	redis.Spec.ReplicasPerShard = 2
+	redis.Spec.AuthToken = &schema.SecretRef{Ref: "sessions-auth-token"}
+	redis.Spec.Encryption.InTransit = false
+	err = NewValidator().Validate(result)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "encryption.inTransit")
+
+	redis.Spec.Encryption.InTransit = true
+	assert.NoError(t, NewValidator().Validate(result))
+
+	memcached.Spec.Encryption.AtRest = true
+	err = NewValidator().Validate(result)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "encryption at rest")
+}
+
+func TestValidator_LoadBalancerValidation(t *testing.T) {
+	api := schema.NewMicroService("api", "backend", "test-stack")
+	api.Spec.Image = schema.ImageConfig{Repository: "example/api", Tag: "1.0.0"}
+	api.Infra = schema.NewComponentInfra("api", "backend", "test-stack")
+	lb := &schema.LoadBalancerConfig{Enabled: true, HealthCheckPath: "/healthz"}
+	api.Infra.Spec.Networking.LoadBalancer = lb
+
+	result := &ParseResult{
+		Stack: schema.NewStack("test-stack"),
+		Services: []*schema.Service{
+			schema.NewService("backend", "test-stack"),
+		},
+		Components: []schema.Resource{api},
+	}
+	result.Stack.Spec.Provider.Name = "aws"
+	result.Stack.Spec.Provider.Region = "us-east-1"
+
+	// The load balancer needs a port to forward to
+	err := NewValidator().Validate(result)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "TCP port")
+
+	api.Spec.Ports = []schema.Port{{Name: "metrics", Port: 9090}, {Name: "http", Port: 8080}}
+	assert.NoError(t, NewValidator().Validate(result))
+	port, ok := api.TrafficPort()
+	require.True(t, ok)
+	assert.Equal(t, 8080, port.Port)
+
+	lb.SSLPolicy = "ELBSecurityPolicy-TLS13-1-2-2021-06"
+	err = NewValidator().Validate(result)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "certificateArn")
+
+	lb.CertificateARN = "arn:aws:acm:us-east-1:123456789012:certificate/abc"
+	lb.AllowedCIDRs = []string{"10.0.0.0/8", "203.0.113.7"}
+	err = NewValidator().Validate(result)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "203.0.113.7")
+
+	// A shared load balancer routes by host or path
+	lb.AllowedCIDRs = []string{"10.0.0.0/8"}
+	lb.Shared = true
+	err = NewValidator().Validate(result)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "ingress hostname or path")
+
+	api.Infra.Spec.Networking.Ingress = &schema.IngressConfig{Enabled: true, Hostname: "api.example.com", Path: "v1"}
+	err = NewValidator().Validate(result)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "ingress.path")
+
+	api.Infra.Spec.Networking.Ingress.Path = "/v1"
+	err = NewValidator().Validate(result)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "allowedCIDRs cannot be used with a shared load balancer")
+
+	lb.AllowedCIDRs = nil
+	assert.NoError(t, NewValidator().Validate(result))
+}
+
+func TestValidator_ConfigsValidation(t *testing.T) {
+	api := schema.NewMicroService("api", "backend", "test-stack")
+	api.Spec.Image = schema.ImageConfig{Repository: "example/api", Tag: "1.0.0"}
+	api.Spec.Configs = &schema.ConfigsMount{
+		MountPath: "/etc/api",
+		Files:     []string{"app.conf"},
+		Contents:  map[string]string{"app.conf": "workers = 4\n"},
+	}
+
+	result := &ParseResult{
+		Stack: schema.NewStack("test-stack"),
+		Services: []*schema.Service{
+			schema.NewService("backend", "test-stack"),
+		},
+		Components: []schema.Resource{api},
+	}
+	result.Stack.Spec.Provider.Name = "aws"
+	result.Stack.Spec.Provider.Region = "us-east-1"
+
+	assert.NoError(t, NewValidator().Validate(result))
+
+	invalid := []struct {
+		configs schema.ConfigsMount
+		message string
+	}{
+		{schema.ConfigsMount{MountPath: "etc/api", Files: []string{"app.conf"}}, "absolute path"},
+		{schema.ConfigsMount{MountPath: "/etc/api", Files: []string{"app conf"}}, "may only contain"},
+		{schema.ConfigsMount{MountPath: "/etc/api", Files: []string{"app.conf", "app.conf"}}, "mounted more than once"},
+		{schema.ConfigsMount{MountPath: "/etc/api", Files: []string{"app.conf"}, Contents: map[string]string{"app.conf": ""}}, "is empty"},
+		{schema.ConfigsMount{MountPath: "/etc/api", Files: []string{"app.conf"}, Contents: map[string]string{"app.conf": strings.Repeat("x", schema.ConfigFileMaxSize+1)}}, "larger than"},
+		{schema.ConfigsMount{MountPath: "/etc/api", Files: []string{"app.conf"}, Contents: map[string]string{"app.conf": "\xff\xfe"}}, "not UTF-8"},
+	}
+	for _, tc := range invalid {
+		configs := tc.configs
+		api.Spec.Configs = &configs
+		err := NewValidator().Validate(result)
+		require.Error(t, err, tc.message)
+		assert.Contains(t, err.Error(), tc.message)
+	}
+}
+
+func TestValidator_LambdaTriggerValidation(t *testing.T) {
+	queue := schema.NewSQS("orders", "backend", "test-stack")
+	table := schema.NewDynamoDB("users", "backend", "test-stack")
+	table.Spec.HashKey = schema.AttributeDefinition{Name: "id", Type: "S"}
+	table.Spec.BillingMode = "PAY_PER_REQUEST"
+
+	fn := schema.NewLambda("processor", "backend", "test-stack")
+	trigger := schema.LambdaTrigger{Type: schema.TriggerSQS, Source: &schema.TriggerSource{Component: "orders"}, BatchSize: "10"}
+	fn.Spec.Triggers = []schema.LambdaTrigger{trigger}
+
+	result := &ParseResult{
+		Stack: schema.NewStack("test-stack"),
+		Services: []*schema.Service{
+			schema.NewService("backend", "test-stack"),
+		},
+		Components: []schema.Resource{queue, table, fn},
+	}
+	result.Stack.Spec.Provider.Name = "aws"
+	result.Stack.Spec.Provider.Region = "us-east-1"
+
+	assert.NoError(t, NewValidator().Validate(result))
+
+	invalid := []struct {
+		trigger schema.LambdaTrigger
+		message string
+	}{
+		{schema.LambdaTrigger{Type: "webhook"}, "unknown trigger type"},
+		{schema.LambdaTrigger{Type: schema.TriggerSQS}, "requires a source"},
+		{schema.LambdaTrigger{Type: schema.TriggerSQS, Source: &schema.TriggerSource{Component: "users"}}, "not a SQS"},
+		{schema.LambdaTrigger{Type: schema.TriggerSNS, Source: &schema.TriggerSource{Component: "alerts"}}, "unknown component: alerts"},
+		{schema.LambdaTrigger{Type: schema.TriggerKinesis, Source: &schema.TriggerSource{Component: "orders"}}, "source arn"},
+		{schema.LambdaTrigger{Type: schema.TriggerSQS, Source: &schema.TriggerSource{Component: "orders"}, BatchSize: "many"}, "batchSize"},
+		{schema.LambdaTrigger{Type: schema.TriggerDynamoDB, Source: &schema.TriggerSource{Component: "users"}, BatchSize: "20000"}, "between 1 and 10000"},
+		{schema.LambdaTrigger{Type: schema.TriggerS3, S3: &schema.S3Trigger{Bucket: "orders"}}, "not an S3 bucket"},
+		{schema.LambdaTrigger{Type: schema.TriggerS3, S3: &schema.S3Trigger{Bucket: "uploads", Events: []string{"ObjectCreated"}}}, "s3 trigger event"},
+		{schema.LambdaTrigger{Type: schema.TriggerEventBridge}, "requires a schedule"},
+		{schema.LambdaTrigger{Type: schema.TriggerEventBridge, Schedule: "at(2026-01-01T00:00:00)"}, "cron or rate"},
+	}
+	for _, tc := range invalid {
+		fn.Spec.Triggers = []schema.LambdaTrigger{tc.trigger}
+		err := NewValidator().Validate(result)
+		require.Error(t, err, tc.message)
+		assert.Contains(t, err.Error(), tc.message)
+	}
+
+	// Buckets that are not components and schedules are accepted
+	fn.Spec.Triggers = []schema.LambdaTrigger{
+		{Type: schema.TriggerS3, S3: &schema.S3Trigger{Bucket: "existing-bucket", Events: []string{"s3:ObjectCreated:*"}}},
+		{Type: schema.TriggerEventBridge, Schedule: "*/5 * * * *"},
+		{Type: schema.TriggerDynamoDB, Source: &schema.TriggerSource{Component: "users"}, BatchSize: "100"},
+	}
+	assert.NoError(t, NewValidator().Validate(result))
+}
+
+func TestValidator_AccessValidation(t *testing.T) {
+	queue := schema.NewSQS("orders", "backend", "test-stack")
+	topic := schema.NewSNS("events", "backend", "test-stack")
+	bucket := schema.NewS3("uploads", "backend", "test-stack")
+
+	fn := schema.NewLambda("processor", "backend", "test-stack")
+	fn.Spec.DependsOn = []string{"orders", "events", "uploads"}
+	fn.Spec.Access = []schema.ComponentAccess{
+		{Component: "orders", Mode: schema.AccessReadWrite},
+		{Component: "events", Mode: schema.AccessWrite},
+		{Component: "uploads", Mode: schema.AccessRead},
+	}
+
+	result := &ParseResult{
+		Stack: schema.NewStack("test-stack"),
+		Services: []*schema.Service{
+			schema.NewService("backend", "test-stack"),
+		},
+		Components: []schema.Resource{queue, topic, bucket, fn},
+	}
+	result.Stack.Spec.Provider.Name = "aws"
+	result.Stack.Spec.Provider.Region = "us-east-1"
+
+	assert.NoError(t, NewValidator().Validate(result))
+
+	invalid := []struct {
+		access  schema.ComponentAccess
+		message string
+	}{
+		{schema.ComponentAccess{Component: "orders", Mode: "admin"}, "must be read, write or readwrite"},
+		{schema.ComponentAccess{Component: "users", Mode: schema.AccessRead}, "requires it in dependsOn"},
+		{schema.ComponentAccess{Component: "events", Mode: schema.AccessRead}, "only supports write access"},
+	}
+	for _, tc := range invalid {
+		fn.Spec.Access = []schema.ComponentAccess{tc.access}
+		err := NewValidator().Validate(result)
+		require.Error(t, err, tc.message)
+		assert.Contains(t, err.Error(), tc.message)
+	}
+}
+
+func TestValidator_SecretValidation(t *testing.T) {
+	fn := schema.NewLambda("processor", "backend", "test-stack")
+	fn.Spec.Secrets = []schema.Secret{
+		{Name: "token", SecretRef: "panka/acme/test-stack/token", EnvVar: "API_TOKEN"},
+		{Name: "DB_PASSWORD", SecretRef: "/panka/acme/test-stack/db"},
+	}
+
+	result := &ParseResult{
+		Stack: schema.NewStack("test-stack"),
+		Services: []*schema.Service{
+			schema.NewService("backend", "test-stack"),
+		},
+		Components: []schema.Resource{fn},
+	}
+	result.Stack.Spec.Provider.Name = "aws"
+	result.Stack.Spec.Provider.Region = "us-east-1"
+
+	assert.NoError(t, NewValidator().Validate(result))
+
+	invalid := []struct {
+		secret  schema.Secret
+		message string
+	}{
+		{schema.Secret{Name: "bucket", SecretRef: "arn:aws:s3:::bucket"}, "Secrets Manager secret or an SSM parameter"},
+		{schema.Secret{Name: "API_TOKEN", SecretRef: "panka/acme/test-stack/other"}, "API_TOKEN is set more than once"},
+	}
+	for _, tc := range invalid {
+		fn.Spec.Secrets = []schema.Secret{{Name: "token", SecretRef: "panka/acme/test-stack/token", EnvVar: "API_TOKEN"}, tc.secret}
+		err := NewValidator().Validate(result)
+		require.Error(t, err, tc.message)
+		assert.Contains(t, err.Error(), tc.message)
+	}
+}
+
+func TestValidator_AutoScalingValidation(t *testing.T) {
+	api := schema.NewMicroService("api", "backend", "test-stack")
+	api.Spec.Image = schema.ImageConfig{Repository: "example/api", Tag: "1.0.0"}
+	api.Infra = schema.NewComponentInfra("api", "backend", "test-stack")
+
+	fn := schema.NewLambda("processor", "backend", "test-stack")
+
+	result := &ParseResult{
+		Stack: schema.NewStack("test-stack"),
+		Services: []*schema.Service{
+			schema.NewService("backend", "test-stack"),
+		},
+		Components: []schema.Resource{api, fn},
+	}
+	result.Stack.Spec.Provider.Name = "aws"
+	result.Stack.Spec.Provider.Region = "us-east-1"
+
+	zero, two, five := 0, 2, 5
+	validScaling := func() *schema.AutoScaling {
+		return &schema.AutoScaling{
+			Enabled:          true,
+			MinReplicas:      2,
+			MaxReplicas:      10,
+			TargetCPUPercent: 60,
+			Schedules: []schema.ScheduledScaling{
+				{Name: "night", Schedule: "0 22 * * *", Timezone: "Europe/Berlin", MinCapacity: &zero, MaxCapacity: &two},
+				{Name: "morning", Schedule: "cron(0 6 ? * MON-FRI *)", MinCapacity: &two},
+			},
+		}
+	}
+	api.Infra.Spec.Scaling.AutoScaling = validScaling()
+	fn.Spec.ProvisionedConcurrency = &schema.ProvisionedConcurrency{
+		MinCapacity: 1,
+		MaxCapacity: 10,
+		Schedules:   []schema.ScheduledScaling{{Name: "peak", Schedule: "rate(1 day)", MaxCapacity: &five}},
+	}
+	assert.NoError(t, NewValidator().Validate(result))
+
+	invalid := []struct {
+		modify  func(a *schema.AutoScaling)
+		message string
+	}{
+		{func(a *schema.AutoScaling) { a.MinReplicas = 20 }, "minReplicas <= maxReplicas"},
+		{func(a *schema.AutoScaling) { a.TargetMemoryPercent = 150 }, "between 1 and 100 percent"},
+		{func(a *schema.AutoScaling) { a.Schedules[1].Name = "night" }, "schedule night is defined more than once"},
+		{func(a *schema.AutoScaling) { a.Schedules[0].Schedule = "nightly" }, "expected 5 cron fields"},
+		{func(a *schema.AutoScaling) { a.Schedules[0].Timezone = "Mars/Olympus" }, "unknown timezone"},
+		{func(a *schema.AutoScaling) { a.Schedules[1].MinCapacity = nil }, "must set minCapacity or maxCapacity"},
+		{func(a *schema.AutoScaling) { a.Schedules[0].MinCapacity = &five }, "minCapacity above maxCapacity"},
+	}
+	for _, tc := range invalid {
+		api.Infra.Spec.Scaling.AutoScaling = validScaling()
+		tc.modify(api.Infra.Spec.Scaling.AutoScaling)
+		err := NewValidator().Validate(result)
+		require.Error(t, err, tc.message)
+		assert.Contains(t, err.Error(), tc.message)
+	}
+
+	// Disabled autoscaling is not applied, so it is not validated
+	api.Infra.Spec.Scaling.AutoScaling.Enabled = false
+	assert.NoError(t, NewValidator().Validate(result))
+
+	fn.Spec.ReservedConcurrentExecutions = 5
+	err := NewValidator().Validate(result)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "exceeds reservedConcurrentExecutions")
+
+	fn.Spec.ReservedConcurrentExecutions = 0
+	fn.Spec.ProvisionedConcurrency.TargetUtilization = 95
+	err = NewValidator().Validate(result)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "between 10 and 90 percent")
+
+	fn.Spec.ProvisionedConcurrency.TargetUtilization = 0
+	fn.Spec.ProvisionedConcurrency.MinCapacity = 0
+	err = NewValidator().Validate(result)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "1 <= minCapacity <= maxCapacity")
+}
@@ -0,0 +1,220 @@
+// Package planfile reads and writes saved deployment plans.
+//
+// A saved plan records the deployment plan and change set computed by
+// `panka plan --out`, together with what they were computed from: the tenant,
+// a hash of the stack source and the version of the state. `panka apply`
+// executes a saved plan only while all of these are unchanged.
+package planfile
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/yourusername/panka/pkg/diff"
+	"github.com/yourusername/panka/pkg/graph"
+	"github.com/yourusername/panka/pkg/parser/schema"
+)
+
+// FormatVersion is the version of the plan file format
+const FormatVersion = 1
+
+// Extension is the conventional extension of plan files
+const Extension = ".panka"
+
+// PlanFile is a saved deployment plan
+type PlanFile struct {
+	// FormatVersion is the version of the file format
+	FormatVersion int `json:"format_version"`
+
+	// CreatedAt is when the plan was generated
+	CreatedAt time.Time `json:"created_at"`
+
+	// TenantID is the tenant the plan was generated for
+	TenantID string `json:"tenant_id"`
+
+	// StackName is the name of the planned stack
+	StackName string `json:"stack_name"`
+
+	// Environment is the planned stack environment
+	Environment string `json:"environment"`
+
+	// StackPath is the stack folder, relative to the plan file
+	StackPath string `json:"stack_path"`
+
+	// SourceHash is the hash of the stack folder when the plan was generated
+	SourceHash string `json:"source_hash"`
+
+	// StateVersionID is the version of the state the plan was computed
+	// against (empty if the stack had no state yet)
+	StateVersionID string `json:"state_version_id"`
+
+	// Targets are the --target values the plan was limited to
+	Targets []string `json:"targets,omitempty"`
+
+	// TargetDependents records whether --target-dependents was set
+	TargetDependents bool `json:"target_dependents,omitempty"`
+
+	// Plan is the deployment plan
+	Plan *graph.DeploymentPlan `json:"plan"`
+
+	// ChangeSet holds the changes the plan applies
+	ChangeSet *diff.ChangeSet `json:"change_set"`
+}
+
+// Write writes a plan file. The stack path is stored relative to the
+// plan file so that the plan and the stack can be moved together.
+func Write(path string, pf *PlanFile) error {
+	if pf.Plan == nil || pf.ChangeSet == nil {
+		return fmt.Errorf("plan file requires a deployment plan and a change set")
+	}
+
+	out := *pf
+	out.FormatVersion = FormatVersion
+
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return fmt.Errorf("failed to resolve plan path: %w", err)
+	}
+	if filepath.IsAbs(out.StackPath) {
+		if rel, err := filepath.Rel(filepath.Dir(absPath), out.StackPath); err == nil {
+			out.StackPath = rel
+		}
+	}
+
+	data, err := json.MarshalIndent(&out, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal plan: %w", err)
+	}
+
+	if err := os.WriteFile(absPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write plan file: %w", err)
+	}
+
+	return nil
+}
+
+// Read reads a plan file. The returned StackPath is absolute.
+func Read(path string) (*PlanFile, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve plan path: %w", err)
+	}
+
+	data, err := os.ReadFile(absPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read plan file: %w", err)
+	}
+
+	var pf PlanFile
+	if err := json.Unmarshal(data, &pf); err != nil {
+		return nil, fmt.Errorf("failed to parse plan file: %w", err)
+	}
+
+	if pf.FormatVersion != FormatVersion {
+		return nil, fmt.Errorf("unsupported plan file format version %d (expected %d)", pf.FormatVersion, FormatVersion)
+	}
+	if pf.Plan == nil || pf.ChangeSet == nil {
+		return nil, fmt.Errorf("plan file is missing the deployment plan or change set")
+	}
+
+	if !filepath.IsAbs(pf.StackPath) {
+		pf.StackPath = filepath.Join(filepath.Dir(absPath), pf.StackPath)
+	}
+
+	return &pf, nil
+}
+
+// AttachResources links the planned resources and changes to the parsed
+// components of the stack. Every planned resource must be present.
+func (pf *PlanFile) AttachResources(components []schema.Resource) error {
+	byName := make(map[string]schema.Resource, len(components))
+	for _, component := range components {
+		byName[component.GetMetadata().Name] = component
+	}
+
+	for _, stage := range pf.Plan.Stages {
+		for _, res := range stage.Resources {
+			component, ok := byName[res.ID]
+			if !ok {
+				return fmt.Errorf("planned resource %s not found in stack configuration", res.ID)
+			}
+			if component.GetKind() != res.Kind {
+				return fmt.Errorf("planned resource %s is a %s, but the configuration defines a %s", res.ID, res.Kind, component.GetKind())
+			}
+			res.Resource = component
+		}
+	}
+
+	for _, change := range pf.ChangeSet.Changes {
+		if change.Type == diff.ChangeDelete {
+			continue
+		}
+		component, ok := byName[change.ResourceName]
+		if !ok {
+			return fmt.Errorf("planned change for %s not found in stack configuration", change.ResourceName)
+		}
+		change.After = component
+	}
+
+	return nil
+}
+
+// SourceHash returns a hash of the files in a stack folder. Hidden files
+// and directories and plan files are ignored.
+func SourceHash(stackPath string) (string, error) {
+	var files []string
+
+	err := filepath.WalkDir(stackPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		name := d.Name()
+		if path != stackPath && strings.HasPrefix(name, ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() || strings.HasSuffix(name, Extension) {
+			return nil
+		}
+		files = append(files, path)
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to list stack files: %w", err)
+	}
+
+	sort.Strings(files)
+
+	h := sha256.New()
+	for _, path := range files {
+		rel, err := filepath.Rel(stackPath, path)
+		if err != nil {
+			return "", fmt.Errorf("failed to resolve %s: %w", path, err)
+		}
+
+		f, err := os.Open(path)
+		if err != nil {
+			return "", fmt.Errorf("failed to read %s: %w", rel, err)
+		}
+		fmt.Fprintf(h, "%s\x00", filepath.ToSlash(rel))
+		_, err = io.Copy(h, f)
+		f.Close()
+		if err != nil {
+			return "", fmt.Errorf("failed to read %s: %w", rel, err)
+		}
+		h.Write([]byte{0})
+	}
+
+	return "sha256:" + hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,107 @@
+package planfile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourusername/panka/pkg/diff"
+	"github.com/yourusername/panka/pkg/graph"
+	"github.com/yourusername/panka/pkg/parser"
+	"github.com/yourusername/panka/pkg/parser/schema"
+	"github.com/yourusername/panka/pkg/state"
+)
+
+func createTestPlanFile(t *testing.T, stackPath string) (*PlanFile, []schema.Resource) {
+	t.Helper()
+
+	queue := schema.NewSQS("queue", "backend", "test-stack")
+	table := schema.NewDynamoDB("table", "backend", "test-stack")
+	components := []schema.Resource{queue, table}
+
+	g, err := graph.NewBuilder().BuildFromComponents("test-stack", components)
+	require.NoError(t, err)
+	plan, err := graph.NewPlanner().CreateDeploymentPlan(g, graph.ActionCreate)
+	require.NoError(t, err)
+
+	cs, err := diff.NewDiffer(nil).ComputeChanges(&parser.ParseResult{Stack: schema.NewStack("test-stack"), Components: components}, state.NewState("test-stack", "default"), "test-stack", "default")
+	require.NoError(t, err)
+
+	return &PlanFile{
+		TenantID:       "acme",
+		StackName:      "test-stack",
+		Environment:    "default",
+		StackPath:      stackPath,
+		SourceHash:     "sha256:abc",
+		StateVersionID: "v1",
+		Plan:           plan,
+		ChangeSet:      cs,
+	}, components
+}
+
+func TestWriteRead_RoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	stackPath := filepath.Join(dir, "stack")
+	pf, components := createTestPlanFile(t, stackPath)
+
+	path := filepath.Join(dir, "plan.panka")
+	require.NoError(t, Write(path, pf))
+
+	loaded, err := Read(path)
+	require.NoError(t, err)
+
+	assert.Equal(t, FormatVersion, loaded.FormatVersion)
+	assert.Equal(t, "acme", loaded.TenantID)
+	assert.Equal(t, "v1", loaded.StateVersionID)
+	assert.Equal(t, "sha256:abc", loaded.SourceHash)
+	assert.Equal(t, stackPath, loaded.StackPath)
+	assert.Equal(t, pf.Plan.TotalResources, loaded.Plan.TotalResources)
+	assert.Len(t, loaded.ChangeSet.Changes, 2)
+
+	require.NoError(t, loaded.AttachResources(components))
+	for _, stage := range loaded.Plan.Stages {
+		for _, res := range stage.Resources {
+			assert.NotNil(t, res.Resource)
+		}
+	}
+	for _, change := range loaded.ChangeSet.Changes {
+		assert.NotNil(t, change.After)
+	}
+}
+
+func TestRead_UnsupportedVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "plan.panka")
+	require.NoError(t, os.WriteFile(path, []byte(`{"format_version": 99}`), 0644))
+
+	_, err := Read(path)
+	assert.Error(t, err)
+}
+
+func TestAttachResources_MissingComponent(t *testing.T) {
+	pf, components := createTestPlanFile(t, "stack")
+
+	err := pf.AttachResources(components[:1])
+	assert.Error(t, err)
+}
+
+func TestSourceHash(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "stack.yaml"), []byte("kind: Stack"), 0644))
+
+	first, err := SourceHash(dir)
+	require.NoError(t, err)
+
+	// Plan files and hidden files do not affect the hash
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "plan.panka"), []byte("{}"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".DS_Store"), []byte("x"), 0644))
+	second, err := SourceHash(dir)
+	require.NoError(t, err)
+	assert.Equal(t, first, second)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "stack.yaml"), []byte("kind: Stack\n"), 0644))
+	third, err := SourceHash(dir)
+	require.NoError(t, err)
+	assert.NotEqual(t, first, third)
+}
@@ -0,0 +1,369 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/applicationautoscaling"
+	"github.com/aws/aws-sdk-go-v2/service/applicationautoscaling/types"
+
+	"github.com/yourusername/panka/pkg/parser/schema"
+	"github.com/yourusername/panka/pkg/provider"
+)
+
+// defaultScalingCooldown is the cooldown in seconds after scaling when the
+// spec leaves it out
+const defaultScalingCooldown = 60
+
+// appAutoScalingClient wraps the Application Auto Scaling client with the
+// calls that apply the scaling of a resource
+type appAutoScalingClient struct {
+	client *applicationautoscaling.Client
+}
+
+// newAppAutoScalingClient creates an Application Auto Scaling client
+func newAppAutoScalingClient(p *Provider) *appAutoScalingClient {
+	return &appAutoScalingClient{
+		client: applicationautoscaling.NewFromConfig(p.GetConfig()),
+	}
+}
+
+// scalableTarget identifies the capacity of a resource that is scaled, with
+// its bounds when they are known
+type scalableTarget struct {
+	ServiceNamespace  types.ServiceNamespace
+	ResourceID        string
+	ScalableDimension types.ScalableDimension
+	MinCapacity       *int
+	MaxCapacity       *int
+}
+
+// scalingConfiguration is the Application Auto Scaling configuration of a
+// scalable target
+type scalingConfiguration struct {
+	target   scalableTarget
+	policies []*applicationautoscaling.PutScalingPolicyInput
+	actions  []*applicationautoscaling.PutScheduledActionInput
+}
+
+// apply registers the target of a scaling configuration and puts its
+// policies and scheduled actions. Policies and actions of the target that
+// the configuration no longer has are deleted.
+func (c *appAutoScalingClient) apply(ctx context.Context, config *scalingConfiguration) error {
+	if err := c.registerScalableTarget(ctx, config.target); err != nil {
+		return fmt.Errorf("failed to register scalable target: %w", err)
+	}
+
+	keep := make(map[string]bool)
+	for _, policy := range config.policies {
+		name := aws.ToString(policy.PolicyName)
+		if _, err := c.client.PutScalingPolicy(ctx, policy); err != nil {
+			return fmt.Errorf("failed to put scaling policy %s: %w", name, err)
+		}
+		keep[name] = true
+	}
+	policies, err := c.describeScalingPolicies(ctx, config.target)
+	if err != nil {
+		return fmt.Errorf("failed to list scaling policies: %w", err)
+	}
+	for _, name := range policies {
+		if keep[name] {
+			continue
+		}
+		if err := c.deleteScalingPolicy(ctx, config.target, name); err != nil {
+			return fmt.Errorf("failed to delete scaling policy %s: %w", name, err)
+		}
+	}
+
+	keep = make(map[string]bool)
+	for _, action := range config.actions {
+		name := aws.ToString(action.ScheduledActionName)
+		if _, err := c.client.PutScheduledAction(ctx, action); err != nil {
+			return fmt.Errorf("failed to put scheduled action %s: %w", name, err)
+		}
+		keep[name] = true
+	}
+	actions, err := c.describeScheduledActions(ctx, config.target)
+	if err != nil {
+		return fmt.Errorf("failed to list scheduled actions: %w", err)
+	}
+	for _, name := range actions {
+		if keep[name] {
+			continue
+		}
+		if err := c.deleteScheduledAction(ctx, config.target, name); err != nil {
+			return fmt.Errorf("failed to delete scheduled action %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// registerScalableTarget registers or updates the bounds of a scalable
+// target
+func (c *appAutoScalingClient) registerScalableTarget(ctx context.Context, target scalableTarget) error {
+	_, err := c.client.RegisterScalableTarget(ctx, &applicationautoscaling.RegisterScalableTargetInput{
+		ServiceNamespace:  target.ServiceNamespace,
+		ResourceId:        aws.String(target.ResourceID),
+		ScalableDimension: target.ScalableDimension,
+		MinCapacity:       optionalInt32(target.MinCapacity),
+		MaxCapacity:       optionalInt32(target.MaxCapacity),
+	})
+	return err
+}
+
+// deregisterScalableTarget deregisters a scalable target with its policies.
+// A target that is not registered is ignored.
+func (c *appAutoScalingClient) deregisterScalableTarget(ctx context.Context, target scalableTarget) error {
+	_, err := c.client.DeregisterScalableTarget(ctx, &applicationautoscaling.DeregisterScalableTargetInput{
+		ServiceNamespace:  target.ServiceNamespace,
+		ResourceId:        aws.String(target.ResourceID),
+		ScalableDimension: target.ScalableDimension,
+	})
+	var notFound *types.ObjectNotFoundException
+	if errors.As(err, &notFound) {
+		return nil
+	}
+	return err
+}
+
+// describeScalableTarget returns a registered scalable target, or nil if it
+// is not registered
+func (c *appAutoScalingClient) describeScalableTarget(ctx context.Context, target scalableTarget) (*scalableTarget, error) {
+	output, err := c.client.DescribeScalableTargets(ctx, &applicationautoscaling.DescribeScalableTargetsInput{
+		ServiceNamespace:  target.ServiceNamespace,
+		ResourceIds:       []string{target.ResourceID},
+		ScalableDimension: target.ScalableDimension,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(output.ScalableTargets) == 0 {
+		return nil, nil
+	}
+
+	registered := output.ScalableTargets[0]
+	return &scalableTarget{
+		ServiceNamespace:  registered.ServiceNamespace,
+		ResourceID:        aws.ToString(registered.ResourceId),
+		ScalableDimension: registered.ScalableDimension,
+		MinCapacity:       optionalInt(registered.MinCapacity),
+		MaxCapacity:       optionalInt(registered.MaxCapacity),
+	}, nil
+}
+
+// describeScalingPolicies returns the names of the scaling policies of a
+// scalable target
+func (c *appAutoScalingClient) describeScalingPolicies(ctx context.Context, target scalableTarget) ([]string, error) {
+	var names []string
+	paginator := applicationautoscaling.NewDescribeScalingPoliciesPaginator(c.client, &applicationautoscaling.DescribeScalingPoliciesInput{
+		ServiceNamespace:  target.ServiceNamespace,
+		ResourceId:        aws.String(target.ResourceID),
+		ScalableDimension: target.ScalableDimension,
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, policy := range page.ScalingPolicies {
+			names = append(names, aws.ToString(policy.PolicyName))
+		}
+	}
+	return names, nil
+}
+
+// deleteScalingPolicy deletes a scaling policy of a scalable target
+func (c *appAutoScalingClient) deleteScalingPolicy(ctx context.Context, target scalableTarget, name string) error {
+	_, err := c.client.DeleteScalingPolicy(ctx, &applicationautoscaling.DeleteScalingPolicyInput{
+		PolicyName:        aws.String(name),
+		ServiceNamespace:  target.ServiceNamespace,
+		ResourceId:        aws.String(target.ResourceID),
+		ScalableDimension: target.ScalableDimension,
+	})
+	var notFound *types.ObjectNotFoundException
+	if errors.As(err, &notFound) {
+		return nil
+	}
+	return err
+}
+
+// describeScheduledActions returns the names of the scheduled actions of a
+// scalable target
+func (c *appAutoScalingClient) describeScheduledActions(ctx context.Context, target scalableTarget) ([]string, error) {
+	var names []string
+	paginator := applicationautoscaling.NewDescribeScheduledActionsPaginator(c.client, &applicationautoscaling.DescribeScheduledActionsInput{
+		ServiceNamespace:  target.ServiceNamespace,
+		ResourceId:        aws.String(target.ResourceID),
+		ScalableDimension: target.ScalableDimension,
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, action := range page.ScheduledActions {
+			names = append(names, aws.ToString(action.ScheduledActionName))
+		}
+	}
+	return names, nil
+}
+
+// deleteScheduledAction deletes a scheduled action of a scalable target
+func (c *appAutoScalingClient) deleteScheduledAction(ctx context.Context, target scalableTarget, name string) error {
+	_, err := c.client.DeleteScheduledAction(ctx, &applicationautoscaling.DeleteScheduledActionInput{
+		ScheduledActionName: aws.String(name),
+		ServiceNamespace:    target.ServiceNamespace,
+		ResourceId:          aws.String(target.ResourceID),
+		ScalableDimension:   target.ScalableDimension,
+	})
+	var notFound *types.ObjectNotFoundException
+	if errors.As(err, &notFound) {
+		return nil
+	}
+	return err
+}
+
+// ecsScalableTarget returns the scalable target of the desired count of an
+// ECS service
+func ecsScalableTarget(clusterName, serviceName string, minCapacity, maxCapacity int) scalableTarget {
+	return scalableTarget{
+		ServiceNamespace:  types.ServiceNamespaceEcs,
+		ResourceID:        fmt.Sprintf("service/%s/%s", clusterName, serviceName),
+		ScalableDimension: types.ScalableDimensionECSServiceDesiredCount,
+		MinCapacity:       &minCapacity,
+		MaxCapacity:       &maxCapacity,
+	}
+}
+
+// scalingCooldown returns a cooldown in seconds, defaulting when unset
+func scalingCooldown(seconds int) *int32 {
+	if seconds > 0 {
+		return aws.Int32(int32(seconds))
+	}
+	return aws.Int32(defaultScalingCooldown)
+}
+
+// ecsScaling returns the autoscaling of the ECS service of a microservice or
+// worker, or nil when its desired count is fixed. A worker that scales on
+// its queue tracks the backlog per task; other services track the average
+// CPU and memory utilization of their tasks.
+func ecsScaling(resource schema.Resource, clusterName, serviceName string, opts *provider.ResourceOptions) (*scalingConfiguration, error) {
+	var autoScaling *schema.AutoScaling
+	switch r := resource.(type) {
+	case *schema.MicroService:
+		autoScaling = r.Infra.AutoScalingConfig()
+	case *schema.Worker:
+		if scaling := r.Spec.Scaling; scaling != nil {
+			queueName, err := workerQueueName(scaling, opts)
+			if err != nil {
+				return nil, err
+			}
+			target := ecsScalableTarget(clusterName, serviceName, scaling.MinReplicas, scaling.MaxReplicas)
+			actions, err := scheduledActions(serviceName, target, scaling.Schedules)
+			if err != nil {
+				return nil, err
+			}
+			return &scalingConfiguration{
+				target:   target,
+				policies: []*applicationautoscaling.PutScalingPolicyInput{queueBacklogPolicy(scaling, target, clusterName, serviceName, queueName)},
+				actions:  actions,
+			}, nil
+		}
+		autoScaling = r.Infra.AutoScalingConfig()
+	}
+	if autoScaling == nil {
+		return nil, nil
+	}
+
+	target := ecsScalableTarget(clusterName, serviceName, autoScaling.MinReplicas, autoScaling.MaxReplicas)
+	config := &scalingConfiguration{target: target}
+	if cpu := autoScaling.CPUTarget(); cpu > 0 {
+		config.policies = append(config.policies, targetTrackingPolicy(serviceName+"-cpu", target,
+			types.MetricTypeECSServiceAverageCPUUtilization, float64(cpu), autoScaling.ScaleInCooldown, autoScaling.ScaleOutCooldown))
+	}
+	if memory := autoScaling.TargetMemoryPercent; memory > 0 {
+		config.policies = append(config.policies, targetTrackingPolicy(serviceName+"-memory", target,
+			types.MetricTypeECSServiceAverageMemoryUtilization, float64(memory), autoScaling.ScaleInCooldown, autoScaling.ScaleOutCooldown))
+	}
+
+	actions, err := scheduledActions(serviceName, target, autoScaling.Schedules)
+	if err != nil {
+		return nil, err
+	}
+	config.actions = actions
+	return config, nil
+}
+
+// lambdaScalableTarget returns the scalable target of the provisioned
+// concurrency of a function alias
+func lambdaScalableTarget(functionName, alias string, minCapacity, maxCapacity int) scalableTarget {
+	return scalableTarget{
+		ServiceNamespace:  types.ServiceNamespaceLambda,
+		ResourceID:        fmt.Sprintf("function:%s:%s", functionName, alias),
+		ScalableDimension: types.ScalableDimensionLambdaFunctionProvisionedConcurrency,
+		MinCapacity:       &minCapacity,
+		MaxCapacity:       &maxCapacity,
+	}
+}
+
+// targetTrackingPolicy returns a target tracking policy that keeps a
+// predefined metric of a scalable target at a value
+func targetTrackingPolicy(name string, target scalableTarget, metricType types.MetricType, value float64, scaleInCooldown, scaleOutCooldown int) *applicationautoscaling.PutScalingPolicyInput {
+	return &applicationautoscaling.PutScalingPolicyInput{
+		PolicyName:        aws.String(name),
+		ServiceNamespace:  target.ServiceNamespace,
+		ResourceId:        aws.String(target.ResourceID),
+		ScalableDimension: target.ScalableDimension,
+		PolicyType:        types.PolicyTypeTargetTrackingScaling,
+		TargetTrackingScalingPolicyConfiguration: &types.TargetTrackingScalingPolicyConfiguration{
+			TargetValue:                   aws.Float64(value),
+			PredefinedMetricSpecification: &types.PredefinedMetricSpecification{PredefinedMetricType: metricType},
+			ScaleInCooldown:               scalingCooldown(scaleInCooldown),
+			ScaleOutCooldown:              scalingCooldown(scaleOutCooldown),
+		},
+	}
+}
+
+// scheduledActions returns the scheduled actions of a scalable target,
+// named after their owner and schedule
+func scheduledActions(owner string, target scalableTarget, schedules []schema.ScheduledScaling) ([]*applicationautoscaling.PutScheduledActionInput, error) {
+	actions := make([]*applicationautoscaling.PutScheduledActionInput, 0, len(schedules))
+	for _, schedule := range schedules {
+		expression, err := schema.ScheduleExpression(schedule.Schedule)
+		if err != nil {
+			return nil, fmt.Errorf("schedule %s: %w", schedule.Name, err)
+		}
+		actions = append(actions, &applicationautoscaling.PutScheduledActionInput{
+			ScheduledActionName: aws.String(owner + "-" + schedule.Name),
+			ServiceNamespace:    target.ServiceNamespace,
+			ResourceId:          aws.String(target.ResourceID),
+			ScalableDimension:   target.ScalableDimension,
+			Schedule:            aws.String(expression),
+			Timezone:            optionalString(schedule.Timezone),
+			ScalableTargetAction: &types.ScalableTargetAction{
+				MinCapacity: optionalInt32(schedule.MinCapacity),
+				MaxCapacity: optionalInt32(schedule.MaxCapacity),
+			},
+		})
+	}
+	return actions, nil
+}
+
+// optionalInt32 converts an optional int into an optional int32
+func optionalInt32(v *int) *int32 {
+	if v == nil {
+		return nil
+	}
+	return aws.Int32(int32(*v))
+}
+
+// optionalInt converts an optional int32 into an optional int
+func optionalInt(v *int32) *int {
+	if v == nil {
+		return nil
+	}
+	i := int(*v)
+	return &i
+}
@@ -0,0 +1,117 @@
+package aws
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourusername/panka/pkg/parser/schema"
+	"github.com/yourusername/panka/pkg/provider"
+)
+
+// fakeAppAutoScaling serves Application Auto Scaling requests from canned
+// responses by operation and records the operations with their input
+type fakeAppAutoScaling struct {
+	responses  map[string]string
+	operations []string
+	inputs     map[string][]map[string]interface{}
+}
+
+func (f *fakeAppAutoScaling) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	operation := strings.TrimPrefix(r.Header.Get("X-Amz-Target"), "AnyScaleFrontendService.")
+	f.operations = append(f.operations, operation)
+
+	body, _ := io.ReadAll(r.Body)
+	var input map[string]interface{}
+	_ = json.Unmarshal(body, &input)
+	if f.inputs == nil {
+		f.inputs = make(map[string][]map[string]interface{})
+	}
+	f.inputs[operation] = append(f.inputs[operation], input)
+
+	w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+	if operation == "DeregisterScalableTarget" {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"__type": "com.amazonaws.applicationautoscaling#ObjectNotFoundException", "Message": "No scalable target found"}`))
+		return
+	}
+	response, ok := f.responses[operation]
+	if !ok {
+		response = "{}"
+	}
+	_, _ = w.Write([]byte(response))
+}
+
+func TestAppAutoScalingClient_Apply(t *testing.T) {
+	fake := &fakeAppAutoScaling{responses: map[string]string{
+		"DescribeScalingPolicies":  `{"ScalingPolicies": [{"PolicyName": "my-stack-backend-api-cpu"}, {"PolicyName": "my-stack-backend-api-memory"}]}`,
+		"DescribeScheduledActions": `{"ScheduledActions": [{"ScheduledActionName": "my-stack-backend-api-weekend"}]}`,
+	}}
+	client := newAppAutoScalingClient(testServerProvider(t, fake.ServeHTTP))
+
+	ms := schema.NewMicroService("api", "backend", "my-stack")
+	night := 1
+	ms.Infra = schema.NewComponentInfra("api", "backend", "my-stack")
+	ms.Infra.Spec.Scaling.AutoScaling = &schema.AutoScaling{
+		Enabled:     true,
+		MinReplicas: 2,
+		MaxReplicas: 6,
+		Schedules:   []schema.ScheduledScaling{{Name: "night", Schedule: "0 22 * * *", MinCapacity: &night}},
+	}
+	config, err := ecsScaling(ms, "panka-acme", "my-stack-backend-api",
+		&provider.ResourceOptions{TenantID: "acme", StackName: "my-stack", ServiceName: "backend"})
+	require.NoError(t, err)
+
+	// The memory policy and the weekend action are no longer declared
+	require.NoError(t, client.apply(context.Background(), config))
+	assert.Equal(t, []string{
+		"RegisterScalableTarget",
+		"PutScalingPolicy",
+		"DescribeScalingPolicies",
+		"DeleteScalingPolicy",
+		"PutScheduledAction",
+		"DescribeScheduledActions",
+		"DeleteScheduledAction",
+	}, fake.operations)
+
+	register := fake.inputs["RegisterScalableTarget"][0]
+	assert.Equal(t, "ecs", register["ServiceNamespace"])
+	assert.Equal(t, "service/panka-acme/my-stack-backend-api", register["ResourceId"])
+	assert.Equal(t, "ecs:service:DesiredCount", register["ScalableDimension"])
+	assert.Equal(t, float64(2), register["MinCapacity"])
+	assert.Equal(t, float64(6), register["MaxCapacity"])
+	assert.Equal(t, "my-stack-backend-api-memory", fake.inputs["DeleteScalingPolicy"][0]["PolicyName"])
+	assert.Equal(t, "my-stack-backend-api-weekend", fake.inputs["DeleteScheduledAction"][0]["ScheduledActionName"])
+
+	action := fake.inputs["PutScheduledAction"][0]
+	assert.Equal(t, "cron(0 22 * * ? *)", action["Schedule"])
+	assert.Equal(t, map[string]interface{}{"MinCapacity": float64(1)}, action["ScalableTargetAction"])
+
+	// A target that is not registered is already deregistered
+	assert.NoError(t, client.deregisterScalableTarget(context.Background(), config.target))
+}
+
+func TestAppAutoScalingClient_DescribeScalableTarget(t *testing.T) {
+	fake := &fakeAppAutoScaling{responses: map[string]string{
+		"DescribeScalableTargets": `{"ScalableTargets": [{"ServiceNamespace": "ecs", "ResourceId": "service/panka/api", "ScalableDimension": "ecs:service:DesiredCount", "MinCapacity": 1, "MaxCapacity": 4}]}`,
+	}}
+	client := newAppAutoScalingClient(testServerProvider(t, fake.ServeHTTP))
+
+	target, err := client.describeScalableTarget(context.Background(), ecsScalableTarget("panka", "api", 0, 0))
+	require.NoError(t, err)
+	require.NotNil(t, target)
+	assert.Equal(t, "service/panka/api", target.ResourceID)
+	assert.Equal(t, 1, *target.MinCapacity)
+	assert.Equal(t, 4, *target.MaxCapacity)
+	assert.Equal(t, []interface{}{"service/panka/api"}, fake.inputs["DescribeScalableTargets"][0]["ResourceIds"])
+
+	fake.responses["DescribeScalableTargets"] = `{"ScalableTargets": []}`
+	target, err = client.describeScalableTarget(context.Background(), ecsScalableTarget("panka", "api", 0, 0))
+	require.NoError(t, err)
+	assert.Nil(t, target)
+}
@@ -0,0 +1,230 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ecs"
+	"github.com/aws/aws-sdk-go-v2/service/ecs/types"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	ssmtypes "github.com/aws/aws-sdk-go-v2/service/ssm/types"
+	"github.com/yourusername/panka/pkg/parser/schema"
+)
+
+const (
+	// configInitContainer writes the config files of a task into the config
+	// volume before the application container starts
+	configInitContainer = "panka-config-init"
+	configInitImage     = "public.ecr.aws/docker/library/busybox:stable"
+
+	// configVolume is the task volume shared by the init and application
+	// containers
+	configVolume         = "panka-configs"
+	configInitMountPath  = "/panka/configs"
+	configLabelPrefix    = "panka.config."
+	configEnvPrefix      = "PANKA_CONFIG_"
+	configDeleteMaxNames = 10
+)
+
+// configFile is a config file of a service stored in an SSM parameter
+type configFile struct {
+	name         string
+	parameterARN string
+	hash         string
+}
+
+// configStore stores the config files of ECS services as SSM parameters
+// under /<cluster>/configs/<service>/. Tasks read them through their
+// execution role when they start.
+type configStore struct {
+	provider *Provider
+	client   *ssm.Client
+}
+
+// newConfigStore creates the config file store of a provider
+func newConfigStore(p *Provider) *configStore {
+	return &configStore{
+		provider: p,
+		client:   ssm.NewFromConfig(p.GetConfig()),
+	}
+}
+
+// sync stores the mounted files of a service and deletes the parameters of
+// files it no longer mounts. It returns the stored files in mount order.
+func (s *configStore) sync(ctx context.Context, clusterName, serviceName string, configs *schema.ConfigsMount, tags map[string]string) ([]configFile, error) {
+	path := configPath(clusterName, serviceName)
+
+	var files []configFile
+	keep := make(map[string]bool)
+	if configs != nil {
+		for _, name := range configs.Files {
+			content, ok := configs.Contents[name]
+			if !ok {
+				return nil, fmt.Errorf("config file %s has no content; configs are loaded from the config/ folder of the service", name)
+			}
+
+			parameter := path + name
+			// Intelligent tiering moves files over 4 KB to the advanced tier
+			if _, err := s.client.PutParameter(ctx, &ssm.PutParameterInput{
+				Name:      aws.String(parameter),
+				Value:     aws.String(content),
+				Type:      ssmtypes.ParameterTypeString,
+				Tier:      ssmtypes.ParameterTierIntelligentTiering,
+				Overwrite: aws.Bool(true),
+			}); err != nil {
+				return nil, fmt.Errorf("failed to store config file %s: %w", name, err)
+			}
+
+			// Tags cannot be passed when overwriting a parameter
+			if len(tags) > 0 {
+				if _, err := s.client.AddTagsToResource(ctx, &ssm.AddTagsToResourceInput{
+					ResourceType: ssmtypes.ResourceTypeForTaggingParameter,
+					ResourceId:   aws.String(parameter),
+					Tags:         ssmParameterTags(tags),
+				}); err != nil {
+					return nil, fmt.Errorf("failed to tag config file %s: %w", name, err)
+				}
+			}
+
+			keep[parameter] = true
+			files = append(files, configFile{
+				name:         name,
+				parameterARN: s.parameterARN(parameter),
+				hash:         configs.FileHash(name),
+			})
+		}
+	}
+
+	if err := s.deleteStale(ctx, path, keep); err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// delete deletes the config files of a service
+func (s *configStore) delete(ctx context.Context, clusterName, serviceName string) error {
+	return s.deleteStale(ctx, configPath(clusterName, serviceName), nil)
+}
+
+// deleteStale deletes the parameters under a path that are not kept
+func (s *configStore) deleteStale(ctx context.Context, path string, keep map[string]bool) error {
+	var stale []string
+	paginator := ssm.NewGetParametersByPathPaginator(s.client, &ssm.GetParametersByPathInput{
+		Path: aws.String(strings.TrimSuffix(path, "/")),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to list config files: %w", err)
+		}
+		for _, parameter := range page.Parameters {
+			if name := aws.ToString(parameter.Name); !keep[name] {
+				stale = append(stale, name)
+			}
+		}
+	}
+
+	for start := 0; start < len(stale); start += configDeleteMaxNames {
+		end := min(start+configDeleteMaxNames, len(stale))
+		if _, err := s.client.DeleteParameters(ctx, &ssm.DeleteParametersInput{
+			Names: stale[start:end],
+		}); err != nil {
+			return fmt.Errorf("failed to delete config files: %w", err)
+		}
+	}
+	return nil
+}
+
+// parameterARN returns the ARN of a parameter, whose name starts with a
+// slash
+func (s *configStore) parameterARN(name string) string {
+	region := s.provider.GetRegion()
+	return fmt.Sprintf("arn:%s:ssm:%s:%s:parameter%s", arnPartition(region), region, s.provider.GetAccountID(), name)
+}
+
+// configPath returns the SSM path of the config files of a service
+func configPath(clusterName, serviceName string) string {
+	return fmt.Sprintf("/%s/configs/%s/", clusterName, serviceName)
+}
+
+// configSecrets returns the config files as the secrets the init container
+// reads them from
+func configSecrets(files []configFile) []schema.Secret {
+	result := make([]schema.Secret, 0, len(files))
+	for i, file := range files {
+		result = append(result, schema.Secret{
+			Name:      fmt.Sprintf("%s%d", configEnvPrefix, i),
+			SecretRef: file.parameterARN,
+		})
+	}
+	return result
+}
+
+// withConfigFiles adds the init container that materializes the config
+// files of a task at the mount path of its application container. The
+// hash of each file labels the application container, so that a changed
+// file registers a new task definition revision and rolls out the service.
+func withConfigFiles(input *ecs.RegisterTaskDefinitionInput, mountPath string, files []configFile) {
+	if len(files) == 0 {
+		return
+	}
+
+	var script []string
+	script = append(script, "set -e")
+	initContainer := types.ContainerDefinition{
+		Name:       aws.String(configInitContainer),
+		Image:      aws.String(configInitImage),
+		Essential:  aws.Bool(false),
+		EntryPoint: []string{"sh", "-c"},
+		MountPoints: []types.MountPoint{
+			{SourceVolume: aws.String(configVolume), ContainerPath: aws.String(configInitMountPath)},
+		},
+	}
+	for i, secret := range configSecrets(files) {
+		initContainer.Secrets = append(initContainer.Secrets, types.Secret{
+			Name:      aws.String(secret.Name),
+			ValueFrom: aws.String(secret.SecretRef),
+		})
+		// File names are restricted to characters that need no quoting
+		script = append(script, fmt.Sprintf(`printf '%%s' "$%s" > %s/%s`, secret.Name, configInitMountPath, files[i].name))
+	}
+	initContainer.Command = []string{strings.Join(script, "\n")}
+
+	app := &input.ContainerDefinitions[0]
+	app.MountPoints = append(app.MountPoints, types.MountPoint{
+		SourceVolume:  aws.String(configVolume),
+		ContainerPath: aws.String(mountPath),
+		ReadOnly:      aws.Bool(true),
+	})
+	app.DependsOn = append(app.DependsOn, types.ContainerDependency{
+		ContainerName: aws.String(configInitContainer),
+		Condition:     types.ContainerConditionSuccess,
+	})
+	if app.DockerLabels == nil {
+		app.DockerLabels = make(map[string]string)
+	}
+	for _, file := range files {
+		app.DockerLabels[configLabelPrefix+file.name] = file.hash
+	}
+
+	input.ContainerDefinitions = append(input.ContainerDefinitions, initContainer)
+	input.Volumes = append(input.Volumes, types.Volume{Name: aws.String(configVolume)})
+}
+
+// ssmParameterTags converts tags into SSM tags, sorted by key
+func ssmParameterTags(tags map[string]string) []ssmtypes.Tag {
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	result := make([]ssmtypes.Tag, 0, len(keys))
+	for _, k := range keys {
+		result = append(result, ssmtypes.Tag{Key: aws.String(k), Value: aws.String(tags[k])})
+	}
+	return result
+}
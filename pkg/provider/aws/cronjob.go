@@ -0,0 +1,584 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ecs"
+	"github.com/aws/aws-sdk-go-v2/service/ecs/types"
+	"github.com/aws/aws-sdk-go-v2/service/scheduler"
+	schedulertypes "github.com/aws/aws-sdk-go-v2/service/scheduler/types"
+	"github.com/yourusername/panka/pkg/parser/schema"
+	"github.com/yourusername/panka/pkg/provider"
+	"go.uber.org/zap"
+)
+
+const (
+	// cronJobDefaultWaitTime bounds the wait for a run without a timeout
+	cronJobDefaultWaitTime = 12 * time.Hour
+)
+
+// CronJobProvider implements cron jobs as ECS/Fargate task definitions run
+// by an EventBridge schedule in the tenant's cluster and private subnets.
+// Resource IDs have the form <cluster>/<schedule>, where the schedule is
+// named like the task definition family.
+//
+// EventBridge Scheduler starts a run even if the previous one is still
+// running and does not stop runs, so the concurrency policy and timeout of
+// a cron job are enforced for runs started with Run. The retry count
+// applies to scheduled runs that fail to start.
+type CronJobProvider struct {
+	ecs       *ECSProvider
+	scheduler *schedulerClient
+}
+
+// NewCronJobProvider creates a new cron job provider
+func NewCronJobProvider(p *Provider) *CronJobProvider {
+	ecsProvider := NewECSProvider(p)
+	ecsProvider.kind = schema.KindCronJob
+
+	return &CronJobProvider{
+		ecs:       ecsProvider,
+		scheduler: newSchedulerClient(p),
+	}
+}
+
+// Create registers the task definition of a cron job and creates its
+// schedule
+func (cp *CronJobProvider) Create(ctx context.Context, resource schema.Resource, opts *provider.ResourceOptions) (*provider.ResourceResult, error) {
+	cronJob, ok := resource.(*schema.CronJob)
+	if !ok {
+		return nil, &provider.ProviderError{
+			Provider:  "aws",
+			Operation: "create",
+			Message:   "invalid resource type for cron job provider",
+		}
+	}
+
+	clusterName := ecsClusterName(opts)
+	scheduleName := ecsServiceName(cronJob.Metadata.Name, opts)
+	resourceID := clusterName + "/" + scheduleName
+
+	expression, err := schema.ScheduleExpression(cronJob.Spec.Schedule)
+	if err != nil {
+		return nil, ecsError("create", resourceID, "invalid schedule", err)
+	}
+
+	cp.ecs.provider.GetLogger().Info("Creating cron job",
+		zap.String("cluster", clusterName),
+		zap.String("schedule", scheduleName),
+		zap.String("expression", expression),
+	)
+
+	if opts.DryRun {
+		return &provider.ResourceResult{
+			ResourceID: resourceID,
+			Kind:       schema.KindCronJob,
+			Status:     provider.StatusPending,
+			Outputs: map[string]string{
+				"cluster_name":        clusterName,
+				"schedule_name":       scheduleName,
+				"schedule_expression": expression,
+				"image":               cronJob.Spec.Image.Repository + ":" + cronJob.Spec.Image.Tag,
+			},
+			Timestamp: time.Now(),
+		}, nil
+	}
+
+	// An interrupted create may have left the schedule behind
+	existing, err := cp.scheduler.getSchedule(ctx, scheduleName)
+	if err != nil {
+		return nil, ecsError("create", resourceID, "failed to get schedule", err)
+	}
+	if existing != nil {
+		cp.ecs.provider.GetLogger().Info("Schedule already exists, updating it",
+			zap.String("schedule", resourceID),
+		)
+		return cp.Update(ctx, resource, opts)
+	}
+
+	if err := cp.ecs.ensureCluster(ctx, clusterName, opts, resource); err != nil {
+		return nil, ecsError("create", resourceID, "failed to create ECS cluster", err)
+	}
+
+	return cp.apply(ctx, cronJob, expression, clusterName, scheduleName, opts, "create")
+}
+
+// Update registers a new task definition revision of a cron job and points
+// its schedule at it
+func (cp *CronJobProvider) Update(ctx context.Context, resource schema.Resource, opts *provider.ResourceOptions) (*provider.ResourceResult, error) {
+	cronJob, ok := resource.(*schema.CronJob)
+	if !ok {
+		return nil, &provider.ProviderError{
+			Provider:  "aws",
+			Operation: "update",
+			Message:   "invalid resource type for cron job provider",
+		}
+	}
+
+	clusterName := ecsClusterName(opts)
+	scheduleName := ecsServiceName(cronJob.Metadata.Name, opts)
+
+	expression, err := schema.ScheduleExpression(cronJob.Spec.Schedule)
+	if err != nil {
+		return nil, ecsError("update", clusterName+"/"+scheduleName, "invalid schedule", err)
+	}
+
+	cp.ecs.provider.GetLogger().Info("Updating cron job",
+		zap.String("cluster", clusterName),
+		zap.String("schedule", scheduleName),
+		zap.String("expression", expression),
+	)
+
+	return cp.apply(ctx, cronJob, expression, clusterName, scheduleName, opts, "update")
+}
+
+// apply registers the task definition of a cron job and creates or
+// replaces its schedule
+func (cp *CronJobProvider) apply(ctx context.Context, cronJob *schema.CronJob, expression, clusterName, scheduleName string, opts *provider.ResourceOptions, operation string) (*provider.ResourceResult, error) {
+	resourceID := clusterName + "/" + scheduleName
+
+	networkConfig, err := ecsNetworkConfiguration(cp.ecs.provider.GetNetworking())
+	if err != nil {
+		return nil, ecsError(operation, resourceID, "cannot place cron job tasks", err)
+	}
+
+	component, _ := ecsComponentOf(cronJob)
+	taskDefinitionARN, err := cp.ecs.registerTaskDefinition(ctx, component, cronJob, opts)
+	if err != nil {
+		return nil, ecsError(operation, resourceID, "failed to register task definition", err)
+	}
+
+	roleARN, err := cp.ensureSchedulerRole(ctx, cronJob, clusterName, scheduleName, opts)
+	if err != nil {
+		return nil, ecsError(operation, resourceID, "failed to create scheduler role", err)
+	}
+
+	input := buildSchedule(cronJob, scheduleName, expression, cp.clusterARN(clusterName), roleARN, taskDefinitionARN, networkConfig)
+
+	// The scheduler checks that it can assume a new role
+	var scheduleARN string
+	err = retryNewRole(ctx, func() error {
+		var err error
+		if operation == "create" {
+			scheduleARN, err = cp.scheduler.createSchedule(ctx, input)
+		} else {
+			scheduleARN, err = cp.scheduler.updateSchedule(ctx, input)
+		}
+		return err
+	})
+	if err != nil {
+		cp.ecs.provider.GetLogger().Error("Failed to "+operation+" schedule",
+			zap.String("schedule", resourceID),
+			zap.Error(err),
+		)
+		return nil, ecsError(operation, resourceID, "failed to "+operation+" schedule", err)
+	}
+
+	cp.ecs.provider.GetLogger().Info("Cron job scheduled",
+		zap.String("schedule", resourceID),
+		zap.String("task_definition", taskDefinitionARN),
+	)
+
+	outputs := cronJobOutputs(clusterName, scheduleName, scheduleARN, expression, input.Target)
+	return &provider.ResourceResult{
+		ResourceID: resourceID,
+		Kind:       schema.KindCronJob,
+		Status:     provider.StatusAvailable,
+		Outputs:    cp.ecs.withRoleOutputs(outputs, scheduleName),
+		Timestamp:  time.Now(),
+	}, nil
+}
+
+// PlannedID returns the <cluster>/<schedule> ID of the schedule of a cron
+// job
+func (cp *CronJobProvider) PlannedID(resource schema.Resource, opts *provider.ResourceOptions) (string, error) {
+	cronJob, ok := resource.(*schema.CronJob)
+	if !ok {
+		return "", &provider.ProviderError{
+			Provider:  "aws",
+			Operation: "plan",
+			Message:   "invalid resource type for cron job provider",
+		}
+	}
+	return ecsClusterName(opts) + "/" + ecsServiceName(cronJob.Metadata.Name, opts), nil
+}
+
+// Read reads the schedule of a cron job
+func (cp *CronJobProvider) Read(ctx context.Context, resourceID string, opts *provider.ResourceOptions) (*provider.ResourceResult, error) {
+	clusterName, scheduleName := parseECSResourceID(resourceID, opts)
+
+	s, err := cp.scheduler.getSchedule(ctx, scheduleName)
+	if err != nil {
+		return nil, ecsError("read", resourceID, "failed to get schedule", err)
+	}
+	if s == nil {
+		return nil, &provider.ProviderError{
+			Provider:   "aws",
+			Operation:  "read",
+			ResourceID: resourceID,
+			Message:    "schedule not found",
+		}
+	}
+
+	return &provider.ResourceResult{
+		ResourceID: resourceID,
+		Kind:       schema.KindCronJob,
+		Status:     provider.StatusAvailable,
+		Outputs:    cp.ecs.withRoleOutputs(cronJobOutputs(clusterName, aws.ToString(s.Name), aws.ToString(s.Arn), aws.ToString(s.ScheduleExpression), s.Target), scheduleName),
+		Timestamp:  time.Now(),
+	}, nil
+}
+
+// Delete deletes the schedule of a cron job and its IAM roles. Runs in
+// progress are left to finish, and the task definitions are kept like those
+// of services.
+func (cp *CronJobProvider) Delete(ctx context.Context, resourceID string, opts *provider.ResourceOptions) (*provider.ResourceResult, error) {
+	_, scheduleName := parseECSResourceID(resourceID, opts)
+
+	cp.ecs.provider.GetLogger().Info("Deleting cron job", zap.String("schedule", resourceID))
+
+	if err := cp.scheduler.deleteSchedule(ctx, scheduleName); err != nil {
+		return nil, ecsError("delete", resourceID, "failed to delete schedule", err)
+	}
+
+	if err := cp.ecs.roles.delete(ctx, cp.ecs.roles.roleName(scheduleName, "scheduler")); err != nil {
+		return nil, ecsError("delete", resourceID, "failed to delete scheduler role", err)
+	}
+	if err := cp.ecs.deleteTaskRoles(ctx, scheduleName); err != nil {
+		return nil, ecsError("delete", resourceID, "failed to delete task roles", err)
+	}
+
+	return &provider.ResourceResult{
+		ResourceID: resourceID,
+		Kind:       schema.KindCronJob,
+		Status:     provider.StatusDeleted,
+		Timestamp:  time.Now(),
+	}, nil
+}
+
+// Exists checks if the schedule of a cron job exists
+func (cp *CronJobProvider) Exists(ctx context.Context, resourceID string, opts *provider.ResourceOptions) (bool, error) {
+	_, scheduleName := parseECSResourceID(resourceID, opts)
+
+	s, err := cp.scheduler.getSchedule(ctx, scheduleName)
+	if err != nil {
+		return false, err
+	}
+	return s != nil, nil
+}
+
+// GetOutputs gets the outputs of a cron job
+func (cp *CronJobProvider) GetOutputs(ctx context.Context, resourceID string, opts *provider.ResourceOptions) (map[string]string, error) {
+	result, err := cp.Read(ctx, resourceID, opts)
+	if err != nil {
+		return nil, err
+	}
+	return result.Outputs, nil
+}
+
+// JobRunOptions configures a one-off run of a cron job
+type JobRunOptions struct {
+	// TaskDefinitionARN is the task definition to run, as recorded in state
+	TaskDefinitionARN string
+
+	// ConcurrencyPolicy is applied to runs of the job that are in progress
+	ConcurrencyPolicy string
+
+	// Timeout stops the run after this long; zero waits until it finishes
+	Timeout time.Duration
+}
+
+// JobRun is the result of a finished run of a cron job
+type JobRun struct {
+	TaskARN  string
+	ExitCode int
+	Reason   string
+	TimedOut bool
+	Duration time.Duration
+}
+
+// Run starts a one-off run of a cron job in the tenant's cluster and waits
+// until it has stopped
+func (cp *CronJobProvider) Run(ctx context.Context, resourceID string, runOpts JobRunOptions) (*JobRun, error) {
+	clusterName, scheduleName := parseECSResourceID(resourceID, nil)
+	log := cp.ecs.provider.GetLogger()
+
+	if runOpts.TaskDefinitionARN == "" {
+		return nil, fmt.Errorf("cron job %s has no task definition, apply the stack first", resourceID)
+	}
+
+	networkConfig, err := ecsNetworkConfiguration(cp.ecs.provider.GetNetworking())
+	if err != nil {
+		return nil, fmt.Errorf("cannot place cron job task: %w", err)
+	}
+
+	running, err := cp.runningTasks(ctx, clusterName, scheduleName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list running tasks: %w", err)
+	}
+	if len(running) > 0 {
+		switch runOpts.ConcurrencyPolicy {
+		case schema.ConcurrencyForbid:
+			return nil, fmt.Errorf("cron job %s is already running (%d tasks) and its concurrency policy is Forbid", resourceID, len(running))
+		case schema.ConcurrencyReplace:
+			for _, taskARN := range running {
+				log.Info("Stopping running task of cron job", zap.String("task", taskARN))
+				if _, err := cp.ecs.client.StopTask(ctx, &ecs.StopTaskInput{
+					Cluster: aws.String(clusterName),
+					Task:    aws.String(taskARN),
+					Reason:  aws.String("Replaced by a new run of " + scheduleName),
+				}); err != nil {
+					return nil, fmt.Errorf("failed to stop task %s: %w", taskARN, err)
+				}
+			}
+		}
+	}
+
+	started := time.Now()
+	result, err := cp.ecs.client.RunTask(ctx, &ecs.RunTaskInput{
+		Cluster:              aws.String(clusterName),
+		TaskDefinition:       aws.String(runOpts.TaskDefinitionARN),
+		Count:                aws.Int32(1),
+		LaunchType:           types.LaunchTypeFargate,
+		NetworkConfiguration: networkConfig,
+		Group:                aws.String(cronJobGroup(scheduleName)),
+		StartedBy:            aws.String("panka"),
+		PropagateTags:        types.PropagateTagsTaskDefinition,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to run task: %w", err)
+	}
+	if len(result.Tasks) == 0 {
+		reason := "no task was started"
+		if len(result.Failures) > 0 {
+			reason = aws.ToString(result.Failures[0].Reason)
+		}
+		return nil, fmt.Errorf("failed to run task: %s", reason)
+	}
+	taskARN := aws.ToString(result.Tasks[0].TaskArn)
+
+	log.Info("Cron job task started",
+		zap.String("cron_job", resourceID),
+		zap.String("task", taskARN),
+	)
+
+	timedOut, err := cp.waitStopped(ctx, clusterName, taskARN, runOpts.Timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	described, err := cp.ecs.client.DescribeTasks(ctx, &ecs.DescribeTasksInput{
+		Cluster: aws.String(clusterName),
+		Tasks:   []string{taskARN},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe task %s: %w", taskARN, err)
+	}
+	if len(described.Tasks) == 0 {
+		return nil, fmt.Errorf("task %s not found", taskARN)
+	}
+
+	run := jobRunOf(described.Tasks[0])
+	run.TimedOut = timedOut
+	run.Duration = time.Since(started)
+	return run, nil
+}
+
+// runningTasks returns the ARNs of the tasks of a cron job that have not
+// stopped, whether they were started by its schedule or by Run
+func (cp *CronJobProvider) runningTasks(ctx context.Context, clusterName, scheduleName string) ([]string, error) {
+	var taskARNs []string
+	paginator := ecs.NewListTasksPaginator(cp.ecs.client, &ecs.ListTasksInput{
+		Cluster:       aws.String(clusterName),
+		Family:        aws.String(scheduleName),
+		DesiredStatus: types.DesiredStatusRunning,
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			if isECSNotFound(err) {
+				return nil, nil
+			}
+			return nil, err
+		}
+		taskARNs = append(taskARNs, page.TaskArns...)
+	}
+	return taskARNs, nil
+}
+
+// waitStopped waits until a task has stopped. A task still running after
+// the timeout is stopped, and timedOut is true.
+func (cp *CronJobProvider) waitStopped(ctx context.Context, clusterName, taskARN string, timeout time.Duration) (timedOut bool, err error) {
+	input := &ecs.DescribeTasksInput{
+		Cluster: aws.String(clusterName),
+		Tasks:   []string{taskARN},
+	}
+	waiter := ecs.NewTasksStoppedWaiter(cp.ecs.client)
+
+	if timeout <= 0 {
+		if err := waiter.Wait(ctx, input, cronJobWaitTime(ctx)); err != nil {
+			return false, fmt.Errorf("task %s did not stop: %w", taskARN, err)
+		}
+		return false, nil
+	}
+
+	runCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	err = waiter.Wait(runCtx, input, timeout+time.Minute)
+	if err == nil {
+		return false, nil
+	}
+	if ctx.Err() != nil || !errors.Is(runCtx.Err(), context.DeadlineExceeded) {
+		return false, fmt.Errorf("task %s did not stop: %w", taskARN, err)
+	}
+
+	cp.ecs.provider.GetLogger().Warn("Cron job task timed out, stopping it",
+		zap.String("task", taskARN),
+		zap.Duration("timeout", timeout),
+	)
+	if _, err := cp.ecs.client.StopTask(ctx, &ecs.StopTaskInput{
+		Cluster: aws.String(clusterName),
+		Task:    aws.String(taskARN),
+		Reason:  aws.String(fmt.Sprintf("Timed out after %s", timeout)),
+	}); err != nil {
+		return true, fmt.Errorf("failed to stop task %s: %w", taskARN, err)
+	}
+	if err := waiter.Wait(ctx, input, ecsWaitTime(ctx)); err != nil {
+		return true, fmt.Errorf("task %s did not stop: %w", taskARN, err)
+	}
+	return true, nil
+}
+
+// clusterARN returns the ARN of a cluster of the tenant
+func (cp *CronJobProvider) clusterARN(clusterName string) string {
+	region := cp.ecs.provider.GetRegion()
+	return fmt.Sprintf("arn:%s:ecs:%s:%s:cluster/%s", arnPartition(region), region, cp.ecs.provider.GetAccountID(), clusterName)
+}
+
+// ensureSchedulerRole creates or updates the role the schedule of a cron
+// job runs its tasks with, and returns its ARN
+func (cp *CronJobProvider) ensureSchedulerRole(ctx context.Context, cronJob *schema.CronJob, clusterName, scheduleName string, opts *provider.ResourceOptions) (string, error) {
+	roles := cp.ecs.roles
+	return roles.ensure(ctx, roleSpec{
+		Name:        roles.roleName(scheduleName, "scheduler"),
+		Description: fmt.Sprintf("Scheduler role of cron job %s", scheduleName),
+		Service:     "scheduler.amazonaws.com",
+		Statements: schedulerStatements(
+			cp.ecs.provider.GetRegion(),
+			cp.ecs.provider.GetAccountID(),
+			clusterName,
+			scheduleName,
+			[]string{roles.roleARN(roles.roleName(scheduleName, "task")), roles.roleARN(roles.roleName(scheduleName, "execution"))},
+		),
+		Tags: cp.ecs.provider.tagHelper.BuildTags(opts, cronJob),
+	})
+}
+
+// schedulerStatements returns the statements that let a schedule run the
+// tasks of a cron job: run its task definition, pass its roles to ECS and
+// tag the tasks it starts
+func schedulerStatements(region, accountID, clusterName, family string, taskRoleARNs []string) []policyStatement {
+	partition := arnPartition(region)
+	return []policyStatement{
+		allow([]string{"ecs:RunTask"}, fmt.Sprintf("arn:%s:ecs:%s:%s:task-definition/%s:*", partition, region, accountID, family)),
+		allow([]string{"ecs:TagResource"}, fmt.Sprintf("arn:%s:ecs:%s:%s:task/%s/*", partition, region, accountID, clusterName)),
+		allow([]string{"iam:PassRole"}, taskRoleARNs...),
+	}
+}
+
+// buildSchedule builds the schedule that runs a cron job's task definition
+func buildSchedule(cronJob *schema.CronJob, scheduleName, expression, clusterARN, roleARN, taskDefinitionARN string, networkConfig *types.NetworkConfiguration) *scheduler.CreateScheduleInput {
+	vpc := networkConfig.AwsvpcConfiguration
+
+	return &scheduler.CreateScheduleInput{
+		Name:                       aws.String(scheduleName),
+		GroupName:                  aws.String(schedulerGroupName),
+		Description:                aws.String(fmt.Sprintf("panka cron job %s/%s/%s", cronJob.Metadata.Stack, cronJob.Metadata.Service, cronJob.Metadata.Name)),
+		ScheduleExpression:         aws.String(expression),
+		ScheduleExpressionTimezone: optionalString(cronJob.Spec.Timezone),
+		FlexibleTimeWindow:         &schedulertypes.FlexibleTimeWindow{Mode: schedulertypes.FlexibleTimeWindowModeOff},
+		State:                      schedulertypes.ScheduleStateEnabled,
+		Target: &schedulertypes.Target{
+			Arn:     aws.String(clusterARN),
+			RoleArn: aws.String(roleARN),
+			EcsParameters: &schedulertypes.EcsParameters{
+				TaskDefinitionArn: aws.String(taskDefinitionARN),
+				TaskCount:         aws.Int32(1),
+				LaunchType:        schedulertypes.LaunchTypeFargate,
+				Group:             aws.String(cronJobGroup(scheduleName)),
+				NetworkConfiguration: &schedulertypes.NetworkConfiguration{
+					AwsvpcConfiguration: &schedulertypes.AwsVpcConfiguration{
+						Subnets:        vpc.Subnets,
+						SecurityGroups: vpc.SecurityGroups,
+						AssignPublicIp: schedulertypes.AssignPublicIp(vpc.AssignPublicIp),
+					},
+				},
+				PropagateTags: schedulertypes.PropagateTagsTaskDefinition,
+			},
+			RetryPolicy: &schedulertypes.RetryPolicy{
+				MaximumRetryAttempts: aws.Int32(int32(cronJob.Spec.RetryCount)),
+			},
+		},
+	}
+}
+
+// cronJobGroup returns the ECS task group of the runs of a cron job
+func cronJobGroup(scheduleName string) string {
+	return "cronjob:" + scheduleName
+}
+
+// cronJobOutputs returns the outputs of a cron job from its schedule
+func cronJobOutputs(clusterName, scheduleName, scheduleARN, expression string, target *schedulertypes.Target) map[string]string {
+	outputs := map[string]string{
+		"cluster_name":        clusterName,
+		"schedule_name":       scheduleName,
+		"schedule_arn":        scheduleARN,
+		"schedule_expression": expression,
+	}
+	if target == nil {
+		return outputs
+	}
+	if roleARN := aws.ToString(target.RoleArn); roleARN != "" {
+		outputs["scheduler_role_arn"] = roleARN
+	}
+	if target.EcsParameters != nil {
+		outputs["task_definition_arn"] = aws.ToString(target.EcsParameters.TaskDefinitionArn)
+	}
+	return outputs
+}
+
+// jobRunOf returns the result of a stopped task. The exit code is that of
+// the first container that has one; a task whose container never ran exits
+// with -1.
+func jobRunOf(task types.Task) *JobRun {
+	run := &JobRun{
+		TaskARN:  aws.ToString(task.TaskArn),
+		ExitCode: -1,
+		Reason:   aws.ToString(task.StoppedReason),
+	}
+	for _, container := range task.Containers {
+		if container.ExitCode != nil {
+			run.ExitCode = int(*container.ExitCode)
+			if container.Reason != nil {
+				run.Reason = aws.ToString(container.Reason)
+			}
+			break
+		}
+	}
+	return run
+}
+
+// cronJobWaitTime returns how long to wait for a run without a timeout, up
+// to the deadline of the operation
+func cronJobWaitTime(ctx context.Context) time.Duration {
+	if deadline, ok := ctx.Deadline(); ok {
+		if remaining := time.Until(deadline); remaining > 0 {
+			return remaining
+		}
+	}
+	return cronJobDefaultWaitTime
+}
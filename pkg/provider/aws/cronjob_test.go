@@ -0,0 +1,225 @@
+package aws
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ecs/types"
+	"github.com/aws/aws-sdk-go-v2/service/scheduler"
+	schedulertypes "github.com/aws/aws-sdk-go-v2/service/scheduler/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourusername/panka/pkg/parser/schema"
+	"github.com/yourusername/panka/pkg/provider"
+)
+
+func TestScheduleExpression(t *testing.T) {
+	tests := []struct {
+		schedule string
+		want     string
+	}{
+		{"0 3 * * *", "cron(0 3 * * ? *)"},
+		{"*/15 * * * *", "cron(*/15 * * * ? *)"},
+		{"0 3 1 * *", "cron(0 3 1 * ? *)"},
+		{"0 3 * * 1-5", "cron(0 3 ? * 2-6 *)"},
+		{"0 3 * * 0,6", "cron(0 3 ? * 1,7 *)"},
+		{"0 3 * * 7", "cron(0 3 ? * 1 *)"},
+		{"0 3 * * MON", "cron(0 3 ? * MON *)"},
+		{"0 3 * * */2", "cron(0 3 ? * */2 *)"},
+		{"rate(5 minutes)", "rate(5 minutes)"},
+		{"cron(0 3 ? * MON-FRI *)", "cron(0 3 ? * MON-FRI *)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.schedule, func(t *testing.T) {
+			got, err := schema.ScheduleExpression(tt.schedule)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	for _, invalid := range []string{"", "0 3 * *", "rate(5 minutes", "0 3 * * 8", "0 3 1 * 1"} {
+		_, err := schema.ScheduleExpression(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestBuildSchedule(t *testing.T) {
+	networkConfig, err := ecsNetworkConfiguration(&provider.Networking{
+		PrivateSubnetIDs: []string{"subnet-1", "subnet-2"},
+		SecurityGroupID:  "sg-1",
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		timezone   string
+		retryCount int
+	}{
+		{name: "defaults"},
+		{name: "timezone and retries", timezone: "Europe/Berlin", retryCount: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			job := schema.NewCronJob("report", "backend", "my-stack")
+			job.Spec.Schedule = "0 3 * * 1-5"
+			job.Spec.Timezone = tt.timezone
+			job.Spec.RetryCount = tt.retryCount
+
+			s := buildSchedule(job, "my-stack-backend-report", "cron(0 3 ? * 2-6 *)",
+				"arn:aws:ecs:us-east-1:123456789012:cluster/panka-acme",
+				"arn:aws:iam::123456789012:role/panka-scheduler",
+				"arn:aws:ecs:us-east-1:123456789012:task-definition/my-stack-backend-report:3",
+				networkConfig)
+
+			assert.Equal(t, "my-stack-backend-report", aws.ToString(s.Name))
+			assert.Equal(t, "default", aws.ToString(s.GroupName))
+			assert.Equal(t, "cron(0 3 ? * 2-6 *)", aws.ToString(s.ScheduleExpression))
+			assert.Equal(t, schedulertypes.FlexibleTimeWindowModeOff, s.FlexibleTimeWindow.Mode)
+			if tt.timezone == "" {
+				assert.Nil(t, s.ScheduleExpressionTimezone)
+			} else {
+				assert.Equal(t, tt.timezone, aws.ToString(s.ScheduleExpressionTimezone))
+			}
+
+			target := s.Target
+			assert.Equal(t, "arn:aws:ecs:us-east-1:123456789012:cluster/panka-acme", aws.ToString(target.Arn))
+			assert.Equal(t, "arn:aws:iam::123456789012:role/panka-scheduler", aws.ToString(target.RoleArn))
+			assert.Equal(t, int32(tt.retryCount), aws.ToInt32(target.RetryPolicy.MaximumRetryAttempts))
+
+			ecsParameters := target.EcsParameters
+			assert.Equal(t, "arn:aws:ecs:us-east-1:123456789012:task-definition/my-stack-backend-report:3", aws.ToString(ecsParameters.TaskDefinitionArn))
+			assert.Equal(t, schedulertypes.LaunchTypeFargate, ecsParameters.LaunchType)
+			assert.Equal(t, "cronjob:my-stack-backend-report", aws.ToString(ecsParameters.Group))
+			vpc := ecsParameters.NetworkConfiguration.AwsvpcConfiguration
+			assert.Equal(t, []string{"subnet-1", "subnet-2"}, vpc.Subnets)
+			assert.Equal(t, []string{"sg-1"}, vpc.SecurityGroups)
+			assert.Equal(t, schedulertypes.AssignPublicIpDisabled, vpc.AssignPublicIp)
+
+			outputs := cronJobOutputs("panka-acme", "my-stack-backend-report", "arn:schedule", "cron(0 3 ? * 2-6 *)", target)
+			assert.Equal(t, "arn:aws:iam::123456789012:role/panka-scheduler", outputs["scheduler_role_arn"])
+			assert.Equal(t, "arn:aws:ecs:us-east-1:123456789012:task-definition/my-stack-backend-report:3", outputs["task_definition_arn"])
+		})
+	}
+}
+
+func TestSchedulerClient(t *testing.T) {
+	awsProvider := testServerProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/schedules/my-stack-backend-report":
+			body, _ := io.ReadAll(r.Body)
+			var input map[string]interface{}
+			require.NoError(t, json.Unmarshal(body, &input))
+			assert.Equal(t, "rate(1 hour)", input["ScheduleExpression"])
+			_, _ = w.Write([]byte(`{"ScheduleArn": "arn:aws:scheduler:us-east-1:123456789012:schedule/default/my-stack-backend-report"}`))
+		case r.Method == http.MethodGet:
+			assert.Equal(t, "default", r.URL.Query().Get("groupName"))
+			w.Header().Set("X-Amzn-ErrorType", "ResourceNotFoundException")
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"Message": "Schedule my-stack-backend-report does not exist."}`))
+		case r.Method == http.MethodDelete:
+			w.Header().Set("X-Amzn-ErrorType", "ResourceNotFoundException")
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	})
+	client := newSchedulerClient(awsProvider)
+
+	arn, err := client.createSchedule(context.Background(), &scheduler.CreateScheduleInput{
+		Name:               aws.String("my-stack-backend-report"),
+		ScheduleExpression: aws.String("rate(1 hour)"),
+		FlexibleTimeWindow: &schedulertypes.FlexibleTimeWindow{Mode: schedulertypes.FlexibleTimeWindowModeOff},
+		Target:             &schedulertypes.Target{Arn: aws.String("arn:cluster"), RoleArn: aws.String("arn:role")},
+	})
+	require.NoError(t, err)
+	assert.Contains(t, arn, "schedule/default/my-stack-backend-report")
+
+	// A missing schedule is reported as nil, and deleting it is a no-op
+	existing, err := client.getSchedule(context.Background(), "my-stack-backend-report")
+	require.NoError(t, err)
+	assert.Nil(t, existing)
+	assert.NoError(t, client.deleteSchedule(context.Background(), "my-stack-backend-report"))
+}
+
+func TestJobRunOf(t *testing.T) {
+	run := jobRunOf(types.Task{
+		TaskArn:       aws.String("arn:aws:ecs:us-east-1:123456789012:task/panka-acme/abc"),
+		StoppedReason: aws.String("Essential container in task exited"),
+		Containers: []types.Container{
+			{Name: aws.String("report"), ExitCode: aws.Int32(3)},
+		},
+	})
+	assert.Equal(t, 3, run.ExitCode)
+	assert.Equal(t, "Essential container in task exited", run.Reason)
+
+	// A task whose container never started has no exit code
+	run = jobRunOf(types.Task{
+		StoppedReason: aws.String("CannotPullContainerError"),
+		Containers:    []types.Container{{Name: aws.String("report")}},
+	})
+	assert.Equal(t, -1, run.ExitCode)
+	assert.Equal(t, "CannotPullContainerError", run.Reason)
+}
+
+func TestCronJobProvider_Lifecycle(t *testing.T) {
+	fake := newFakeECS()
+	cp := NewCronJobProvider(testServerProvider(t, fake.ServeHTTP))
+	opts := &provider.ResourceOptions{TenantID: "acme", StackName: "my-stack", ServiceName: "backend"}
+
+	job := schema.NewCronJob("report", "backend", "my-stack")
+	job.Spec.Image = schema.ImageConfig{Repository: "example/report", Tag: "1.0.0"}
+	job.Spec.Schedule = "0 3 * * 1-5"
+
+	result, err := cp.Create(context.Background(), job, opts)
+	require.NoError(t, err)
+	assert.Equal(t, "panka-acme/my-stack-backend-report", result.ResourceID)
+	assert.Equal(t, schema.KindCronJob, result.Kind)
+	assert.Equal(t, "cron(0 3 ? * 2-6 *)", result.Outputs["schedule_expression"])
+	assert.Equal(t, "arn:aws:ecs:us-east-1:123456789012:task-definition/my-stack-backend-report:1", result.Outputs["task_definition_arn"])
+	assert.Equal(t, "arn:aws:iam::123456789012:role/my-stack-backend-report-us-east-1-scheduler", result.Outputs["scheduler_role_arn"])
+	assert.Equal(t, []string{"CreateCluster", "RegisterTaskDefinition"}, fake.calls("ecs"))
+	assert.Equal(t, []string{"CreateSchedule"}, fake.calls("scheduler"))
+	assert.Equal(t, 3, strings.Count(strings.Join(fake.calls("iam"), ","), "CreateRole"))
+
+	// Cron jobs run tasks, they have no service or autoscaling
+	assert.Empty(t, fake.services)
+	assert.Empty(t, fake.calls("autoscaling"))
+
+	target := fake.input("scheduler:CreateSchedule")["Target"].(map[string]interface{})
+	assert.Equal(t, "arn:aws:ecs:us-east-1:123456789012:cluster/panka-acme", target["Arn"])
+
+	// A new image points the schedule at a new revision
+	job.Spec.Image.Tag = "1.1.0"
+	result, err = cp.Update(context.Background(), job, opts)
+	require.NoError(t, err)
+	assert.Equal(t, "arn:aws:ecs:us-east-1:123456789012:task-definition/my-stack-backend-report:2", result.Outputs["task_definition_arn"])
+	assert.Equal(t, []string{"CreateSchedule", "UpdateSchedule"}, fake.calls("scheduler"))
+
+	read, err := cp.Read(context.Background(), result.ResourceID, opts)
+	require.NoError(t, err)
+	assert.Equal(t, result.Outputs["task_definition_arn"], read.Outputs["task_definition_arn"])
+
+	// Creating an existing schedule updates it
+	_, err = cp.Create(context.Background(), job, opts)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"CreateSchedule", "UpdateSchedule", "UpdateSchedule"}, fake.calls("scheduler"))
+
+	_, err = cp.Delete(context.Background(), result.ResourceID, opts)
+	require.NoError(t, err)
+	assert.Equal(t, "DeleteSchedule", fake.calls("scheduler")[3])
+	assert.Empty(t, fake.roles)
+
+	exists, err := cp.Exists(context.Background(), result.ResourceID, opts)
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	_, err = cp.Create(context.Background(), schema.NewWorker("consumer", "backend", "my-stack"), opts)
+	assert.Error(t, err)
+}
@@ -2,6 +2,7 @@ package aws
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -229,6 +230,22 @@ func (dp *DynamoDBProvider) Create(ctx context.Context, resource schema.Resource
 	}, nil
 }
 
+// PlannedID returns the name of the table of a resource
+func (dp *DynamoDBProvider) PlannedID(resource schema.Resource, opts *provider.ResourceOptions) (string, error) {
+	dynamoResource, ok := resource.(*schema.DynamoDB)
+	if !ok {
+		return "", &provider.ProviderError{
+			Provider:  "aws",
+			Operation: "plan",
+			Message:   "invalid resource type for DynamoDB provider",
+		}
+	}
+	if dynamoResource.Spec.TableName != "" {
+		return dynamoResource.Spec.TableName, nil
+	}
+	return dp.generateTableName(dynamoResource, opts), nil
+}
+
 // Read reads the current state of a DynamoDB table
 func (dp *DynamoDBProvider) Read(ctx context.Context, resourceID string, opts *provider.ResourceOptions) (*provider.ResourceResult, error) {
 	output, err := dp.client.DescribeTable(ctx, &dynamodb.DescribeTableInput{
@@ -358,7 +375,11 @@ func (dp *DynamoDBProvider) Exists(ctx context.Context, resourceID string, opts
 		TableName: aws.String(resourceID),
 	})
 	if err != nil {
-		return false, nil
+		var notFound *types.ResourceNotFoundException
+		if errors.As(err, &notFound) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to describe table %s: %w", resourceID, err)
 	}
 	return true, nil
 }
@@ -2,32 +2,150 @@ package aws
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/ecs"
+	"github.com/aws/aws-sdk-go-v2/service/ecs/types"
 	"github.com/yourusername/panka/pkg/parser/schema"
 	"github.com/yourusername/panka/pkg/provider"
+	"github.com/yourusername/panka/pkg/secrets"
 	"go.uber.org/zap"
 )
 
-// ECSProvider implements ECS/Fargate service management
+const (
+	// ecsDefaultWaitTime bounds the wait for a service to become stable
+	// when the operation has no deadline
+	ecsDefaultWaitTime = 15 * time.Minute
+
+	// ecsHealthCheckGracePeriod is how long new tasks behind a load
+	// balancer may fail its health checks, unless their probes wait longer
+	ecsHealthCheckGracePeriod = 60
+)
+
+// ECSProvider implements ECS/Fargate service management for microservices
+// and workers. The services of a tenant run in one cluster, in the tenant's
+// private subnets and security group. Microservices with a load balancer
+// are registered in its target group. Services with autoscaling get an
+// Application Auto Scaling target that owns their desired count. Resource
+// IDs have the form <cluster>/<service>.
 type ECSProvider struct {
-	provider *Provider
-	client   *ecs.Client
+	provider      *Provider
+	client        *ecs.Client
+	loadBalancers *LoadBalancerProvider
+	roles         *iamRoles
+	secrets       *secrets.Manager
+	configs       *configStore
+	scaling       *appAutoScalingClient
+	kind          schema.Kind
 }
 
-// NewECSProvider creates a new ECS provider
+// NewECSProvider creates a new ECS provider for microservices
 func NewECSProvider(p *Provider) *ECSProvider {
 	return &ECSProvider{
-		provider: p,
-		client:   ecs.NewFromConfig(p.GetConfig()),
+		provider:      p,
+		client:        ecs.NewFromConfig(p.GetConfig()),
+		loadBalancers: NewLoadBalancerProvider(p),
+		roles:         newIAMRoles(p),
+		secrets:       secrets.NewManager(p.GetConfig()),
+		configs:       newConfigStore(p),
+		scaling:       newAppAutoScalingClient(p),
+		kind:          schema.KindMicroService,
+	}
+}
+
+// ecsComponent is what the ECS service and task definition of a
+// containerized component are built from
+type ecsComponent struct {
+	name        string
+	image       schema.ImageConfig
+	ports       []schema.Port
+	environment []schema.EnvironmentVariable
+	secrets     []schema.Secret
+	access      []schema.ComponentAccess
+	command     []string
+	args        []string
+	healthCheck *schema.HealthCheck
+	cpu         int
+	memory      int
+	stopTimeout int
+
+	// configs are mounted into the application container from configFiles,
+	// which are stored before the task definition is registered
+	configs     *schema.ConfigsMount
+	configFiles []configFile
+
+	desiredCount int
+	// autoscaled components leave their desired count to autoscaling once
+	// the service exists
+	autoscaled bool
+}
+
+// ecsComponentOf returns the ECS description of a microservice, worker or
+// cron job
+func ecsComponentOf(resource schema.Resource) (*ecsComponent, bool) {
+	switch r := resource.(type) {
+	case *schema.MicroService:
+		cpu, memory := r.TaskResources()
+		return &ecsComponent{
+			name:         r.Metadata.Name,
+			image:        r.Spec.Image,
+			ports:        r.Spec.Ports,
+			environment:  r.Spec.Environment,
+			secrets:      r.Spec.Secrets,
+			access:       r.Spec.Access,
+			command:      r.Spec.Command,
+			args:         r.Spec.Args,
+			healthCheck:  r.Spec.HealthCheck,
+			cpu:          cpu,
+			memory:       memory,
+			configs:      r.Spec.Configs,
+			desiredCount: r.DesiredCount(),
+			autoscaled:   r.Infra.AutoScalingConfig() != nil,
+		}, true
+	case *schema.Worker:
+		cpu, memory := r.TaskResources()
+		return &ecsComponent{
+			name:         r.Metadata.Name,
+			image:        r.Spec.Image,
+			environment:  r.Spec.Environment,
+			secrets:      r.Spec.Secrets,
+			access:       r.Spec.Access,
+			command:      r.Spec.Command,
+			args:         r.Spec.Args,
+			healthCheck:  r.Spec.HealthCheck,
+			cpu:          cpu,
+			memory:       memory,
+			stopTimeout:  r.Spec.StopTimeout,
+			desiredCount: r.DesiredCount(),
+			autoscaled:   r.Spec.Scaling != nil || r.Infra.AutoScalingConfig() != nil,
+		}, true
+	case *schema.CronJob:
+		cpu, memory := r.TaskResources()
+		return &ecsComponent{
+			name:        r.Metadata.Name,
+			image:       r.Spec.Image,
+			environment: r.Spec.Environment,
+			secrets:     r.Spec.Secrets,
+			access:      r.Spec.Access,
+			command:     r.Spec.Command,
+			args:        r.Spec.Args,
+			cpu:         cpu,
+			memory:      memory,
+		}, true
+	default:
+		return nil, false
 	}
 }
 
 // Create creates a new ECS service
 func (ep *ECSProvider) Create(ctx context.Context, resource schema.Resource, opts *provider.ResourceOptions) (*provider.ResourceResult, error) {
-	ecsResource, ok := resource.(*schema.MicroService)
+	component, ok := ecsComponentOf(resource)
 	if !ok {
 		return nil, &provider.ProviderError{
 			Provider:  "aws",
@@ -36,76 +154,339 @@ func (ep *ECSProvider) Create(ctx context.Context, resource schema.Resource, opt
 		}
 	}
 
+	clusterName := ecsClusterName(opts)
+	serviceName := ecsServiceName(component.name, opts)
+	resourceID := clusterName + "/" + serviceName
+
 	ep.provider.GetLogger().Info("Creating ECS service",
-		zap.String("name", ecsResource.Metadata.Name),
+		zap.String("cluster", clusterName),
+		zap.String("service", serviceName),
 	)
 
-	// TODO: Implement full ECS/Fargate creation
-	// This is the most complex resource requiring:
-	// - ECS cluster creation/selection
-	// - Task definition creation
-	// - Service creation
-	// - Load balancer integration
-	// - Auto-scaling configuration
-	// - IAM role creation
-	// - Security group configuration
-	// - Service discovery setup
-
-	serviceName := fmt.Sprintf("%s-%s-%s",
-		opts.StackName,
-		opts.ServiceName,
-		ecsResource.Metadata.Name,
-	)
+	if opts.DryRun {
+		return &provider.ResourceResult{
+			ResourceID: resourceID,
+			Kind:       ep.kind,
+			Status:     provider.StatusPending,
+			Outputs: map[string]string{
+				"cluster_name": clusterName,
+				"service_name": serviceName,
+				"image":        component.image.Repository + ":" + component.image.Tag,
+			},
+			Timestamp: time.Now(),
+		}, nil
+	}
+
+	networkConfig, err := ecsNetworkConfiguration(ep.provider.GetNetworking())
+	if err != nil {
+		return nil, ecsError("create", resourceID, "cannot place ECS service", err)
+	}
+
+	// An interrupted create may have left the service behind
+	existing, err := ep.describeService(ctx, clusterName, serviceName)
+	if err != nil {
+		return nil, ecsError("create", resourceID, "failed to describe ECS service", err)
+	}
+	if existing != nil && aws.ToString(existing.Status) == "ACTIVE" {
+		ep.provider.GetLogger().Info("ECS service already exists, updating it",
+			zap.String("service", resourceID),
+		)
+		return ep.Update(ctx, resource, opts)
+	}
+
+	if err := ep.ensureCluster(ctx, clusterName, opts, resource); err != nil {
+		return nil, ecsError("create", resourceID, "failed to create ECS cluster", err)
+	}
+
+	taskDefinitionARN, err := ep.registerTaskDefinition(ctx, component, resource, opts)
+	if err != nil {
+		return nil, ecsError("create", resourceID, "failed to register task definition", err)
+	}
 
-	ep.provider.GetLogger().Warn("ECS provider not fully implemented yet")
+	attachment, err := ep.attachLoadBalancer(ctx, resource, serviceName, opts)
+	if err != nil {
+		return nil, ecsError("create", resourceID, "failed to attach load balancer", err)
+	}
+
+	input := &ecs.CreateServiceInput{
+		ServiceName:          aws.String(serviceName),
+		Cluster:              aws.String(clusterName),
+		TaskDefinition:       aws.String(taskDefinitionARN),
+		DesiredCount:         aws.Int32(int32(component.desiredCount)),
+		LaunchType:           types.LaunchTypeFargate,
+		NetworkConfiguration: networkConfig,
+		PropagateTags:        types.PropagateTagsService,
+		Tags:                 ecsTags(ep.provider.tagHelper.BuildTags(opts, resource)),
+	}
+	if attachment != nil {
+		input.LoadBalancers = ecsLoadBalancers(component, resource, attachment)
+		input.HealthCheckGracePeriodSeconds = aws.Int32(ecsGracePeriod(component))
+	}
+
+	var result *ecs.CreateServiceOutput
+	err = retryNewRole(ctx, func() error {
+		var err error
+		result, err = ep.client.CreateService(ctx, input)
+		return err
+	})
+	if err != nil {
+		ep.provider.GetLogger().Error("Failed to create ECS service",
+			zap.String("service", resourceID),
+			zap.Error(err),
+		)
+		return nil, ecsError("create", resourceID, "failed to create ECS service", err)
+	}
+
+	if err := ep.waitStable(ctx, clusterName, serviceName); err != nil {
+		return nil, ecsError("create", resourceID, "ECS service did not become stable", err)
+	}
+
+	outputs := ep.withRoleOutputs(withLoadBalancerOutputs(ecsServiceOutputs(clusterName, result.Service), attachment), serviceName)
+	if component.autoscaled {
+		if err := ep.applyScaling(ctx, resource, clusterName, serviceName, opts, outputs); err != nil {
+			return nil, ecsError("create", resourceID, "failed to configure autoscaling", err)
+		}
+	}
+
+	ep.provider.GetLogger().Info("ECS service created",
+		zap.String("service", resourceID),
+		zap.String("task_definition", taskDefinitionARN),
+	)
 
 	return &provider.ResourceResult{
-		ResourceID: serviceName,
-		Kind:       schema.KindMicroService,
-		Status:     provider.StatusPending,
-		Outputs: map[string]string{
-			"service_name": serviceName,
-			"image":        ecsResource.Spec.Image.Repository + ":" + ecsResource.Spec.Image.Tag,
-			"platform":     ecsResource.Spec.Runtime.Platform,
-		},
-		Timestamp: time.Now(),
+		ResourceID: resourceID,
+		Kind:       ep.kind,
+		Status:     provider.StatusAvailable,
+		Outputs:    outputs,
+		Timestamp:  time.Now(),
 	}, nil
 }
 
+// PlannedID returns the <cluster>/<service> ID of the service of a resource
+func (ep *ECSProvider) PlannedID(resource schema.Resource, opts *provider.ResourceOptions) (string, error) {
+	component, ok := ecsComponentOf(resource)
+	if !ok {
+		return "", &provider.ProviderError{
+			Provider:  "aws",
+			Operation: "plan",
+			Message:   "invalid resource type for ECS provider",
+		}
+	}
+	return ecsClusterName(opts) + "/" + ecsServiceName(component.name, opts), nil
+}
+
 // Read reads the current state of an ECS service
 func (ep *ECSProvider) Read(ctx context.Context, resourceID string, opts *provider.ResourceOptions) (*provider.ResourceResult, error) {
+	clusterName, serviceName := parseECSResourceID(resourceID, opts)
+
+	service, err := ep.describeService(ctx, clusterName, serviceName)
+	if err != nil {
+		return nil, ecsError("read", resourceID, "failed to describe ECS service", err)
+	}
+	if service == nil || aws.ToString(service.Status) == "INACTIVE" {
+		return nil, &provider.ProviderError{
+			Provider:   "aws",
+			Operation:  "read",
+			ResourceID: resourceID,
+			Cause:      provider.ErrResourceNotFound,
+			Message:    "ECS service not found",
+		}
+	}
+
+	var attachment *LoadBalancerAttachment
+	if len(service.LoadBalancers) > 0 {
+		attachment, err = ep.loadBalancers.Lookup(ctx, serviceName)
+		if err != nil {
+			return nil, ecsError("read", resourceID, "failed to describe load balancer", err)
+		}
+	}
+
+	target, err := ep.readScaling(ctx, clusterName, serviceName)
+	if err != nil {
+		return nil, ecsError("read", resourceID, "failed to describe autoscaling", err)
+	}
+
 	return &provider.ResourceResult{
 		ResourceID: resourceID,
-		Kind:       schema.KindMicroService,
-		Status:     provider.StatusUnknown,
+		Kind:       ep.kind,
+		Status:     provider.StatusAvailable,
+		Outputs:    withScalingOutputs(ep.withRoleOutputs(withLoadBalancerOutputs(ecsServiceOutputs(clusterName, service), attachment), serviceName), target),
 		Timestamp:  time.Now(),
 	}, nil
 }
 
-// Update updates an existing ECS service
+// Update registers a new task definition revision and rolls it out to the
+// ECS service
 func (ep *ECSProvider) Update(ctx context.Context, resource schema.Resource, opts *provider.ResourceOptions) (*provider.ResourceResult, error) {
-	return nil, &provider.ProviderError{
-		Provider:  "aws",
-		Operation: "update",
-		Message:   "ECS provider not fully implemented",
+	component, ok := ecsComponentOf(resource)
+	if !ok {
+		return nil, &provider.ProviderError{
+			Provider:  "aws",
+			Operation: "update",
+			Message:   "invalid resource type for ECS provider",
+		}
+	}
+
+	clusterName := ecsClusterName(opts)
+	serviceName := ecsServiceName(component.name, opts)
+	resourceID := clusterName + "/" + serviceName
+
+	ep.provider.GetLogger().Info("Updating ECS service",
+		zap.String("service", resourceID),
+	)
+
+	networkConfig, err := ecsNetworkConfiguration(ep.provider.GetNetworking())
+	if err != nil {
+		return nil, ecsError("update", resourceID, "cannot place ECS service", err)
+	}
+
+	taskDefinitionARN, err := ep.registerTaskDefinition(ctx, component, resource, opts)
+	if err != nil {
+		return nil, ecsError("update", resourceID, "failed to register task definition", err)
+	}
+
+	attachment, err := ep.attachLoadBalancer(ctx, resource, serviceName, opts)
+	if err != nil {
+		return nil, ecsError("update", resourceID, "failed to attach load balancer", err)
+	}
+
+	input := &ecs.UpdateServiceInput{
+		Service:              aws.String(serviceName),
+		Cluster:              aws.String(clusterName),
+		TaskDefinition:       aws.String(taskDefinitionARN),
+		NetworkConfiguration: networkConfig,
+	}
+	if !component.autoscaled {
+		// Without autoscaling the desired count is managed by panka again
+		if err := ep.scaling.deregisterScalableTarget(ctx, ecsScalableTarget(clusterName, serviceName, 0, 0)); err != nil {
+			return nil, ecsError("update", resourceID, "failed to remove autoscaling", err)
+		}
+		input.DesiredCount = aws.Int32(int32(component.desiredCount))
+	}
+	if ep.kind == schema.KindMicroService {
+		// An empty list takes the service out of a load balancer it no
+		// longer has
+		input.LoadBalancers = ecsLoadBalancers(component, resource, attachment)
+		if attachment != nil {
+			input.HealthCheckGracePeriodSeconds = aws.Int32(ecsGracePeriod(component))
+		}
+	}
+
+	result, err := ep.client.UpdateService(ctx, input)
+	if err != nil {
+		return nil, ecsError("update", resourceID, "failed to update ECS service", err)
+	}
+
+	if err := ep.waitStable(ctx, clusterName, serviceName); err != nil {
+		return nil, ecsError("update", resourceID, "ECS service did not become stable", err)
+	}
+
+	if attachment == nil && ep.kind == schema.KindMicroService {
+		if err := ep.loadBalancers.Detach(ctx, serviceName); err != nil {
+			return nil, ecsError("update", resourceID, "failed to detach load balancer", err)
+		}
 	}
+
+	outputs := ep.withRoleOutputs(withLoadBalancerOutputs(ecsServiceOutputs(clusterName, result.Service), attachment), serviceName)
+	if component.autoscaled {
+		if err := ep.applyScaling(ctx, resource, clusterName, serviceName, opts, outputs); err != nil {
+			return nil, ecsError("update", resourceID, "failed to configure autoscaling", err)
+		}
+	}
+
+	ep.provider.GetLogger().Info("ECS service updated",
+		zap.String("service", resourceID),
+		zap.String("task_definition", taskDefinitionARN),
+	)
+
+	return &provider.ResourceResult{
+		ResourceID: resourceID,
+		Kind:       ep.kind,
+		Status:     provider.StatusAvailable,
+		Outputs:    outputs,
+		Timestamp:  time.Now(),
+	}, nil
 }
 
-// Delete deletes an ECS service
+// Delete removes the autoscaling of an ECS service, scales it down, deletes
+// it and waits until it is inactive, then detaches it from its load
+// balancer and deletes its IAM roles. The cluster is kept for the other
+// services of the tenant.
 func (ep *ECSProvider) Delete(ctx context.Context, resourceID string, opts *provider.ResourceOptions) (*provider.ResourceResult, error) {
+	clusterName, serviceName := parseECSResourceID(resourceID, opts)
+
 	ep.provider.GetLogger().Info("Deleting ECS service", zap.String("service", resourceID))
 
-	return &provider.ResourceResult{
+	// Autoscaling would scale the service back up while it drains
+	if err := ep.scaling.deregisterScalableTarget(ctx, ecsScalableTarget(clusterName, serviceName, 0, 0)); err != nil {
+		return nil, ecsError("delete", resourceID, "failed to remove autoscaling", err)
+	}
+
+	deleted := &provider.ResourceResult{
 		ResourceID: resourceID,
-		Kind:       schema.KindMicroService,
+		Kind:       ep.kind,
 		Status:     provider.StatusDeleted,
 		Timestamp:  time.Now(),
-	}, nil
+	}
+
+	service, err := ep.describeService(ctx, clusterName, serviceName)
+	if err != nil {
+		return nil, ecsError("delete", resourceID, "failed to describe ECS service", err)
+	}
+	if service == nil || aws.ToString(service.Status) == "INACTIVE" {
+		ep.provider.GetLogger().Info("ECS service already deleted or never existed",
+			zap.String("service", resourceID),
+		)
+		return ep.release(ctx, resourceID, clusterName, serviceName, deleted)
+	}
+
+	if aws.ToString(service.Status) == "ACTIVE" {
+		if _, err := ep.client.UpdateService(ctx, &ecs.UpdateServiceInput{
+			Service:      aws.String(serviceName),
+			Cluster:      aws.String(clusterName),
+			DesiredCount: aws.Int32(0),
+		}); err != nil && !isECSNotFound(err) {
+			return nil, ecsError("delete", resourceID, "failed to scale down ECS service", err)
+		}
+	}
+
+	if _, err := ep.client.DeleteService(ctx, &ecs.DeleteServiceInput{
+		Service: aws.String(serviceName),
+		Cluster: aws.String(clusterName),
+		Force:   aws.Bool(true),
+	}); err != nil {
+		if isECSNotFound(err) {
+			return ep.release(ctx, resourceID, clusterName, serviceName, deleted)
+		}
+		ep.provider.GetLogger().Error("Failed to delete ECS service",
+			zap.String("service", resourceID),
+			zap.Error(err),
+		)
+		return nil, ecsError("delete", resourceID, "failed to delete ECS service", err)
+	}
+
+	waiter := ecs.NewServicesInactiveWaiter(ep.client)
+	if err := waiter.Wait(ctx, &ecs.DescribeServicesInput{
+		Cluster:  aws.String(clusterName),
+		Services: []string{serviceName},
+	}, ecsWaitTime(ctx)); err != nil {
+		return nil, ecsError("delete", resourceID, "ECS service did not become inactive", err)
+	}
+
+	ep.provider.GetLogger().Info("ECS service deleted", zap.String("service", resourceID))
+
+	return ep.release(ctx, resourceID, clusterName, serviceName, deleted)
 }
 
-// Exists checks if an ECS service exists
+// Exists checks if an ECS service exists and is active
 func (ep *ECSProvider) Exists(ctx context.Context, resourceID string, opts *provider.ResourceOptions) (bool, error) {
-	return false, nil
+	clusterName, serviceName := parseECSResourceID(resourceID, opts)
+
+	service, err := ep.describeService(ctx, clusterName, serviceName)
+	if err != nil {
+		return false, ecsError("exists", resourceID, "failed to describe ECS service", err)
+	}
+	return service != nil && aws.ToString(service.Status) == "ACTIVE", nil
 }
 
 // GetOutputs returns the outputs of an ECS service
@@ -117,3 +498,548 @@ func (ep *ECSProvider) GetOutputs(ctx context.Context, resourceID string, opts *
 	return result.Outputs, nil
 }
 
+// attachLoadBalancer sets up the load balancer of a microservice, or
+// returns nil when the component has none
+func (ep *ECSProvider) attachLoadBalancer(ctx context.Context, resource schema.Resource, serviceName string, opts *provider.ResourceOptions) (*LoadBalancerAttachment, error) {
+	ms, ok := resource.(*schema.MicroService)
+	if !ok || ms.LoadBalancer() == nil {
+		return nil, nil
+	}
+	return ep.loadBalancers.Attach(ctx, ms, serviceName, opts)
+}
+
+// applyScaling registers the scalable target of an autoscaled service with
+// its policies and scheduled actions, and replaces the desired count in its
+// outputs by the scaling bounds
+func (ep *ECSProvider) applyScaling(ctx context.Context, resource schema.Resource, clusterName, serviceName string, opts *provider.ResourceOptions, outputs map[string]string) error {
+	config, err := ecsScaling(resource, clusterName, serviceName, opts)
+	if err != nil || config == nil {
+		return err
+	}
+
+	ep.provider.GetLogger().Info("Configuring ECS service autoscaling",
+		zap.String("service", clusterName+"/"+serviceName),
+		zap.Int("min", *config.target.MinCapacity),
+		zap.Int("max", *config.target.MaxCapacity),
+		zap.Int("policies", len(config.policies)),
+		zap.Int("schedules", len(config.actions)),
+	)
+
+	if err := ep.scaling.apply(ctx, config); err != nil {
+		return err
+	}
+	withScalingOutputs(outputs, &config.target)
+	return nil
+}
+
+// readScaling returns the registered scalable target of a service, or nil
+// when its desired count is fixed. Scheduled actions move the bounds of the
+// target, which are then left out.
+func (ep *ECSProvider) readScaling(ctx context.Context, clusterName, serviceName string) (*scalableTarget, error) {
+	target, err := ep.scaling.describeScalableTarget(ctx, ecsScalableTarget(clusterName, serviceName, 0, 0))
+	if err != nil || target == nil {
+		return nil, err
+	}
+
+	actions, err := ep.scaling.describeScheduledActions(ctx, *target)
+	if err != nil {
+		return nil, err
+	}
+	if len(actions) > 0 {
+		target.MinCapacity, target.MaxCapacity = nil, nil
+	}
+	return target, nil
+}
+
+// release removes the load balancer, config files and IAM roles of a
+// deleted service and returns its deletion result
+func (ep *ECSProvider) release(ctx context.Context, resourceID, clusterName, serviceName string, deleted *provider.ResourceResult) (*provider.ResourceResult, error) {
+	if ep.kind == schema.KindMicroService {
+		if err := ep.loadBalancers.Detach(ctx, serviceName); err != nil {
+			return nil, ecsError("delete", resourceID, "failed to detach load balancer", err)
+		}
+		if err := ep.configs.delete(ctx, clusterName, serviceName); err != nil {
+			return nil, ecsError("delete", resourceID, "failed to delete config files", err)
+		}
+	}
+	if err := ep.deleteTaskRoles(ctx, serviceName); err != nil {
+		return nil, ecsError("delete", resourceID, "failed to delete task roles", err)
+	}
+	return deleted, nil
+}
+
+// ensureCluster creates the tenant's cluster unless it is already active
+func (ep *ECSProvider) ensureCluster(ctx context.Context, clusterName string, opts *provider.ResourceOptions, resource schema.Resource) error {
+	result, err := ep.client.DescribeClusters(ctx, &ecs.DescribeClustersInput{
+		Clusters: []string{clusterName},
+	})
+	if err != nil {
+		return err
+	}
+	for _, cluster := range result.Clusters {
+		if aws.ToString(cluster.Status) == "ACTIVE" {
+			return nil
+		}
+	}
+
+	ep.provider.GetLogger().Info("Creating ECS cluster", zap.String("cluster", clusterName))
+
+	// Cluster tags leave out the service that created it
+	tags := ep.provider.tagHelper.BuildTags(&provider.ResourceOptions{TenantID: opts.TenantID}, nil)
+	_, err = ep.client.CreateCluster(ctx, &ecs.CreateClusterInput{
+		ClusterName:       aws.String(clusterName),
+		CapacityProviders: []string{"FARGATE", "FARGATE_SPOT"},
+		Settings: []types.ClusterSetting{
+			{Name: types.ClusterSettingNameContainerInsights, Value: aws.String("enabled")},
+		},
+		Tags: ecsTags(tags),
+	})
+	return err
+}
+
+// registerTaskDefinition registers a new revision of the task definition of
+// a component, with its IAM roles, and returns its ARN
+func (ep *ECSProvider) registerTaskDefinition(ctx context.Context, component *ecsComponent, resource schema.Resource, opts *provider.ResourceOptions) (string, error) {
+	family := ecsServiceName(component.name, opts)
+	tags := ep.provider.tagHelper.BuildTags(opts, resource)
+
+	if err := ep.resolveSecrets(ctx, component); err != nil {
+		return "", err
+	}
+
+	// Microservices also remove the files they no longer mount
+	if ep.kind == schema.KindMicroService {
+		files, err := ep.configs.sync(ctx, ecsClusterName(opts), family, component.configs, tags)
+		if err != nil {
+			return "", err
+		}
+		component.configFiles = files
+	}
+
+	roles, err := ep.ensureTaskRoles(ctx, component, family, tags)
+	if err != nil {
+		return "", err
+	}
+
+	input := buildTaskDefinition(component, family, roles)
+	input.Tags = ecsTags(tags)
+
+	var result *ecs.RegisterTaskDefinitionOutput
+	err = retryNewRole(ctx, func() error {
+		var err error
+		result, err = ep.client.RegisterTaskDefinition(ctx, input)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	return aws.ToString(result.TaskDefinition.TaskDefinitionArn), nil
+}
+
+// resolveSecrets replaces the secret refs of a component with the ARNs of
+// their secrets, so that task definitions only hold references to values
+func (ep *ECSProvider) resolveSecrets(ctx context.Context, component *ecsComponent) error {
+	resolved := make([]schema.Secret, len(component.secrets))
+	for i, secret := range component.secrets {
+		arn, err := ep.secrets.Resolve(ctx, secret.SecretRef)
+		if err != nil {
+			return err
+		}
+		secret.SecretRef = arn
+		resolved[i] = secret
+	}
+	component.secrets = resolved
+	return nil
+}
+
+// taskRoles are the IAM roles of the tasks of a component. The task role
+// is assumed by the containers, the execution role by ECS to pull the image,
+// send logs and read secrets.
+type taskRoles struct {
+	task      string
+	execution string
+}
+
+// ensureTaskRoles creates or updates the task and execution roles of a
+// component and returns their ARNs
+func (ep *ECSProvider) ensureTaskRoles(ctx context.Context, component *ecsComponent, serviceName string, tags map[string]string) (taskRoles, error) {
+	access, err := accessStatements(component.access)
+	if err != nil {
+		return taskRoles{}, err
+	}
+	// The execution role reads the config files for the init container
+	readable := append(append([]schema.Secret{}, component.secrets...), configSecrets(component.configFiles)...)
+	secretAccess, err := secretStatements(readable)
+	if err != nil {
+		return taskRoles{}, err
+	}
+
+	taskRole, err := ep.roles.ensure(ctx, roleSpec{
+		Name:        ep.roles.roleName(serviceName, "task"),
+		Description: fmt.Sprintf("Task role of ECS service %s", serviceName),
+		Service:     "ecs-tasks.amazonaws.com",
+		Statements:  access,
+		Tags:        tags,
+	})
+	if err != nil {
+		return taskRoles{}, err
+	}
+
+	executionRole, err := ep.roles.ensure(ctx, roleSpec{
+		Name:            ep.roles.roleName(serviceName, "execution"),
+		Description:     fmt.Sprintf("Task execution role of ECS service %s", serviceName),
+		Service:         "ecs-tasks.amazonaws.com",
+		ManagedPolicies: []string{ep.roles.managedPolicyARN(ecsTaskExecutionPolicy)},
+		Statements:      secretAccess,
+		Tags:            tags,
+	})
+	if err != nil {
+		return taskRoles{}, err
+	}
+
+	return taskRoles{task: taskRole, execution: executionRole}, nil
+}
+
+// deleteTaskRoles deletes the task and execution roles of a service
+func (ep *ECSProvider) deleteTaskRoles(ctx context.Context, serviceName string) error {
+	for _, suffix := range []string{"task", "execution"} {
+		if err := ep.roles.delete(ctx, ep.roles.roleName(serviceName, suffix)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// withRoleOutputs adds the ARNs of the task and execution roles of a
+// service to its outputs
+func (ep *ECSProvider) withRoleOutputs(outputs map[string]string, serviceName string) map[string]string {
+	outputs["task_role_arn"] = ep.roles.roleARN(ep.roles.roleName(serviceName, "task"))
+	outputs["execution_role_arn"] = ep.roles.roleARN(ep.roles.roleName(serviceName, "execution"))
+	return outputs
+}
+
+// describeService returns a service, or nil if it or its cluster does not
+// exist
+func (ep *ECSProvider) describeService(ctx context.Context, clusterName, serviceName string) (*types.Service, error) {
+	result, err := ep.client.DescribeServices(ctx, &ecs.DescribeServicesInput{
+		Cluster:  aws.String(clusterName),
+		Services: []string{serviceName},
+	})
+	if err != nil {
+		if isECSNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if len(result.Services) == 0 {
+		return nil, nil
+	}
+	return &result.Services[0], nil
+}
+
+// waitStable waits until a service has reached its desired count with its
+// latest deployment
+func (ep *ECSProvider) waitStable(ctx context.Context, clusterName, serviceName string) error {
+	ep.provider.GetLogger().Info("Waiting for ECS service to become stable",
+		zap.String("cluster", clusterName),
+		zap.String("service", serviceName),
+	)
+
+	waiter := ecs.NewServicesStableWaiter(ep.client)
+	return waiter.Wait(ctx, &ecs.DescribeServicesInput{
+		Cluster:  aws.String(clusterName),
+		Services: []string{serviceName},
+	}, ecsWaitTime(ctx))
+}
+
+// buildTaskDefinition builds the Fargate task definition of a component
+func buildTaskDefinition(component *ecsComponent, family string, roles taskRoles) *ecs.RegisterTaskDefinitionInput {
+	container := types.ContainerDefinition{
+		Name:      aws.String(component.name),
+		Image:     aws.String(component.image.Repository + ":" + component.image.Tag),
+		Essential: aws.Bool(true),
+	}
+
+	for _, port := range component.ports {
+		protocol := types.TransportProtocolTcp
+		if strings.EqualFold(port.Protocol, "udp") {
+			protocol = types.TransportProtocolUdp
+		}
+		container.PortMappings = append(container.PortMappings, types.PortMapping{
+			Name:          aws.String(port.Name),
+			ContainerPort: aws.Int32(int32(port.Port)),
+			Protocol:      protocol,
+		})
+	}
+
+	// Output references have been resolved into values before apply
+	for _, env := range component.environment {
+		container.Environment = append(container.Environment, types.KeyValuePair{
+			Name:  aws.String(env.Name),
+			Value: aws.String(env.Value),
+		})
+	}
+
+	// Secret refs have been resolved into ARNs before registration
+	for _, secret := range component.secrets {
+		name := secret.EnvVar
+		if name == "" {
+			name = secret.Name
+		}
+		container.Secrets = append(container.Secrets, types.Secret{
+			Name:      aws.String(name),
+			ValueFrom: aws.String(secret.SecretRef),
+		})
+	}
+
+	// Command and args override the image entrypoint and command
+	if len(component.command) > 0 {
+		container.EntryPoint = component.command
+	}
+	if len(component.args) > 0 {
+		container.Command = component.args
+	}
+
+	if component.healthCheck != nil {
+		probe := component.healthCheck.Liveness
+		if probe == nil {
+			probe = component.healthCheck.Readiness
+		}
+		container.HealthCheck = ecsHealthCheck(probe)
+	}
+
+	// Time to finish in-flight work between SIGTERM and SIGKILL
+	if component.stopTimeout > 0 {
+		container.StopTimeout = aws.Int32(int32(component.stopTimeout))
+	}
+
+	input := &ecs.RegisterTaskDefinitionInput{
+		Family:                  aws.String(family),
+		ContainerDefinitions:    []types.ContainerDefinition{container},
+		RequiresCompatibilities: []types.Compatibility{types.CompatibilityFargate},
+		NetworkMode:             types.NetworkModeAwsvpc,
+		Cpu:                     aws.String(strconv.Itoa(component.cpu)),
+		Memory:                  aws.String(strconv.Itoa(component.memory)),
+	}
+	if roles.task != "" {
+		input.TaskRoleArn = aws.String(roles.task)
+	}
+	if roles.execution != "" {
+		input.ExecutionRoleArn = aws.String(roles.execution)
+	}
+	if component.configs != nil {
+		withConfigFiles(input, component.configs.MountPath, component.configFiles)
+	}
+
+	return input
+}
+
+// ecsHealthCheck converts a health check probe into a container health
+// check. HTTP and TCP probes run inside the container, so the image needs
+// curl or nc.
+func ecsHealthCheck(probe *schema.HealthCheckProbe) *types.HealthCheck {
+	if probe == nil {
+		return nil
+	}
+
+	var command []string
+	switch {
+	case probe.Exec != nil:
+		command = append([]string{"CMD"}, probe.Exec.Command...)
+	case probe.HTTP != nil:
+		scheme := probe.HTTP.Scheme
+		if scheme == "" {
+			scheme = "http"
+		}
+		path := probe.HTTP.Path
+		if !strings.HasPrefix(path, "/") {
+			path = "/" + path
+		}
+		command = []string{"CMD-SHELL", fmt.Sprintf("curl -fsk %s://localhost:%d%s || exit 1", strings.ToLower(scheme), probe.HTTP.Port, path)}
+	case probe.TCP != nil:
+		command = []string{"CMD-SHELL", fmt.Sprintf("nc -z localhost %d || exit 1", probe.TCP.Port)}
+	default:
+		return nil
+	}
+
+	check := &types.HealthCheck{Command: command}
+	if probe.PeriodSeconds > 0 {
+		check.Interval = aws.Int32(int32(probe.PeriodSeconds))
+	}
+	if probe.TimeoutSeconds > 0 {
+		check.Timeout = aws.Int32(int32(probe.TimeoutSeconds))
+	}
+	if probe.FailureThreshold > 0 {
+		check.Retries = aws.Int32(int32(probe.FailureThreshold))
+	}
+	if probe.InitialDelaySeconds > 0 {
+		check.StartPeriod = aws.Int32(int32(probe.InitialDelaySeconds))
+	}
+	return check
+}
+
+// ecsNetworkConfiguration places tasks in the tenant's private subnets and
+// security group
+func ecsNetworkConfiguration(networking *provider.Networking) (*types.NetworkConfiguration, error) {
+	if networking == nil || len(networking.PrivateSubnetIDs) == 0 {
+		return nil, errors.New("tenant networking has no private subnets, set up the tenant networking first")
+	}
+
+	config := &types.AwsVpcConfiguration{
+		Subnets:        networking.PrivateSubnetIDs,
+		AssignPublicIp: types.AssignPublicIpDisabled,
+	}
+	if networking.SecurityGroupID != "" {
+		config.SecurityGroups = []string{networking.SecurityGroupID}
+	}
+
+	return &types.NetworkConfiguration{AwsvpcConfiguration: config}, nil
+}
+
+// ecsServiceOutputs returns the outputs of a service
+func ecsServiceOutputs(clusterName string, service *types.Service) map[string]string {
+	outputs := map[string]string{
+		"cluster_name": clusterName,
+	}
+	if service == nil {
+		return outputs
+	}
+
+	outputs["service_name"] = aws.ToString(service.ServiceName)
+	outputs["service_arn"] = aws.ToString(service.ServiceArn)
+	outputs["task_definition_arn"] = aws.ToString(service.TaskDefinition)
+	outputs["desired_count"] = strconv.Itoa(int(service.DesiredCount))
+	return outputs
+}
+
+// withScalingOutputs replaces the desired count in the outputs of an
+// autoscaled service, which autoscaling changes, by the bounds of its
+// scalable target. A manual change of the bounds then shows as drift, like a
+// manual change of the desired count of a service without autoscaling.
+func withScalingOutputs(outputs map[string]string, target *scalableTarget) map[string]string {
+	if target == nil {
+		return outputs
+	}
+	delete(outputs, "desired_count")
+	if target.MinCapacity != nil {
+		outputs["min_replicas"] = strconv.Itoa(*target.MinCapacity)
+	}
+	if target.MaxCapacity != nil {
+		outputs["max_replicas"] = strconv.Itoa(*target.MaxCapacity)
+	}
+	return outputs
+}
+
+// withLoadBalancerOutputs adds the outputs of a load balancer attachment to
+// the outputs of a service
+func withLoadBalancerOutputs(outputs map[string]string, attachment *LoadBalancerAttachment) map[string]string {
+	if attachment == nil {
+		return outputs
+	}
+	for k, v := range attachment.Outputs() {
+		outputs[k] = v
+	}
+	return outputs
+}
+
+// ecsLoadBalancers registers the traffic port of a microservice's container
+// in its target group. It is empty without an attachment.
+func ecsLoadBalancers(component *ecsComponent, resource schema.Resource, attachment *LoadBalancerAttachment) []types.LoadBalancer {
+	loadBalancers := []types.LoadBalancer{}
+	ms, ok := resource.(*schema.MicroService)
+	if !ok || attachment == nil {
+		return loadBalancers
+	}
+	port, ok := ms.TrafficPort()
+	if !ok {
+		return loadBalancers
+	}
+	return append(loadBalancers, types.LoadBalancer{
+		TargetGroupArn: aws.String(attachment.TargetGroupARN),
+		ContainerName:  aws.String(component.name),
+		ContainerPort:  aws.Int32(int32(port.Port)),
+	})
+}
+
+// ecsGracePeriod returns how long new tasks may fail load balancer health
+// checks while they start
+func ecsGracePeriod(component *ecsComponent) int32 {
+	grace := ecsHealthCheckGracePeriod
+	if hc := component.healthCheck; hc != nil {
+		for _, probe := range []*schema.HealthCheckProbe{hc.Readiness, hc.Liveness} {
+			if probe != nil && probe.InitialDelaySeconds > grace {
+				grace = probe.InitialDelaySeconds
+			}
+		}
+	}
+	return int32(grace)
+}
+
+// ecsClusterName returns the name of the cluster shared by a tenant's
+// services
+func ecsClusterName(opts *provider.ResourceOptions) string {
+	if opts == nil || opts.TenantID == "" {
+		return "panka"
+	}
+	return "panka-" + opts.TenantID
+}
+
+// ecsServiceName returns the name of the ECS service and task definition
+// family of a component
+func ecsServiceName(name string, opts *provider.ResourceOptions) string {
+	return fmt.Sprintf("%s-%s-%s", opts.StackName, opts.ServiceName, name)
+}
+
+// parseECSResourceID splits a resource ID into cluster and service name.
+// IDs recorded without a cluster refer to the tenant's cluster.
+func parseECSResourceID(resourceID string, opts *provider.ResourceOptions) (string, string) {
+	if clusterName, serviceName, ok := strings.Cut(resourceID, "/"); ok {
+		return clusterName, serviceName
+	}
+	return ecsClusterName(opts), resourceID
+}
+
+// ecsWaitTime returns how long to wait for a service, up to the deadline of
+// the operation
+func ecsWaitTime(ctx context.Context) time.Duration {
+	if deadline, ok := ctx.Deadline(); ok {
+		if remaining := time.Until(deadline); remaining > 0 {
+			return remaining
+		}
+	}
+	return ecsDefaultWaitTime
+}
+
+// ecsTags converts tags into ECS tags, sorted by key
+func ecsTags(tags map[string]string) []types.Tag {
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	ecsTags := make([]types.Tag, 0, len(keys))
+	for _, k := range keys {
+		ecsTags = append(ecsTags, types.Tag{Key: aws.String(k), Value: aws.String(tags[k])})
+	}
+	return ecsTags
+}
+
+// isECSNotFound reports whether an error says that a service or cluster
+// does not exist
+func isECSNotFound(err error) bool {
+	var serviceNotFound *types.ServiceNotFoundException
+	var clusterNotFound *types.ClusterNotFoundException
+	return errors.As(err, &serviceNotFound) || errors.As(err, &clusterNotFound)
+}
+
+// ecsError wraps an ECS error
+func ecsError(operation, resourceID, message string, err error) *provider.ProviderError {
+	return &provider.ProviderError{
+		Provider:   "aws",
+		Operation:  operation,
+		ResourceID: resourceID,
+		Cause:      err,
+		Message:    message,
+	}
+}
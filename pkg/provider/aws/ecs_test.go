@@ -0,0 +1,723 @@
+package aws
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	autoscalingtypes "github.com/aws/aws-sdk-go-v2/service/applicationautoscaling/types"
+	"github.com/aws/aws-sdk-go-v2/service/ecs/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourusername/panka/internal/logger"
+	"github.com/yourusername/panka/pkg/parser/schema"
+	"github.com/yourusername/panka/pkg/provider"
+)
+
+// fakeECS serves the requests of the ECS, worker and cron job providers
+// for one cluster, whose services are stable as soon as they are created
+// or updated. It keeps the services, task definition revisions, IAM roles
+// and schedules, answers the other services with empty results, and
+// records the operations as <service>:<operation> with their input.
+type fakeECS struct {
+	mu         sync.Mutex
+	operations []string
+	inputs     map[string][]map[string]interface{}
+	forms      map[string]url.Values
+
+	cluster   bool
+	services  map[string]map[string]interface{}
+	revision  int
+	roles     map[string]bool
+	schedules map[string]map[string]interface{}
+}
+
+func newFakeECS() *fakeECS {
+	return &fakeECS{
+		inputs:    make(map[string][]map[string]interface{}),
+		forms:     make(map[string]url.Values),
+		services:  make(map[string]map[string]interface{}),
+		roles:     make(map[string]bool),
+		schedules: make(map[string]map[string]interface{}),
+	}
+}
+
+// fakeECSTargets are the services of JSON requests by target prefix
+var fakeECSTargets = map[string]string{
+	"AmazonEC2ContainerServiceV20141113": "ecs",
+	"AmazonSSM":                          "ssm",
+	"AnyScaleFrontendService":            "autoscaling",
+	"secretsmanager":                     "secretsmanager",
+}
+
+func (f *fakeECS) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	body, _ := io.ReadAll(r.Body)
+	var input map[string]interface{}
+
+	if name, ok := strings.CutPrefix(r.URL.Path, "/schedules/"); ok {
+		_ = json.Unmarshal(body, &input)
+		f.serveScheduler(w, r.Method, name, input)
+		return
+	}
+
+	prefix, operation, ok := strings.Cut(r.Header.Get("X-Amz-Target"), ".")
+	if !ok {
+		form, err := url.ParseQuery(string(body))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		f.serveQuery(w, form)
+		return
+	}
+
+	_ = json.Unmarshal(body, &input)
+	service := fakeECSTargets[prefix]
+	f.record(service+":"+operation, input)
+	w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+
+	switch {
+	case service == "ecs":
+		f.serveECS(w, operation, input)
+	case operation == "DeregisterScalableTarget":
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"__type": "ObjectNotFoundException", "Message": "No scalable target found"}`))
+	case operation == "DescribeSecret":
+		writeJSON(w, map[string]interface{}{"ARN": input["SecretId"]})
+	default:
+		_, _ = w.Write([]byte("{}"))
+	}
+}
+
+func (f *fakeECS) serveECS(w http.ResponseWriter, operation string, input map[string]interface{}) {
+	switch operation {
+	case "DescribeClusters":
+		clusters := []interface{}{}
+		if f.cluster {
+			clusters = append(clusters, map[string]interface{}{"clusterName": "panka-acme", "status": "ACTIVE"})
+		}
+		writeJSON(w, map[string]interface{}{"clusters": clusters})
+	case "CreateCluster":
+		f.cluster = true
+		writeJSON(w, map[string]interface{}{"cluster": map[string]interface{}{"status": "ACTIVE"}})
+	case "RegisterTaskDefinition":
+		f.revision++
+		writeJSON(w, map[string]interface{}{"taskDefinition": map[string]interface{}{
+			"taskDefinitionArn": fmt.Sprintf("arn:aws:ecs:us-east-1:123456789012:task-definition/%s:%d", input["family"], f.revision),
+		}})
+	case "CreateService":
+		name, _ := input["serviceName"].(string)
+		f.services[name] = map[string]interface{}{
+			"serviceName":    name,
+			"serviceArn":     "arn:aws:ecs:us-east-1:123456789012:service/panka-acme/" + name,
+			"status":         "ACTIVE",
+			"taskDefinition": input["taskDefinition"],
+			"desiredCount":   input["desiredCount"],
+			"runningCount":   input["desiredCount"],
+			"deployments":    []interface{}{map[string]interface{}{"status": "PRIMARY"}},
+		}
+		writeJSON(w, map[string]interface{}{"service": f.services[name]})
+	case "UpdateService", "DeleteService":
+		name, _ := input["service"].(string)
+		service, ok := f.services[name]
+		if !ok {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"__type": "ServiceNotFoundException", "message": "Service not found."}`))
+			return
+		}
+		for _, key := range []string{"taskDefinition", "desiredCount"} {
+			if value, ok := input[key]; ok {
+				service[key] = value
+			}
+		}
+		service["runningCount"] = service["desiredCount"]
+		if operation == "DeleteService" {
+			service["status"] = "INACTIVE"
+		}
+		writeJSON(w, map[string]interface{}{"service": service})
+	case "DescribeServices":
+		services, failures := []interface{}{}, []interface{}{}
+		names, _ := input["services"].([]interface{})
+		for _, name := range names {
+			if service, ok := f.services[name.(string)]; ok {
+				services = append(services, service)
+			} else {
+				failures = append(failures, map[string]interface{}{"arn": name, "reason": "MISSING"})
+			}
+		}
+		writeJSON(w, map[string]interface{}{"services": services, "failures": failures})
+	default:
+		_, _ = w.Write([]byte("{}"))
+	}
+}
+
+// serveQuery serves IAM and Elastic Load Balancing requests. Services
+// have no load balancer.
+func (f *fakeECS) serveQuery(w http.ResponseWriter, form url.Values) {
+	service := "elb"
+	if form.Get("Version") == "2010-05-08" {
+		service = "iam"
+	}
+	action := form.Get("Action")
+	f.record(service+":"+action, nil)
+	f.forms[service+":"+action] = form
+
+	name := form.Get("RoleName")
+	result := ""
+	switch action {
+	case "GetRole":
+		if !f.roles[name] {
+			writeQueryError(w, "NoSuchEntity")
+			return
+		}
+		result = fmt.Sprintf(testIAMRole, name, "<member><Key>panka:managed</Key><Value>true</Value></member>")
+	case "CreateRole":
+		f.roles[name] = true
+		result = fmt.Sprintf(testIAMRole, name, "")
+	case "DeleteRole":
+		delete(f.roles, name)
+	case "ListAttachedRolePolicies":
+		result = "<AttachedPolicies></AttachedPolicies><IsTruncated>false</IsTruncated>"
+	}
+
+	fmt.Fprintf(w, "<%sResponse><%[1]sResult>%s</%[1]sResult></%[1]sResponse>", action, result)
+}
+
+// serveScheduler serves EventBridge Scheduler requests for a schedule
+func (f *fakeECS) serveScheduler(w http.ResponseWriter, method, name string, input map[string]interface{}) {
+	operation := map[string]string{
+		http.MethodPost:   "CreateSchedule",
+		http.MethodPut:    "UpdateSchedule",
+		http.MethodGet:    "GetSchedule",
+		http.MethodDelete: "DeleteSchedule",
+	}[method]
+	f.record("scheduler:"+operation, input)
+
+	arn := "arn:aws:scheduler:us-east-1:123456789012:schedule/default/" + name
+	switch operation {
+	case "CreateSchedule", "UpdateSchedule":
+		input["Name"], input["Arn"] = name, arn
+		f.schedules[name] = input
+		writeJSON(w, map[string]interface{}{"ScheduleArn": arn})
+	case "GetSchedule":
+		schedule, ok := f.schedules[name]
+		if !ok {
+			w.Header().Set("X-Amzn-ErrorType", "ResourceNotFoundException")
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"Message": "Schedule does not exist."}`))
+			return
+		}
+		writeJSON(w, schedule)
+	case "DeleteSchedule":
+		delete(f.schedules, name)
+		_, _ = w.Write([]byte("{}"))
+	}
+}
+
+func (f *fakeECS) record(operation string, input map[string]interface{}) {
+	f.operations = append(f.operations, operation)
+	f.inputs[operation] = append(f.inputs[operation], input)
+}
+
+// calls returns the operations of a service called so far, leaving out
+// reads
+func (f *fakeECS) calls(service string) []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var calls []string
+	for _, operation := range f.operations {
+		name, ok := strings.CutPrefix(operation, service+":")
+		if !ok || strings.HasPrefix(name, "Describe") || strings.HasPrefix(name, "Get") || strings.HasPrefix(name, "List") {
+			continue
+		}
+		calls = append(calls, name)
+	}
+	return calls
+}
+
+// input returns the input of the last call of an operation
+func (f *fakeECS) input(operation string) map[string]interface{} {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	inputs := f.inputs[operation]
+	if len(inputs) == 0 {
+		return nil
+	}
+	return inputs[len(inputs)-1]
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func TestBuildTaskDefinition(t *testing.T) {
+	roles := taskRoles{
+		task:      "arn:aws:iam::123456789012:role/my-stack-backend-api-us-east-1-task",
+		execution: "arn:aws:iam::123456789012:role/my-stack-backend-api-us-east-1-execution",
+	}
+
+	api := schema.NewMicroService("api", "backend", "my-stack")
+	api.Spec.Image = schema.ImageConfig{Repository: "example/api", Tag: "1.2.0"}
+	api.Spec.Ports = []schema.Port{
+		{Name: "http", Port: 8080, Protocol: "tcp"},
+		{Name: "metrics", Port: 9090, Protocol: "UDP"},
+	}
+	api.Spec.Environment = []schema.EnvironmentVariable{{Name: "LOG_LEVEL", Value: "info"}}
+	api.Spec.Secrets = []schema.Secret{
+		{Name: "db-password", SecretRef: "arn:aws:secretsmanager:us-east-1:123456789012:secret:db", EnvVar: "DB_PASSWORD"},
+		{Name: "API_TOKEN", SecretRef: "arn:aws:secretsmanager:us-east-1:123456789012:secret:token"},
+	}
+	api.Spec.Command = []string{"/bin/api"}
+	api.Spec.Args = []string{"--port", "8080"}
+	api.Spec.HealthCheck = &schema.HealthCheck{
+		Readiness: &schema.HealthCheckProbe{HTTP: &schema.HTTPHealthCheck{Path: "/ready", Port: 8080}},
+	}
+	api.Infra = schema.NewComponentInfra("api", "backend", "my-stack")
+	api.Infra.Spec.Resources.CPU = 1024
+	api.Infra.Spec.Resources.Memory = 2048
+
+	web := schema.NewMicroService("web", "frontend", "my-stack")
+	web.Spec.Image = schema.ImageConfig{Repository: "nginx", Tag: "1.25-alpine"}
+
+	consumer := schema.NewWorker("consumer", "backend", "my-stack")
+	consumer.Spec.Image = schema.ImageConfig{Repository: "example/consumer", Tag: "2.0.0"}
+	consumer.Spec.StopTimeout = 90
+
+	report := schema.NewCronJob("report", "backend", "my-stack")
+	report.Spec.Image = schema.ImageConfig{Repository: "example/report", Tag: "1.0.0"}
+	report.Spec.Args = []string{"--since", "24h"}
+
+	tests := []struct {
+		name        string
+		resource    schema.Resource
+		roles       taskRoles
+		image       string
+		cpu         string
+		memory      string
+		ports       []types.PortMapping
+		environment map[string]string
+		secrets     map[string]string
+		entryPoint  []string
+		command     []string
+		healthCheck []string
+		stopTimeout *int32
+	}{
+		{
+			name:     "microservice",
+			resource: api,
+			roles:    roles,
+			image:    "example/api:1.2.0",
+			cpu:      "1024",
+			memory:   "2048",
+			ports: []types.PortMapping{
+				{Name: aws.String("http"), ContainerPort: aws.Int32(8080), Protocol: types.TransportProtocolTcp},
+				{Name: aws.String("metrics"), ContainerPort: aws.Int32(9090), Protocol: types.TransportProtocolUdp},
+			},
+			environment: map[string]string{"LOG_LEVEL": "info"},
+			secrets: map[string]string{
+				"DB_PASSWORD": "arn:aws:secretsmanager:us-east-1:123456789012:secret:db",
+				"API_TOKEN":   "arn:aws:secretsmanager:us-east-1:123456789012:secret:token",
+			},
+			entryPoint:  []string{"/bin/api"},
+			command:     []string{"--port", "8080"},
+			healthCheck: []string{"CMD-SHELL", "curl -fsk http://localhost:8080/ready || exit 1"},
+		},
+		{
+			name:     "microservice defaults",
+			resource: web,
+			image:    "nginx:1.25-alpine",
+			cpu:      "256",
+			memory:   "512",
+		},
+		{
+			name:        "worker",
+			resource:    consumer,
+			roles:       roles,
+			image:       "example/consumer:2.0.0",
+			cpu:         "256",
+			memory:      "512",
+			stopTimeout: aws.Int32(90),
+		},
+		{
+			name:     "cron job",
+			resource: report,
+			roles:    roles,
+			image:    "example/report:1.0.0",
+			cpu:      "256",
+			memory:   "512",
+			command:  []string{"--since", "24h"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			component, ok := ecsComponentOf(tt.resource)
+			require.True(t, ok)
+			input := buildTaskDefinition(component, "my-stack-backend-"+tt.resource.GetMetadata().Name, tt.roles)
+
+			assert.Equal(t, "my-stack-backend-"+tt.resource.GetMetadata().Name, aws.ToString(input.Family))
+			assert.Equal(t, tt.cpu, aws.ToString(input.Cpu))
+			assert.Equal(t, tt.memory, aws.ToString(input.Memory))
+			assert.Equal(t, types.NetworkModeAwsvpc, input.NetworkMode)
+			assert.Equal(t, []types.Compatibility{types.CompatibilityFargate}, input.RequiresCompatibilities)
+			if tt.roles.task == "" {
+				assert.Nil(t, input.TaskRoleArn)
+				assert.Nil(t, input.ExecutionRoleArn)
+			} else {
+				assert.Equal(t, tt.roles.task, aws.ToString(input.TaskRoleArn))
+				assert.Equal(t, tt.roles.execution, aws.ToString(input.ExecutionRoleArn))
+			}
+
+			require.Len(t, input.ContainerDefinitions, 1)
+			container := input.ContainerDefinitions[0]
+			assert.Equal(t, tt.resource.GetMetadata().Name, aws.ToString(container.Name))
+			assert.Equal(t, tt.image, aws.ToString(container.Image))
+			assert.True(t, aws.ToBool(container.Essential))
+			assert.Equal(t, tt.ports, container.PortMappings)
+			assert.Equal(t, tt.entryPoint, container.EntryPoint)
+			assert.Equal(t, tt.command, container.Command)
+			assert.Equal(t, tt.stopTimeout, container.StopTimeout)
+
+			environment := make(map[string]string)
+			for _, env := range container.Environment {
+				environment[aws.ToString(env.Name)] = aws.ToString(env.Value)
+			}
+			secrets := make(map[string]string)
+			for _, secret := range container.Secrets {
+				secrets[aws.ToString(secret.Name)] = aws.ToString(secret.ValueFrom)
+			}
+			if tt.environment == nil {
+				assert.Empty(t, environment)
+			} else {
+				assert.Equal(t, tt.environment, environment)
+			}
+			if tt.secrets == nil {
+				assert.Empty(t, secrets)
+			} else {
+				assert.Equal(t, tt.secrets, secrets)
+			}
+
+			if tt.healthCheck == nil {
+				assert.Nil(t, container.HealthCheck)
+			} else {
+				require.NotNil(t, container.HealthCheck)
+				assert.Equal(t, tt.healthCheck, container.HealthCheck.Command)
+			}
+		})
+	}
+}
+
+func TestBuildTaskDefinition_ConfigFiles(t *testing.T) {
+	ms := schema.NewMicroService("api", "backend", "my-stack")
+	ms.Spec.Configs = &schema.ConfigsMount{
+		MountPath: "/etc/api",
+		Files:     []string{"app.conf", "logging.yaml"},
+		Contents:  map[string]string{"app.conf": "workers = 4\n", "logging.yaml": "level: info\n"},
+	}
+
+	component, ok := ecsComponentOf(ms)
+	require.True(t, ok)
+
+	// Without stored files the task has no init container
+	input := buildTaskDefinition(component, "my-stack-backend-api", taskRoles{})
+	require.Len(t, input.ContainerDefinitions, 1)
+	assert.Empty(t, input.Volumes)
+
+	prefix := "arn:aws:ssm:us-east-1:123456789012:parameter" + configPath("panka-acme", "my-stack-backend-api")
+	component.configFiles = []configFile{
+		{name: "app.conf", parameterARN: prefix + "app.conf", hash: ms.Spec.Configs.FileHash("app.conf")},
+		{name: "logging.yaml", parameterARN: prefix + "logging.yaml", hash: ms.Spec.Configs.FileHash("logging.yaml")},
+	}
+	input = buildTaskDefinition(component, "my-stack-backend-api", taskRoles{})
+
+	require.Len(t, input.Volumes, 1)
+	assert.Equal(t, configVolume, *input.Volumes[0].Name)
+	require.Len(t, input.ContainerDefinitions, 2)
+
+	// The application mounts the files read-only once they are written
+	app := input.ContainerDefinitions[0]
+	assert.Equal(t, "api", *app.Name)
+	require.Len(t, app.MountPoints, 1)
+	assert.Equal(t, "/etc/api", *app.MountPoints[0].ContainerPath)
+	assert.True(t, *app.MountPoints[0].ReadOnly)
+	require.Len(t, app.DependsOn, 1)
+	assert.Equal(t, configInitContainer, *app.DependsOn[0].ContainerName)
+	assert.Equal(t, types.ContainerConditionSuccess, app.DependsOn[0].Condition)
+
+	// Content hashes change the task definition when a file changes
+	assert.Equal(t, map[string]string{
+		"panka.config.app.conf":     ms.Spec.Configs.FileHash("app.conf"),
+		"panka.config.logging.yaml": ms.Spec.Configs.FileHash("logging.yaml"),
+	}, app.DockerLabels)
+
+	initContainer := input.ContainerDefinitions[1]
+	assert.Equal(t, configInitContainer, *initContainer.Name)
+	assert.False(t, *initContainer.Essential)
+	require.Len(t, initContainer.Secrets, 2)
+	assert.Equal(t, "PANKA_CONFIG_0", *initContainer.Secrets[0].Name)
+	assert.Equal(t, prefix+"app.conf", *initContainer.Secrets[0].ValueFrom)
+	assert.Equal(t, []string{
+		"set -e\n" +
+			`printf '%s' "$PANKA_CONFIG_0" > /panka/configs/app.conf` + "\n" +
+			`printf '%s' "$PANKA_CONFIG_1" > /panka/configs/logging.yaml`,
+	}, initContainer.Command)
+
+	// The execution role can read the stored files
+	statements, err := secretStatements(configSecrets(component.configFiles))
+	require.NoError(t, err)
+	require.Len(t, statements, 1)
+	assert.Equal(t, []string{prefix + "app.conf", prefix + "logging.yaml"}, statements[0].Resource)
+}
+
+func TestECSScaling(t *testing.T) {
+	opts := &provider.ResourceOptions{TenantID: "acme", StackName: "my-stack", ServiceName: "backend"}
+
+	// Without autoscaling the desired count is fixed
+	ms := schema.NewMicroService("api", "backend", "my-stack")
+	ms.Infra = schema.NewComponentInfra("api", "backend", "my-stack")
+	ms.Infra.Spec.Scaling.Replicas = 3
+	config, err := ecsScaling(ms, "panka-acme", "my-stack-backend-api", opts)
+	require.NoError(t, err)
+	assert.Nil(t, config)
+
+	night := 1
+	ms.Infra.Spec.Scaling.AutoScaling = &schema.AutoScaling{
+		Enabled:             true,
+		MinReplicas:         4,
+		MaxReplicas:         12,
+		TargetCPUPercent:    60,
+		TargetMemoryPercent: 75,
+		ScaleInCooldown:     300,
+		Schedules: []schema.ScheduledScaling{
+			{Name: "night", Schedule: "0 22 * * 1-5", Timezone: "Europe/Berlin", MinCapacity: &night},
+		},
+	}
+	component, ok := ecsComponentOf(ms)
+	require.True(t, ok)
+	assert.True(t, component.autoscaled)
+	assert.Equal(t, 4, component.desiredCount)
+
+	config, err = ecsScaling(ms, "panka-acme", "my-stack-backend-api", opts)
+	require.NoError(t, err)
+	require.NotNil(t, config)
+	assert.Equal(t, "service/panka-acme/my-stack-backend-api", config.target.ResourceID)
+	assert.Equal(t, 4, *config.target.MinCapacity)
+	assert.Equal(t, 12, *config.target.MaxCapacity)
+
+	require.Len(t, config.policies, 2)
+	cpu := config.policies[0]
+	assert.Equal(t, "my-stack-backend-api-cpu", aws.ToString(cpu.PolicyName))
+	assert.Equal(t, "service/panka-acme/my-stack-backend-api", aws.ToString(cpu.ResourceId))
+	assert.Equal(t, autoscalingtypes.MetricTypeECSServiceAverageCPUUtilization, cpu.TargetTrackingScalingPolicyConfiguration.PredefinedMetricSpecification.PredefinedMetricType)
+	assert.Equal(t, float64(60), aws.ToFloat64(cpu.TargetTrackingScalingPolicyConfiguration.TargetValue))
+	assert.Equal(t, int32(300), aws.ToInt32(cpu.TargetTrackingScalingPolicyConfiguration.ScaleInCooldown))
+	assert.Equal(t, int32(defaultScalingCooldown), aws.ToInt32(cpu.TargetTrackingScalingPolicyConfiguration.ScaleOutCooldown))
+	assert.Equal(t, "my-stack-backend-api-memory", aws.ToString(config.policies[1].PolicyName))
+	assert.Equal(t, float64(75), aws.ToFloat64(config.policies[1].TargetTrackingScalingPolicyConfiguration.TargetValue))
+
+	// Schedules only move the bounds they set
+	require.Len(t, config.actions, 1)
+	action := config.actions[0]
+	assert.Equal(t, "my-stack-backend-api-night", aws.ToString(action.ScheduledActionName))
+	assert.Equal(t, "cron(0 22 ? * 2-6 *)", aws.ToString(action.Schedule))
+	assert.Equal(t, "Europe/Berlin", aws.ToString(action.Timezone))
+	assert.Equal(t, int32(1), aws.ToInt32(action.ScalableTargetAction.MinCapacity))
+	assert.Nil(t, action.ScalableTargetAction.MaxCapacity)
+
+	// CPU is tracked when no target is set
+	ms.Infra.Spec.Scaling.AutoScaling.TargetCPUPercent = 0
+	ms.Infra.Spec.Scaling.AutoScaling.TargetMemoryPercent = 0
+	config, err = ecsScaling(ms, "panka-acme", "my-stack-backend-api", opts)
+	require.NoError(t, err)
+	require.Len(t, config.policies, 1)
+	assert.Equal(t, float64(schema.DefaultTargetCPUPercent), aws.ToFloat64(config.policies[0].TargetTrackingScalingPolicyConfiguration.TargetValue))
+}
+
+func TestWithScalingOutputs(t *testing.T) {
+	outputs := map[string]string{"service_name": "my-stack-backend-api", "desired_count": "3"}
+	assert.Equal(t, outputs, withScalingOutputs(outputs, nil))
+
+	// Autoscaling changes the desired count, so the bounds are reported
+	target := ecsScalableTarget("panka-acme", "my-stack-backend-api", 2, 10)
+	assert.Equal(t, map[string]string{
+		"service_name": "my-stack-backend-api",
+		"min_replicas": "2",
+		"max_replicas": "10",
+	}, withScalingOutputs(outputs, &target))
+
+	// Bounds moved by scheduled actions are left out
+	outputs = map[string]string{"desired_count": "3"}
+	assert.Empty(t, withScalingOutputs(outputs, &scalableTarget{ResourceID: "service/panka-acme/my-stack-backend-api"}))
+}
+
+func TestECSHealthCheck(t *testing.T) {
+	check := ecsHealthCheck(&schema.HealthCheckProbe{
+		HTTP:                &schema.HTTPHealthCheck{Path: "health", Port: 8080},
+		PeriodSeconds:       10,
+		TimeoutSeconds:      5,
+		FailureThreshold:    3,
+		InitialDelaySeconds: 30,
+	})
+	require.NotNil(t, check)
+	assert.Equal(t, []string{"CMD-SHELL", "curl -fsk http://localhost:8080/health || exit 1"}, check.Command)
+	assert.Equal(t, int32(10), *check.Interval)
+	assert.Equal(t, int32(5), *check.Timeout)
+	assert.Equal(t, int32(3), *check.Retries)
+	assert.Equal(t, int32(30), *check.StartPeriod)
+
+	check = ecsHealthCheck(&schema.HealthCheckProbe{
+		Exec: &schema.ExecHealthCheck{Command: []string{"/bin/check", "--ready"}},
+	})
+	require.NotNil(t, check)
+	assert.Equal(t, []string{"CMD", "/bin/check", "--ready"}, check.Command)
+	assert.Nil(t, check.Interval)
+
+	assert.Nil(t, ecsHealthCheck(nil))
+	assert.Nil(t, ecsHealthCheck(&schema.HealthCheckProbe{}))
+}
+
+func TestECSNetworkConfiguration(t *testing.T) {
+	_, err := ecsNetworkConfiguration(nil)
+	assert.Error(t, err)
+
+	_, err = ecsNetworkConfiguration(&provider.Networking{VPCID: "vpc-1"})
+	assert.Error(t, err)
+
+	config, err := ecsNetworkConfiguration(&provider.Networking{
+		VPCID:            "vpc-1",
+		PrivateSubnetIDs: []string{"subnet-a", "subnet-b"},
+		PublicSubnetIDs:  []string{"subnet-public"},
+		SecurityGroupID:  "sg-1",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"subnet-a", "subnet-b"}, config.AwsvpcConfiguration.Subnets)
+	assert.Equal(t, []string{"sg-1"}, config.AwsvpcConfiguration.SecurityGroups)
+	assert.Equal(t, types.AssignPublicIpDisabled, config.AwsvpcConfiguration.AssignPublicIp)
+}
+
+func TestParseECSResourceID(t *testing.T) {
+	opts := &provider.ResourceOptions{TenantID: "acme"}
+
+	cluster, service := parseECSResourceID("panka-acme/my-stack-backend-api", opts)
+	assert.Equal(t, "panka-acme", cluster)
+	assert.Equal(t, "my-stack-backend-api", service)
+
+	// IDs recorded without a cluster
+	cluster, service = parseECSResourceID("my-stack-backend-api", opts)
+	assert.Equal(t, "panka-acme", cluster)
+	assert.Equal(t, "my-stack-backend-api", service)
+
+	assert.Equal(t, "panka", ecsClusterName(nil))
+}
+
+func TestECSProvider_WithRoleOutputs(t *testing.T) {
+	log, _ := logger.NewDevelopment()
+	ep := NewECSProvider(&Provider{logger: log, accountID: "123456789012", region: "us-east-1"})
+
+	outputs := ep.withRoleOutputs(map[string]string{"cluster_name": "panka-acme"}, "my-stack-backend-api")
+	assert.Equal(t, map[string]string{
+		"cluster_name":       "panka-acme",
+		"task_role_arn":      "arn:aws:iam::123456789012:role/my-stack-backend-api-us-east-1-task",
+		"execution_role_arn": "arn:aws:iam::123456789012:role/my-stack-backend-api-us-east-1-execution",
+	}, outputs)
+}
+
+func TestECSProvider_Lifecycle(t *testing.T) {
+	fake := newFakeECS()
+	ep := NewECSProvider(testServerProvider(t, fake.ServeHTTP))
+	opts := &provider.ResourceOptions{TenantID: "acme", StackName: "my-stack", ServiceName: "backend"}
+
+	ms := schema.NewMicroService("api", "backend", "my-stack")
+	ms.Spec.Image = schema.ImageConfig{Repository: "example/api", Tag: "1.2.0"}
+	ms.Spec.Secrets = []schema.Secret{
+		{Name: "db-password", SecretRef: "arn:aws:secretsmanager:us-east-1:123456789012:secret:db", EnvVar: "DB_PASSWORD"},
+	}
+
+	result, err := ep.Create(context.Background(), ms, opts)
+	require.NoError(t, err)
+	assert.Equal(t, "panka-acme/my-stack-backend-api", result.ResourceID)
+	assert.Equal(t, provider.StatusAvailable, result.Status)
+	assert.Equal(t, "my-stack-backend-api", result.Outputs["service_name"])
+	assert.Equal(t, "arn:aws:ecs:us-east-1:123456789012:task-definition/my-stack-backend-api:1", result.Outputs["task_definition_arn"])
+	assert.Equal(t, "1", result.Outputs["desired_count"])
+	assert.Equal(t, "arn:aws:iam::123456789012:role/my-stack-backend-api-us-east-1-task", result.Outputs["task_role_arn"])
+	assert.Equal(t, []string{"CreateCluster", "RegisterTaskDefinition", "CreateService"}, fake.calls("ecs"))
+	// The task role has no permissions without access grants
+	assert.Equal(t, []string{"CreateRole", "DeleteRolePolicy", "CreateRole", "AttachRolePolicy", "PutRolePolicy"}, fake.calls("iam"))
+	assert.Equal(t, "arn:aws:iam::aws:policy/service-role/AmazonECSTaskExecutionRolePolicy",
+		fake.forms["iam:AttachRolePolicy"].Get("PolicyArn"))
+
+	// Tasks run in the tenant's private subnets with the resolved secrets
+	create := fake.input("ecs:CreateService")
+	assert.Equal(t, "panka-acme", create["cluster"])
+	assert.Equal(t, "FARGATE", create["launchType"])
+	assert.Equal(t, map[string]interface{}{
+		"subnets":        []interface{}{"subnet-a", "subnet-b"},
+		"securityGroups": []interface{}{"sg-123"},
+		"assignPublicIp": "DISABLED",
+	}, create["networkConfiguration"].(map[string]interface{})["awsvpcConfiguration"])
+	container := fake.input("ecs:RegisterTaskDefinition")["containerDefinitions"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, []interface{}{map[string]interface{}{
+		"name":      "DB_PASSWORD",
+		"valueFrom": "arn:aws:secretsmanager:us-east-1:123456789012:secret:db",
+	}}, container["secrets"])
+
+	// A new image and replica count roll out a new revision, and a service
+	// without autoscaling has its scalable target removed
+	ms.Spec.Image.Tag = "1.3.0"
+	ms.Infra = schema.NewComponentInfra("api", "backend", "my-stack")
+	ms.Infra.Spec.Scaling.Replicas = 3
+	result, err = ep.Update(context.Background(), ms, opts)
+	require.NoError(t, err)
+	assert.Equal(t, "3", result.Outputs["desired_count"])
+	assert.Equal(t, []string{"CreateCluster", "RegisterTaskDefinition", "CreateService", "RegisterTaskDefinition", "UpdateService"}, fake.calls("ecs"))
+	update := fake.input("ecs:UpdateService")
+	assert.Equal(t, "arn:aws:ecs:us-east-1:123456789012:task-definition/my-stack-backend-api:2", update["taskDefinition"])
+	assert.Equal(t, float64(3), update["desiredCount"])
+	assert.Equal(t, []interface{}{}, update["loadBalancers"])
+	assert.Equal(t, []string{"DeregisterScalableTarget"}, fake.calls("autoscaling"))
+
+	// Creating an existing service updates it
+	_, err = ep.Create(context.Background(), ms, opts)
+	require.NoError(t, err)
+	assert.Equal(t, "UpdateService", fake.calls("ecs")[len(fake.calls("ecs"))-1])
+	assert.Equal(t, 1, strings.Count(strings.Join(fake.calls("ecs"), ","), "CreateService"))
+
+	// Deleting scales the service down first and removes its roles
+	_, err = ep.Delete(context.Background(), result.ResourceID, opts)
+	require.NoError(t, err)
+	calls := fake.calls("ecs")
+	assert.Equal(t, []string{"UpdateService", "DeleteService"}, calls[len(calls)-2:])
+	assert.Equal(t, float64(0), fake.input("ecs:UpdateService")["desiredCount"])
+	assert.Equal(t, true, fake.input("ecs:DeleteService")["force"])
+	assert.Empty(t, fake.roles)
+
+	exists, err := ep.Exists(context.Background(), result.ResourceID, opts)
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	// A service that is already gone is deleted
+	_, err = ep.Delete(context.Background(), "panka-acme/my-stack-backend-other", opts)
+	assert.NoError(t, err)
+}
+
+func TestECSProvider_CreateInvalidResource(t *testing.T) {
+	fake := newFakeECS()
+	ep := NewECSProvider(testServerProvider(t, fake.ServeHTTP))
+
+	_, err := ep.Create(context.Background(), schema.NewS3("uploads", "backend", "my-stack"), &provider.ResourceOptions{StackName: "my-stack"})
+	assert.Error(t, err)
+	assert.Empty(t, fake.operations)
+}
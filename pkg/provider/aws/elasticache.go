@@ -0,0 +1,219 @@
+package aws
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/elasticache"
+	"github.com/aws/aws-sdk-go-v2/service/elasticache/types"
+)
+
+const (
+	// elastiCacheDefaultWaitTime bounds the wait for a cache when the
+	// operation has no deadline
+	elastiCacheDefaultWaitTime = 60 * time.Minute
+
+	// replicationGroupIDMaxLength and cacheClusterIDMaxLength are the
+	// longest identifiers ElastiCache accepts
+	replicationGroupIDMaxLength = 40
+	cacheClusterIDMaxLength     = 50
+)
+
+// elastiCachePollInterval is how often a cache is described while waiting
+// for it
+var elastiCachePollInterval = 15 * time.Second
+
+// elastiCacheClient wraps the ElastiCache client with the lookups and
+// waits shared by the Redis and Memcached providers
+type elastiCacheClient struct {
+	client *elasticache.Client
+}
+
+// newElastiCacheClient creates an ElastiCache client
+func newElastiCacheClient(p *Provider) *elastiCacheClient {
+	return &elastiCacheClient{
+		client: elasticache.NewFromConfig(p.GetConfig()),
+	}
+}
+
+// describeReplicationGroup returns a replication group, or nil if it does
+// not exist
+func (c *elastiCacheClient) describeReplicationGroup(ctx context.Context, id string) (*types.ReplicationGroup, error) {
+	output, err := c.client.DescribeReplicationGroups(ctx, &elasticache.DescribeReplicationGroupsInput{
+		ReplicationGroupId: aws.String(id),
+	})
+	if err != nil {
+		var notFound *types.ReplicationGroupNotFoundFault
+		if errors.As(err, &notFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if len(output.ReplicationGroups) == 0 {
+		return nil, nil
+	}
+	return &output.ReplicationGroups[0], nil
+}
+
+// describeCacheCluster returns a cache cluster with its nodes, or nil if it
+// does not exist
+func (c *elastiCacheClient) describeCacheCluster(ctx context.Context, id string) (*types.CacheCluster, error) {
+	output, err := c.client.DescribeCacheClusters(ctx, &elasticache.DescribeCacheClustersInput{
+		CacheClusterId:    aws.String(id),
+		ShowCacheNodeInfo: aws.Bool(true),
+	})
+	if err != nil {
+		var notFound *types.CacheClusterNotFoundFault
+		if errors.As(err, &notFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if len(output.CacheClusters) == 0 {
+		return nil, nil
+	}
+	return &output.CacheClusters[0], nil
+}
+
+// ensureSubnetGroup creates the cache subnet group of a cache in the
+// tenant's private subnets unless it exists
+func (c *elastiCacheClient) ensureSubnetGroup(ctx context.Context, name string, subnetIDs []string, tags []types.Tag) error {
+	_, err := c.client.CreateCacheSubnetGroup(ctx, &elasticache.CreateCacheSubnetGroupInput{
+		CacheSubnetGroupName:        aws.String(name),
+		CacheSubnetGroupDescription: aws.String("Private subnets of " + name),
+		SubnetIds:                   subnetIDs,
+		Tags:                        tags,
+	})
+	var exists *types.CacheSubnetGroupAlreadyExistsFault
+	if errors.As(err, &exists) {
+		return nil
+	}
+	return err
+}
+
+// deleteSubnetGroup deletes a cache subnet group, ignoring one that does not
+// exist
+func (c *elastiCacheClient) deleteSubnetGroup(ctx context.Context, name string) error {
+	_, err := c.client.DeleteCacheSubnetGroup(ctx, &elasticache.DeleteCacheSubnetGroupInput{
+		CacheSubnetGroupName: aws.String(name),
+	})
+	var notFound *types.CacheSubnetGroupNotFoundFault
+	if errors.As(err, &notFound) {
+		return nil
+	}
+	return err
+}
+
+// waitReplicationGroup waits until a replication group is available, or
+// until it is gone when deleted is set
+func (c *elastiCacheClient) waitReplicationGroup(ctx context.Context, id string, deleted bool) error {
+	return waitElastiCache(ctx, func() (bool, error) {
+		group, err := c.describeReplicationGroup(ctx, id)
+		if err != nil {
+			return false, err
+		}
+		if group == nil {
+			if deleted {
+				return true, nil
+			}
+			return false, fmt.Errorf("replication group %s not found", id)
+		}
+		status := aws.ToString(group.Status)
+		if !deleted && status == "create-failed" {
+			return false, fmt.Errorf("replication group %s failed to create", id)
+		}
+		return !deleted && status == "available", nil
+	})
+}
+
+// waitCacheCluster waits until a cache cluster is available, or until it is
+// gone when deleted is set
+func (c *elastiCacheClient) waitCacheCluster(ctx context.Context, id string, deleted bool) error {
+	return waitElastiCache(ctx, func() (bool, error) {
+		cluster, err := c.describeCacheCluster(ctx, id)
+		if err != nil {
+			return false, err
+		}
+		if cluster == nil {
+			if deleted {
+				return true, nil
+			}
+			return false, fmt.Errorf("cache cluster %s not found", id)
+		}
+		status := aws.ToString(cluster.CacheClusterStatus)
+		if !deleted && status == "create-failed" {
+			return false, fmt.Errorf("cache cluster %s failed to create", id)
+		}
+		return !deleted && status == "available", nil
+	})
+}
+
+// waitElastiCache calls done until it reports true, up to the deadline of
+// the operation
+func waitElastiCache(ctx context.Context, done func() (bool, error)) error {
+	waitCtx, cancel := context.WithTimeout(ctx, elastiCacheWaitTime(ctx))
+	defer cancel()
+
+	for {
+		ok, err := done()
+		if err != nil {
+			return err
+		}
+		if ok {
+			return nil
+		}
+
+		select {
+		case <-waitCtx.Done():
+			return fmt.Errorf("timed out waiting for cache: %w", waitCtx.Err())
+		case <-time.After(elastiCachePollInterval):
+		}
+	}
+}
+
+// elastiCacheWaitTime returns how long to wait for a cache, up to the
+// deadline of the operation
+func elastiCacheWaitTime(ctx context.Context) time.Duration {
+	if deadline, ok := ctx.Deadline(); ok {
+		if remaining := time.Until(deadline); remaining > 0 {
+			return remaining
+		}
+	}
+	return elastiCacheDefaultWaitTime
+}
+
+// elastiCacheTags converts tags into ElastiCache tags, sorted by key
+func elastiCacheTags(tags map[string]string) []types.Tag {
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	cacheTags := make([]types.Tag, 0, len(keys))
+	for _, k := range keys {
+		cacheTags = append(cacheTags, types.Tag{Key: aws.String(k), Value: aws.String(tags[k])})
+	}
+	return cacheTags
+}
+
+// shortName returns a name that fits the length limit of an AWS resource:
+// lower case, and shortened with a hash of the full name when it is too long
+func shortName(name string, maxLength int) string {
+	id := strings.ToLower(name)
+	if len(id) <= maxLength {
+		return id
+	}
+
+	sum := sha256.Sum256([]byte(id))
+	suffix := hex.EncodeToString(sum[:])[:8]
+	prefix := strings.TrimRight(id[:maxLength-len(suffix)-1], "-")
+	return prefix + "-" + suffix
+}
@@ -0,0 +1,449 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/elasticache/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourusername/panka/pkg/parser/schema"
+	"github.com/yourusername/panka/pkg/provider"
+)
+
+const testReplicationGroup = `<ReplicationGroup>
+  <ReplicationGroupId>%s</ReplicationGroupId>
+  <ARN>arn:aws:elasticache:us-east-1:123456789012:replicationgroup:%[1]s</ARN>
+  <Status>available</Status>
+  <CacheNodeType>%s</CacheNodeType>
+  <ClusterEnabled>false</ClusterEnabled>
+  <AuthTokenEnabled>false</AuthTokenEnabled>
+  <TransitEncryptionEnabled>true</TransitEncryptionEnabled>
+  <AtRestEncryptionEnabled>true</AtRestEncryptionEnabled>
+  <MemberClusters>
+    <ClusterId>%[1]s-001</ClusterId>
+    <ClusterId>%[1]s-002</ClusterId>
+  </MemberClusters>
+  <NodeGroups>
+    <NodeGroup>
+      <NodeGroupId>0001</NodeGroupId>
+      <PrimaryEndpoint><Address>master.sessions.cache.amazonaws.com</Address><Port>6379</Port></PrimaryEndpoint>
+      <ReaderEndpoint><Address>replica.sessions.cache.amazonaws.com</Address><Port>6379</Port></ReaderEndpoint>
+      <NodeGroupMembers>
+        <NodeGroupMember><CacheClusterId>%[1]s-001</CacheClusterId><CurrentRole>primary</CurrentRole></NodeGroupMember>
+        <NodeGroupMember><CacheClusterId>%[1]s-002</CacheClusterId><CurrentRole>replica</CurrentRole></NodeGroupMember>
+      </NodeGroupMembers>
+    </NodeGroup>
+  </NodeGroups>
+</ReplicationGroup>`
+
+const testCacheCluster = `<CacheCluster>
+  <CacheClusterId>%s</CacheClusterId>
+  <ARN>arn:aws:elasticache:us-east-1:123456789012:cluster:%[1]s</ARN>
+  <CacheClusterStatus>available</CacheClusterStatus>
+  <CacheNodeType>cache.t4g.micro</CacheNodeType>
+  <Engine>memcached</Engine>
+  <EngineVersion>1.6.22</EngineVersion>
+  <NumCacheNodes>%d</NumCacheNodes>
+  <TransitEncryptionEnabled>false</TransitEncryptionEnabled>
+  <ConfigurationEndpoint><Address>pages.cfg.cache.amazonaws.com</Address><Port>11211</Port></ConfigurationEndpoint>
+  <CacheNodes>%s</CacheNodes>
+</CacheCluster>`
+
+// fakeElastiCache serves the ElastiCache actions of the providers for one
+// replication group or cache cluster, which are available as soon as they
+// are created
+type fakeElastiCache struct {
+	mu      sync.Mutex
+	actions []string
+	forms   map[string]url.Values
+	group   string
+	cluster string
+}
+
+func newFakeElastiCache() *fakeElastiCache {
+	return &fakeElastiCache{forms: make(map[string]url.Values)}
+}
+
+func (f *fakeElastiCache) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	action := r.Form.Get("Action")
+	f.actions = append(f.actions, action)
+	f.forms[action] = r.Form
+
+	result := ""
+	switch action {
+	case "DescribeReplicationGroups":
+		if f.group == "" {
+			writeQueryError(w, "ReplicationGroupNotFoundFault")
+			return
+		}
+		result = "<ReplicationGroups>" + f.group + "</ReplicationGroups>"
+	case "CreateReplicationGroup":
+		f.group = fmt.Sprintf(testReplicationGroup, r.Form.Get("ReplicationGroupId"), r.Form.Get("CacheNodeType"))
+	case "DeleteReplicationGroup":
+		f.group = ""
+	case "DescribeCacheClusters":
+		if f.cluster == "" {
+			writeQueryError(w, "CacheClusterNotFound")
+			return
+		}
+		result = "<CacheClusters>" + f.cluster + "</CacheClusters>"
+	case "CreateCacheCluster":
+		f.cluster = testCacheClusterXML(r.Form.Get("CacheClusterId"), 3)
+	case "DeleteCacheCluster":
+		f.cluster = ""
+	}
+
+	fmt.Fprintf(w, "<%sResponse><%[1]sResult>%s</%[1]sResult></%[1]sResponse>", action, result)
+}
+
+// calls returns the actions called so far, leaving out describes
+func (f *fakeElastiCache) calls() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var calls []string
+	for _, action := range f.actions {
+		if !strings.HasPrefix(action, "Describe") {
+			calls = append(calls, action)
+		}
+	}
+	return calls
+}
+
+func testCacheClusterXML(id string, nodes int) string {
+	var cacheNodes strings.Builder
+	for i := 1; i <= nodes; i++ {
+		fmt.Fprintf(&cacheNodes, "<CacheNode><CacheNodeId>%04d</CacheNodeId></CacheNode>", i)
+	}
+	return fmt.Sprintf(testCacheCluster, id, nodes, cacheNodes.String())
+}
+
+func writeQueryError(w http.ResponseWriter, code string) {
+	w.Header().Set("Content-Type", "text/xml")
+	w.WriteHeader(http.StatusNotFound)
+	fmt.Fprintf(w, "<ErrorResponse><Error><Type>Sender</Type><Code>%s</Code><Message>not found</Message></Error></ErrorResponse>", code)
+}
+
+func noElastiCacheWait(t *testing.T) {
+	interval := elastiCachePollInterval
+	elastiCachePollInterval = 0
+	t.Cleanup(func() { elastiCachePollInterval = interval })
+}
+
+func TestElastiCacheRedisProvider_Lifecycle(t *testing.T) {
+	noElastiCacheWait(t)
+	fake := newFakeElastiCache()
+	rp := NewElastiCacheRedisProvider(testServerProvider(t, fake.ServeHTTP))
+	opts := &provider.ResourceOptions{StackName: "my-stack", ServiceName: "backend"}
+
+	redis := schema.NewElastiCacheRedis("sessions", "backend", "my-stack")
+	redis.Spec.ReplicasPerShard = 1
+
+	result, err := rp.Create(context.Background(), redis, opts)
+	require.NoError(t, err)
+	assert.Equal(t, "my-stack-backend-sessions", result.ResourceID)
+	assert.Equal(t, provider.StatusAvailable, result.Status)
+	assert.Equal(t, "master.sessions.cache.amazonaws.com", result.Outputs["primary_endpoint"])
+	assert.Equal(t, "replica.sessions.cache.amazonaws.com", result.Outputs["reader_endpoint"])
+	assert.Equal(t, "6379", result.Outputs["port"])
+	assert.Equal(t, "1", result.Outputs["replicas_per_shard"])
+	assert.Equal(t, []string{"CreateCacheSubnetGroup", "CreateReplicationGroup"}, fake.calls())
+
+	subnetGroup := fake.forms["CreateCacheSubnetGroup"]
+	assert.Equal(t, "subnet-a", subnetGroup.Get("SubnetIds.SubnetIdentifier.1"))
+	assert.Equal(t, "subnet-b", subnetGroup.Get("SubnetIds.SubnetIdentifier.2"))
+	create := fake.forms["CreateReplicationGroup"]
+	assert.Equal(t, "sg-123", create.Get("SecurityGroupIds.SecurityGroupId.1"))
+	assert.Equal(t, "2", create.Get("NumCacheClusters"))
+	assert.Equal(t, "my-stack-backend-sessions", create.Get("CacheSubnetGroupName"))
+
+	// A larger node type and no replicas turn off failover before the
+	// replica is removed
+	redis.Spec.NodeType = "cache.t4g.small"
+	redis.Spec.ReplicasPerShard = 0
+	_, err = rp.Update(context.Background(), redis, opts)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"CreateCacheSubnetGroup", "CreateReplicationGroup",
+		"ModifyReplicationGroup", "ModifyReplicationGroup", "DecreaseReplicaCount",
+	}, fake.calls())
+	assert.Equal(t, "false", fake.forms["ModifyReplicationGroup"].Get("AutomaticFailoverEnabled"))
+	assert.Equal(t, "0", fake.forms["DecreaseReplicaCount"].Get("NewReplicaCount"))
+
+	_, err = rp.Delete(context.Background(), result.ResourceID, opts)
+	require.NoError(t, err)
+	assert.Equal(t, "DeleteCacheSubnetGroup", fake.calls()[len(fake.calls())-1])
+
+	exists, err := rp.Exists(context.Background(), result.ResourceID, opts)
+	require.NoError(t, err)
+	assert.False(t, exists)
+}
+
+func TestElastiCacheMemcachedProvider_Lifecycle(t *testing.T) {
+	noElastiCacheWait(t)
+	fake := newFakeElastiCache()
+	mp := NewElastiCacheMemcachedProvider(testServerProvider(t, fake.ServeHTTP))
+	opts := &provider.ResourceOptions{StackName: "my-stack", ServiceName: "backend"}
+
+	memcached := schema.NewElastiCacheMemcached("pages", "backend", "my-stack")
+	memcached.Spec.Nodes = 3
+
+	result, err := mp.Create(context.Background(), memcached, opts)
+	require.NoError(t, err)
+	assert.Equal(t, "my-stack-backend-pages", result.ResourceID)
+	assert.Equal(t, "pages.cfg.cache.amazonaws.com", result.Outputs["primary_endpoint"])
+	assert.Equal(t, "3", result.Outputs["num_nodes"])
+	assert.Equal(t, "cross-az", fake.forms["CreateCacheCluster"].Get("AZMode"))
+
+	// Creating it again updates the existing cluster, which already has
+	// its nodes
+	_, err = mp.Create(context.Background(), memcached, opts)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"CreateCacheSubnetGroup", "CreateCacheCluster"}, fake.calls())
+
+	memcached.Spec.Nodes = 1
+	_, err = mp.Update(context.Background(), memcached, opts)
+	require.NoError(t, err)
+	modify := fake.forms["ModifyCacheCluster"]
+	require.NotNil(t, modify)
+	assert.Equal(t, "1", modify.Get("NumCacheNodes"))
+	assert.Equal(t, "0002", modify.Get("CacheNodeIdsToRemove.CacheNodeId.1"))
+	assert.Equal(t, "0003", modify.Get("CacheNodeIdsToRemove.CacheNodeId.2"))
+
+	_, err = mp.Delete(context.Background(), result.ResourceID, opts)
+	require.NoError(t, err)
+	calls := fake.calls()
+	assert.Equal(t, []string{"DeleteCacheCluster", "DeleteCacheSubnetGroup"}, calls[len(calls)-2:])
+}
+
+func TestElastiCacheRedisProvider_CreateWithoutNetworking(t *testing.T) {
+	fake := newFakeElastiCache()
+	awsProvider := testServerProvider(t, fake.ServeHTTP)
+	awsProvider.awsConfig = nil
+	rp := NewElastiCacheRedisProvider(awsProvider)
+
+	_, err := rp.Create(context.Background(), schema.NewElastiCacheRedis("sessions", "backend", "my-stack"),
+		&provider.ResourceOptions{StackName: "my-stack", ServiceName: "backend"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "private subnets")
+	assert.Empty(t, fake.calls())
+}
+
+func TestReplicationGroupInput(t *testing.T) {
+	tests := []struct {
+		name             string
+		shards           int
+		replicas         int
+		authToken        string
+		numCacheClusters *int32
+		numNodeGroups    *int32
+		parameterGroup   *string
+		failover         bool
+		multiAZ          bool
+	}{
+		{
+			name:             "single shard with a replica",
+			replicas:         1,
+			authToken:        "token",
+			numCacheClusters: aws.Int32(2),
+			failover:         true,
+			multiAZ:          true,
+		},
+		{
+			name:             "single shard without replicas",
+			numCacheClusters: aws.Int32(1),
+		},
+		{
+			name:           "cluster mode",
+			shards:         3,
+			numNodeGroups:  aws.Int32(3),
+			parameterGroup: aws.String("default.redis7.cluster.on"),
+			failover:       true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			redis := schema.NewElastiCacheRedis("sessions", "backend", "my-stack")
+			redis.Spec.EngineVersion = "7.1"
+			redis.Spec.Shards = tt.shards
+			redis.Spec.ReplicasPerShard = tt.replicas
+
+			input := replicationGroupInput(redis, "my-stack-backend-sessions", tt.authToken, []string{"sg-123"},
+				elastiCacheTags(map[string]string{"panka:stack": "my-stack"}))
+
+			assert.Equal(t, "redis", aws.ToString(input.Engine))
+			assert.Equal(t, "7.1", aws.ToString(input.EngineVersion))
+			assert.Equal(t, "cache.t4g.micro", aws.ToString(input.CacheNodeType))
+			assert.Equal(t, "my-stack-backend-sessions", aws.ToString(input.CacheSubnetGroupName))
+			assert.Equal(t, int32(6379), aws.ToInt32(input.Port))
+			assert.Equal(t, tt.numCacheClusters, input.NumCacheClusters)
+			assert.Equal(t, tt.numNodeGroups, input.NumNodeGroups)
+			assert.Equal(t, tt.parameterGroup, input.CacheParameterGroupName)
+			assert.Equal(t, tt.failover, aws.ToBool(input.AutomaticFailoverEnabled))
+			assert.Equal(t, tt.multiAZ, aws.ToBool(input.MultiAZEnabled))
+			assert.True(t, aws.ToBool(input.AtRestEncryptionEnabled))
+			assert.True(t, aws.ToBool(input.TransitEncryptionEnabled))
+			assert.Equal(t, optionalString(tt.authToken), input.AuthToken)
+			assert.Equal(t, []string{"sg-123"}, input.SecurityGroupIds)
+			assert.Equal(t, "panka:stack", aws.ToString(input.Tags[0].Key))
+		})
+	}
+}
+
+func TestShardConfigurationInput(t *testing.T) {
+	group := &types.ReplicationGroup{
+		ReplicationGroupId: aws.String("cache"),
+		NodeGroups: []types.NodeGroup{
+			{NodeGroupId: aws.String("0003")},
+			{NodeGroupId: aws.String("0001")},
+			{NodeGroupId: aws.String("0002")},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		shards  int
+		removed []string
+	}{
+		{"more shards", 4, nil},
+		{"fewer shards", 2, []string{"0003"}},
+		{"one shard", 1, []string{"0002", "0003"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := shardConfigurationInput(group, tt.shards)
+			assert.Equal(t, int32(tt.shards), aws.ToInt32(input.NodeGroupCount))
+			assert.Equal(t, tt.removed, input.NodeGroupsToRemove)
+			assert.True(t, aws.ToBool(input.ApplyImmediately))
+		})
+	}
+}
+
+func TestReplicationGroupOutputs_ClusterMode(t *testing.T) {
+	endpoint := &types.Endpoint{Address: aws.String("clustercfg.cache.amazonaws.com"), Port: aws.Int32(6380)}
+	members := []types.NodeGroupMember{{}, {}, {}}
+	outputs := replicationGroupOutputs(&types.ReplicationGroup{
+		ReplicationGroupId:    aws.String("my-stack-backend-cache"),
+		ClusterEnabled:        aws.Bool(true),
+		ConfigurationEndpoint: endpoint,
+		NodeGroups: []types.NodeGroup{
+			{NodeGroupId: aws.String("0001"), NodeGroupMembers: members},
+			{NodeGroupId: aws.String("0002"), NodeGroupMembers: members},
+		},
+	})
+
+	assert.Equal(t, "clustercfg.cache.amazonaws.com", outputs["primary_endpoint"])
+	assert.Equal(t, "clustercfg.cache.amazonaws.com", outputs["reader_endpoint"])
+	assert.Equal(t, "6380", outputs["port"])
+	assert.Equal(t, "2", outputs["num_shards"])
+	assert.Equal(t, "2", outputs["replicas_per_shard"])
+}
+
+func TestRedisClusterParameterGroup(t *testing.T) {
+	assert.Equal(t, "default.redis7.cluster.on", redisClusterParameterGroup(""))
+	assert.Equal(t, "default.redis7.cluster.on", redisClusterParameterGroup("7.1"))
+	assert.Equal(t, "default.redis6.x.cluster.on", redisClusterParameterGroup("6.2"))
+	assert.Equal(t, "default.redis5.0.cluster.on", redisClusterParameterGroup("5.0.6"))
+}
+
+func TestCacheClusterInput(t *testing.T) {
+	tests := []struct {
+		name   string
+		nodes  int
+		azMode types.AZMode
+	}{
+		{"one node", 1, ""},
+		{"nodes across zones", 3, types.AZModeCrossAz},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			memcached := schema.NewElastiCacheMemcached("pages", "backend", "my-stack")
+			memcached.Spec.Nodes = tt.nodes
+			memcached.Spec.EngineVersion = "1.6"
+
+			input := cacheClusterInput(memcached, "my-stack-backend-pages", []string{"sg-123"}, nil)
+			assert.Equal(t, "memcached", aws.ToString(input.Engine))
+			assert.Equal(t, "1.6", aws.ToString(input.EngineVersion))
+			assert.Equal(t, int32(tt.nodes), aws.ToInt32(input.NumCacheNodes))
+			assert.Equal(t, tt.azMode, input.AZMode)
+			assert.Equal(t, int32(11211), aws.ToInt32(input.Port))
+			assert.False(t, aws.ToBool(input.TransitEncryptionEnabled))
+		})
+	}
+}
+
+func TestModifyCacheClusterInput(t *testing.T) {
+	cluster := &types.CacheCluster{
+		CacheClusterId: aws.String("my-stack-backend-pages"),
+		EngineVersion:  aws.String("1.6.22"),
+		NumCacheNodes:  aws.Int32(3),
+		CacheNodes: []types.CacheNode{
+			{CacheNodeId: aws.String("0003")},
+			{CacheNodeId: aws.String("0001")},
+			{CacheNodeId: aws.String("0002")},
+		},
+	}
+
+	tests := []struct {
+		name          string
+		nodes         int
+		version       string
+		unchanged     bool
+		numCacheNodes *int32
+		removed       []string
+		engineVersion *string
+	}{
+		{name: "unchanged", nodes: 3, version: "1.6", unchanged: true},
+		{name: "fewer nodes", nodes: 1, version: "1.6", numCacheNodes: aws.Int32(1), removed: []string{"0002", "0003"}},
+		{name: "more nodes", nodes: 4, numCacheNodes: aws.Int32(4)},
+		{name: "engine version", nodes: 3, version: "1.7", engineVersion: aws.String("1.7")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			memcached := schema.NewElastiCacheMemcached("pages", "backend", "my-stack")
+			memcached.Spec.Nodes = tt.nodes
+			memcached.Spec.EngineVersion = tt.version
+
+			input := modifyCacheClusterInput(memcached, cluster)
+			if tt.unchanged {
+				assert.Nil(t, input)
+				return
+			}
+			require.NotNil(t, input)
+			assert.Equal(t, tt.numCacheNodes, input.NumCacheNodes)
+			assert.Equal(t, tt.removed, input.CacheNodeIdsToRemove)
+			assert.Equal(t, tt.engineVersion, input.EngineVersion)
+			assert.True(t, aws.ToBool(input.ApplyImmediately))
+		})
+	}
+}
+
+func TestShortName(t *testing.T) {
+	assert.Equal(t, "my-stack-backend-cache", shortName("My-Stack-backend-cache", replicationGroupIDMaxLength))
+
+	long := "a-very-long-stack-name-backend-service-session-cache"
+	id := shortName(long, replicationGroupIDMaxLength)
+	assert.LessOrEqual(t, len(id), replicationGroupIDMaxLength)
+	assert.False(t, strings.Contains(id, "--"))
+	assert.True(t, strings.HasPrefix(id, "a-very-long-stack-name-backend"))
+	assert.NotEqual(t, id, shortName(long+"-2", replicationGroupIDMaxLength))
+	assert.Equal(t, id, shortName(long, replicationGroupIDMaxLength))
+}
@@ -0,0 +1,466 @@
+package aws
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	"github.com/aws/aws-sdk-go-v2/service/iam/types"
+	"github.com/aws/smithy-go"
+	"github.com/yourusername/panka/pkg/parser/schema"
+	"github.com/yourusername/panka/pkg/secrets"
+	"go.uber.org/zap"
+)
+
+const (
+	// rolePolicyName is the name of the inline policy of the roles panka
+	// creates
+	rolePolicyName = "panka"
+
+	// roleNameMaxLength is the longest role name IAM accepts
+	roleNameMaxLength = 64
+
+	// rolePropagationTimeout bounds the wait for a new role to be usable by
+	// the service that assumes it
+	rolePropagationTimeout = 2 * time.Minute
+
+	// managedRoleTag marks the roles panka creates, which are the only
+	// roles it changes
+	managedRoleTag = "panka:managed"
+
+	// AWS managed policies of workload roles, by name
+	lambdaBasicExecutionPolicy = "service-role/AWSLambdaBasicExecutionRole"
+	lambdaVPCAccessPolicy      = "service-role/AWSLambdaVPCAccessExecutionRole"
+	ecsTaskExecutionPolicy     = "service-role/AmazonECSTaskExecutionRolePolicy"
+)
+
+// rolePropagationInterval is how often an operation is retried while a new
+// role is not usable yet
+var rolePropagationInterval = 5 * time.Second
+
+// iamRoles creates and deletes the IAM roles of workloads. Each role trusts
+// one service, has the managed policies it needs and an inline policy with
+// the permissions derived from the stack.
+type iamRoles struct {
+	provider *Provider
+	client   *iam.Client
+}
+
+// newIAMRoles creates the IAM role manager of a provider
+func newIAMRoles(p *Provider) *iamRoles {
+	return &iamRoles{
+		provider: p,
+		client:   iam.NewFromConfig(p.GetConfig()),
+	}
+}
+
+// roleSpec describes a role of a workload
+type roleSpec struct {
+	Name            string
+	Description     string
+	Service         string
+	ManagedPolicies []string
+	Statements      []policyStatement
+	Tags            map[string]string
+}
+
+// policyDocument is an IAM policy
+type policyDocument struct {
+	Version   string            `json:"Version"`
+	Statement []policyStatement `json:"Statement"`
+}
+
+// policyStatement is a statement of an IAM policy
+type policyStatement struct {
+	Effect    string            `json:"Effect"`
+	Principal map[string]string `json:"Principal,omitempty"`
+	Action    []string          `json:"Action"`
+	Resource  []string          `json:"Resource,omitempty"`
+}
+
+// ensure creates a role or brings an existing one to its spec, and returns
+// its ARN. An existing role that panka did not create is left alone.
+func (r *iamRoles) ensure(ctx context.Context, spec roleSpec) (string, error) {
+	trust, err := json.Marshal(trustPolicy(spec.Service))
+	if err != nil {
+		return "", fmt.Errorf("failed to encode trust policy: %w", err)
+	}
+
+	var roleARN string
+
+	existing, err := r.client.GetRole(ctx, &iam.GetRoleInput{RoleName: aws.String(spec.Name)})
+	switch {
+	case err == nil:
+		if !isManagedRole(existing.Role.Tags) {
+			return "", fmt.Errorf("role %s already exists and is not managed by panka", spec.Name)
+		}
+		roleARN = aws.ToString(existing.Role.Arn)
+		if _, err := r.client.UpdateAssumeRolePolicy(ctx, &iam.UpdateAssumeRolePolicyInput{
+			RoleName:       aws.String(spec.Name),
+			PolicyDocument: aws.String(string(trust)),
+		}); err != nil {
+			return "", fmt.Errorf("failed to update the trust policy of role %s: %w", spec.Name, err)
+		}
+
+	case isAPIErrorCode(err, "NoSuchEntity"):
+		result, err := r.client.CreateRole(ctx, &iam.CreateRoleInput{
+			RoleName:                 aws.String(spec.Name),
+			Description:              aws.String(spec.Description),
+			AssumeRolePolicyDocument: aws.String(string(trust)),
+			Tags:                     iamTags(spec.Tags),
+		})
+		if err != nil {
+			return "", fmt.Errorf("failed to create role %s: %w", spec.Name, err)
+		}
+		roleARN = aws.ToString(result.Role.Arn)
+
+		r.provider.GetLogger().Info("IAM role created",
+			zap.String("role", spec.Name),
+			zap.String("service", spec.Service),
+		)
+
+	default:
+		return "", fmt.Errorf("failed to get role %s: %w", spec.Name, err)
+	}
+
+	if err := r.syncManagedPolicies(ctx, spec.Name, spec.ManagedPolicies); err != nil {
+		return "", err
+	}
+	if err := r.syncInlinePolicy(ctx, spec.Name, spec.Statements); err != nil {
+		return "", err
+	}
+
+	return roleARN, nil
+}
+
+// delete deletes a role with its policies. A role that does not exist is
+// ignored.
+func (r *iamRoles) delete(ctx context.Context, name string) error {
+	if err := r.syncManagedPolicies(ctx, name, nil); err != nil {
+		if isAPIErrorCode(err, "NoSuchEntity") {
+			return nil
+		}
+		return err
+	}
+	if err := r.syncInlinePolicy(ctx, name, nil); err != nil {
+		return err
+	}
+
+	_, err := r.client.DeleteRole(ctx, &iam.DeleteRoleInput{RoleName: aws.String(name)})
+	if err != nil && !isAPIErrorCode(err, "NoSuchEntity") {
+		return fmt.Errorf("failed to delete role %s: %w", name, err)
+	}
+	if err == nil {
+		r.provider.GetLogger().Info("IAM role deleted", zap.String("role", name))
+	}
+	return nil
+}
+
+// syncManagedPolicies attaches the managed policies of a role and detaches
+// the others
+func (r *iamRoles) syncManagedPolicies(ctx context.Context, name string, policies []string) error {
+	attached := make(map[string]bool)
+	paginator := iam.NewListAttachedRolePoliciesPaginator(r.client, &iam.ListAttachedRolePoliciesInput{
+		RoleName: aws.String(name),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to list the policies of role %s: %w", name, err)
+		}
+		for _, policy := range page.AttachedPolicies {
+			attached[aws.ToString(policy.PolicyArn)] = true
+		}
+	}
+
+	wanted := make(map[string]bool, len(policies))
+	for _, policy := range policies {
+		wanted[policy] = true
+		if attached[policy] {
+			continue
+		}
+		if _, err := r.client.AttachRolePolicy(ctx, &iam.AttachRolePolicyInput{
+			RoleName:  aws.String(name),
+			PolicyArn: aws.String(policy),
+		}); err != nil {
+			return fmt.Errorf("failed to attach policy %s to role %s: %w", policy, name, err)
+		}
+	}
+
+	for policy := range attached {
+		if wanted[policy] {
+			continue
+		}
+		_, err := r.client.DetachRolePolicy(ctx, &iam.DetachRolePolicyInput{
+			RoleName:  aws.String(name),
+			PolicyArn: aws.String(policy),
+		})
+		if err != nil && !isAPIErrorCode(err, "NoSuchEntity") {
+			return fmt.Errorf("failed to detach policy %s from role %s: %w", policy, name, err)
+		}
+	}
+
+	return nil
+}
+
+// syncInlinePolicy puts the inline policy of a role, or deletes it when the
+// role needs no permissions of its own
+func (r *iamRoles) syncInlinePolicy(ctx context.Context, name string, statements []policyStatement) error {
+	if len(statements) == 0 {
+		_, err := r.client.DeleteRolePolicy(ctx, &iam.DeleteRolePolicyInput{
+			RoleName:   aws.String(name),
+			PolicyName: aws.String(rolePolicyName),
+		})
+		if err != nil && !isAPIErrorCode(err, "NoSuchEntity") {
+			return fmt.Errorf("failed to delete the policy of role %s: %w", name, err)
+		}
+		return nil
+	}
+
+	document, err := json.Marshal(policyDocument{Version: "2012-10-17", Statement: statements})
+	if err != nil {
+		return fmt.Errorf("failed to encode the policy of role %s: %w", name, err)
+	}
+
+	if _, err := r.client.PutRolePolicy(ctx, &iam.PutRolePolicyInput{
+		RoleName:       aws.String(name),
+		PolicyName:     aws.String(rolePolicyName),
+		PolicyDocument: aws.String(string(document)),
+	}); err != nil {
+		return fmt.Errorf("failed to put the policy of role %s: %w", name, err)
+	}
+	return nil
+}
+
+// roleARN returns the ARN of a role of the provider's account
+func (r *iamRoles) roleARN(name string) string {
+	return fmt.Sprintf("arn:%s:iam::%s:role/%s", arnPartition(r.provider.GetRegion()), r.provider.GetAccountID(), name)
+}
+
+// managedPolicyARN returns the ARN of an AWS managed policy in the
+// provider's partition
+func (r *iamRoles) managedPolicyARN(name string) string {
+	return fmt.Sprintf("arn:%s:iam::aws:policy/%s", arnPartition(r.provider.GetRegion()), name)
+}
+
+// arnPartition returns the partition of the ARNs of a region
+func arnPartition(region string) string {
+	switch {
+	case strings.HasPrefix(region, "cn-"):
+		return "aws-cn"
+	case strings.HasPrefix(region, "us-gov-"):
+		return "aws-us-gov"
+	default:
+		return "aws"
+	}
+}
+
+// isManagedRole reports whether the tags of a role mark it as created by
+// panka
+func isManagedRole(tags []types.Tag) bool {
+	for _, tag := range tags {
+		if aws.ToString(tag.Key) == managedRoleTag {
+			return aws.ToString(tag.Value) == "true"
+		}
+	}
+	return false
+}
+
+// roleName returns the name of a role of a workload. Role names are global
+// to the account, so they include the region.
+func (r *iamRoles) roleName(workload, suffix string) string {
+	parts := []string{workload}
+	if region := r.provider.GetRegion(); region != "" {
+		parts = append(parts, region)
+	}
+	parts = append(parts, suffix)
+	return shortName(strings.Join(parts, "-"), roleNameMaxLength)
+}
+
+// retryNewRole runs an operation that uses a role, retrying while a role
+// that was just created or changed is not usable yet
+func retryNewRole(ctx context.Context, operation func() error) error {
+	deadline := time.Now().Add(rolePropagationTimeout)
+	for {
+		err := operation()
+		if err == nil || !isRoleNotReady(err) || time.Now().After(deadline) {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(rolePropagationInterval):
+		}
+	}
+}
+
+// isRoleNotReady reports whether an error is a service failing to assume a
+// role, or to use its permissions, because IAM changes have not propagated
+func isRoleNotReady(err error) bool {
+	var apiErr smithy.APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	switch apiErr.ErrorCode() {
+	case "InvalidParameterValueException", "InvalidParameterException", "ValidationException", "ClientException":
+	default:
+		return false
+	}
+	message := strings.ToLower(apiErr.ErrorMessage())
+	return strings.Contains(message, "assume") || strings.Contains(message, "role does not have permissions")
+}
+
+// trustPolicy returns the policy that lets a service assume a role
+func trustPolicy(service string) policyDocument {
+	return policyDocument{
+		Version: "2012-10-17",
+		Statement: []policyStatement{{
+			Effect:    "Allow",
+			Principal: map[string]string{"Service": service},
+			Action:    []string{"sts:AssumeRole"},
+		}},
+	}
+}
+
+// accessStatements returns the statements that grant a workload access to
+// the components it depends on
+func accessStatements(grants []schema.ComponentAccess) ([]policyStatement, error) {
+	var statements []policyStatement
+	for _, grant := range grants {
+		if grant.Arn == "" {
+			return nil, fmt.Errorf("access grant component %s has no ARN, deploy it first", grant.Component)
+		}
+
+		var actions []string
+		resources := []string{grant.Arn}
+
+		switch arnPart(grant.Arn, 2) {
+		case "s3":
+			if grant.Reads() {
+				statements = append(statements, allow([]string{"s3:ListBucket"}, grant.Arn))
+				actions = append(actions, "s3:GetObject")
+			}
+			if grant.Writes() {
+				actions = append(actions, "s3:PutObject", "s3:DeleteObject")
+			}
+			resources = []string{grant.Arn + "/*"}
+		case "dynamodb":
+			if grant.Reads() {
+				actions = append(actions, "dynamodb:GetItem", "dynamodb:BatchGetItem", "dynamodb:Query", "dynamodb:Scan", "dynamodb:DescribeTable", "dynamodb:ConditionCheckItem")
+			}
+			if grant.Writes() {
+				actions = append(actions, "dynamodb:PutItem", "dynamodb:UpdateItem", "dynamodb:DeleteItem", "dynamodb:BatchWriteItem")
+			}
+			resources = append(resources, grant.Arn+"/index/*")
+		case "sqs":
+			actions = append(actions, "sqs:GetQueueAttributes", "sqs:GetQueueUrl")
+			if grant.Reads() {
+				actions = append(actions, "sqs:ReceiveMessage", "sqs:DeleteMessage", "sqs:ChangeMessageVisibility")
+			}
+			if grant.Writes() {
+				actions = append(actions, "sqs:SendMessage")
+			}
+		case "sns":
+			actions = append(actions, "sns:Publish")
+		default:
+			return nil, fmt.Errorf("access to %s is not supported", grant.Arn)
+		}
+
+		statements = append(statements, allow(actions, resources...))
+	}
+	return statements, nil
+}
+
+// triggerStatements returns the statements that let Lambda poll the sources
+// of the event source mappings of a function. Push triggers invoke the
+// function through its resource policy instead.
+func triggerStatements(triggers []schema.LambdaTrigger) ([]policyStatement, error) {
+	var statements []policyStatement
+	for i := range triggers {
+		trigger := &triggers[i]
+		if !trigger.PollsSource() {
+			continue
+		}
+
+		sourceARN, err := triggerSourceARN(trigger)
+		if err != nil {
+			return nil, err
+		}
+
+		switch trigger.Type {
+		case schema.TriggerSQS:
+			statements = append(statements, allow([]string{
+				"sqs:ReceiveMessage", "sqs:DeleteMessage", "sqs:ChangeMessageVisibility", "sqs:GetQueueAttributes",
+			}, sourceARN))
+		case schema.TriggerDynamoDB:
+			streamARN := sourceARN
+			if !strings.Contains(sourceARN, "/stream/") {
+				streamARN = sourceARN + "/stream/*"
+			}
+			statements = append(statements, allow([]string{
+				"dynamodb:DescribeStream", "dynamodb:GetRecords", "dynamodb:GetShardIterator", "dynamodb:ListStreams",
+			}, streamARN))
+		case schema.TriggerKinesis:
+			statements = append(statements, allow([]string{
+				"kinesis:DescribeStream", "kinesis:DescribeStreamSummary", "kinesis:GetRecords", "kinesis:GetShardIterator", "kinesis:ListShards", "kinesis:ListStreams",
+			}, sourceARN))
+		}
+	}
+	return statements, nil
+}
+
+// secretStatements returns the statements that let a workload read its
+// secrets. Secret refs have been resolved into ARNs.
+func secretStatements(refs []schema.Secret) ([]policyStatement, error) {
+	var secretARNs, parameterARNs []string
+	for _, secret := range refs {
+		ref, err := secrets.ParseRef(secret.SecretRef)
+		if err != nil {
+			return nil, err
+		}
+		if !strings.HasPrefix(ref.ID, "arn:") {
+			return nil, fmt.Errorf("secret %s has not been resolved to an ARN", secret.Name)
+		}
+		if ref.Backend == secrets.BackendSSM {
+			parameterARNs = append(parameterARNs, ref.ID)
+		} else {
+			secretARNs = append(secretARNs, ref.ID)
+		}
+	}
+
+	var statements []policyStatement
+	if len(secretARNs) > 0 {
+		statements = append(statements, allow([]string{"secretsmanager:GetSecretValue"}, uniqueSorted(secretARNs)...))
+	}
+	if len(parameterARNs) > 0 {
+		statements = append(statements, allow([]string{"ssm:GetParameter", "ssm:GetParameters"}, uniqueSorted(parameterARNs)...))
+	}
+	return statements, nil
+}
+
+// allow returns a statement that allows actions on resources
+func allow(actions []string, resources ...string) policyStatement {
+	return policyStatement{Effect: "Allow", Action: actions, Resource: resources}
+}
+
+// iamTags converts tags into IAM tags, sorted by key
+func iamTags(tags map[string]string) []types.Tag {
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	result := make([]types.Tag, 0, len(keys))
+	for _, k := range keys {
+		result = append(result, types.Tag{Key: aws.String(k), Value: aws.String(tags[k])})
+	}
+	return result
+}
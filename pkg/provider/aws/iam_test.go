@@ -0,0 +1,277 @@
+package aws
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/aws/smithy-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourusername/panka/internal/logger"
+	"github.com/yourusername/panka/pkg/parser/schema"
+)
+
+func TestTrustPolicy(t *testing.T) {
+	document, err := json.Marshal(trustPolicy("lambda.amazonaws.com"))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"Version": "2012-10-17",
+		"Statement": [{
+			"Effect": "Allow",
+			"Principal": {"Service": "lambda.amazonaws.com"},
+			"Action": ["sts:AssumeRole"]
+		}]
+	}`, string(document))
+}
+
+func TestAccessStatements(t *testing.T) {
+	statements, err := accessStatements([]schema.ComponentAccess{
+		{Component: "uploads", Mode: schema.AccessRead, Arn: "arn:aws:s3:::my-stack-storage-uploads"},
+		{Component: "users", Mode: schema.AccessReadWrite, Arn: "arn:aws:dynamodb:us-east-1:123456789012:table/users"},
+		{Component: "orders", Mode: schema.AccessWrite, Arn: "arn:aws:sqs:us-east-1:123456789012:orders"},
+		{Component: "events", Mode: schema.AccessWrite, Arn: "arn:aws:sns:us-east-1:123456789012:events"},
+	})
+	require.NoError(t, err)
+	require.Len(t, statements, 5)
+
+	assert.Equal(t, allow([]string{"s3:ListBucket"}, "arn:aws:s3:::my-stack-storage-uploads"), statements[0])
+	assert.Equal(t, allow([]string{"s3:GetObject"}, "arn:aws:s3:::my-stack-storage-uploads/*"), statements[1])
+
+	assert.Contains(t, statements[2].Action, "dynamodb:Query")
+	assert.Contains(t, statements[2].Action, "dynamodb:PutItem")
+	assert.Equal(t, []string{
+		"arn:aws:dynamodb:us-east-1:123456789012:table/users",
+		"arn:aws:dynamodb:us-east-1:123456789012:table/users/index/*",
+	}, statements[2].Resource)
+
+	// Write access to a queue does not allow receiving from it
+	assert.Contains(t, statements[3].Action, "sqs:SendMessage")
+	assert.NotContains(t, statements[3].Action, "sqs:ReceiveMessage")
+
+	assert.Equal(t, allow([]string{"sns:Publish"}, "arn:aws:sns:us-east-1:123456789012:events"), statements[4])
+
+	// Components must be deployed before the workloads that access them
+	_, err = accessStatements([]schema.ComponentAccess{{Component: "uploads", Mode: schema.AccessRead}})
+	assert.ErrorContains(t, err, "deploy it first")
+}
+
+func TestTriggerStatements(t *testing.T) {
+	statements, err := triggerStatements([]schema.LambdaTrigger{
+		{Type: schema.TriggerSQS, Source: &schema.TriggerSource{Component: "orders", Arn: "arn:aws:sqs:us-east-1:123456789012:orders"}},
+		{Type: schema.TriggerDynamoDB, Source: &schema.TriggerSource{Component: "users", Arn: "arn:aws:dynamodb:us-east-1:123456789012:table/users"}},
+		// Push triggers invoke the function through its resource policy
+		{Type: schema.TriggerSNS, Source: &schema.TriggerSource{Component: "events", Arn: "arn:aws:sns:us-east-1:123456789012:events"}},
+	})
+	require.NoError(t, err)
+	require.Len(t, statements, 2)
+
+	assert.Contains(t, statements[0].Action, "sqs:ReceiveMessage")
+	assert.Equal(t, []string{"arn:aws:sqs:us-east-1:123456789012:orders"}, statements[0].Resource)
+	assert.Contains(t, statements[1].Action, "dynamodb:GetRecords")
+	assert.Equal(t, []string{"arn:aws:dynamodb:us-east-1:123456789012:table/users/stream/*"}, statements[1].Resource)
+
+	_, err = triggerStatements([]schema.LambdaTrigger{
+		{Type: schema.TriggerSQS, Source: &schema.TriggerSource{Component: "orders"}},
+	})
+	assert.ErrorContains(t, err, "deploy it first")
+}
+
+func TestSecretStatements(t *testing.T) {
+	statements, err := secretStatements([]schema.Secret{
+		{Name: "db", SecretRef: "arn:aws:secretsmanager:us-east-1:123456789012:secret:db-AbCdEf:password::"},
+		{Name: "db-user", SecretRef: "arn:aws:secretsmanager:us-east-1:123456789012:secret:db-AbCdEf"},
+		{Name: "token", SecretRef: "arn:aws:ssm:us-east-1:123456789012:parameter/my-stack/token"},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []policyStatement{
+		allow([]string{"secretsmanager:GetSecretValue"}, "arn:aws:secretsmanager:us-east-1:123456789012:secret:db-AbCdEf"),
+		allow([]string{"ssm:GetParameter", "ssm:GetParameters"}, "arn:aws:ssm:us-east-1:123456789012:parameter/my-stack/token"),
+	}, statements)
+
+	statements, err = secretStatements(nil)
+	require.NoError(t, err)
+	assert.Empty(t, statements)
+
+	// Refs are resolved into ARNs first
+	_, err = secretStatements([]schema.Secret{{Name: "token", SecretRef: "/my-stack/token"}})
+	assert.ErrorContains(t, err, "not been resolved")
+}
+
+func TestSchedulerStatements(t *testing.T) {
+	statements := schedulerStatements("us-east-1", "123456789012", "panka-acme", "my-stack-jobs-cleanup", []string{
+		"arn:aws:iam::123456789012:role/task",
+		"arn:aws:iam::123456789012:role/execution",
+	})
+
+	require.Len(t, statements, 3)
+	assert.Equal(t, []string{"arn:aws:ecs:us-east-1:123456789012:task-definition/my-stack-jobs-cleanup:*"}, statements[0].Resource)
+	assert.Equal(t, []string{"arn:aws:ecs:us-east-1:123456789012:task/panka-acme/*"}, statements[1].Resource)
+	assert.Equal(t, []string{"iam:PassRole"}, statements[2].Action)
+
+	statements = schedulerStatements("cn-north-1", "123456789012", "panka-acme", "my-stack-jobs-cleanup", nil)
+	assert.Equal(t, []string{"arn:aws-cn:ecs:cn-north-1:123456789012:task-definition/my-stack-jobs-cleanup:*"}, statements[0].Resource)
+}
+
+func TestIAMRoles_RoleName(t *testing.T) {
+	log, _ := logger.NewDevelopment()
+	roles := newIAMRoles(&Provider{logger: log, accountID: "123456789012", region: "us-east-1"})
+
+	assert.Equal(t, "my-stack-backend-api-us-east-1-task", roles.roleName("my-stack-backend-api", "task"))
+	assert.Equal(t, "arn:aws:iam::123456789012:role/my-stack-backend-api-us-east-1-task",
+		roles.roleARN(roles.roleName("my-stack-backend-api", "task")))
+
+	// Long names are shortened to what IAM accepts
+	name := roles.roleName("a-very-long-stack-name-with-a-very-long-service-name-and-function", "execution")
+	assert.LessOrEqual(t, len(name), roleNameMaxLength)
+}
+
+func TestArnPartition(t *testing.T) {
+	tests := []struct {
+		region    string
+		partition string
+	}{
+		{"us-east-1", "aws"},
+		{"eu-west-1", "aws"},
+		{"cn-north-1", "aws-cn"},
+		{"cn-northwest-1", "aws-cn"},
+		{"us-gov-west-1", "aws-us-gov"},
+		{"", "aws"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.region, func(t *testing.T) {
+			assert.Equal(t, tt.partition, arnPartition(tt.region))
+		})
+	}
+}
+
+func TestIAMRoles_ARNs(t *testing.T) {
+	log, _ := logger.NewDevelopment()
+	roles := newIAMRoles(&Provider{logger: log, accountID: "123456789012", region: "cn-north-1"})
+
+	assert.Equal(t, "arn:aws-cn:iam::123456789012:role/api-task", roles.roleARN("api-task"))
+	assert.Equal(t, "arn:aws-cn:iam::aws:policy/service-role/AmazonECSTaskExecutionRolePolicy",
+		roles.managedPolicyARN(ecsTaskExecutionPolicy))
+
+	roles.provider.region = "us-gov-west-1"
+	assert.Equal(t, "arn:aws-us-gov:iam::aws:policy/service-role/AWSLambdaBasicExecutionRole",
+		roles.managedPolicyARN(lambdaBasicExecutionPolicy))
+}
+
+// fakeIAM serves IAM requests for a single role, which exists when it has
+// tags, and records the actions with their forms
+type fakeIAM struct {
+	actions []string
+	forms   map[string]url.Values
+	tags    map[string]string
+}
+
+func (f *fakeIAM) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	action := r.Form.Get("Action")
+	f.actions = append(f.actions, action)
+	if f.forms == nil {
+		f.forms = make(map[string]url.Values)
+	}
+	f.forms[action] = r.Form
+
+	result := ""
+	switch action {
+	case "GetRole":
+		if f.tags == nil {
+			writeQueryError(w, "NoSuchEntity")
+			return
+		}
+		var tags string
+		for k, v := range f.tags {
+			tags += fmt.Sprintf("<member><Key>%s</Key><Value>%s</Value></member>", k, v)
+		}
+		result = fmt.Sprintf(testIAMRole, r.Form.Get("RoleName"), tags)
+	case "CreateRole":
+		result = fmt.Sprintf(testIAMRole, r.Form.Get("RoleName"), "")
+	case "ListAttachedRolePolicies":
+		result = "<AttachedPolicies></AttachedPolicies><IsTruncated>false</IsTruncated>"
+	}
+
+	fmt.Fprintf(w, "<%sResponse><%[1]sResult>%s</%[1]sResult></%[1]sResponse>", action, result)
+}
+
+const testIAMRole = `<Role>
+  <Path>/</Path>
+  <RoleName>%[1]s</RoleName>
+  <RoleId>AROAEXAMPLE</RoleId>
+  <Arn>arn:aws:iam::123456789012:role/%[1]s</Arn>
+  <CreateDate>2026-01-01T00:00:00Z</CreateDate>
+  <Tags>%[2]s</Tags>
+</Role>`
+
+func TestIAMRoles_Ensure(t *testing.T) {
+	spec := roleSpec{
+		Name:            "api-task",
+		Description:     "Task role of ECS service api",
+		Service:         "ecs-tasks.amazonaws.com",
+		ManagedPolicies: []string{"arn:aws:iam::aws:policy/service-role/AmazonECSTaskExecutionRolePolicy"},
+		Statements:      []policyStatement{allow([]string{"sns:Publish"}, "arn:aws:sns:us-east-1:123456789012:events")},
+		Tags:            map[string]string{"panka:managed": "true", "panka:stack": "my-stack"},
+	}
+
+	t.Run("creates a missing role", func(t *testing.T) {
+		fake := &fakeIAM{}
+		roles := newIAMRoles(testServerProvider(t, fake.ServeHTTP))
+
+		roleARN, err := roles.ensure(context.Background(), spec)
+		require.NoError(t, err)
+		assert.Equal(t, "arn:aws:iam::123456789012:role/api-task", roleARN)
+		assert.Equal(t, []string{"GetRole", "CreateRole", "ListAttachedRolePolicies", "AttachRolePolicy", "PutRolePolicy"}, fake.actions)
+
+		create := fake.forms["CreateRole"]
+		assert.Equal(t, "panka:managed", create.Get("Tags.member.1.Key"))
+		assert.Equal(t, "true", create.Get("Tags.member.1.Value"))
+		assert.Equal(t, spec.ManagedPolicies[0], fake.forms["AttachRolePolicy"].Get("PolicyArn"))
+	})
+
+	t.Run("updates a role panka created", func(t *testing.T) {
+		fake := &fakeIAM{tags: map[string]string{"panka:managed": "true"}}
+		roles := newIAMRoles(testServerProvider(t, fake.ServeHTTP))
+
+		_, err := roles.ensure(context.Background(), spec)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"GetRole", "UpdateAssumeRolePolicy", "ListAttachedRolePolicies", "AttachRolePolicy", "PutRolePolicy"}, fake.actions)
+	})
+
+	t.Run("refuses a role panka did not create", func(t *testing.T) {
+		for _, tags := range []map[string]string{{}, {"team": "platform"}, {"panka:managed": "false"}} {
+			fake := &fakeIAM{tags: tags}
+			roles := newIAMRoles(testServerProvider(t, fake.ServeHTTP))
+
+			_, err := roles.ensure(context.Background(), spec)
+			assert.ErrorContains(t, err, "role api-task already exists and is not managed by panka")
+			assert.Equal(t, []string{"GetRole"}, fake.actions)
+		}
+	})
+}
+
+func TestIsRoleNotReady(t *testing.T) {
+	assert.True(t, isRoleNotReady(&smithy.GenericAPIError{
+		Code:    "InvalidParameterValueException",
+		Message: "The role defined for the function cannot be assumed by Lambda.",
+	}))
+	assert.True(t, isRoleNotReady(&smithy.GenericAPIError{
+		Code:    "InvalidParameterValueException",
+		Message: "The provided execution role does not have permissions to call ReceiveMessage on SQS",
+	}))
+	assert.False(t, isRoleNotReady(&smithy.GenericAPIError{
+		Code:    "InvalidParameterValueException",
+		Message: "Unsupported runtime",
+	}))
+	assert.False(t, isRoleNotReady(errors.New("assume failed")))
+}
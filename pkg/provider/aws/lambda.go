@@ -2,34 +2,46 @@ package aws
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"strconv"
 	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
 	"github.com/aws/aws-sdk-go-v2/service/lambda"
 	"github.com/aws/aws-sdk-go-v2/service/lambda/types"
 	"github.com/yourusername/panka/pkg/parser/schema"
 	"github.com/yourusername/panka/pkg/provider"
+	"github.com/yourusername/panka/pkg/secrets"
 	"go.uber.org/zap"
 )
 
 // LambdaProvider implements Lambda function management
 type LambdaProvider struct {
-	provider *Provider
-	client   *lambda.Client
+	provider    *Provider
+	client      *lambda.Client
+	triggers    *lambdaTriggers
+	concurrency *lambdaConcurrency
+	roles       *iamRoles
+	secrets     *secrets.Manager
 }
 
 // NewLambdaProvider creates a new Lambda provider
 func NewLambdaProvider(p *Provider) *LambdaProvider {
+	client := lambda.NewFromConfig(p.GetConfig())
 	return &LambdaProvider{
-		provider: p,
-		client:   lambda.NewFromConfig(p.GetConfig()),
+		provider:    p,
+		client:      client,
+		triggers:    newLambdaTriggers(p, client),
+		concurrency: newLambdaConcurrency(p, client),
+		roles:       newIAMRoles(p),
+		secrets:     secrets.NewManager(p.GetConfig()),
 	}
 }
 
-// Create creates a new Lambda function
+// Create creates a new Lambda function with its triggers and provisioned
+// concurrency
 func (lp *LambdaProvider) Create(ctx context.Context, resource schema.Resource, opts *provider.ResourceOptions) (*provider.ResourceResult, error) {
 	lambdaResource, ok := resource.(*schema.Lambda)
 	if !ok {
@@ -40,11 +52,7 @@ func (lp *LambdaProvider) Create(ctx context.Context, resource schema.Resource,
 		}
 	}
 
-	functionName := fmt.Sprintf("%s-%s-%s",
-		opts.StackName,
-		opts.ServiceName,
-		lambdaResource.Metadata.Name,
-	)
+	functionName := lambdaFunctionName(lambdaResource, opts)
 
 	lp.provider.GetLogger().Info("Creating Lambda function",
 		zap.String("name", functionName),
@@ -64,27 +72,6 @@ func (lp *LambdaProvider) Create(ctx context.Context, resource schema.Resource,
 		}, nil
 	}
 
-	// Check if IAM role is specified
-	if lambdaResource.Spec.RoleArn == "" {
-		lp.provider.GetLogger().Warn("Lambda function requires IAM role - returning placeholder",
-			zap.String("function", functionName),
-		)
-		// Return a placeholder result - Lambda requires an IAM execution role
-		// In a full implementation, we would auto-create the role
-		return &provider.ResourceResult{
-			ResourceID: functionName,
-			Kind:       schema.KindLambda,
-			Status:     provider.StatusPending,
-			Outputs: map[string]string{
-				"function_name": functionName,
-				"runtime":       lambdaResource.Spec.Runtime,
-				"status":        "role_required",
-				"message":       "Lambda function requires an IAM execution role. Specify 'roleArn' in the spec or use 'panka admin' to configure auto-provisioning.",
-			},
-			Timestamp: time.Now(),
-		}, nil
-	}
-
 	// Check if code source is specified
 	if lambdaResource.Spec.Code.ImageUri == "" && lambdaResource.Spec.Code.S3Bucket == "" {
 		lp.provider.GetLogger().Warn("Lambda function requires code - returning placeholder",
@@ -107,53 +94,40 @@ func (lp *LambdaProvider) Create(ctx context.Context, resource schema.Resource,
 	// Build tags
 	tags := lp.provider.tagHelper.BuildTags(opts, resource)
 
-	// Parse memory (default 128MB)
-	memory := int32(128)
-	if lambdaResource.Spec.Memory != "" {
-		if m, err := strconv.Atoi(lambdaResource.Spec.Memory); err == nil {
-			memory = int32(m)
+	functionSecrets, err := lp.resolveSecrets(ctx, lambdaResource)
+	if err != nil {
+		return nil, &provider.ProviderError{
+			Provider:   "aws",
+			Operation:  "create",
+			ResourceID: functionName,
+			Cause:      err,
+			Message:    "failed to resolve Lambda secrets",
 		}
 	}
 
-	// Parse timeout (default 30 seconds)
-	timeout := int32(30)
-	if lambdaResource.Spec.Timeout != "" {
-		if t, err := strconv.Atoi(lambdaResource.Spec.Timeout); err == nil {
-			timeout = int32(t)
+	roleARN, err := lp.executionRole(ctx, lambdaResource, functionName, functionSecrets, tags)
+	if err != nil {
+		return nil, &provider.ProviderError{
+			Provider:   "aws",
+			Operation:  "create",
+			ResourceID: functionName,
+			Cause:      err,
+			Message:    "failed to create Lambda execution role",
 		}
 	}
 
 	// Build environment variables
-	var envVars map[string]string
-	if len(lambdaResource.Spec.Environment) > 0 {
-		envVars = make(map[string]string)
-		for k, v := range lambdaResource.Spec.Environment {
-			envVars[k] = fmt.Sprintf("%v", v)
-		}
-	}
-
-	// Determine code source (already validated above)
-	var code *types.FunctionCode
-	if lambdaResource.Spec.Code.ImageUri != "" {
-		code = &types.FunctionCode{
-			ImageUri: aws.String(lambdaResource.Spec.Code.ImageUri),
-		}
-	} else {
-		code = &types.FunctionCode{
-			S3Bucket: aws.String(lambdaResource.Spec.Code.S3Bucket),
-			S3Key:    aws.String(lambdaResource.Spec.Code.S3Key),
-		}
-	}
+	envVars := lambdaEnvironment(lambdaResource, functionSecrets)
 
 	// Create function input
 	input := &lambda.CreateFunctionInput{
 		FunctionName: aws.String(functionName),
 		Runtime:      types.Runtime(lambdaResource.Spec.Runtime),
 		Handler:      aws.String(lambdaResource.Spec.Handler),
-		Role:         aws.String(lambdaResource.Spec.RoleArn), // Note: Role must exist
-		Code:         code,
-		MemorySize:   aws.Int32(memory),
-		Timeout:      aws.Int32(timeout),
+		Role:         aws.String(roleARN),
+		Code:         lambdaFunctionCode(lambdaResource),
+		MemorySize:   aws.Int32(int32(lambdaResource.MemoryMB())),
+		Timeout:      aws.Int32(int32(lambdaResource.TimeoutSeconds())),
 		Tags:         tags,
 	}
 
@@ -179,8 +153,13 @@ func (lp *LambdaProvider) Create(ctx context.Context, resource schema.Resource,
 		input.Layers = lambdaResource.Spec.Layers
 	}
 
-	// Create function
-	result, err := lp.client.CreateFunction(ctx, input)
+	// Create function, waiting for a new role to be assumable
+	var result *lambda.CreateFunctionOutput
+	err = retryNewRole(ctx, func() error {
+		var err error
+		result, err = lp.client.CreateFunction(ctx, input)
+		return err
+	})
 	if err != nil {
 		lp.provider.GetLogger().Error("Failed to create Lambda function",
 			zap.String("function", functionName),
@@ -200,22 +179,71 @@ func (lp *LambdaProvider) Create(ctx context.Context, resource schema.Resource,
 		zap.String("arn", *result.FunctionArn),
 	)
 
+	// Event source mappings and triggers need an active function
+	waiter := lambda.NewFunctionActiveV2Waiter(lp.client)
+	if err := waiter.Wait(ctx, &lambda.GetFunctionInput{FunctionName: aws.String(functionName)}, ecsWaitTime(ctx)); err != nil {
+		return nil, &provider.ProviderError{
+			Provider:   "aws",
+			Operation:  "create",
+			ResourceID: functionName,
+			Cause:      err,
+			Message:    "Lambda function did not become active",
+		}
+	}
+
+	if err := retryNewRole(ctx, func() error {
+		return lp.triggers.reconcile(ctx, lambdaResource, functionName, *result.FunctionArn)
+	}); err != nil {
+		return nil, &provider.ProviderError{
+			Provider:   "aws",
+			Operation:  "create",
+			ResourceID: functionName,
+			Cause:      err,
+			Message:    "failed to create Lambda triggers",
+		}
+	}
+
+	outputs := map[string]string{
+		"function_name": functionName,
+		"function_arn":  *result.FunctionArn,
+		"runtime":       string(result.Runtime),
+		"handler":       *result.Handler,
+		"memory_mb":     fmt.Sprintf("%d", *result.MemorySize),
+		"timeout_sec":   fmt.Sprintf("%d", *result.Timeout),
+		"role_arn":      roleARN,
+	}
+	if err := lp.concurrency.reconcile(ctx, lambdaResource, functionName, outputs); err != nil {
+		return nil, &provider.ProviderError{
+			Provider:   "aws",
+			Operation:  "create",
+			ResourceID: functionName,
+			Cause:      err,
+			Message:    "failed to configure Lambda provisioned concurrency",
+		}
+	}
+
 	return &provider.ResourceResult{
 		ResourceID: functionName,
 		Kind:       schema.KindLambda,
 		Status:     provider.StatusAvailable,
-		Outputs: map[string]string{
-			"function_name": functionName,
-			"function_arn":  *result.FunctionArn,
-			"runtime":       string(result.Runtime),
-			"handler":       *result.Handler,
-			"memory_mb":     fmt.Sprintf("%d", *result.MemorySize),
-			"timeout_sec":   fmt.Sprintf("%d", *result.Timeout),
-		},
-		Timestamp: time.Now(),
+		Outputs:    outputs,
+		Timestamp:  time.Now(),
 	}, nil
 }
 
+// PlannedID returns the name of the function of a resource
+func (lp *LambdaProvider) PlannedID(resource schema.Resource, opts *provider.ResourceOptions) (string, error) {
+	lambdaResource, ok := resource.(*schema.Lambda)
+	if !ok {
+		return "", &provider.ProviderError{
+			Provider:  "aws",
+			Operation: "plan",
+			Message:   "invalid resource type for Lambda provider",
+		}
+	}
+	return lambdaFunctionName(lambdaResource, opts), nil
+}
+
 // Read reads the current state of a Lambda function
 func (lp *LambdaProvider) Read(ctx context.Context, resourceID string, opts *provider.ResourceOptions) (*provider.ResourceResult, error) {
 	result, err := lp.client.GetFunction(ctx, &lambda.GetFunctionInput{
@@ -231,22 +259,41 @@ func (lp *LambdaProvider) Read(ctx context.Context, resourceID string, opts *pro
 		}
 	}
 
+	outputs := map[string]string{
+		"function_name": *result.Configuration.FunctionName,
+		"function_arn":  *result.Configuration.FunctionArn,
+		"runtime":       string(result.Configuration.Runtime),
+		"handler":       *result.Configuration.Handler,
+		"state":         string(result.Configuration.State),
+		"role_arn":      aws.ToString(result.Configuration.Role),
+	}
+
+	aliasARN, err := lp.concurrency.aliasARN(ctx, resourceID)
+	if err != nil {
+		return nil, &provider.ProviderError{
+			Provider:   "aws",
+			Operation:  "read",
+			ResourceID: resourceID,
+			Cause:      err,
+			Message:    "failed to get Lambda alias",
+		}
+	}
+	if aliasARN != "" {
+		outputs["alias_name"] = lambdaLiveAlias
+		outputs["alias_arn"] = aliasARN
+	}
+
 	return &provider.ResourceResult{
 		ResourceID: resourceID,
 		Kind:       schema.KindLambda,
 		Status:     provider.StatusAvailable,
-		Outputs: map[string]string{
-			"function_name": *result.Configuration.FunctionName,
-			"function_arn":  *result.Configuration.FunctionArn,
-			"runtime":       string(result.Configuration.Runtime),
-			"handler":       *result.Configuration.Handler,
-			"state":         string(result.Configuration.State),
-		},
-		Timestamp: time.Now(),
+		Outputs:    outputs,
+		Timestamp:  time.Now(),
 	}, nil
 }
 
-// Update updates an existing Lambda function
+// Update updates an existing Lambda function and reconciles its triggers
+// and provisioned concurrency
 func (lp *LambdaProvider) Update(ctx context.Context, resource schema.Resource, opts *provider.ResourceOptions) (*provider.ResourceResult, error) {
 	lambdaResource, ok := resource.(*schema.Lambda)
 	if !ok {
@@ -257,36 +304,52 @@ func (lp *LambdaProvider) Update(ctx context.Context, resource schema.Resource,
 		}
 	}
 
-	functionName := fmt.Sprintf("%s-%s-%s",
-		opts.StackName,
-		opts.ServiceName,
-		lambdaResource.Metadata.Name,
-	)
+	functionName := lambdaFunctionName(lambdaResource, opts)
 
 	lp.provider.GetLogger().Info("Updating Lambda function",
 		zap.String("function", functionName),
 	)
 
-	// Parse memory and timeout
-	memory := int32(128)
-	if lambdaResource.Spec.Memory != "" {
-		if m, err := strconv.Atoi(lambdaResource.Spec.Memory); err == nil {
-			memory = int32(m)
+	functionSecrets, err := lp.resolveSecrets(ctx, lambdaResource)
+	if err != nil {
+		return nil, &provider.ProviderError{
+			Provider:   "aws",
+			Operation:  "update",
+			ResourceID: functionName,
+			Cause:      err,
+			Message:    "failed to resolve Lambda secrets",
 		}
 	}
-	timeout := int32(30)
-	if lambdaResource.Spec.Timeout != "" {
-		if t, err := strconv.Atoi(lambdaResource.Spec.Timeout); err == nil {
-			timeout = int32(t)
+
+	// Build environment variables
+	envVars := lambdaEnvironment(lambdaResource, functionSecrets)
+
+	roleARN, err := lp.executionRole(ctx, lambdaResource, functionName, functionSecrets, lp.provider.tagHelper.BuildTags(opts, resource))
+	if err != nil {
+		return nil, &provider.ProviderError{
+			Provider:   "aws",
+			Operation:  "update",
+			ResourceID: functionName,
+			Cause:      err,
+			Message:    "failed to update Lambda execution role",
 		}
 	}
 
-	// Build environment variables
-	var envVars map[string]string
-	if len(lambdaResource.Spec.Environment) > 0 {
-		envVars = make(map[string]string)
-		for k, v := range lambdaResource.Spec.Environment {
-			envVars[k] = fmt.Sprintf("%v", v)
+	// Deploy the code before the configuration, which may need it, such
+	// as a new handler
+	code := lambdaFunctionCode(lambdaResource)
+	if _, err := lp.client.UpdateFunctionCode(ctx, &lambda.UpdateFunctionCodeInput{
+		FunctionName: aws.String(functionName),
+		ImageUri:     code.ImageUri,
+		S3Bucket:     code.S3Bucket,
+		S3Key:        code.S3Key,
+	}, retryOnConflict); err != nil {
+		return nil, &provider.ProviderError{
+			Provider:   "aws",
+			Operation:  "update",
+			ResourceID: functionName,
+			Cause:      err,
+			Message:    "failed to update Lambda function code",
 		}
 	}
 
@@ -294,8 +357,13 @@ func (lp *LambdaProvider) Update(ctx context.Context, resource schema.Resource,
 	input := &lambda.UpdateFunctionConfigurationInput{
 		FunctionName: aws.String(functionName),
 		Handler:      aws.String(lambdaResource.Spec.Handler),
-		MemorySize:   aws.Int32(memory),
-		Timeout:      aws.Int32(timeout),
+		Role:         aws.String(roleARN),
+		MemorySize:   aws.Int32(int32(lambdaResource.MemoryMB())),
+		Timeout:      aws.Int32(int32(lambdaResource.TimeoutSeconds())),
+	}
+	// Functions deployed as container images have no runtime
+	if lambdaResource.Spec.Code.ImageUri == "" {
+		input.Runtime = types.Runtime(lambdaResource.Spec.Runtime)
 	}
 
 	if len(envVars) > 0 {
@@ -304,7 +372,12 @@ func (lp *LambdaProvider) Update(ctx context.Context, resource schema.Resource,
 		}
 	}
 
-	result, err := lp.client.UpdateFunctionConfiguration(ctx, input)
+	var result *lambda.UpdateFunctionConfigurationOutput
+	err = retryNewRole(ctx, func() error {
+		var err error
+		result, err = lp.client.UpdateFunctionConfiguration(ctx, input, retryOnConflict)
+		return err
+	})
 	if err != nil {
 		return nil, &provider.ProviderError{
 			Provider:   "aws",
@@ -315,24 +388,95 @@ func (lp *LambdaProvider) Update(ctx context.Context, resource schema.Resource,
 		}
 	}
 
+	waiter := lambda.NewFunctionUpdatedV2Waiter(lp.client)
+	if err := waiter.Wait(ctx, &lambda.GetFunctionInput{FunctionName: aws.String(functionName)}, ecsWaitTime(ctx)); err != nil {
+		return nil, &provider.ProviderError{
+			Provider:   "aws",
+			Operation:  "update",
+			ResourceID: functionName,
+			Cause:      err,
+			Message:    "Lambda function update did not complete",
+		}
+	}
+
+	if err := retryNewRole(ctx, func() error {
+		return lp.triggers.reconcile(ctx, lambdaResource, functionName, *result.FunctionArn)
+	}); err != nil {
+		return nil, &provider.ProviderError{
+			Provider:   "aws",
+			Operation:  "update",
+			ResourceID: functionName,
+			Cause:      err,
+			Message:    "failed to update Lambda triggers",
+		}
+	}
+
+	// A role given in the spec replaces the one panka created
+	if lambdaResource.Spec.RoleArn != "" {
+		if err := lp.roles.delete(ctx, lp.roles.roleName(functionName, "lambda")); err != nil {
+			return nil, &provider.ProviderError{
+				Provider:   "aws",
+				Operation:  "update",
+				ResourceID: functionName,
+				Cause:      err,
+				Message:    "failed to delete Lambda execution role",
+			}
+		}
+	}
+
+	outputs := map[string]string{
+		"function_name": *result.FunctionName,
+		"function_arn":  *result.FunctionArn,
+		"role_arn":      roleARN,
+	}
+	if err := lp.concurrency.reconcile(ctx, lambdaResource, functionName, outputs); err != nil {
+		return nil, &provider.ProviderError{
+			Provider:   "aws",
+			Operation:  "update",
+			ResourceID: functionName,
+			Cause:      err,
+			Message:    "failed to configure Lambda provisioned concurrency",
+		}
+	}
+
 	return &provider.ResourceResult{
 		ResourceID: functionName,
 		Kind:       schema.KindLambda,
 		Status:     provider.StatusAvailable,
-		Outputs: map[string]string{
-			"function_name": *result.FunctionName,
-			"function_arn":  *result.FunctionArn,
-		},
-		Timestamp: time.Now(),
+		Outputs:    outputs,
+		Timestamp:  time.Now(),
 	}, nil
 }
 
-// Delete deletes a Lambda function
+// Delete deletes a Lambda function and its triggers
 func (lp *LambdaProvider) Delete(ctx context.Context, resourceID string, opts *provider.ResourceOptions) (*provider.ResourceResult, error) {
 	lp.provider.GetLogger().Info("Deleting Lambda function",
 		zap.String("function", resourceID),
 	)
 
+	// Triggers are removed first, as sources keep their subscriptions and
+	// notifications to a deleted function
+	if err := lp.triggers.remove(ctx, resourceID); err != nil {
+		return nil, &provider.ProviderError{
+			Provider:   "aws",
+			Operation:  "delete",
+			ResourceID: resourceID,
+			Cause:      err,
+			Message:    "failed to delete Lambda triggers",
+		}
+	}
+
+	// The scalable target of the live alias outlives the function
+	if err := lp.concurrency.remove(ctx, resourceID); err != nil {
+		return nil, &provider.ProviderError{
+			Provider:   "aws",
+			Operation:  "delete",
+			ResourceID: resourceID,
+			Cause:      err,
+			Message:    "failed to remove Lambda provisioned concurrency",
+		}
+	}
+
 	_, err := lp.client.DeleteFunction(ctx, &lambda.DeleteFunctionInput{
 		FunctionName: aws.String(resourceID),
 	})
@@ -343,31 +487,36 @@ func (lp *LambdaProvider) Delete(ctx context.Context, resourceID string, opts *p
 			lp.provider.GetLogger().Info("Lambda function already deleted or never existed",
 				zap.String("function", resourceID),
 			)
-			return &provider.ResourceResult{
+		} else {
+			lp.provider.GetLogger().Error("Failed to delete Lambda function",
+				zap.String("function", resourceID),
+				zap.Error(err),
+			)
+			return nil, &provider.ProviderError{
+				Provider:   "aws",
+				Operation:  "delete",
 				ResourceID: resourceID,
-				Kind:       schema.KindLambda,
-				Status:     provider.StatusDeleted,
-				Timestamp:  time.Now(),
-			}, nil
+				Cause:      err,
+				Message:    "failed to delete Lambda function",
+			}
 		}
-
-		lp.provider.GetLogger().Error("Failed to delete Lambda function",
+	} else {
+		lp.provider.GetLogger().Info("Lambda function deleted",
 			zap.String("function", resourceID),
-			zap.Error(err),
 		)
+	}
+
+	// The execution role is deleted with the function, if panka created it
+	if err := lp.roles.delete(ctx, lp.roles.roleName(resourceID, "lambda")); err != nil {
 		return nil, &provider.ProviderError{
 			Provider:   "aws",
 			Operation:  "delete",
 			ResourceID: resourceID,
 			Cause:      err,
-			Message:    "failed to delete Lambda function",
+			Message:    "failed to delete Lambda execution role",
 		}
 	}
 
-	lp.provider.GetLogger().Info("Lambda function deleted",
-		zap.String("function", resourceID),
-	)
-
 	return &provider.ResourceResult{
 		ResourceID: resourceID,
 		Kind:       schema.KindLambda,
@@ -376,14 +525,99 @@ func (lp *LambdaProvider) Delete(ctx context.Context, resourceID string, opts *p
 	}, nil
 }
 
+// executionRole returns the ARN of the execution role of a function: the
+// role of the spec, or a role panka creates with the permissions the
+// function's triggers, access grants and secrets need
+func (lp *LambdaProvider) executionRole(ctx context.Context, fn *schema.Lambda, functionName string, functionSecrets []schema.Secret, tags map[string]string) (string, error) {
+	if fn.Spec.RoleArn != "" {
+		return fn.Spec.RoleArn, nil
+	}
+
+	statements, err := triggerStatements(fn.Spec.Triggers)
+	if err != nil {
+		return "", err
+	}
+	access, err := accessStatements(fn.Spec.Access)
+	if err != nil {
+		return "", err
+	}
+	secretAccess, err := secretStatements(functionSecrets)
+	if err != nil {
+		return "", err
+	}
+	statements = append(statements, access...)
+
+	policies := []string{lp.roles.managedPolicyARN(lambdaBasicExecutionPolicy)}
+	if fn.Spec.VPC.Enabled {
+		policies = append(policies, lp.roles.managedPolicyARN(lambdaVPCAccessPolicy))
+	}
+
+	roleARN, err := lp.roles.ensure(ctx, roleSpec{
+		Name:            lp.roles.roleName(functionName, "lambda"),
+		Description:     fmt.Sprintf("Execution role of Lambda function %s", functionName),
+		Service:         "lambda.amazonaws.com",
+		ManagedPolicies: policies,
+		Statements:      append(statements, secretAccess...),
+		Tags:            tags,
+	})
+	return roleARN, err
+}
+
+// resolveSecrets returns the secrets of a function with their refs
+// resolved into ARNs
+func (lp *LambdaProvider) resolveSecrets(ctx context.Context, fn *schema.Lambda) ([]schema.Secret, error) {
+	resolved := make([]schema.Secret, len(fn.Spec.Secrets))
+	for i, secret := range fn.Spec.Secrets {
+		arn, err := lp.secrets.Resolve(ctx, secret.SecretRef)
+		if err != nil {
+			return nil, err
+		}
+		secret.SecretRef = arn
+		resolved[i] = secret
+	}
+	return resolved, nil
+}
+
+// lambdaFunctionCode returns the code of a function: its container image,
+// or its deployment package in S3
+func lambdaFunctionCode(fn *schema.Lambda) *types.FunctionCode {
+	if fn.Spec.Code.ImageUri != "" {
+		return &types.FunctionCode{ImageUri: aws.String(fn.Spec.Code.ImageUri)}
+	}
+	return &types.FunctionCode{
+		S3Bucket: aws.String(fn.Spec.Code.S3Bucket),
+		S3Key:    aws.String(fn.Spec.Code.S3Key),
+	}
+}
+
+// lambdaEnvironment returns the environment variables of a function. Each
+// secret sets its variable to the ARN of the secret, never to its value.
+func lambdaEnvironment(fn *schema.Lambda, functionSecrets []schema.Secret) map[string]string {
+	if len(fn.Spec.Environment) == 0 && len(functionSecrets) == 0 {
+		return nil
+	}
+
+	envVars := make(map[string]string)
+	for k, v := range fn.Spec.Environment {
+		envVars[k] = fmt.Sprintf("%v", v)
+	}
+	for _, secret := range functionSecrets {
+		envVars[secret.EnvName()] = secret.SecretRef
+	}
+	return envVars
+}
+
 // Exists checks if a Lambda function exists
 func (lp *LambdaProvider) Exists(ctx context.Context, resourceID string, opts *provider.ResourceOptions) (bool, error) {
 	_, err := lp.client.GetFunction(ctx, &lambda.GetFunctionInput{
 		FunctionName: aws.String(resourceID),
 	})
 	if err != nil {
-		// Check if it's a "not found" error
-		return false, nil
+		var notFound *types.ResourceNotFoundException
+		if errors.As(err, &notFound) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to get function %s: %w", resourceID, err)
 	}
 	return true, nil
 }
@@ -397,3 +631,15 @@ func (lp *LambdaProvider) GetOutputs(ctx context.Context, resourceID string, opt
 	return result.Outputs, nil
 }
 
+// lambdaFunctionName returns the name of the function of a resource
+func lambdaFunctionName(resource *schema.Lambda, opts *provider.ResourceOptions) string {
+	return fmt.Sprintf("%s-%s-%s", opts.StackName, opts.ServiceName, resource.Metadata.Name)
+}
+
+// retryOnConflict makes the SDK retry a call on an existing function that
+// conflicts with an update of the function still in progress. Calls that
+// create something are not retried, since their conflict means that it
+// exists already.
+func retryOnConflict(o *lambda.Options) {
+	o.Retryer = retry.AddWithErrorCodes(o.Retryer, "ResourceConflictException")
+}
@@ -0,0 +1,189 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/applicationautoscaling"
+	"github.com/aws/aws-sdk-go-v2/service/applicationautoscaling/types"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	"github.com/yourusername/panka/pkg/parser/schema"
+	"go.uber.org/zap"
+)
+
+// lambdaLiveAlias is the alias that carries the provisioned concurrency of
+// a function
+const lambdaLiveAlias = "live"
+
+// lambdaConcurrency reconciles the provisioned concurrency of Lambda
+// functions. Provisioned concurrency applies to a version or an alias, so
+// each apply publishes a version and points the live alias to it. The
+// alias is an Application Auto Scaling target that tracks the utilization
+// of its provisioned instances.
+type lambdaConcurrency struct {
+	provider *Provider
+	lambda   *lambda.Client
+	scaling  *appAutoScalingClient
+}
+
+// newLambdaConcurrency creates the provisioned concurrency reconciler of the
+// Lambda provider
+func newLambdaConcurrency(p *Provider, client *lambda.Client) *lambdaConcurrency {
+	return &lambdaConcurrency{
+		provider: p,
+		lambda:   client,
+		scaling:  newAppAutoScalingClient(p),
+	}
+}
+
+// reconcile brings the provisioned concurrency of a function to the
+// declared one and adds the live alias to its outputs. A function without
+// provisioned concurrency has its live alias removed.
+func (lc *lambdaConcurrency) reconcile(ctx context.Context, fn *schema.Lambda, functionName string, outputs map[string]string) error {
+	provisioned := fn.Spec.ProvisionedConcurrency
+	if provisioned == nil {
+		return lc.remove(ctx, functionName)
+	}
+
+	version, err := lc.lambda.PublishVersion(ctx, &lambda.PublishVersionInput{
+		FunctionName: aws.String(functionName),
+	}, retryOnConflict)
+	if err != nil {
+		return fmt.Errorf("failed to publish version: %w", err)
+	}
+
+	aliasARN, err := lc.ensureAlias(ctx, functionName, aws.ToString(version.Version))
+	if err != nil {
+		return err
+	}
+
+	lc.provider.GetLogger().Info("Configuring Lambda provisioned concurrency",
+		zap.String("function", functionName),
+		zap.String("version", aws.ToString(version.Version)),
+		zap.Int("min", provisioned.MinCapacity),
+		zap.Int("max", provisioned.MaxCapacity),
+	)
+
+	if err := lc.ensureProvisioned(ctx, functionName, provisioned.MinCapacity); err != nil {
+		return err
+	}
+
+	config, err := lambdaScaling(provisioned, functionName)
+	if err != nil {
+		return err
+	}
+	if err := lc.scaling.apply(ctx, config); err != nil {
+		return err
+	}
+
+	outputs["alias_name"] = lambdaLiveAlias
+	outputs["alias_arn"] = aliasARN
+	outputs["version"] = aws.ToString(version.Version)
+	return nil
+}
+
+// ensureAlias points the live alias of a function to a version and returns
+// the alias ARN
+func (lc *lambdaConcurrency) ensureAlias(ctx context.Context, functionName, version string) (string, error) {
+	updated, err := lc.lambda.UpdateAlias(ctx, &lambda.UpdateAliasInput{
+		FunctionName:    aws.String(functionName),
+		Name:            aws.String(lambdaLiveAlias),
+		FunctionVersion: aws.String(version),
+	}, retryOnConflict)
+	if err == nil {
+		return aws.ToString(updated.AliasArn), nil
+	}
+	if !isAPIErrorCode(err, "ResourceNotFoundException") {
+		return "", fmt.Errorf("failed to update alias %s: %w", lambdaLiveAlias, err)
+	}
+
+	created, err := lc.lambda.CreateAlias(ctx, &lambda.CreateAliasInput{
+		FunctionName:    aws.String(functionName),
+		Name:            aws.String(lambdaLiveAlias),
+		FunctionVersion: aws.String(version),
+		Description:     aws.String("Managed by panka; carries the provisioned concurrency of the function"),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to create alias %s: %w", lambdaLiveAlias, err)
+	}
+	return aws.ToString(created.AliasArn), nil
+}
+
+// ensureProvisioned provisions the minimum concurrency on the live alias of
+// a function that has none yet. Once provisioned, autoscaling owns it.
+func (lc *lambdaConcurrency) ensureProvisioned(ctx context.Context, functionName string, minCapacity int) error {
+	_, err := lc.lambda.GetProvisionedConcurrencyConfig(ctx, &lambda.GetProvisionedConcurrencyConfigInput{
+		FunctionName: aws.String(functionName),
+		Qualifier:    aws.String(lambdaLiveAlias),
+	})
+	if err == nil {
+		return nil
+	}
+	if !isAPIErrorCode(err, "ProvisionedConcurrencyConfigNotFoundException") {
+		return fmt.Errorf("failed to get provisioned concurrency: %w", err)
+	}
+
+	if _, err := lc.lambda.PutProvisionedConcurrencyConfig(ctx, &lambda.PutProvisionedConcurrencyConfigInput{
+		FunctionName:                    aws.String(functionName),
+		Qualifier:                       aws.String(lambdaLiveAlias),
+		ProvisionedConcurrentExecutions: aws.Int32(int32(minCapacity)),
+	}, retryOnConflict); err != nil {
+		return fmt.Errorf("failed to provision concurrency: %w", err)
+	}
+	return nil
+}
+
+// remove deregisters the scalable target of the live alias of a function,
+// which deletes its policies and scheduled actions, and deletes the alias
+// with its provisioned concurrency
+func (lc *lambdaConcurrency) remove(ctx context.Context, functionName string) error {
+	if err := lc.scaling.deregisterScalableTarget(ctx, lambdaScalableTarget(functionName, lambdaLiveAlias, 0, 0)); err != nil {
+		return fmt.Errorf("failed to deregister scalable target: %w", err)
+	}
+
+	_, err := lc.lambda.DeleteAlias(ctx, &lambda.DeleteAliasInput{
+		FunctionName: aws.String(functionName),
+		Name:         aws.String(lambdaLiveAlias),
+	})
+	if err != nil && !isAPIErrorCode(err, "ResourceNotFoundException") {
+		return fmt.Errorf("failed to delete alias %s: %w", lambdaLiveAlias, err)
+	}
+	return nil
+}
+
+// aliasARN returns the ARN of the live alias of a function, or "" when the
+// function has none
+func (lc *lambdaConcurrency) aliasARN(ctx context.Context, functionName string) (string, error) {
+	alias, err := lc.lambda.GetAlias(ctx, &lambda.GetAliasInput{
+		FunctionName: aws.String(functionName),
+		Name:         aws.String(lambdaLiveAlias),
+	})
+	if err != nil {
+		if isAPIErrorCode(err, "ResourceNotFoundException") {
+			return "", nil
+		}
+		return "", err
+	}
+	return aws.ToString(alias.AliasArn), nil
+}
+
+// lambdaScaling returns the scaling of the provisioned concurrency of the
+// live alias of a function
+func lambdaScaling(provisioned *schema.ProvisionedConcurrency, functionName string) (*scalingConfiguration, error) {
+	target := lambdaScalableTarget(functionName, lambdaLiveAlias, provisioned.MinCapacity, provisioned.MaxCapacity)
+	actions, err := scheduledActions(functionName, target, provisioned.Schedules)
+	if err != nil {
+		return nil, err
+	}
+
+	// The utilization of provisioned concurrency goes from 0 to 1
+	return &scalingConfiguration{
+		target: target,
+		policies: []*applicationautoscaling.PutScalingPolicyInput{
+			targetTrackingPolicy(functionName+"-provisioned-concurrency", target, types.MetricTypeLambdaProvisionedConcurrencyUtilization,
+				float64(provisioned.TargetPercent())/100, provisioned.ScaleInCooldown, provisioned.ScaleOutCooldown),
+		},
+		actions: actions,
+	}, nil
+}
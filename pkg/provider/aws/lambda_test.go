@@ -0,0 +1,57 @@
+package aws
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	autoscalingtypes "github.com/aws/aws-sdk-go-v2/service/applicationautoscaling/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourusername/panka/pkg/parser/schema"
+)
+
+func TestLambdaEnvironment(t *testing.T) {
+	fn := schema.NewLambda("processor", "backend", "my-stack")
+	assert.Nil(t, lambdaEnvironment(fn, nil))
+
+	fn.Spec.Environment = map[string]interface{}{"LOG_LEVEL": "info", "WORKERS": 4}
+	env := lambdaEnvironment(fn, []schema.Secret{
+		{Name: "API_TOKEN", SecretRef: "arn:aws:secretsmanager:us-east-1:123456789012:secret:token-AbCdEf"},
+		{Name: "db", SecretRef: "arn:aws:ssm:us-east-1:123456789012:parameter/my-stack/db", EnvVar: "DB_PASSWORD_ARN"},
+	})
+
+	// Secrets are passed as the ARN of the secret, not its value
+	assert.Equal(t, map[string]string{
+		"LOG_LEVEL":       "info",
+		"WORKERS":         "4",
+		"API_TOKEN":       "arn:aws:secretsmanager:us-east-1:123456789012:secret:token-AbCdEf",
+		"DB_PASSWORD_ARN": "arn:aws:ssm:us-east-1:123456789012:parameter/my-stack/db",
+	}, env)
+}
+
+func TestLambdaScaling(t *testing.T) {
+	night := 0
+	config, err := lambdaScaling(&schema.ProvisionedConcurrency{
+		MinCapacity:       2,
+		MaxCapacity:       20,
+		TargetUtilization: 80,
+		Schedules:         []schema.ScheduledScaling{{Name: "night", Schedule: "0 23 * * *", MinCapacity: &night}},
+	}, "my-stack-backend-processor")
+	require.NoError(t, err)
+
+	assert.Equal(t, autoscalingtypes.ServiceNamespaceLambda, config.target.ServiceNamespace)
+	assert.Equal(t, "function:my-stack-backend-processor:live", config.target.ResourceID)
+	assert.Equal(t, autoscalingtypes.ScalableDimensionLambdaFunctionProvisionedConcurrency, config.target.ScalableDimension)
+	assert.Equal(t, 2, *config.target.MinCapacity)
+	assert.Equal(t, 20, *config.target.MaxCapacity)
+
+	// Utilization is tracked as a fraction of the provisioned instances
+	require.Len(t, config.policies, 1)
+	policy := config.policies[0].TargetTrackingScalingPolicyConfiguration
+	assert.Equal(t, autoscalingtypes.MetricTypeLambdaProvisionedConcurrencyUtilization, policy.PredefinedMetricSpecification.PredefinedMetricType)
+	assert.Equal(t, 0.8, aws.ToFloat64(policy.TargetValue))
+
+	require.Len(t, config.actions, 1)
+	assert.Equal(t, "my-stack-backend-processor-night", aws.ToString(config.actions[0].ScheduledActionName))
+	assert.Equal(t, "function:my-stack-backend-processor:live", aws.ToString(config.actions[0].ResourceId))
+}
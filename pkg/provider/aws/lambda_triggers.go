@@ -0,0 +1,732 @@
+package aws
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	dynamodbtypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	eventbridgetypes "github.com/aws/aws-sdk-go-v2/service/eventbridge/types"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	lambdatypes "github.com/aws/aws-sdk-go-v2/service/lambda/types"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	"github.com/yourusername/panka/pkg/parser/schema"
+	"go.uber.org/zap"
+)
+
+const (
+	// triggerStatementPrefix starts the IDs of the invoke permissions panka
+	// grants to trigger sources
+	triggerStatementPrefix = "panka-"
+
+	// eventRuleTargetID is the ID of the function in the targets of its
+	// EventBridge rules
+	eventRuleTargetID = "lambda"
+
+	// eventRuleNameMaxLength is the longest name EventBridge accepts
+	eventRuleNameMaxLength = 64
+
+	// defaultS3TriggerEvent is the event of S3 triggers without events
+	defaultS3TriggerEvent = "s3:ObjectCreated:*"
+)
+
+// triggerPrincipals are the services that invoke a function for each push
+// trigger type
+var triggerPrincipals = map[string]string{
+	schema.TriggerSNS:         "sns.amazonaws.com",
+	schema.TriggerS3:          "s3.amazonaws.com",
+	schema.TriggerEventBridge: "events.amazonaws.com",
+}
+
+// lambdaTriggers reconciles the triggers of Lambda functions. Polled sources
+// (SQS, DynamoDB streams and Kinesis) are event source mappings. Push sources
+// (SNS, S3 and EventBridge) are an invoke permission on the function plus a
+// subscription, bucket notification or rule on the source. The permissions
+// record the push triggers panka manages, so that the ones no longer declared
+// can be removed.
+type lambdaTriggers struct {
+	provider *Provider
+	lambda   *lambda.Client
+	sns      *sns.Client
+	s3       *s3.Client
+	dynamodb *dynamodb.Client
+	events   *eventbridge.Client
+}
+
+// newLambdaTriggers creates the trigger reconciler of the Lambda provider
+func newLambdaTriggers(p *Provider, client *lambda.Client) *lambdaTriggers {
+	cfg := p.GetConfig()
+	return &lambdaTriggers{
+		provider: p,
+		lambda:   client,
+		sns:      sns.NewFromConfig(cfg),
+		s3:       s3.NewFromConfig(cfg),
+		dynamodb: dynamodb.NewFromConfig(cfg),
+		events:   eventbridge.NewFromConfig(cfg),
+	}
+}
+
+// eventSourceMapping is an event source mapping of a function. A zero
+// BatchSize leaves the default of the source.
+type eventSourceMapping struct {
+	UUID      string
+	SourceARN string
+	BatchSize int32
+}
+
+// pushTrigger is a source that invokes a function. For S3 triggers the
+// source is the bucket, which may have several notifications.
+type pushTrigger struct {
+	Type      string
+	SourceARN string
+	Schedule  string
+	Trigger   *schema.LambdaTrigger
+}
+
+// statementID returns the ID of the invoke permission of a trigger
+func (t pushTrigger) statementID() string {
+	return triggerStatementPrefix + t.Type + "-" + shortHash(t.SourceARN)
+}
+
+// reconcile brings the triggers of a function to the declared ones
+func (lt *lambdaTriggers) reconcile(ctx context.Context, fn *schema.Lambda, functionName, functionARN string) error {
+	var mappings []eventSourceMapping
+	var pushes []pushTrigger
+
+	for i := range fn.Spec.Triggers {
+		trigger := &fn.Spec.Triggers[i]
+
+		switch {
+		case trigger.PollsSource():
+			mapping, err := lt.eventSourceMapping(ctx, trigger)
+			if err != nil {
+				return err
+			}
+			mappings = append(mappings, mapping)
+
+		case trigger.Type == schema.TriggerAPIGateway:
+			lt.provider.GetLogger().Warn("API Gateway triggers are not supported yet, skipping",
+				zap.String("function", functionName),
+			)
+
+		default:
+			push, err := desiredPushTrigger(trigger, functionName, functionARN)
+			if err != nil {
+				return err
+			}
+			pushes = append(pushes, push)
+		}
+	}
+
+	if err := lt.reconcileEventSourceMappings(ctx, functionName, mappings); err != nil {
+		return fmt.Errorf("failed to reconcile event source mappings: %w", err)
+	}
+	if err := lt.reconcilePushTriggers(ctx, fn, functionName, functionARN, pushes); err != nil {
+		return fmt.Errorf("failed to reconcile triggers: %w", err)
+	}
+	return nil
+}
+
+// remove deletes all the triggers of a function, before the function itself
+func (lt *lambdaTriggers) remove(ctx context.Context, functionName string) error {
+	function, err := lt.lambda.GetFunction(ctx, &lambda.GetFunctionInput{
+		FunctionName: aws.String(functionName),
+	})
+	if err != nil {
+		if isAPIErrorCode(err, "ResourceNotFoundException") {
+			return nil
+		}
+		return fmt.Errorf("failed to get function: %w", err)
+	}
+
+	if err := lt.reconcileEventSourceMappings(ctx, functionName, nil); err != nil {
+		return fmt.Errorf("failed to delete event source mappings: %w", err)
+	}
+	if err := lt.reconcilePushTriggers(ctx, nil, functionName, aws.ToString(function.Configuration.FunctionArn), nil); err != nil {
+		return fmt.Errorf("failed to delete triggers: %w", err)
+	}
+	return nil
+}
+
+// eventSourceMapping returns the event source mapping a polled trigger
+// needs
+func (lt *lambdaTriggers) eventSourceMapping(ctx context.Context, trigger *schema.LambdaTrigger) (eventSourceMapping, error) {
+	sourceARN, err := triggerSourceARN(trigger)
+	if err != nil {
+		return eventSourceMapping{}, err
+	}
+
+	size, err := trigger.BatchSizeCount()
+	if err != nil {
+		return eventSourceMapping{}, err
+	}
+
+	// Functions read the stream of a table, which is enabled if needed
+	if trigger.Type == schema.TriggerDynamoDB && !strings.Contains(sourceARN, "/stream/") {
+		sourceARN, err = lt.tableStream(ctx, sourceARN)
+		if err != nil {
+			return eventSourceMapping{}, err
+		}
+	}
+
+	return eventSourceMapping{SourceARN: sourceARN, BatchSize: int32(size)}, nil
+}
+
+// tableStream returns the ARN of the stream of a DynamoDB table, enabling
+// the stream when the table has none
+func (lt *lambdaTriggers) tableStream(ctx context.Context, tableARN string) (string, error) {
+	tableName := tableARN[strings.LastIndex(tableARN, "/")+1:]
+
+	table, err := lt.dynamodb.DescribeTable(ctx, &dynamodb.DescribeTableInput{
+		TableName: aws.String(tableName),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to describe table %s: %w", tableName, err)
+	}
+	if spec := table.Table.StreamSpecification; spec != nil && aws.ToBool(spec.StreamEnabled) {
+		return aws.ToString(table.Table.LatestStreamArn), nil
+	}
+
+	lt.provider.GetLogger().Info("Enabling DynamoDB stream", zap.String("table", tableName))
+
+	updated, err := lt.dynamodb.UpdateTable(ctx, &dynamodb.UpdateTableInput{
+		TableName: aws.String(tableName),
+		StreamSpecification: &dynamodbtypes.StreamSpecification{
+			StreamEnabled:  aws.Bool(true),
+			StreamViewType: dynamodbtypes.StreamViewTypeNewAndOldImages,
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to enable the stream of table %s: %w", tableName, err)
+	}
+
+	waiter := dynamodb.NewTableExistsWaiter(lt.dynamodb)
+	if err := waiter.Wait(ctx, &dynamodb.DescribeTableInput{TableName: aws.String(tableName)}, ecsWaitTime(ctx)); err != nil {
+		return "", fmt.Errorf("table %s did not become active: %w", tableName, err)
+	}
+
+	return aws.ToString(updated.TableDescription.LatestStreamArn), nil
+}
+
+// reconcileEventSourceMappings creates, updates and deletes the event source
+// mappings of a function to match the desired ones
+func (lt *lambdaTriggers) reconcileEventSourceMappings(ctx context.Context, functionName string, desired []eventSourceMapping) error {
+	var current []eventSourceMapping
+	paginator := lambda.NewListEventSourceMappingsPaginator(lt.lambda, &lambda.ListEventSourceMappingsInput{
+		FunctionName: aws.String(functionName),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return err
+		}
+		for _, m := range page.EventSourceMappings {
+			current = append(current, eventSourceMapping{
+				UUID:      aws.ToString(m.UUID),
+				SourceARN: aws.ToString(m.EventSourceArn),
+				BatchSize: aws.ToInt32(m.BatchSize),
+			})
+		}
+	}
+
+	create, update, remove := eventSourceMappingChanges(current, desired)
+
+	for _, m := range create {
+		input := &lambda.CreateEventSourceMappingInput{
+			FunctionName:   aws.String(functionName),
+			EventSourceArn: aws.String(m.SourceARN),
+			Enabled:        aws.Bool(true),
+		}
+		if m.BatchSize > 0 {
+			input.BatchSize = aws.Int32(m.BatchSize)
+		}
+		// Streams are read from new records on
+		if arnPart(m.SourceARN, 2) != "sqs" {
+			input.StartingPosition = lambdatypes.EventSourcePositionLatest
+		}
+		if _, err := lt.lambda.CreateEventSourceMapping(ctx, input); err != nil {
+			return fmt.Errorf("failed to create event source mapping for %s: %w", m.SourceARN, err)
+		}
+		lt.provider.GetLogger().Info("Event source mapping created",
+			zap.String("function", functionName),
+			zap.String("source", m.SourceARN),
+		)
+	}
+
+	for _, m := range update {
+		_, err := lt.lambda.UpdateEventSourceMapping(ctx, &lambda.UpdateEventSourceMappingInput{
+			UUID:      aws.String(m.UUID),
+			BatchSize: aws.Int32(m.BatchSize),
+		}, retryOnConflict)
+		if err != nil {
+			return fmt.Errorf("failed to update event source mapping for %s: %w", m.SourceARN, err)
+		}
+	}
+
+	for _, m := range remove {
+		_, err := lt.lambda.DeleteEventSourceMapping(ctx, &lambda.DeleteEventSourceMappingInput{
+			UUID: aws.String(m.UUID),
+		})
+		if err != nil && !isAPIErrorCode(err, "ResourceNotFoundException") {
+			return fmt.Errorf("failed to delete event source mapping for %s: %w", m.SourceARN, err)
+		}
+		lt.provider.GetLogger().Info("Event source mapping deleted",
+			zap.String("function", functionName),
+			zap.String("source", m.SourceARN),
+		)
+	}
+
+	return nil
+}
+
+// reconcilePushTriggers grants the invoke permissions of the desired push
+// triggers and sets up their sources, then tears down the triggers that are
+// no longer desired. fn is nil when all triggers are removed.
+func (lt *lambdaTriggers) reconcilePushTriggers(ctx context.Context, fn *schema.Lambda, functionName, functionARN string, desired []pushTrigger) error {
+	current, err := lt.currentPushTriggers(ctx, functionName)
+	if err != nil {
+		return err
+	}
+
+	granted := make(map[string]bool, len(current))
+	for _, t := range current {
+		granted[t.statementID()] = true
+	}
+
+	wanted := make(map[string]bool, len(desired))
+	for _, t := range desired {
+		id := t.statementID()
+		if wanted[id] {
+			continue
+		}
+		wanted[id] = true
+		if granted[id] {
+			continue
+		}
+
+		input := &lambda.AddPermissionInput{
+			FunctionName: aws.String(functionName),
+			StatementId:  aws.String(id),
+			Action:       aws.String("lambda:InvokeFunction"),
+			Principal:    aws.String(triggerPrincipals[t.Type]),
+			SourceArn:    aws.String(t.SourceARN),
+		}
+		// Bucket ARNs do not name the account that owns the bucket
+		if t.Type == schema.TriggerS3 {
+			input.SourceAccount = aws.String(arnAccount(functionARN))
+		}
+		if _, err := lt.lambda.AddPermission(ctx, input); err != nil {
+			return fmt.Errorf("failed to allow %s to invoke the function: %w", t.SourceARN, err)
+		}
+	}
+
+	for _, t := range desired {
+		switch t.Type {
+		case schema.TriggerSNS:
+			_, err := lt.sns.Subscribe(ctx, &sns.SubscribeInput{
+				TopicArn: aws.String(t.SourceARN),
+				Protocol: aws.String("lambda"),
+				Endpoint: aws.String(functionARN),
+			})
+			if err != nil {
+				return fmt.Errorf("failed to subscribe to %s: %w", t.SourceARN, err)
+			}
+		case schema.TriggerEventBridge:
+			if err := lt.putEventRule(ctx, t, functionARN); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Bucket notifications are rewritten for every bucket with triggers
+	// before or after
+	var buckets []string
+	for _, t := range append(append([]pushTrigger{}, desired...), current...) {
+		if t.Type == schema.TriggerS3 {
+			buckets = append(buckets, s3BucketName(t.SourceARN))
+		}
+	}
+	for _, bucket := range uniqueSorted(buckets) {
+		if err := lt.putBucketNotifications(ctx, bucket, functionName, functionARN, fn); err != nil {
+			return err
+		}
+	}
+
+	for _, t := range current {
+		if wanted[t.statementID()] {
+			continue
+		}
+
+		switch t.Type {
+		case schema.TriggerSNS:
+			if err := lt.unsubscribe(ctx, t.SourceARN, functionARN); err != nil {
+				return err
+			}
+		case schema.TriggerEventBridge:
+			if err := lt.deleteEventRule(ctx, t.SourceARN); err != nil {
+				return err
+			}
+		}
+
+		_, err := lt.lambda.RemovePermission(ctx, &lambda.RemovePermissionInput{
+			FunctionName: aws.String(functionName),
+			StatementId:  aws.String(t.statementID()),
+		})
+		if err != nil && !isAPIErrorCode(err, "ResourceNotFoundException") {
+			return fmt.Errorf("failed to remove the permission of %s: %w", t.SourceARN, err)
+		}
+		lt.provider.GetLogger().Info("Trigger removed",
+			zap.String("function", functionName),
+			zap.String("source", t.SourceARN),
+		)
+	}
+
+	return nil
+}
+
+// currentPushTriggers returns the push triggers panka granted invoke
+// permissions to
+func (lt *lambdaTriggers) currentPushTriggers(ctx context.Context, functionName string) ([]pushTrigger, error) {
+	output, err := lt.lambda.GetPolicy(ctx, &lambda.GetPolicyInput{
+		FunctionName: aws.String(functionName),
+	})
+	if err != nil {
+		// A function without permissions has no policy
+		if isAPIErrorCode(err, "ResourceNotFoundException") {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get function policy: %w", err)
+	}
+	return parsePushTriggers(aws.ToString(output.Policy))
+}
+
+// putEventRule creates or updates the EventBridge rule of a schedule and
+// targets the function with it
+func (lt *lambdaTriggers) putEventRule(ctx context.Context, t pushTrigger, functionARN string) error {
+	name := eventRuleName(t.SourceARN)
+
+	if _, err := lt.events.PutRule(ctx, &eventbridge.PutRuleInput{
+		Name:               aws.String(name),
+		ScheduleExpression: aws.String(t.Schedule),
+		State:              eventbridgetypes.RuleStateEnabled,
+		Description:        optionalString(t.Trigger.Description),
+	}); err != nil {
+		return fmt.Errorf("failed to put rule %s: %w", name, err)
+	}
+
+	output, err := lt.events.PutTargets(ctx, &eventbridge.PutTargetsInput{
+		Rule:    aws.String(name),
+		Targets: []eventbridgetypes.Target{{Id: aws.String(eventRuleTargetID), Arn: aws.String(functionARN)}},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to target rule %s: %w", name, err)
+	}
+	if output.FailedEntryCount > 0 && len(output.FailedEntries) > 0 {
+		return fmt.Errorf("failed to target rule %s: %s", name, aws.ToString(output.FailedEntries[0].ErrorMessage))
+	}
+	return nil
+}
+
+// deleteEventRule deletes an EventBridge rule and its target
+func (lt *lambdaTriggers) deleteEventRule(ctx context.Context, ruleARN string) error {
+	name := eventRuleName(ruleARN)
+
+	_, err := lt.events.RemoveTargets(ctx, &eventbridge.RemoveTargetsInput{
+		Rule: aws.String(name),
+		Ids:  []string{eventRuleTargetID},
+	})
+	if err != nil && !isAPIErrorCode(err, "ResourceNotFoundException") {
+		return fmt.Errorf("failed to remove the targets of rule %s: %w", name, err)
+	}
+
+	_, err = lt.events.DeleteRule(ctx, &eventbridge.DeleteRuleInput{Name: aws.String(name)})
+	if err != nil && !isAPIErrorCode(err, "ResourceNotFoundException") {
+		return fmt.Errorf("failed to delete rule %s: %w", name, err)
+	}
+	return nil
+}
+
+// unsubscribe removes the subscriptions of a function to a topic
+func (lt *lambdaTriggers) unsubscribe(ctx context.Context, topicARN, functionARN string) error {
+	paginator := sns.NewListSubscriptionsByTopicPaginator(lt.sns, &sns.ListSubscriptionsByTopicInput{
+		TopicArn: aws.String(topicARN),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			if isAPIErrorCode(err, "NotFound") {
+				return nil
+			}
+			return fmt.Errorf("failed to list the subscriptions of %s: %w", topicARN, err)
+		}
+		for _, sub := range page.Subscriptions {
+			if aws.ToString(sub.Endpoint) != functionARN {
+				continue
+			}
+			_, err := lt.sns.Unsubscribe(ctx, &sns.UnsubscribeInput{SubscriptionArn: sub.SubscriptionArn})
+			if err != nil && !isAPIErrorCode(err, "NotFound") {
+				return fmt.Errorf("failed to unsubscribe from %s: %w", topicARN, err)
+			}
+		}
+	}
+	return nil
+}
+
+// putBucketNotifications replaces the notifications of a bucket to the
+// function with the S3 triggers of fn on that bucket, keeping the other
+// notifications of the bucket
+func (lt *lambdaTriggers) putBucketNotifications(ctx context.Context, bucket, functionName, functionARN string, fn *schema.Lambda) error {
+	current, err := lt.s3.GetBucketNotificationConfiguration(ctx, &s3.GetBucketNotificationConfigurationInput{
+		Bucket: aws.String(bucket),
+	})
+	if err != nil {
+		if isAPIErrorCode(err, "NoSuchBucket") {
+			return nil
+		}
+		return fmt.Errorf("failed to get the notifications of bucket %s: %w", bucket, err)
+	}
+
+	var triggers []schema.S3Trigger
+	if fn != nil {
+		for _, trigger := range fn.Spec.Triggers {
+			if trigger.Type == schema.TriggerS3 && trigger.S3 != nil && trigger.S3.Bucket == bucket {
+				triggers = append(triggers, *trigger.S3)
+			}
+		}
+	}
+
+	_, err = lt.s3.PutBucketNotificationConfiguration(ctx, &s3.PutBucketNotificationConfigurationInput{
+		Bucket:                    aws.String(bucket),
+		NotificationConfiguration: bucketNotifications(current, functionName, functionARN, triggers),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to put the notifications of bucket %s: %w", bucket, err)
+	}
+	return nil
+}
+
+// eventSourceMappingChanges returns the mappings to create, the current
+// mappings to update to a new batch size and the current mappings to
+// delete. Mappings are matched by source ARN.
+func eventSourceMappingChanges(current, desired []eventSourceMapping) (create, update, remove []eventSourceMapping) {
+	bySource := make(map[string]eventSourceMapping, len(current))
+	for _, m := range current {
+		bySource[m.SourceARN] = m
+	}
+
+	wanted := make(map[string]bool, len(desired))
+	for _, m := range desired {
+		if wanted[m.SourceARN] {
+			continue
+		}
+		wanted[m.SourceARN] = true
+
+		existing, ok := bySource[m.SourceARN]
+		if !ok {
+			create = append(create, m)
+			continue
+		}
+		if m.BatchSize > 0 && m.BatchSize != existing.BatchSize {
+			existing.BatchSize = m.BatchSize
+			update = append(update, existing)
+		}
+	}
+
+	for _, m := range current {
+		if !wanted[m.SourceARN] {
+			remove = append(remove, m)
+		}
+	}
+	return create, update, remove
+}
+
+// desiredPushTrigger returns the push trigger of a declared SNS, S3 or
+// EventBridge trigger
+func desiredPushTrigger(trigger *schema.LambdaTrigger, functionName, functionARN string) (pushTrigger, error) {
+	push := pushTrigger{Type: trigger.Type, Trigger: trigger}
+
+	switch trigger.Type {
+	case schema.TriggerSNS:
+		sourceARN, err := triggerSourceARN(trigger)
+		if err != nil {
+			return pushTrigger{}, err
+		}
+		push.SourceARN = sourceARN
+
+	case schema.TriggerS3:
+		if trigger.S3 == nil || trigger.S3.Bucket == "" {
+			return pushTrigger{}, fmt.Errorf("s3 trigger has no bucket")
+		}
+		push.SourceARN = fmt.Sprintf("arn:%s:s3:::%s", arnPart(functionARN, 1), trigger.S3.Bucket)
+
+	case schema.TriggerEventBridge:
+		expression, err := schema.ScheduleExpression(trigger.Schedule)
+		if err != nil {
+			return pushTrigger{}, err
+		}
+		name := shortName(functionName+"-"+shortHash(expression), eventRuleNameMaxLength)
+		push.Schedule = expression
+		push.SourceARN = fmt.Sprintf("arn:%s:events:%s:%s:rule/%s",
+			arnPart(functionARN, 1), arnPart(functionARN, 3), arnAccount(functionARN), name)
+
+	default:
+		return pushTrigger{}, fmt.Errorf("unknown trigger type %q", trigger.Type)
+	}
+
+	return push, nil
+}
+
+// parsePushTriggers returns the push triggers recorded in the permissions
+// of a function policy
+func parsePushTriggers(policy string) ([]pushTrigger, error) {
+	if policy == "" {
+		return nil, nil
+	}
+
+	var document struct {
+		Statement []struct {
+			Sid       string `json:"Sid"`
+			Condition struct {
+				ArnLike map[string]string `json:"ArnLike"`
+			} `json:"Condition"`
+		} `json:"Statement"`
+	}
+	if err := json.Unmarshal([]byte(policy), &document); err != nil {
+		return nil, fmt.Errorf("failed to parse function policy: %w", err)
+	}
+
+	var triggers []pushTrigger
+	for _, statement := range document.Statement {
+		if !strings.HasPrefix(statement.Sid, triggerStatementPrefix) {
+			continue
+		}
+		rest := strings.TrimPrefix(statement.Sid, triggerStatementPrefix)
+		i := strings.LastIndex(rest, "-")
+		if i < 0 {
+			continue
+		}
+		triggerType := rest[:i]
+		if _, ok := triggerPrincipals[triggerType]; !ok {
+			continue
+		}
+		sourceARN := statement.Condition.ArnLike["AWS:SourceArn"]
+		if sourceARN == "" {
+			continue
+		}
+		triggers = append(triggers, pushTrigger{Type: triggerType, SourceARN: sourceARN})
+	}
+	return triggers, nil
+}
+
+// bucketNotifications returns the notification configuration of a bucket
+// with its notifications to the function replaced by the given triggers
+func bucketNotifications(current *s3.GetBucketNotificationConfigurationOutput, functionName, functionARN string, triggers []schema.S3Trigger) *s3types.NotificationConfiguration {
+	config := &s3types.NotificationConfiguration{
+		EventBridgeConfiguration: current.EventBridgeConfiguration,
+		QueueConfigurations:      current.QueueConfigurations,
+		TopicConfigurations:      current.TopicConfigurations,
+	}
+
+	for _, c := range current.LambdaFunctionConfigurations {
+		if aws.ToString(c.LambdaFunctionArn) != functionARN {
+			config.LambdaFunctionConfigurations = append(config.LambdaFunctionConfigurations, c)
+		}
+	}
+
+	for i, trigger := range triggers {
+		events := trigger.Events
+		if len(events) == 0 {
+			events = []string{defaultS3TriggerEvent}
+		}
+
+		c := s3types.LambdaFunctionConfiguration{
+			Id:                aws.String(fmt.Sprintf("%s-%d", functionName, i)),
+			LambdaFunctionArn: aws.String(functionARN),
+		}
+		for _, event := range events {
+			c.Events = append(c.Events, s3types.Event(event))
+		}
+
+		var rules []s3types.FilterRule
+		if trigger.Prefix != "" {
+			rules = append(rules, s3types.FilterRule{Name: s3types.FilterRuleNamePrefix, Value: aws.String(trigger.Prefix)})
+		}
+		if trigger.Suffix != "" {
+			rules = append(rules, s3types.FilterRule{Name: s3types.FilterRuleNameSuffix, Value: aws.String(trigger.Suffix)})
+		}
+		if len(rules) > 0 {
+			c.Filter = &s3types.NotificationConfigurationFilter{Key: &s3types.S3KeyFilter{FilterRules: rules}}
+		}
+
+		config.LambdaFunctionConfigurations = append(config.LambdaFunctionConfigurations, c)
+	}
+
+	return config
+}
+
+// triggerSourceARN returns the ARN of the source of a trigger, which is set
+// from the outputs of its component before the function is applied
+func triggerSourceARN(trigger *schema.LambdaTrigger) (string, error) {
+	if trigger.Source == nil {
+		return "", fmt.Errorf("%s trigger has no source", trigger.Type)
+	}
+	if trigger.Source.Arn == "" {
+		return "", fmt.Errorf("%s trigger source %s has no ARN, deploy it first", trigger.Type, trigger.Source.Component)
+	}
+	return trigger.Source.Arn, nil
+}
+
+// eventRuleName returns the name of an EventBridge rule from its ARN
+func eventRuleName(ruleARN string) string {
+	return ruleARN[strings.LastIndex(ruleARN, "/")+1:]
+}
+
+// s3BucketName returns the name of a bucket from its ARN
+func s3BucketName(bucketARN string) string {
+	return bucketARN[strings.LastIndex(bucketARN, ":")+1:]
+}
+
+// arnAccount returns the account ID of an ARN
+func arnAccount(arn string) string {
+	return arnPart(arn, 4)
+}
+
+// arnPart returns a colon-separated field of an ARN: 1 is the partition,
+// 2 the service, 3 the region and 4 the account ID
+func arnPart(arn string, i int) string {
+	parts := strings.SplitN(arn, ":", 6)
+	if i >= len(parts) {
+		return ""
+	}
+	return parts[i]
+}
+
+// shortHash returns a short hash of a value, for names derived from values
+// that are too long or not allowed in names
+func shortHash(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])[:8]
+}
+
+// uniqueSorted returns the distinct values of a list in order
+func uniqueSorted(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	var unique []string
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			unique = append(unique, v)
+		}
+	}
+	sort.Strings(unique)
+	return unique
+}
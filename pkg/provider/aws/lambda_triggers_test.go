@@ -0,0 +1,161 @@
+package aws
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourusername/panka/pkg/parser/schema"
+)
+
+const testFunctionARN = "arn:aws:lambda:us-east-1:123456789012:function:my-stack-backend-processor"
+
+func TestEventSourceMappingChanges(t *testing.T) {
+	current := []eventSourceMapping{
+		{UUID: "1", SourceARN: "arn:aws:sqs:us-east-1:123456789012:orders", BatchSize: 10},
+		{UUID: "2", SourceARN: "arn:aws:sqs:us-east-1:123456789012:refunds", BatchSize: 10},
+		{UUID: "3", SourceARN: "arn:aws:kinesis:us-east-1:123456789012:stream/clicks", BatchSize: 100},
+	}
+	desired := []eventSourceMapping{
+		{SourceARN: "arn:aws:sqs:us-east-1:123456789012:orders", BatchSize: 5},
+		// The default batch size keeps the current one
+		{SourceARN: "arn:aws:kinesis:us-east-1:123456789012:stream/clicks"},
+		{SourceARN: "arn:aws:dynamodb:us-east-1:123456789012:table/users/stream/2026-01-01T00:00:00.000"},
+	}
+
+	create, update, remove := eventSourceMappingChanges(current, desired)
+	assert.Equal(t, []eventSourceMapping{desired[2]}, create)
+	assert.Equal(t, []eventSourceMapping{
+		{UUID: "1", SourceARN: "arn:aws:sqs:us-east-1:123456789012:orders", BatchSize: 5},
+	}, update)
+	assert.Equal(t, []eventSourceMapping{current[1]}, remove)
+
+	// Without triggers every mapping is deleted
+	_, _, remove = eventSourceMappingChanges(current, nil)
+	assert.Equal(t, current, remove)
+}
+
+func TestDesiredPushTrigger(t *testing.T) {
+	functionName := "my-stack-backend-processor"
+
+	topic := &schema.LambdaTrigger{Type: schema.TriggerSNS, Source: &schema.TriggerSource{Component: "events", Arn: "arn:aws:sns:us-east-1:123456789012:events"}}
+	push, err := desiredPushTrigger(topic, functionName, testFunctionARN)
+	require.NoError(t, err)
+	assert.Equal(t, "arn:aws:sns:us-east-1:123456789012:events", push.SourceARN)
+	assert.Regexp(t, `^panka-sns-[0-9a-f]{8}$`, push.statementID())
+
+	bucket := &schema.LambdaTrigger{Type: schema.TriggerS3, S3: &schema.S3Trigger{Bucket: "uploads"}}
+	push, err = desiredPushTrigger(bucket, functionName, testFunctionARN)
+	require.NoError(t, err)
+	assert.Equal(t, "arn:aws:s3:::uploads", push.SourceARN)
+	assert.Equal(t, "uploads", s3BucketName(push.SourceARN))
+
+	schedule := &schema.LambdaTrigger{Type: schema.TriggerEventBridge, Schedule: "0 * * * *"}
+	push, err = desiredPushTrigger(schedule, functionName, testFunctionARN)
+	require.NoError(t, err)
+	assert.Equal(t, "cron(0 * * * ? *)", push.Schedule)
+	assert.Regexp(t, `^arn:aws:events:us-east-1:123456789012:rule/my-stack-backend-processor-[0-9a-f]{8}$`, push.SourceARN)
+	assert.LessOrEqual(t, len(eventRuleName(push.SourceARN)), eventRuleNameMaxLength)
+
+	// Sources must be resolved to an ARN
+	_, err = desiredPushTrigger(&schema.LambdaTrigger{Type: schema.TriggerSNS, Source: &schema.TriggerSource{Component: "events"}}, functionName, testFunctionARN)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "events")
+}
+
+func TestParsePushTriggers(t *testing.T) {
+	policy := `{
+		"Version": "2012-10-17",
+		"Statement": [
+			{
+				"Sid": "panka-sns-1a2b3c4d",
+				"Effect": "Allow",
+				"Principal": {"Service": "sns.amazonaws.com"},
+				"Action": "lambda:InvokeFunction",
+				"Resource": "` + testFunctionARN + `",
+				"Condition": {"ArnLike": {"AWS:SourceArn": "arn:aws:sns:us-east-1:123456789012:events"}}
+			},
+			{
+				"Sid": "panka-eventbridge-5e6f7a8b",
+				"Effect": "Allow",
+				"Principal": {"Service": "events.amazonaws.com"},
+				"Action": "lambda:InvokeFunction",
+				"Resource": "` + testFunctionARN + `",
+				"Condition": {"ArnLike": {"AWS:SourceArn": "arn:aws:events:us-east-1:123456789012:rule/nightly"}}
+			},
+			{
+				"Sid": "manual-permission",
+				"Effect": "Allow",
+				"Principal": {"Service": "apigateway.amazonaws.com"},
+				"Action": "lambda:InvokeFunction",
+				"Resource": "` + testFunctionARN + `"
+			}
+		]
+	}`
+
+	triggers, err := parsePushTriggers(policy)
+	require.NoError(t, err)
+	assert.Equal(t, []pushTrigger{
+		{Type: schema.TriggerSNS, SourceARN: "arn:aws:sns:us-east-1:123456789012:events"},
+		{Type: schema.TriggerEventBridge, SourceARN: "arn:aws:events:us-east-1:123456789012:rule/nightly"},
+	}, triggers)
+	assert.Equal(t, "nightly", eventRuleName(triggers[1].SourceARN))
+
+	triggers, err = parsePushTriggers("")
+	require.NoError(t, err)
+	assert.Empty(t, triggers)
+
+	_, err = parsePushTriggers("{")
+	assert.Error(t, err)
+}
+
+func TestBucketNotifications(t *testing.T) {
+	other := "arn:aws:lambda:us-east-1:123456789012:function:other"
+	current := &s3.GetBucketNotificationConfigurationOutput{
+		LambdaFunctionConfigurations: []s3types.LambdaFunctionConfiguration{
+			{Id: aws.String("other"), LambdaFunctionArn: aws.String(other), Events: []s3types.Event{"s3:ObjectRemoved:*"}},
+			{Id: aws.String("old"), LambdaFunctionArn: aws.String(testFunctionARN), Events: []s3types.Event{"s3:ObjectCreated:*"}},
+		},
+		QueueConfigurations: []s3types.QueueConfiguration{
+			{Id: aws.String("queue"), QueueArn: aws.String("arn:aws:sqs:us-east-1:123456789012:uploads")},
+		},
+	}
+
+	config := bucketNotifications(current, "processor", testFunctionARN, []schema.S3Trigger{
+		{Bucket: "uploads", Prefix: "images/", Suffix: ".jpg"},
+		{Bucket: "uploads", Events: []string{"s3:ObjectRemoved:Delete"}},
+	})
+
+	// Other notifications of the bucket are kept
+	assert.Equal(t, current.QueueConfigurations, config.QueueConfigurations)
+	require.Len(t, config.LambdaFunctionConfigurations, 3)
+	assert.Equal(t, other, aws.ToString(config.LambdaFunctionConfigurations[0].LambdaFunctionArn))
+
+	images := config.LambdaFunctionConfigurations[1]
+	assert.Equal(t, "processor-0", aws.ToString(images.Id))
+	assert.Equal(t, []s3types.Event{defaultS3TriggerEvent}, images.Events)
+	assert.Equal(t, []s3types.FilterRule{
+		{Name: s3types.FilterRuleNamePrefix, Value: aws.String("images/")},
+		{Name: s3types.FilterRuleNameSuffix, Value: aws.String(".jpg")},
+	}, images.Filter.Key.FilterRules)
+
+	deletes := config.LambdaFunctionConfigurations[2]
+	assert.Equal(t, []s3types.Event{"s3:ObjectRemoved:Delete"}, deletes.Events)
+	assert.Nil(t, deletes.Filter)
+
+	// Without triggers the notifications to the function are removed
+	config = bucketNotifications(current, "processor", testFunctionARN, nil)
+	require.Len(t, config.LambdaFunctionConfigurations, 1)
+	assert.Equal(t, "other", aws.ToString(config.LambdaFunctionConfigurations[0].Id))
+}
+
+func TestARNParts(t *testing.T) {
+	assert.Equal(t, "aws", arnPart(testFunctionARN, 1))
+	assert.Equal(t, "lambda", arnPart(testFunctionARN, 2))
+	assert.Equal(t, "us-east-1", arnPart(testFunctionARN, 3))
+	assert.Equal(t, "123456789012", arnAccount(testFunctionARN))
+	assert.Empty(t, arnPart("not-an-arn", 3))
+}
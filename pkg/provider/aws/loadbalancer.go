@@ -0,0 +1,1015 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	elbv2 "github.com/aws/aws-sdk-go-v2/service/elasticloadbalancingv2"
+	"github.com/aws/aws-sdk-go-v2/service/elasticloadbalancingv2/types"
+	"github.com/yourusername/panka/pkg/parser/schema"
+	"github.com/yourusername/panka/pkg/provider"
+	"go.uber.org/zap"
+)
+
+const (
+	// loadBalancerNameMaxLength is the longest name of a load balancer or
+	// target group
+	loadBalancerNameMaxLength = 32
+
+	// defaultSSLPolicy is the security policy of HTTPS listeners that do
+	// not set one
+	defaultSSLPolicy = "ELBSecurityPolicy-TLS13-1-2-2021-06"
+
+	// targetGroupTagKey tags the listener rules of a target group, so that
+	// they are found again when the ingress changes
+	targetGroupTagKey = "panka:target-group"
+
+	httpPort  = 80
+	httpsPort = 443
+)
+
+// loadBalancerPollInterval is how often the security group of a deleted
+// load balancer is retried while its network interfaces are released
+var loadBalancerPollInterval = 10 * time.Second
+
+// LoadBalancerProvider manages the Application Load Balancers in front of
+// microservices. A microservice gets a load balancer of its own, or rules
+// on the load balancer shared by its tenant's services. Its tasks are
+// registered by ECS in a target group named after the ECS service.
+type LoadBalancerProvider struct {
+	provider       *Provider
+	client         *elbv2.Client
+	securityGroups *SecurityGroupProvider
+}
+
+// NewLoadBalancerProvider creates a new load balancer provider
+func NewLoadBalancerProvider(p *Provider) *LoadBalancerProvider {
+	return &LoadBalancerProvider{
+		provider:       p,
+		client:         elbv2.NewFromConfig(p.GetConfig()),
+		securityGroups: NewSecurityGroupProvider(p),
+	}
+}
+
+// LoadBalancerAttachment is where a microservice is attached to its load
+// balancer
+type LoadBalancerAttachment struct {
+	LoadBalancerARN  string
+	DNSName          string
+	TargetGroupARN   string
+	HTTPListenerARN  string
+	HTTPSListenerARN string
+	URL              string
+}
+
+// Outputs returns the outputs of an attachment
+func (a *LoadBalancerAttachment) Outputs() map[string]string {
+	outputs := map[string]string{
+		"target_group_arn": a.TargetGroupARN,
+	}
+	if a.LoadBalancerARN != "" {
+		outputs["load_balancer_arn"] = a.LoadBalancerARN
+		outputs["load_balancer_dns"] = a.DNSName
+	}
+	if a.HTTPListenerARN != "" {
+		outputs["http_listener_arn"] = a.HTTPListenerARN
+	}
+	if a.HTTPSListenerARN != "" {
+		outputs["https_listener_arn"] = a.HTTPSListenerARN
+	}
+	if a.URL != "" {
+		outputs["url"] = a.URL
+	}
+	return outputs
+}
+
+// Attach creates or updates the load balancer, listeners, target group,
+// listener rules and security group rules of a microservice. It returns
+// nil when the microservice has no load balancer.
+func (lp *LoadBalancerProvider) Attach(ctx context.Context, ms *schema.MicroService, serviceName string, opts *provider.ResourceOptions) (*LoadBalancerAttachment, error) {
+	lb := ms.LoadBalancer()
+	if lb == nil {
+		return nil, nil
+	}
+	port, ok := ms.TrafficPort()
+	if !ok {
+		return nil, errors.New("microservice has no port for the load balancer to forward to")
+	}
+
+	networking := lp.provider.GetNetworking()
+	if networking == nil || networking.VPCID == "" {
+		return nil, errors.New("tenant networking has no VPC, set up the tenant networking first")
+	}
+	subnets := networking.PublicSubnetIDs
+	if lb.Internal {
+		subnets = networking.PrivateSubnetIDs
+	}
+	if len(subnets) == 0 {
+		return nil, fmt.Errorf("tenant networking has no subnets for an %s load balancer", loadBalancerScheme(lb))
+	}
+
+	name := loadBalancerName(lb, serviceName, opts)
+	tags := lp.provider.tagHelper.BuildTags(opts, ms)
+	lbTags := tags
+	if lb.Shared {
+		// The shared load balancer leaves out the service that created it
+		lbTags = lp.provider.tagHelper.BuildTags(&provider.ResourceOptions{TenantID: opts.TenantID}, nil)
+	}
+
+	lp.provider.GetLogger().Info("Attaching load balancer",
+		zap.String("load_balancer", name),
+		zap.String("service", serviceName),
+		zap.Bool("shared", lb.Shared),
+	)
+
+	// The service may be moving between its own and the shared load
+	// balancer, or between internal and internet-facing
+	if err := lp.releaseStale(ctx, targetGroupName(serviceName), name, loadBalancerScheme(lb)); err != nil {
+		return nil, err
+	}
+
+	sgID, err := lp.ensureSecurityGroup(ctx, name, networking.VPCID, lb, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set up load balancer security group: %w", err)
+	}
+
+	// Tasks accept traffic from the load balancer on the container port
+	if networking.SecurityGroupID != "" {
+		err := lp.securityGroups.AddIngressRule(ctx, networking.SecurityGroupID, SecurityGroupRule{
+			Protocol:    "tcp",
+			Port:        int32(port.Port),
+			SourceSGID:  sgID,
+			Description: "Traffic from load balancer " + name,
+		})
+		if err != nil && !isAPIErrorCode(err, "InvalidPermission.Duplicate") {
+			return nil, fmt.Errorf("failed to allow load balancer traffic to tasks: %w", err)
+		}
+	}
+
+	loadBalancer, err := lp.ensureLoadBalancer(ctx, name, lb, subnets, sgID, lbTags)
+	if err != nil {
+		return nil, err
+	}
+	lbARN := aws.ToString(loadBalancer.LoadBalancerArn)
+
+	targetGroup, err := lp.ensureTargetGroup(ctx, targetGroupName(serviceName), port.Port, networking.VPCID, healthCheckPath(ms), tags)
+	if err != nil {
+		return nil, err
+	}
+	tgARN := aws.ToString(targetGroup.TargetGroupArn)
+
+	conditions := ruleConditions(ms.Ingress())
+	forward := forwardAction(tgARN)
+
+	// A load balancer of its own forwards everything unless the ingress
+	// narrows it down
+	serveDefault := notFoundAction()
+	if !lb.Shared && len(conditions) == 0 {
+		serveDefault = forward
+	}
+
+	listeners, err := lp.ensureListeners(ctx, lbARN, lb, serveDefault)
+	if err != nil {
+		return nil, err
+	}
+
+	desired := map[string]*types.Action{}
+	if len(conditions) > 0 {
+		if lb.CertificateARN != "" {
+			desired[listeners[httpsPort]] = &forward
+			redirect := redirectToHTTPSAction()
+			desired[listeners[httpPort]] = &redirect
+		} else {
+			desired[listeners[httpPort]] = &forward
+		}
+	}
+	ruleTags := map[string]string{targetGroupTagKey: targetGroupName(serviceName)}
+	for k, v := range tags {
+		ruleTags[k] = v
+	}
+	for _, listenerARN := range listeners {
+		if err := lp.reconcileRule(ctx, listenerARN, targetGroupName(serviceName), conditions, desired[listenerARN], ruleTags); err != nil {
+			return nil, err
+		}
+	}
+
+	attachment := &LoadBalancerAttachment{
+		LoadBalancerARN:  lbARN,
+		DNSName:          aws.ToString(loadBalancer.DNSName),
+		TargetGroupARN:   tgARN,
+		HTTPListenerARN:  listeners[httpPort],
+		HTTPSListenerARN: listeners[httpsPort],
+		URL:              serviceURL(lb, ms.Ingress(), aws.ToString(loadBalancer.DNSName)),
+	}
+
+	lp.provider.GetLogger().Info("Load balancer attached",
+		zap.String("service", serviceName),
+		zap.String("dns_name", attachment.DNSName),
+	)
+
+	return attachment, nil
+}
+
+// Lookup returns where the ECS service of a microservice is attached, or
+// nil when it has no target group
+func (lp *LoadBalancerProvider) Lookup(ctx context.Context, serviceName string) (*LoadBalancerAttachment, error) {
+	targetGroup, err := lp.describeTargetGroup(ctx, targetGroupName(serviceName))
+	if err != nil || targetGroup == nil {
+		return nil, err
+	}
+
+	attachment := &LoadBalancerAttachment{TargetGroupARN: aws.ToString(targetGroup.TargetGroupArn)}
+	if len(targetGroup.LoadBalancerArns) == 0 {
+		return attachment, nil
+	}
+
+	result, err := lp.client.DescribeLoadBalancers(ctx, &elbv2.DescribeLoadBalancersInput{
+		LoadBalancerArns: targetGroup.LoadBalancerArns[:1],
+	})
+	if err != nil {
+		if isLoadBalancerNotFound(err) {
+			return attachment, nil
+		}
+		return nil, fmt.Errorf("failed to describe load balancer: %w", err)
+	}
+	if len(result.LoadBalancers) == 0 {
+		return attachment, nil
+	}
+	loadBalancer := result.LoadBalancers[0]
+	attachment.LoadBalancerARN = aws.ToString(loadBalancer.LoadBalancerArn)
+	attachment.DNSName = aws.ToString(loadBalancer.DNSName)
+
+	listeners, err := lp.describeListeners(ctx, attachment.LoadBalancerARN)
+	if err != nil {
+		return nil, err
+	}
+	if listener, ok := listeners[httpPort]; ok {
+		attachment.HTTPListenerARN = aws.ToString(listener.ListenerArn)
+	}
+	if listener, ok := listeners[httpsPort]; ok {
+		attachment.HTTPSListenerARN = aws.ToString(listener.ListenerArn)
+	}
+
+	return attachment, nil
+}
+
+// Detach removes the target group and listener rules of an ECS service,
+// and its load balancer and security group unless the load balancer is
+// shared. The service must not use the target group anymore.
+func (lp *LoadBalancerProvider) Detach(ctx context.Context, serviceName string) error {
+	name := targetGroupName(serviceName)
+
+	targetGroup, err := lp.describeTargetGroup(ctx, name)
+	if err != nil || targetGroup == nil {
+		return err
+	}
+
+	lp.provider.GetLogger().Info("Detaching load balancer", zap.String("service", serviceName))
+
+	if len(targetGroup.LoadBalancerArns) > 0 {
+		result, err := lp.client.DescribeLoadBalancers(ctx, &elbv2.DescribeLoadBalancersInput{
+			LoadBalancerArns: targetGroup.LoadBalancerArns,
+		})
+		if err != nil && !isLoadBalancerNotFound(err) {
+			return fmt.Errorf("failed to describe load balancer: %w", err)
+		}
+		if result != nil {
+			for _, loadBalancer := range result.LoadBalancers {
+				// The load balancer of a single service is named like its
+				// target group
+				if aws.ToString(loadBalancer.LoadBalancerName) == name {
+					err = lp.deleteLoadBalancer(ctx, loadBalancer)
+				} else {
+					err = lp.removeRules(ctx, aws.ToString(loadBalancer.LoadBalancerArn), name)
+				}
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	if _, err := lp.client.DeleteTargetGroup(ctx, &elbv2.DeleteTargetGroupInput{
+		TargetGroupArn: targetGroup.TargetGroupArn,
+	}); err != nil && !isLoadBalancerNotFound(err) {
+		return fmt.Errorf("failed to delete target group: %w", err)
+	}
+
+	lp.provider.GetLogger().Info("Load balancer detached", zap.String("service", serviceName))
+
+	return nil
+}
+
+// releaseStale takes a target group off the load balancers it is attached
+// to other than the named one with the given scheme. A stale load balancer
+// of the service's own is deleted.
+func (lp *LoadBalancerProvider) releaseStale(ctx context.Context, targetGroup, name string, scheme types.LoadBalancerSchemeEnum) error {
+	existing, err := lp.describeTargetGroup(ctx, targetGroup)
+	if err != nil || existing == nil || len(existing.LoadBalancerArns) == 0 {
+		return err
+	}
+
+	result, err := lp.client.DescribeLoadBalancers(ctx, &elbv2.DescribeLoadBalancersInput{
+		LoadBalancerArns: existing.LoadBalancerArns,
+	})
+	if err != nil {
+		if isLoadBalancerNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to describe load balancer: %w", err)
+	}
+
+	for _, loadBalancer := range result.LoadBalancers {
+		lbName := aws.ToString(loadBalancer.LoadBalancerName)
+		switch {
+		case lbName == name && loadBalancer.Scheme == scheme:
+			continue
+		case lbName == targetGroup:
+			err = lp.deleteLoadBalancer(ctx, loadBalancer)
+		default:
+			err = lp.removeRules(ctx, aws.ToString(loadBalancer.LoadBalancerArn), targetGroup)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ensureSecurityGroup creates the security group of a load balancer unless
+// it exists, and opens its listener ports to the allowed CIDRs. The rules of
+// a shared load balancer are only ever added, since other services may
+// need them; validation keeps them open to everyone, as shared load
+// balancers take no allowed CIDRs.
+func (lp *LoadBalancerProvider) ensureSecurityGroup(ctx context.Context, name, vpcID string, lb *schema.LoadBalancerConfig, opts *provider.ResourceOptions) (string, error) {
+	sgName := name + "-alb"
+
+	existing, err := lp.securityGroups.FindByName(ctx, vpcID, sgName)
+	if err != nil {
+		return "", err
+	}
+
+	var sgID string
+	var current []SecurityGroupRule
+	if existing != nil {
+		sgID = existing.SecurityGroupID
+		current = existing.Ingress
+	} else {
+		created, err := lp.securityGroups.Create(ctx, &SecurityGroupConfig{
+			Name:        sgName,
+			Description: "Load balancer " + name,
+			VPCID:       vpcID,
+			TenantID:    opts.TenantID,
+		}, nil)
+		if err != nil {
+			return "", err
+		}
+		sgID = created.SecurityGroupID
+	}
+
+	add, revoke := securityGroupRuleChanges(current, loadBalancerIngressRules(lb))
+	for _, rule := range add {
+		if err := lp.securityGroups.AddIngressRule(ctx, sgID, rule); err != nil && !isAPIErrorCode(err, "InvalidPermission.Duplicate") {
+			return "", err
+		}
+	}
+	if !lb.Shared {
+		for _, rule := range revoke {
+			if err := lp.securityGroups.RevokeIngressRule(ctx, sgID, rule); err != nil && !isAPIErrorCode(err, "InvalidPermission.NotFound") {
+				return "", err
+			}
+		}
+	}
+
+	return sgID, nil
+}
+
+// ensureLoadBalancer creates a load balancer unless it exists and waits
+// until it is active
+func (lp *LoadBalancerProvider) ensureLoadBalancer(ctx context.Context, name string, lb *schema.LoadBalancerConfig, subnets []string, sgID string, tags map[string]string) (*types.LoadBalancer, error) {
+	result, err := lp.client.DescribeLoadBalancers(ctx, &elbv2.DescribeLoadBalancersInput{
+		Names: []string{name},
+	})
+	if err != nil && !isLoadBalancerNotFound(err) {
+		return nil, fmt.Errorf("failed to describe load balancer: %w", err)
+	}
+	if err == nil && len(result.LoadBalancers) > 0 {
+		existing := result.LoadBalancers[0]
+		if existing.Scheme != loadBalancerScheme(lb) {
+			return nil, fmt.Errorf("load balancer %s is %s, not %s", name, existing.Scheme, loadBalancerScheme(lb))
+		}
+		return &existing, nil
+	}
+
+	lp.provider.GetLogger().Info("Creating load balancer",
+		zap.String("load_balancer", name),
+		zap.String("scheme", string(loadBalancerScheme(lb))),
+	)
+
+	created, err := lp.client.CreateLoadBalancer(ctx, &elbv2.CreateLoadBalancerInput{
+		Name:           aws.String(name),
+		Type:           types.LoadBalancerTypeEnumApplication,
+		Scheme:         loadBalancerScheme(lb),
+		Subnets:        subnets,
+		SecurityGroups: []string{sgID},
+		Tags:           elbTags(tags),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create load balancer: %w", err)
+	}
+	loadBalancer := created.LoadBalancers[0]
+
+	waiter := elbv2.NewLoadBalancerAvailableWaiter(lp.client)
+	if err := waiter.Wait(ctx, &elbv2.DescribeLoadBalancersInput{
+		LoadBalancerArns: []string{aws.ToString(loadBalancer.LoadBalancerArn)},
+	}, ecsWaitTime(ctx)); err != nil {
+		return nil, fmt.Errorf("load balancer did not become active: %w", err)
+	}
+
+	return &loadBalancer, nil
+}
+
+// ensureTargetGroup creates the target group of an ECS service unless it
+// exists, and keeps its health check up to date
+func (lp *LoadBalancerProvider) ensureTargetGroup(ctx context.Context, name string, port int, vpcID, healthPath string, tags map[string]string) (*types.TargetGroup, error) {
+	existing, err := lp.describeTargetGroup(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	if existing != nil {
+		if int(aws.ToInt32(existing.Port)) != port {
+			return nil, fmt.Errorf("target group %s forwards to port %d, the traffic port cannot change to %d", name, aws.ToInt32(existing.Port), port)
+		}
+		if aws.ToString(existing.HealthCheckPath) != healthPath {
+			if _, err := lp.client.ModifyTargetGroup(ctx, &elbv2.ModifyTargetGroupInput{
+				TargetGroupArn:  existing.TargetGroupArn,
+				HealthCheckPath: aws.String(healthPath),
+			}); err != nil {
+				return nil, fmt.Errorf("failed to update target group health check: %w", err)
+			}
+		}
+		return existing, nil
+	}
+
+	lp.provider.GetLogger().Info("Creating target group", zap.String("target_group", name))
+
+	result, err := lp.client.CreateTargetGroup(ctx, &elbv2.CreateTargetGroupInput{
+		Name:                       aws.String(name),
+		Protocol:                   types.ProtocolEnumHttp,
+		Port:                       aws.Int32(int32(port)),
+		VpcId:                      aws.String(vpcID),
+		TargetType:                 types.TargetTypeEnumIp,
+		HealthCheckPath:            aws.String(healthPath),
+		HealthCheckIntervalSeconds: aws.Int32(15),
+		HealthyThresholdCount:      aws.Int32(2),
+		Matcher:                    &types.Matcher{HttpCode: aws.String("200-399")},
+		Tags:                       elbTags(tags),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create target group: %w", err)
+	}
+	return &result.TargetGroups[0], nil
+}
+
+// ensureListeners creates or updates the HTTP and HTTPS listeners of a load
+// balancer and returns their ARNs by port. Listeners of a shared load
+// balancer keep their default actions; they only gain certificates.
+func (lp *LoadBalancerProvider) ensureListeners(ctx context.Context, lbARN string, lb *schema.LoadBalancerConfig, serveDefault types.Action) (map[int32]string, error) {
+	existing, err := lp.describeListeners(ctx, lbARN)
+	if err != nil {
+		return nil, err
+	}
+
+	arns := make(map[int32]string)
+	for _, listener := range listenerConfigs(lb, serveDefault) {
+		port := aws.ToInt32(listener.Port)
+		current, ok := existing[port]
+
+		switch {
+		case !ok:
+			result, err := lp.client.CreateListener(ctx, &elbv2.CreateListenerInput{
+				LoadBalancerArn: aws.String(lbARN),
+				Port:            listener.Port,
+				Protocol:        listener.Protocol,
+				Certificates:    listener.Certificates,
+				SslPolicy:       listener.SslPolicy,
+				DefaultActions:  listener.DefaultActions,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to create %s listener: %w", listener.Protocol, err)
+			}
+			arns[port] = aws.ToString(result.Listeners[0].ListenerArn)
+			continue
+		case lb.Shared:
+			// Further certificates are picked by SNI
+			if len(listener.Certificates) > 0 {
+				if _, err := lp.client.AddListenerCertificates(ctx, &elbv2.AddListenerCertificatesInput{
+					ListenerArn:  current.ListenerArn,
+					Certificates: listener.Certificates,
+				}); err != nil {
+					return nil, fmt.Errorf("failed to add listener certificate: %w", err)
+				}
+			}
+		default:
+			if _, err := lp.client.ModifyListener(ctx, &elbv2.ModifyListenerInput{
+				ListenerArn:    current.ListenerArn,
+				Port:           listener.Port,
+				Protocol:       listener.Protocol,
+				Certificates:   listener.Certificates,
+				SslPolicy:      listener.SslPolicy,
+				DefaultActions: listener.DefaultActions,
+			}); err != nil {
+				return nil, fmt.Errorf("failed to update %s listener: %w", listener.Protocol, err)
+			}
+		}
+		arns[port] = aws.ToString(current.ListenerArn)
+	}
+
+	// A load balancer of its own stops serving HTTPS without a certificate
+	if https, ok := existing[httpsPort]; ok && arns[httpsPort] == "" && !lb.Shared {
+		if _, err := lp.client.DeleteListener(ctx, &elbv2.DeleteListenerInput{
+			ListenerArn: https.ListenerArn,
+		}); err != nil && !isLoadBalancerNotFound(err) {
+			return nil, fmt.Errorf("failed to delete HTTPS listener: %w", err)
+		}
+	}
+
+	return arns, nil
+}
+
+// reconcileRule makes the listener rule of a target group match the
+// conditions and action, creating it at the lowest free priority, or
+// removes it when action is nil
+func (lp *LoadBalancerProvider) reconcileRule(ctx context.Context, listenerARN, targetGroup string, conditions []types.RuleCondition, action *types.Action, tags map[string]string) error {
+	owned, priorities, err := lp.targetGroupRules(ctx, listenerARN, targetGroup)
+	if err != nil {
+		return err
+	}
+
+	if action != nil && len(owned) > 0 {
+		if _, err := lp.client.ModifyRule(ctx, &elbv2.ModifyRuleInput{
+			RuleArn:    owned[0].RuleArn,
+			Conditions: conditions,
+			Actions:    []types.Action{*action},
+		}); err != nil {
+			return fmt.Errorf("failed to update listener rule: %w", err)
+		}
+		owned = owned[1:]
+	} else if action != nil {
+		if _, err := lp.client.CreateRule(ctx, &elbv2.CreateRuleInput{
+			ListenerArn: aws.String(listenerARN),
+			Priority:    aws.Int32(nextRulePriority(priorities)),
+			Conditions:  conditions,
+			Actions:     []types.Action{*action},
+			Tags:        elbTags(tags),
+		}); err != nil {
+			return fmt.Errorf("failed to create listener rule: %w", err)
+		}
+	}
+
+	for _, rule := range owned {
+		if _, err := lp.client.DeleteRule(ctx, &elbv2.DeleteRuleInput{
+			RuleArn: rule.RuleArn,
+		}); err != nil && !isLoadBalancerNotFound(err) {
+			return fmt.Errorf("failed to delete listener rule: %w", err)
+		}
+	}
+	return nil
+}
+
+// removeRules deletes the rules of a target group from all listeners of a
+// load balancer
+func (lp *LoadBalancerProvider) removeRules(ctx context.Context, lbARN, targetGroup string) error {
+	listeners, err := lp.describeListeners(ctx, lbARN)
+	if err != nil {
+		return err
+	}
+	for _, listener := range listeners {
+		if err := lp.reconcileRule(ctx, aws.ToString(listener.ListenerArn), targetGroup, nil, nil, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// targetGroupRules returns the rules of a listener tagged with a target
+// group, and the priorities in use by all its rules
+func (lp *LoadBalancerProvider) targetGroupRules(ctx context.Context, listenerARN, targetGroup string) ([]types.Rule, []int32, error) {
+	var rules []types.Rule
+	var marker *string
+	for {
+		result, err := lp.client.DescribeRules(ctx, &elbv2.DescribeRulesInput{
+			ListenerArn: aws.String(listenerARN),
+			Marker:      marker,
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to describe listener rules: %w", err)
+		}
+		for _, rule := range result.Rules {
+			if !aws.ToBool(rule.IsDefault) {
+				rules = append(rules, rule)
+			}
+		}
+		if result.NextMarker == nil {
+			break
+		}
+		marker = result.NextMarker
+	}
+
+	priorities := make([]int32, 0, len(rules))
+	byARN := make(map[string]types.Rule, len(rules))
+	arns := make([]string, 0, len(rules))
+	for _, rule := range rules {
+		if priority, err := strconv.Atoi(aws.ToString(rule.Priority)); err == nil {
+			priorities = append(priorities, int32(priority))
+		}
+		byARN[aws.ToString(rule.RuleArn)] = rule
+		arns = append(arns, aws.ToString(rule.RuleArn))
+	}
+
+	// Tags are described 20 resources at a time
+	var owned []types.Rule
+	for start := 0; start < len(arns); start += 20 {
+		end := start + 20
+		if end > len(arns) {
+			end = len(arns)
+		}
+		result, err := lp.client.DescribeTags(ctx, &elbv2.DescribeTagsInput{
+			ResourceArns: arns[start:end],
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to describe listener rule tags: %w", err)
+		}
+		for _, description := range result.TagDescriptions {
+			for _, tag := range description.Tags {
+				if aws.ToString(tag.Key) == targetGroupTagKey && aws.ToString(tag.Value) == targetGroup {
+					owned = append(owned, byARN[aws.ToString(description.ResourceArn)])
+				}
+			}
+		}
+	}
+
+	return owned, priorities, nil
+}
+
+// deleteLoadBalancer deletes a load balancer of a single service with its
+// security group
+func (lp *LoadBalancerProvider) deleteLoadBalancer(ctx context.Context, loadBalancer types.LoadBalancer) error {
+	name := aws.ToString(loadBalancer.LoadBalancerName)
+
+	lp.provider.GetLogger().Info("Deleting load balancer", zap.String("load_balancer", name))
+
+	if _, err := lp.client.DeleteLoadBalancer(ctx, &elbv2.DeleteLoadBalancerInput{
+		LoadBalancerArn: loadBalancer.LoadBalancerArn,
+	}); err != nil && !isLoadBalancerNotFound(err) {
+		return fmt.Errorf("failed to delete load balancer: %w", err)
+	}
+
+	waiter := elbv2.NewLoadBalancersDeletedWaiter(lp.client)
+	if err := waiter.Wait(ctx, &elbv2.DescribeLoadBalancersInput{
+		LoadBalancerArns: []string{aws.ToString(loadBalancer.LoadBalancerArn)},
+	}, ecsWaitTime(ctx)); err != nil {
+		return fmt.Errorf("load balancer was not deleted: %w", err)
+	}
+
+	sg, err := lp.securityGroups.FindByName(ctx, aws.ToString(loadBalancer.VpcId), name+"-alb")
+	if err != nil || sg == nil {
+		return err
+	}
+
+	// The tasks stop accepting traffic from the load balancer
+	if networking := lp.provider.GetNetworking(); networking != nil && networking.SecurityGroupID != "" {
+		tenant, err := lp.securityGroups.Get(ctx, networking.SecurityGroupID)
+		if err != nil {
+			return err
+		}
+		for _, rule := range tenant.Ingress {
+			if rule.SourceSGID != sg.SecurityGroupID {
+				continue
+			}
+			if err := lp.securityGroups.RevokeIngressRule(ctx, networking.SecurityGroupID, rule); err != nil && !isAPIErrorCode(err, "InvalidPermission.NotFound") {
+				return fmt.Errorf("failed to revoke load balancer traffic to tasks: %w", err)
+			}
+		}
+	}
+
+	// The network interfaces of the load balancer are released a little
+	// after it is deleted
+	waitCtx, cancel := context.WithTimeout(ctx, ecsWaitTime(ctx))
+	defer cancel()
+	for {
+		err := lp.securityGroups.Delete(ctx, sg.SecurityGroupID, nil)
+		if err == nil || isAPIErrorCode(err, "InvalidGroup.NotFound") {
+			return nil
+		}
+		if !isAPIErrorCode(err, "DependencyViolation") {
+			return err
+		}
+
+		select {
+		case <-waitCtx.Done():
+			return fmt.Errorf("timed out deleting load balancer security group: %w", err)
+		case <-time.After(loadBalancerPollInterval):
+		}
+	}
+}
+
+// describeTargetGroup returns a target group, or nil if it does not exist
+func (lp *LoadBalancerProvider) describeTargetGroup(ctx context.Context, name string) (*types.TargetGroup, error) {
+	result, err := lp.client.DescribeTargetGroups(ctx, &elbv2.DescribeTargetGroupsInput{
+		Names: []string{name},
+	})
+	if err != nil {
+		if isLoadBalancerNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to describe target group: %w", err)
+	}
+	if len(result.TargetGroups) == 0 {
+		return nil, nil
+	}
+	return &result.TargetGroups[0], nil
+}
+
+// describeListeners returns the listeners of a load balancer by port
+func (lp *LoadBalancerProvider) describeListeners(ctx context.Context, lbARN string) (map[int32]types.Listener, error) {
+	listeners := make(map[int32]types.Listener)
+	var marker *string
+	for {
+		result, err := lp.client.DescribeListeners(ctx, &elbv2.DescribeListenersInput{
+			LoadBalancerArn: aws.String(lbARN),
+			Marker:          marker,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe listeners: %w", err)
+		}
+		for _, listener := range result.Listeners {
+			listeners[aws.ToInt32(listener.Port)] = listener
+		}
+		if result.NextMarker == nil {
+			return listeners, nil
+		}
+		marker = result.NextMarker
+	}
+}
+
+// listenerConfigs returns the listeners a load balancer needs: HTTPS with
+// HTTP redirecting to it when it has a certificate, or else plain HTTP.
+// The listener serving the requests has the serveDefault action.
+func listenerConfigs(lb *schema.LoadBalancerConfig, serveDefault types.Action) []types.Listener {
+	if lb.CertificateARN == "" {
+		return []types.Listener{{
+			Port:           aws.Int32(httpPort),
+			Protocol:       types.ProtocolEnumHttp,
+			DefaultActions: []types.Action{serveDefault},
+		}}
+	}
+
+	sslPolicy := lb.SSLPolicy
+	if sslPolicy == "" {
+		sslPolicy = defaultSSLPolicy
+	}
+
+	return []types.Listener{
+		{
+			Port:           aws.Int32(httpsPort),
+			Protocol:       types.ProtocolEnumHttps,
+			Certificates:   []types.Certificate{{CertificateArn: aws.String(lb.CertificateARN)}},
+			SslPolicy:      aws.String(sslPolicy),
+			DefaultActions: []types.Action{serveDefault},
+		},
+		{
+			Port:           aws.Int32(httpPort),
+			Protocol:       types.ProtocolEnumHttp,
+			DefaultActions: []types.Action{redirectToHTTPSAction()},
+		},
+	}
+}
+
+// ruleConditions returns the conditions of the listener rule of an
+// ingress, or nil when it routes every request
+func ruleConditions(ingress *schema.IngressConfig) []types.RuleCondition {
+	if ingress == nil {
+		return nil
+	}
+
+	var conditions []types.RuleCondition
+	if ingress.Hostname != "" {
+		conditions = append(conditions, types.RuleCondition{
+			Field:            aws.String("host-header"),
+			HostHeaderConfig: &types.HostHeaderConditionConfig{Values: []string{ingress.Hostname}},
+		})
+	}
+	// A path matches itself and everything below it
+	if path := strings.TrimRight(ingress.Path, "/"); path != "" {
+		conditions = append(conditions, types.RuleCondition{
+			Field:             aws.String("path-pattern"),
+			PathPatternConfig: &types.PathPatternConditionConfig{Values: []string{path, path + "/*"}},
+		})
+	}
+	return conditions
+}
+
+// forwardAction forwards requests to a target group
+func forwardAction(tgARN string) types.Action {
+	return types.Action{
+		Type:           types.ActionTypeEnumForward,
+		TargetGroupArn: aws.String(tgARN),
+	}
+}
+
+// notFoundAction answers requests that no rule matches
+func notFoundAction() types.Action {
+	return types.Action{
+		Type: types.ActionTypeEnumFixedResponse,
+		FixedResponseConfig: &types.FixedResponseActionConfig{
+			StatusCode:  aws.String("404"),
+			ContentType: aws.String("text/plain"),
+			MessageBody: aws.String("Not Found"),
+		},
+	}
+}
+
+// redirectToHTTPSAction redirects HTTP requests to HTTPS
+func redirectToHTTPSAction() types.Action {
+	return types.Action{
+		Type: types.ActionTypeEnumRedirect,
+		RedirectConfig: &types.RedirectActionConfig{
+			Protocol:   aws.String("HTTPS"),
+			Port:       aws.String(strconv.Itoa(httpsPort)),
+			StatusCode: types.RedirectActionStatusCodeEnumHttp301,
+		},
+	}
+}
+
+// nextRulePriority returns the lowest priority not used by a rule
+func nextRulePriority(used []int32) int32 {
+	sorted := append([]int32(nil), used...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	next := int32(1)
+	for _, priority := range sorted {
+		if priority == next {
+			next++
+		} else if priority > next {
+			break
+		}
+	}
+	return next
+}
+
+// loadBalancerIngressRules returns the rules that open the listener ports
+// of a load balancer to its allowed CIDRs, or to everyone when it has none
+func loadBalancerIngressRules(lb *schema.LoadBalancerConfig) []SecurityGroupRule {
+	cidrs := lb.AllowedCIDRs
+	if len(cidrs) == 0 {
+		cidrs = []string{"0.0.0.0/0"}
+	}
+	ports := []int32{httpPort}
+	if lb.CertificateARN != "" {
+		ports = append(ports, httpsPort)
+	}
+
+	var rules []SecurityGroupRule
+	for _, port := range ports {
+		for _, cidr := range cidrs {
+			rules = append(rules, SecurityGroupRule{
+				Protocol:   "tcp",
+				FromPort:   port,
+				ToPort:     port,
+				CidrBlocks: []string{cidr},
+			})
+		}
+	}
+	return rules
+}
+
+// securityGroupRuleChanges returns the CIDR rules to add and to revoke to go
+// from the current ingress rules of a security group to the desired ones.
+// Rules that reference other security groups are left alone.
+func securityGroupRuleChanges(current, desired []SecurityGroupRule) ([]SecurityGroupRule, []SecurityGroupRule) {
+	key := func(rule SecurityGroupRule, cidr string) string {
+		return fmt.Sprintf("%s/%d-%d/%s", rule.Protocol, rule.FromPort, rule.ToPort, cidr)
+	}
+
+	have := make(map[string]bool)
+	for _, rule := range current {
+		for _, cidr := range rule.CidrBlocks {
+			have[key(rule, cidr)] = true
+		}
+	}
+	want := make(map[string]bool)
+	var add []SecurityGroupRule
+	for _, rule := range desired {
+		for _, cidr := range rule.CidrBlocks {
+			want[key(rule, cidr)] = true
+			if !have[key(rule, cidr)] {
+				add = append(add, SecurityGroupRule{Protocol: rule.Protocol, FromPort: rule.FromPort, ToPort: rule.ToPort, CidrBlocks: []string{cidr}})
+			}
+		}
+	}
+
+	var revoke []SecurityGroupRule
+	for _, rule := range current {
+		for _, cidr := range rule.CidrBlocks {
+			if !want[key(rule, cidr)] {
+				revoke = append(revoke, SecurityGroupRule{Protocol: rule.Protocol, FromPort: rule.FromPort, ToPort: rule.ToPort, CidrBlocks: []string{cidr}})
+			}
+		}
+	}
+	return add, revoke
+}
+
+// healthCheckPath returns the path the target group checks: the one of the
+// load balancer, or else of the readiness probe, or else the root
+func healthCheckPath(ms *schema.MicroService) string {
+	if lb := ms.LoadBalancer(); lb != nil && lb.HealthCheckPath != "" {
+		return lb.HealthCheckPath
+	}
+	if hc := ms.Spec.HealthCheck; hc != nil && hc.Readiness != nil && hc.Readiness.HTTP != nil && hc.Readiness.HTTP.Path != "" {
+		path := hc.Readiness.HTTP.Path
+		if !strings.HasPrefix(path, "/") {
+			path = "/" + path
+		}
+		return path
+	}
+	return "/"
+}
+
+// serviceURL returns the URL a microservice is reached at
+func serviceURL(lb *schema.LoadBalancerConfig, ingress *schema.IngressConfig, dnsName string) string {
+	scheme := "http"
+	if lb.CertificateARN != "" {
+		scheme = "https"
+	}
+	host := dnsName
+	path := ""
+	if ingress != nil {
+		if ingress.Hostname != "" {
+			host = ingress.Hostname
+		}
+		path = strings.TrimRight(ingress.Path, "/")
+	}
+	return scheme + "://" + host + path
+}
+
+// loadBalancerName returns the name of the load balancer of an ECS service:
+// its own, named like the service, or the one shared by the tenant's
+// internet-facing or internal services
+func loadBalancerName(lb *schema.LoadBalancerConfig, serviceName string, opts *provider.ResourceOptions) string {
+	if !lb.Shared {
+		return targetGroupName(serviceName)
+	}
+	name := ecsClusterName(opts)
+	if lb.Internal {
+		name += "-internal"
+	}
+	return shortName(name, loadBalancerNameMaxLength)
+}
+
+// targetGroupName returns the name of the target group of an ECS service
+func targetGroupName(serviceName string) string {
+	return shortName(serviceName, loadBalancerNameMaxLength)
+}
+
+// loadBalancerScheme returns whether a load balancer is internal or
+// internet-facing
+func loadBalancerScheme(lb *schema.LoadBalancerConfig) types.LoadBalancerSchemeEnum {
+	if lb.Internal {
+		return types.LoadBalancerSchemeEnumInternal
+	}
+	return types.LoadBalancerSchemeEnumInternetFacing
+}
+
+// elbTags converts tags into load balancer tags, sorted by key
+func elbTags(tags map[string]string) []types.Tag {
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	elbTags := make([]types.Tag, 0, len(keys))
+	for _, k := range keys {
+		elbTags = append(elbTags, types.Tag{Key: aws.String(k), Value: aws.String(tags[k])})
+	}
+	return elbTags
+}
+
+// isLoadBalancerNotFound reports whether an error says that a load
+// balancer, target group, listener or rule does not exist
+func isLoadBalancerNotFound(err error) bool {
+	var loadBalancerNotFound *types.LoadBalancerNotFoundException
+	var targetGroupNotFound *types.TargetGroupNotFoundException
+	var listenerNotFound *types.ListenerNotFoundException
+	var ruleNotFound *types.RuleNotFoundException
+	return errors.As(err, &loadBalancerNotFound) || errors.As(err, &targetGroupNotFound) ||
+		errors.As(err, &listenerNotFound) || errors.As(err, &ruleNotFound)
+}
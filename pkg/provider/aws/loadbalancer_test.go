@@ -0,0 +1,173 @@
+package aws
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/elasticloadbalancingv2/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourusername/panka/pkg/parser/schema"
+	"github.com/yourusername/panka/pkg/provider"
+)
+
+func testLoadBalancedService(lb *schema.LoadBalancerConfig, ingress *schema.IngressConfig) *schema.MicroService {
+	ms := schema.NewMicroService("api", "backend", "my-stack")
+	ms.Spec.Ports = []schema.Port{{Name: "http", Port: 8080, Protocol: "tcp"}}
+	ms.Infra = schema.NewComponentInfra("api", "backend", "my-stack")
+	ms.Infra.Spec.Networking.LoadBalancer = lb
+	ms.Infra.Spec.Networking.Ingress = ingress
+	return ms
+}
+
+func TestLoadBalancerName(t *testing.T) {
+	opts := &provider.ResourceOptions{TenantID: "acme", StackName: "my-stack", ServiceName: "backend"}
+	serviceName := ecsServiceName("api", opts)
+
+	assert.Equal(t, "my-stack-backend-api", loadBalancerName(&schema.LoadBalancerConfig{}, serviceName, opts))
+	assert.Equal(t, "panka-acme", loadBalancerName(&schema.LoadBalancerConfig{Shared: true}, serviceName, opts))
+	assert.Equal(t, "panka-acme-internal", loadBalancerName(&schema.LoadBalancerConfig{Shared: true, Internal: true}, serviceName, opts))
+
+	// Names are cut to the 32 characters load balancers allow
+	long := targetGroupName("a-very-long-stack-name-backend-api-server")
+	assert.LessOrEqual(t, len(long), loadBalancerNameMaxLength)
+	assert.Equal(t, long, loadBalancerName(&schema.LoadBalancerConfig{}, "a-very-long-stack-name-backend-api-server", opts))
+}
+
+func TestRuleConditions(t *testing.T) {
+	assert.Nil(t, ruleConditions(nil))
+	assert.Empty(t, ruleConditions(&schema.IngressConfig{Enabled: true, Path: "/"}))
+
+	conditions := ruleConditions(&schema.IngressConfig{Enabled: true, Hostname: "api.example.com", Path: "/v1/"})
+	require.Len(t, conditions, 2)
+	assert.Equal(t, "host-header", aws.ToString(conditions[0].Field))
+	assert.Equal(t, []string{"api.example.com"}, conditions[0].HostHeaderConfig.Values)
+	assert.Equal(t, "path-pattern", aws.ToString(conditions[1].Field))
+	assert.Equal(t, []string{"/v1", "/v1/*"}, conditions[1].PathPatternConfig.Values)
+}
+
+func TestListenerConfigs(t *testing.T) {
+	forward := forwardAction("arn:aws:elasticloadbalancing:us-east-1:123456789012:targetgroup/api/1")
+
+	listeners := listenerConfigs(&schema.LoadBalancerConfig{}, forward)
+	require.Len(t, listeners, 1)
+	assert.Equal(t, int32(httpPort), aws.ToInt32(listeners[0].Port))
+	assert.Equal(t, types.ProtocolEnumHttp, listeners[0].Protocol)
+	assert.Equal(t, []types.Action{forward}, listeners[0].DefaultActions)
+
+	// With a certificate, HTTP redirects to HTTPS
+	listeners = listenerConfigs(&schema.LoadBalancerConfig{CertificateARN: "arn:aws:acm:us-east-1:123456789012:certificate/abc"}, forward)
+	require.Len(t, listeners, 2)
+	https, http := listeners[0], listeners[1]
+	assert.Equal(t, int32(httpsPort), aws.ToInt32(https.Port))
+	assert.Equal(t, types.ProtocolEnumHttps, https.Protocol)
+	assert.Equal(t, defaultSSLPolicy, aws.ToString(https.SslPolicy))
+	assert.Equal(t, "arn:aws:acm:us-east-1:123456789012:certificate/abc", aws.ToString(https.Certificates[0].CertificateArn))
+	assert.Equal(t, []types.Action{forward}, https.DefaultActions)
+	require.Len(t, http.DefaultActions, 1)
+	assert.Equal(t, types.ActionTypeEnumRedirect, http.DefaultActions[0].Type)
+	assert.Equal(t, "HTTPS", aws.ToString(http.DefaultActions[0].RedirectConfig.Protocol))
+	assert.Equal(t, types.RedirectActionStatusCodeEnumHttp301, http.DefaultActions[0].RedirectConfig.StatusCode)
+}
+
+func TestNextRulePriority(t *testing.T) {
+	assert.Equal(t, int32(1), nextRulePriority(nil))
+	assert.Equal(t, int32(4), nextRulePriority([]int32{3, 1, 2}))
+	assert.Equal(t, int32(2), nextRulePriority([]int32{1, 5, 3}))
+	assert.Equal(t, int32(1), nextRulePriority([]int32{2, 3}))
+}
+
+func TestSecurityGroupRuleChanges(t *testing.T) {
+	lb := &schema.LoadBalancerConfig{
+		CertificateARN: "arn:aws:acm:us-east-1:123456789012:certificate/abc",
+		AllowedCIDRs:   []string{"10.0.0.0/8"},
+	}
+	desired := loadBalancerIngressRules(lb)
+	require.Len(t, desired, 2)
+
+	current := []SecurityGroupRule{
+		{Protocol: "tcp", FromPort: 443, ToPort: 443, CidrBlocks: []string{"10.0.0.0/8"}},
+		{Protocol: "tcp", FromPort: 443, ToPort: 443, CidrBlocks: []string{"0.0.0.0/0"}},
+		{Protocol: "tcp", FromPort: 8080, ToPort: 8080, SourceSGID: "sg-123"},
+	}
+
+	add, revoke := securityGroupRuleChanges(current, desired)
+	assert.Equal(t, []SecurityGroupRule{
+		{Protocol: "tcp", FromPort: 80, ToPort: 80, CidrBlocks: []string{"10.0.0.0/8"}},
+	}, add)
+	assert.Equal(t, []SecurityGroupRule{
+		{Protocol: "tcp", FromPort: 443, ToPort: 443, CidrBlocks: []string{"0.0.0.0/0"}},
+	}, revoke)
+
+	// Without allowed CIDRs the listeners are open to everyone
+	open := loadBalancerIngressRules(&schema.LoadBalancerConfig{})
+	require.Len(t, open, 1)
+	assert.Equal(t, []string{"0.0.0.0/0"}, open[0].CidrBlocks)
+	assert.Equal(t, int32(80), open[0].FromPort)
+}
+
+func TestHealthCheckPath(t *testing.T) {
+	ms := testLoadBalancedService(&schema.LoadBalancerConfig{Enabled: true}, nil)
+	assert.Equal(t, "/", healthCheckPath(ms))
+
+	ms.Spec.HealthCheck = &schema.HealthCheck{
+		Readiness: &schema.HealthCheckProbe{HTTP: &schema.HTTPHealthCheck{Path: "ready", Port: 8080}},
+	}
+	assert.Equal(t, "/ready", healthCheckPath(ms))
+
+	ms.Infra.Spec.Networking.LoadBalancer.HealthCheckPath = "/healthz"
+	assert.Equal(t, "/healthz", healthCheckPath(ms))
+}
+
+func TestServiceURL(t *testing.T) {
+	lb := &schema.LoadBalancerConfig{Enabled: true}
+	assert.Equal(t, "http://api-123.us-east-1.elb.amazonaws.com", serviceURL(lb, nil, "api-123.us-east-1.elb.amazonaws.com"))
+
+	lb.CertificateARN = "arn:aws:acm:us-east-1:123456789012:certificate/abc"
+	ingress := &schema.IngressConfig{Enabled: true, Hostname: "api.example.com", Path: "/v1/"}
+	assert.Equal(t, "https://api.example.com/v1", serviceURL(lb, ingress, "api-123.us-east-1.elb.amazonaws.com"))
+}
+
+func TestLoadBalancerAttachment_Outputs(t *testing.T) {
+	attachment := &LoadBalancerAttachment{
+		LoadBalancerARN:  "arn:aws:elasticloadbalancing:us-east-1:123456789012:loadbalancer/app/api/1",
+		DNSName:          "api-123.us-east-1.elb.amazonaws.com",
+		TargetGroupARN:   "arn:aws:elasticloadbalancing:us-east-1:123456789012:targetgroup/api/1",
+		HTTPSListenerARN: "arn:aws:elasticloadbalancing:us-east-1:123456789012:listener/app/api/1/443",
+		HTTPListenerARN:  "arn:aws:elasticloadbalancing:us-east-1:123456789012:listener/app/api/1/80",
+		URL:              "https://api.example.com",
+	}
+
+	outputs := withLoadBalancerOutputs(map[string]string{"cluster_name": "panka-acme"}, attachment)
+	assert.Equal(t, "panka-acme", outputs["cluster_name"])
+	assert.Equal(t, attachment.DNSName, outputs["load_balancer_dns"])
+	assert.Equal(t, attachment.LoadBalancerARN, outputs["load_balancer_arn"])
+	assert.Equal(t, attachment.TargetGroupARN, outputs["target_group_arn"])
+	assert.Equal(t, attachment.HTTPSListenerARN, outputs["https_listener_arn"])
+	assert.Equal(t, attachment.HTTPListenerARN, outputs["http_listener_arn"])
+	assert.Equal(t, "https://api.example.com", outputs["url"])
+
+	assert.Len(t, withLoadBalancerOutputs(map[string]string{"cluster_name": "panka-acme"}, nil), 1)
+}
+
+func TestECSLoadBalancers(t *testing.T) {
+	ms := testLoadBalancedService(&schema.LoadBalancerConfig{Enabled: true}, nil)
+	component, ok := ecsComponentOf(ms)
+	require.True(t, ok)
+
+	// Without an attachment the service is taken out of any load balancer
+	loadBalancers := ecsLoadBalancers(component, ms, nil)
+	assert.NotNil(t, loadBalancers)
+	assert.Empty(t, loadBalancers)
+
+	attachment := &LoadBalancerAttachment{TargetGroupARN: "arn:aws:elasticloadbalancing:us-east-1:123456789012:targetgroup/api/1"}
+	loadBalancers = ecsLoadBalancers(component, ms, attachment)
+	require.Len(t, loadBalancers, 1)
+	assert.Equal(t, attachment.TargetGroupARN, aws.ToString(loadBalancers[0].TargetGroupArn))
+	assert.Equal(t, "api", aws.ToString(loadBalancers[0].ContainerName))
+	assert.Equal(t, int32(8080), aws.ToInt32(loadBalancers[0].ContainerPort))
+
+	assert.Equal(t, int32(ecsHealthCheckGracePeriod), ecsGracePeriod(component))
+	component.healthCheck = &schema.HealthCheck{Readiness: &schema.HealthCheckProbe{InitialDelaySeconds: 120}}
+	assert.Equal(t, int32(120), ecsGracePeriod(component))
+}
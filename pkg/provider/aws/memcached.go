@@ -0,0 +1,330 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/elasticache"
+	"github.com/aws/aws-sdk-go-v2/service/elasticache/types"
+	"github.com/yourusername/panka/pkg/parser/schema"
+	"github.com/yourusername/panka/pkg/provider"
+	"go.uber.org/zap"
+)
+
+// ElastiCacheMemcachedProvider implements ElastiCache for Memcached
+// management. A cache is a cache cluster in the tenant's private subnets,
+// with its nodes spread across availability zones. Resource IDs are the
+// cache cluster ID.
+type ElastiCacheMemcachedProvider struct {
+	provider *Provider
+	client   *elastiCacheClient
+}
+
+// NewElastiCacheMemcachedProvider creates a new Memcached provider
+func NewElastiCacheMemcachedProvider(p *Provider) *ElastiCacheMemcachedProvider {
+	return &ElastiCacheMemcachedProvider{
+		provider: p,
+		client:   newElastiCacheClient(p),
+	}
+}
+
+// Create creates a Memcached cluster with its subnet group and waits until
+// it is available
+func (mp *ElastiCacheMemcachedProvider) Create(ctx context.Context, resource schema.Resource, opts *provider.ResourceOptions) (*provider.ResourceResult, error) {
+	memcached, ok := resource.(*schema.ElastiCacheMemcached)
+	if !ok {
+		return nil, &provider.ProviderError{
+			Provider:  "aws",
+			Operation: "create",
+			Message:   "invalid resource type for ElastiCache Memcached provider",
+		}
+	}
+
+	id := memcachedClusterID(memcached, opts)
+
+	mp.provider.GetLogger().Info("Creating Memcached cache",
+		zap.String("cache_cluster", id),
+		zap.String("node_type", memcached.Spec.NodeType),
+		zap.Int("nodes", memcached.NodeCount()),
+	)
+
+	if opts.DryRun {
+		return &provider.ResourceResult{
+			ResourceID: id,
+			Kind:       schema.KindElastiCacheMemcached,
+			Status:     provider.StatusPending,
+			Outputs: map[string]string{
+				"cache_cluster_id": id,
+				"port":             strconv.Itoa(memcached.CachePort()),
+			},
+			Timestamp: time.Now(),
+		}, nil
+	}
+
+	// An interrupted create may have left the cluster behind
+	exists, err := mp.Exists(ctx, id, opts)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		mp.provider.GetLogger().Info("Memcached cache already exists, updating it",
+			zap.String("cache_cluster", id),
+		)
+		return mp.Update(ctx, resource, opts)
+	}
+
+	networking := mp.provider.GetNetworking()
+	if networking == nil || len(networking.PrivateSubnetIDs) == 0 {
+		return nil, cacheError("create", id, "cannot place Memcached cache",
+			errors.New("tenant networking has no private subnets, set up the tenant networking first"))
+	}
+
+	tags := elastiCacheTags(mp.provider.tagHelper.BuildTags(opts, resource))
+
+	if err := mp.client.ensureSubnetGroup(ctx, id, networking.PrivateSubnetIDs, tags); err != nil {
+		return nil, cacheError("create", id, "failed to create cache subnet group", err)
+	}
+
+	var securityGroups []string
+	if networking.SecurityGroupID != "" {
+		securityGroups = []string{networking.SecurityGroupID}
+	}
+
+	input := cacheClusterInput(memcached, id, securityGroups, tags)
+	if _, err := mp.client.client.CreateCacheCluster(ctx, input); err != nil {
+		mp.provider.GetLogger().Error("Failed to create Memcached cache",
+			zap.String("cache_cluster", id),
+			zap.Error(err),
+		)
+		return nil, cacheError("create", id, "failed to create cache cluster", err)
+	}
+
+	if err := mp.client.waitCacheCluster(ctx, id, false); err != nil {
+		return nil, cacheError("create", id, "cache cluster did not become available", err)
+	}
+
+	mp.provider.GetLogger().Info("Memcached cache created", zap.String("cache_cluster", id))
+
+	return mp.Read(ctx, id, opts)
+}
+
+// PlannedID returns the ID of the cache cluster of a resource
+func (mp *ElastiCacheMemcachedProvider) PlannedID(resource schema.Resource, opts *provider.ResourceOptions) (string, error) {
+	memcached, ok := resource.(*schema.ElastiCacheMemcached)
+	if !ok {
+		return "", &provider.ProviderError{
+			Provider:  "aws",
+			Operation: "plan",
+			Message:   "invalid resource type for ElastiCache Memcached provider",
+		}
+	}
+	return memcachedClusterID(memcached, opts), nil
+}
+
+// Read reads the current state of a cache cluster
+func (mp *ElastiCacheMemcachedProvider) Read(ctx context.Context, resourceID string, opts *provider.ResourceOptions) (*provider.ResourceResult, error) {
+	cluster, err := mp.client.describeCacheCluster(ctx, resourceID)
+	if err != nil {
+		return nil, cacheError("read", resourceID, "failed to describe cache cluster", err)
+	}
+	if cluster == nil {
+		return nil, &provider.ProviderError{
+			Provider:   "aws",
+			Operation:  "read",
+			ResourceID: resourceID,
+			Cause:      provider.ErrResourceNotFound,
+			Message:    "Memcached cache cluster not found",
+		}
+	}
+
+	return &provider.ResourceResult{
+		ResourceID: resourceID,
+		Kind:       schema.KindElastiCacheMemcached,
+		Status:     cacheStatus(aws.ToString(cluster.CacheClusterStatus)),
+		Outputs:    cacheClusterOutputs(cluster),
+		Timestamp:  time.Now(),
+	}, nil
+}
+
+// Update applies node count and engine version changes immediately and
+// waits until the cluster is available again
+func (mp *ElastiCacheMemcachedProvider) Update(ctx context.Context, resource schema.Resource, opts *provider.ResourceOptions) (*provider.ResourceResult, error) {
+	memcached, ok := resource.(*schema.ElastiCacheMemcached)
+	if !ok {
+		return nil, &provider.ProviderError{
+			Provider:  "aws",
+			Operation: "update",
+			Message:   "invalid resource type for ElastiCache Memcached provider",
+		}
+	}
+
+	id := memcachedClusterID(memcached, opts)
+
+	mp.provider.GetLogger().Info("Updating Memcached cache", zap.String("cache_cluster", id))
+
+	cluster, err := mp.client.describeCacheCluster(ctx, id)
+	if err != nil {
+		return nil, cacheError("update", id, "failed to describe cache cluster", err)
+	}
+	if cluster == nil {
+		return nil, &provider.ProviderError{
+			Provider:   "aws",
+			Operation:  "update",
+			ResourceID: id,
+			Cause:      provider.ErrResourceNotFound,
+			Message:    "Memcached cache cluster not found",
+		}
+	}
+
+	if input := modifyCacheClusterInput(memcached, cluster); input != nil {
+		if _, err := mp.client.client.ModifyCacheCluster(ctx, input); err != nil {
+			return nil, cacheError("update", id, "failed to modify cache cluster", err)
+		}
+		if err := mp.client.waitCacheCluster(ctx, id, false); err != nil {
+			return nil, cacheError("update", id, "cache cluster did not become available", err)
+		}
+	}
+
+	mp.provider.GetLogger().Info("Memcached cache updated", zap.String("cache_cluster", id))
+
+	return mp.Read(ctx, id, opts)
+}
+
+// Delete deletes a cache cluster and then its subnet group
+func (mp *ElastiCacheMemcachedProvider) Delete(ctx context.Context, resourceID string, opts *provider.ResourceOptions) (*provider.ResourceResult, error) {
+	mp.provider.GetLogger().Info("Deleting Memcached cache", zap.String("cache_cluster", resourceID))
+
+	_, err := mp.client.client.DeleteCacheCluster(ctx, &elasticache.DeleteCacheClusterInput{
+		CacheClusterId: aws.String(resourceID),
+	})
+	var notFound *types.CacheClusterNotFoundFault
+	if err != nil && !errors.As(err, &notFound) {
+		mp.provider.GetLogger().Error("Failed to delete Memcached cache",
+			zap.String("cache_cluster", resourceID),
+			zap.Error(err),
+		)
+		return nil, cacheError("delete", resourceID, "failed to delete cache cluster", err)
+	}
+
+	if err := mp.client.waitCacheCluster(ctx, resourceID, true); err != nil {
+		return nil, cacheError("delete", resourceID, "cache cluster was not deleted", err)
+	}
+
+	// The subnet group is named after the cluster
+	if err := mp.client.deleteSubnetGroup(ctx, resourceID); err != nil {
+		return nil, cacheError("delete", resourceID, "failed to delete cache subnet group", err)
+	}
+
+	mp.provider.GetLogger().Info("Memcached cache deleted", zap.String("cache_cluster", resourceID))
+
+	return &provider.ResourceResult{
+		ResourceID: resourceID,
+		Kind:       schema.KindElastiCacheMemcached,
+		Status:     provider.StatusDeleted,
+		Timestamp:  time.Now(),
+	}, nil
+}
+
+// Exists checks if a cache cluster exists
+func (mp *ElastiCacheMemcachedProvider) Exists(ctx context.Context, resourceID string, opts *provider.ResourceOptions) (bool, error) {
+	cluster, err := mp.client.describeCacheCluster(ctx, resourceID)
+	if err != nil {
+		return false, cacheError("exists", resourceID, "failed to describe cache cluster", err)
+	}
+	return cluster != nil && aws.ToString(cluster.CacheClusterStatus) != "deleting", nil
+}
+
+// GetOutputs returns the outputs of a cache cluster
+func (mp *ElastiCacheMemcachedProvider) GetOutputs(ctx context.Context, resourceID string, opts *provider.ResourceOptions) (map[string]string, error) {
+	result, err := mp.Read(ctx, resourceID, opts)
+	if err != nil {
+		return nil, err
+	}
+	return result.Outputs, nil
+}
+
+// cacheClusterInput returns the input that creates the cluster of a cache
+func cacheClusterInput(memcached *schema.ElastiCacheMemcached, id string, securityGroups []string, tags []types.Tag) *elasticache.CreateCacheClusterInput {
+	spec := &memcached.Spec
+
+	input := &elasticache.CreateCacheClusterInput{
+		CacheClusterId:           aws.String(id),
+		Engine:                   aws.String("memcached"),
+		EngineVersion:            optionalString(spec.EngineVersion),
+		CacheNodeType:            aws.String(spec.NodeType),
+		NumCacheNodes:            aws.Int32(int32(memcached.NodeCount())),
+		CacheSubnetGroupName:     aws.String(id),
+		Port:                     aws.Int32(int32(memcached.CachePort())),
+		TransitEncryptionEnabled: aws.Bool(spec.Encryption.InTransit),
+		SecurityGroupIds:         securityGroups,
+		Tags:                     tags,
+	}
+	if memcached.NodeCount() > 1 {
+		input.AZMode = types.AZModeCrossAz
+	}
+
+	return input
+}
+
+// modifyCacheClusterInput returns the input that brings a cluster to the
+// node count and engine version of a cache, or nil when it has them. The
+// nodes with the highest IDs are the ones removed.
+func modifyCacheClusterInput(memcached *schema.ElastiCacheMemcached, cluster *types.CacheCluster) *elasticache.ModifyCacheClusterInput {
+	input := &elasticache.ModifyCacheClusterInput{}
+	changed := false
+
+	if nodes := memcached.NodeCount(); nodes != int(aws.ToInt32(cluster.NumCacheNodes)) {
+		input.NumCacheNodes = aws.Int32(int32(nodes))
+		if nodes < len(cluster.CacheNodes) {
+			ids := make([]string, 0, len(cluster.CacheNodes))
+			for _, node := range cluster.CacheNodes {
+				ids = append(ids, aws.ToString(node.CacheNodeId))
+			}
+			sort.Strings(ids)
+			input.CacheNodeIdsToRemove = ids[nodes:]
+		}
+		changed = true
+	}
+
+	version := memcached.Spec.EngineVersion
+	if version != "" && !engineVersionMatches(aws.ToString(cluster.EngineVersion), version) {
+		input.EngineVersion = aws.String(version)
+		changed = true
+	}
+
+	if !changed {
+		return nil
+	}
+	input.CacheClusterId = cluster.CacheClusterId
+	input.ApplyImmediately = aws.Bool(true)
+	return input
+}
+
+// cacheClusterOutputs returns the outputs of a cache cluster. Clients use
+// the configuration endpoint to discover the nodes, so it is also the
+// primary endpoint.
+func cacheClusterOutputs(cluster *types.CacheCluster) map[string]string {
+	outputs := map[string]string{
+		"cache_cluster_id":   aws.ToString(cluster.CacheClusterId),
+		"arn":                aws.ToString(cluster.ARN),
+		"node_type":          aws.ToString(cluster.CacheNodeType),
+		"num_nodes":          strconv.Itoa(int(aws.ToInt32(cluster.NumCacheNodes))),
+		"transit_encryption": strconv.FormatBool(aws.ToBool(cluster.TransitEncryptionEnabled)),
+	}
+	if endpoint := cluster.ConfigurationEndpoint; endpoint != nil {
+		outputs["configuration_endpoint"] = aws.ToString(endpoint.Address)
+		outputs["primary_endpoint"] = aws.ToString(endpoint.Address)
+		outputs["port"] = strconv.Itoa(int(aws.ToInt32(endpoint.Port)))
+	}
+	return outputs
+}
+
+// memcachedClusterID returns the cache cluster ID of a cache
+func memcachedClusterID(memcached *schema.ElastiCacheMemcached, opts *provider.ResourceOptions) string {
+	return shortName(fmt.Sprintf("%s-%s-%s", opts.StackName, opts.ServiceName, memcached.Metadata.Name), cacheClusterIDMaxLength)
+}
@@ -164,31 +164,47 @@ func (p *Provider) Close() error {
 // registerResourceProviders registers all supported resource providers
 func (p *Provider) registerResourceProviders() {
 	// Register S3 provider
-	p.resourceProviders[schema.KindS3] = NewS3Provider(p)
+	p.register(schema.KindS3, NewS3Provider(p))
 	
 	// Register DynamoDB provider
-	p.resourceProviders[schema.KindDynamoDB] = NewDynamoDBProvider(p)
+	p.register(schema.KindDynamoDB, NewDynamoDBProvider(p))
 	
 	// Register SQS provider
-	p.resourceProviders[schema.KindSQS] = NewSQSProvider(p)
+	p.register(schema.KindSQS, NewSQSProvider(p))
 	
 	// Register SNS provider
-	p.resourceProviders[schema.KindSNS] = NewSNSProvider(p)
+	p.register(schema.KindSNS, NewSNSProvider(p))
 	
 	// Register RDS provider
-	p.resourceProviders[schema.KindRDS] = NewRDSProvider(p)
+	p.register(schema.KindRDS, NewRDSProvider(p))
 	
 	// Register MicroService provider (ECS/Fargate)
-	p.resourceProviders[schema.KindMicroService] = NewECSProvider(p)
+	p.register(schema.KindMicroService, NewECSProvider(p))
+
+	// Register Worker provider (ECS/Fargate without load balancer)
+	p.register(schema.KindWorker, NewWorkerProvider(p))
+
+	// Register CronJob provider (ECS task definition run by EventBridge Scheduler)
+	p.register(schema.KindCronJob, NewCronJobProvider(p))
+
+	// Register ElastiCache providers
+	p.register(schema.KindElastiCacheRedis, NewElastiCacheRedisProvider(p))
+	p.register(schema.KindElastiCacheMemcached, NewElastiCacheMemcachedProvider(p))
 
 	// Register Lambda provider
-	p.resourceProviders[schema.KindLambda] = NewLambdaProvider(p)
+	p.register(schema.KindLambda, NewLambdaProvider(p))
 
 	p.logger.Info("Registered AWS resource providers",
 		zap.Int("count", len(p.resourceProviders)),
 	)
 }
 
+// register registers the provider of a resource kind, adding retries,
+// rate limiting and operation timeouts
+func (p *Provider) register(kind schema.Kind, resourceProvider provider.ResourceProvider) {
+	p.resourceProviders[kind] = provider.WithRetries(resourceProvider, p.retryConfig())
+}
+
 // GetAccountID returns the AWS account ID
 func (p *Provider) GetAccountID() string {
 	return p.accountID
@@ -204,6 +220,15 @@ func (p *Provider) GetConfig() aws.Config {
 	return p.config
 }
 
+// GetNetworking returns the tenant networking, or nil if the provider was
+// configured without it
+func (p *Provider) GetNetworking() *provider.Networking {
+	if p.awsConfig == nil {
+		return nil
+	}
+	return p.awsConfig.Networking
+}
+
 // GetTagHelper returns the tag helper
 func (p *Provider) GetTagHelper() *provider.TagHelper {
 	return p.tagHelper
@@ -1,8 +1,12 @@
 package aws
 
 import (
+	"context"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/stretchr/testify/assert"
 	"github.com/yourusername/panka/internal/logger"
 	"github.com/yourusername/panka/pkg/parser/schema"
@@ -61,13 +65,18 @@ func TestProvider_RegisterResourceProviders(t *testing.T) {
 	p.registerResourceProviders()
 	
 	// Verify all providers are registered
-	assert.Len(t, p.resourceProviders, 6)
+	assert.Len(t, p.resourceProviders, 11)
 	assert.Contains(t, p.resourceProviders, schema.KindS3)
 	assert.Contains(t, p.resourceProviders, schema.KindDynamoDB)
 	assert.Contains(t, p.resourceProviders, schema.KindSQS)
 	assert.Contains(t, p.resourceProviders, schema.KindSNS)
 	assert.Contains(t, p.resourceProviders, schema.KindRDS)
 	assert.Contains(t, p.resourceProviders, schema.KindMicroService)
+	assert.Contains(t, p.resourceProviders, schema.KindWorker)
+	assert.Contains(t, p.resourceProviders, schema.KindCronJob)
+	assert.Contains(t, p.resourceProviders, schema.KindElastiCacheRedis)
+	assert.Contains(t, p.resourceProviders, schema.KindElastiCacheMemcached)
+	assert.Contains(t, p.resourceProviders, schema.KindLambda)
 }
 
 func TestProvider_GetAccountID(t *testing.T) {
@@ -126,3 +135,34 @@ func TestProviderError_WithoutCause(t *testing.T) {
 	assert.NotContains(t, err.Error(), "caused by")
 }
 
+
+// testServerProvider returns a provider whose AWS clients call handler, in
+// the tenant networking of a private subnet and a security group
+func testServerProvider(t *testing.T, handler http.HandlerFunc) *Provider {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	log, _ := logger.NewDevelopment()
+	return &Provider{
+		logger:    log,
+		accountID: "123456789012",
+		region:    "us-east-1",
+		tagHelper: provider.NewTagHelper(nil),
+		awsConfig: &provider.Config{
+			Networking: &provider.Networking{
+				PrivateSubnetIDs: []string{"subnet-a", "subnet-b"},
+				SecurityGroupID:  "sg-123",
+			},
+		},
+		config: aws.Config{
+			Region:           "us-east-1",
+			BaseEndpoint:     aws.String(server.URL),
+			HTTPClient:       server.Client(),
+			RetryMaxAttempts: 1,
+			Credentials: aws.CredentialsProviderFunc(func(context.Context) (aws.Credentials, error) {
+				return aws.Credentials{AccessKeyID: "AKID", SecretAccessKey: "secret"}, nil
+			}),
+		},
+	}
+}
@@ -2,19 +2,42 @@ package aws
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/rds"
+	"github.com/aws/aws-sdk-go-v2/service/rds/types"
 	"github.com/yourusername/panka/pkg/parser/schema"
 	"github.com/yourusername/panka/pkg/provider"
+	"github.com/yourusername/panka/pkg/secrets"
 	"go.uber.org/zap"
 )
 
-// RDSProvider implements RDS database management
+const (
+	// rdsDefaultWaitTime bounds the wait for a database when the operation
+	// has no deadline
+	rdsDefaultWaitTime = 60 * time.Minute
+
+	// rdsMaxParametersPerCall is the number of parameters RDS accepts in one
+	// parameter group modification
+	rdsMaxParametersPerCall = 20
+)
+
+// RDSProvider implements RDS database management. Engines of type
+// aurora-postgresql and aurora-mysql are created as an Aurora cluster with a
+// writer instance, and a reader instance when multiAZ is set. Other engines
+// are created as a single DB instance. Resource IDs are the instance or
+// cluster identifier.
 type RDSProvider struct {
 	provider *Provider
 	client   *rds.Client
+	secrets  *secrets.Manager
 }
 
 // NewRDSProvider creates a new RDS provider
@@ -22,10 +45,12 @@ func NewRDSProvider(p *Provider) *RDSProvider {
 	return &RDSProvider{
 		provider: p,
 		client:   rds.NewFromConfig(p.GetConfig()),
+		secrets:  secrets.NewManager(p.GetConfig()),
 	}
 }
 
-// Create creates a new RDS instance
+// Create creates a new RDS instance or Aurora cluster with its subnet group
+// and parameter group
 func (rp *RDSProvider) Create(ctx context.Context, resource schema.Resource, opts *provider.ResourceOptions) (*provider.ResourceResult, error) {
 	rdsResource, ok := resource.(*schema.RDS)
 	if !ok {
@@ -36,73 +61,396 @@ func (rp *RDSProvider) Create(ctx context.Context, resource schema.Resource, opt
 		}
 	}
 
-	rp.provider.GetLogger().Info("Creating RDS instance",
-		zap.String("name", rdsResource.Metadata.Name),
-	)
+	identifier := rdsIdentifier(rdsResource, opts)
 
-	// TODO: Implement full RDS creation
-	// This is a complex resource requiring:
-	// - DB instance creation
-	// - Security group configuration
-	// - Subnet group setup
-	// - Parameter group configuration
-	// - Backup configuration
-	// - Multi-AZ setup
-
-	instanceID := fmt.Sprintf("%s-%s-%s",
-		opts.StackName,
-		opts.ServiceName,
-		rdsResource.Metadata.Name,
+	rp.provider.GetLogger().Info("Creating RDS database",
+		zap.String("identifier", identifier),
+		zap.String("engine", rdsResource.Spec.Engine.Type),
 	)
 
-	rp.provider.GetLogger().Warn("RDS provider not fully implemented yet")
+	if opts.DryRun {
+		return &provider.ResourceResult{
+			ResourceID: identifier,
+			Kind:       schema.KindRDS,
+			Status:     provider.StatusPending,
+			Outputs: map[string]string{
+				"identifier": identifier,
+				"engine":     rdsResource.Spec.Engine.Type,
+				"port":       strconv.Itoa(rdsPort(&rdsResource.Spec)),
+			},
+			Timestamp: time.Now(),
+		}, nil
+	}
 
-	return &provider.ResourceResult{
-		ResourceID: instanceID,
-		Kind:       schema.KindRDS,
-		Status:     provider.StatusPending,
-		Outputs: map[string]string{
-			"instance_id": instanceID,
-			"engine":      rdsResource.Spec.Engine.Type,
-		},
-		Timestamp: time.Now(),
-	}, nil
+	// An interrupted create may have left the database behind
+	exists, err := rp.Exists(ctx, identifier, opts)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		rp.provider.GetLogger().Info("RDS database already exists, updating it",
+			zap.String("identifier", identifier),
+		)
+		return rp.Update(ctx, resource, opts)
+	}
+
+	networking := rp.provider.GetNetworking()
+	if networking == nil || len(networking.PrivateSubnetIDs) == 0 {
+		return nil, rdsError("create", identifier, "cannot place RDS database",
+			errors.New("tenant networking has no private subnets, set up the tenant networking first"))
+	}
+
+	password, err := rp.masterPassword(ctx, rdsResource.Spec.Database.PasswordSecret.Ref)
+	if err != nil {
+		return nil, rdsError("create", identifier, "failed to read master password", err)
+	}
+
+	tags := rdsTags(rp.provider.tagHelper.BuildTags(opts, resource))
+
+	if err := rp.ensureSubnetGroup(ctx, identifier, networking.PrivateSubnetIDs, tags); err != nil {
+		return nil, rdsError("create", identifier, "failed to create DB subnet group", err)
+	}
+
+	parameterGroup, err := rp.ensureParameterGroup(ctx, identifier, &rdsResource.Spec, tags)
+	if err != nil {
+		return nil, rdsError("create", identifier, "failed to create parameter group", err)
+	}
+
+	var securityGroups []string
+	if networking.SecurityGroupID != "" {
+		securityGroups = []string{networking.SecurityGroupID}
+	}
+
+	if isAurora(rdsResource.Spec.Engine.Type) {
+		err = rp.createCluster(ctx, identifier, rdsResource, password, parameterGroup, securityGroups, tags)
+	} else {
+		err = rp.createInstance(ctx, identifier, rdsResource, password, parameterGroup, securityGroups, tags)
+	}
+	if err != nil {
+		rp.provider.GetLogger().Error("Failed to create RDS database",
+			zap.String("identifier", identifier),
+			zap.Error(err),
+		)
+		return nil, rdsError("create", identifier, "failed to create RDS database", err)
+	}
+
+	rp.provider.GetLogger().Info("RDS database created", zap.String("identifier", identifier))
+
+	return rp.Read(ctx, identifier, opts)
+}
+
+// createInstance creates a DB instance and waits until it is available
+func (rp *RDSProvider) createInstance(ctx context.Context, identifier string, res *schema.RDS, password, parameterGroup string, securityGroups []string, tags []types.Tag) error {
+	input := dbInstanceInput(identifier, &res.Spec, password, parameterGroup, securityGroups, tags)
+	if _, err := rp.client.CreateDBInstance(ctx, input); err != nil {
+		return err
+	}
+
+	return rp.waitInstanceAvailable(ctx, identifier)
+}
+
+// createCluster creates an Aurora cluster with its instances and waits until
+// they are available
+func (rp *RDSProvider) createCluster(ctx context.Context, identifier string, res *schema.RDS, password, parameterGroup string, securityGroups []string, tags []types.Tag) error {
+	spec := &res.Spec
+	input := dbClusterInput(identifier, spec, password, parameterGroup, securityGroups, tags)
+	if _, err := rp.client.CreateDBCluster(ctx, input); err != nil {
+		return err
+	}
+
+	for _, instanceID := range auroraInstanceIDs(identifier, spec.Instance.MultiAZ) {
+		if _, err := rp.client.CreateDBInstance(ctx, &rds.CreateDBInstanceInput{
+			DBInstanceIdentifier: aws.String(instanceID),
+			DBClusterIdentifier:  aws.String(identifier),
+			DBInstanceClass:      aws.String(spec.Instance.Class),
+			Engine:               aws.String(spec.Engine.Type),
+			PubliclyAccessible:   aws.Bool(false),
+			Tags:                 tags,
+		}); err != nil {
+			return fmt.Errorf("failed to create instance %s: %w", instanceID, err)
+		}
+	}
+
+	if err := rds.NewDBClusterAvailableWaiter(rp.client).Wait(ctx, &rds.DescribeDBClustersInput{
+		DBClusterIdentifier: aws.String(identifier),
+	}, rdsWaitTime(ctx)); err != nil {
+		return err
+	}
+	for _, instanceID := range auroraInstanceIDs(identifier, spec.Instance.MultiAZ) {
+		if err := rp.waitInstanceAvailable(ctx, instanceID); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
-// Read reads the current state of an RDS instance
+// PlannedID returns the identifier of the instance or cluster of a resource
+func (rp *RDSProvider) PlannedID(resource schema.Resource, opts *provider.ResourceOptions) (string, error) {
+	rdsResource, ok := resource.(*schema.RDS)
+	if !ok {
+		return "", &provider.ProviderError{
+			Provider:  "aws",
+			Operation: "plan",
+			Message:   "invalid resource type for RDS provider",
+		}
+	}
+	return rdsIdentifier(rdsResource, opts), nil
+}
+
+// Read reads the current state of an RDS instance or Aurora cluster
 func (rp *RDSProvider) Read(ctx context.Context, resourceID string, opts *provider.ResourceOptions) (*provider.ResourceResult, error) {
+	cluster, err := rp.describeCluster(ctx, resourceID)
+	if err != nil {
+		return nil, rdsError("read", resourceID, "failed to describe Aurora cluster", err)
+	}
+	if cluster != nil {
+		return &provider.ResourceResult{
+			ResourceID: resourceID,
+			Kind:       schema.KindRDS,
+			Status:     rdsStatus(aws.ToString(cluster.Status)),
+			Outputs:    clusterOutputs(cluster),
+			Timestamp:  time.Now(),
+		}, nil
+	}
+
+	instance, err := rp.describeInstance(ctx, resourceID)
+	if err != nil {
+		return nil, rdsError("read", resourceID, "failed to describe RDS instance", err)
+	}
+	if instance == nil {
+		return nil, &provider.ProviderError{
+			Provider:   "aws",
+			Operation:  "read",
+			ResourceID: resourceID,
+			Cause:      provider.ErrResourceNotFound,
+			Message:    "RDS database not found",
+		}
+	}
+
 	return &provider.ResourceResult{
 		ResourceID: resourceID,
 		Kind:       schema.KindRDS,
-		Status:     provider.StatusUnknown,
+		Status:     rdsStatus(aws.ToString(instance.DBInstanceStatus)),
+		Outputs:    instanceOutputs(instance),
 		Timestamp:  time.Now(),
 	}, nil
 }
 
-// Update updates an existing RDS instance
+// Update applies class, storage, Multi-AZ, backup and parameter changes
+// immediately and waits until the database is available again
 func (rp *RDSProvider) Update(ctx context.Context, resource schema.Resource, opts *provider.ResourceOptions) (*provider.ResourceResult, error) {
-	return nil, &provider.ProviderError{
-		Provider:  "aws",
-		Operation: "update",
-		Message:   "RDS provider not fully implemented",
+	rdsResource, ok := resource.(*schema.RDS)
+	if !ok {
+		return nil, &provider.ProviderError{
+			Provider:  "aws",
+			Operation: "update",
+			Message:   "invalid resource type for RDS provider",
+		}
+	}
+
+	identifier := rdsIdentifier(rdsResource, opts)
+	spec := &rdsResource.Spec
+
+	rp.provider.GetLogger().Info("Updating RDS database",
+		zap.String("identifier", identifier),
+	)
+
+	parameterGroup, err := rp.ensureParameterGroup(ctx, identifier, spec, rdsTags(rp.provider.tagHelper.BuildTags(opts, resource)))
+	if err != nil {
+		return nil, rdsError("update", identifier, "failed to update parameter group", err)
+	}
+
+	if isAurora(spec.Engine.Type) {
+		err = rp.updateCluster(ctx, identifier, spec, parameterGroup)
+	} else {
+		err = rp.updateInstance(ctx, identifier, spec, parameterGroup)
+	}
+	if err != nil {
+		return nil, rdsError("update", identifier, "failed to update RDS database", err)
 	}
+
+	rp.provider.GetLogger().Info("RDS database updated", zap.String("identifier", identifier))
+
+	return rp.Read(ctx, identifier, opts)
 }
 
-// Delete deletes an RDS instance
+// updateInstance modifies a DB instance
+func (rp *RDSProvider) updateInstance(ctx context.Context, identifier string, spec *schema.RDSSpec, parameterGroup string) error {
+	input := modifyDBInstanceInput(identifier, spec, parameterGroup)
+	if _, err := rp.client.ModifyDBInstance(ctx, input); err != nil {
+		return err
+	}
+	return rp.waitInstanceAvailable(ctx, identifier)
+}
+
+// updateCluster modifies an Aurora cluster and the class of its instances
+func (rp *RDSProvider) updateCluster(ctx context.Context, identifier string, spec *schema.RDSSpec, parameterGroup string) error {
+	input := &rds.ModifyDBClusterInput{
+		DBClusterIdentifier:        aws.String(identifier),
+		BackupRetentionPeriod:      aws.Int32(int32(rdsBackupRetention(spec, true))),
+		PreferredBackupWindow:      optionalString(spec.Backup.PreferredWindow),
+		PreferredMaintenanceWindow: optionalString(spec.Backup.MaintenanceWindow),
+		ApplyImmediately:           aws.Bool(true),
+	}
+	if parameterGroup != "" {
+		input.DBClusterParameterGroupName = aws.String(parameterGroup)
+	}
+
+	result, err := rp.client.ModifyDBCluster(ctx, input)
+	if err != nil {
+		return err
+	}
+
+	for _, member := range result.DBCluster.DBClusterMembers {
+		instanceID := aws.ToString(member.DBInstanceIdentifier)
+		if _, err := rp.client.ModifyDBInstance(ctx, &rds.ModifyDBInstanceInput{
+			DBInstanceIdentifier: aws.String(instanceID),
+			DBInstanceClass:      aws.String(spec.Instance.Class),
+			ApplyImmediately:     aws.Bool(true),
+		}); err != nil {
+			return fmt.Errorf("failed to modify instance %s: %w", instanceID, err)
+		}
+	}
+	for _, member := range result.DBCluster.DBClusterMembers {
+		if err := rp.waitInstanceAvailable(ctx, aws.ToString(member.DBInstanceIdentifier)); err != nil {
+			return err
+		}
+	}
+
+	return rds.NewDBClusterAvailableWaiter(rp.client).Wait(ctx, &rds.DescribeDBClustersInput{
+		DBClusterIdentifier: aws.String(identifier),
+	}, rdsWaitTime(ctx))
+}
+
+// Delete deletes an RDS instance or Aurora cluster, keeping a final
+// snapshot, and then its subnet group and parameter group
 func (rp *RDSProvider) Delete(ctx context.Context, resourceID string, opts *provider.ResourceOptions) (*provider.ResourceResult, error) {
-	rp.provider.GetLogger().Info("Deleting RDS instance", zap.String("instance", resourceID))
+	rp.provider.GetLogger().Info("Deleting RDS database", zap.String("identifier", resourceID))
+
+	finalSnapshot := fmt.Sprintf("%s-final-%d", resourceID, time.Now().Unix())
+
+	cluster, err := rp.describeCluster(ctx, resourceID)
+	if err != nil {
+		return nil, rdsError("delete", resourceID, "failed to describe Aurora cluster", err)
+	}
+
+	if cluster != nil {
+		err = rp.deleteCluster(ctx, cluster, finalSnapshot)
+	} else {
+		err = rp.deleteInstance(ctx, resourceID, finalSnapshot)
+	}
+	if err != nil {
+		rp.provider.GetLogger().Error("Failed to delete RDS database",
+			zap.String("identifier", resourceID),
+			zap.Error(err),
+		)
+		return nil, rdsError("delete", resourceID, "failed to delete RDS database", err)
+	}
+
+	// The groups are named after the database
+	if _, err := rp.client.DeleteDBSubnetGroup(ctx, &rds.DeleteDBSubnetGroupInput{
+		DBSubnetGroupName: aws.String(resourceID),
+	}); err != nil && !isRDSNotFound(err) {
+		return nil, rdsError("delete", resourceID, "failed to delete DB subnet group", err)
+	}
+	if cluster != nil {
+		_, err = rp.client.DeleteDBClusterParameterGroup(ctx, &rds.DeleteDBClusterParameterGroupInput{
+			DBClusterParameterGroupName: aws.String(resourceID),
+		})
+	} else {
+		_, err = rp.client.DeleteDBParameterGroup(ctx, &rds.DeleteDBParameterGroupInput{
+			DBParameterGroupName: aws.String(resourceID),
+		})
+	}
+	if err != nil && !isRDSNotFound(err) {
+		return nil, rdsError("delete", resourceID, "failed to delete parameter group", err)
+	}
+
+	rp.provider.GetLogger().Info("RDS database deleted",
+		zap.String("identifier", resourceID),
+		zap.String("final_snapshot", finalSnapshot),
+	)
 
 	return &provider.ResourceResult{
 		ResourceID: resourceID,
 		Kind:       schema.KindRDS,
 		Status:     provider.StatusDeleted,
-		Timestamp:  time.Now(),
+		Outputs: map[string]string{
+			"final_snapshot": finalSnapshot,
+		},
+		Timestamp: time.Now(),
 	}, nil
 }
 
-// Exists checks if an RDS instance exists
+// deleteInstance deletes a DB instance and waits until it is gone
+func (rp *RDSProvider) deleteInstance(ctx context.Context, identifier, finalSnapshot string) error {
+	_, err := rp.client.DeleteDBInstance(ctx, &rds.DeleteDBInstanceInput{
+		DBInstanceIdentifier:      aws.String(identifier),
+		FinalDBSnapshotIdentifier: aws.String(finalSnapshot),
+	})
+	if err != nil {
+		if isRDSNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	return rds.NewDBInstanceDeletedWaiter(rp.client).Wait(ctx, &rds.DescribeDBInstancesInput{
+		DBInstanceIdentifier: aws.String(identifier),
+	}, rdsWaitTime(ctx))
+}
+
+// deleteCluster deletes the instances of an Aurora cluster, then the
+// cluster, and waits until it is gone
+func (rp *RDSProvider) deleteCluster(ctx context.Context, cluster *types.DBCluster, finalSnapshot string) error {
+	identifier := aws.ToString(cluster.DBClusterIdentifier)
+
+	for _, member := range cluster.DBClusterMembers {
+		if _, err := rp.client.DeleteDBInstance(ctx, &rds.DeleteDBInstanceInput{
+			DBInstanceIdentifier: member.DBInstanceIdentifier,
+			SkipFinalSnapshot:    aws.Bool(true),
+		}); err != nil && !isRDSNotFound(err) {
+			return fmt.Errorf("failed to delete instance %s: %w", aws.ToString(member.DBInstanceIdentifier), err)
+		}
+	}
+	for _, member := range cluster.DBClusterMembers {
+		if err := rds.NewDBInstanceDeletedWaiter(rp.client).Wait(ctx, &rds.DescribeDBInstancesInput{
+			DBInstanceIdentifier: member.DBInstanceIdentifier,
+		}, rdsWaitTime(ctx)); err != nil {
+			return err
+		}
+	}
+
+	if _, err := rp.client.DeleteDBCluster(ctx, &rds.DeleteDBClusterInput{
+		DBClusterIdentifier:       aws.String(identifier),
+		FinalDBSnapshotIdentifier: aws.String(finalSnapshot),
+	}); err != nil {
+		if isRDSNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	return rds.NewDBClusterDeletedWaiter(rp.client).Wait(ctx, &rds.DescribeDBClustersInput{
+		DBClusterIdentifier: aws.String(identifier),
+	}, rdsWaitTime(ctx))
+}
+
+// Exists checks if an RDS instance or Aurora cluster exists
 func (rp *RDSProvider) Exists(ctx context.Context, resourceID string, opts *provider.ResourceOptions) (bool, error) {
-	return false, nil
+	cluster, err := rp.describeCluster(ctx, resourceID)
+	if err != nil {
+		return false, rdsError("exists", resourceID, "failed to describe Aurora cluster", err)
+	}
+	if cluster != nil {
+		return aws.ToString(cluster.Status) != "deleting", nil
+	}
+
+	instance, err := rp.describeInstance(ctx, resourceID)
+	if err != nil {
+		return false, rdsError("exists", resourceID, "failed to describe RDS instance", err)
+	}
+	return instance != nil && aws.ToString(instance.DBInstanceStatus) != "deleting", nil
 }
 
 // GetOutputs returns the outputs of an RDS instance
@@ -114,3 +462,496 @@ func (rp *RDSProvider) GetOutputs(ctx context.Context, resourceID string, opts *
 	return result.Outputs, nil
 }
 
+// ensureSubnetGroup creates the DB subnet group of a database in the tenant's
+// private subnets unless it exists
+func (rp *RDSProvider) ensureSubnetGroup(ctx context.Context, name string, subnetIDs []string, tags []types.Tag) error {
+	_, err := rp.client.CreateDBSubnetGroup(ctx, &rds.CreateDBSubnetGroupInput{
+		DBSubnetGroupName:        aws.String(name),
+		DBSubnetGroupDescription: aws.String("Private subnets of " + name),
+		SubnetIds:                subnetIDs,
+		Tags:                     tags,
+	})
+	var exists *types.DBSubnetGroupAlreadyExistsFault
+	if errors.As(err, &exists) {
+		return nil
+	}
+	return err
+}
+
+// ensureParameterGroup creates or updates the parameter group of a database
+// from its engine parameters and returns its name. A named parameterGroup is
+// used as is; without parameters the engine default is used and the name is
+// empty.
+func (rp *RDSProvider) ensureParameterGroup(ctx context.Context, name string, spec *schema.RDSSpec, tags []types.Tag) (string, error) {
+	if spec.Engine.ParameterGroup != "" {
+		return spec.Engine.ParameterGroup, nil
+	}
+	if len(spec.Engine.Parameters) == 0 {
+		return "", nil
+	}
+
+	versions, err := rp.client.DescribeDBEngineVersions(ctx, &rds.DescribeDBEngineVersionsInput{
+		Engine:        aws.String(spec.Engine.Type),
+		EngineVersion: aws.String(spec.Engine.Version),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to look up engine version: %w", err)
+	}
+	if len(versions.DBEngineVersions) == 0 {
+		return "", fmt.Errorf("unknown engine version %s %s", spec.Engine.Type, spec.Engine.Version)
+	}
+	family := versions.DBEngineVersions[0].DBParameterGroupFamily
+	description := aws.String("Parameters of " + name)
+
+	if isAurora(spec.Engine.Type) {
+		_, err = rp.client.CreateDBClusterParameterGroup(ctx, &rds.CreateDBClusterParameterGroupInput{
+			DBClusterParameterGroupName: aws.String(name),
+			DBParameterGroupFamily:      family,
+			Description:                 description,
+			Tags:                        tags,
+		})
+	} else {
+		_, err = rp.client.CreateDBParameterGroup(ctx, &rds.CreateDBParameterGroupInput{
+			DBParameterGroupName:   aws.String(name),
+			DBParameterGroupFamily: family,
+			Description:            description,
+			Tags:                   tags,
+		})
+	}
+	var exists *types.DBParameterGroupAlreadyExistsFault
+	if err != nil && !errors.As(err, &exists) {
+		return "", err
+	}
+
+	applyTypes, err := rp.parameterApplyTypes(ctx, name, isAurora(spec.Engine.Type))
+	if err != nil {
+		return "", fmt.Errorf("failed to describe parameters: %w", err)
+	}
+
+	for _, batch := range rdsParameters(spec.Engine.Parameters, applyTypes) {
+		if isAurora(spec.Engine.Type) {
+			_, err = rp.client.ModifyDBClusterParameterGroup(ctx, &rds.ModifyDBClusterParameterGroupInput{
+				DBClusterParameterGroupName: aws.String(name),
+				Parameters:                  batch,
+			})
+		} else {
+			_, err = rp.client.ModifyDBParameterGroup(ctx, &rds.ModifyDBParameterGroupInput{
+				DBParameterGroupName: aws.String(name),
+				Parameters:           batch,
+			})
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return name, nil
+}
+
+// parameterApplyTypes returns whether each parameter of a group is static or
+// dynamic
+func (rp *RDSProvider) parameterApplyTypes(ctx context.Context, name string, cluster bool) (map[string]string, error) {
+	applyTypes := make(map[string]string)
+
+	if cluster {
+		paginator := rds.NewDescribeDBClusterParametersPaginator(rp.client, &rds.DescribeDBClusterParametersInput{
+			DBClusterParameterGroupName: aws.String(name),
+		})
+		for paginator.HasMorePages() {
+			page, err := paginator.NextPage(ctx)
+			if err != nil {
+				return nil, err
+			}
+			for _, p := range page.Parameters {
+				applyTypes[aws.ToString(p.ParameterName)] = aws.ToString(p.ApplyType)
+			}
+		}
+		return applyTypes, nil
+	}
+
+	paginator := rds.NewDescribeDBParametersPaginator(rp.client, &rds.DescribeDBParametersInput{
+		DBParameterGroupName: aws.String(name),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range page.Parameters {
+			applyTypes[aws.ToString(p.ParameterName)] = aws.ToString(p.ApplyType)
+		}
+	}
+	return applyTypes, nil
+}
+
+// masterPassword reads the master password from a Secrets Manager secret or
+// an SSM parameter. A secret holding JSON uses its password field.
+func (rp *RDSProvider) masterPassword(ctx context.Context, secretID string) (string, error) {
+	if secretID == "" {
+		return "", errors.New("database.passwordSecret.ref is required")
+	}
+
+	value, err := rp.secrets.Value(ctx, secretID)
+	if err != nil {
+		return "", err
+	}
+
+	return secretPassword(value)
+}
+
+// describeInstance returns a DB instance, or nil if it does not exist
+func (rp *RDSProvider) describeInstance(ctx context.Context, identifier string) (*types.DBInstance, error) {
+	result, err := rp.client.DescribeDBInstances(ctx, &rds.DescribeDBInstancesInput{
+		DBInstanceIdentifier: aws.String(identifier),
+	})
+	if err != nil {
+		if isRDSNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if len(result.DBInstances) == 0 {
+		return nil, nil
+	}
+	return &result.DBInstances[0], nil
+}
+
+// describeCluster returns an Aurora cluster, or nil if it does not exist
+func (rp *RDSProvider) describeCluster(ctx context.Context, identifier string) (*types.DBCluster, error) {
+	result, err := rp.client.DescribeDBClusters(ctx, &rds.DescribeDBClustersInput{
+		DBClusterIdentifier: aws.String(identifier),
+	})
+	if err != nil {
+		if isRDSNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if len(result.DBClusters) == 0 {
+		return nil, nil
+	}
+	return &result.DBClusters[0], nil
+}
+
+// waitInstanceAvailable waits until a DB instance is available
+func (rp *RDSProvider) waitInstanceAvailable(ctx context.Context, identifier string) error {
+	rp.provider.GetLogger().Info("Waiting for RDS instance to become available",
+		zap.String("identifier", identifier),
+	)
+
+	return rds.NewDBInstanceAvailableWaiter(rp.client).Wait(ctx, &rds.DescribeDBInstancesInput{
+		DBInstanceIdentifier: aws.String(identifier),
+	}, rdsWaitTime(ctx))
+}
+
+// dbInstanceInput builds the DB instance of a database that does not run
+// on Aurora, in its subnet group and with the tenant's security groups
+func dbInstanceInput(identifier string, spec *schema.RDSSpec, password, parameterGroup string, securityGroups []string, tags []types.Tag) *rds.CreateDBInstanceInput {
+	input := &rds.CreateDBInstanceInput{
+		DBInstanceIdentifier:       aws.String(identifier),
+		DBInstanceClass:            aws.String(spec.Instance.Class),
+		Engine:                     aws.String(spec.Engine.Type),
+		EngineVersion:              aws.String(spec.Engine.Version),
+		DBName:                     aws.String(spec.Database.Name),
+		MasterUsername:             aws.String(spec.Database.Username),
+		MasterUserPassword:         aws.String(password),
+		Port:                       aws.Int32(int32(rdsPort(spec))),
+		DBSubnetGroupName:          aws.String(identifier),
+		VpcSecurityGroupIds:        securityGroups,
+		MultiAZ:                    aws.Bool(spec.Instance.MultiAZ),
+		PubliclyAccessible:         aws.Bool(false),
+		StorageEncrypted:           aws.Bool(true),
+		CopyTagsToSnapshot:         aws.Bool(true),
+		BackupRetentionPeriod:      aws.Int32(int32(rdsBackupRetention(spec, false))),
+		PreferredBackupWindow:      optionalString(spec.Backup.PreferredWindow),
+		PreferredMaintenanceWindow: optionalString(spec.Backup.MaintenanceWindow),
+		Tags:                       tags,
+	}
+	applyStorage(input, &spec.Instance)
+	if parameterGroup != "" {
+		input.DBParameterGroupName = aws.String(parameterGroup)
+	}
+
+	return input
+}
+
+// dbClusterInput builds the Aurora cluster of a database, in its subnet
+// group and with the tenant's security groups
+func dbClusterInput(identifier string, spec *schema.RDSSpec, password, parameterGroup string, securityGroups []string, tags []types.Tag) *rds.CreateDBClusterInput {
+	input := &rds.CreateDBClusterInput{
+		DBClusterIdentifier:        aws.String(identifier),
+		Engine:                     aws.String(spec.Engine.Type),
+		EngineVersion:              aws.String(spec.Engine.Version),
+		DatabaseName:               aws.String(spec.Database.Name),
+		MasterUsername:             aws.String(spec.Database.Username),
+		MasterUserPassword:         aws.String(password),
+		Port:                       aws.Int32(int32(rdsPort(spec))),
+		DBSubnetGroupName:          aws.String(identifier),
+		VpcSecurityGroupIds:        securityGroups,
+		StorageEncrypted:           aws.Bool(true),
+		CopyTagsToSnapshot:         aws.Bool(true),
+		BackupRetentionPeriod:      aws.Int32(int32(rdsBackupRetention(spec, true))),
+		PreferredBackupWindow:      optionalString(spec.Backup.PreferredWindow),
+		PreferredMaintenanceWindow: optionalString(spec.Backup.MaintenanceWindow),
+		Tags:                       tags,
+	}
+	if parameterGroup != "" {
+		input.DBClusterParameterGroupName = aws.String(parameterGroup)
+	}
+
+	return input
+}
+
+// modifyDBInstanceInput builds the changes of a DB instance to its spec,
+// applied immediately
+func modifyDBInstanceInput(identifier string, spec *schema.RDSSpec, parameterGroup string) *rds.ModifyDBInstanceInput {
+	input := &rds.ModifyDBInstanceInput{
+		DBInstanceIdentifier:       aws.String(identifier),
+		DBInstanceClass:            aws.String(spec.Instance.Class),
+		MultiAZ:                    aws.Bool(spec.Instance.MultiAZ),
+		BackupRetentionPeriod:      aws.Int32(int32(rdsBackupRetention(spec, false))),
+		PreferredBackupWindow:      optionalString(spec.Backup.PreferredWindow),
+		PreferredMaintenanceWindow: optionalString(spec.Backup.MaintenanceWindow),
+		ApplyImmediately:           aws.Bool(true),
+	}
+	if spec.Instance.Storage.AllocatedGB > 0 {
+		input.AllocatedStorage = aws.Int32(int32(spec.Instance.Storage.AllocatedGB))
+	}
+	if spec.Instance.Storage.MaxAllocatedGB > spec.Instance.Storage.AllocatedGB {
+		input.MaxAllocatedStorage = aws.Int32(int32(spec.Instance.Storage.MaxAllocatedGB))
+	}
+	if spec.Instance.Storage.Type != "" {
+		input.StorageType = aws.String(spec.Instance.Storage.Type)
+	}
+	if spec.Instance.IOPS > 0 {
+		input.Iops = aws.Int32(int32(spec.Instance.IOPS))
+	}
+	if parameterGroup != "" {
+		input.DBParameterGroupName = aws.String(parameterGroup)
+	}
+
+	return input
+}
+
+// applyStorage sets the storage of a DB instance, with storage autoscaling
+// up to MaxAllocatedGB
+func applyStorage(input *rds.CreateDBInstanceInput, instance *schema.InstanceConfig) {
+	storage := instance.Storage
+	if storage.AllocatedGB > 0 {
+		input.AllocatedStorage = aws.Int32(int32(storage.AllocatedGB))
+	}
+	if storage.MaxAllocatedGB > storage.AllocatedGB {
+		input.MaxAllocatedStorage = aws.Int32(int32(storage.MaxAllocatedGB))
+	}
+	if storage.Type != "" {
+		input.StorageType = aws.String(storage.Type)
+	}
+	if instance.IOPS > 0 {
+		input.Iops = aws.Int32(int32(instance.IOPS))
+	}
+}
+
+// rdsParameters converts engine parameters into batches for a parameter
+// group modification. Static parameters are applied at the next reboot.
+func rdsParameters(parameters map[string]string, applyTypes map[string]string) [][]types.Parameter {
+	names := make([]string, 0, len(parameters))
+	for name := range parameters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var batches [][]types.Parameter
+	var batch []types.Parameter
+	for _, name := range names {
+		method := types.ApplyMethodImmediate
+		if applyTypes[name] == "static" {
+			method = types.ApplyMethodPendingReboot
+		}
+		batch = append(batch, types.Parameter{
+			ParameterName:  aws.String(name),
+			ParameterValue: aws.String(parameters[name]),
+			ApplyMethod:    method,
+		})
+		if len(batch) == rdsMaxParametersPerCall {
+			batches = append(batches, batch)
+			batch = nil
+		}
+	}
+	if len(batch) > 0 {
+		batches = append(batches, batch)
+	}
+	return batches
+}
+
+// secretPassword returns the password held by a secret string: the password
+// field of a JSON object, or the whole string
+func secretPassword(secret string) (string, error) {
+	if secret == "" {
+		return "", errors.New("secret has no string value")
+	}
+	if !strings.HasPrefix(strings.TrimSpace(secret), "{") {
+		return secret, nil
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal([]byte(secret), &fields); err != nil {
+		return "", fmt.Errorf("failed to parse secret: %w", err)
+	}
+	password, ok := fields["password"].(string)
+	if !ok || password == "" {
+		return "", errors.New("secret has no password field")
+	}
+	return password, nil
+}
+
+// instanceOutputs returns the outputs of a DB instance
+func instanceOutputs(instance *types.DBInstance) map[string]string {
+	outputs := map[string]string{
+		"identifier":     aws.ToString(instance.DBInstanceIdentifier),
+		"arn":            aws.ToString(instance.DBInstanceArn),
+		"engine":         aws.ToString(instance.Engine),
+		"instance_class": aws.ToString(instance.DBInstanceClass),
+		"status":         aws.ToString(instance.DBInstanceStatus),
+	}
+	if instance.Endpoint != nil {
+		outputs["endpoint"] = aws.ToString(instance.Endpoint.Address)
+		outputs["port"] = strconv.Itoa(int(aws.ToInt32(instance.Endpoint.Port)))
+	}
+	return outputs
+}
+
+// clusterOutputs returns the outputs of an Aurora cluster
+func clusterOutputs(cluster *types.DBCluster) map[string]string {
+	return map[string]string{
+		"identifier":      aws.ToString(cluster.DBClusterIdentifier),
+		"arn":             aws.ToString(cluster.DBClusterArn),
+		"engine":          aws.ToString(cluster.Engine),
+		"status":          aws.ToString(cluster.Status),
+		"endpoint":        aws.ToString(cluster.Endpoint),
+		"reader_endpoint": aws.ToString(cluster.ReaderEndpoint),
+		"port":            strconv.Itoa(int(aws.ToInt32(cluster.Port))),
+	}
+}
+
+// rdsStatus maps an RDS status to a resource status
+func rdsStatus(status string) provider.ResourceStatus {
+	switch status {
+	case "available":
+		return provider.StatusAvailable
+	case "creating":
+		return provider.StatusCreating
+	case "deleting":
+		return provider.StatusDeleting
+	case "failed", "incompatible-parameters", "incompatible-network", "storage-full":
+		return provider.StatusFailed
+	default:
+		return provider.StatusUpdating
+	}
+}
+
+// rdsIdentifier returns the identifier of the instance or cluster of a
+// database
+func rdsIdentifier(res *schema.RDS, opts *provider.ResourceOptions) string {
+	return strings.ToLower(fmt.Sprintf("%s-%s-%s", opts.StackName, opts.ServiceName, res.Metadata.Name))
+}
+
+// auroraInstanceIDs returns the instances of an Aurora cluster: a writer,
+// and a reader in another availability zone for Multi-AZ
+func auroraInstanceIDs(identifier string, multiAZ bool) []string {
+	ids := []string{identifier + "-1"}
+	if multiAZ {
+		ids = append(ids, identifier+"-2")
+	}
+	return ids
+}
+
+// rdsPort returns the port of a database, defaulting to the engine's port
+func rdsPort(spec *schema.RDSSpec) int {
+	if spec.Database.Port > 0 {
+		return spec.Database.Port
+	}
+	if strings.Contains(spec.Engine.Type, "postgres") {
+		return 5432
+	}
+	return 3306
+}
+
+// rdsBackupRetention returns the backup retention in days. Aurora always
+// keeps at least one day of backups.
+func rdsBackupRetention(spec *schema.RDSSpec, aurora bool) int {
+	if !spec.Backup.Enabled {
+		if aurora {
+			return 1
+		}
+		return 0
+	}
+	if spec.Backup.RetentionDays > 0 {
+		return spec.Backup.RetentionDays
+	}
+	return 7
+}
+
+// isAurora reports whether an engine runs as an Aurora cluster
+func isAurora(engine string) bool {
+	return strings.HasPrefix(engine, "aurora")
+}
+
+// rdsWaitTime returns how long to wait for a database, up to the deadline of
+// the operation
+func rdsWaitTime(ctx context.Context) time.Duration {
+	if deadline, ok := ctx.Deadline(); ok {
+		if remaining := time.Until(deadline); remaining > 0 {
+			return remaining
+		}
+	}
+	return rdsDefaultWaitTime
+}
+
+// rdsTags converts tags into RDS tags, sorted by key
+func rdsTags(tags map[string]string) []types.Tag {
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	rdsTags := make([]types.Tag, 0, len(keys))
+	for _, k := range keys {
+		rdsTags = append(rdsTags, types.Tag{Key: aws.String(k), Value: aws.String(tags[k])})
+	}
+	return rdsTags
+}
+
+// optionalString returns nil for an empty string
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return aws.String(s)
+}
+
+// isRDSNotFound reports whether an error says that an RDS resource does not
+// exist
+func isRDSNotFound(err error) bool {
+	var instanceNotFound *types.DBInstanceNotFoundFault
+	var clusterNotFound *types.DBClusterNotFoundFault
+	var subnetGroupNotFound *types.DBSubnetGroupNotFoundFault
+	var parameterGroupNotFound *types.DBParameterGroupNotFoundFault
+	var clusterParameterGroupNotFound *types.DBClusterParameterGroupNotFoundFault
+	return errors.As(err, &instanceNotFound) ||
+		errors.As(err, &clusterNotFound) ||
+		errors.As(err, &subnetGroupNotFound) ||
+		errors.As(err, &parameterGroupNotFound) ||
+		errors.As(err, &clusterParameterGroupNotFound)
+}
+
+// rdsError wraps an RDS error
+func rdsError(operation, resourceID, message string, err error) *provider.ProviderError {
+	return &provider.ProviderError{
+		Provider:   "aws",
+		Operation:  operation,
+		ResourceID: resourceID,
+		Cause:      err,
+		Message:    message,
+	}
+}
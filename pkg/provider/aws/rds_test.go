@@ -0,0 +1,519 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/rds"
+	"github.com/aws/aws-sdk-go-v2/service/rds/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourusername/panka/pkg/parser/schema"
+	"github.com/yourusername/panka/pkg/provider"
+)
+
+const testRDSPasswordRef = "arn:aws:secretsmanager:us-east-1:123456789012:secret:orders-db"
+
+const testDBInstance = `<DBInstance>
+  <DBInstanceIdentifier>%s</DBInstanceIdentifier>
+  <DBInstanceArn>arn:aws:rds:us-east-1:123456789012:db:%[1]s</DBInstanceArn>
+  <DBInstanceClass>%s</DBInstanceClass>
+  <Engine>%s</Engine>
+  <DBInstanceStatus>available</DBInstanceStatus>
+  <Endpoint><Address>%[1]s.abc.us-east-1.rds.amazonaws.com</Address><Port>5432</Port></Endpoint>
+</DBInstance>`
+
+const testDBCluster = `<DBCluster>
+  <DBClusterIdentifier>%s</DBClusterIdentifier>
+  <DBClusterArn>arn:aws:rds:us-east-1:123456789012:cluster:%[1]s</DBClusterArn>
+  <Engine>%s</Engine>
+  <Status>available</Status>
+  <Endpoint>%[1]s.cluster-abc.us-east-1.rds.amazonaws.com</Endpoint>
+  <ReaderEndpoint>%[1]s.cluster-ro-abc.us-east-1.rds.amazonaws.com</ReaderEndpoint>
+  <Port>5432</Port>
+  <DBClusterMembers>%[3]s</DBClusterMembers>
+</DBCluster>`
+
+// fakeRDS serves the RDS actions of the provider for DB instances and one
+// Aurora cluster, which are available as soon as they are created and gone
+// as soon as they are deleted, and the master password from Secrets Manager
+type fakeRDS struct {
+	mu        sync.Mutex
+	actions   []string
+	forms     map[string]url.Values
+	instances map[string]string
+	cluster   string
+	engine    string
+}
+
+func newFakeRDS() *fakeRDS {
+	return &fakeRDS{
+		forms:     make(map[string]url.Values),
+		instances: make(map[string]string),
+	}
+}
+
+func (f *fakeRDS) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if target := r.Header.Get("X-Amz-Target"); target != "" {
+		f.actions = append(f.actions, target)
+		writeJSON(w, map[string]interface{}{"SecretString": `{"username": "orders", "password": "s3cret"}`})
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	action := r.Form.Get("Action")
+	f.actions = append(f.actions, action)
+	f.forms[action] = r.Form
+
+	result := ""
+	switch action {
+	case "DescribeDBClusters":
+		if f.cluster == "" {
+			writeQueryError(w, "DBClusterNotFoundFault")
+			return
+		}
+		result = "<DBClusters>" + f.clusterXML() + "</DBClusters>"
+	case "CreateDBCluster":
+		f.cluster = r.Form.Get("DBClusterIdentifier")
+		f.engine = r.Form.Get("Engine")
+	case "ModifyDBCluster":
+		result = f.clusterXML()
+	case "DeleteDBCluster":
+		f.cluster = ""
+	case "DescribeDBInstances":
+		instance, ok := f.instances[r.Form.Get("DBInstanceIdentifier")]
+		if !ok {
+			writeQueryError(w, "DBInstanceNotFound")
+			return
+		}
+		result = "<DBInstances>" + instance + "</DBInstances>"
+	case "CreateDBInstance":
+		id := r.Form.Get("DBInstanceIdentifier")
+		engine := r.Form.Get("Engine")
+		f.instances[id] = fmt.Sprintf(testDBInstance, id, r.Form.Get("DBInstanceClass"), engine)
+		if f.cluster == "" {
+			f.engine = engine
+		}
+	case "ModifyDBInstance":
+		id := r.Form.Get("DBInstanceIdentifier")
+		f.instances[id] = fmt.Sprintf(testDBInstance, id, r.Form.Get("DBInstanceClass"), f.engine)
+	case "DeleteDBInstance":
+		delete(f.instances, r.Form.Get("DBInstanceIdentifier"))
+	case "DescribeDBEngineVersions":
+		result = "<DBEngineVersions><DBEngineVersion><DBParameterGroupFamily>postgres15</DBParameterGroupFamily></DBEngineVersion></DBEngineVersions>"
+	case "DescribeDBParameters", "DescribeDBClusterParameters":
+		result = "<Parameters>" +
+			"<Parameter><ParameterName>shared_buffers</ParameterName><ApplyType>static</ApplyType></Parameter>" +
+			"<Parameter><ParameterName>log_statement</ParameterName><ApplyType>dynamic</ApplyType></Parameter>" +
+			"</Parameters>"
+	}
+
+	fmt.Fprintf(w, "<%sResponse><%[1]sResult>%s</%[1]sResult></%[1]sResponse>", action, result)
+}
+
+// clusterXML returns the cluster with the instances created in it
+func (f *fakeRDS) clusterXML() string {
+	var ids []string
+	for id := range f.instances {
+		if strings.HasPrefix(id, f.cluster+"-") {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+
+	var members strings.Builder
+	for _, id := range ids {
+		fmt.Fprintf(&members, "<DBClusterMember><DBInstanceIdentifier>%s</DBInstanceIdentifier></DBClusterMember>", id)
+	}
+	return fmt.Sprintf(testDBCluster, f.cluster, f.engine, members.String())
+}
+
+// calls returns the actions called so far, leaving out describes and the
+// password lookup
+func (f *fakeRDS) calls() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var calls []string
+	for _, action := range f.actions {
+		if !strings.HasPrefix(action, "Describe") && !strings.HasPrefix(action, "secretsmanager.") {
+			calls = append(calls, action)
+		}
+	}
+	return calls
+}
+
+func testRDS(engine string) *schema.RDS {
+	db := schema.NewRDS("orders", "backend", "my-stack")
+	db.Spec.Engine = schema.EngineConfig{Type: engine, Version: "15.4"}
+	db.Spec.Instance = schema.InstanceConfig{
+		Class:   "db.t4g.medium",
+		Storage: schema.StorageSpec{Type: "gp3", AllocatedGB: 20},
+	}
+	db.Spec.Database = schema.DatabaseConfig{
+		Name:           "orders",
+		Username:       "orders",
+		PasswordSecret: schema.SecretRef{Ref: testRDSPasswordRef},
+	}
+	return db
+}
+
+func TestRDSIdentifier(t *testing.T) {
+	db := schema.NewRDS("Orders-DB", "backend", "my-stack")
+
+	id := rdsIdentifier(db, &provider.ResourceOptions{StackName: "My-Stack", ServiceName: "backend"})
+
+	assert.Equal(t, "my-stack-backend-orders-db", id)
+	assert.Equal(t, []string{id + "-1"}, auroraInstanceIDs(id, false))
+	assert.Equal(t, []string{id + "-1", id + "-2"}, auroraInstanceIDs(id, true))
+}
+
+func TestRDSPortAndBackupRetention(t *testing.T) {
+	db := schema.NewRDS("db", "backend", "my-stack")
+
+	db.Spec.Engine.Type = "postgres"
+	assert.Equal(t, 5432, rdsPort(&db.Spec))
+	db.Spec.Engine.Type = "aurora-mysql"
+	assert.Equal(t, 3306, rdsPort(&db.Spec))
+	db.Spec.Database.Port = 6000
+	assert.Equal(t, 6000, rdsPort(&db.Spec))
+
+	assert.Equal(t, 7, rdsBackupRetention(&db.Spec, false))
+	db.Spec.Backup.RetentionDays = 14
+	assert.Equal(t, 14, rdsBackupRetention(&db.Spec, false))
+	db.Spec.Backup.Enabled = false
+	assert.Equal(t, 0, rdsBackupRetention(&db.Spec, false))
+	assert.Equal(t, 1, rdsBackupRetention(&db.Spec, true))
+}
+
+func TestApplyStorage(t *testing.T) {
+	input := &rds.CreateDBInstanceInput{}
+	applyStorage(input, &schema.InstanceConfig{
+		Storage: schema.StorageSpec{Type: "io1", AllocatedGB: 100, MaxAllocatedGB: 500},
+		IOPS:    3000,
+	})
+
+	assert.Equal(t, int32(100), *input.AllocatedStorage)
+	assert.Equal(t, int32(500), *input.MaxAllocatedStorage)
+	assert.Equal(t, "io1", *input.StorageType)
+	assert.Equal(t, int32(3000), *input.Iops)
+
+	// Autoscaling is off unless the maximum is above the allocated storage
+	input = &rds.CreateDBInstanceInput{}
+	applyStorage(input, &schema.InstanceConfig{
+		Storage: schema.StorageSpec{AllocatedGB: 100, MaxAllocatedGB: 100},
+	})
+	assert.Nil(t, input.MaxAllocatedStorage)
+	assert.Nil(t, input.StorageType)
+	assert.Nil(t, input.Iops)
+}
+
+func TestRDSParameters(t *testing.T) {
+	params := rdsParameters(map[string]string{
+		"shared_buffers":  "256MB",
+		"log_statement":   "all",
+		"max_connections": "200",
+	}, map[string]string{
+		"shared_buffers":  "static",
+		"log_statement":   "dynamic",
+		"max_connections": "static",
+	})
+
+	require.Len(t, params, 1)
+	require.Len(t, params[0], 3)
+	assert.Equal(t, "log_statement", *params[0][0].ParameterName)
+	assert.Equal(t, types.ApplyMethodImmediate, params[0][0].ApplyMethod)
+	assert.Equal(t, "max_connections", *params[0][1].ParameterName)
+	assert.Equal(t, types.ApplyMethodPendingReboot, params[0][1].ApplyMethod)
+	assert.Equal(t, "256MB", *params[0][2].ParameterValue)
+
+	many := make(map[string]string)
+	for i := 0; i < 45; i++ {
+		many[fmt.Sprintf("param_%02d", i)] = "1"
+	}
+	batches := rdsParameters(many, nil)
+	require.Len(t, batches, 3)
+	assert.Len(t, batches[0], 20)
+	assert.Len(t, batches[1], 20)
+	assert.Len(t, batches[2], 5)
+
+	assert.Empty(t, rdsParameters(nil, nil))
+}
+
+func TestSecretPassword(t *testing.T) {
+	password, err := secretPassword("s3cret")
+	require.NoError(t, err)
+	assert.Equal(t, "s3cret", password)
+
+	password, err = secretPassword(`{"username": "admin", "password": "from-json"}`)
+	require.NoError(t, err)
+	assert.Equal(t, "from-json", password)
+
+	_, err = secretPassword(`{"username": "admin"}`)
+	assert.Error(t, err)
+
+	_, err = secretPassword("")
+	assert.Error(t, err)
+}
+
+func TestRDSOutputs(t *testing.T) {
+	outputs := instanceOutputs(&types.DBInstance{
+		DBInstanceIdentifier: aws.String("my-stack-backend-db"),
+		DBInstanceArn:        aws.String("arn:aws:rds:us-east-1:123456789012:db:my-stack-backend-db"),
+		Engine:               aws.String("postgres"),
+		DBInstanceStatus:     aws.String("available"),
+		Endpoint: &types.Endpoint{
+			Address: aws.String("my-stack-backend-db.abc.us-east-1.rds.amazonaws.com"),
+			Port:    aws.Int32(5432),
+		},
+	})
+	assert.Equal(t, "my-stack-backend-db.abc.us-east-1.rds.amazonaws.com", outputs["endpoint"])
+	assert.Equal(t, "5432", outputs["port"])
+	assert.Equal(t, "arn:aws:rds:us-east-1:123456789012:db:my-stack-backend-db", outputs["arn"])
+
+	outputs = clusterOutputs(&types.DBCluster{
+		DBClusterIdentifier: aws.String("my-stack-backend-db"),
+		Endpoint:            aws.String("writer.example"),
+		ReaderEndpoint:      aws.String("reader.example"),
+		Port:                aws.Int32(3306),
+	})
+	assert.Equal(t, "writer.example", outputs["endpoint"])
+	assert.Equal(t, "reader.example", outputs["reader_endpoint"])
+	assert.Equal(t, "3306", outputs["port"])
+
+	assert.Equal(t, provider.StatusAvailable, rdsStatus("available"))
+	assert.Equal(t, provider.StatusUpdating, rdsStatus("modifying"))
+	assert.Equal(t, provider.StatusFailed, rdsStatus("storage-full"))
+}
+
+func TestDBInstanceInput(t *testing.T) {
+	tests := []struct {
+		name           string
+		engine         string
+		multiAZ        bool
+		backups        bool
+		parameterGroup string
+		port           int32
+		retention      int32
+	}{
+		{name: "postgres", engine: "postgres", backups: true, port: 5432, retention: 7},
+		{name: "multi-AZ mysql without backups", engine: "mysql", multiAZ: true, port: 3306},
+		{name: "parameter group", engine: "postgres", backups: true, parameterGroup: "orders-params", port: 5432, retention: 7},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := testRDS(tt.engine)
+			db.Spec.Instance.MultiAZ = tt.multiAZ
+			db.Spec.Backup.Enabled = tt.backups
+			db.Spec.Backup.PreferredWindow = "03:00-04:00"
+
+			input := dbInstanceInput("my-stack-backend-orders", &db.Spec, "s3cret", tt.parameterGroup,
+				[]string{"sg-123"}, rdsTags(map[string]string{"panka:stack": "my-stack"}))
+
+			assert.Equal(t, "my-stack-backend-orders", aws.ToString(input.DBInstanceIdentifier))
+			assert.Equal(t, "db.t4g.medium", aws.ToString(input.DBInstanceClass))
+			assert.Equal(t, tt.engine, aws.ToString(input.Engine))
+			assert.Equal(t, "s3cret", aws.ToString(input.MasterUserPassword))
+			assert.Equal(t, "my-stack-backend-orders", aws.ToString(input.DBSubnetGroupName))
+			assert.Equal(t, []string{"sg-123"}, input.VpcSecurityGroupIds)
+			assert.Equal(t, tt.port, aws.ToInt32(input.Port))
+			assert.Equal(t, tt.multiAZ, aws.ToBool(input.MultiAZ))
+			assert.Equal(t, tt.retention, aws.ToInt32(input.BackupRetentionPeriod))
+			assert.Equal(t, optionalString(tt.parameterGroup), input.DBParameterGroupName)
+			assert.Equal(t, "03:00-04:00", aws.ToString(input.PreferredBackupWindow))
+			assert.Nil(t, input.PreferredMaintenanceWindow)
+			assert.Equal(t, int32(20), aws.ToInt32(input.AllocatedStorage))
+			assert.False(t, aws.ToBool(input.PubliclyAccessible))
+			assert.True(t, aws.ToBool(input.StorageEncrypted))
+			assert.Equal(t, "panka:stack", aws.ToString(input.Tags[0].Key))
+		})
+	}
+}
+
+func TestDBClusterInput(t *testing.T) {
+	tests := []struct {
+		name           string
+		engine         string
+		backups        bool
+		parameterGroup string
+		port           int32
+		retention      int32
+	}{
+		{name: "aurora postgres", engine: "aurora-postgresql", backups: true, port: 5432, retention: 7},
+		{name: "aurora mysql without backups", engine: "aurora-mysql", port: 3306, retention: 1},
+		{name: "parameter group", engine: "aurora-postgresql", backups: true, parameterGroup: "orders-params", port: 5432, retention: 7},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := testRDS(tt.engine)
+			db.Spec.Backup.Enabled = tt.backups
+
+			input := dbClusterInput("my-stack-backend-orders", &db.Spec, "s3cret", tt.parameterGroup, []string{"sg-123"}, nil)
+
+			assert.Equal(t, "my-stack-backend-orders", aws.ToString(input.DBClusterIdentifier))
+			assert.Equal(t, tt.engine, aws.ToString(input.Engine))
+			assert.Equal(t, "orders", aws.ToString(input.DatabaseName))
+			assert.Equal(t, "s3cret", aws.ToString(input.MasterUserPassword))
+			assert.Equal(t, "my-stack-backend-orders", aws.ToString(input.DBSubnetGroupName))
+			assert.Equal(t, []string{"sg-123"}, input.VpcSecurityGroupIds)
+			assert.Equal(t, tt.port, aws.ToInt32(input.Port))
+			assert.Equal(t, tt.retention, aws.ToInt32(input.BackupRetentionPeriod))
+			assert.Equal(t, optionalString(tt.parameterGroup), input.DBClusterParameterGroupName)
+			assert.True(t, aws.ToBool(input.StorageEncrypted))
+		})
+	}
+}
+
+func TestModifyDBInstanceInput(t *testing.T) {
+	tests := []struct {
+		name                string
+		storage             schema.StorageSpec
+		iops                int
+		parameterGroup      string
+		allocatedStorage    *int32
+		maxAllocatedStorage *int32
+		storageType         *string
+		iopsValue           *int32
+	}{
+		{name: "class only"},
+		{
+			name:                "storage autoscaling",
+			storage:             schema.StorageSpec{Type: "gp3", AllocatedGB: 50, MaxAllocatedGB: 200},
+			allocatedStorage:    aws.Int32(50),
+			maxAllocatedStorage: aws.Int32(200),
+			storageType:         aws.String("gp3"),
+		},
+		{
+			name:             "provisioned IOPS",
+			storage:          schema.StorageSpec{Type: "io1", AllocatedGB: 100, MaxAllocatedGB: 100},
+			iops:             3000,
+			allocatedStorage: aws.Int32(100),
+			storageType:      aws.String("io1"),
+			iopsValue:        aws.Int32(3000),
+		},
+		{name: "parameter group", parameterGroup: "orders-params"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := testRDS("postgres")
+			db.Spec.Instance.Class = "db.r6g.large"
+			db.Spec.Instance.Storage = tt.storage
+			db.Spec.Instance.IOPS = tt.iops
+
+			input := modifyDBInstanceInput("my-stack-backend-orders", &db.Spec, tt.parameterGroup)
+
+			assert.Equal(t, "my-stack-backend-orders", aws.ToString(input.DBInstanceIdentifier))
+			assert.Equal(t, "db.r6g.large", aws.ToString(input.DBInstanceClass))
+			assert.Equal(t, tt.allocatedStorage, input.AllocatedStorage)
+			assert.Equal(t, tt.maxAllocatedStorage, input.MaxAllocatedStorage)
+			assert.Equal(t, tt.storageType, input.StorageType)
+			assert.Equal(t, tt.iopsValue, input.Iops)
+			assert.Equal(t, optionalString(tt.parameterGroup), input.DBParameterGroupName)
+			assert.Equal(t, int32(7), aws.ToInt32(input.BackupRetentionPeriod))
+			assert.True(t, aws.ToBool(input.ApplyImmediately))
+		})
+	}
+}
+
+func TestRDSProvider_InstanceLifecycle(t *testing.T) {
+	fake := newFakeRDS()
+	rp := NewRDSProvider(testServerProvider(t, fake.ServeHTTP))
+	opts := &provider.ResourceOptions{StackName: "my-stack", ServiceName: "backend"}
+
+	db := testRDS("postgres")
+	db.Spec.Engine.Parameters = map[string]string{"shared_buffers": "256MB", "log_statement": "all"}
+
+	result, err := rp.Create(context.Background(), db, opts)
+	require.NoError(t, err)
+	assert.Equal(t, "my-stack-backend-orders", result.ResourceID)
+	assert.Equal(t, provider.StatusAvailable, result.Status)
+	assert.Equal(t, "my-stack-backend-orders.abc.us-east-1.rds.amazonaws.com", result.Outputs["endpoint"])
+	assert.Equal(t, "5432", result.Outputs["port"])
+	assert.Equal(t, []string{
+		"CreateDBSubnetGroup", "CreateDBParameterGroup", "ModifyDBParameterGroup", "CreateDBInstance",
+	}, fake.calls())
+
+	subnetGroup := fake.forms["CreateDBSubnetGroup"]
+	assert.Equal(t, "subnet-a", subnetGroup.Get("SubnetIds.SubnetIdentifier.1"))
+	assert.Equal(t, "subnet-b", subnetGroup.Get("SubnetIds.SubnetIdentifier.2"))
+	assert.Equal(t, "postgres15", fake.forms["CreateDBParameterGroup"].Get("DBParameterGroupFamily"))
+	parameters := fake.forms["ModifyDBParameterGroup"]
+	assert.Equal(t, "log_statement", parameters.Get("Parameters.Parameter.1.ParameterName"))
+	assert.Equal(t, "immediate", parameters.Get("Parameters.Parameter.1.ApplyMethod"))
+	assert.Equal(t, "shared_buffers", parameters.Get("Parameters.Parameter.2.ParameterName"))
+	assert.Equal(t, "pending-reboot", parameters.Get("Parameters.Parameter.2.ApplyMethod"))
+	create := fake.forms["CreateDBInstance"]
+	assert.Equal(t, "s3cret", create.Get("MasterUserPassword"))
+	assert.Equal(t, "sg-123", create.Get("VpcSecurityGroupIds.VpcSecurityGroupId.1"))
+	assert.Equal(t, "my-stack-backend-orders", create.Get("DBParameterGroupName"))
+
+	// Creating it again updates the existing instance
+	db.Spec.Instance.Class = "db.r6g.large"
+	_, err = rp.Create(context.Background(), db, opts)
+	require.NoError(t, err)
+	calls := fake.calls()
+	assert.Equal(t, []string{"CreateDBParameterGroup", "ModifyDBParameterGroup", "ModifyDBInstance"}, calls[4:])
+	assert.Equal(t, "db.r6g.large", fake.forms["ModifyDBInstance"].Get("DBInstanceClass"))
+	assert.Equal(t, "true", fake.forms["ModifyDBInstance"].Get("ApplyImmediately"))
+
+	deleted, err := rp.Delete(context.Background(), result.ResourceID, opts)
+	require.NoError(t, err)
+	assert.Equal(t, provider.StatusDeleted, deleted.Status)
+	calls = fake.calls()
+	assert.Equal(t, []string{"DeleteDBInstance", "DeleteDBSubnetGroup", "DeleteDBParameterGroup"}, calls[len(calls)-3:])
+	assert.Equal(t, deleted.Outputs["final_snapshot"], fake.forms["DeleteDBInstance"].Get("FinalDBSnapshotIdentifier"))
+
+	exists, err := rp.Exists(context.Background(), result.ResourceID, opts)
+	require.NoError(t, err)
+	assert.False(t, exists)
+}
+
+func TestRDSProvider_AuroraLifecycle(t *testing.T) {
+	fake := newFakeRDS()
+	rp := NewRDSProvider(testServerProvider(t, fake.ServeHTTP))
+	opts := &provider.ResourceOptions{StackName: "my-stack", ServiceName: "backend"}
+
+	db := testRDS("aurora-postgresql")
+	db.Spec.Instance.MultiAZ = true
+
+	result, err := rp.Create(context.Background(), db, opts)
+	require.NoError(t, err)
+	assert.Equal(t, "my-stack-backend-orders", result.ResourceID)
+	assert.Equal(t, provider.StatusAvailable, result.Status)
+	assert.Equal(t, "my-stack-backend-orders.cluster-ro-abc.us-east-1.rds.amazonaws.com", result.Outputs["reader_endpoint"])
+	assert.Equal(t, []string{
+		"CreateDBSubnetGroup", "CreateDBCluster", "CreateDBInstance", "CreateDBInstance",
+	}, fake.calls())
+	assert.Equal(t, "my-stack-backend-orders-2", fake.forms["CreateDBInstance"].Get("DBInstanceIdentifier"))
+	assert.Equal(t, "my-stack-backend-orders", fake.forms["CreateDBInstance"].Get("DBClusterIdentifier"))
+
+	db.Spec.Instance.Class = "db.r6g.large"
+	_, err = rp.Update(context.Background(), db, opts)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ModifyDBCluster", "ModifyDBInstance", "ModifyDBInstance"}, fake.calls()[4:])
+	assert.Equal(t, "db.r6g.large", fake.forms["ModifyDBInstance"].Get("DBInstanceClass"))
+
+	deleted, err := rp.Delete(context.Background(), result.ResourceID, opts)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"DeleteDBInstance", "DeleteDBInstance", "DeleteDBCluster", "DeleteDBSubnetGroup", "DeleteDBClusterParameterGroup",
+	}, fake.calls()[7:])
+	assert.Equal(t, "true", fake.forms["DeleteDBInstance"].Get("SkipFinalSnapshot"))
+	assert.Equal(t, deleted.Outputs["final_snapshot"], fake.forms["DeleteDBCluster"].Get("FinalDBSnapshotIdentifier"))
+}
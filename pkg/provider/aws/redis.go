@@ -0,0 +1,556 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/elasticache"
+	"github.com/aws/aws-sdk-go-v2/service/elasticache/types"
+	"github.com/yourusername/panka/pkg/parser/schema"
+	"github.com/yourusername/panka/pkg/provider"
+	"github.com/yourusername/panka/pkg/secrets"
+	"go.uber.org/zap"
+)
+
+// ElastiCacheRedisProvider implements ElastiCache for Redis management. A
+// cache is a replication group in the tenant's private subnets; more than
+// one shard creates it in cluster mode. Resource IDs are the replication
+// group ID.
+type ElastiCacheRedisProvider struct {
+	provider *Provider
+	client   *elastiCacheClient
+	secrets  *secrets.Manager
+}
+
+// NewElastiCacheRedisProvider creates a new Redis provider
+func NewElastiCacheRedisProvider(p *Provider) *ElastiCacheRedisProvider {
+	return &ElastiCacheRedisProvider{
+		provider: p,
+		client:   newElastiCacheClient(p),
+		secrets:  secrets.NewManager(p.GetConfig()),
+	}
+}
+
+// Create creates a Redis replication group with its subnet group and waits
+// until it is available
+func (rp *ElastiCacheRedisProvider) Create(ctx context.Context, resource schema.Resource, opts *provider.ResourceOptions) (*provider.ResourceResult, error) {
+	redis, ok := resource.(*schema.ElastiCacheRedis)
+	if !ok {
+		return nil, &provider.ProviderError{
+			Provider:  "aws",
+			Operation: "create",
+			Message:   "invalid resource type for ElastiCache Redis provider",
+		}
+	}
+
+	id := redisReplicationGroupID(redis, opts)
+
+	rp.provider.GetLogger().Info("Creating Redis cache",
+		zap.String("replication_group", id),
+		zap.String("node_type", redis.Spec.NodeType),
+		zap.Int("shards", redis.ShardCount()),
+	)
+
+	if opts.DryRun {
+		return &provider.ResourceResult{
+			ResourceID: id,
+			Kind:       schema.KindElastiCacheRedis,
+			Status:     provider.StatusPending,
+			Outputs: map[string]string{
+				"replication_group_id": id,
+				"port":                 strconv.Itoa(redis.CachePort()),
+			},
+			Timestamp: time.Now(),
+		}, nil
+	}
+
+	// An interrupted create may have left the replication group behind
+	exists, err := rp.Exists(ctx, id, opts)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		rp.provider.GetLogger().Info("Redis cache already exists, updating it",
+			zap.String("replication_group", id),
+		)
+		return rp.Update(ctx, resource, opts)
+	}
+
+	networking := rp.provider.GetNetworking()
+	if networking == nil || len(networking.PrivateSubnetIDs) == 0 {
+		return nil, cacheError("create", id, "cannot place Redis cache",
+			errors.New("tenant networking has no private subnets, set up the tenant networking first"))
+	}
+
+	authToken, err := rp.authToken(ctx, redis)
+	if err != nil {
+		return nil, cacheError("create", id, "failed to read auth token", err)
+	}
+
+	tags := elastiCacheTags(rp.provider.tagHelper.BuildTags(opts, resource))
+
+	if err := rp.client.ensureSubnetGroup(ctx, id, networking.PrivateSubnetIDs, tags); err != nil {
+		return nil, cacheError("create", id, "failed to create cache subnet group", err)
+	}
+
+	var securityGroups []string
+	if networking.SecurityGroupID != "" {
+		securityGroups = []string{networking.SecurityGroupID}
+	}
+
+	input := replicationGroupInput(redis, id, authToken, securityGroups, tags)
+	if _, err := rp.client.client.CreateReplicationGroup(ctx, input); err != nil {
+		rp.provider.GetLogger().Error("Failed to create Redis cache",
+			zap.String("replication_group", id),
+			zap.Error(err),
+		)
+		return nil, cacheError("create", id, "failed to create replication group", err)
+	}
+
+	if err := rp.client.waitReplicationGroup(ctx, id, false); err != nil {
+		return nil, cacheError("create", id, "replication group did not become available", err)
+	}
+
+	rp.provider.GetLogger().Info("Redis cache created", zap.String("replication_group", id))
+
+	return rp.Read(ctx, id, opts)
+}
+
+// PlannedID returns the ID of the replication group of a resource
+func (rp *ElastiCacheRedisProvider) PlannedID(resource schema.Resource, opts *provider.ResourceOptions) (string, error) {
+	redis, ok := resource.(*schema.ElastiCacheRedis)
+	if !ok {
+		return "", &provider.ProviderError{
+			Provider:  "aws",
+			Operation: "plan",
+			Message:   "invalid resource type for ElastiCache Redis provider",
+		}
+	}
+	return redisReplicationGroupID(redis, opts), nil
+}
+
+// Read reads the current state of a replication group
+func (rp *ElastiCacheRedisProvider) Read(ctx context.Context, resourceID string, opts *provider.ResourceOptions) (*provider.ResourceResult, error) {
+	group, err := rp.client.describeReplicationGroup(ctx, resourceID)
+	if err != nil {
+		return nil, cacheError("read", resourceID, "failed to describe replication group", err)
+	}
+	if group == nil {
+		return nil, &provider.ProviderError{
+			Provider:   "aws",
+			Operation:  "read",
+			ResourceID: resourceID,
+			Cause:      provider.ErrResourceNotFound,
+			Message:    "Redis replication group not found",
+		}
+	}
+
+	return &provider.ResourceResult{
+		ResourceID: resourceID,
+		Kind:       schema.KindElastiCacheRedis,
+		Status:     cacheStatus(aws.ToString(group.Status)),
+		Outputs:    replicationGroupOutputs(group),
+		Timestamp:  time.Now(),
+	}, nil
+}
+
+// Update applies node type, engine version, auth, replica and shard changes
+// immediately, one at a time, waiting until the replication group is
+// available after each
+func (rp *ElastiCacheRedisProvider) Update(ctx context.Context, resource schema.Resource, opts *provider.ResourceOptions) (*provider.ResourceResult, error) {
+	redis, ok := resource.(*schema.ElastiCacheRedis)
+	if !ok {
+		return nil, &provider.ProviderError{
+			Provider:  "aws",
+			Operation: "update",
+			Message:   "invalid resource type for ElastiCache Redis provider",
+		}
+	}
+
+	id := redisReplicationGroupID(redis, opts)
+
+	rp.provider.GetLogger().Info("Updating Redis cache", zap.String("replication_group", id))
+
+	group, err := rp.client.describeReplicationGroup(ctx, id)
+	if err != nil {
+		return nil, cacheError("update", id, "failed to describe replication group", err)
+	}
+	if group == nil {
+		return nil, &provider.ProviderError{
+			Provider:   "aws",
+			Operation:  "update",
+			ResourceID: id,
+			Cause:      provider.ErrResourceNotFound,
+			Message:    "Redis replication group not found",
+		}
+	}
+	if aws.ToBool(group.ClusterEnabled) != redis.ClusterMode() {
+		return nil, cacheError("update", id, "cannot change the shards of the cache",
+			errors.New("a cache cannot switch between one shard and cluster mode, replace it with a new cache"))
+	}
+
+	if err := rp.modify(ctx, redis, group); err != nil {
+		return nil, cacheError("update", id, "failed to modify replication group", err)
+	}
+	if err := rp.updateReplicas(ctx, redis, group); err != nil {
+		return nil, cacheError("update", id, "failed to change replica count", err)
+	}
+	if err := rp.updateShards(ctx, redis, group); err != nil {
+		return nil, cacheError("update", id, "failed to change shard count", err)
+	}
+
+	rp.provider.GetLogger().Info("Redis cache updated", zap.String("replication_group", id))
+
+	return rp.Read(ctx, id, opts)
+}
+
+// modify changes the node type, engine version and auth token of a
+// replication group
+func (rp *ElastiCacheRedisProvider) modify(ctx context.Context, redis *schema.ElastiCacheRedis, group *types.ReplicationGroup) error {
+	id := aws.ToString(group.ReplicationGroupId)
+	input := &elasticache.ModifyReplicationGroupInput{}
+	changed := false
+
+	if aws.ToString(group.CacheNodeType) != redis.Spec.NodeType {
+		input.CacheNodeType = aws.String(redis.Spec.NodeType)
+		changed = true
+	}
+
+	if redis.Spec.EngineVersion != "" && len(group.MemberClusters) > 0 {
+		member, err := rp.client.describeCacheCluster(ctx, group.MemberClusters[0])
+		if err != nil {
+			return fmt.Errorf("failed to describe cache cluster %s: %w", group.MemberClusters[0], err)
+		}
+		if member != nil && !engineVersionMatches(aws.ToString(member.EngineVersion), redis.Spec.EngineVersion) {
+			input.EngineVersion = aws.String(redis.Spec.EngineVersion)
+			changed = true
+		}
+	}
+
+	authTokenEnabled := aws.ToBool(group.AuthTokenEnabled)
+	switch {
+	case redis.AuthEnabled() && !authTokenEnabled:
+		authToken, err := rp.authToken(ctx, redis)
+		if err != nil {
+			return fmt.Errorf("failed to read auth token: %w", err)
+		}
+		input.AuthToken = aws.String(authToken)
+		input.AuthTokenUpdateStrategy = types.AuthTokenUpdateStrategyTypeRotate
+		changed = true
+	case !redis.AuthEnabled() && authTokenEnabled:
+		input.AuthTokenUpdateStrategy = types.AuthTokenUpdateStrategyTypeDelete
+		changed = true
+	}
+
+	if !changed {
+		return nil
+	}
+	input.ReplicationGroupId = aws.String(id)
+	input.ApplyImmediately = aws.Bool(true)
+
+	if _, err := rp.client.client.ModifyReplicationGroup(ctx, input); err != nil {
+		return err
+	}
+	return rp.client.waitReplicationGroup(ctx, id, false)
+}
+
+// updateReplicas changes the number of replicas of each shard. Automatic
+// failover is turned on once there are replicas, and off before the last
+// replica of a single shard is removed.
+func (rp *ElastiCacheRedisProvider) updateReplicas(ctx context.Context, redis *schema.ElastiCacheRedis, group *types.ReplicationGroup) error {
+	id := aws.ToString(group.ReplicationGroupId)
+	current := replicasPerShard(group)
+	desired := redis.Spec.ReplicasPerShard
+	if current == desired {
+		return nil
+	}
+
+	if desired > current {
+		if _, err := rp.client.client.IncreaseReplicaCount(ctx, &elasticache.IncreaseReplicaCountInput{
+			ReplicationGroupId: aws.String(id),
+			NewReplicaCount:    aws.Int32(int32(desired)),
+			ApplyImmediately:   aws.Bool(true),
+		}); err != nil {
+			return err
+		}
+		if err := rp.client.waitReplicationGroup(ctx, id, false); err != nil {
+			return err
+		}
+		if current == 0 && !redis.ClusterMode() {
+			return rp.setFailover(ctx, id, true)
+		}
+		return nil
+	}
+
+	if desired == 0 && !redis.ClusterMode() {
+		if err := rp.setFailover(ctx, id, false); err != nil {
+			return err
+		}
+	}
+	if _, err := rp.client.client.DecreaseReplicaCount(ctx, &elasticache.DecreaseReplicaCountInput{
+		ReplicationGroupId: aws.String(id),
+		NewReplicaCount:    aws.Int32(int32(desired)),
+		ApplyImmediately:   aws.Bool(true),
+	}); err != nil {
+		return err
+	}
+	return rp.client.waitReplicationGroup(ctx, id, false)
+}
+
+// setFailover turns automatic failover and Multi-AZ on or off
+func (rp *ElastiCacheRedisProvider) setFailover(ctx context.Context, id string, enabled bool) error {
+	if _, err := rp.client.client.ModifyReplicationGroup(ctx, &elasticache.ModifyReplicationGroupInput{
+		ReplicationGroupId:       aws.String(id),
+		AutomaticFailoverEnabled: aws.Bool(enabled),
+		MultiAZEnabled:           aws.Bool(enabled),
+		ApplyImmediately:         aws.Bool(true),
+	}); err != nil {
+		return err
+	}
+	return rp.client.waitReplicationGroup(ctx, id, false)
+}
+
+// updateShards changes the number of shards of a cluster mode replication
+// group. Removed shards have their slots moved to the remaining ones.
+func (rp *ElastiCacheRedisProvider) updateShards(ctx context.Context, redis *schema.ElastiCacheRedis, group *types.ReplicationGroup) error {
+	if !aws.ToBool(group.ClusterEnabled) || len(group.NodeGroups) == redis.ShardCount() {
+		return nil
+	}
+
+	input := shardConfigurationInput(group, redis.ShardCount())
+	if _, err := rp.client.client.ModifyReplicationGroupShardConfiguration(ctx, input); err != nil {
+		return err
+	}
+	return rp.client.waitReplicationGroup(ctx, aws.ToString(group.ReplicationGroupId), false)
+}
+
+// Delete deletes a replication group and then its subnet group
+func (rp *ElastiCacheRedisProvider) Delete(ctx context.Context, resourceID string, opts *provider.ResourceOptions) (*provider.ResourceResult, error) {
+	rp.provider.GetLogger().Info("Deleting Redis cache", zap.String("replication_group", resourceID))
+
+	_, err := rp.client.client.DeleteReplicationGroup(ctx, &elasticache.DeleteReplicationGroupInput{
+		ReplicationGroupId: aws.String(resourceID),
+	})
+	var notFound *types.ReplicationGroupNotFoundFault
+	if err != nil && !errors.As(err, &notFound) {
+		rp.provider.GetLogger().Error("Failed to delete Redis cache",
+			zap.String("replication_group", resourceID),
+			zap.Error(err),
+		)
+		return nil, cacheError("delete", resourceID, "failed to delete replication group", err)
+	}
+
+	if err := rp.client.waitReplicationGroup(ctx, resourceID, true); err != nil {
+		return nil, cacheError("delete", resourceID, "replication group was not deleted", err)
+	}
+
+	// The subnet group is named after the replication group
+	if err := rp.client.deleteSubnetGroup(ctx, resourceID); err != nil {
+		return nil, cacheError("delete", resourceID, "failed to delete cache subnet group", err)
+	}
+
+	rp.provider.GetLogger().Info("Redis cache deleted", zap.String("replication_group", resourceID))
+
+	return &provider.ResourceResult{
+		ResourceID: resourceID,
+		Kind:       schema.KindElastiCacheRedis,
+		Status:     provider.StatusDeleted,
+		Timestamp:  time.Now(),
+	}, nil
+}
+
+// Exists checks if a replication group exists
+func (rp *ElastiCacheRedisProvider) Exists(ctx context.Context, resourceID string, opts *provider.ResourceOptions) (bool, error) {
+	group, err := rp.client.describeReplicationGroup(ctx, resourceID)
+	if err != nil {
+		return false, cacheError("exists", resourceID, "failed to describe replication group", err)
+	}
+	return group != nil && aws.ToString(group.Status) != "deleting", nil
+}
+
+// GetOutputs returns the outputs of a replication group
+func (rp *ElastiCacheRedisProvider) GetOutputs(ctx context.Context, resourceID string, opts *provider.ResourceOptions) (map[string]string, error) {
+	result, err := rp.Read(ctx, resourceID, opts)
+	if err != nil {
+		return nil, err
+	}
+	return result.Outputs, nil
+}
+
+// authToken reads the AUTH token of a cache from its secret, or returns an
+// empty string when the cache has no auth token
+func (rp *ElastiCacheRedisProvider) authToken(ctx context.Context, redis *schema.ElastiCacheRedis) (string, error) {
+	if !redis.AuthEnabled() {
+		return "", nil
+	}
+
+	secretID := redis.Spec.AuthToken.Ref
+	value, err := rp.secrets.Value(ctx, secretID)
+	if err != nil {
+		return "", err
+	}
+
+	return secretPassword(value)
+}
+
+// replicationGroupInput returns the input that creates the replication
+// group of a cache
+func replicationGroupInput(redis *schema.ElastiCacheRedis, id, authToken string, securityGroups []string, tags []types.Tag) *elasticache.CreateReplicationGroupInput {
+	spec := &redis.Spec
+	replicas := int32(spec.ReplicasPerShard)
+
+	input := &elasticache.CreateReplicationGroupInput{
+		ReplicationGroupId:          aws.String(id),
+		ReplicationGroupDescription: aws.String("Redis cache " + redis.Metadata.Name),
+		Engine:                      aws.String("redis"),
+		EngineVersion:               optionalString(spec.EngineVersion),
+		CacheNodeType:               aws.String(spec.NodeType),
+		CacheSubnetGroupName:        aws.String(id),
+		Port:                        aws.Int32(int32(redis.CachePort())),
+		AtRestEncryptionEnabled:     aws.Bool(spec.Encryption.AtRest),
+		TransitEncryptionEnabled:    aws.Bool(spec.Encryption.InTransit),
+		AuthToken:                   optionalString(authToken),
+		SecurityGroupIds:            securityGroups,
+		Tags:                        tags,
+	}
+
+	if redis.ClusterMode() {
+		input.NumNodeGroups = aws.Int32(int32(redis.ShardCount()))
+		input.ReplicasPerNodeGroup = aws.Int32(replicas)
+		input.CacheParameterGroupName = aws.String(redisClusterParameterGroup(spec.EngineVersion))
+	} else {
+		input.NumCacheClusters = aws.Int32(1 + replicas)
+	}
+
+	// Cluster mode always fails over; a single shard needs a replica to
+	// fail over to
+	input.AutomaticFailoverEnabled = aws.Bool(redis.ClusterMode() || replicas > 0)
+	input.MultiAZEnabled = aws.Bool(replicas > 0)
+
+	return input
+}
+
+// shardConfigurationInput returns the input that changes the number of
+// shards of a replication group. The shards with the highest IDs are the
+// ones removed.
+func shardConfigurationInput(group *types.ReplicationGroup, shards int) *elasticache.ModifyReplicationGroupShardConfigurationInput {
+	input := &elasticache.ModifyReplicationGroupShardConfigurationInput{
+		ReplicationGroupId: group.ReplicationGroupId,
+		NodeGroupCount:     aws.Int32(int32(shards)),
+		ApplyImmediately:   aws.Bool(true),
+	}
+
+	if shards < len(group.NodeGroups) {
+		ids := make([]string, 0, len(group.NodeGroups))
+		for _, nodeGroup := range group.NodeGroups {
+			ids = append(ids, aws.ToString(nodeGroup.NodeGroupId))
+		}
+		sort.Strings(ids)
+		input.NodeGroupsToRemove = ids[shards:]
+	}
+
+	return input
+}
+
+// replicationGroupOutputs returns the outputs of a replication group. In
+// cluster mode both endpoints are the configuration endpoint, which clients
+// use to discover the shards.
+func replicationGroupOutputs(group *types.ReplicationGroup) map[string]string {
+	outputs := map[string]string{
+		"replication_group_id": aws.ToString(group.ReplicationGroupId),
+		"arn":                  aws.ToString(group.ARN),
+		"node_type":            aws.ToString(group.CacheNodeType),
+		"num_shards":           strconv.Itoa(len(group.NodeGroups)),
+		"replicas_per_shard":   strconv.Itoa(replicasPerShard(group)),
+		"at_rest_encryption":   strconv.FormatBool(aws.ToBool(group.AtRestEncryptionEnabled)),
+		"transit_encryption":   strconv.FormatBool(aws.ToBool(group.TransitEncryptionEnabled)),
+		"auth_enabled":         strconv.FormatBool(aws.ToBool(group.AuthTokenEnabled)),
+	}
+
+	var primary, reader *types.Endpoint
+	if aws.ToBool(group.ClusterEnabled) {
+		primary, reader = group.ConfigurationEndpoint, group.ConfigurationEndpoint
+	} else if len(group.NodeGroups) > 0 {
+		primary, reader = group.NodeGroups[0].PrimaryEndpoint, group.NodeGroups[0].ReaderEndpoint
+	}
+	if primary != nil {
+		outputs["primary_endpoint"] = aws.ToString(primary.Address)
+		outputs["port"] = strconv.Itoa(int(aws.ToInt32(primary.Port)))
+	}
+	if reader != nil {
+		outputs["reader_endpoint"] = aws.ToString(reader.Address)
+	}
+
+	return outputs
+}
+
+// replicasPerShard returns the number of replicas of the first shard of a
+// replication group
+func replicasPerShard(group *types.ReplicationGroup) int {
+	if len(group.NodeGroups) == 0 || len(group.NodeGroups[0].NodeGroupMembers) == 0 {
+		return 0
+	}
+	return len(group.NodeGroups[0].NodeGroupMembers) - 1
+}
+
+// redisClusterParameterGroup returns the default cluster mode parameter
+// group of a Redis engine version
+func redisClusterParameterGroup(version string) string {
+	parts := strings.Split(version, ".")
+	major, _ := strconv.Atoi(parts[0])
+
+	switch {
+	case version == "":
+		return "default.redis7.cluster.on"
+	case major == 6:
+		return "default.redis6.x.cluster.on"
+	case major > 6 || len(parts) < 2:
+		return "default.redis" + parts[0] + ".cluster.on"
+	default:
+		return "default.redis" + parts[0] + "." + parts[1] + ".cluster.on"
+	}
+}
+
+// redisReplicationGroupID returns the replication group ID of a cache
+func redisReplicationGroupID(redis *schema.ElastiCacheRedis, opts *provider.ResourceOptions) string {
+	return shortName(fmt.Sprintf("%s-%s-%s", opts.StackName, opts.ServiceName, redis.Metadata.Name), replicationGroupIDMaxLength)
+}
+
+// engineVersionMatches reports whether an engine version satisfies a
+// desired version, which may leave out the minor or patch version
+func engineVersionMatches(actual, desired string) bool {
+	return actual == desired || strings.HasPrefix(actual, desired+".")
+}
+
+// cacheStatus maps an ElastiCache status to a resource status
+func cacheStatus(status string) provider.ResourceStatus {
+	switch status {
+	case "available":
+		return provider.StatusAvailable
+	case "creating":
+		return provider.StatusCreating
+	case "deleting":
+		return provider.StatusDeleting
+	case "create-failed", "incompatible-network", "restore-failed":
+		return provider.StatusFailed
+	default:
+		return provider.StatusUpdating
+	}
+}
+
+// cacheError wraps an ElastiCache error
+func cacheError(operation, resourceID, message string, err error) *provider.ProviderError {
+	return &provider.ProviderError{
+		Provider:   "aws",
+		Operation:  operation,
+		ResourceID: resourceID,
+		Cause:      err,
+		Message:    message,
+	}
+}
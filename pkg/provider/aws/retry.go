@@ -0,0 +1,145 @@
+package aws
+
+import (
+	"errors"
+	"strings"
+	"sync"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/smithy-go"
+	"github.com/yourusername/panka/pkg/provider"
+)
+
+// Rate of the operations of all resource providers of an AWS account
+const (
+	accountOperationsPerSecond = 10
+	accountOperationsBurst     = 20
+)
+
+// retryableErrorCodes are the codes of AWS errors that go away when the
+// operation is retried, on top of the throttling and transient errors the
+// SDK already knows about
+var retryableErrorCodes = map[string]bool{
+	// Another operation on the resource is still in progress
+	"OperationAbortedException":       true,
+	"ConcurrentModification":          true,
+	"ConcurrentModificationException": true,
+	"PriorRequestNotComplete":         true,
+	"InvalidReplicationGroupState":    true,
+	"InvalidCacheClusterState":        true,
+	"ResourceInUse":                   true,
+	"PriorityInUse":                   true,
+
+	// The service is temporarily unable to handle the request
+	"ServiceUnavailable":          true,
+	"ServiceUnavailableException": true,
+	"InternalFailure":             true,
+	"InternalError":               true,
+}
+
+// permanentErrorCodes are the codes of AWS errors that are not retried
+// even though they can be transient, since they mostly report failures a
+// retry does not fix: ResourceInUseException is returned when creating a
+// DynamoDB table that exists, ResourceConflictException when creating a
+// Lambda function that exists, and LimitExceededException, which the SDK
+// treats as throttling, is usually a hard quota. Calls that conflict with
+// an update in progress are retried by the SDK instead (see
+// retryOnConflict).
+var permanentErrorCodes = map[string]bool{
+	"ResourceInUseException":    true,
+	"ResourceConflictException": true,
+	"LimitExceededException":    true,
+}
+
+// eventualConsistencyMessages are parts of the messages of errors caused
+// by resources that were just created elsewhere and are not visible yet,
+// such as an IAM role that Lambda cannot assume in the first seconds
+var eventualConsistencyMessages = []string{
+	"cannot be assumed",
+	"role defined for the function",
+	"not authorized to perform: sts:AssumeRole",
+	"does not exist or is not accessible yet",
+}
+
+// IsRetryable reports whether an AWS error is transient: throttling,
+// conflicting operations still in progress, temporary service failures and
+// eventual consistency.
+//
+// Retries happen at two levels. The SDK retries a single API call on
+// throttling and transient errors, which is cheap since the calls before it
+// are not repeated. Resource operations are retried as a whole on the errors
+// the SDK does not retry, such as a conflicting operation still in progress.
+// Errors the SDK already gave up on are not retried again, so that the
+// attempts of both levels do not multiply.
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var exhausted *retry.MaxAttemptsError
+	if errors.As(err, &exhausted) {
+		return false
+	}
+
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) && permanentErrorCodes[apiErr.ErrorCode()] {
+		return false
+	}
+
+	if retry.IsErrorRetryables(retry.DefaultRetryables).IsErrorRetryable(err) == awssdk.TrueTernary {
+		return true
+	}
+
+	if apiErr == nil {
+		return false
+	}
+
+	code := apiErr.ErrorCode()
+	if retryableErrorCodes[code] {
+		return true
+	}
+	if code == "InvalidParameterValueException" || code == "InvalidParameterValue" || code == "AccessDeniedException" {
+		for _, msg := range eventualConsistencyMessages {
+			if strings.Contains(apiErr.ErrorMessage(), msg) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+var (
+	accountLimitersMu sync.Mutex
+	accountLimiters   = make(map[string]*provider.RateLimiter)
+)
+
+// accountLimiter returns the rate limiter shared by the resource providers
+// of an AWS account
+func accountLimiter(accountID string) *provider.RateLimiter {
+	accountLimitersMu.Lock()
+	defer accountLimitersMu.Unlock()
+
+	limiter, ok := accountLimiters[accountID]
+	if !ok {
+		limiter = provider.NewRateLimiter(accountOperationsPerSecond, accountOperationsBurst)
+		accountLimiters[accountID] = limiter
+	}
+	return limiter
+}
+
+// retryConfig returns the retry configuration of the resource providers
+func (p *Provider) retryConfig() provider.RetryConfig {
+	config := provider.DefaultRetryConfig()
+	config.Retryable = IsRetryable
+	config.Limiter = accountLimiter(p.accountID)
+	return config
+}
+
+// isAPIErrorCode reports whether an error is an AWS API error with the given
+// code
+func isAPIErrorCode(err error, code string) bool {
+	var apiErr smithy.APIError
+	return errors.As(err, &apiErr) && apiErr.ErrorCode() == code
+}
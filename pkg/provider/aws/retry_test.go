@@ -0,0 +1,69 @@
+package aws
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	"github.com/aws/smithy-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/yourusername/panka/pkg/provider"
+)
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"plain error", errors.New("boom"), false},
+		{"throttling", &smithy.GenericAPIError{Code: "ThrottlingException"}, true},
+		{"request limit", &smithy.GenericAPIError{Code: "RequestLimitExceeded"}, true},
+		{"conflicting operation", &smithy.GenericAPIError{Code: "ConcurrentModificationException"}, true},
+		{"function already exists", &smithy.GenericAPIError{Code: "ResourceConflictException"}, false},
+		{"role not assumable yet", &smithy.GenericAPIError{
+			Code:    "InvalidParameterValueException",
+			Message: "The role defined for the function cannot be assumed by Lambda.",
+		}, true},
+		{"invalid parameter", &smithy.GenericAPIError{Code: "InvalidParameterValueException", Message: "Runtime is not supported"}, false},
+		{"not found", &smithy.GenericAPIError{Code: "ResourceNotFoundException"}, false},
+		{"table already exists", &smithy.GenericAPIError{Code: "ResourceInUseException"}, false},
+		{"quota", &smithy.GenericAPIError{Code: "LimitExceededException"}, false},
+		{"retries exhausted by the SDK", fmt.Errorf("operation error: %w", &retry.MaxAttemptsError{
+			Attempt: 3,
+			Err:     &smithy.GenericAPIError{Code: "ThrottlingException"},
+		}), false},
+		{"wrapped in provider error", &provider.ProviderError{
+			Provider:  "aws",
+			Operation: "create",
+			Message:   "failed to create queue",
+			Cause:     fmt.Errorf("operation error: %w", &smithy.GenericAPIError{Code: "Throttling"}),
+		}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, IsRetryable(tt.err))
+		})
+	}
+}
+
+func TestRetryOnConflict(t *testing.T) {
+	conflict := &smithy.GenericAPIError{Code: "ResourceConflictException"}
+
+	// Calls on an existing function are retried by the SDK on a conflict,
+	// on top of the errors it retries anyway
+	options := lambda.Options{Retryer: retry.NewStandard()}
+	assert.False(t, options.Retryer.IsErrorRetryable(conflict))
+	retryOnConflict(&options)
+	assert.True(t, options.Retryer.IsErrorRetryable(conflict))
+	assert.True(t, options.Retryer.IsErrorRetryable(&smithy.GenericAPIError{Code: "ThrottlingException"}))
+}
+
+func TestAccountLimiter_SharedPerAccount(t *testing.T) {
+	assert.Same(t, accountLimiter("111111111111"), accountLimiter("111111111111"))
+	assert.NotSame(t, accountLimiter("111111111111"), accountLimiter("222222222222"))
+}
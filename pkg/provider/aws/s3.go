@@ -2,6 +2,7 @@ package aws
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -158,6 +159,22 @@ func (sp *S3Provider) Create(ctx context.Context, resource schema.Resource, opts
 	return result, nil
 }
 
+// PlannedID returns the name of the bucket of a resource
+func (sp *S3Provider) PlannedID(resource schema.Resource, opts *provider.ResourceOptions) (string, error) {
+	s3Resource, ok := resource.(*schema.S3)
+	if !ok {
+		return "", &provider.ProviderError{
+			Provider:  "aws",
+			Operation: "plan",
+			Message:   "invalid resource type for S3 provider",
+		}
+	}
+	if s3Resource.Spec.Bucket.Name != "" {
+		return s3Resource.Spec.Bucket.Name, nil
+	}
+	return sp.generateBucketName(s3Resource, opts), nil
+}
+
 // Read reads the current state of an S3 bucket
 func (sp *S3Provider) Read(ctx context.Context, resourceID string, opts *provider.ResourceOptions) (*provider.ResourceResult, error) {
 	// Check if bucket exists
@@ -276,8 +293,12 @@ func (sp *S3Provider) Exists(ctx context.Context, resourceID string, opts *provi
 		Bucket: aws.String(resourceID),
 	})
 	if err != nil {
-		// Bucket doesn't exist or we don't have access
-		return false, nil
+		var notFound *types.NotFound
+		var noSuchBucket *types.NoSuchBucket
+		if errors.As(err, &notFound) || errors.As(err, &noSuchBucket) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to check bucket %s: %w", resourceID, err)
 	}
 	return true, nil
 }
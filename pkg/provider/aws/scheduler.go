@@ -0,0 +1,76 @@
+package aws
+
+import (
+	"context"
+	"errors"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/scheduler"
+	"github.com/aws/aws-sdk-go-v2/service/scheduler/types"
+)
+
+// schedulerGroupName is the schedule group of all panka schedules
+const schedulerGroupName = "default"
+
+// schedulerClient wraps the EventBridge Scheduler client with the calls on
+// the schedules of cron jobs
+type schedulerClient struct {
+	client *scheduler.Client
+}
+
+// newSchedulerClient creates an EventBridge Scheduler client
+func newSchedulerClient(p *Provider) *schedulerClient {
+	return &schedulerClient{
+		client: scheduler.NewFromConfig(p.GetConfig()),
+	}
+}
+
+// createSchedule creates a schedule and returns its ARN
+func (c *schedulerClient) createSchedule(ctx context.Context, input *scheduler.CreateScheduleInput) (string, error) {
+	output, err := c.client.CreateSchedule(ctx, input)
+	if err != nil {
+		return "", err
+	}
+	return aws.ToString(output.ScheduleArn), nil
+}
+
+// updateSchedule replaces a schedule with the one input creates and returns
+// its ARN
+func (c *schedulerClient) updateSchedule(ctx context.Context, input *scheduler.CreateScheduleInput) (string, error) {
+	update := scheduler.UpdateScheduleInput(*input)
+	output, err := c.client.UpdateSchedule(ctx, &update)
+	if err != nil {
+		return "", err
+	}
+	return aws.ToString(output.ScheduleArn), nil
+}
+
+// getSchedule returns a schedule, or nil if it does not exist
+func (c *schedulerClient) getSchedule(ctx context.Context, name string) (*scheduler.GetScheduleOutput, error) {
+	output, err := c.client.GetSchedule(ctx, &scheduler.GetScheduleInput{
+		Name:      aws.String(name),
+		GroupName: aws.String(schedulerGroupName),
+	})
+	if err != nil {
+		var notFound *types.ResourceNotFoundException
+		if errors.As(err, &notFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return output, nil
+}
+
+// deleteSchedule deletes a schedule. A schedule that does not exist is
+// ignored.
+func (c *schedulerClient) deleteSchedule(ctx context.Context, name string) error {
+	_, err := c.client.DeleteSchedule(ctx, &scheduler.DeleteScheduleInput{
+		Name:      aws.String(name),
+		GroupName: aws.String(schedulerGroupName),
+	})
+	var notFound *types.ResourceNotFoundException
+	if errors.As(err, &notFound) {
+		return nil
+	}
+	return err
+}
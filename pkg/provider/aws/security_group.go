@@ -202,6 +202,66 @@ func (s *SecurityGroupProvider) AddEgressRule(ctx context.Context, sgID string,
 	return s.addEgressRules(ctx, sgID, []SecurityGroupRule{rule})
 }
 
+// RevokeIngressRule removes an ingress rule from a security group
+func (s *SecurityGroupProvider) RevokeIngressRule(ctx context.Context, sgID string, rule SecurityGroupRule) error {
+	_, err := s.ec2Client.RevokeSecurityGroupIngress(ctx, &ec2.RevokeSecurityGroupIngressInput{
+		GroupId:       aws.String(sgID),
+		IpPermissions: s.buildIpPermissions([]SecurityGroupRule{rule}),
+	})
+	if err != nil {
+		return err
+	}
+
+	s.awsProvider.logger.Debug("Revoked ingress rule", zap.String("sg_id", sgID))
+
+	return nil
+}
+
+// FindByName finds a Security Group by name in a VPC, returning nil if there
+// is none
+func (s *SecurityGroupProvider) FindByName(ctx context.Context, vpcID, name string) (*SecurityGroupResult, error) {
+	s.awsProvider.logger.Debug("Finding Security Group by name",
+		zap.String("vpc_id", vpcID),
+		zap.String("name", name),
+	)
+
+	result, err := s.ec2Client.DescribeSecurityGroups(ctx, &ec2.DescribeSecurityGroupsInput{
+		Filters: []types.Filter{
+			{
+				Name:   aws.String("vpc-id"),
+				Values: []string{vpcID},
+			},
+			{
+				Name:   aws.String("group-name"),
+				Values: []string{name},
+			},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find security group: %w", err)
+	}
+
+	if len(result.SecurityGroups) == 0 {
+		return nil, nil
+	}
+
+	sg := result.SecurityGroups[0]
+	tags := make(map[string]string)
+	for _, tag := range sg.Tags {
+		tags[*tag.Key] = *tag.Value
+	}
+
+	return &SecurityGroupResult{
+		SecurityGroupID: *sg.GroupId,
+		Name:            *sg.GroupName,
+		Description:     *sg.Description,
+		VPCID:           *sg.VpcId,
+		Ingress:         s.convertIpPermissions(sg.IpPermissions),
+		Egress:          s.convertIpPermissions(sg.IpPermissionsEgress),
+		Tags:            tags,
+	}, nil
+}
+
 // FindByVPC finds Security Groups by VPC ID
 func (s *SecurityGroupProvider) FindByVPC(ctx context.Context, vpcID string) ([]SecurityGroupResult, error) {
 	s.awsProvider.logger.Debug("Finding Security Groups by VPC", zap.String("vpc_id", vpcID))
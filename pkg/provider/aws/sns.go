@@ -2,7 +2,9 @@ package aws
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -43,14 +45,7 @@ func (sp *SNSProvider) Create(ctx context.Context, resource schema.Resource, opt
 	)
 
 	// Generate topic name
-	topicName := sp.generateTopicName(snsResource, opts)
-
-	// Add .fifo suffix for FIFO topics
-	if snsResource.Spec.FifoTopic {
-		if len(topicName) < 5 || topicName[len(topicName)-5:] != ".fifo" {
-			topicName = topicName + ".fifo"
-		}
-	}
+	topicName := sp.fullTopicName(snsResource, opts)
 
 	// Build attributes
 	attributes := make(map[string]string)
@@ -135,15 +130,29 @@ func (sp *SNSProvider) Create(ctx context.Context, resource schema.Resource, opt
 		return result, nil
 	}
 
-	// Dry run result
+	// Dry run result. The ID is the ARN the topic is created with, so that
+	// an interrupted create can be found again.
 	return &provider.ResourceResult{
-		ResourceID: topicName,
+		ResourceID: sp.topicARN(topicName),
 		Kind:       schema.KindSNS,
 		Status:     provider.StatusPending,
 		Timestamp:  time.Now(),
 	}, nil
 }
 
+// PlannedID returns the ARN of the topic of a resource
+func (sp *SNSProvider) PlannedID(resource schema.Resource, opts *provider.ResourceOptions) (string, error) {
+	snsResource, ok := resource.(*schema.SNS)
+	if !ok {
+		return "", &provider.ProviderError{
+			Provider:  "aws",
+			Operation: "plan",
+			Message:   "invalid resource type for SNS provider",
+		}
+	}
+	return sp.topicARN(sp.fullTopicName(snsResource, opts)), nil
+}
+
 // Read reads the current state of an SNS topic
 func (sp *SNSProvider) Read(ctx context.Context, resourceID string, opts *provider.ResourceOptions) (*provider.ResourceResult, error) {
 	// resourceID is the topic ARN
@@ -188,10 +197,7 @@ func (sp *SNSProvider) Update(ctx context.Context, resource schema.Resource, opt
 		}
 	}
 
-	topicName := sp.generateTopicName(snsResource, opts)
-	if snsResource.Spec.FifoTopic && len(topicName) >= 5 && topicName[len(topicName)-5:] != ".fifo" {
-		topicName = topicName + ".fifo"
-	}
+	topicName := sp.fullTopicName(snsResource, opts)
 
 	sp.provider.GetLogger().Info("Updating SNS topic", zap.String("topic", topicName))
 
@@ -239,17 +245,29 @@ func (sp *SNSProvider) Delete(ctx context.Context, resourceID string, opts *prov
 	}, nil
 }
 
-// Exists checks if an SNS topic exists
+// Exists checks if an SNS topic exists. Errors other than a missing topic
+// are returned.
 func (sp *SNSProvider) Exists(ctx context.Context, resourceID string, opts *provider.ResourceOptions) (bool, error) {
 	_, err := sp.client.GetTopicAttributes(ctx, &sns.GetTopicAttributesInput{
 		TopicArn: aws.String(resourceID),
 	})
 	if err != nil {
-		return false, nil
+		var notFound *types.NotFoundException
+		if errors.As(err, &notFound) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to look up topic %s: %w", resourceID, err)
 	}
 	return true, nil
 }
 
+// topicARN returns the ARN of a topic of the account in the region of the
+// provider
+func (sp *SNSProvider) topicARN(topicName string) string {
+	region := sp.provider.GetRegion()
+	return fmt.Sprintf("arn:%s:sns:%s:%s:%s", arnPartition(region), region, sp.provider.GetAccountID(), topicName)
+}
+
 // GetOutputs returns the outputs of an SNS topic
 func (sp *SNSProvider) GetOutputs(ctx context.Context, resourceID string, opts *provider.ResourceOptions) (map[string]string, error) {
 	result, err := sp.Read(ctx, resourceID, opts)
@@ -269,6 +287,16 @@ func (sp *SNSProvider) generateTopicName(resource *schema.SNS, opts *provider.Re
 	)
 }
 
+// fullTopicName returns the name of a topic, with the .fifo suffix that
+// FIFO topics need
+func (sp *SNSProvider) fullTopicName(resource *schema.SNS, opts *provider.ResourceOptions) string {
+	topicName := sp.generateTopicName(resource, opts)
+	if resource.Spec.FifoTopic && !strings.HasSuffix(topicName, ".fifo") {
+		topicName += ".fifo"
+	}
+	return topicName
+}
+
 func (sp *SNSProvider) createSubscription(ctx context.Context, topicARN string, sub schema.SNSSubscription) error {
 	subscribeInput := &sns.SubscribeInput{
 		TopicArn: aws.String(topicARN),
@@ -168,3 +168,25 @@ func TestSNSProvider_FilterPolicies(t *testing.T) {
 	assert.Contains(t, sub.FilterPolicy, "numeric")
 }
 
+
+func TestSNSProvider_PlannedID(t *testing.T) {
+	log, _ := logger.NewDevelopment()
+	awsProvider := &Provider{logger: log, accountID: "123456789012", region: "us-east-1"}
+	awsProvider.tagHelper = provider.NewTagHelper(nil)
+	snsProvider := NewSNSProvider(awsProvider)
+
+	resource := schema.NewSNS("events", "backend", "my-stack")
+	opts := &provider.ResourceOptions{StackName: "my-stack", ServiceName: "backend", DryRun: true}
+
+	// The planned ID is the ARN the topic is created with, as reported by a
+	// dry run
+	id, err := snsProvider.PlannedID(resource, opts)
+	require.NoError(t, err)
+	assert.Equal(t, "arn:aws:sns:us-east-1:123456789012:my-stack-backend-events", id)
+
+	result, err := snsProvider.Create(context.Background(), resource, opts)
+	require.NoError(t, err)
+	assert.Equal(t, id, result.ResourceID)
+
+	assert.Equal(t, result.ResourceID, snsProvider.topicARN("my-stack-backend-events"))
+}
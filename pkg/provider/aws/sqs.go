@@ -2,7 +2,9 @@ package aws
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -44,7 +46,7 @@ func (sp *SQSProvider) Create(ctx context.Context, resource schema.Resource, opt
 	)
 
 	// Generate queue name
-	queueName := sp.generateQueueName(sqsResource, opts)
+	queueName := sp.fullQueueName(sqsResource, opts)
 
 	// Build queue attributes
 	attributes := make(map[string]string)
@@ -89,11 +91,6 @@ func (sp *SQSProvider) Create(ctx context.Context, resource schema.Resource, opt
 		if sqsResource.Spec.FifoThroughputLimit != "" {
 			attributes["FifoThroughputLimit"] = sqsResource.Spec.FifoThroughputLimit
 		}
-
-		// Add .fifo suffix if not present
-		if len(queueName) < 5 || queueName[len(queueName)-5:] != ".fifo" {
-			queueName = queueName + ".fifo"
-		}
 	}
 
 	// Build tags
@@ -168,15 +165,29 @@ func (sp *SQSProvider) Create(ctx context.Context, resource schema.Resource, opt
 		return result, nil
 	}
 
-	// Dry run result
+	// Dry run result. The ID is the URL the queue is created with, so that
+	// an interrupted create can be found again.
 	return &provider.ResourceResult{
-		ResourceID: queueName,
+		ResourceID: sp.queueURL(queueName),
 		Kind:       schema.KindSQS,
 		Status:     provider.StatusPending,
 		Timestamp:  time.Now(),
 	}, nil
 }
 
+// PlannedID returns the URL of the queue of a resource
+func (sp *SQSProvider) PlannedID(resource schema.Resource, opts *provider.ResourceOptions) (string, error) {
+	sqsResource, ok := resource.(*schema.SQS)
+	if !ok {
+		return "", &provider.ProviderError{
+			Provider:  "aws",
+			Operation: "plan",
+			Message:   "invalid resource type for SQS provider",
+		}
+	}
+	return sp.queueURL(sp.fullQueueName(sqsResource, opts)), nil
+}
+
 // Read reads the current state of an SQS queue
 func (sp *SQSProvider) Read(ctx context.Context, resourceID string, opts *provider.ResourceOptions) (*provider.ResourceResult, error) {
 	// resourceID is the queue URL
@@ -225,10 +236,7 @@ func (sp *SQSProvider) Update(ctx context.Context, resource schema.Resource, opt
 		}
 	}
 
-	queueName := sp.generateQueueName(sqsResource, opts)
-	if sqsResource.Spec.Type == "fifo" && len(queueName) >= 5 && queueName[len(queueName)-5:] != ".fifo" {
-		queueName = queueName + ".fifo"
-	}
+	queueName := sp.fullQueueName(sqsResource, opts)
 
 	sp.provider.GetLogger().Info("Updating SQS queue", zap.String("queue", queueName))
 
@@ -308,18 +316,50 @@ func (sp *SQSProvider) Delete(ctx context.Context, resourceID string, opts *prov
 	}, nil
 }
 
-// Exists checks if an SQS queue exists
+// Exists checks if an SQS queue exists. The queue is looked up by the name
+// and owner in its URL. Errors other than a missing queue are returned.
 func (sp *SQSProvider) Exists(ctx context.Context, resourceID string, opts *provider.ResourceOptions) (bool, error) {
-	_, err := sp.client.GetQueueAttributes(ctx, &sqs.GetQueueAttributesInput{
-		QueueUrl:       aws.String(resourceID),
-		AttributeNames: []types.QueueAttributeName{types.QueueAttributeNameQueueArn},
-	})
+	queueName, owner := parseQueueID(resourceID)
+	input := &sqs.GetQueueUrlInput{
+		QueueName: aws.String(queueName),
+	}
+	if owner != "" {
+		input.QueueOwnerAWSAccountId = aws.String(owner)
+	}
+
+	_, err := sp.client.GetQueueUrl(ctx, input)
 	if err != nil {
-		return false, nil
+		if isQueueNotFound(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to look up queue %s: %w", resourceID, err)
 	}
 	return true, nil
 }
 
+// queueURL returns the URL of a queue of the account in the region of the
+// provider
+func (sp *SQSProvider) queueURL(queueName string) string {
+	return fmt.Sprintf("https://sqs.%s.amazonaws.com/%s/%s", sp.provider.GetRegion(), sp.provider.GetAccountID(), queueName)
+}
+
+// parseQueueID returns the queue name and owning account of a queue URL
+// (https://sqs.<region>.amazonaws.com/<account>/<name>)
+func parseQueueID(resourceID string) (queueName, owner string) {
+	parts := strings.Split(resourceID, "/")
+	queueName = parts[len(parts)-1]
+	if len(parts) >= 5 {
+		owner = parts[len(parts)-2]
+	}
+	return queueName, owner
+}
+
+// isQueueNotFound reports whether an error is SQS reporting a missing queue
+func isQueueNotFound(err error) bool {
+	var notFound *types.QueueDoesNotExist
+	return errors.As(err, &notFound) || isAPIErrorCode(err, "AWS.SimpleQueueService.NonExistentQueue")
+}
+
 // GetOutputs returns the outputs of an SQS queue
 func (sp *SQSProvider) GetOutputs(ctx context.Context, resourceID string, opts *provider.ResourceOptions) (map[string]string, error) {
 	result, err := sp.Read(ctx, resourceID, opts)
@@ -339,6 +379,16 @@ func (sp *SQSProvider) generateQueueName(resource *schema.SQS, opts *provider.Re
 	)
 }
 
+// fullQueueName returns the name of a queue, with the .fifo suffix that
+// FIFO queues need
+func (sp *SQSProvider) fullQueueName(resource *schema.SQS, opts *provider.ResourceOptions) string {
+	queueName := sp.generateQueueName(resource, opts)
+	if resource.Spec.Type == "fifo" && !strings.HasSuffix(queueName, ".fifo") {
+		queueName += ".fifo"
+	}
+	return queueName
+}
+
 func (sp *SQSProvider) configureDLQ(ctx context.Context, queueURL string, dlqConfig *schema.DeadLetterQueueConfig) error {
 	// Note: In a real implementation, you would need to create the DLQ first
 	// and get its ARN, then configure the redrive policy
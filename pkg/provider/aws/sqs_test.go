@@ -2,8 +2,11 @@ package aws
 
 import (
 	"context"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/yourusername/panka/internal/logger"
@@ -159,3 +162,70 @@ func TestSQSProvider_MessageSizeConfiguration(t *testing.T) {
 	assert.LessOrEqual(t, resource.Spec.MaxMessageSize, 262144)   // Max 256KB
 }
 
+
+func TestSQSProvider_PlannedID(t *testing.T) {
+	log, _ := logger.NewDevelopment()
+	awsProvider := &Provider{logger: log, accountID: "123456789012", region: "us-east-1"}
+	awsProvider.tagHelper = provider.NewTagHelper(nil)
+	sqsProvider := NewSQSProvider(awsProvider)
+
+	resource := schema.NewSQS("orders", "backend", "my-stack")
+	opts := &provider.ResourceOptions{StackName: "my-stack", ServiceName: "backend", DryRun: true}
+
+	// The planned ID is the URL the queue is created with, as reported by a
+	// dry run
+	for queueType, url := range map[string]string{
+		"standard": "https://sqs.us-east-1.amazonaws.com/123456789012/my-stack-backend-orders",
+		"fifo":     "https://sqs.us-east-1.amazonaws.com/123456789012/my-stack-backend-orders.fifo",
+	} {
+		resource.Spec.Type = queueType
+
+		id, err := sqsProvider.PlannedID(resource, opts)
+		require.NoError(t, err)
+		assert.Equal(t, url, id)
+
+		result, err := sqsProvider.Create(context.Background(), resource, opts)
+		require.NoError(t, err)
+		assert.Equal(t, url, result.ResourceID)
+	}
+}
+
+func TestParseQueueID(t *testing.T) {
+	name, owner := parseQueueID("https://sqs.us-east-1.amazonaws.com/123456789012/orders")
+	assert.Equal(t, "orders", name)
+	assert.Equal(t, "123456789012", owner)
+}
+
+func TestSQSProvider_Exists(t *testing.T) {
+	respond := func(status int, body string) *SQSProvider {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/x-amz-json-1.0")
+			w.WriteHeader(status)
+			_, _ = w.Write([]byte(body))
+		}))
+		t.Cleanup(server.Close)
+
+		return NewSQSProvider(&Provider{config: aws.Config{
+			Region:           "us-east-1",
+			BaseEndpoint:     aws.String(server.URL),
+			HTTPClient:       server.Client(),
+			RetryMaxAttempts: 1,
+			Credentials: aws.CredentialsProviderFunc(func(context.Context) (aws.Credentials, error) {
+				return aws.Credentials{AccessKeyID: "AKID", SecretAccessKey: "secret"}, nil
+			}),
+		}})
+	}
+	queueURL := "https://sqs.us-east-1.amazonaws.com/123456789012/orders"
+
+	exists, err := respond(http.StatusOK, `{"QueueUrl": "`+queueURL+`"}`).Exists(context.Background(), queueURL, nil)
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	exists, err = respond(http.StatusBadRequest, `{"__type": "com.amazonaws.sqs#QueueDoesNotExist", "message": "The specified queue does not exist."}`).Exists(context.Background(), queueURL, nil)
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	// Other errors are not taken as a missing queue
+	_, err = respond(http.StatusBadRequest, `{"__type": "com.amazon.coral.service#AccessDeniedException", "message": "denied"}`).Exists(context.Background(), queueURL, nil)
+	assert.Error(t, err)
+}
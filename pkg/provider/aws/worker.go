@@ -0,0 +1,155 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/applicationautoscaling"
+	"github.com/aws/aws-sdk-go-v2/service/applicationautoscaling/types"
+	"github.com/yourusername/panka/pkg/parser/schema"
+	"github.com/yourusername/panka/pkg/provider"
+)
+
+// WorkerProvider implements workers as ECS/Fargate services without ports
+// or a load balancer. A worker that scales on its queue gets an Application
+// Auto Scaling target with a target tracking policy on the queue's backlog
+// per running task.
+type WorkerProvider struct {
+	*ECSProvider
+}
+
+// NewWorkerProvider creates a new worker provider
+func NewWorkerProvider(p *Provider) *WorkerProvider {
+	ecsProvider := NewECSProvider(p)
+	ecsProvider.kind = schema.KindWorker
+
+	return &WorkerProvider{
+		ECSProvider: ecsProvider,
+	}
+}
+
+// Create creates the ECS service of a worker and its scaling
+func (wp *WorkerProvider) Create(ctx context.Context, resource schema.Resource, opts *provider.ResourceOptions) (*provider.ResourceResult, error) {
+	worker, ok := resource.(*schema.Worker)
+	if !ok {
+		return nil, &provider.ProviderError{
+			Provider:  "aws",
+			Operation: "create",
+			Message:   "invalid resource type for worker provider",
+		}
+	}
+
+	result, err := wp.ECSProvider.Create(ctx, resource, opts)
+	if err != nil || opts.DryRun {
+		return result, err
+	}
+	return withQueueOutputs("create", result, worker, opts)
+}
+
+// Update rolls out a new task definition to a worker and reconciles its
+// scaling
+func (wp *WorkerProvider) Update(ctx context.Context, resource schema.Resource, opts *provider.ResourceOptions) (*provider.ResourceResult, error) {
+	worker, ok := resource.(*schema.Worker)
+	if !ok {
+		return nil, &provider.ProviderError{
+			Provider:  "aws",
+			Operation: "update",
+			Message:   "invalid resource type for worker provider",
+		}
+	}
+
+	result, err := wp.ECSProvider.Update(ctx, resource, opts)
+	if err != nil {
+		return nil, err
+	}
+	return withQueueOutputs("update", result, worker, opts)
+}
+
+// withQueueOutputs adds the queue a worker scales on to its outputs
+func withQueueOutputs(operation string, result *provider.ResourceResult, worker *schema.Worker, opts *provider.ResourceOptions) (*provider.ResourceResult, error) {
+	if worker.Spec.Scaling == nil {
+		return result, nil
+	}
+
+	queueName, err := workerQueueName(worker.Spec.Scaling, opts)
+	if err != nil {
+		return nil, ecsError(operation, result.ResourceID, "failed to configure worker scaling", err)
+	}
+	if result.Outputs == nil {
+		result.Outputs = make(map[string]string)
+	}
+	result.Outputs["scaling_queue"] = queueName
+	return result, nil
+}
+
+// queueBacklogPolicy returns a target tracking policy that keeps the number
+// of visible messages per running task at the target. With no running task
+// the whole backlog counts, so that the worker scales out from zero.
+func queueBacklogPolicy(scaling *schema.QueueScaling, target scalableTarget, clusterName, serviceName, queueName string) *applicationautoscaling.PutScalingPolicyInput {
+	return &applicationautoscaling.PutScalingPolicyInput{
+		PolicyName:        aws.String(serviceName + "-queue-backlog"),
+		ServiceNamespace:  target.ServiceNamespace,
+		ResourceId:        aws.String(target.ResourceID),
+		ScalableDimension: target.ScalableDimension,
+		PolicyType:        types.PolicyTypeTargetTrackingScaling,
+		TargetTrackingScalingPolicyConfiguration: &types.TargetTrackingScalingPolicyConfiguration{
+			TargetValue: aws.Float64(float64(scaling.TargetMessagesPerTask())),
+			CustomizedMetricSpecification: &types.CustomizedMetricSpecification{
+				Metrics: []types.TargetTrackingMetricDataQuery{
+					{
+						Id: aws.String("visible"),
+						MetricStat: &types.TargetTrackingMetricStat{
+							Metric: &types.TargetTrackingMetric{
+								Namespace:  aws.String("AWS/SQS"),
+								MetricName: aws.String("ApproximateNumberOfMessagesVisible"),
+								Dimensions: []types.TargetTrackingMetricDimension{
+									{Name: aws.String("QueueName"), Value: aws.String(queueName)},
+								},
+							},
+							Stat: aws.String("Sum"),
+						},
+						ReturnData: aws.Bool(false),
+					},
+					{
+						Id: aws.String("tasks"),
+						MetricStat: &types.TargetTrackingMetricStat{
+							Metric: &types.TargetTrackingMetric{
+								Namespace:  aws.String("ECS/ContainerInsights"),
+								MetricName: aws.String("RunningTaskCount"),
+								Dimensions: []types.TargetTrackingMetricDimension{
+									{Name: aws.String("ClusterName"), Value: aws.String(clusterName)},
+									{Name: aws.String("ServiceName"), Value: aws.String(serviceName)},
+								},
+							},
+							Stat: aws.String("Average"),
+						},
+						ReturnData: aws.Bool(false),
+					},
+					{
+						Id:         aws.String("backlog_per_task"),
+						Expression: aws.String("visible / IF(tasks > 0, tasks, 1)"),
+						Label:      aws.String("Backlog per task"),
+						ReturnData: aws.Bool(true),
+					},
+				},
+			},
+			ScaleInCooldown:  scalingCooldown(scaling.ScaleInCooldown),
+			ScaleOutCooldown: scalingCooldown(scaling.ScaleOutCooldown),
+		},
+	}
+}
+
+// workerQueueName returns the AWS name of the queue a worker scales on.
+// Workers parsed without the folder parser refer to a queue of their own
+// service.
+func workerQueueName(scaling *schema.QueueScaling, opts *provider.ResourceOptions) (string, error) {
+	if scaling.QueueName == "" {
+		return fmt.Sprintf("%s-%s-%s", opts.StackName, opts.ServiceName, scaling.Queue), nil
+	}
+	if strings.Contains(scaling.QueueName, "${") {
+		return "", fmt.Errorf("queue name of %s is not resolved: %s", scaling.Queue, scaling.QueueName)
+	}
+	return scaling.QueueName, nil
+}
@@ -0,0 +1,150 @@
+package aws
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	autoscalingtypes "github.com/aws/aws-sdk-go-v2/service/applicationautoscaling/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourusername/panka/pkg/parser/schema"
+	"github.com/yourusername/panka/pkg/provider"
+)
+
+func TestQueueBacklogPolicy(t *testing.T) {
+	scaling := &schema.QueueScaling{Queue: "orders", QueueName: "my-stack-messaging-orders", MaxReplicas: 8, MessagesPerTask: 25}
+	target := ecsScalableTarget("panka-acme", "my-stack-backend-consumer", 0, 8)
+
+	policy := queueBacklogPolicy(scaling, target, "panka-acme", "my-stack-backend-consumer", "my-stack-messaging-orders")
+
+	assert.Equal(t, "my-stack-backend-consumer-queue-backlog", aws.ToString(policy.PolicyName))
+	assert.Equal(t, "service/panka-acme/my-stack-backend-consumer", aws.ToString(policy.ResourceId))
+	assert.Equal(t, autoscalingtypes.ScalableDimensionECSServiceDesiredCount, policy.ScalableDimension)
+	assert.Equal(t, autoscalingtypes.PolicyTypeTargetTrackingScaling, policy.PolicyType)
+
+	config := policy.TargetTrackingScalingPolicyConfiguration
+	require.NotNil(t, config)
+	assert.Equal(t, float64(25), aws.ToFloat64(config.TargetValue))
+	assert.Equal(t, int32(defaultScalingCooldown), aws.ToInt32(config.ScaleInCooldown))
+
+	metrics := config.CustomizedMetricSpecification.Metrics
+	require.Len(t, metrics, 3)
+	assert.Equal(t, "my-stack-messaging-orders", aws.ToString(metrics[0].MetricStat.Metric.Dimensions[0].Value))
+	assert.Equal(t, "RunningTaskCount", aws.ToString(metrics[1].MetricStat.Metric.MetricName))
+	assert.True(t, aws.ToBool(metrics[2].ReturnData))
+	assert.False(t, aws.ToBool(metrics[0].ReturnData))
+}
+
+func TestECSScaling_Worker(t *testing.T) {
+	opts := &provider.ResourceOptions{TenantID: "acme", StackName: "my-stack", ServiceName: "backend"}
+
+	weekend := 0
+	worker := schema.NewWorker("consumer", "backend", "my-stack")
+	worker.Spec.Scaling = &schema.QueueScaling{
+		Queue:       "orders",
+		QueueName:   "my-stack-messaging-orders",
+		MaxReplicas: 8,
+		Schedules:   []schema.ScheduledScaling{{Name: "weekend", Schedule: "0 0 * * 6", MaxCapacity: &weekend}},
+	}
+
+	config, err := ecsScaling(worker, "panka-acme", "my-stack-backend-consumer", opts)
+	require.NoError(t, err)
+	require.NotNil(t, config)
+	assert.Equal(t, 0, *config.target.MinCapacity)
+	assert.Equal(t, 8, *config.target.MaxCapacity)
+	require.Len(t, config.policies, 1)
+	assert.Equal(t, "my-stack-backend-consumer-queue-backlog", aws.ToString(config.policies[0].PolicyName))
+	require.Len(t, config.actions, 1)
+	assert.Equal(t, "my-stack-backend-consumer-weekend", aws.ToString(config.actions[0].ScheduledActionName))
+	assert.Equal(t, "cron(0 0 ? * 7 *)", aws.ToString(config.actions[0].Schedule))
+
+	// Workers without queue scaling use the autoscaling of their infra
+	worker.Spec.Scaling = nil
+	worker.Infra = schema.NewComponentInfra("consumer", "backend", "my-stack")
+	worker.Infra.Spec.Scaling.AutoScaling = &schema.AutoScaling{Enabled: true, MinReplicas: 1, MaxReplicas: 3, TargetMemoryPercent: 80}
+	component, ok := ecsComponentOf(worker)
+	require.True(t, ok)
+	assert.True(t, component.autoscaled)
+
+	config, err = ecsScaling(worker, "panka-acme", "my-stack-backend-consumer", opts)
+	require.NoError(t, err)
+	require.Len(t, config.policies, 1)
+	assert.Equal(t, "my-stack-backend-consumer-memory", aws.ToString(config.policies[0].PolicyName))
+}
+
+func TestWorkerQueueName(t *testing.T) {
+	opts := &provider.ResourceOptions{StackName: "my-stack", ServiceName: "backend"}
+
+	name, err := workerQueueName(&schema.QueueScaling{Queue: "orders", QueueName: "my-stack-messaging-orders"}, opts)
+	require.NoError(t, err)
+	assert.Equal(t, "my-stack-messaging-orders", name)
+
+	// Without the folder parser the queue belongs to the worker's service
+	name, err = workerQueueName(&schema.QueueScaling{Queue: "orders"}, opts)
+	require.NoError(t, err)
+	assert.Equal(t, "my-stack-backend-orders", name)
+
+	_, err = workerQueueName(&schema.QueueScaling{Queue: "orders", QueueName: "${orders.queue_name}"}, opts)
+	assert.Error(t, err)
+}
+
+func TestWorkerProvider_Lifecycle(t *testing.T) {
+	fake := newFakeECS()
+	wp := NewWorkerProvider(testServerProvider(t, fake.ServeHTTP))
+	opts := &provider.ResourceOptions{TenantID: "acme", StackName: "my-stack", ServiceName: "backend"}
+
+	worker := schema.NewWorker("consumer", "backend", "my-stack")
+	worker.Spec.Image = schema.ImageConfig{Repository: "example/consumer", Tag: "2.0.0"}
+	worker.Spec.StopTimeout = 90
+	worker.Spec.Scaling = &schema.QueueScaling{
+		Queue:           "orders",
+		QueueName:       "my-stack-messaging-orders",
+		MinReplicas:     1,
+		MaxReplicas:     8,
+		MessagesPerTask: 25,
+	}
+
+	// Queue scaling registers a scalable target with a backlog policy
+	result, err := wp.Create(context.Background(), worker, opts)
+	require.NoError(t, err)
+	assert.Equal(t, "panka-acme/my-stack-backend-consumer", result.ResourceID)
+	assert.Equal(t, schema.KindWorker, result.Kind)
+	assert.Equal(t, "my-stack-messaging-orders", result.Outputs["scaling_queue"])
+	assert.Equal(t, "1", result.Outputs["min_replicas"])
+	assert.Equal(t, "8", result.Outputs["max_replicas"])
+	assert.NotContains(t, result.Outputs, "desired_count")
+	assert.Equal(t, []string{"CreateCluster", "RegisterTaskDefinition", "CreateService"}, fake.calls("ecs"))
+	assert.Equal(t, []string{"RegisterScalableTarget", "PutScalingPolicy"}, fake.calls("autoscaling"))
+	assert.Equal(t, "my-stack-backend-consumer-queue-backlog", fake.input("autoscaling:PutScalingPolicy")["PolicyName"])
+	assert.Equal(t, float64(1), fake.input("ecs:CreateService")["desiredCount"])
+	assert.NotContains(t, fake.input("ecs:CreateService"), "loadBalancers")
+
+	// Workers have no config files or load balancer
+	assert.Empty(t, fake.calls("ssm"))
+	assert.Empty(t, fake.calls("elb"))
+
+	// Without queue scaling the desired count is set again
+	worker.Spec.Scaling = nil
+	worker.Infra = schema.NewComponentInfra("consumer", "backend", "my-stack")
+	worker.Infra.Spec.Scaling.Replicas = 2
+	result, err = wp.Update(context.Background(), worker, opts)
+	require.NoError(t, err)
+	assert.Equal(t, "2", result.Outputs["desired_count"])
+	assert.NotContains(t, result.Outputs, "scaling_queue")
+	assert.Equal(t, []string{"RegisterScalableTarget", "PutScalingPolicy", "DeregisterScalableTarget"}, fake.calls("autoscaling"))
+	update := fake.input("ecs:UpdateService")
+	assert.Equal(t, float64(2), update["desiredCount"])
+	assert.NotContains(t, update, "loadBalancers")
+
+	_, err = wp.Delete(context.Background(), result.ResourceID, opts)
+	require.NoError(t, err)
+	calls := fake.calls("ecs")
+	assert.Equal(t, []string{"UpdateService", "DeleteService"}, calls[len(calls)-2:])
+	assert.Empty(t, fake.calls("ssm"))
+	assert.Empty(t, fake.calls("elb"))
+	assert.Empty(t, fake.roles)
+
+	_, err = wp.Create(context.Background(), schema.NewMicroService("api", "backend", "my-stack"), opts)
+	assert.Error(t, err)
+}
@@ -0,0 +1,313 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/yourusername/panka/internal/logger"
+	"github.com/yourusername/panka/pkg/parser/schema"
+	"go.uber.org/zap"
+)
+
+// RetryConfig configures the retries, rate limiting and deadlines that
+// WithRetries adds to a resource provider
+type RetryConfig struct {
+	// MaxAttempts is the number of attempts of an operation, including the
+	// first one
+	MaxAttempts int
+
+	// BaseDelay is the backoff before the first retry. It doubles with each
+	// retry up to MaxDelay, and the actual delay is a random duration up to
+	// the backoff (full jitter).
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+
+	// Retryable reports whether an error is transient. Without it no error
+	// is retried.
+	Retryable func(error) bool
+
+	// Limiter limits the rate of operations. It may be shared by several
+	// providers; nil means no limit.
+	Limiter *RateLimiter
+}
+
+// DefaultRetryConfig returns the default retry configuration. Retryable
+// and Limiter are left for the cloud provider to set.
+func DefaultRetryConfig() RetryConfig {
+	return RetryConfig{
+		MaxAttempts: 8,
+		BaseDelay:   500 * time.Millisecond,
+		MaxDelay:    30 * time.Second,
+	}
+}
+
+// retryingProvider wraps a resource provider with retries, rate limiting and
+// operation deadlines
+type retryingProvider struct {
+	next   ResourceProvider
+	config RetryConfig
+	logger *logger.Logger
+
+	randMu sync.Mutex
+	rand   *rand.Rand
+}
+
+// WithRetries wraps a resource provider so that its operations:
+//   - wait for the rate limiter before each attempt
+//   - are retried with exponential backoff and jitter when they fail with
+//     an error the config classifies as retryable
+//   - are given up once ResourceOptions.Timeout has passed, across all
+//     attempts
+//
+// Dry runs are passed through unchanged.
+func WithRetries(next ResourceProvider, config RetryConfig) ResourceProvider {
+	if config.MaxAttempts < 1 {
+		config.MaxAttempts = 1
+	}
+	return &retryingProvider{
+		next:   next,
+		config: config,
+		logger: logger.Global(),
+		rand:   rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// Create creates a new resource
+func (p *retryingProvider) Create(ctx context.Context, resource schema.Resource, opts *ResourceOptions) (*ResourceResult, error) {
+	var result *ResourceResult
+	err := p.do(ctx, "create", resourceName(resource), opts, func(ctx context.Context) error {
+		var err error
+		result, err = p.next.Create(ctx, resource, opts)
+		return err
+	})
+	return result, err
+}
+
+// Read reads the current state of a resource
+func (p *retryingProvider) Read(ctx context.Context, resourceID string, opts *ResourceOptions) (*ResourceResult, error) {
+	var result *ResourceResult
+	err := p.do(ctx, "read", resourceID, opts, func(ctx context.Context) error {
+		var err error
+		result, err = p.next.Read(ctx, resourceID, opts)
+		return err
+	})
+	return result, err
+}
+
+// Update updates an existing resource
+func (p *retryingProvider) Update(ctx context.Context, resource schema.Resource, opts *ResourceOptions) (*ResourceResult, error) {
+	var result *ResourceResult
+	err := p.do(ctx, "update", resourceName(resource), opts, func(ctx context.Context) error {
+		var err error
+		result, err = p.next.Update(ctx, resource, opts)
+		return err
+	})
+	return result, err
+}
+
+// Delete deletes a resource
+func (p *retryingProvider) Delete(ctx context.Context, resourceID string, opts *ResourceOptions) (*ResourceResult, error) {
+	var result *ResourceResult
+	err := p.do(ctx, "delete", resourceID, opts, func(ctx context.Context) error {
+		var err error
+		result, err = p.next.Delete(ctx, resourceID, opts)
+		return err
+	})
+	return result, err
+}
+
+// Exists checks if a resource exists
+func (p *retryingProvider) Exists(ctx context.Context, resourceID string, opts *ResourceOptions) (bool, error) {
+	var exists bool
+	err := p.do(ctx, "exists", resourceID, opts, func(ctx context.Context) error {
+		var err error
+		exists, err = p.next.Exists(ctx, resourceID, opts)
+		return err
+	})
+	return exists, err
+}
+
+// GetOutputs returns the outputs of a resource
+func (p *retryingProvider) GetOutputs(ctx context.Context, resourceID string, opts *ResourceOptions) (map[string]string, error) {
+	var outputs map[string]string
+	err := p.do(ctx, "get_outputs", resourceID, opts, func(ctx context.Context) error {
+		var err error
+		outputs, err = p.next.GetOutputs(ctx, resourceID, opts)
+		return err
+	})
+	return outputs, err
+}
+
+// PlannedID returns the planned ID of the wrapped provider, or an empty
+// string if it does not implement IDPlanner
+func (p *retryingProvider) PlannedID(resource schema.Resource, opts *ResourceOptions) (string, error) {
+	planner, ok := p.next.(IDPlanner)
+	if !ok {
+		return "", nil
+	}
+	return planner.PlannedID(resource, opts)
+}
+
+// do runs an operation until it succeeds, fails with an error that is not
+// retryable, runs out of attempts or reaches its deadline
+func (p *retryingProvider) do(ctx context.Context, operation, target string, opts *ResourceOptions, fn func(context.Context) error) error {
+	if opts != nil && opts.DryRun {
+		return fn(ctx)
+	}
+
+	var timeout time.Duration
+	if opts != nil && opts.Timeout > 0 {
+		timeout = opts.Timeout
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	var lastErr error
+	for attempt := 1; ; attempt++ {
+		if err := p.config.Limiter.Wait(ctx); err != nil {
+			return p.stopped(ctx, operation, timeout, lastErr)
+		}
+
+		err := fn(ctx)
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return p.stopped(ctx, operation, timeout, err)
+		}
+		lastErr = err
+
+		if attempt >= p.config.MaxAttempts || p.config.Retryable == nil || !p.config.Retryable(err) {
+			return err
+		}
+
+		delay := p.backoff(attempt)
+		p.logger.Warn("Retrying provider operation",
+			zap.String("operation", operation),
+			zap.String("resource", target),
+			zap.Int("attempt", attempt),
+			zap.Duration("delay", delay),
+			zap.Error(err),
+		)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return p.stopped(ctx, operation, timeout, lastErr)
+		case <-timer.C:
+		}
+	}
+}
+
+// stopped returns the error of an operation whose context is done
+func (p *retryingProvider) stopped(ctx context.Context, operation string, timeout time.Duration, lastErr error) error {
+	ctxErr := ctx.Err()
+	if ctxErr == nil {
+		ctxErr = context.Canceled
+	}
+
+	if timeout > 0 && errors.Is(ctxErr, context.DeadlineExceeded) {
+		if lastErr != nil && !errors.Is(lastErr, context.DeadlineExceeded) {
+			return fmt.Errorf("%s did not complete within %s: %w (last error: %w)", operation, timeout, ctxErr, lastErr)
+		}
+		return fmt.Errorf("%s did not complete within %s: %w", operation, timeout, ctxErr)
+	}
+
+	if lastErr != nil {
+		return lastErr
+	}
+	return ctxErr
+}
+
+// backoff returns the delay before a retry
+func (p *retryingProvider) backoff(attempt int) time.Duration {
+	ceiling := p.config.MaxDelay
+	if d := p.config.BaseDelay << (attempt - 1); d > 0 && (ceiling <= 0 || d < ceiling) {
+		ceiling = d
+	}
+	if ceiling <= 0 {
+		return 0
+	}
+
+	p.randMu.Lock()
+	defer p.randMu.Unlock()
+	return time.Duration(p.rand.Int63n(int64(ceiling))) + 1
+}
+
+// resourceName returns the name of a resource for logging
+func resourceName(resource schema.Resource) string {
+	if resource == nil {
+		return ""
+	}
+	return resource.GetMetadata().Name
+}
+
+// RateLimiter is a token bucket limiting the rate of provider operations.
+// It is safe for concurrent use, and a nil RateLimiter does not limit.
+type RateLimiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter creates a rate limiter allowing perSecond operations per
+// second on average and bursts of up to burst operations
+func NewRateLimiter(perSecond float64, burst int) *RateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &RateLimiter{
+		rate:   perSecond,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// Wait blocks until an operation may start or the context is done
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	if l == nil || l.rate <= 0 {
+		return ctx.Err()
+	}
+
+	// Take a token now, going into debt if none is left, and wait until
+	// the debt is paid off
+	l.mu.Lock()
+	now := time.Now()
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.last = now
+	l.tokens--
+	var wait time.Duration
+	if l.tokens < 0 {
+		wait = time.Duration(-l.tokens / l.rate * float64(time.Second))
+	}
+	l.mu.Unlock()
+
+	if wait == 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		// Give the token back
+		l.mu.Lock()
+		l.tokens++
+		l.mu.Unlock()
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
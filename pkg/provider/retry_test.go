@@ -0,0 +1,215 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourusername/panka/pkg/parser/schema"
+)
+
+var errThrottled = errors.New("ThrottlingException: rate exceeded")
+
+// fakeResourceProvider fails its calls with the queued errors, then succeeds
+type fakeResourceProvider struct {
+	mu     sync.Mutex
+	errs   []error
+	calls  int
+	block  bool
+	dryRun []bool
+}
+
+func (f *fakeResourceProvider) call(ctx context.Context, opts *ResourceOptions) error {
+	f.mu.Lock()
+	f.calls++
+	if opts != nil {
+		f.dryRun = append(f.dryRun, opts.DryRun)
+	}
+	var err error
+	if len(f.errs) > 0 {
+		err, f.errs = f.errs[0], f.errs[1:]
+	}
+	block := f.block
+	f.mu.Unlock()
+
+	if block {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	return err
+}
+
+func (f *fakeResourceProvider) Create(ctx context.Context, resource schema.Resource, opts *ResourceOptions) (*ResourceResult, error) {
+	if err := f.call(ctx, opts); err != nil {
+		return nil, err
+	}
+	return &ResourceResult{ResourceID: resource.GetMetadata().Name + "-id"}, nil
+}
+
+func (f *fakeResourceProvider) Read(ctx context.Context, resourceID string, opts *ResourceOptions) (*ResourceResult, error) {
+	if err := f.call(ctx, opts); err != nil {
+		return nil, err
+	}
+	return &ResourceResult{ResourceID: resourceID}, nil
+}
+
+func (f *fakeResourceProvider) Update(ctx context.Context, resource schema.Resource, opts *ResourceOptions) (*ResourceResult, error) {
+	return f.Create(ctx, resource, opts)
+}
+
+func (f *fakeResourceProvider) Delete(ctx context.Context, resourceID string, opts *ResourceOptions) (*ResourceResult, error) {
+	return f.Read(ctx, resourceID, opts)
+}
+
+func (f *fakeResourceProvider) Exists(ctx context.Context, resourceID string, opts *ResourceOptions) (bool, error) {
+	if err := f.call(ctx, opts); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (f *fakeResourceProvider) GetOutputs(ctx context.Context, resourceID string, opts *ResourceOptions) (map[string]string, error) {
+	if err := f.call(ctx, opts); err != nil {
+		return nil, err
+	}
+	return map[string]string{"id": resourceID}, nil
+}
+
+func testRetryConfig() RetryConfig {
+	return RetryConfig{
+		MaxAttempts: 4,
+		BaseDelay:   time.Millisecond,
+		MaxDelay:    5 * time.Millisecond,
+		Retryable: func(err error) bool {
+			return errors.Is(err, errThrottled)
+		},
+	}
+}
+
+func TestWithRetries_RetriesRetryableErrors(t *testing.T) {
+	fake := &fakeResourceProvider{errs: []error{errThrottled, errThrottled}}
+	rp := WithRetries(fake, testRetryConfig())
+
+	result, err := rp.Create(context.Background(), schema.NewSQS("queue", "backend", "my-stack"), &ResourceOptions{})
+
+	require.NoError(t, err)
+	assert.Equal(t, "queue-id", result.ResourceID)
+	assert.Equal(t, 3, fake.calls)
+}
+
+func TestWithRetries_ReturnsOtherErrorsAtOnce(t *testing.T) {
+	invalid := errors.New("ValidationException: bad name")
+	fake := &fakeResourceProvider{errs: []error{invalid}}
+	rp := WithRetries(fake, testRetryConfig())
+
+	_, err := rp.Delete(context.Background(), "queue-id", &ResourceOptions{})
+
+	assert.Equal(t, invalid, err)
+	assert.Equal(t, 1, fake.calls)
+}
+
+func TestWithRetries_GivesUpAfterMaxAttempts(t *testing.T) {
+	fake := &fakeResourceProvider{errs: []error{errThrottled, errThrottled, errThrottled, errThrottled, errThrottled}}
+	rp := WithRetries(fake, testRetryConfig())
+
+	exists, err := rp.Exists(context.Background(), "queue-id", nil)
+
+	assert.ErrorIs(t, err, errThrottled)
+	assert.False(t, exists)
+	assert.Equal(t, 4, fake.calls)
+}
+
+func TestWithRetries_AppliesTimeout(t *testing.T) {
+	fake := &fakeResourceProvider{block: true}
+	rp := WithRetries(fake, testRetryConfig())
+
+	start := time.Now()
+	_, err := rp.Read(context.Background(), "queue-id", &ResourceOptions{Timeout: 20 * time.Millisecond})
+
+	require.Error(t, err)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Contains(t, err.Error(), "read did not complete within 20ms")
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestWithRetries_TimeoutKeepsLastError(t *testing.T) {
+	fake := &fakeResourceProvider{errs: []error{errThrottled, errThrottled, errThrottled}}
+	config := testRetryConfig()
+	config.BaseDelay = time.Second
+	config.MaxDelay = time.Second
+	rp := WithRetries(fake, config)
+
+	// The deadline passes while backing off
+	_, err := rp.GetOutputs(context.Background(), "queue-id", &ResourceOptions{Timeout: 10 * time.Millisecond})
+
+	require.Error(t, err)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.ErrorIs(t, err, errThrottled)
+}
+
+func TestWithRetries_PassesDryRunThrough(t *testing.T) {
+	fake := &fakeResourceProvider{errs: []error{errThrottled}}
+	config := testRetryConfig()
+	config.Limiter = NewRateLimiter(0.001, 1)
+	rp := WithRetries(fake, config)
+
+	_, err := rp.Create(context.Background(), schema.NewSQS("queue", "backend", "my-stack"), &ResourceOptions{DryRun: true})
+
+	assert.ErrorIs(t, err, errThrottled)
+	assert.Equal(t, 1, fake.calls)
+	assert.Equal(t, []bool{true}, fake.dryRun)
+}
+
+// plannedProvider plans the IDs of its resources
+type plannedProvider struct {
+	fakeResourceProvider
+}
+
+func (p *plannedProvider) PlannedID(resource schema.Resource, opts *ResourceOptions) (string, error) {
+	return resource.GetMetadata().Name + "-id", nil
+}
+
+func TestWithRetries_PlannedID(t *testing.T) {
+	queue := schema.NewSQS("queue", "backend", "my-stack")
+
+	// The planned ID comes from the wrapped provider without calling it
+	planned := &plannedProvider{}
+	id, err := WithRetries(planned, testRetryConfig()).(IDPlanner).PlannedID(queue, &ResourceOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "queue-id", id)
+	assert.Zero(t, planned.calls)
+
+	// Providers that cannot plan IDs give an empty ID
+	id, err = WithRetries(&fakeResourceProvider{}, testRetryConfig()).(IDPlanner).PlannedID(queue, &ResourceOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, id)
+}
+
+func TestRateLimiter_Wait(t *testing.T) {
+	limiter := NewRateLimiter(100, 2)
+	ctx := context.Background()
+
+	// The burst is available at once, the next operation waits ~10ms
+	start := time.Now()
+	require.NoError(t, limiter.Wait(ctx))
+	require.NoError(t, limiter.Wait(ctx))
+	assert.Less(t, time.Since(start), 5*time.Millisecond)
+
+	require.NoError(t, limiter.Wait(ctx))
+	assert.GreaterOrEqual(t, time.Since(start), 5*time.Millisecond)
+
+	// A cancelled wait returns the context error
+	slow := NewRateLimiter(0.001, 1)
+	require.NoError(t, slow.Wait(ctx))
+	cancelled, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, slow.Wait(cancelled), context.DeadlineExceeded)
+
+	// A nil limiter does not limit
+	var unlimited *RateLimiter
+	assert.NoError(t, unlimited.Wait(ctx))
+}
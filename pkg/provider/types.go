@@ -47,6 +47,14 @@ type ResourceProvider interface {
 	GetOutputs(ctx context.Context, resourceID string, opts *ResourceOptions) (map[string]string, error)
 }
 
+// IDPlanner is implemented by resource providers that know the ID a
+// resource will be created with before creating it
+type IDPlanner interface {
+	// PlannedID returns the ID Create will return for the resource, or an
+	// empty string if it is only known once the resource exists
+	PlannedID(resource schema.Resource, opts *ResourceOptions) (string, error)
+}
+
 // Config holds provider configuration
 type Config struct {
 	// Provider name (aws, azure, gcp)
@@ -61,10 +69,21 @@ type Config struct {
 	// Tags to apply to all resources
 	DefaultTags map[string]string
 	
+	// Networking of the tenant that resources are deployed into
+	Networking *Networking
+	
 	// Additional provider-specific configuration
 	Extra map[string]interface{}
 }
 
+// Networking identifies the tenant network that resources run in
+type Networking struct {
+	VPCID            string
+	PrivateSubnetIDs []string
+	PublicSubnetIDs  []string
+	SecurityGroupID  string
+}
+
 // ResourceOptions contains options for resource operations
 type ResourceOptions struct {
 	// Tenant ID for multi-tenancy
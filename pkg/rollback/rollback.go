@@ -4,6 +4,7 @@ package rollback
 import (
 	"context"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/yourusername/panka/pkg/parser/schema"
@@ -99,8 +100,12 @@ type RollbackError struct {
 	Error        string `json:"error"`
 }
 
-// Manager manages rollback operations
+// Manager manages rollback operations.
+// It is safe for concurrent use, so resources applied in parallel can
+// record their actions on a shared transaction.
 type Manager struct {
+	mu sync.Mutex
+
 	// provider is the cloud provider for executing rollback
 	provider provider.Provider
 
@@ -121,6 +126,8 @@ func NewManager(prov provider.Provider) *Manager {
 
 // StartTransaction begins a new rollback transaction
 func (m *Manager) StartTransaction(stackName, tenantID string, snapshotState *state.State) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	m.currentPlan = &RollbackPlan{
 		StackName:     stackName,
 		TenantID:      tenantID,
@@ -132,6 +139,8 @@ func (m *Manager) StartTransaction(stackName, tenantID string, snapshotState *st
 
 // RecordAction records an action for potential rollback
 func (m *Manager) RecordAction(action *Action) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	if m.currentPlan == nil {
 		return
 	}
@@ -195,29 +204,39 @@ func (m *Manager) RecordDelete(name string, id string, kind schema.Kind, beforeS
 
 // GetPlan returns the current rollback plan
 func (m *Manager) GetPlan() *RollbackPlan {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	return m.currentPlan
 }
 
 // ClearTransaction clears the current transaction (on successful completion)
 func (m *Manager) ClearTransaction() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	m.currentPlan = nil
 }
 
 // Rollback executes a rollback of all recorded actions
 func (m *Manager) Rollback(ctx context.Context) (*RollbackResult, error) {
-	if m.currentPlan == nil || len(m.currentPlan.Actions) == 0 {
+	// Take ownership of the plan so no new actions are recorded against it
+	m.mu.Lock()
+	plan := m.currentPlan
+	m.currentPlan = nil
+	m.mu.Unlock()
+
+	if plan == nil || len(plan.Actions) == 0 {
 		return &RollbackResult{Success: true}, nil
 	}
 
 	startTime := time.Now()
 	result := &RollbackResult{
-		Plan:   m.currentPlan,
+		Plan:   plan,
 		Errors: make([]RollbackError, 0),
 	}
 
 	// Process actions in reverse order
-	for i := len(m.currentPlan.Actions) - 1; i >= 0; i-- {
-		action := m.currentPlan.Actions[i]
+	for i := len(plan.Actions) - 1; i >= 0; i-- {
+		action := plan.Actions[i]
 
 		// Skip unsuccessful actions - they don't need rollback
 		if !action.Success {
@@ -256,9 +275,6 @@ func (m *Manager) Rollback(ctx context.Context) (*RollbackResult, error) {
 	result.Duration = time.Since(startTime)
 	result.Success = result.FailedCount == 0
 
-	// Clear the plan after rollback attempt
-	m.ClearTransaction()
-
 	return result, nil
 }
 
@@ -293,6 +309,8 @@ func (m *Manager) rollbackUpdate(ctx context.Context, action *Action) error {
 
 // CanRollback returns true if there are actions that can be rolled back
 func (m *Manager) CanRollback() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	if m.currentPlan == nil {
 		return false
 	}
@@ -306,6 +324,8 @@ func (m *Manager) CanRollback() bool {
 
 // ActionCount returns the number of recorded actions
 func (m *Manager) ActionCount() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	if m.currentPlan == nil {
 		return 0
 	}
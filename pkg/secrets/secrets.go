@@ -0,0 +1,263 @@
+// Package secrets resolves the secret references of stack components and
+// manages the secrets of tenants. Secrets live in AWS Secrets Manager or in
+// SSM Parameter Store; panka only records references to them, never their
+// values.
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/yourusername/panka/pkg/parser/schema"
+)
+
+// Secret backends
+const (
+	BackendSecretsManager = "secretsmanager"
+	BackendSSM            = "ssm"
+)
+
+// ErrNotFound is returned for secrets that do not exist
+var ErrNotFound = errors.New("secret not found")
+
+// Secret describes a stored secret, without its value
+type Secret struct {
+	Backend   string
+	Name      string
+	ARN       string
+	UpdatedAt time.Time
+}
+
+// Store is a secret backend
+type Store interface {
+	// Describe returns a secret by name or ARN, or ErrNotFound
+	Describe(ctx context.Context, id string) (*Secret, error)
+
+	// Value returns the value of a secret by name or ARN
+	Value(ctx context.Context, id string) (string, error)
+
+	// Put creates a secret or sets a new value of an existing one. Tags
+	// are only applied to new secrets.
+	Put(ctx context.Context, name, value string, tags map[string]string) (*Secret, error)
+
+	// List returns the secrets whose name starts with a prefix
+	List(ctx context.Context, prefix string) ([]*Secret, error)
+}
+
+// Ref is a parsed secret reference. References are written as:
+//
+//	arn:aws:secretsmanager:...   a Secrets Manager secret, optionally followed
+//	                             by :json-key:version-stage:version-id
+//	arn:aws:ssm:...:parameter/x  an SSM parameter
+//	secretsmanager:<name>        a Secrets Manager secret
+//	ssm:<name>                   an SSM parameter
+//	/<path>                      an SSM parameter
+//	<name>                       a Secrets Manager secret
+type Ref struct {
+	Backend string
+
+	// ID is the name or ARN of the secret or parameter
+	ID string
+
+	// Selector is what follows the ARN of a Secrets Manager secret, such as
+	// ":password::", which ECS uses to select a JSON key or version
+	Selector string
+}
+
+// ParseRef parses a secret reference
+func ParseRef(ref string) (Ref, error) {
+	switch {
+	case ref == "":
+		return Ref{}, errors.New("secret reference is empty")
+
+	case strings.HasPrefix(ref, "arn:"):
+		fields := strings.SplitN(ref, ":", 8)
+		if len(fields) < 6 {
+			return Ref{}, fmt.Errorf("invalid secret ARN: %s", ref)
+		}
+		switch fields[2] {
+		case BackendSecretsManager:
+			if len(fields) < 7 {
+				return Ref{}, fmt.Errorf("invalid secret ARN: %s", ref)
+			}
+			parsed := Ref{Backend: BackendSecretsManager, ID: strings.Join(fields[:7], ":")}
+			if len(fields) == 8 {
+				parsed.Selector = ":" + fields[7]
+			}
+			return parsed, nil
+		case BackendSSM:
+			return Ref{Backend: BackendSSM, ID: ref}, nil
+		default:
+			return Ref{}, fmt.Errorf("secret ARN must be a Secrets Manager secret or an SSM parameter: %s", ref)
+		}
+
+	case strings.HasPrefix(ref, BackendSecretsManager+":"):
+		return Ref{Backend: BackendSecretsManager, ID: strings.TrimPrefix(ref, BackendSecretsManager+":")}, nil
+
+	case strings.HasPrefix(ref, BackendSSM+":"):
+		return Ref{Backend: BackendSSM, ID: strings.TrimPrefix(ref, BackendSSM+":")}, nil
+
+	case strings.HasPrefix(ref, "/"):
+		return Ref{Backend: BackendSSM, ID: ref}, nil
+
+	default:
+		return Ref{Backend: BackendSecretsManager, ID: ref}, nil
+	}
+}
+
+// jsonKey returns the JSON key selected by a Secrets Manager reference
+func (r Ref) jsonKey() string {
+	parts := strings.Split(r.Selector, ":")
+	if len(parts) < 2 {
+		return ""
+	}
+	return parts[1]
+}
+
+// Path returns the name of a secret of a tenant's stack. Secrets Manager
+// names are panka/<tenant>/<stack>/<name>; SSM parameter names start with a
+// slash.
+func Path(backend, tenantID, stack, name string) string {
+	path := fmt.Sprintf("panka/%s/%s/%s", tenantID, stack, strings.TrimPrefix(name, "/"))
+	if backend == BackendSSM {
+		return "/" + path
+	}
+	return path
+}
+
+// Manager resolves secret references against the Secrets Manager and SSM
+// stores of an account
+type Manager struct {
+	stores map[string]Store
+}
+
+// NewManager creates a secret manager for an AWS account and region
+func NewManager(cfg aws.Config) *Manager {
+	return newManager(map[string]Store{
+		BackendSecretsManager: NewSecretsManagerStore(cfg),
+		BackendSSM:            NewSSMStore(cfg),
+	})
+}
+
+// newManager creates a secret manager with the given stores
+func newManager(stores map[string]Store) *Manager {
+	return &Manager{stores: stores}
+}
+
+// Store returns the store of a backend
+func (m *Manager) Store(backend string) (Store, error) {
+	store, ok := m.stores[backend]
+	if !ok {
+		return nil, fmt.Errorf("unknown secret backend: %s (use %s or %s)", backend, BackendSecretsManager, BackendSSM)
+	}
+	return store, nil
+}
+
+// Resolve returns the ARN a secret reference points to, keeping the
+// selector of Secrets Manager references. It fails if the secret does not
+// exist.
+func (m *Manager) Resolve(ctx context.Context, ref string) (string, error) {
+	parsed, err := ParseRef(ref)
+	if err != nil {
+		return "", err
+	}
+	store, err := m.Store(parsed.Backend)
+	if err != nil {
+		return "", err
+	}
+
+	secret, err := store.Describe(ctx, parsed.ID)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve secret %s: %w", ref, err)
+	}
+	return secret.ARN + parsed.Selector, nil
+}
+
+// Value returns the value of a secret reference. A reference that selects
+// a JSON key returns the value of that key.
+func (m *Manager) Value(ctx context.Context, ref string) (string, error) {
+	parsed, err := ParseRef(ref)
+	if err != nil {
+		return "", err
+	}
+	store, err := m.Store(parsed.Backend)
+	if err != nil {
+		return "", err
+	}
+
+	value, err := store.Value(ctx, parsed.ID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get secret %s: %w", ref, err)
+	}
+
+	key := parsed.jsonKey()
+	if key == "" {
+		return value, nil
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal([]byte(value), &fields); err != nil {
+		return "", fmt.Errorf("secret %s is not JSON: %w", ref, err)
+	}
+	field, ok := fields[key].(string)
+	if !ok {
+		return "", fmt.Errorf("secret %s has no string field %s", ref, key)
+	}
+	return field, nil
+}
+
+// Check verifies that every reference points to an existing secret. The
+// error lists all references that do not resolve.
+func (m *Manager) Check(ctx context.Context, refs []string) error {
+	var missing []string
+	checked := make(map[string]bool, len(refs))
+	for _, ref := range refs {
+		if checked[ref] {
+			continue
+		}
+		checked[ref] = true
+		if _, err := m.Resolve(ctx, ref); err != nil {
+			if !errors.Is(err, ErrNotFound) {
+				return err
+			}
+			missing = append(missing, ref)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("secret(s) not found: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// References returns the distinct secret references of a resource, sorted
+func References(resource schema.Resource) []string {
+	var refs []string
+	for _, secret := range schema.WorkloadSecrets(resource) {
+		refs = append(refs, secret.SecretRef)
+	}
+
+	switch r := resource.(type) {
+	case *schema.RDS:
+		refs = append(refs, r.Spec.Database.PasswordSecret.Ref)
+	case *schema.ElastiCacheRedis:
+		if r.AuthEnabled() {
+			refs = append(refs, r.Spec.AuthToken.Ref)
+		}
+	}
+
+	seen := make(map[string]bool, len(refs))
+	unique := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		if ref != "" && !seen[ref] {
+			seen[ref] = true
+			unique = append(unique, ref)
+		}
+	}
+	sort.Strings(unique)
+	return unique
+}
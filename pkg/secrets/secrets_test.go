@@ -0,0 +1,210 @@
+package secrets
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourusername/panka/pkg/parser/schema"
+)
+
+// memoryStore is an in-memory secret store
+type memoryStore struct {
+	backend string
+	arn     func(name string) string
+	values  map[string]string
+}
+
+func newMemoryStore(backend string, arn func(name string) string) *memoryStore {
+	return &memoryStore{backend: backend, arn: arn, values: make(map[string]string)}
+}
+
+func (s *memoryStore) name(id string) string {
+	for name := range s.values {
+		if s.arn(name) == id {
+			return name
+		}
+	}
+	return id
+}
+
+func (s *memoryStore) Describe(ctx context.Context, id string) (*Secret, error) {
+	name := s.name(id)
+	if _, ok := s.values[name]; !ok {
+		return nil, ErrNotFound
+	}
+	return &Secret{Backend: s.backend, Name: name, ARN: s.arn(name)}, nil
+}
+
+func (s *memoryStore) Value(ctx context.Context, id string) (string, error) {
+	value, ok := s.values[s.name(id)]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return value, nil
+}
+
+func (s *memoryStore) Put(ctx context.Context, name, value string, tags map[string]string) (*Secret, error) {
+	s.values[name] = value
+	return s.Describe(ctx, name)
+}
+
+func (s *memoryStore) List(ctx context.Context, prefix string) ([]*Secret, error) {
+	var secrets []*Secret
+	for name := range s.values {
+		secrets = append(secrets, &Secret{Backend: s.backend, Name: name, ARN: s.arn(name)})
+	}
+	return filterPrefix(secrets, prefix), nil
+}
+
+func newTestManager() *Manager {
+	return newManager(map[string]Store{
+		BackendSecretsManager: newMemoryStore(BackendSecretsManager, func(name string) string {
+			return "arn:aws:secretsmanager:us-east-1:123456789012:secret:" + name + "-AbCdEf"
+		}),
+		BackendSSM: newMemoryStore(BackendSSM, func(name string) string {
+			return "arn:aws:ssm:us-east-1:123456789012:parameter/" + strings.TrimPrefix(name, "/")
+		}),
+	})
+}
+
+func TestParseRef(t *testing.T) {
+	tests := []struct {
+		ref  string
+		want Ref
+	}{
+		{"panka/acme/my-stack/db", Ref{Backend: BackendSecretsManager, ID: "panka/acme/my-stack/db"}},
+		{"secretsmanager:db", Ref{Backend: BackendSecretsManager, ID: "db"}},
+		{"/panka/acme/my-stack/token", Ref{Backend: BackendSSM, ID: "/panka/acme/my-stack/token"}},
+		{"ssm:token", Ref{Backend: BackendSSM, ID: "token"}},
+		{
+			"arn:aws:secretsmanager:us-east-1:123456789012:secret:db-AbCdEf",
+			Ref{Backend: BackendSecretsManager, ID: "arn:aws:secretsmanager:us-east-1:123456789012:secret:db-AbCdEf"},
+		},
+		{
+			"arn:aws:secretsmanager:us-east-1:123456789012:secret:db-AbCdEf:password::",
+			Ref{Backend: BackendSecretsManager, ID: "arn:aws:secretsmanager:us-east-1:123456789012:secret:db-AbCdEf", Selector: ":password::"},
+		},
+		{
+			"arn:aws:ssm:us-east-1:123456789012:parameter/token",
+			Ref{Backend: BackendSSM, ID: "arn:aws:ssm:us-east-1:123456789012:parameter/token"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			ref, err := ParseRef(tt.ref)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, ref)
+		})
+	}
+
+	for _, ref := range []string{"", "arn:aws:s3:::bucket", "arn:aws:secretsmanager:us-east-1"} {
+		_, err := ParseRef(ref)
+		assert.Error(t, err, ref)
+	}
+}
+
+func TestPath(t *testing.T) {
+	assert.Equal(t, "panka/acme/my-stack/db-password", Path(BackendSecretsManager, "acme", "my-stack", "db-password"))
+	assert.Equal(t, "/panka/acme/my-stack/db-password", Path(BackendSSM, "acme", "my-stack", "/db-password"))
+}
+
+func TestManager_Resolve(t *testing.T) {
+	ctx := context.Background()
+	m := newTestManager()
+
+	sm, _ := m.Store(BackendSecretsManager)
+	_, err := sm.Put(ctx, "panka/acme/my-stack/db", `{"username":"app","password":"s3cret"}`, nil)
+	require.NoError(t, err)
+	ssm, _ := m.Store(BackendSSM)
+	_, err = ssm.Put(ctx, "/panka/acme/my-stack/token", "t0ken", nil)
+	require.NoError(t, err)
+
+	arn, err := m.Resolve(ctx, "panka/acme/my-stack/db")
+	require.NoError(t, err)
+	assert.Equal(t, "arn:aws:secretsmanager:us-east-1:123456789012:secret:panka/acme/my-stack/db-AbCdEf", arn)
+
+	// Selectors are kept for ECS
+	arn, err = m.Resolve(ctx, arn+":password::")
+	require.NoError(t, err)
+	assert.Equal(t, "arn:aws:secretsmanager:us-east-1:123456789012:secret:panka/acme/my-stack/db-AbCdEf:password::", arn)
+
+	arn, err = m.Resolve(ctx, "/panka/acme/my-stack/token")
+	require.NoError(t, err)
+	assert.Equal(t, "arn:aws:ssm:us-east-1:123456789012:parameter/panka/acme/my-stack/token", arn)
+
+	_, err = m.Resolve(ctx, "panka/acme/my-stack/missing")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	_, err = m.Store("vault")
+	assert.Error(t, err)
+}
+
+func TestManager_Value(t *testing.T) {
+	ctx := context.Background()
+	m := newTestManager()
+
+	sm, _ := m.Store(BackendSecretsManager)
+	secret, err := sm.Put(ctx, "db", `{"password":"s3cret"}`, nil)
+	require.NoError(t, err)
+
+	value, err := m.Value(ctx, "db")
+	require.NoError(t, err)
+	assert.Equal(t, `{"password":"s3cret"}`, value)
+
+	// A JSON key selects a field of the secret
+	value, err = m.Value(ctx, secret.ARN+":password::")
+	require.NoError(t, err)
+	assert.Equal(t, "s3cret", value)
+
+	_, err = m.Value(ctx, secret.ARN+":username::")
+	assert.ErrorContains(t, err, "no string field username")
+}
+
+func TestManager_Check(t *testing.T) {
+	ctx := context.Background()
+	m := newTestManager()
+
+	sm, _ := m.Store(BackendSecretsManager)
+	_, err := sm.Put(ctx, "db", "s3cret", nil)
+	require.NoError(t, err)
+
+	assert.NoError(t, m.Check(ctx, []string{"db"}))
+
+	err = m.Check(ctx, []string{"db", "api-key", "/token", "api-key"})
+	require.Error(t, err)
+	assert.Equal(t, "secret(s) not found: api-key, /token", err.Error())
+
+	// Invalid references fail the check
+	assert.Error(t, m.Check(ctx, []string{"arn:aws:s3:::bucket"}))
+}
+
+func TestReferences(t *testing.T) {
+	ms := schema.NewMicroService("api", "backend", "my-stack")
+	ms.Spec.Secrets = []schema.Secret{
+		{Name: "db", SecretRef: "panka/acme/my-stack/db"},
+		{Name: "token", SecretRef: "/panka/acme/my-stack/token"},
+		{Name: "db-again", SecretRef: "panka/acme/my-stack/db"},
+	}
+	assert.Equal(t, []string{"/panka/acme/my-stack/token", "panka/acme/my-stack/db"}, References(ms))
+
+	db := schema.NewRDS("orders", "backend", "my-stack")
+	db.Spec.Database.PasswordSecret.Ref = "panka/acme/my-stack/orders-db"
+	assert.Equal(t, []string{"panka/acme/my-stack/orders-db"}, References(db))
+
+	assert.Empty(t, References(schema.NewSQS("jobs", "backend", "my-stack")))
+}
+
+func TestFilterPrefix(t *testing.T) {
+	secrets := []*Secret{
+		{Name: "panka/acme/my-stack/b"},
+		{Name: "panka/acme/other/a"},
+		{Name: "panka/acme/my-stack/a"},
+	}
+	filtered := filterPrefix(secrets, "panka/acme/my-stack/")
+	require.Len(t, filtered, 2)
+	assert.Equal(t, "panka/acme/my-stack/a", filtered[0].Name)
+	assert.Equal(t, "panka/acme/my-stack/b", filtered[1].Name)
+}
@@ -0,0 +1,134 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager/types"
+)
+
+// SecretsManagerStore stores secrets in AWS Secrets Manager
+type SecretsManagerStore struct {
+	client *secretsmanager.Client
+}
+
+// NewSecretsManagerStore creates a Secrets Manager store
+func NewSecretsManagerStore(cfg aws.Config) *SecretsManagerStore {
+	return &SecretsManagerStore{client: secretsmanager.NewFromConfig(cfg)}
+}
+
+// Describe returns a secret by name or ARN. Secrets scheduled for deletion
+// are not found.
+func (s *SecretsManagerStore) Describe(ctx context.Context, id string) (*Secret, error) {
+	result, err := s.client.DescribeSecret(ctx, &secretsmanager.DescribeSecretInput{
+		SecretId: aws.String(id),
+	})
+	if err != nil {
+		var notFound *types.ResourceNotFoundException
+		if errors.As(err, &notFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	if result.DeletedDate != nil {
+		return nil, ErrNotFound
+	}
+
+	return &Secret{
+		Backend:   BackendSecretsManager,
+		Name:      aws.ToString(result.Name),
+		ARN:       aws.ToString(result.ARN),
+		UpdatedAt: aws.ToTime(result.LastChangedDate),
+	}, nil
+}
+
+// Value returns the string value of a secret
+func (s *SecretsManagerStore) Value(ctx context.Context, id string) (string, error) {
+	result, err := s.client.GetSecretValue(ctx, &secretsmanager.GetSecretValueInput{
+		SecretId: aws.String(id),
+	})
+	if err != nil {
+		var notFound *types.ResourceNotFoundException
+		if errors.As(err, &notFound) {
+			return "", ErrNotFound
+		}
+		return "", err
+	}
+	if result.SecretString == nil {
+		return "", errors.New("secret has no string value")
+	}
+	return aws.ToString(result.SecretString), nil
+}
+
+// Put creates a secret or stores a new version of an existing one
+func (s *SecretsManagerStore) Put(ctx context.Context, name, value string, tags map[string]string) (*Secret, error) {
+	existing, err := s.Describe(ctx, name)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return nil, fmt.Errorf("failed to describe secret %s: %w", name, err)
+	}
+
+	if existing != nil {
+		if _, err := s.client.PutSecretValue(ctx, &secretsmanager.PutSecretValueInput{
+			SecretId:     aws.String(existing.ARN),
+			SecretString: aws.String(value),
+		}); err != nil {
+			return nil, fmt.Errorf("failed to update secret %s: %w", name, err)
+		}
+	} else {
+		if _, err := s.client.CreateSecret(ctx, &secretsmanager.CreateSecretInput{
+			Name:         aws.String(name),
+			SecretString: aws.String(value),
+			Tags:         secretsManagerTags(tags),
+		}); err != nil {
+			return nil, fmt.Errorf("failed to create secret %s: %w", name, err)
+		}
+	}
+
+	return s.Describe(ctx, name)
+}
+
+// List returns the secrets whose name starts with a prefix
+func (s *SecretsManagerStore) List(ctx context.Context, prefix string) ([]*Secret, error) {
+	var secrets []*Secret
+	paginator := secretsmanager.NewListSecretsPaginator(s.client, &secretsmanager.ListSecretsInput{
+		Filters: []types.Filter{
+			{Key: types.FilterNameStringTypeName, Values: []string{prefix}},
+		},
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list secrets: %w", err)
+		}
+		for _, entry := range page.SecretList {
+			secrets = append(secrets, &Secret{
+				Backend:   BackendSecretsManager,
+				Name:      aws.ToString(entry.Name),
+				ARN:       aws.ToString(entry.ARN),
+				UpdatedAt: aws.ToTime(entry.LastChangedDate),
+			})
+		}
+	}
+
+	// The name filter matches prefixes of words, not of the whole name
+	return filterPrefix(secrets, prefix), nil
+}
+
+// secretsManagerTags converts tags into Secrets Manager tags, sorted by key
+func secretsManagerTags(tags map[string]string) []types.Tag {
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	result := make([]types.Tag, 0, len(keys))
+	for _, k := range keys {
+		result = append(result, types.Tag{Key: aws.String(k), Value: aws.String(tags[k])})
+	}
+	return result
+}
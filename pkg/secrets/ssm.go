@@ -0,0 +1,150 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+// SSMStore stores secrets as SecureString parameters in SSM Parameter Store
+type SSMStore struct {
+	client *ssm.Client
+}
+
+// NewSSMStore creates an SSM Parameter Store store
+func NewSSMStore(cfg aws.Config) *SSMStore {
+	return &SSMStore{client: ssm.NewFromConfig(cfg)}
+}
+
+// Describe returns a parameter by name or ARN
+func (s *SSMStore) Describe(ctx context.Context, id string) (*Secret, error) {
+	parameter, err := s.get(ctx, id, false)
+	if err != nil {
+		return nil, err
+	}
+	return ssmSecret(parameter), nil
+}
+
+// Value returns the decrypted value of a parameter
+func (s *SSMStore) Value(ctx context.Context, id string) (string, error) {
+	parameter, err := s.get(ctx, id, true)
+	if err != nil {
+		return "", err
+	}
+	return aws.ToString(parameter.Value), nil
+}
+
+// Put creates a SecureString parameter or overwrites its value. SSM does
+// not accept tags when overwriting, so they are set on new parameters only.
+func (s *SSMStore) Put(ctx context.Context, name, value string, tags map[string]string) (*Secret, error) {
+	_, err := s.get(ctx, name, false)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return nil, fmt.Errorf("failed to describe parameter %s: %w", name, err)
+	}
+
+	input := &ssm.PutParameterInput{
+		Name:  aws.String(name),
+		Value: aws.String(value),
+		Type:  types.ParameterTypeSecureString,
+	}
+	if err == nil {
+		input.Overwrite = aws.Bool(true)
+	} else {
+		input.Tags = ssmTags(tags)
+	}
+
+	if _, err := s.client.PutParameter(ctx, input); err != nil {
+		return nil, fmt.Errorf("failed to put parameter %s: %w", name, err)
+	}
+
+	return s.Describe(ctx, name)
+}
+
+// List returns the parameters under a path prefix
+func (s *SSMStore) List(ctx context.Context, prefix string) ([]*Secret, error) {
+	// Parameters are listed by the path that contains the prefix
+	path := prefix
+	if !strings.HasSuffix(path, "/") {
+		path = path[:strings.LastIndex(path, "/")+1]
+	}
+
+	var secrets []*Secret
+	paginator := ssm.NewGetParametersByPathPaginator(s.client, &ssm.GetParametersByPathInput{
+		Path:           aws.String(path),
+		Recursive:      aws.Bool(true),
+		WithDecryption: aws.Bool(false),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list parameters: %w", err)
+		}
+		for i := range page.Parameters {
+			secrets = append(secrets, ssmSecret(&page.Parameters[i]))
+		}
+	}
+
+	return filterPrefix(secrets, prefix), nil
+}
+
+// get returns a parameter, or ErrNotFound
+func (s *SSMStore) get(ctx context.Context, id string, decrypt bool) (*types.Parameter, error) {
+	result, err := s.client.GetParameter(ctx, &ssm.GetParameterInput{
+		Name:           aws.String(id),
+		WithDecryption: aws.Bool(decrypt),
+	})
+	if err != nil {
+		var notFound *types.ParameterNotFound
+		if errors.As(err, &notFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return result.Parameter, nil
+}
+
+// ssmSecret describes a parameter
+func ssmSecret(parameter *types.Parameter) *Secret {
+	return &Secret{
+		Backend:   BackendSSM,
+		Name:      aws.ToString(parameter.Name),
+		ARN:       aws.ToString(parameter.ARN),
+		UpdatedAt: aws.ToTime(parameter.LastModifiedDate),
+	}
+}
+
+// ssmTags converts tags into SSM tags, sorted by key
+func ssmTags(tags map[string]string) []types.Tag {
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	result := make([]types.Tag, 0, len(keys))
+	for _, k := range keys {
+		result = append(result, types.Tag{Key: aws.String(k), Value: aws.String(tags[k])})
+	}
+	return result
+}
+
+// filterPrefix returns the secrets whose name starts with a prefix, sorted
+// by name
+func filterPrefix(secrets []*Secret, prefix string) []*Secret {
+	var filtered []*Secret
+	for _, secret := range secrets {
+		if strings.HasPrefix(secret.Name, prefix) {
+			filtered = append(filtered, secret)
+		}
+	}
+	sort.Slice(filtered, func(i, j int) bool {
+		return filtered[i].Name < filtered[j].Name
+	})
+	return filtered
+}
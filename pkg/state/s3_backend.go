@@ -170,6 +170,36 @@ func (b *S3Backend) Exists(ctx context.Context, key string) (bool, error) {
 	return true, nil
 }
 
+// CurrentVersion returns the S3 version ID of the current state object, or an
+// empty string if the state does not exist. When bucket versioning is
+// disabled the object's ETag is returned instead, so the value still changes
+// whenever the state is saved.
+func (b *S3Backend) CurrentVersion(ctx context.Context, key string) (string, error) {
+	s3Key := b.buildKey(key)
+
+	result, err := b.client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(b.bucket),
+		Key:    aws.String(s3Key),
+	})
+
+	if err != nil {
+		// Check if object doesn't exist
+		if strings.Contains(err.Error(), "NotFound") || strings.Contains(err.Error(), "NoSuchKey") {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to get state version: %w", err)
+	}
+
+	if result.VersionId != nil && *result.VersionId != "" && *result.VersionId != "null" {
+		return *result.VersionId, nil
+	}
+	if result.ETag != nil {
+		return "etag:" + strings.Trim(*result.ETag, `"`), nil
+	}
+
+	return "", fmt.Errorf("state object %s has neither a version ID nor an ETag", s3Key)
+}
+
 // Delete deletes the state from S3
 func (b *S3Backend) Delete(ctx context.Context, key string) error {
 	s3Key := b.buildKey(key)
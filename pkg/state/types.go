@@ -1,6 +1,7 @@
 package state
 
 import (
+	"sort"
 	"time"
 )
 
@@ -36,6 +37,10 @@ type Resource struct {
 	DependsOn  []string               `json:"depends_on,omitempty"`
 	CreatedAt  time.Time              `json:"created_at"`
 	UpdatedAt  time.Time              `json:"updated_at"`
+
+	// PreventDestroy records lifecycle.preventDestroy, so that the resource
+	// stays protected after it is removed from the configuration
+	PreventDestroy bool `json:"prevent_destroy,omitempty"`
 }
 
 // ResourceStatus represents the status of a resource
@@ -56,6 +61,11 @@ const (
 	ResourceStatusUnknown ResourceStatus = "unknown"
 )
 
+// InProgress reports whether the status marks an unfinished operation
+func (s ResourceStatus) InProgress() bool {
+	return s == ResourceStatusCreating || s == ResourceStatusUpdating || s == ResourceStatusDeleting
+}
+
 // StateVersion represents a version of the state with metadata
 type StateVersion struct {
 	VersionID  string    `json:"version_id"`
@@ -196,6 +206,25 @@ func (s *State) ListResources() []*Resource {
 	return resources
 }
 
+// InProgressResources returns the resources with an operation that was
+// started but never recorded as finished, sorted by name. These are left
+// behind when an apply is interrupted.
+func (s *State) InProgressResources() []*Resource {
+	if s == nil || s.Resources == nil {
+		return nil
+	}
+	var resources []*Resource
+	for _, res := range s.Resources {
+		if res.Status.InProgress() {
+			resources = append(resources, res)
+		}
+	}
+	sort.Slice(resources, func(i, j int) bool {
+		return resources[i].Name < resources[j].Name
+	})
+	return resources
+}
+
 // ListResourcesByType returns resources filtered by type
 func (s *State) ListResourcesByType(resourceType string) []*Resource {
 	if s == nil || s.Resources == nil {
@@ -188,6 +188,21 @@ func TestResourceStatus(t *testing.T) {
 	}
 }
 
+func TestStateInProgressResources(t *testing.T) {
+	state := NewState("test-stack", "dev")
+	state.AddResource("queue", &Resource{Name: "queue", Status: ResourceStatusCreating})
+	state.AddResource("table", &Resource{Name: "table", Status: ResourceStatusReady})
+	state.AddResource("bucket", &Resource{Name: "bucket", Status: ResourceStatusDeleting})
+	
+	resources := state.InProgressResources()
+	require.Len(t, resources, 2)
+	assert.Equal(t, "bucket", resources[0].Name)
+	assert.Equal(t, "queue", resources[1].Name)
+	
+	assert.True(t, ResourceStatusUpdating.InProgress())
+	assert.False(t, ResourceStatusFailed.InProgress())
+}
+
 func TestStateVersion(t *testing.T) {
 	now := time.Now()
 	